@@ -5,8 +5,12 @@
 package main
 
 import (
+	"context"
 	"flag"
+	"fmt"
 	"log"
+	"net/http"
+	"os"
 	"time"
 
 	clientset "github.com/openfaas/faas-netes/pkg/client/clientset/versioned"
@@ -16,14 +20,18 @@ import (
 	"github.com/openfaas/faas-netes/pkg/controller"
 	"github.com/openfaas/faas-netes/pkg/handlers"
 	"github.com/openfaas/faas-netes/pkg/k8s"
+	"github.com/openfaas/faas-netes/pkg/metrics"
 	"github.com/openfaas/faas-netes/pkg/server"
 	"github.com/openfaas/faas-netes/pkg/signals"
 	version "github.com/openfaas/faas-netes/version"
 	faasProvider "github.com/openfaas/faas-provider"
 	"github.com/openfaas/faas-provider/logs"
-	"github.com/openfaas/faas-provider/proxy"
 	providertypes "github.com/openfaas/faas-provider/types"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
 	kubeinformers "k8s.io/client-go/informers"
 	v1apps "k8s.io/client-go/informers/apps/v1"
 	v1core "k8s.io/client-go/informers/core/v1"
@@ -46,7 +54,8 @@ func main() {
 	var masterURL string
 	var (
 		operator,
-		verbose bool
+		verbose,
+		validate bool
 	)
 
 	flag.StringVar(&kubeconfig, "kubeconfig", "",
@@ -56,10 +65,11 @@ func main() {
 		"The address of the Kubernetes API server. Overrides any value in kubeconfig. Only required if out-of-cluster.")
 
 	flag.BoolVar(&operator, "operator", false, "Use the operator mode instead of faas-netes")
+	flag.BoolVar(&validate, "validate", false, "Check the kubeconfig and connectivity to the cluster, then exit")
 	flag.Parse()
 
-	sha, release := version.GetReleaseInfo()
-	log.Printf("Version: %s\tcommit: %s\n", release, sha)
+	releaseInfo := version.GetReleaseInfo()
+	log.Printf("Version: %s\tcommit: %s\tgo: %s\n", releaseInfo.Release, releaseInfo.SHA, releaseInfo.GoVersion)
 
 	clientCmdConfig, err := clientcmd.BuildConfigFromFlags(masterURL, kubeconfig)
 	if err != nil {
@@ -92,6 +102,23 @@ func main() {
 
 	config.Fprint(verbose)
 
+	if validate {
+		if err := runValidate(kubeClient, faasClient, config); err != nil {
+			log.Printf("Validation failed: %s", err.Error())
+			os.Exit(1)
+		}
+		log.Println("Validation succeeded")
+		os.Exit(0)
+	}
+
+	if config.StartupKubeWaitTimeout > 0 {
+		info, err := k8s.WaitForAPIServer(kubeClient.Discovery(), config.StartupKubeWaitTimeout)
+		if err != nil {
+			log.Fatalf("Error connecting to Kubernetes API: %s", err.Error())
+		}
+		log.Printf("Kubernetes API version: %s\n", info.GitVersion)
+	}
+
 	deployConfig := k8s.DeploymentConfig{
 		RuntimeHTTPPort: 8080,
 		HTTPProbe:       config.HTTPProbe,
@@ -106,8 +133,32 @@ func main() {
 			TimeoutSeconds:      int32(config.LivenessProbeTimeoutSeconds),
 			PeriodSeconds:       int32(config.LivenessProbePeriodSeconds),
 		},
-		ImagePullPolicy:   config.ImagePullPolicy,
-		ProfilesNamespace: config.ProfilesNamespace,
+		ImagePullPolicy:              config.ImagePullPolicy,
+		ProfilesNamespace:            config.ProfilesNamespace,
+		ProgressDeadlineSeconds:      int32(config.ProgressDeadlineSeconds),
+		DefaultFunctionAnnotations:   config.DefaultFunctionAnnotations,
+		DefaultAnnotations:           config.DefaultAnnotations,
+		DefaultPodLabels:             config.DefaultPodLabels,
+		BackupAnnotations:            config.BackupAnnotations,
+		DefaultSchedulerName:         config.DefaultSchedulerName,
+		NodePoolLabel:                config.NodePoolLabel,
+		PDBMinAvailable:              config.PDBMinAvailable,
+		EnablePodMetricsScraping:     config.EnablePodMetricsScraping,
+		MeshInjectDisableAnnotations: config.MeshInjectDisableAnnotations,
+		ServiceMesh:                  config.ServiceMesh,
+		AutoZoneSpread:               config.AutoZoneSpread,
+		CertManagerIssuer:            config.CertManagerIssuerName,
+		CertManagerIssuerKind:        config.CertManagerIssuerKind,
+		MaxFunctionNameLength:        config.MaxFunctionNameLength,
+		AllowedUnsafeSysctls:         config.AllowedUnsafeSysctls,
+		RenderManifests:              config.RenderManifests(),
+		RenderConfigMap:              config.RenderConfigMap,
+		PSAEnforceLevel:              config.PSAEnforceLevel,
+		MaxFunctionsPerNamespace:     config.MaxFunctionsPerNamespace,
+		FunctionQuotaConfigMap:       config.FunctionQuotaConfigMap,
+		UpdateConflictRetries:        config.UpdateConflictRetries,
+		UpdateWaitTimeout:            config.UpdateWaitTimeout,
+		ImageNameRegex:               config.ImageNameRegex,
 	}
 
 	// the sync interval does not affect the scale to/from zero feature
@@ -131,6 +182,20 @@ func main() {
 
 	profileLister := profileInformerFactory.Openfaas().V1().Profiles().Lister()
 	factory := k8s.NewFunctionFactory(kubeClient, deployConfig, profileLister)
+	factory.Breaker = k8s.NewCircuitBreaker(k8s.DefaultCircuitBreakerThreshold, k8s.DefaultCircuitBreakerCooldown)
+	factory.Breaker.OnStateChange = func(from, to k8s.CircuitState) {
+		log.Printf("Kubernetes API circuit breaker: %s -> %s\n", from, to)
+		metrics.ObserveCircuitBreaker(from.String(), to.String(), int(to))
+	}
+	if len(config.NamespaceLabelPrefix) > 0 {
+		factory.NamespaceLabels = k8s.NewNamespaceLabels(config.NamespaceLabelPrefix)
+	}
+	if !config.DisableLocalProfiles {
+		factory.LocalProfiles = k8s.NewProfileAPIClient(faasClient)
+	}
+	if config.ImageCheck {
+		factory.ImageChecker = k8s.NewImageChecker(config.ImageCheckTimeout)
+	}
 
 	setup := serverSetup{
 		config:                 config,
@@ -138,6 +203,7 @@ func main() {
 		kubeInformerFactory:    kubeInformerFactory,
 		faasInformerFactory:    faasInformerFactory,
 		profileInformerFactory: profileInformerFactory,
+		namespaceScope:         namespaceScope,
 		kubeClient:             kubeClient,
 		faasClient:             faasClient,
 	}
@@ -151,6 +217,50 @@ func main() {
 	}
 }
 
+// runValidate checks that the cluster can be reached with the current configuration and prints
+// a summary of what was found. No informers or servers are started.
+func runValidate(kubeClient kubernetes.Interface, faasClient clientset.Interface, config config.BootstrapConfig) error {
+	ctx := context.Background()
+
+	info, err := kubeClient.Discovery().ServerVersion()
+	if err != nil {
+		return fmt.Errorf("unable to reach the Kubernetes API: %s", err.Error())
+	}
+	log.Printf("Kubernetes API version: %s\n", info.GitVersion)
+
+	if config.ClusterRole {
+		res, err := kubeClient.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return fmt.Errorf("unable to list namespaces: %s", err.Error())
+		}
+
+		names := make([]string, 0, len(res.Items))
+		for _, item := range res.Items {
+			names = append(names, item.Name)
+		}
+		log.Printf("Namespaces: %d %v\n", len(names), names)
+	}
+
+	if _, err := kubeClient.CoreV1().Namespaces().Get(ctx, config.DefaultFunctionNamespace, metav1.GetOptions{}); err != nil {
+		return fmt.Errorf("unable to find function namespace %s: %s", config.DefaultFunctionNamespace, err.Error())
+	}
+	log.Printf("Function namespace: %s found\n", config.DefaultFunctionNamespace)
+
+	deployments, err := kubeClient.AppsV1().Deployments(config.DefaultFunctionNamespace).List(ctx, metav1.ListOptions{LabelSelector: "faas_function"})
+	if err != nil {
+		return fmt.Errorf("unable to list functions in %s: %s", config.DefaultFunctionNamespace, err.Error())
+	}
+	log.Printf("Functions: %d\n", len(deployments.Items))
+
+	profiles, err := faasClient.OpenfaasV1().Profiles(config.ProfilesNamespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("unable to list profiles in %s: %s", config.ProfilesNamespace, err.Error())
+	}
+	log.Printf("Profiles: %d in %s\n", len(profiles.Items), config.ProfilesNamespace)
+
+	return nil
+}
+
 type customInformers struct {
 	EndpointsInformer  v1core.EndpointsInformer
 	DeploymentInformer v1apps.DeploymentInformer
@@ -195,6 +305,14 @@ func startInformers(setup serverSetup, stopCh <-chan struct{}, operator bool) cu
 		log.Fatalf("failed to wait for cache to sync")
 	}
 
+	if len(setup.config.FeatureFlagsConfigMap) > 0 {
+		startFeatureFlagsInformer(setup, stopCh)
+	}
+
+	if setup.functionFactory.NamespaceLabels != nil {
+		startNamespaceLabelsInformer(setup, stopCh)
+	}
+
 	return customInformers{
 		EndpointsInformer:  endpoints,
 		DeploymentInformer: deployments,
@@ -202,6 +320,77 @@ func startInformers(setup serverSetup, stopCh <-chan struct{}, operator bool) cu
 	}
 }
 
+// startFeatureFlagsInformer watches the feature flags ConfigMap and updates
+// config.FeatureFlags whenever it changes
+func startFeatureFlagsInformer(setup serverSetup, stopCh <-chan struct{}) {
+	flags := setup.config.FeatureFlags
+	name := setup.config.FeatureFlagsConfigMap
+
+	factory := kubeinformers.NewSharedInformerFactoryWithOptions(setup.kubeClient, time.Minute*5,
+		kubeinformers.WithNamespace(setup.config.FeatureFlagsNamespace),
+		kubeinformers.WithTweakListOptions(func(opts *metav1.ListOptions) {
+			opts.FieldSelector = fields.OneTermEqualSelector("metadata.name", name).String()
+		}))
+
+	configMaps := factory.Core().V1().ConfigMaps()
+	configMaps.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if cm, ok := obj.(*corev1.ConfigMap); ok {
+				flags.Update(cm.Data)
+			}
+		},
+		UpdateFunc: func(old, new interface{}) {
+			if cm, ok := new.(*corev1.ConfigMap); ok {
+				flags.Update(cm.Data)
+				log.Printf("Feature flags updated from ConfigMap: %s\n", name)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			flags.Update(nil)
+			log.Printf("Feature flags ConfigMap: %s deleted, all features disabled\n", name)
+		},
+	})
+
+	go configMaps.Informer().Run(stopCh)
+	if ok := cache.WaitForNamedCacheSync("faas-netes:feature-flags", stopCh, configMaps.Informer().HasSynced); !ok {
+		log.Fatalf("failed to wait for cache to sync")
+	}
+}
+
+// startNamespaceLabelsInformer keeps the FunctionFactory's NamespaceLabels up to date with the
+// labels of every namespace, functions pick up a change the next time they are deployed or updated
+func startNamespaceLabelsInformer(setup serverSetup, stopCh <-chan struct{}) {
+	namespaceLabels := setup.functionFactory.NamespaceLabels
+
+	factory := kubeinformers.NewSharedInformerFactory(setup.kubeClient, time.Minute*5)
+
+	namespaces := factory.Core().V1().Namespaces()
+	namespaces.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if ns, ok := obj.(*corev1.Namespace); ok {
+				namespaceLabels.Update(ns)
+			}
+		},
+		UpdateFunc: func(old, new interface{}) {
+			if ns, ok := new.(*corev1.Namespace); ok {
+				namespaceLabels.Update(ns)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			if ns, ok := obj.(*corev1.Namespace); ok {
+				namespaceLabels.Delete(ns.Name)
+			} else if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				namespaceLabels.Delete(tombstone.Key)
+			}
+		},
+	})
+
+	go namespaces.Informer().Run(stopCh)
+	if ok := cache.WaitForNamedCacheSync("faas-netes:namespace-labels", stopCh, namespaces.Informer().HasSynced); !ok {
+		log.Fatalf("failed to wait for cache to sync")
+	}
+}
+
 // runController runs the faas-netes imperative controller
 func runController(setup serverSetup) {
 	config := setup.config
@@ -213,23 +402,197 @@ func runController(setup serverSetup) {
 	operator := false
 	listers := startInformers(setup, stopCh, operator)
 
+	if config.ExecDeadlineWatchdog {
+		go k8s.NewExecDeadlineWatchdog(kubeClient, listers.DeploymentInformer.Lister()).Run(stopCh)
+	}
+	go k8s.NewExpirySweeper(k8s.NewDeploymentExpiryTarget(kubeClient, listers.DeploymentInformer.Lister())).Run(config.ExpiryCheckInterval, stopCh)
+
+	if len(config.FunctionsDir) > 0 {
+		log.Printf("Deploying functions from: %s\n", config.FunctionsDir)
+		go handlers.NewFunctionFileReconciler(config.FunctionsDir, config.DefaultFunctionNamespace, factory).Run(stopCh)
+	}
+
 	functionLookup := k8s.NewFunctionLookup(config.DefaultFunctionNamespace, listers.EndpointsInformer.Lister())
+	functionLookup.RoutingTable = k8s.NewRoutingTable(kubeClient, config.ProfilesNamespace)
+	functionLookup.LoadBalancer = config.ProxyLoadBalancer
+
+	statusConfig := k8s.StatusConfig{
+		ScaleFromZeroGracePeriod: config.ScaleFromZeroGracePeriod,
+		ReadyThreshold:           config.ReadyThreshold,
+	}
+
+	scaleHistory := k8s.NewScaleHistory(k8s.DefaultScaleHistorySize)
+
+	logHandler := logs.NewLogHandlerFunc(server.NewLogRequestor(config, kubeClient, config.DefaultFunctionNamespace), config.FaaSConfig.WriteTimeout)
 
 	bootstrapHandlers := providertypes.FaaSHandlers{
-		FunctionProxy:        proxy.NewHandlerFunc(config.FaaSConfig, functionLookup),
+		FunctionProxy:        handlers.MakeABTestProxyHandler(handlers.MakeProxyHandler(config.FaaSConfig, functionLookup, config.DefaultFunctionNamespace, listers.DeploymentInformer.Lister()), config.DefaultFunctionNamespace, listers.DeploymentInformer.Lister()),
 		DeleteHandler:        handlers.MakeDeleteHandler(config.DefaultFunctionNamespace, kubeClient),
 		DeployHandler:        handlers.MakeDeployHandler(config.DefaultFunctionNamespace, factory),
-		FunctionReader:       handlers.MakeFunctionReader(config.DefaultFunctionNamespace, listers.DeploymentInformer.Lister()),
-		ReplicaReader:        handlers.MakeReplicaReader(config.DefaultFunctionNamespace, listers.DeploymentInformer.Lister()),
-		ReplicaUpdater:       handlers.MakeReplicaUpdater(config.DefaultFunctionNamespace, kubeClient),
+		FunctionReader:       handlers.MakeFunctionReader(config.DefaultFunctionNamespace, listers.DeploymentInformer.Lister(), statusConfig),
+		ReplicaReader:        handlers.MakeReplicaReader(config.DefaultFunctionNamespace, listers.DeploymentInformer.Lister(), statusConfig),
+		ReplicaUpdater:       handlers.MakeReplicaUpdater(config.DefaultFunctionNamespace, kubeClient, int32(config.MaxReplicasPerFunction), scaleHistory),
 		UpdateHandler:        handlers.MakeUpdateHandler(config.DefaultFunctionNamespace, factory),
 		HealthHandler:        handlers.MakeHealthHandler(),
-		InfoHandler:          handlers.MakeInfoHandler(version.BuildVersion(), version.GitCommit),
-		SecretHandler:        handlers.MakeSecretHandler(config.DefaultFunctionNamespace, kubeClient),
-		LogHandler:           logs.NewLogHandlerFunc(k8s.NewLogRequestor(kubeClient, config.DefaultFunctionNamespace), config.FaaSConfig.WriteTimeout),
+		InfoHandler:          handlers.MakeInfoHandler(version.GetReleaseInfo()),
+		SecretHandler:        handlers.MakeSecretHandler(config.DefaultFunctionNamespace, kubeClient, config.SecretLockTimeout),
+		LogHandler:           handlers.MakeContainerLogHandler(logHandler, config.DefaultFunctionNamespace, kubeClient),
 		ListNamespaceHandler: handlers.MakeNamespacesLister(config.DefaultFunctionNamespace, config.ClusterRole, kubeClient),
 	}
 
+	routes := []server.Route{
+		{
+			Path:    server.FunctionPath + "/images",
+			Methods: []string{http.MethodGet},
+			Handler: handlers.MakeFunctionImagesHandler(config.DefaultFunctionNamespace, kubeClient),
+		},
+		{
+			Path:    server.FunctionPath + "/events",
+			Methods: []string{http.MethodGet},
+			Handler: handlers.MakeEventListHandler(config.DefaultFunctionNamespace, kubeClient),
+		},
+		{
+			Path:    server.FunctionPath + "/logs",
+			Methods: []string{http.MethodGet},
+			Handler: handlers.MakeFunctionLogsHandler(config.DefaultFunctionNamespace, kubeClient, config.FaaSConfig.WriteTimeout),
+		},
+		{
+			Path:    server.FunctionPath + "/scale-history",
+			Methods: []string{http.MethodGet},
+			Handler: handlers.MakeScaleHistoryHandler(config.DefaultFunctionNamespace, scaleHistory),
+		},
+		{
+			Path:    server.FunctionPath + "/probe",
+			Methods: []string{http.MethodGet},
+			Handler: handlers.MakeFunctionProbeHandler(config.DefaultFunctionNamespace, functionLookup, config.FaaSConfig.ReadTimeout),
+		},
+		{
+			Path:    server.FunctionPath + "/usage",
+			Methods: []string{http.MethodGet},
+			Handler: handlers.MakeResourceUsageHandler(config.DefaultFunctionNamespace, listers.DeploymentInformer.Lister(), k8s.NewPodMetricsClient(kubeClient)),
+		},
+		{
+			Path:    server.FunctionPath + "/async",
+			Methods: []string{http.MethodGet},
+			Handler: handlers.MakeAsyncConfigHandler(config.DefaultFunctionNamespace, listers.DeploymentInformer.Lister()),
+		},
+		{
+			Path:    server.FunctionPath + "/drift",
+			Methods: []string{http.MethodGet},
+			Handler: handlers.MakeFunctionDriftHandler(config.DefaultFunctionNamespace, listers.DeploymentInformer.Lister()),
+		},
+		{
+			Path:    server.FunctionPath + "/ownership",
+			Methods: []string{http.MethodGet},
+			Handler: handlers.MakeOwnershipHandler(config.DefaultFunctionNamespace, kubeClient),
+		},
+		{
+			Path:    "/system/functions/cordon",
+			Methods: []string{http.MethodPost},
+			Handler: handlers.MakeNamespaceCordonHandler(config.DefaultFunctionNamespace, kubeClient),
+		},
+		{
+			Path:    "/system/functions/cordon",
+			Methods: []string{http.MethodDelete},
+			Handler: handlers.MakeNamespaceUncordonHandler(config.DefaultFunctionNamespace, kubeClient),
+		},
+		{
+			Path:    server.FunctionPath + "/pause",
+			Methods: []string{http.MethodPost},
+			Handler: handlers.MakePauseHandler(config.DefaultFunctionNamespace, kubeClient, scaleHistory),
+		},
+		{
+			Path:    server.FunctionPath + "/resume",
+			Methods: []string{http.MethodPost},
+			Handler: handlers.MakeResumeHandler(config.DefaultFunctionNamespace, kubeClient, scaleHistory),
+		},
+		{
+			Path:    server.FunctionPath + "/staging",
+			Methods: []string{http.MethodPost},
+			Handler: handlers.MakeStagingCloneHandler(config.DefaultFunctionNamespace, factory, config.MaxFunctionNameLength),
+		},
+		{
+			Path:    "/system/functions/staging",
+			Methods: []string{http.MethodDelete},
+			Handler: handlers.MakeStagingCleanupHandler(config.DefaultFunctionNamespace, kubeClient, config.StagingTTL),
+		},
+		{
+			Path:    "/system/functions/blue-green",
+			Methods: []string{http.MethodPost},
+			Handler: handlers.MakeBlueGreenDeployHandler(config.DefaultFunctionNamespace, factory, config.BlueGreenReadyTimeout, config.DrainDelay),
+		},
+		{
+			Path:    "/system/functions/terraform",
+			Methods: []string{http.MethodGet},
+			Handler: handlers.MakeTerraformExportHandler(config.DefaultFunctionNamespace, listers.DeploymentInformer.Lister()),
+		},
+		{
+			Path:    "/system/secrets/names",
+			Methods: []string{http.MethodGet},
+			Handler: handlers.MakeSecretListHandler(config.DefaultFunctionNamespace, kubeClient),
+		},
+		{
+			Path:    server.SecretPath + "/impact",
+			Methods: []string{http.MethodGet},
+			Handler: handlers.MakeSecretImpactHandler(config.DefaultFunctionNamespace, config.ClusterRole, kubeClient),
+		},
+		{
+			Path:    "/system/functions/batch",
+			Methods: []string{http.MethodPost},
+			Handler: handlers.MakeBatchDeployHandler(config.DefaultFunctionNamespace, factory),
+		},
+		{
+			Path:    "/system/routing",
+			Methods: []string{http.MethodGet},
+			Handler: handlers.MakeRoutingTableHandler(functionLookup.RoutingTable),
+		},
+		{
+			Path:    "/system/profiles",
+			Methods: []string{http.MethodGet},
+			Handler: handlers.MakeProfileListHandler(config.ProfilesNamespace, setup.profileInformerFactory.Openfaas().V1().Profiles().Lister(), config.ProfilesDetailLevel),
+		},
+	}
+
+	if traces := server.NewTraceQuerier(config); traces != nil {
+		routes = append(routes, server.Route{
+			Path:    "/system/functions/call-graph",
+			Methods: []string{http.MethodGet},
+			Handler: handlers.MakeCallGraphHandler(traces),
+		})
+	}
+
+	if len(config.CacheRefreshToken) > 0 {
+		routes = append(routes, server.Route{
+			Path:    "/-/cache/refresh",
+			Methods: []string{http.MethodPost},
+			Handler: handlers.MakeCacheRefreshHandler(config.CacheRefreshToken,
+				k8s.NewDeploymentCacheRefresher(kubeClient, setup.namespaceScope, listers.DeploymentInformer),
+				k8s.NewEndpointsCacheRefresher(kubeClient, setup.namespaceScope, listers.EndpointsInformer)),
+		})
+	}
+
+	if config.EnableConfigEndpoint {
+		routes = append(routes, server.Route{
+			Path:    "/system/config",
+			Methods: []string{http.MethodGet},
+			Handler: handlers.MakeConfigHandler(config, factory.Config),
+		})
+	}
+
+	if err := server.RegisterRoutes(&config.FaaSConfig, routes); err != nil {
+		log.Fatalf("Error registering routes: %s", err.Error())
+	}
+
+	metrics.InstrumentHandlers(&bootstrapHandlers)
+
+	statsd, err := metrics.NewStatsD(config.StatsDHost, config.StatsDPort)
+	if err != nil {
+		log.Fatalf("Error creating StatsD client: %s", err.Error())
+	}
+	metrics.InstrumentStatsD(&bootstrapHandlers, statsd)
+
+	faasProvider.Router().Path("/metrics").Handler(promhttp.Handler())
+
 	faasProvider.Serve(&bootstrapHandlers, &config.FaaSConfig)
 }
 
@@ -261,10 +624,24 @@ func runOperator(setup serverSetup, cfg config.BootstrapConfig) {
 		factory,
 	)
 
-	srv := server.New(faasClient, kubeClient, listers.EndpointsInformer, listers.DeploymentInformer.Lister(), cfg.ClusterRole, cfg)
+	statusConfig := k8s.StatusConfig{
+		ScaleFromZeroGracePeriod: cfg.ScaleFromZeroGracePeriod,
+		ReadyThreshold:           cfg.ReadyThreshold,
+	}
+
+	srv := server.New(faasClient, kubeClient, listers.EndpointsInformer, listers.DeploymentInformer, cfg.ClusterRole, statusConfig, cfg)
+
+	if cfg.ExecDeadlineWatchdog {
+		go k8s.NewExecDeadlineWatchdog(kubeClient, listers.DeploymentInformer.Lister()).Run(stopCh)
+	}
 
 	go srv.Start()
-	if err := ctrl.Run(1, stopCh); err != nil {
+	go ctrl.RunFullReconcile(cfg.FullReconcileInterval, stopCh)
+	go ctrl.RunTTLSweep(cfg.ExpiryCheckInterval, stopCh)
+	if cfg.RestartOnSecretChange {
+		go ctrl.RunSecretRestarts(kubeInformerFactory.Core().V1().Secrets(), stopCh)
+	}
+	if err := ctrl.Run(cfg.ReconcileWorkers, stopCh); err != nil {
 		glog.Fatalf("Error running controller: %s", err.Error())
 	}
 }
@@ -279,6 +656,8 @@ type serverSetup struct {
 	kubeInformerFactory    kubeinformers.SharedInformerFactory
 	faasInformerFactory    informers.SharedInformerFactory
 	profileInformerFactory informers.SharedInformerFactory
+	// namespaceScope is the namespace of the informers, empty for all namespaces
+	namespaceScope string
 }
 
 func setupLogging() {
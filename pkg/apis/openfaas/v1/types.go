@@ -112,6 +112,14 @@ type ProfileSpec struct {
 	//
 	// +optional
 	PodSecurityContext *corev1.PodSecurityContext `json:"podSecurityContext,omitempty"`
+
+	// IdleTimeout is how long a function may be idle before it is scaled to zero, i.e. 15m.
+	//
+	// copied to the function's com.openfaas.scale.zero-duration label, which is read by the
+	// idler, unless the function sets the label itself
+	//
+	// +optional
+	IdleTimeout *metav1.Duration `json:"idleTimeout,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
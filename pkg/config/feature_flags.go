@@ -0,0 +1,56 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package config
+
+import (
+	"log"
+	"strconv"
+	"sync"
+)
+
+// FeatureFlags holds the experimental features that can be toggled at runtime. The flags are
+// read from the keys of the ConfigMap named by the feature_flags_configmap environment variable,
+// and are updated whenever the ConfigMap changes.
+type FeatureFlags struct {
+	lock  sync.RWMutex
+	flags map[string]bool
+}
+
+// NewFeatureFlags creates a FeatureFlags with every feature disabled
+func NewFeatureFlags() *FeatureFlags {
+	return &FeatureFlags{
+		flags: map[string]bool{},
+	}
+}
+
+// FlagEnabled returns true when the named feature is enabled, unknown features are disabled.
+func (f *FeatureFlags) FlagEnabled(name string) bool {
+	if f == nil {
+		return false
+	}
+
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	return f.flags[name]
+}
+
+// Update replaces all flags with the values from the data of a ConfigMap. Values which are not
+// booleans are logged and treated as disabled.
+func (f *FeatureFlags) Update(data map[string]string) {
+	flags := make(map[string]bool, len(data))
+	for k, v := range data {
+		enabled, err := strconv.ParseBool(v)
+		if err != nil {
+			log.Printf("Feature flag %s has an invalid value: %q, must be true or false\n", k, v)
+			continue
+		}
+		flags[k] = enabled
+	}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.flags = flags
+}
@@ -0,0 +1,48 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package config
+
+import "testing"
+
+func Test_FeatureFlags(t *testing.T) {
+	flags := NewFeatureFlags()
+
+	if flags.FlagEnabled("streaming") {
+		t.Errorf("want features disabled by default")
+	}
+
+	flags.Update(map[string]string{
+		"streaming": "true",
+		"scale":     "false",
+		"invalid":   "yes please",
+	})
+
+	cases := []struct {
+		name string
+		want bool
+	}{
+		{name: "streaming", want: true},
+		{name: "scale", want: false},
+		{name: "invalid", want: false},
+		{name: "unknown", want: false},
+	}
+
+	for _, tc := range cases {
+		if got := flags.FlagEnabled(tc.name); got != tc.want {
+			t.Errorf("%s: want: %t, got: %t", tc.name, tc.want, got)
+		}
+	}
+
+	flags.Update(nil)
+	if flags.FlagEnabled("streaming") {
+		t.Errorf("want removed flags to be disabled")
+	}
+}
+
+func Test_FeatureFlags_Nil(t *testing.T) {
+	var flags *FeatureFlags
+	if flags.FlagEnabled("streaming") {
+		t.Errorf("want a nil FeatureFlags to report features as disabled")
+	}
+}
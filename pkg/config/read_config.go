@@ -4,8 +4,13 @@
 package config
 
 import (
+	"encoding/json"
 	"fmt"
 	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
 
 	ftypes "github.com/openfaas/faas-provider/types"
 )
@@ -16,6 +21,91 @@ var validPullPolicyOptions = map[string]bool{
 	"Never":        true,
 }
 
+const (
+	// ProfilesDetailNames only reveals the names of Profiles
+	ProfilesDetailNames = "names"
+	// ProfilesDetailSummary reveals the names of Profiles and which settings they change
+	ProfilesDetailSummary = "summary"
+	// ProfilesDetailFull reveals the full spec of each Profile
+	ProfilesDetailFull = "full"
+)
+
+var validProfilesDetailLevels = map[string]bool{
+	ProfilesDetailNames:   true,
+	ProfilesDetailSummary: true,
+	ProfilesDetailFull:    true,
+}
+
+const (
+	// ServiceMeshNone does not add any sidecar injection annotations
+	ServiceMeshNone = "none"
+	// ServiceMeshLinkerd adds the Linkerd injection annotation to functions
+	ServiceMeshLinkerd = "linkerd"
+	// ServiceMeshIstio adds the Istio injection annotation to functions
+	ServiceMeshIstio = "istio"
+)
+
+var validServiceMeshes = map[string]bool{
+	ServiceMeshNone:    true,
+	ServiceMeshLinkerd: true,
+	ServiceMeshIstio:   true,
+}
+
+const (
+	// LogBackendKubernetes reads function logs from the Kubernetes API
+	LogBackendKubernetes = "kubernetes"
+	// LogBackendLoki reads function logs from a Loki server
+	LogBackendLoki = "loki"
+)
+
+var validLogBackends = map[string]bool{
+	LogBackendKubernetes: true,
+	LogBackendLoki:       true,
+}
+
+const (
+	// ProxyLoadBalancerRandom picks a function endpoint at random
+	ProxyLoadBalancerRandom = "random"
+	// ProxyLoadBalancerLeastConnections picks the function endpoint with the fewest in-flight
+	// requests
+	ProxyLoadBalancerLeastConnections = "least-connections"
+)
+
+var validProxyLoadBalancers = map[string]bool{
+	ProxyLoadBalancerRandom:           true,
+	ProxyLoadBalancerLeastConnections: true,
+}
+
+const (
+	// TraceBackendJaeger queries traces from the Jaeger query API
+	TraceBackendJaeger = "jaeger"
+	// TraceBackendTempo queries traces from the Grafana Tempo API
+	TraceBackendTempo = "tempo"
+)
+
+var validTraceBackends = map[string]bool{
+	TraceBackendJaeger: true,
+	TraceBackendTempo:  true,
+}
+
+const (
+	// DeployModeApply creates functions in the cluster
+	DeployModeApply = "apply"
+	// DeployModeRender returns the manifests of functions so that a GitOps tool can apply them
+	DeployModeRender = "render"
+)
+
+var validDeployModes = map[string]bool{
+	DeployModeApply:  true,
+	DeployModeRender: true,
+}
+
+var validPSAEnforceLevels = map[string]bool{
+	"privileged": true,
+	"baseline":   true,
+	"restricted": true,
+}
+
 // ReadConfig constitutes config from env variables
 type ReadConfig struct {
 }
@@ -47,6 +137,81 @@ func (ReadConfig) Read(hasEnv ftypes.HasEnv) (BootstrapConfig, error) {
 		return cfg, fmt.Errorf("invalid image_pull_policy configured: %s", imagePullPolicy)
 	}
 
+	progressDeadlineSeconds := ftypes.ParseIntValue(hasEnv.Getenv("progress_deadline_seconds"), 600)
+	if progressDeadlineSeconds <= 0 {
+		return cfg, fmt.Errorf("invalid progress_deadline_seconds configured: %d", progressDeadlineSeconds)
+	}
+
+	profilesDetailLevel := ftypes.ParseString(hasEnv.Getenv("profiles_detail_level"), ProfilesDetailSummary)
+	if !validProfilesDetailLevels[profilesDetailLevel] {
+		return cfg, fmt.Errorf("invalid profiles_detail_level configured: %s", profilesDetailLevel)
+	}
+
+	serviceMesh := ftypes.ParseString(hasEnv.Getenv("service_mesh"), ServiceMeshNone)
+	if !validServiceMeshes[serviceMesh] {
+		return cfg, fmt.Errorf("invalid service_mesh configured: %s, must be one of %s, %s or %s", serviceMesh, ServiceMeshNone, ServiceMeshLinkerd, ServiceMeshIstio)
+	}
+
+	logBackend := ftypes.ParseString(hasEnv.Getenv("log_backend"), LogBackendKubernetes)
+	if !validLogBackends[logBackend] {
+		return cfg, fmt.Errorf("invalid log_backend configured: %s", logBackend)
+	}
+
+	proxyLoadBalancer := ftypes.ParseString(hasEnv.Getenv("proxy_load_balancer"), ProxyLoadBalancerRandom)
+	if !validProxyLoadBalancers[proxyLoadBalancer] {
+		return cfg, fmt.Errorf("invalid proxy_load_balancer configured: %s", proxyLoadBalancer)
+	}
+
+	lokiURL := hasEnv.Getenv("loki_url")
+	if logBackend == LogBackendLoki && len(lokiURL) == 0 {
+		return cfg, fmt.Errorf("loki_url must be configured when log_backend is %s", LogBackendLoki)
+	}
+
+	traceBackend := ftypes.ParseString(hasEnv.Getenv("trace_backend"), TraceBackendJaeger)
+	if !validTraceBackends[traceBackend] {
+		return cfg, fmt.Errorf("invalid trace_backend configured: %s", traceBackend)
+	}
+
+	psaEnforceLevel := hasEnv.Getenv("psa_enforce_level")
+	if len(psaEnforceLevel) > 0 && !validPSAEnforceLevels[psaEnforceLevel] {
+		return cfg, fmt.Errorf("invalid psa_enforce_level configured: %s", psaEnforceLevel)
+	}
+
+	deployMode := ftypes.ParseString(hasEnv.Getenv("deploy_mode"), DeployModeApply)
+	if !validDeployModes[deployMode] {
+		return cfg, fmt.Errorf("invalid deploy_mode configured: %s", deployMode)
+	}
+
+	certManagerIssuerKind := ftypes.ParseString(hasEnv.Getenv("cert_manager_issuer_kind"), "Issuer")
+	if certManagerIssuerKind != "Issuer" && certManagerIssuerKind != "ClusterIssuer" {
+		return cfg, fmt.Errorf("invalid cert_manager_issuer_kind configured: %s", certManagerIssuerKind)
+	}
+
+	defaultFunctionAnnotations, err := parseStringMap(hasEnv.Getenv("default_function_annotations"))
+	if err != nil {
+		return cfg, fmt.Errorf("invalid default_function_annotations configured: %s", err)
+	}
+
+	defaultAnnotations, err := parseStringMap(hasEnv.Getenv("default_annotations"))
+	if err != nil {
+		return cfg, fmt.Errorf("invalid default_annotations configured: %s", err)
+	}
+
+	defaultPodLabels, err := parseStringMap(hasEnv.Getenv("default_pod_labels"))
+	if err != nil {
+		return cfg, fmt.Errorf("invalid default_pod_labels configured: %s", err)
+	}
+
+	backupAnnotations, err := parseStringMap(hasEnv.Getenv("backup_annotations"))
+	if err != nil {
+		return cfg, fmt.Errorf("invalid backup_annotations configured: %s", err)
+	}
+
+	meshInjectDisableAnnotations, err := parseStringMap(hasEnv.Getenv("mesh_inject_disable_annotations"))
+	if err != nil {
+		return cfg, fmt.Errorf("invalid mesh_inject_disable_annotations configured: %s", err)
+	}
+
 	cfg.DefaultFunctionNamespace = ftypes.ParseString(hasEnv.Getenv("function_namespace"), "default")
 	cfg.ProfilesNamespace = ftypes.ParseString(hasEnv.Getenv("profiles_namespace"), cfg.DefaultFunctionNamespace)
 	cfg.ClusterRole = ftypes.ParseBoolValue(hasEnv.Getenv("cluster_role"), false)
@@ -63,6 +228,89 @@ func (ReadConfig) Read(hasEnv ftypes.HasEnv) (BootstrapConfig, error) {
 	cfg.LivenessProbePeriodSeconds = livenessProbePeriodSeconds
 
 	cfg.ImagePullPolicy = imagePullPolicy
+	cfg.ProgressDeadlineSeconds = progressDeadlineSeconds
+	cfg.DefaultFunctionAnnotations = defaultFunctionAnnotations
+	cfg.ProfilesDetailLevel = profilesDetailLevel
+	cfg.LogBackend = logBackend
+	cfg.LokiURL = lokiURL
+	cfg.ProxyLoadBalancer = proxyLoadBalancer
+	cfg.TraceBackend = traceBackend
+	cfg.TraceQueryURL = hasEnv.Getenv("trace_query_url")
+	cfg.TraceService = ftypes.ParseString(hasEnv.Getenv("trace_service"), "gateway")
+	cfg.DeployMode = deployMode
+	cfg.RenderConfigMap = hasEnv.Getenv("render_configmap")
+	cfg.PSAEnforceLevel = psaEnforceLevel
+	cfg.FunctionsDir = hasEnv.Getenv("functions_dir")
+	cfg.DefaultAnnotations = defaultAnnotations
+	cfg.DefaultPodLabels = defaultPodLabels
+	cfg.BackupAnnotations = backupAnnotations
+	cfg.DefaultSchedulerName = hasEnv.Getenv("default_scheduler_name")
+	cfg.NodePoolLabel = hasEnv.Getenv("node_pool_label")
+	cfg.PDBMinAvailable = hasEnv.Getenv("pdb_min_available")
+	cfg.NamespaceLabelPrefix = hasEnv.Getenv("namespace_label_prefix")
+	cfg.DisableLocalProfiles = ftypes.ParseBoolValue(hasEnv.Getenv("disable_local_profiles"), false)
+	cfg.MeshInjectDisableAnnotations = meshInjectDisableAnnotations
+	cfg.ServiceMesh = serviceMesh
+	cfg.MaxFunctionNameLength = ftypes.ParseIntValue(hasEnv.Getenv("max_function_name_length"), 63)
+	cfg.MaxReplicasPerFunction = ftypes.ParseIntValue(hasEnv.Getenv("max_replicas_per_function"), 0)
+	cfg.MaxFunctionsPerNamespace = ftypes.ParseIntValue(hasEnv.Getenv("max_functions_per_namespace"), 0)
+	cfg.FunctionQuotaConfigMap = hasEnv.Getenv("function_quota_configmap")
+	cfg.UpdateConflictRetries = ftypes.ParseIntValue(hasEnv.Getenv("update_conflict_retries"), 5)
+	cfg.UpdateWaitTimeout = ftypes.ParseIntOrDurationValue(hasEnv.Getenv("update_wait_timeout"), time.Second*120)
+
+	if v := hasEnv.Getenv("image_name_regex"); len(v) > 0 {
+		imageNameRegex, err := regexp.Compile(v)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid image_name_regex configured: %s, %s", v, err)
+		}
+		cfg.ImageNameRegex = imageNameRegex
+	}
+	cfg.ImageCheck = ftypes.ParseBoolValue(hasEnv.Getenv("image_check"), false)
+	cfg.ImageCheckTimeout = ftypes.ParseIntOrDurationValue(hasEnv.Getenv("image_check_timeout"), time.Second*5)
+	cfg.CacheRefreshToken = hasEnv.Getenv("cache_refresh_token")
+	cfg.AutoZoneSpread = ftypes.ParseBoolValue(hasEnv.Getenv("auto_zone_spread"), true)
+	cfg.CertManagerIssuerName = hasEnv.Getenv("cert_manager_issuer_name")
+	cfg.CertManagerIssuerKind = certManagerIssuerKind
+	cfg.AllowedUnsafeSysctls = parseStringList(hasEnv.Getenv("allowed_unsafe_sysctls"))
+	cfg.StartupKubeWaitTimeout = ftypes.ParseIntOrDurationValue(hasEnv.Getenv("startup_kube_wait_timeout"), time.Second*60)
+	cfg.StagingTTL = ftypes.ParseIntOrDurationValue(hasEnv.Getenv("staging_ttl"), time.Hour*24)
+	cfg.DrainDelay = ftypes.ParseIntOrDurationValue(hasEnv.Getenv("drain_delay"), time.Minute*5)
+	cfg.BlueGreenReadyTimeout = ftypes.ParseIntOrDurationValue(hasEnv.Getenv("blue_green_ready_timeout"), time.Minute*2)
+	cfg.FullReconcileInterval = ftypes.ParseIntOrDurationValue(hasEnv.Getenv("full_reconcile_interval"), time.Minute*10)
+
+	cfg.ExpiryCheckInterval = ftypes.ParseIntOrDurationValue(hasEnv.Getenv("expiry_check_interval"), time.Minute)
+	cfg.ExecDeadlineWatchdog = ftypes.ParseBoolValue(hasEnv.Getenv("exec_deadline_watchdog"), false)
+
+	cfg.ReconcileWorkers = ftypes.ParseIntValue(hasEnv.Getenv("reconcile_workers"), 1)
+	if cfg.ReconcileWorkers < 1 {
+		return cfg, fmt.Errorf("reconcile_workers must be at least 1, got: %d", cfg.ReconcileWorkers)
+	}
+	cfg.ScaleFromZeroGracePeriod = ftypes.ParseIntOrDurationValue(hasEnv.Getenv("scale_from_zero_grace_period"), time.Second*30)
+	cfg.SecretLockTimeout = ftypes.ParseIntOrDurationValue(hasEnv.Getenv("secret_lock_timeout"), time.Second*5)
+
+	if v := hasEnv.Getenv("ready_threshold"); len(v) > 0 {
+		readyThreshold, err := strconv.ParseFloat(v, 64)
+		if err != nil || readyThreshold < 0 || readyThreshold > 1 {
+			return cfg, fmt.Errorf("invalid ready_threshold configured: %s, must be between 0 and 1", v)
+		}
+		cfg.ReadyThreshold = readyThreshold
+	}
+
+	cfg.EnableConfigEndpoint = ftypes.ParseBoolValue(hasEnv.Getenv("enable_config_endpoint"), false)
+	cfg.EnablePodMetricsScraping = ftypes.ParseBoolValue(hasEnv.Getenv("enable_pod_metrics_scraping"), false)
+	cfg.RestartOnSecretChange = ftypes.ParseBoolValue(hasEnv.Getenv("restart_on_secret_change"), false)
+	cfg.StatsDHost = hasEnv.Getenv("statsd_host")
+	cfg.StatsDPort = ftypes.ParseIntValue(hasEnv.Getenv("statsd_port"), 8125)
+
+	cfg.FeatureFlags = NewFeatureFlags()
+	if featureFlags := hasEnv.Getenv("feature_flags_configmap"); len(featureFlags) > 0 {
+		cfg.FeatureFlagsNamespace = cfg.ProfilesNamespace
+		cfg.FeatureFlagsConfigMap = featureFlags
+		if parts := strings.SplitN(featureFlags, "/", 2); len(parts) == 2 {
+			cfg.FeatureFlagsNamespace = parts[0]
+			cfg.FeatureFlagsConfigMap = parts[1]
+		}
+	}
 
 	return cfg, nil
 }
@@ -120,6 +368,289 @@ type BootstrapConfig struct {
 
 	// ClusterRole determines whether the operator should have cluster wide access
 	ClusterRole bool
+
+	// ProgressDeadlineSeconds is the default number of seconds a function rollout may take
+	// before it is reported as failed. Value is set via the progress_deadline_seconds
+	// environment variable, defaults to 600 to match Kubernetes.
+	ProgressDeadlineSeconds int
+
+	// StartupKubeWaitTimeout is how long to wait for the Kubernetes API to become reachable
+	// before starting. Value is set via the startup_kube_wait_timeout environment variable,
+	// defaults to 60s, a value of 0 disables the wait.
+	StartupKubeWaitTimeout time.Duration
+
+	// DefaultFunctionAnnotations are merged into the annotations of every function, values
+	// set by the function take precedence. Value is set via the default_function_annotations
+	// environment variable as a JSON object.
+	DefaultFunctionAnnotations map[string]string
+
+	// DefaultAnnotations are added to the Pod template of every function, such as those used to
+	// enable APM or secret injection. Unlike DefaultFunctionAnnotations they are not part of the
+	// function's own annotations. Value is set via the default_annotations environment variable
+	// as a JSON object.
+	DefaultAnnotations map[string]string
+
+	// DefaultPodLabels are added to the Pod template of every function, such as the team or
+	// environment labels used for cost allocation. Labels set by the function take precedence.
+	// Value is set via the default_pod_labels environment variable as a JSON object.
+	DefaultPodLabels map[string]string
+
+	// BackupAnnotations are added to the Pod template of every function for backup tooling such
+	// as Velero, i.e. {"backup.velero.io/backup-volumes":"{{volumes}}"}, where {{volumes}} is
+	// replaced with the names of the function's volumes. Functions opt out with
+	// com.openfaas.backup=false. Value is set via the backup_annotations environment variable
+	// as a JSON object.
+	BackupAnnotations map[string]string
+
+	// DefaultSchedulerName is the scheduler used for the Pods of functions that do not set the
+	// com.openfaas.scheduler-name annotation, such as volcano. Value is set via the
+	// default_scheduler_name environment variable, when empty the Kubernetes default scheduler
+	// is used.
+	DefaultSchedulerName string
+
+	// NodePoolLabel is the node label that the com.openfaas.nodepool annotation is mapped to,
+	// it differs per cloud, i.e. cloud.google.com/gke-nodepool on GKE or
+	// eks.amazonaws.com/nodegroup on EKS. Value is set via the node_pool_label environment
+	// variable, when empty the annotation is rejected.
+	NodePoolLabel string
+
+	// PDBMinAvailable is the minAvailable, as a number or a percentage, of the
+	// PodDisruptionBudget created for each function with more than one replica. Functions can
+	// override it with the com.openfaas.pdb.min-available annotation. Value is set via the
+	// pdb_min_available environment variable, when empty no PodDisruptionBudgets are created
+	// unless a function sets the annotation.
+	PDBMinAvailable string
+
+	// EnablePodMetricsScraping adds the Prometheus scrape annotations to the Pods of every
+	// function, on /metrics and port 8080 unless the function sets com.openfaas.metrics.path or
+	// com.openfaas.metrics.port. Value is set via the enable_pod_metrics_scraping environment
+	// variable, when false only functions that set one of the annotations are scraped.
+	EnablePodMetricsScraping bool
+
+	// RestartOnSecretChange makes the operator watch Secrets and restart the functions with the
+	// com.openfaas.restart-on-secret-change annotation when a Secret they read changes. Value is
+	// set via the restart_on_secret_change environment variable, defaults to false.
+	RestartOnSecretChange bool
+
+	// StatsDHost receives deploy, update and delete metrics over UDP in addition to the
+	// Prometheus metrics. Value is set via the statsd_host environment variable, when empty no
+	// StatsD metrics are sent.
+	StatsDHost string
+
+	// StatsDPort is the UDP port of StatsDHost. Value is set via the statsd_port environment
+	// variable, defaults to 8125.
+	StatsDPort int
+
+	// NamespaceLabelPrefix selects the labels of a function's namespace that are inherited by
+	// its Pods, i.e. billing.example.com/. Labels set by the function take precedence. Value is
+	// set via the namespace_label_prefix environment variable, when empty no labels are inherited.
+	NamespaceLabelPrefix string
+
+	// DisableLocalProfiles restricts Profiles to ProfilesNamespace. By default a Profile is
+	// first looked up in the function's own namespace, so that namespace owners can define
+	// their own. Value is set via the disable_local_profiles environment variable.
+	DisableLocalProfiles bool
+
+	// MeshInjectDisableAnnotations are the Pod annotations used to disable service mesh sidecar
+	// injection for functions with com.openfaas.mesh.inject=false. Value is set via the
+	// mesh_inject_disable_annotations environment variable as a JSON object, when unset the
+	// Istio and Linkerd annotations are used.
+	MeshInjectDisableAnnotations map[string]string
+
+	// ServiceMesh adds the sidecar injection annotation of a service mesh to the Pod template of
+	// every function. Value is set via the service_mesh environment variable as none, linkerd
+	// or istio, defaults to none.
+	ServiceMesh string
+
+	// MaxFunctionNameLength is the longest function name that the deploy and update handlers
+	// accept. Value is set via the max_function_name_length environment variable, defaults to 63,
+	// the limit for a Service name, a value of 0 disables the check.
+	MaxFunctionNameLength int
+
+	// MaxReplicasPerFunction is a provider-wide cap on the replicas of any function, scale requests
+	// above it are clamped. Value is set via the max_replicas_per_function environment variable,
+	// defaults to 0 which means there is no cap.
+	MaxReplicasPerFunction int
+
+	// MaxFunctionsPerNamespace is the number of functions that may be deployed to each namespace,
+	// further deployments are rejected with a 429. Value is set via the
+	// max_functions_per_namespace environment variable, defaults to 0 which is unlimited.
+	MaxFunctionsPerNamespace int
+
+	// FunctionQuotaConfigMap is the name of a ConfigMap in the ProfilesNamespace which overrides
+	// MaxFunctionsPerNamespace, its keys are namespace names and its values the number of
+	// functions allowed. Value is set via the function_quota_configmap environment variable.
+	FunctionQuotaConfigMap string
+
+	// UpdateConflictRetries is how many times the update handler retries a Deployment or Service
+	// update that conflicts with a concurrent change, re-reading the object each time. Value is
+	// set via the update_conflict_retries environment variable, defaults to 5, 0 disables retries.
+	UpdateConflictRetries int
+
+	// UpdateWaitTimeout is how long an update with ?wait=true waits for the rollout of the
+	// function to complete before returning a 408. Value is set via the update_wait_timeout
+	// environment variable, defaults to 120s.
+	UpdateWaitTimeout time.Duration
+
+	// ImageNameRegex is a policy that the image of every deployed function must match, i.e.
+	// ^registry\.example\.com/. Value is set via the image_name_regex environment variable,
+	// when empty any image is accepted.
+	ImageNameRegex *regexp.Regexp
+
+	// ImageCheck looks up the image of a function in its registry before it is deployed, so that
+	// a missing image is rejected with a 400. Value is set via the image_check environment
+	// variable, defaults to false.
+	ImageCheck bool
+
+	// ImageCheckTimeout bounds the registry lookup of ImageCheck, the function is deployed
+	// without the check when it is reached. Value is set via the image_check_timeout
+	// environment variable, defaults to 5s.
+	ImageCheckTimeout time.Duration
+
+	// CacheRefreshToken is required in the X-Cache-Refresh-Token header to re-list the
+	// Deployment and Endpoints caches via /-/cache/refresh. Value is set via the
+	// cache_refresh_token environment variable, when empty the endpoint is not registered.
+	CacheRefreshToken string
+
+	// AutoZoneSpread spreads the replicas of functions with more than two replicas across
+	// zones. Value is set via the auto_zone_spread environment variable, defaults to true.
+	AutoZoneSpread bool
+
+	// CertManagerIssuerName is the cert-manager issuer used for the Certificates of functions
+	// that set com.openfaas.tls.domain. Value is set via the cert_manager_issuer_name
+	// environment variable.
+	CertManagerIssuerName string
+
+	// CertManagerIssuerKind is either Issuer or ClusterIssuer. Value is set via the
+	// cert_manager_issuer_kind environment variable, defaults to Issuer.
+	CertManagerIssuerKind string
+
+	// AllowedUnsafeSysctls are the sysctls outside of the Kubernetes safe set that Profiles may
+	// set, each must also be allowed by the kubelet. Value is set via the allowed_unsafe_sysctls
+	// environment variable as a comma separated list, a trailing "*" matches a prefix.
+	AllowedUnsafeSysctls []string
+
+	// ProfilesDetailLevel controls how much of each Profile is returned by the Profiles endpoint,
+	// one of names, summary or full. Value is set via the profiles_detail_level environment
+	// variable, defaults to summary which lists the settings a Profile changes without their values.
+	ProfilesDetailLevel string
+
+	// LogBackend selects where function logs are read from, either kubernetes or loki. Value is
+	// set via the log_backend environment variable, defaults to kubernetes.
+	LogBackend string
+
+	// LokiURL is the base URL of the Loki server used when LogBackend is loki. Value is set via
+	// the loki_url environment variable.
+	LokiURL string
+
+	// TraceBackend is the API that the call-graph endpoint queries for the traces of functions,
+	// either jaeger or tempo. Value is set via the trace_backend environment variable, defaults
+	// to jaeger.
+	TraceBackend string
+
+	// TraceQueryURL is the base URL of the trace backend, the call-graph endpoint is only
+	// registered when it is set. Value is set via the trace_query_url environment variable.
+	TraceQueryURL string
+
+	// TraceService is the service whose traces are read from Jaeger, it should be the service
+	// that invokes functions. Value is set via the trace_service environment variable, defaults
+	// to gateway.
+	TraceService string
+
+	// ProxyLoadBalancer selects how the proxy picks an endpoint of a function, either random or
+	// least-connections. Functions can override it with the com.openfaas.load-balancer
+	// annotation. Value is set via the proxy_load_balancer environment variable, defaults to
+	// random.
+	ProxyLoadBalancer string
+
+	// DeployMode is either apply, where functions are created in the cluster, or render, where
+	// their manifests are returned to the caller instead. Value is set via the deploy_mode
+	// environment variable, defaults to apply.
+	DeployMode string
+
+	// RenderConfigMap is the name of a ConfigMap in the function's namespace that rendered
+	// manifests are also written to, for a GitOps tool to pick up. Value is set via the
+	// render_configmap environment variable.
+	RenderConfigMap string
+
+	// PSAEnforceLevel is the Pod Security Standard, one of privileged, baseline or restricted,
+	// that is set with the pod-security.kubernetes.io/enforce label on function namespaces
+	// which do not already have it. Value is set via the psa_enforce_level environment variable.
+	PSAEnforceLevel string
+
+	// FunctionsDir is a directory of YAML or JSON function files that are kept deployed, so that
+	// functions can be managed without the gateway. Value is set via the functions_dir
+	// environment variable, when empty no directory is watched.
+	FunctionsDir string
+
+	// StagingTTL is the age after which staging clones of functions are deleted by the staging
+	// cleanup endpoint. Value is set via the staging_ttl environment variable, defaults to 24h.
+	StagingTTL time.Duration
+
+	// DrainDelay is how long the green Deployment of a blue/green deploy is kept after traffic
+	// has been switched away from it, it is deleted by the expiry sweeper. Value is set via the
+	// drain_delay environment variable, defaults to 5m.
+	DrainDelay time.Duration
+
+	// BlueGreenReadyTimeout is how long a blue/green deploy waits for the new Deployment to
+	// become ready before it is abandoned. Value is set via the blue_green_ready_timeout
+	// environment variable, defaults to 2m.
+	BlueGreenReadyTimeout time.Duration
+
+	// FullReconcileInterval is how often the operator compares every Function with its
+	// Deployment, so that Deployments that were changed directly are corrected. Value is set via
+	// the full_reconcile_interval environment variable, defaults to 10m, 0 disables it.
+	FullReconcileInterval time.Duration
+
+	// ExpiryCheckInterval is how often functions are checked for an elapsed com.openfaas.ttl or
+	// com.openfaas.expires-at time. Value is set via the expiry_check_interval environment
+	// variable, defaults to 1m, 0 disables the check.
+	ExpiryCheckInterval time.Duration
+
+	// ExecDeadlineWatchdog deletes the Pods of functions that have been running for longer than
+	// their com.openfaas.exec.max-duration, it needs permission to delete Pods. Value is set via
+	// the exec_deadline_watchdog environment variable, defaults to false.
+	ExecDeadlineWatchdog bool
+
+	// ReconcileWorkers is the number of workers that reconcile Functions in parallel in the
+	// operator. Value is set via the reconcile_workers environment variable, defaults to 1.
+	ReconcileWorkers int
+
+	// ScaleFromZeroGracePeriod is how long a function that has been scaled from zero is reported
+	// as scaling rather than unavailable while it has no ready replicas. Value is set via the
+	// scale_from_zero_grace_period environment variable, defaults to 30s, a value of 0 disables it.
+	ScaleFromZeroGracePeriod time.Duration
+
+	// SecretLockTimeout is how long a secret update waits for a concurrent update of the same
+	// secret to finish before it is rejected with a 423. Value is set via the secret_lock_timeout
+	// environment variable, defaults to 5s, a value of 0 disables the lock.
+	SecretLockTimeout time.Duration
+
+	// ReadyThreshold is the fraction of a function's replicas that must be available for the
+	// function to be reported as ready. Value is set via the ready_threshold environment variable,
+	// defaults to 0 which means a single available replica is enough.
+	ReadyThreshold float64
+
+	// FeatureFlagsConfigMap is the name of a ConfigMap whose boolean keys toggle experimental
+	// features at runtime. Value is set via the feature_flags_configmap environment variable as
+	// either name or namespace/name, when empty no ConfigMap is watched.
+	FeatureFlagsConfigMap string
+
+	// FeatureFlagsNamespace is the namespace of FeatureFlagsConfigMap, defaults to ProfilesNamespace
+	FeatureFlagsNamespace string
+
+	// FeatureFlags are the current values from FeatureFlagsConfigMap
+	FeatureFlags *FeatureFlags `json:"-"`
+
+	// EnableConfigEndpoint exposes the effective configuration, with secrets redacted, at
+	// /system/config. Value is set via the enable_config_endpoint environment variable,
+	// defaults to false.
+	EnableConfigEndpoint bool
+}
+
+// RenderManifests returns true when functions are rendered rather than applied
+func (c BootstrapConfig) RenderManifests() bool {
+	return c.DeployMode == DeployModeRender
 }
 
 // Fprint pretty-prints the config with the stdlib logger. One line per config value.
@@ -144,5 +675,83 @@ func (c BootstrapConfig) Fprint(verbose bool) {
 		log.Printf("LivenessProbeTimeoutSeconds: %d\n", c.LivenessProbeTimeoutSeconds)
 		log.Printf("LivenessProbePeriodSeconds: %d\n", c.LivenessProbePeriodSeconds)
 		log.Printf("ClusterRole: %v\n", c.ClusterRole)
+		log.Printf("ProgressDeadlineSeconds: %d\n", c.ProgressDeadlineSeconds)
+		log.Printf("StartupKubeWaitTimeout: %s\n", c.StartupKubeWaitTimeout)
+		log.Printf("DefaultFunctionAnnotations: %v\n", c.DefaultFunctionAnnotations)
+		log.Printf("DefaultAnnotations: %v\n", c.DefaultAnnotations)
+		log.Printf("DefaultPodLabels: %v\n", c.DefaultPodLabels)
+		log.Printf("BackupAnnotations: %v\n", c.BackupAnnotations)
+		log.Printf("DefaultSchedulerName: %s\n", c.DefaultSchedulerName)
+		log.Printf("NodePoolLabel: %s\n", c.NodePoolLabel)
+		log.Printf("PDBMinAvailable: %s\n", c.PDBMinAvailable)
+		log.Printf("EnablePodMetricsScraping: %v\n", c.EnablePodMetricsScraping)
+		log.Printf("RestartOnSecretChange: %v\n", c.RestartOnSecretChange)
+		log.Printf("StatsD: %s:%d\n", c.StatsDHost, c.StatsDPort)
+		log.Printf("NamespaceLabelPrefix: %s\n", c.NamespaceLabelPrefix)
+		log.Printf("DisableLocalProfiles: %v\n", c.DisableLocalProfiles)
+		log.Printf("MeshInjectDisableAnnotations: %v\n", c.MeshInjectDisableAnnotations)
+		log.Printf("ServiceMesh: %s\n", c.ServiceMesh)
+		log.Printf("MaxFunctionNameLength: %d\n", c.MaxFunctionNameLength)
+		log.Printf("MaxReplicasPerFunction: %d\n", c.MaxReplicasPerFunction)
+		log.Printf("MaxFunctionsPerNamespace: %d\n", c.MaxFunctionsPerNamespace)
+		log.Printf("FunctionQuotaConfigMap: %s\n", c.FunctionQuotaConfigMap)
+		log.Printf("UpdateConflictRetries: %d\n", c.UpdateConflictRetries)
+		log.Printf("UpdateWaitTimeout: %s\n", c.UpdateWaitTimeout)
+		log.Printf("ImageNameRegex: %v\n", c.ImageNameRegex)
+		log.Printf("ImageCheck: %v\n", c.ImageCheck)
+		log.Printf("ImageCheckTimeout: %s\n", c.ImageCheckTimeout)
+		log.Printf("CacheRefreshEndpoint: %v\n", len(c.CacheRefreshToken) > 0)
+		log.Printf("AutoZoneSpread: %v\n", c.AutoZoneSpread)
+		log.Printf("CertManagerIssuer: %s/%s\n", c.CertManagerIssuerKind, c.CertManagerIssuerName)
+		log.Printf("AllowedUnsafeSysctls: %v\n", c.AllowedUnsafeSysctls)
+		log.Printf("ProfilesDetailLevel: %s\n", c.ProfilesDetailLevel)
+		log.Printf("LogBackend: %s\n", c.LogBackend)
+		log.Printf("ProxyLoadBalancer: %s\n", c.ProxyLoadBalancer)
+		log.Printf("LokiURL: %s\n", c.LokiURL)
+		log.Printf("TraceBackend: %s\n", c.TraceBackend)
+		log.Printf("TraceQueryURL: %s\n", c.TraceQueryURL)
+		log.Printf("TraceService: %s\n", c.TraceService)
+		log.Printf("DeployMode: %s\n", c.DeployMode)
+		log.Printf("RenderConfigMap: %s\n", c.RenderConfigMap)
+		log.Printf("PSAEnforceLevel: %s\n", c.PSAEnforceLevel)
+		log.Printf("FunctionsDir: %s\n", c.FunctionsDir)
+		log.Printf("ScaleFromZeroGracePeriod: %s\n", c.ScaleFromZeroGracePeriod)
+		log.Printf("FullReconcileInterval: %s\n", c.FullReconcileInterval)
+		log.Printf("ExpiryCheckInterval: %s\n", c.ExpiryCheckInterval)
+		log.Printf("ExecDeadlineWatchdog: %v\n", c.ExecDeadlineWatchdog)
+		log.Printf("ReconcileWorkers: %d\n", c.ReconcileWorkers)
+		log.Printf("StagingTTL: %s\n", c.StagingTTL)
+		log.Printf("DrainDelay: %s\n", c.DrainDelay)
+		log.Printf("BlueGreenReadyTimeout: %s\n", c.BlueGreenReadyTimeout)
+		log.Printf("SecretLockTimeout: %s\n", c.SecretLockTimeout)
+		log.Printf("ReadyThreshold: %v\n", c.ReadyThreshold)
+		log.Printf("EnableConfigEndpoint: %v\n", c.EnableConfigEndpoint)
+		log.Printf("FeatureFlagsConfigMap: %s/%s\n", c.FeatureFlagsNamespace, c.FeatureFlagsConfigMap)
 	}
 }
+
+// parseStringMap parses a JSON object of string values, an empty value returns a nil map
+func parseStringMap(value string) (map[string]string, error) {
+	if len(value) == 0 {
+		return nil, nil
+	}
+
+	res := map[string]string{}
+	if err := json.Unmarshal([]byte(value), &res); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// parseStringList parses a comma separated list, empty items are skipped
+func parseStringList(value string) []string {
+	var res []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); len(item) > 0 {
+			res = append(res, item)
+		}
+	}
+
+	return res
+}
@@ -5,7 +5,9 @@
 package config
 
 import (
+	"reflect"
 	"testing"
+	"time"
 )
 
 type EnvBucket struct {
@@ -102,3 +104,991 @@ func TestRead_ImagePullPolicy_empty(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestRead_ProgressDeadlineSeconds_default(t *testing.T) {
+	defaults := NewEnvBucket()
+
+	readConfig := ReadConfig{}
+	config, err := readConfig.Read(defaults)
+	if err != nil {
+		t.Fatalf("Unexpected error while reading env %s", err.Error())
+	}
+
+	if config.ProgressDeadlineSeconds != 600 {
+		t.Logf("ProgressDeadlineSeconds incorrect, got: %v\n", config.ProgressDeadlineSeconds)
+		t.Fail()
+	}
+}
+
+func TestRead_ProgressDeadlineSeconds_invalid(t *testing.T) {
+	defaults := NewEnvBucket()
+	defaults.Setenv("progress_deadline_seconds", "0")
+
+	readConfig := ReadConfig{}
+	_, err := readConfig.Read(defaults)
+	if err == nil {
+		t.Fatalf("Expected an error for a zero progress_deadline_seconds")
+	}
+}
+
+func TestRead_DefaultFunctionAnnotations(t *testing.T) {
+	defaults := NewEnvBucket()
+	defaults.Setenv("default_function_annotations", `{"com.openfaas.profile":"default"}`)
+
+	readConfig := ReadConfig{}
+	config, err := readConfig.Read(defaults)
+	if err != nil {
+		t.Fatalf("Unexpected error while reading env %s", err.Error())
+	}
+
+	if config.DefaultFunctionAnnotations["com.openfaas.profile"] != "default" {
+		t.Logf("DefaultFunctionAnnotations incorrect, got: %v\n", config.DefaultFunctionAnnotations)
+		t.Fail()
+	}
+}
+
+func TestRead_DefaultFunctionAnnotations_invalid(t *testing.T) {
+	defaults := NewEnvBucket()
+	defaults.Setenv("default_function_annotations", "com.openfaas.profile=default")
+
+	readConfig := ReadConfig{}
+	_, err := readConfig.Read(defaults)
+	if err == nil {
+		t.Fatalf("Expected an error for a non-JSON default_function_annotations")
+	}
+}
+
+func TestRead_FeatureFlagsConfigMap(t *testing.T) {
+	cases := []struct {
+		value         string
+		wantNamespace string
+		wantName      string
+	}{
+		{value: "", wantNamespace: "", wantName: ""},
+		{value: "feature-flags", wantNamespace: "openfaas", wantName: "feature-flags"},
+		{value: "system/feature-flags", wantNamespace: "system", wantName: "feature-flags"},
+	}
+
+	for _, tc := range cases {
+		defaults := NewEnvBucket()
+		defaults.Setenv("profiles_namespace", "openfaas")
+		defaults.Setenv("feature_flags_configmap", tc.value)
+
+		readConfig := ReadConfig{}
+		config, err := readConfig.Read(defaults)
+		if err != nil {
+			t.Fatalf("Unexpected error while reading env %s", err.Error())
+		}
+
+		if config.FeatureFlagsNamespace != tc.wantNamespace || config.FeatureFlagsConfigMap != tc.wantName {
+			t.Errorf("%q: want: %s/%s, got: %s/%s", tc.value, tc.wantNamespace, tc.wantName, config.FeatureFlagsNamespace, config.FeatureFlagsConfigMap)
+		}
+
+		if config.FeatureFlags == nil {
+			t.Errorf("%q: want FeatureFlags to be initialised", tc.value)
+		}
+	}
+}
+
+func TestRead_ScaleFromZeroGracePeriod(t *testing.T) {
+	cases := []struct {
+		value string
+		want  time.Duration
+	}{
+		{value: "", want: time.Second * 30},
+		{value: "45s", want: time.Second * 45},
+		{value: "0", want: 0},
+	}
+
+	for _, tc := range cases {
+		defaults := NewEnvBucket()
+		defaults.Setenv("scale_from_zero_grace_period", tc.value)
+
+		readConfig := ReadConfig{}
+		config, err := readConfig.Read(defaults)
+		if err != nil {
+			t.Fatalf("Unexpected error while reading env %s", err.Error())
+		}
+
+		if config.ScaleFromZeroGracePeriod != tc.want {
+			t.Errorf("%q: want: %s, got: %s", tc.value, tc.want, config.ScaleFromZeroGracePeriod)
+		}
+	}
+}
+
+func TestRead_ProfilesDetailLevel(t *testing.T) {
+	defaults := NewEnvBucket()
+
+	readConfig := ReadConfig{}
+	config, err := readConfig.Read(defaults)
+	if err != nil {
+		t.Fatalf("Unexpected error while reading env %s", err.Error())
+	}
+
+	if config.ProfilesDetailLevel != ProfilesDetailSummary {
+		t.Errorf("want default: %s, got: %s", ProfilesDetailSummary, config.ProfilesDetailLevel)
+	}
+
+	defaults.Setenv("profiles_detail_level", "everything")
+	if _, err := readConfig.Read(defaults); err == nil {
+		t.Fatalf("Expected an error for an invalid profiles_detail_level")
+	}
+}
+
+func TestRead_DefaultAnnotations(t *testing.T) {
+	defaults := NewEnvBucket()
+	defaults.Setenv("default_annotations", `{"vault.hashicorp.com/agent-inject":"true"}`)
+
+	readConfig := ReadConfig{}
+	config, err := readConfig.Read(defaults)
+	if err != nil {
+		t.Fatalf("Unexpected error while reading env %s", err.Error())
+	}
+
+	if config.DefaultAnnotations["vault.hashicorp.com/agent-inject"] != "true" {
+		t.Errorf("DefaultAnnotations incorrect, got: %v", config.DefaultAnnotations)
+	}
+
+	defaults.Setenv("default_annotations", "vault=true")
+	if _, err := readConfig.Read(defaults); err == nil {
+		t.Fatalf("Expected an error for a non-JSON default_annotations")
+	}
+}
+
+func TestRead_FunctionQuota(t *testing.T) {
+	defaults := NewEnvBucket()
+
+	readConfig := ReadConfig{}
+	config, err := readConfig.Read(defaults)
+	if err != nil {
+		t.Fatalf("Unexpected error while reading env %s", err.Error())
+	}
+	if config.MaxFunctionsPerNamespace != 0 {
+		t.Errorf("MaxFunctionsPerNamespace want: %d, got: %d", 0, config.MaxFunctionsPerNamespace)
+	}
+
+	defaults.Setenv("max_functions_per_namespace", "20")
+	defaults.Setenv("function_quota_configmap", "function-quotas")
+	config, err = readConfig.Read(defaults)
+	if err != nil {
+		t.Fatalf("Unexpected error while reading env %s", err.Error())
+	}
+	if config.MaxFunctionsPerNamespace != 20 {
+		t.Errorf("MaxFunctionsPerNamespace want: %d, got: %d", 20, config.MaxFunctionsPerNamespace)
+	}
+	if config.FunctionQuotaConfigMap != "function-quotas" {
+		t.Errorf("FunctionQuotaConfigMap want: %s, got: %s", "function-quotas", config.FunctionQuotaConfigMap)
+	}
+}
+
+func TestRead_UpdateConflictRetries(t *testing.T) {
+	defaults := NewEnvBucket()
+
+	readConfig := ReadConfig{}
+	config, err := readConfig.Read(defaults)
+	if err != nil {
+		t.Fatalf("Unexpected error while reading env %s", err.Error())
+	}
+	if config.UpdateConflictRetries != 5 {
+		t.Errorf("UpdateConflictRetries want: %d, got: %d", 5, config.UpdateConflictRetries)
+	}
+
+	defaults.Setenv("update_conflict_retries", "0")
+	config, err = readConfig.Read(defaults)
+	if err != nil {
+		t.Fatalf("Unexpected error while reading env %s", err.Error())
+	}
+	if config.UpdateConflictRetries != 0 {
+		t.Errorf("UpdateConflictRetries want: %d, got: %d", 0, config.UpdateConflictRetries)
+	}
+}
+
+func TestRead_UpdateWaitTimeout(t *testing.T) {
+	defaults := NewEnvBucket()
+
+	readConfig := ReadConfig{}
+	config, err := readConfig.Read(defaults)
+	if err != nil {
+		t.Fatalf("Unexpected error while reading env %s", err.Error())
+	}
+	if config.UpdateWaitTimeout != time.Second*120 {
+		t.Errorf("UpdateWaitTimeout want: %s, got: %s", time.Second*120, config.UpdateWaitTimeout)
+	}
+
+	defaults.Setenv("update_wait_timeout", "30s")
+	config, err = readConfig.Read(defaults)
+	if err != nil {
+		t.Fatalf("Unexpected error while reading env %s", err.Error())
+	}
+	if config.UpdateWaitTimeout != time.Second*30 {
+		t.Errorf("UpdateWaitTimeout want: %s, got: %s", time.Second*30, config.UpdateWaitTimeout)
+	}
+}
+
+func TestRead_ServiceMesh(t *testing.T) {
+	cases := []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{value: "", want: ServiceMeshNone},
+		{value: "none", want: ServiceMeshNone},
+		{value: "linkerd", want: ServiceMeshLinkerd},
+		{value: "istio", want: ServiceMeshIstio},
+		{value: "consul", wantErr: true},
+	}
+
+	for _, tc := range cases {
+		defaults := NewEnvBucket()
+		defaults.Setenv("service_mesh", tc.value)
+
+		readConfig := ReadConfig{}
+		config, err := readConfig.Read(defaults)
+		if tc.wantErr != (err != nil) {
+			t.Errorf("%q: want error: %v, got: %v", tc.value, tc.wantErr, err)
+			continue
+		}
+		if !tc.wantErr && config.ServiceMesh != tc.want {
+			t.Errorf("%q: ServiceMesh want: %s, got: %s", tc.value, tc.want, config.ServiceMesh)
+		}
+	}
+}
+
+func TestRead_ReconcileWorkers(t *testing.T) {
+	defaults := NewEnvBucket()
+
+	readConfig := ReadConfig{}
+	config, err := readConfig.Read(defaults)
+	if err != nil {
+		t.Fatalf("Unexpected error while reading env %s", err.Error())
+	}
+	if config.ReconcileWorkers != 1 {
+		t.Errorf("ReconcileWorkers want: %d, got: %d", 1, config.ReconcileWorkers)
+	}
+
+	defaults.Setenv("reconcile_workers", "4")
+	config, err = readConfig.Read(defaults)
+	if err != nil {
+		t.Fatalf("Unexpected error while reading env %s", err.Error())
+	}
+	if config.ReconcileWorkers != 4 {
+		t.Errorf("ReconcileWorkers want: %d, got: %d", 4, config.ReconcileWorkers)
+	}
+
+	defaults.Setenv("reconcile_workers", "0")
+	if _, err = readConfig.Read(defaults); err == nil {
+		t.Errorf("ReconcileWorkers want error for 0 workers")
+	}
+}
+
+func TestRead_ImageNameRegex(t *testing.T) {
+	defaults := NewEnvBucket()
+
+	readConfig := ReadConfig{}
+	config, err := readConfig.Read(defaults)
+	if err != nil {
+		t.Fatalf("Unexpected error while reading env %s", err.Error())
+	}
+	if config.ImageNameRegex != nil {
+		t.Errorf("ImageNameRegex want: nil, got: %s", config.ImageNameRegex)
+	}
+
+	defaults.Setenv("image_name_regex", `^registry\.example\.com/`)
+	config, err = readConfig.Read(defaults)
+	if err != nil {
+		t.Fatalf("Unexpected error while reading env %s", err.Error())
+	}
+	if config.ImageNameRegex == nil || !config.ImageNameRegex.MatchString("registry.example.com/figlet:0.1.0") {
+		t.Errorf("ImageNameRegex want match for registry.example.com/figlet:0.1.0, got: %v", config.ImageNameRegex)
+	}
+
+	defaults.Setenv("image_name_regex", "(")
+	if _, err = readConfig.Read(defaults); err == nil {
+		t.Errorf("ImageNameRegex want error for an invalid pattern")
+	}
+}
+
+func TestRead_ImageCheck(t *testing.T) {
+	defaults := NewEnvBucket()
+
+	readConfig := ReadConfig{}
+	config, err := readConfig.Read(defaults)
+	if err != nil {
+		t.Fatalf("Unexpected error while reading env %s", err.Error())
+	}
+	if config.ImageCheck {
+		t.Errorf("ImageCheck want: false, got: %v", config.ImageCheck)
+	}
+	if config.ImageCheckTimeout != time.Second*5 {
+		t.Errorf("ImageCheckTimeout want: %s, got: %s", time.Second*5, config.ImageCheckTimeout)
+	}
+
+	defaults.Setenv("image_check", "true")
+	defaults.Setenv("image_check_timeout", "2s")
+	config, err = readConfig.Read(defaults)
+	if err != nil {
+		t.Fatalf("Unexpected error while reading env %s", err.Error())
+	}
+	if !config.ImageCheck {
+		t.Errorf("ImageCheck want: true, got: %v", config.ImageCheck)
+	}
+	if config.ImageCheckTimeout != time.Second*2 {
+		t.Errorf("ImageCheckTimeout want: %s, got: %s", time.Second*2, config.ImageCheckTimeout)
+	}
+}
+
+func TestRead_CacheRefreshToken(t *testing.T) {
+	defaults := NewEnvBucket()
+
+	readConfig := ReadConfig{}
+	config, err := readConfig.Read(defaults)
+	if err != nil {
+		t.Fatalf("Unexpected error while reading env %s", err.Error())
+	}
+	if config.CacheRefreshToken != "" {
+		t.Errorf("CacheRefreshToken want: empty, got: %q", config.CacheRefreshToken)
+	}
+
+	defaults.Setenv("cache_refresh_token", "s3cr3t")
+	config, err = readConfig.Read(defaults)
+	if err != nil {
+		t.Fatalf("Unexpected error while reading env %s", err.Error())
+	}
+	if config.CacheRefreshToken != "s3cr3t" {
+		t.Errorf("CacheRefreshToken want: %q, got: %q", "s3cr3t", config.CacheRefreshToken)
+	}
+}
+
+func TestRead_DefaultPodLabels(t *testing.T) {
+	defaults := NewEnvBucket()
+	defaults.Setenv("default_pod_labels", `{"team":"platform","env":"prod"}`)
+
+	readConfig := ReadConfig{}
+	config, err := readConfig.Read(defaults)
+	if err != nil {
+		t.Fatalf("Unexpected error while reading env %s", err.Error())
+	}
+
+	want := map[string]string{"team": "platform", "env": "prod"}
+	if !reflect.DeepEqual(config.DefaultPodLabels, want) {
+		t.Errorf("DefaultPodLabels want: %v, got: %v", want, config.DefaultPodLabels)
+	}
+
+	defaults.Setenv("default_pod_labels", "team=platform")
+	if _, err := readConfig.Read(defaults); err == nil {
+		t.Fatalf("Expected an error for a non-JSON default_pod_labels")
+	}
+}
+
+func TestRead_ProxyLoadBalancer(t *testing.T) {
+	cases := []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{value: "", want: ProxyLoadBalancerRandom},
+		{value: "random", want: ProxyLoadBalancerRandom},
+		{value: "least-connections", want: ProxyLoadBalancerLeastConnections},
+		{value: "round-robin", wantErr: true},
+	}
+
+	for _, tc := range cases {
+		defaults := NewEnvBucket()
+		defaults.Setenv("proxy_load_balancer", tc.value)
+
+		readConfig := ReadConfig{}
+		config, err := readConfig.Read(defaults)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("%q: want an error", tc.value)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Unexpected error while reading env %s", err.Error())
+		}
+
+		if config.ProxyLoadBalancer != tc.want {
+			t.Errorf("%q: want: %s, got: %s", tc.value, tc.want, config.ProxyLoadBalancer)
+		}
+	}
+}
+
+func TestRead_BackupAnnotations(t *testing.T) {
+	defaults := NewEnvBucket()
+	defaults.Setenv("backup_annotations", `{"backup.velero.io/backup-volumes":"{{volumes}}"}`)
+
+	readConfig := ReadConfig{}
+	config, err := readConfig.Read(defaults)
+	if err != nil {
+		t.Fatalf("Unexpected error while reading env %s", err.Error())
+	}
+
+	want := map[string]string{"backup.velero.io/backup-volumes": "{{volumes}}"}
+	if !reflect.DeepEqual(config.BackupAnnotations, want) {
+		t.Errorf("BackupAnnotations want: %v, got: %v", want, config.BackupAnnotations)
+	}
+
+	defaults.Setenv("backup_annotations", "backup.velero.io/backup-volumes=temp")
+	if _, err := readConfig.Read(defaults); err == nil {
+		t.Fatalf("Expected an error for a non-JSON backup_annotations")
+	}
+}
+
+func TestRead_DefaultSchedulerName(t *testing.T) {
+	defaults := NewEnvBucket()
+	defaults.Setenv("default_scheduler_name", "volcano")
+
+	readConfig := ReadConfig{}
+	config, err := readConfig.Read(defaults)
+	if err != nil {
+		t.Fatalf("Unexpected error while reading env %s", err.Error())
+	}
+
+	if config.DefaultSchedulerName != "volcano" {
+		t.Errorf("DefaultSchedulerName want: %s, got: %s", "volcano", config.DefaultSchedulerName)
+	}
+}
+
+func TestRead_NodePoolLabel(t *testing.T) {
+	defaults := NewEnvBucket()
+	defaults.Setenv("node_pool_label", "cloud.google.com/gke-nodepool")
+
+	readConfig := ReadConfig{}
+	config, err := readConfig.Read(defaults)
+	if err != nil {
+		t.Fatalf("Unexpected error while reading env %s", err.Error())
+	}
+
+	if config.NodePoolLabel != "cloud.google.com/gke-nodepool" {
+		t.Errorf("NodePoolLabel want: %s, got: %s", "cloud.google.com/gke-nodepool", config.NodePoolLabel)
+	}
+}
+
+func TestRead_PDBMinAvailable(t *testing.T) {
+	defaults := NewEnvBucket()
+	defaults.Setenv("pdb_min_available", "50%")
+
+	readConfig := ReadConfig{}
+	config, err := readConfig.Read(defaults)
+	if err != nil {
+		t.Fatalf("Unexpected error while reading env %s", err.Error())
+	}
+
+	if config.PDBMinAvailable != "50%" {
+		t.Errorf("PDBMinAvailable want: %s, got: %s", "50%", config.PDBMinAvailable)
+	}
+}
+
+func TestRead_EnablePodMetricsScraping(t *testing.T) {
+	defaults := NewEnvBucket()
+	defaults.Setenv("enable_pod_metrics_scraping", "true")
+
+	readConfig := ReadConfig{}
+	config, err := readConfig.Read(defaults)
+	if err != nil {
+		t.Fatalf("Unexpected error while reading env %s", err.Error())
+	}
+
+	if !config.EnablePodMetricsScraping {
+		t.Errorf("EnablePodMetricsScraping want: %v, got: %v", true, config.EnablePodMetricsScraping)
+	}
+}
+
+func TestRead_RestartOnSecretChange(t *testing.T) {
+	cases := []struct {
+		value string
+		want  bool
+	}{
+		{value: "", want: false},
+		{value: "true", want: true},
+		{value: "false", want: false},
+	}
+
+	for _, tc := range cases {
+		defaults := NewEnvBucket()
+		defaults.Setenv("restart_on_secret_change", tc.value)
+
+		readConfig := ReadConfig{}
+		config, err := readConfig.Read(defaults)
+		if err != nil {
+			t.Fatalf("Unexpected error while reading env %s", err.Error())
+		}
+
+		if config.RestartOnSecretChange != tc.want {
+			t.Errorf("%q: want: %v, got: %v", tc.value, tc.want, config.RestartOnSecretChange)
+		}
+	}
+}
+
+func TestRead_StatsD(t *testing.T) {
+	defaults := NewEnvBucket()
+
+	readConfig := ReadConfig{}
+	config, err := readConfig.Read(defaults)
+	if err != nil {
+		t.Fatalf("Unexpected error while reading env %s", err.Error())
+	}
+	if config.StatsDHost != "" || config.StatsDPort != 8125 {
+		t.Errorf("StatsD want: %s:%d, got: %s:%d", "", 8125, config.StatsDHost, config.StatsDPort)
+	}
+
+	defaults.Setenv("statsd_host", "statsd.monitoring")
+	defaults.Setenv("statsd_port", "9125")
+	config, err = readConfig.Read(defaults)
+	if err != nil {
+		t.Fatalf("Unexpected error while reading env %s", err.Error())
+	}
+	if config.StatsDHost != "statsd.monitoring" || config.StatsDPort != 9125 {
+		t.Errorf("StatsD want: %s:%d, got: %s:%d", "statsd.monitoring", 9125, config.StatsDHost, config.StatsDPort)
+	}
+}
+
+func TestRead_NamespaceLabelPrefix(t *testing.T) {
+	defaults := NewEnvBucket()
+	defaults.Setenv("namespace_label_prefix", "billing.example.com/")
+
+	readConfig := ReadConfig{}
+	config, err := readConfig.Read(defaults)
+	if err != nil {
+		t.Fatalf("Unexpected error while reading env %s", err.Error())
+	}
+
+	if config.NamespaceLabelPrefix != "billing.example.com/" {
+		t.Errorf("NamespaceLabelPrefix want: %s, got: %s", "billing.example.com/", config.NamespaceLabelPrefix)
+	}
+}
+
+func TestRead_DisableLocalProfiles(t *testing.T) {
+	defaults := NewEnvBucket()
+
+	readConfig := ReadConfig{}
+	config, err := readConfig.Read(defaults)
+	if err != nil {
+		t.Fatalf("Unexpected error while reading env %s", err.Error())
+	}
+	if config.DisableLocalProfiles {
+		t.Errorf("DisableLocalProfiles want: %v, got: %v", false, config.DisableLocalProfiles)
+	}
+
+	defaults.Setenv("disable_local_profiles", "true")
+	config, err = readConfig.Read(defaults)
+	if err != nil {
+		t.Fatalf("Unexpected error while reading env %s", err.Error())
+	}
+	if !config.DisableLocalProfiles {
+		t.Errorf("DisableLocalProfiles want: %v, got: %v", true, config.DisableLocalProfiles)
+	}
+}
+
+func TestRead_ReadyThreshold(t *testing.T) {
+	cases := []struct {
+		value   string
+		want    float64
+		wantErr bool
+	}{
+		{value: "", want: 0},
+		{value: "0.5", want: 0.5},
+		{value: "1.5", wantErr: true},
+		{value: "half", wantErr: true},
+	}
+
+	for _, tc := range cases {
+		defaults := NewEnvBucket()
+		defaults.Setenv("ready_threshold", tc.value)
+
+		readConfig := ReadConfig{}
+		config, err := readConfig.Read(defaults)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("%q: want error, got nil", tc.value)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Unexpected error while reading env %s", err.Error())
+		}
+
+		if config.ReadyThreshold != tc.want {
+			t.Errorf("%q: want: %v, got: %v", tc.value, tc.want, config.ReadyThreshold)
+		}
+	}
+}
+
+func TestRead_AutoZoneSpread(t *testing.T) {
+	cases := []struct {
+		value string
+		want  bool
+	}{
+		{value: "", want: true},
+		{value: "false", want: false},
+		{value: "true", want: true},
+	}
+
+	for _, tc := range cases {
+		defaults := NewEnvBucket()
+		defaults.Setenv("auto_zone_spread", tc.value)
+
+		readConfig := ReadConfig{}
+		config, err := readConfig.Read(defaults)
+		if err != nil {
+			t.Fatalf("Unexpected error while reading env %s", err.Error())
+		}
+
+		if config.AutoZoneSpread != tc.want {
+			t.Errorf("%q: want: %v, got: %v", tc.value, tc.want, config.AutoZoneSpread)
+		}
+	}
+}
+
+func TestRead_LogBackend(t *testing.T) {
+	cases := []struct {
+		backend string
+		lokiURL string
+		want    string
+		wantErr bool
+	}{
+		{backend: "", want: LogBackendKubernetes},
+		{backend: "loki", lokiURL: "http://loki.monitoring:3100", want: LogBackendLoki},
+		{backend: "loki", wantErr: true},
+		{backend: "elasticsearch", wantErr: true},
+	}
+
+	for _, tc := range cases {
+		defaults := NewEnvBucket()
+		defaults.Setenv("log_backend", tc.backend)
+		defaults.Setenv("loki_url", tc.lokiURL)
+
+		readConfig := ReadConfig{}
+		config, err := readConfig.Read(defaults)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("%q: want error, got nil", tc.backend)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Unexpected error while reading env %s", err.Error())
+		}
+
+		if config.LogBackend != tc.want {
+			t.Errorf("%q: want: %s, got: %s", tc.backend, tc.want, config.LogBackend)
+		}
+		if config.LokiURL != tc.lokiURL {
+			t.Errorf("%q: want LokiURL: %s, got: %s", tc.backend, tc.lokiURL, config.LokiURL)
+		}
+	}
+}
+
+func TestRead_TraceBackend(t *testing.T) {
+	cases := []struct {
+		backend     string
+		service     string
+		want        string
+		wantService string
+		wantErr     bool
+	}{
+		{backend: "", want: TraceBackendJaeger, wantService: "gateway"},
+		{backend: "jaeger", service: "edge", want: TraceBackendJaeger, wantService: "edge"},
+		{backend: "tempo", want: TraceBackendTempo, wantService: "gateway"},
+		{backend: "zipkin", wantErr: true},
+	}
+
+	for _, tc := range cases {
+		defaults := NewEnvBucket()
+		defaults.Setenv("trace_backend", tc.backend)
+		defaults.Setenv("trace_service", tc.service)
+		defaults.Setenv("trace_query_url", "http://jaeger-query.monitoring:16686")
+
+		readConfig := ReadConfig{}
+		config, err := readConfig.Read(defaults)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("%q: want error, got nil", tc.backend)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Unexpected error while reading env %s", err.Error())
+		}
+
+		if config.TraceBackend != tc.want {
+			t.Errorf("%q: want: %s, got: %s", tc.backend, tc.want, config.TraceBackend)
+		}
+		if config.TraceService != tc.wantService {
+			t.Errorf("%q: want TraceService: %s, got: %s", tc.backend, tc.wantService, config.TraceService)
+		}
+		if config.TraceQueryURL != "http://jaeger-query.monitoring:16686" {
+			t.Errorf("%q: want TraceQueryURL to be set, got: %s", tc.backend, config.TraceQueryURL)
+		}
+	}
+}
+
+func TestRead_MaxReplicasPerFunction(t *testing.T) {
+	cases := []struct {
+		value string
+		want  int
+	}{
+		{value: "", want: 0},
+		{value: "20", want: 20},
+		{value: "-1", want: 0},
+	}
+
+	for _, tc := range cases {
+		defaults := NewEnvBucket()
+		defaults.Setenv("max_replicas_per_function", tc.value)
+
+		readConfig := ReadConfig{}
+		config, err := readConfig.Read(defaults)
+		if err != nil {
+			t.Fatalf("Unexpected error while reading env %s", err.Error())
+		}
+
+		if config.MaxReplicasPerFunction != tc.want {
+			t.Errorf("%q: want: %d, got: %d", tc.value, tc.want, config.MaxReplicasPerFunction)
+		}
+	}
+}
+
+func TestRead_MaxFunctionNameLength(t *testing.T) {
+	cases := []struct {
+		value string
+		want  int
+	}{
+		{value: "", want: 63},
+		{value: "100", want: 100},
+		{value: "0", want: 0},
+	}
+
+	for _, tc := range cases {
+		defaults := NewEnvBucket()
+		defaults.Setenv("max_function_name_length", tc.value)
+
+		readConfig := ReadConfig{}
+		config, err := readConfig.Read(defaults)
+		if err != nil {
+			t.Fatalf("Unexpected error while reading env %s", err.Error())
+		}
+
+		if config.MaxFunctionNameLength != tc.want {
+			t.Errorf("%q: want: %d, got: %d", tc.value, tc.want, config.MaxFunctionNameLength)
+		}
+	}
+}
+
+func TestRead_AllowedUnsafeSysctls(t *testing.T) {
+	defaults := NewEnvBucket()
+	defaults.Setenv("allowed_unsafe_sysctls", "net.core.somaxconn, net.ipv4.tcp_*,")
+
+	readConfig := ReadConfig{}
+	config, err := readConfig.Read(defaults)
+	if err != nil {
+		t.Fatalf("Unexpected error while reading env %s", err.Error())
+	}
+
+	want := []string{"net.core.somaxconn", "net.ipv4.tcp_*"}
+	if !reflect.DeepEqual(config.AllowedUnsafeSysctls, want) {
+		t.Errorf("want: %v, got: %v", want, config.AllowedUnsafeSysctls)
+	}
+}
+
+func TestRead_ExecDeadlineWatchdog(t *testing.T) {
+	cases := []struct {
+		value string
+		want  bool
+	}{
+		{value: "", want: false},
+		{value: "true", want: true},
+		{value: "false", want: false},
+	}
+
+	for _, tc := range cases {
+		defaults := NewEnvBucket()
+		defaults.Setenv("exec_deadline_watchdog", tc.value)
+
+		readConfig := ReadConfig{}
+		config, err := readConfig.Read(defaults)
+		if err != nil {
+			t.Fatalf("Unexpected error while reading env %s", err.Error())
+		}
+
+		if config.ExecDeadlineWatchdog != tc.want {
+			t.Errorf("%q: want: %v, got: %v", tc.value, tc.want, config.ExecDeadlineWatchdog)
+		}
+	}
+}
+
+func TestRead_SecretLockTimeout(t *testing.T) {
+	cases := []struct {
+		value string
+		want  time.Duration
+	}{
+		{value: "", want: time.Second * 5},
+		{value: "10s", want: time.Second * 10},
+		{value: "0", want: 0},
+	}
+
+	for _, tc := range cases {
+		defaults := NewEnvBucket()
+		defaults.Setenv("secret_lock_timeout", tc.value)
+
+		readConfig := ReadConfig{}
+		config, err := readConfig.Read(defaults)
+		if err != nil {
+			t.Fatalf("Unexpected error while reading env %s", err.Error())
+		}
+
+		if config.SecretLockTimeout != tc.want {
+			t.Errorf("%q: want: %s, got: %s", tc.value, tc.want, config.SecretLockTimeout)
+		}
+	}
+}
+
+func TestRead_StagingTTL(t *testing.T) {
+	cases := []struct {
+		value string
+		want  time.Duration
+	}{
+		{value: "", want: time.Hour * 24},
+		{value: "2h", want: time.Hour * 2},
+	}
+
+	for _, tc := range cases {
+		defaults := NewEnvBucket()
+		defaults.Setenv("staging_ttl", tc.value)
+
+		readConfig := ReadConfig{}
+		config, err := readConfig.Read(defaults)
+		if err != nil {
+			t.Fatalf("Unexpected error while reading env %s", err.Error())
+		}
+
+		if config.StagingTTL != tc.want {
+			t.Errorf("%q: want: %s, got: %s", tc.value, tc.want, config.StagingTTL)
+		}
+	}
+}
+
+func TestRead_BlueGreen(t *testing.T) {
+	defaults := NewEnvBucket()
+	readConfig := ReadConfig{}
+	config, err := readConfig.Read(defaults)
+	if err != nil {
+		t.Fatalf("Unexpected error while reading env %s", err.Error())
+	}
+
+	if config.DrainDelay != time.Minute*5 {
+		t.Errorf("want default drain delay: %s, got: %s", time.Minute*5, config.DrainDelay)
+	}
+	if config.BlueGreenReadyTimeout != time.Minute*2 {
+		t.Errorf("want default ready timeout: %s, got: %s", time.Minute*2, config.BlueGreenReadyTimeout)
+	}
+
+	defaults.Setenv("drain_delay", "30s")
+	defaults.Setenv("blue_green_ready_timeout", "5m")
+	config, err = readConfig.Read(defaults)
+	if err != nil {
+		t.Fatalf("Unexpected error while reading env %s", err.Error())
+	}
+
+	if config.DrainDelay != time.Second*30 {
+		t.Errorf("want drain delay: %s, got: %s", time.Second*30, config.DrainDelay)
+	}
+	if config.BlueGreenReadyTimeout != time.Minute*5 {
+		t.Errorf("want ready timeout: %s, got: %s", time.Minute*5, config.BlueGreenReadyTimeout)
+	}
+}
+
+func TestRead_FullReconcileInterval(t *testing.T) {
+	cases := []struct {
+		value string
+		want  time.Duration
+	}{
+		{value: "", want: time.Minute * 10},
+		{value: "2m", want: time.Minute * 2},
+		{value: "0", want: 0},
+	}
+
+	for _, tc := range cases {
+		defaults := NewEnvBucket()
+		defaults.Setenv("full_reconcile_interval", tc.value)
+
+		readConfig := ReadConfig{}
+		config, err := readConfig.Read(defaults)
+		if err != nil {
+			t.Fatalf("Unexpected error while reading env %s", err.Error())
+		}
+
+		if config.FullReconcileInterval != tc.want {
+			t.Errorf("%q: want: %s, got: %s", tc.value, tc.want, config.FullReconcileInterval)
+		}
+	}
+}
+
+func TestRead_DeployMode(t *testing.T) {
+	cases := []struct {
+		value   string
+		want    string
+		render  bool
+		wantErr bool
+	}{
+		{value: "", want: DeployModeApply},
+		{value: "render", want: DeployModeRender, render: true},
+		{value: "gitops", wantErr: true},
+	}
+
+	for _, tc := range cases {
+		defaults := NewEnvBucket()
+		defaults.Setenv("deploy_mode", tc.value)
+
+		readConfig := ReadConfig{}
+		config, err := readConfig.Read(defaults)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("%q: want error, got nil", tc.value)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Unexpected error while reading env %s", err.Error())
+		}
+
+		if config.DeployMode != tc.want || config.RenderManifests() != tc.render {
+			t.Errorf("%q: want: %s, got: %s", tc.value, tc.want, config.DeployMode)
+		}
+	}
+}
+
+func TestRead_PSAEnforceLevel(t *testing.T) {
+	cases := []struct {
+		value   string
+		wantErr bool
+	}{
+		{value: ""},
+		{value: "privileged"},
+		{value: "baseline"},
+		{value: "restricted"},
+		{value: "strict", wantErr: true},
+	}
+
+	for _, tc := range cases {
+		defaults := NewEnvBucket()
+		defaults.Setenv("psa_enforce_level", tc.value)
+
+		readConfig := ReadConfig{}
+		config, err := readConfig.Read(defaults)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("%q: want error, got nil", tc.value)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Unexpected error while reading env %s", err.Error())
+		}
+
+		if config.PSAEnforceLevel != tc.value {
+			t.Errorf("want: %q, got: %q", tc.value, config.PSAEnforceLevel)
+		}
+	}
+}
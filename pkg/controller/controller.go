@@ -28,6 +28,7 @@ import (
 	faasscheme "github.com/openfaas/faas-netes/pkg/client/clientset/versioned/scheme"
 	informers "github.com/openfaas/faas-netes/pkg/client/informers/externalversions"
 	listers "github.com/openfaas/faas-netes/pkg/client/listers/openfaas/v1"
+	"github.com/openfaas/faas-netes/pkg/k8s"
 )
 
 const (
@@ -41,6 +42,10 @@ const (
 	// to sync due to a Deployment of the same name already existing.
 	ErrResourceExists = "ErrResourceExists"
 
+	// ErrSyncFailed is used as part of the Event 'reason' when a Function fails to sync,
+	// the message of the Event is the error
+	ErrSyncFailed = "SyncFailed"
+
 	// MessageResourceExists is the message used for Events when a resource
 	// fails to sync due to a Deployment already existing
 	MessageResourceExists = "Resource %q already exists and is not managed by OpenFaaS"
@@ -158,7 +163,8 @@ func (c *Controller) Run(threadiness int, stopCh <-chan struct{}) error {
 	}
 
 	glog.Info("Starting workers")
-	// Launch two workers to process Function resources
+	// Launch threadiness workers to process Function resources, the workqueue never hands the
+	// same key to two workers at once
 	for i := 0; i < threadiness; i++ {
 		go wait.Until(c.runWorker, time.Second, stopCh)
 	}
@@ -196,6 +202,7 @@ func (c *Controller) processNextWorkItem() bool {
 			return nil
 		}
 		if err := c.syncHandler(key); err != nil {
+			c.recordSyncError(key, err)
 			return fmt.Errorf("error syncing '%s': %s", key, err.Error())
 		}
 		c.workqueue.Forget(obj)
@@ -210,6 +217,26 @@ func (c *Controller) processNextWorkItem() bool {
 	return true
 }
 
+// recordSyncError records the error of a failed sync as an Event on the Function, so that the
+// outcome of the last reconcile can be looked up through the API
+func (c *Controller) recordSyncError(key string, err error) {
+	if c.recorder == nil {
+		return
+	}
+
+	namespace, name, splitErr := cache.SplitMetaNamespaceKey(key)
+	if splitErr != nil {
+		return
+	}
+
+	function, getErr := c.functionsLister.Functions(namespace).Get(name)
+	if getErr != nil {
+		return
+	}
+
+	c.recorder.Event(function, corev1.EventTypeWarning, ErrSyncFailed, err.Error())
+}
+
 // syncHandler compares the actual state with the desired, and attempts to
 // converge the two.
 func (c *Controller) syncHandler(key string) error {
@@ -241,6 +268,16 @@ func (c *Controller) syncHandler(key string) error {
 		return nil
 	}
 
+	// Stateful functions are run as a StatefulSet instead of a Deployment
+	if function.Spec.Annotations != nil && k8s.IsStateful(*function.Spec.Annotations) {
+		if err := c.syncStatefulSet(function); err != nil {
+			return err
+		}
+
+		c.recorder.Event(function, corev1.EventTypeNormal, SuccessSynced, MessageResourceSynced)
+		return nil
+	}
+
 	// Get the deployment with the name specified in Function.spec
 	deployment, err := c.deploymentsLister.Deployments(function.Namespace).Get(deploymentName)
 	// If the resource doesn't exist, we'll create it
@@ -251,6 +288,11 @@ func (c *Controller) syncHandler(key string) error {
 			return err
 		}
 
+		// a function that was stateful has a StatefulSet, which is replaced
+		if err := c.deleteOwnedStatefulSet(context.TODO(), function); err != nil {
+			return err
+		}
+
 		glog.Infof("Creating deployment for '%s'", function.Spec.Name)
 		deployment, err = c.kubeclientset.AppsV1().Deployments(function.Namespace).Create(
 			context.TODO(),
@@ -292,8 +334,9 @@ func (c *Controller) syncHandler(key string) error {
 		return fmt.Errorf(msg)
 	}
 
-	// Update the Deployment resource if the Function definition differs
-	if deploymentNeedsUpdate(function, deployment) {
+	// Update the Deployment resource if the Function definition differs, or the Deployment
+	// was changed directly
+	if deploymentNeedsUpdate(function, deployment) || len(deploymentDrift(function, deployment)) > 0 {
 		glog.Infof("Updating deployment for '%s'", function.Spec.Name)
 
 		existingSecrets, err := c.getSecrets(function.Namespace, function.Spec.Secrets)
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"strings"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	faasv1 "github.com/openfaas/faas-netes/pkg/apis/openfaas/v1"
@@ -46,7 +47,22 @@ func newDeployment(
 	}
 
 	annotations := makeAnnotations(function)
-  
+	annotations = factory.Factory.WithDefaultAnnotations(&annotations)
+
+	// the current annotations are used to keep the pause state and to determine which
+	// profiles need to be removed, the Pod template annotations keep the last secret restart
+	var currentAnnotations, currentPodAnnotations map[string]string
+	if existingDeployment != nil {
+		currentAnnotations = existingDeployment.Annotations
+		currentPodAnnotations = existingDeployment.Spec.Template.Annotations
+	}
+
+	progressDeadlineSeconds, err := factory.Factory.ProgressDeadlineSeconds(annotations)
+	if err != nil {
+		glog.Warningf("Function %s progress deadline parsing failed: %v",
+			function.Spec.Name, err)
+	}
+
 	var serviceAccount string
 
 	if function.Spec.Annotations != nil {
@@ -81,7 +97,7 @@ func newDeployment(
 	deploymentSpec := &appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:        function.Spec.Name,
-			Annotations: annotations,
+			Annotations: k8s.CopyCordonAnnotation(currentAnnotations, k8s.CopyPauseAnnotations(currentAnnotations, annotations)),
 			Namespace:   function.Namespace,
 			OwnerReferences: []metav1.OwnerReference{
 				*metav1.NewControllerRef(function, schema.GroupVersionKind{
@@ -92,7 +108,8 @@ func newDeployment(
 			},
 		},
 		Spec: appsv1.DeploymentSpec{
-			Replicas: getReplicas(function, existingDeployment),
+			Replicas:                getReplicas(function, existingDeployment),
+			ProgressDeadlineSeconds: progressDeadlineSeconds,
 			Strategy: appsv1.DeploymentStrategy{
 				Type: appsv1.RollingUpdateDeploymentStrategyType,
 				RollingUpdate: &appsv1.RollingUpdateDeployment{
@@ -115,11 +132,13 @@ func newDeployment(
 			RevisionHistoryLimit: int32p(5),
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
-					Labels:      labels,
-					Annotations: annotations,
+					Labels:      factory.Factory.PodLabels(function.Namespace, labels),
+					Annotations: k8s.CopySecretRestartAnnotation(currentPodAnnotations, factory.Factory.PodAnnotations(annotations)),
 				},
 				Spec: corev1.PodSpec{
-					NodeSelector: nodeSelector,
+					NodeSelector:                  nodeSelector,
+					ServiceAccountName:            serviceAccount,
+					TerminationGracePeriodSeconds: &terminationGracePeriodSeconds,
 					Containers: []corev1.Container{
 						{
 							Name:  function.Spec.Name,
@@ -134,7 +153,7 @@ func newDeployment(
 							ReadinessProbe:  probes.Readiness,
 							SecurityContext: &corev1.SecurityContext{
 								AllowPrivilegeEscalation: &allowPrivilegeEscalation,
-								Privileged: &privileged,
+								Privileged:               &privileged,
 							},
 						},
 					},
@@ -146,16 +165,59 @@ func newDeployment(
 	factory.ConfigureReadOnlyRootFilesystem(function, deploymentSpec)
 	factory.ConfigureContainerUserID(deploymentSpec)
 
-	var currentAnnotations map[string]string
-	if existingDeployment != nil {
-		currentAnnotations = existingDeployment.Annotations
+	if err := factory.Factory.ConfigureRunAsIDs(annotations, deploymentSpec); err != nil {
+		glog.Warningf("Function %s user and group configuration failed: %v",
+			function.Spec.Name, err)
+	}
+
+	// the container port of the operator is unnamed unless the function names it
+	if _, ok := annotations[k8s.PortNameAnnotationKey]; ok {
+		if err := factory.Factory.ConfigurePortName(annotations, deploymentSpec); err != nil {
+			glog.Warningf("Function %s port name configuration failed: %v",
+				function.Spec.Name, err)
+		}
+	}
+
+	if err := factory.Factory.ConfigureDownwardAPI(annotations, deploymentSpec); err != nil {
+		glog.Warningf("Function %s downward API configuration failed: %v",
+			function.Spec.Name, err)
+	}
+
+	if err := factory.Factory.ConfigureWorkingDir(annotations, deploymentSpec); err != nil {
+		glog.Warningf("Function %s working directory configuration failed: %v",
+			function.Spec.Name, err)
+	}
+
+	if err := factory.Factory.ConfigureSchedulerName(annotations, deploymentSpec); err != nil {
+		glog.Warningf("Function %s scheduler name configuration failed: %v",
+			function.Spec.Name, err)
+	}
+
+	if err := factory.Factory.ConfigureNodePool(annotations, deploymentSpec); err != nil {
+		glog.Warningf("Function %s node pool configuration failed: %v",
+			function.Spec.Name, err)
+	}
+
+	if err := factory.Factory.ConfigureExtendedResources(annotations, deploymentSpec); err != nil {
+		glog.Warningf("Function %s extended resources configuration failed: %v",
+			function.Spec.Name, err)
+	}
+
+	if err := factory.Factory.ConfigureDNSPolicy(annotations, deploymentSpec); err != nil {
+		glog.Warningf("Function %s DNS policy configuration failed: %v",
+			function.Spec.Name, err)
+	}
+
+	if err := factory.Factory.ConfigureCacheVolume(annotations, deploymentSpec); err != nil {
+		glog.Warningf("Function %s cache volume configuration failed: %v",
+			function.Spec.Name, err)
 	}
 
 	// compare the annotations from args to the cache copy of the deployment annotations
 	// at this point we have already updated the annotations to the new value, if we
 	// compare to that it will produce an empty list
 	profileNamespace := factory.Factory.Config.ProfilesNamespace
-	profileList, err := factory.GetProfilesToRemove(ctx, profileNamespace, annotations, currentAnnotations)
+	profileList, err := factory.GetProfilesToRemove(ctx, function.Namespace, annotations, currentAnnotations)
 	if err != nil {
 		// TODO: a simple warning doesn't seem strong enough if a profile can't be found or there is
 		// some other error
@@ -169,7 +231,7 @@ func newDeployment(
 		glog.Infof("Function %s: no profiles specified", function.Spec.Name)
 	}
 
-	profileList, err = factory.GetProfiles(ctx, profileNamespace, annotations)
+	profileList, err = factory.GetProfiles(ctx, function.Namespace, annotations)
 	if err != nil {
 		// TODO: a simple warning doesn't seem strong enough if a profile can't be found or there is
 		// some other error
@@ -181,12 +243,36 @@ func newDeployment(
 		factory.ApplyProfile(profile, deploymentSpec)
 	}
 
+	if err := factory.Factory.ConfigureZone(annotations, deploymentSpec); err != nil {
+		glog.Warningf("Function %s zone configuration failed: %v",
+			function.Spec.Name, err)
+	}
+
+	if err := factory.Factory.ConfigurePodOverhead(annotations, deploymentSpec); err != nil {
+		glog.Warningf("Function %s pod overhead configuration failed: %v",
+			function.Spec.Name, err)
+	}
+
+	if err := factory.Factory.ConfigureMetricsScraping(annotations, deploymentSpec); err != nil {
+		glog.Warningf("Function %s metrics scraping configuration failed: %v",
+			function.Spec.Name, err)
+	}
+
+	factory.Factory.ConfigureBackupAnnotations(annotations, deploymentSpec)
+
+	if err := factory.Factory.ValidateSysctls(deploymentSpec.Spec.Template.Spec); err != nil {
+		glog.Warningf("Function %s sysctl validation failed: %v",
+			function.Spec.Name, err)
+	}
+
 	if err := UpdateSecrets(function, deploymentSpec, existingSecrets); err != nil {
 		// TODO: a simple warning doesn't seem strong enough if we can't update the secrets
 		glog.Warningf("Function %s secrets update failed: %v",
 			function.Spec.Name, err)
 	}
 
+	k8s.SetSpecChecksum(deploymentSpec)
+
 	return deploymentSpec
 }
 
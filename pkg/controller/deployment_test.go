@@ -141,3 +141,30 @@ func Test_newDeployment_PrometheusScrape_NotOverridden(t *testing.T) {
 		t.Errorf("Annotation prometheus.io.scrape should be %s, was: %s", want, deployment.Spec.Template.Annotations["prometheus.io.scrape"])
 	}
 }
+
+func Test_newDeployment_KeepsSecretRestart(t *testing.T) {
+	function := &faasv1.Function{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "kubesec",
+		},
+		Spec: faasv1.FunctionSpec{
+			Name:        "kubesec",
+			Image:       "docker.io/kubesec/kubesec",
+			Annotations: &map[string]string{k8s.RestartOnSecretChangeAnnotationKey: "true"},
+		},
+	}
+	k8sConfig := k8s.DeploymentConfig{
+		LivenessProbe:  &k8s.ProbeConfig{PeriodSeconds: 1, TimeoutSeconds: 3},
+		ReadinessProbe: &k8s.ProbeConfig{PeriodSeconds: 1, TimeoutSeconds: 3},
+	}
+	factory := NewFunctionFactory(fake.NewSimpleClientset(), k8sConfig)
+
+	existing := newDeployment(function, nil, map[string]*corev1.Secret{}, factory)
+	existing.Spec.Template.Annotations[k8s.SecretRestartAnnotationKey] = "db-password@3"
+
+	deployment := newDeployment(function, existing, map[string]*corev1.Secret{}, factory)
+
+	if v := deployment.Spec.Template.Annotations[k8s.SecretRestartAnnotationKey]; v != "db-password@3" {
+		t.Errorf("want %s to be kept on update, got: %q", k8s.SecretRestartAnnotationKey, v)
+	}
+}
@@ -83,10 +83,10 @@ func (f *FunctionFactory) RemoveProfile(profile k8s.Profile, deployment *appsv1.
 	f.Factory.RemoveProfile(profile, deployment)
 }
 
-func (f *FunctionFactory) GetProfiles(ctx context.Context, namespace string, annotations map[string]string) ([]k8s.Profile, error) {
-	return f.Factory.GetProfiles(ctx, namespace, annotations)
+func (f *FunctionFactory) GetProfiles(ctx context.Context, functionNamespace string, annotations map[string]string) ([]k8s.Profile, error) {
+	return f.Factory.GetProfiles(ctx, functionNamespace, annotations)
 }
 
-func (f *FunctionFactory) GetProfilesToRemove(ctx context.Context, namespace string, annotations, currentAnnotations map[string]string) ([]k8s.Profile, error) {
-	return f.Factory.GetProfilesToRemove(ctx, namespace, annotations, currentAnnotations)
+func (f *FunctionFactory) GetProfilesToRemove(ctx context.Context, functionNamespace string, annotations, currentAnnotations map[string]string) ([]k8s.Profile, error) {
+	return f.Factory.GetProfilesToRemove(ctx, functionNamespace, annotations, currentAnnotations)
 }
@@ -0,0 +1,100 @@
+package controller
+
+import (
+	"time"
+
+	faasv1 "github.com/openfaas/faas-netes/pkg/apis/openfaas/v1"
+	"github.com/openfaas/faas-netes/pkg/k8s"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+	glog "k8s.io/klog"
+)
+
+// RunFullReconcile enqueues every Function whose Deployment has drifted, once per interval
+// until stopCh is closed. Informer events do not cover changes made directly to Deployments,
+// such as `kubectl set image`, so these are only corrected by the full reconcile. An interval
+// of zero disables it.
+func (c *Controller) RunFullReconcile(interval time.Duration, stopCh <-chan struct{}) {
+	if interval <= 0 {
+		return
+	}
+
+	if ok := cache.WaitForCacheSync(stopCh, c.deploymentsSynced, c.functionsSynced); !ok {
+		glog.Errorf("Full reconcile: failed to wait for caches to sync")
+		return
+	}
+
+	// the informers have just enqueued every Function, so skip the first run
+	select {
+	case <-time.After(interval):
+	case <-stopCh:
+		return
+	}
+
+	wait.Until(func() { c.FullReconcile() }, interval, stopCh)
+}
+
+// FullReconcile lists all Functions and enqueues those whose Deployment is missing or does
+// not match the Function, the number of Functions enqueued is returned.
+func (c *Controller) FullReconcile() int {
+	functions, err := c.functionsLister.List(labels.Everything())
+	if err != nil {
+		glog.Errorf("Full reconcile: unable to list functions: %v", err)
+		return 0
+	}
+
+	drifted := 0
+	for _, function := range functions {
+		if len(function.Spec.Name) == 0 {
+			continue
+		}
+
+		deployment, err := c.deploymentsLister.Deployments(function.Namespace).Get(function.Spec.Name)
+		if err != nil && !errors.IsNotFound(err) {
+			glog.Errorf("Full reconcile: unable to get deployment %s.%s: %v", function.Spec.Name, function.Namespace, err)
+			continue
+		}
+
+		reason := ""
+		if errors.IsNotFound(err) {
+			reason = "deployment is missing"
+		} else if deploymentNeedsUpdate(function, deployment) {
+			reason = "function spec changed"
+		} else {
+			reason = deploymentDrift(function, deployment)
+		}
+
+		if len(reason) == 0 {
+			continue
+		}
+
+		glog.V(2).Infof("Full reconcile: %s.%s drifted: %s", function.Spec.Name, function.Namespace, reason)
+		c.enqueueFunction(function)
+		drifted++
+	}
+
+	glog.Infof("Full reconcile: %d of %d functions drifted", drifted, len(functions))
+	return drifted
+}
+
+// deploymentDrift returns why the Deployment no longer matches the Function after it was
+// changed directly, an empty string is returned when it matches
+func deploymentDrift(function *faasv1.Function, deployment *appsv1.Deployment) string {
+	containers := deployment.Spec.Template.Spec.Containers
+	if len(containers) == 0 || containers[0].Name != function.Spec.Name {
+		return "function container is missing"
+	}
+
+	if containers[0].Image != function.Spec.Image {
+		return "image changed"
+	}
+
+	if k8s.SpecDrifted(deployment) {
+		return "spec checksum changed"
+	}
+
+	return ""
+}
@@ -0,0 +1,69 @@
+package controller
+
+import (
+	"testing"
+
+	faasv1 "github.com/openfaas/faas-netes/pkg/apis/openfaas/v1"
+	listers "github.com/openfaas/faas-netes/pkg/client/listers/openfaas/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	appslisters "k8s.io/client-go/listers/apps/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+)
+
+func Test_FullReconcile(t *testing.T) {
+	newFunction := func(name, image string) *faasv1.Function {
+		return &faasv1.Function{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "openfaas-fn"},
+			Spec:       faasv1.FunctionSpec{Name: name, Image: image},
+		}
+	}
+	newDeploymentFor := func(function *faasv1.Function, image string) *appsv1.Deployment {
+		deployment := &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        function.Spec.Name,
+				Namespace:   function.Namespace,
+				Annotations: makeAnnotations(function),
+			},
+		}
+		deployment.Spec.Template.Spec.Containers = []corev1.Container{{Name: function.Spec.Name, Image: image}}
+		return deployment
+	}
+
+	inSync := newFunction("in-sync", "functions/nodeinfo:1.0")
+	missing := newFunction("missing", "functions/nodeinfo:1.0")
+	imageChanged := newFunction("image-changed", "functions/nodeinfo:1.0")
+	specChanged := newFunction("spec-changed", "functions/nodeinfo:1.0")
+
+	specChangedDeployment := newDeploymentFor(specChanged, "functions/nodeinfo:1.0")
+	specChanged.Spec.Handler = "env"
+
+	functionIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	deploymentIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+
+	for _, function := range []*faasv1.Function{inSync, missing, imageChanged, specChanged} {
+		functionIndexer.Add(function)
+	}
+	deploymentIndexer.Add(newDeploymentFor(inSync, "functions/nodeinfo:1.0"))
+	deploymentIndexer.Add(newDeploymentFor(imageChanged, "functions/nodeinfo:latest"))
+	deploymentIndexer.Add(specChangedDeployment)
+
+	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "Functions")
+	defer queue.ShutDown()
+
+	c := &Controller{
+		functionsLister:   listers.NewFunctionLister(functionIndexer),
+		deploymentsLister: appslisters.NewDeploymentLister(deploymentIndexer),
+		workqueue:         queue,
+	}
+
+	if got := c.FullReconcile(); got != 3 {
+		t.Fatalf("want 3 drifted functions, got: %d", got)
+	}
+
+	if got := deploymentDrift(inSync, newDeploymentFor(inSync, "functions/nodeinfo:1.0")); got != "" {
+		t.Errorf("want no drift for a matching deployment, got: %q", got)
+	}
+}
@@ -0,0 +1,107 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/openfaas/faas-netes/pkg/k8s"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	coreinformers "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	glog "k8s.io/klog"
+)
+
+// SecretRestarted is used as the Event reason when a Function is restarted after a change to
+// one of its Secrets
+const SecretRestarted = "SecretRestarted"
+
+// RunSecretRestarts watches Secrets and restarts the Functions that reference a Secret when
+// its data changes and that have the com.openfaas.restart-on-secret-change annotation. It
+// blocks until stopCh is closed.
+func (c *Controller) RunSecretRestarts(secrets coreinformers.SecretInformer, stopCh <-chan struct{}) {
+	secrets.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(old, new interface{}) {
+			oldSecret, ok := old.(*corev1.Secret)
+			if !ok {
+				return
+			}
+			newSecret, ok := new.(*corev1.Secret)
+			if !ok {
+				return
+			}
+			c.RestartForSecret(oldSecret, newSecret)
+		},
+	})
+
+	go secrets.Informer().Run(stopCh)
+	if ok := cache.WaitForCacheSync(stopCh, secrets.Informer().HasSynced, c.deploymentsSynced); !ok {
+		glog.Errorf("Secret restarts: failed to wait for caches to sync")
+		return
+	}
+
+	<-stopCh
+}
+
+// RestartForSecret rolls the Pods of the opted-in functions that read the Secret when its data
+// has changed, and returns their namespace/name keys. The Pod template is annotated with the
+// Secret's name and resourceVersion so that each change restarts a function once.
+func (c *Controller) RestartForSecret(old, new *corev1.Secret) []string {
+	if !k8s.SecretChanged(old, new) {
+		return nil
+	}
+
+	deployments, err := c.deploymentsLister.Deployments(new.Namespace).List(labels.Everything())
+	if err != nil {
+		glog.Errorf("Secret restarts: unable to list deployments: %v", err)
+		return nil
+	}
+
+	value := new.Name + "@" + new.ResourceVersion
+	patch, err := json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"annotations": map[string]string{k8s.SecretRestartAnnotationKey: value},
+				},
+			},
+		},
+	})
+	if err != nil {
+		glog.Errorf("Secret restarts: unable to create patch: %v", err)
+		return nil
+	}
+
+	var restarted []string
+	for _, deployment := range deployments {
+		if _, ok := deployment.Labels["faas_function"]; !ok || deployment.DeletionTimestamp != nil {
+			continue
+		}
+		if !k8s.RestartOnSecretChange(deployment.Annotations) || !k8s.SecretRestartRequired(deployment.Spec.Template.Spec, new.Name) {
+			continue
+		}
+		if deployment.Spec.Template.Annotations[k8s.SecretRestartAnnotationKey] == value {
+			continue
+		}
+
+		_, err := c.kubeclientset.AppsV1().Deployments(deployment.Namespace).Patch(context.TODO(), deployment.Name, types.StrategicMergePatchType, patch, metav1.PatchOptions{})
+		if err != nil {
+			glog.Errorf("Secret restarts: unable to restart %s.%s: %v", deployment.Name, deployment.Namespace, err)
+			continue
+		}
+
+		if owner := metav1.GetControllerOf(deployment); owner != nil && owner.Kind == "Function" && c.recorder != nil {
+			if function, err := c.functionsLister.Functions(deployment.Namespace).Get(owner.Name); err == nil {
+				c.recorder.Event(function, corev1.EventTypeNormal, SecretRestarted, fmt.Sprintf("Restarted after a change to secret %s", new.Name))
+			}
+		}
+		glog.Infof("Secret restarts: restarted %s.%s after a change to secret %s", deployment.Name, deployment.Namespace, new.Name)
+
+		restarted = append(restarted, deployment.Namespace+"/"+deployment.Name)
+	}
+
+	return restarted
+}
@@ -0,0 +1,123 @@
+package controller
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/openfaas/faas-netes/pkg/k8s"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	appslisters "k8s.io/client-go/listers/apps/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func Test_RestartForSecret(t *testing.T) {
+	newDeployment := func(name string, optIn bool, spec corev1.PodSpec) *appsv1.Deployment {
+		annotations := map[string]string{}
+		if optIn {
+			annotations[k8s.RestartOnSecretChangeAnnotationKey] = "true"
+		}
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Namespace:   "openfaas-fn",
+				Labels:      map[string]string{"faas_function": name},
+				Annotations: annotations,
+			},
+			Spec: appsv1.DeploymentSpec{
+				Template: corev1.PodTemplateSpec{Spec: spec},
+			},
+		}
+	}
+
+	envSpec := corev1.PodSpec{
+		Containers: []corev1.Container{{
+			Name: "fn",
+			EnvFrom: []corev1.EnvFromSource{{
+				SecretRef: &corev1.SecretEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "db-password"}},
+			}},
+		}},
+	}
+	volumeSpec := corev1.PodSpec{
+		Volumes: []corev1.Volume{{
+			Name: "secrets",
+			VolumeSource: corev1.VolumeSource{
+				Projected: &corev1.ProjectedVolumeSource{
+					Sources: []corev1.VolumeProjection{{
+						Secret: &corev1.SecretProjection{LocalObjectReference: corev1.LocalObjectReference{Name: "db-password"}},
+					}},
+				},
+			},
+		}},
+	}
+	pullSpec := corev1.PodSpec{
+		ImagePullSecrets: []corev1.LocalObjectReference{{Name: "db-password"}},
+	}
+
+	deployments := []*appsv1.Deployment{
+		newDeployment("env", true, envSpec),
+		newDeployment("volume", true, volumeSpec),
+		newDeployment("not-opted-in", false, envSpec),
+		newDeployment("image-pull", true, pullSpec),
+		newDeployment("unrelated", true, corev1.PodSpec{}),
+	}
+
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	client := kubefake.NewSimpleClientset()
+	for _, deployment := range deployments {
+		indexer.Add(deployment)
+		client.AppsV1().Deployments(deployment.Namespace).Create(context.Background(), deployment, metav1.CreateOptions{})
+	}
+
+	c := &Controller{
+		kubeclientset:     client,
+		deploymentsLister: appslisters.NewDeploymentLister(indexer),
+	}
+
+	old := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "db-password", Namespace: "openfaas-fn", ResourceVersion: "1"},
+		Data:       map[string][]byte{"password": []byte("old")},
+	}
+
+	resync := old.DeepCopy()
+	if got := c.RestartForSecret(old, resync); len(got) != 0 {
+		t.Errorf("want no restarts for a resync, got: %v", got)
+	}
+
+	labelled := old.DeepCopy()
+	labelled.ResourceVersion = "2"
+	labelled.Labels = map[string]string{"team": "payments"}
+	if got := c.RestartForSecret(old, labelled); len(got) != 0 {
+		t.Errorf("want no restarts for a metadata change, got: %v", got)
+	}
+
+	rotated := old.DeepCopy()
+	rotated.ResourceVersion = "3"
+	rotated.Data["password"] = []byte("new")
+
+	got := c.RestartForSecret(old, rotated)
+	sort.Strings(got)
+	if want := []string{"openfaas-fn/env", "openfaas-fn/volume"}; !reflect.DeepEqual(want, got) {
+		t.Fatalf("want restarted: %v, got: %v", want, got)
+	}
+
+	deployment, err := client.AppsV1().Deployments("openfaas-fn").Get(context.Background(), "env", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if v := deployment.Spec.Template.Annotations[k8s.SecretRestartAnnotationKey]; v != "db-password@3" {
+		t.Errorf("want Pod template annotation: %q, got: %q", "db-password@3", v)
+	}
+
+	unchanged, err := client.AppsV1().Deployments("openfaas-fn").Get(context.Background(), "not-opted-in", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, ok := unchanged.Spec.Template.Annotations[k8s.SecretRestartAnnotationKey]; ok {
+		t.Errorf("want no restart for a function without %s", k8s.RestartOnSecretChangeAnnotationKey)
+	}
+}
@@ -7,13 +7,15 @@ import (
 	"k8s.io/apimachinery/pkg/util/intstr"
 
 	faasv1 "github.com/openfaas/faas-netes/pkg/apis/openfaas/v1"
+	"github.com/openfaas/faas-netes/pkg/k8s"
+	glog "k8s.io/klog"
 )
 
 // newService creates a new ClusterIP Service for a Function resource. It also sets
 // the appropriate OwnerReferences on the resource so handleObject can discover
 // the Function resource that 'owns' it.
 func newService(function *faasv1.Function) *corev1.Service {
-	return &corev1.Service{
+	service := &corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:        function.Spec.Name,
 			Namespace:   function.Namespace,
@@ -42,4 +44,12 @@ func newService(function *faasv1.Function) *corev1.Service {
 			},
 		},
 	}
+
+	if function.Spec.Annotations != nil {
+		if err := k8s.ConfigureServicePort(*function.Spec.Annotations, service); err != nil {
+			glog.Warningf("Function %s port configuration failed: %v", function.Spec.Name, err)
+		}
+	}
+
+	return service
 }
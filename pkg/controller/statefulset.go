@@ -0,0 +1,246 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	faasv1 "github.com/openfaas/faas-netes/pkg/apis/openfaas/v1"
+	"github.com/openfaas/faas-netes/pkg/k8s"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	glog "k8s.io/klog"
+)
+
+// MakeStatefulSet converts the Deployment of a stateful function into a StatefulSet with the
+// same Pod template, and a volume claim template for the storage of each replica that is
+// mounted into the function's container.
+func (f *FunctionFactory) MakeStatefulSet(deployment *appsv1.Deployment) (*appsv1.StatefulSet, error) {
+	size, mountPath, err := k8s.StatefulStorage(deployment.Annotations)
+	if err != nil {
+		return nil, err
+	}
+
+	template := deployment.Spec.Template.DeepCopy()
+	container := &template.Spec.Containers[0]
+	container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+		Name:      k8s.StatefulStorageVolumeName,
+		MountPath: mountPath,
+	})
+
+	// a new volume is owned by root, so a non-root function is given write access through its group
+	if sc := container.SecurityContext; sc != nil && sc.RunAsUser != nil {
+		if template.Spec.SecurityContext == nil {
+			template.Spec.SecurityContext = &corev1.PodSecurityContext{}
+		}
+		if template.Spec.SecurityContext.FSGroup == nil {
+			fsGroup := *sc.RunAsUser
+			template.Spec.SecurityContext.FSGroup = &fsGroup
+		}
+	}
+
+	return &appsv1.StatefulSet{
+		ObjectMeta: *deployment.ObjectMeta.DeepCopy(),
+		Spec: appsv1.StatefulSetSpec{
+			Replicas:             deployment.Spec.Replicas,
+			Selector:             deployment.Spec.Selector,
+			Template:             *template,
+			ServiceName:          k8s.HeadlessServiceName(deployment.Name),
+			PodManagementPolicy:  appsv1.ParallelPodManagement,
+			RevisionHistoryLimit: deployment.Spec.RevisionHistoryLimit,
+			UpdateStrategy: appsv1.StatefulSetUpdateStrategy{
+				Type: appsv1.RollingUpdateStatefulSetStrategyType,
+			},
+			VolumeClaimTemplates: []corev1.PersistentVolumeClaim{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:   k8s.StatefulStorageVolumeName,
+						Labels: map[string]string{"faas_function": deployment.Name},
+					},
+					Spec: corev1.PersistentVolumeClaimSpec{
+						AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+						Resources: corev1.ResourceRequirements{
+							Requests: corev1.ResourceList{corev1.ResourceStorage: size},
+						},
+					},
+				},
+			},
+		},
+	}, nil
+}
+
+// newStatefulSet creates a new StatefulSet for a stateful Function resource. The Pod template
+// is built by newDeployment, so every annotation applies to stateful functions too.
+func newStatefulSet(
+	function *faasv1.Function,
+	existingStatefulSet *appsv1.StatefulSet,
+	existingSecrets map[string]*corev1.Secret,
+	factory FunctionFactory) (*appsv1.StatefulSet, error) {
+
+	var existingDeployment *appsv1.Deployment
+	if existingStatefulSet != nil {
+		existingDeployment = &appsv1.Deployment{
+			ObjectMeta: existingStatefulSet.ObjectMeta,
+			Spec: appsv1.DeploymentSpec{
+				Replicas: existingStatefulSet.Spec.Replicas,
+				Template: existingStatefulSet.Spec.Template,
+			},
+		}
+	}
+
+	statefulSet, err := factory.MakeStatefulSet(newDeployment(function, existingDeployment, existingSecrets, factory))
+	if err != nil {
+		return nil, err
+	}
+
+	if existingStatefulSet != nil {
+		// these fields of a StatefulSet can not be updated, so a new storage size only applies
+		// to a function that is deleted and deployed again
+		statefulSet.Spec.Selector = existingStatefulSet.Spec.Selector
+		statefulSet.Spec.ServiceName = existingStatefulSet.Spec.ServiceName
+		statefulSet.Spec.PodManagementPolicy = existingStatefulSet.Spec.PodManagementPolicy
+		statefulSet.Spec.VolumeClaimTemplates = existingStatefulSet.Spec.VolumeClaimTemplates
+		statefulSet.ResourceVersion = existingStatefulSet.ResourceVersion
+	}
+
+	return statefulSet, nil
+}
+
+// newHeadlessService creates the headless Service that gives each replica of a stateful
+// Function a stable DNS name
+func newHeadlessService(function *faasv1.Function) *corev1.Service {
+	service := newService(function)
+	service.Name = k8s.HeadlessServiceName(function.Spec.Name)
+	service.Spec.ClusterIP = corev1.ClusterIPNone
+	service.Spec.PublishNotReadyAddresses = true
+	return service
+}
+
+// syncStatefulSet creates or updates the StatefulSet and Services of a stateful function. A
+// Deployment left from before the function was stateful is deleted once the StatefulSet is
+// created.
+func (c *Controller) syncStatefulSet(function *faasv1.Function) error {
+	ctx := context.TODO()
+	statefulSets := c.kubeclientset.AppsV1().StatefulSets(function.Namespace)
+
+	statefulSet, err := statefulSets.Get(ctx, function.Spec.Name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		existingSecrets, err := c.getSecrets(function.Namespace, function.Spec.Secrets)
+		if err != nil {
+			return err
+		}
+
+		spec, err := newStatefulSet(function, nil, existingSecrets, c.factory)
+		if err != nil {
+			return err
+		}
+
+		if err := c.deleteOwnedDeployment(ctx, function); err != nil {
+			return err
+		}
+
+		glog.Infof("Creating statefulset for '%s'", function.Spec.Name)
+		if statefulSet, err = statefulSets.Create(ctx, spec, metav1.CreateOptions{}); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	}
+
+	services := c.kubeclientset.CoreV1().Services(function.Namespace)
+	for _, service := range []*corev1.Service{newService(function), newHeadlessService(function)} {
+		if _, err := services.Get(ctx, service.Name, metav1.GetOptions{}); errors.IsNotFound(err) {
+			glog.Infof("Creating service '%s' for '%s'", service.Name, function.Spec.Name)
+			if _, err := services.Create(ctx, service, metav1.CreateOptions{}); err != nil && !errors.IsAlreadyExists(err) {
+				return err
+			}
+		}
+	}
+
+	if !metav1.IsControlledBy(statefulSet, function) {
+		msg := fmt.Sprintf(MessageResourceExists, statefulSet.Name)
+		c.recorder.Event(function, corev1.EventTypeWarning, ErrResourceExists, msg)
+		return fmt.Errorf(msg)
+	}
+
+	// the previous function spec is kept in the same annotation as for a Deployment
+	if !deploymentNeedsUpdate(function, &appsv1.Deployment{ObjectMeta: statefulSet.ObjectMeta}) {
+		return nil
+	}
+
+	glog.Infof("Updating statefulset for '%s'", function.Spec.Name)
+	existingSecrets, err := c.getSecrets(function.Namespace, function.Spec.Secrets)
+	if err != nil {
+		return err
+	}
+
+	spec, err := newStatefulSet(function, statefulSet, existingSecrets, c.factory)
+	if err != nil {
+		return err
+	}
+	if _, err := statefulSets.Update(ctx, spec, metav1.UpdateOptions{}); err != nil {
+		return err
+	}
+
+	existingService, err := services.Get(ctx, function.Spec.Name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	existingService.Annotations = makeAnnotations(function)
+	if _, err := services.Update(ctx, existingService, metav1.UpdateOptions{}); err != nil {
+		glog.Errorf("Updating service for '%s' failed: %v", function.Spec.Name, err)
+	}
+
+	return nil
+}
+
+// deleteOwnedDeployment deletes the Deployment of a function that has become stateful
+func (c *Controller) deleteOwnedDeployment(ctx context.Context, function *faasv1.Function) error {
+	deployment, err := c.deploymentsLister.Deployments(function.Namespace).Get(function.Spec.Name)
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !metav1.IsControlledBy(deployment, function) {
+		return nil
+	}
+
+	glog.Infof("Deleting deployment for '%s', it is replaced by a statefulset", function.Spec.Name)
+	err = c.kubeclientset.AppsV1().Deployments(function.Namespace).Delete(ctx, deployment.Name, metav1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// deleteOwnedStatefulSet deletes the StatefulSet and headless Service of a function that is no
+// longer stateful, the PersistentVolumeClaims are kept so the data is there if it becomes
+// stateful again
+func (c *Controller) deleteOwnedStatefulSet(ctx context.Context, function *faasv1.Function) error {
+	statefulSets := c.kubeclientset.AppsV1().StatefulSets(function.Namespace)
+	statefulSet, err := statefulSets.Get(ctx, function.Spec.Name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !metav1.IsControlledBy(statefulSet, function) {
+		return nil
+	}
+
+	glog.Infof("Deleting statefulset for '%s', it is replaced by a deployment", function.Spec.Name)
+	if err := statefulSets.Delete(ctx, statefulSet.Name, metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+
+	headless := k8s.HeadlessServiceName(function.Spec.Name)
+	err = c.kubeclientset.CoreV1().Services(function.Namespace).Delete(ctx, headless, metav1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,201 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	faasv1 "github.com/openfaas/faas-netes/pkg/apis/openfaas/v1"
+	"github.com/openfaas/faas-netes/pkg/k8s"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	appslisters "k8s.io/client-go/listers/apps/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+)
+
+func newStatefulFunction(image string) *faasv1.Function {
+	return &faasv1.Function{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "counter",
+			Namespace: "openfaas-fn",
+			UID:       "counter-uid",
+		},
+		Spec: faasv1.FunctionSpec{
+			Name:  "counter",
+			Image: image,
+			Annotations: &map[string]string{
+				k8s.StatefulAnnotationKey:    "true",
+				k8s.StorageSizeAnnotationKey: "5Gi",
+			},
+		},
+	}
+}
+
+func newStatefulFactory(client *kubefake.Clientset) FunctionFactory {
+	return NewFunctionFactory(client, k8s.DeploymentConfig{
+		HTTPProbe:      true,
+		SetNonRootUser: true,
+		LivenessProbe:  &k8s.ProbeConfig{PeriodSeconds: 1, TimeoutSeconds: 3},
+		ReadinessProbe: &k8s.ProbeConfig{PeriodSeconds: 1, TimeoutSeconds: 3},
+	})
+}
+
+func Test_newStatefulSet(t *testing.T) {
+	function := newStatefulFunction("functions/counter:1.0")
+	factory := newStatefulFactory(kubefake.NewSimpleClientset())
+
+	statefulSet, err := newStatefulSet(function, nil, map[string]*corev1.Secret{}, factory)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if statefulSet.Spec.ServiceName != "counter-headless" {
+		t.Errorf("want service name counter-headless, got %s", statefulSet.Spec.ServiceName)
+	}
+	if !metav1.IsControlledBy(statefulSet, function) {
+		t.Errorf("want the StatefulSet to be controlled by the Function")
+	}
+
+	if len(statefulSet.Spec.VolumeClaimTemplates) != 1 {
+		t.Fatalf("want 1 volume claim template, got %d", len(statefulSet.Spec.VolumeClaimTemplates))
+	}
+	claim := statefulSet.Spec.VolumeClaimTemplates[0]
+	if size := claim.Spec.Resources.Requests[corev1.ResourceStorage]; size.String() != "5Gi" {
+		t.Errorf("want a claim of 5Gi, got %s", size.String())
+	}
+	if claim.Labels["faas_function"] != "counter" {
+		t.Errorf("want the claim to be labelled with the function, got %v", claim.Labels)
+	}
+
+	container := statefulSet.Spec.Template.Spec.Containers[0]
+	if container.Image != "functions/counter:1.0" {
+		t.Errorf("want image functions/counter:1.0, got %s", container.Image)
+	}
+	mounted := false
+	for _, mount := range container.VolumeMounts {
+		if mount.Name == k8s.StatefulStorageVolumeName && mount.MountPath == "/data" {
+			mounted = true
+		}
+	}
+	if !mounted {
+		t.Errorf("want the storage mounted at /data, got %v", container.VolumeMounts)
+	}
+
+	sc := statefulSet.Spec.Template.Spec.SecurityContext
+	if sc == nil || sc.FSGroup == nil || *sc.FSGroup != k8s.SecurityContextUserID {
+		t.Errorf("want the fsGroup to be the non-root user %d, got %v", k8s.SecurityContextUserID, sc)
+	}
+}
+
+func Test_newStatefulSet_KeepsImmutableFields(t *testing.T) {
+	function := newStatefulFunction("functions/counter:1.0")
+	factory := newStatefulFactory(kubefake.NewSimpleClientset())
+
+	existing, err := newStatefulSet(function, nil, map[string]*corev1.Secret{}, factory)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	existing.ResourceVersion = "7"
+
+	(*function.Spec.Annotations)[k8s.StorageSizeAnnotationKey] = "10Gi"
+	function.Spec.Image = "functions/counter:2.0"
+
+	updated, err := newStatefulSet(function, existing, map[string]*corev1.Secret{}, factory)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if size := updated.Spec.VolumeClaimTemplates[0].Spec.Resources.Requests[corev1.ResourceStorage]; size.String() != "5Gi" {
+		t.Errorf("want the existing claim of 5Gi to be kept, got %s", size.String())
+	}
+	if updated.ResourceVersion != "7" {
+		t.Errorf("want resource version 7, got %q", updated.ResourceVersion)
+	}
+	if image := updated.Spec.Template.Spec.Containers[0].Image; image != "functions/counter:2.0" {
+		t.Errorf("want image functions/counter:2.0, got %s", image)
+	}
+}
+
+func Test_syncStatefulSet(t *testing.T) {
+	function := newStatefulFunction("functions/counter:1.0")
+
+	// the function was deployed before it was stateful
+	deployment := newDeployment(function, nil, map[string]*corev1.Secret{}, newStatefulFactory(kubefake.NewSimpleClientset()))
+	client := kubefake.NewSimpleClientset(deployment)
+
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	indexer.Add(deployment)
+
+	c := &Controller{
+		kubeclientset:     client,
+		deploymentsLister: appslisters.NewDeploymentLister(indexer),
+		recorder:          record.NewFakeRecorder(10),
+		factory:           newStatefulFactory(client),
+	}
+
+	if err := c.syncStatefulSet(function); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	ctx := context.Background()
+	if _, err := client.AppsV1().StatefulSets("openfaas-fn").Get(ctx, "counter", metav1.GetOptions{}); err != nil {
+		t.Fatalf("want a StatefulSet, got: %s", err)
+	}
+	if _, err := client.AppsV1().Deployments("openfaas-fn").Get(ctx, "counter", metav1.GetOptions{}); !errors.IsNotFound(err) {
+		t.Errorf("want the Deployment to be deleted, got: %v", err)
+	}
+
+	for _, name := range []string{"counter", "counter-headless"} {
+		if _, err := client.CoreV1().Services("openfaas-fn").Get(ctx, name, metav1.GetOptions{}); err != nil {
+			t.Errorf("want Service %s, got: %s", name, err)
+		}
+	}
+	headless, _ := client.CoreV1().Services("openfaas-fn").Get(ctx, "counter-headless", metav1.GetOptions{})
+	if headless != nil && headless.Spec.ClusterIP != corev1.ClusterIPNone {
+		t.Errorf("want a headless Service, got cluster IP %q", headless.Spec.ClusterIP)
+	}
+
+	function.Spec.Image = "functions/counter:2.0"
+	if err := c.syncStatefulSet(function); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	updated, err := client.AppsV1().StatefulSets("openfaas-fn").Get(ctx, "counter", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if image := updated.Spec.Template.Spec.Containers[0].Image; image != "functions/counter:2.0" {
+		t.Errorf("want the StatefulSet to be updated to functions/counter:2.0, got %s", image)
+	}
+}
+
+func Test_deleteOwnedStatefulSet(t *testing.T) {
+	function := newStatefulFunction("functions/counter:1.0")
+	statefulSet, err := newStatefulSet(function, nil, map[string]*corev1.Secret{}, newStatefulFactory(kubefake.NewSimpleClientset()))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	client := kubefake.NewSimpleClientset(statefulSet, newHeadlessService(function), &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "database", Namespace: "openfaas-fn"},
+	})
+	c := &Controller{kubeclientset: client}
+
+	if err := c.deleteOwnedStatefulSet(context.Background(), function); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	ctx := context.Background()
+	if _, err := client.AppsV1().StatefulSets("openfaas-fn").Get(ctx, "counter", metav1.GetOptions{}); !errors.IsNotFound(err) {
+		t.Errorf("want the StatefulSet to be deleted, got: %v", err)
+	}
+	if _, err := client.CoreV1().Services("openfaas-fn").Get(ctx, "counter-headless", metav1.GetOptions{}); !errors.IsNotFound(err) {
+		t.Errorf("want the headless Service to be deleted, got: %v", err)
+	}
+	if _, err := client.AppsV1().StatefulSets("openfaas-fn").Get(ctx, "database", metav1.GetOptions{}); err != nil {
+		t.Errorf("want a StatefulSet that is not owned by the function to be kept, got: %s", err)
+	}
+}
@@ -0,0 +1,94 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	"github.com/openfaas/faas-netes/pkg/k8s"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+	glog "k8s.io/klog"
+)
+
+// RunTTLSweep deletes the Functions whose com.openfaas.ttl has elapsed, or whose
+// com.openfaas.expires-at time has passed, every interval until stopCh is closed
+func (c *Controller) RunTTLSweep(interval time.Duration, stopCh <-chan struct{}) {
+	if ok := cache.WaitForCacheSync(stopCh, c.functionsSynced); !ok {
+		glog.Errorf("TTL sweep: failed to wait for caches to sync")
+		return
+	}
+
+	k8s.NewExpirySweeper(functionExpiryTarget{c}).Run(interval, stopCh)
+}
+
+// SweepExpired deletes the Functions that have expired by now and returns their
+// namespace/name keys
+func (c *Controller) SweepExpired(now time.Time) []string {
+	expired, err := k8s.NewExpirySweeper(functionExpiryTarget{c}).Sweep(context.TODO(), now)
+	if err != nil {
+		glog.Errorf("TTL sweep: %v", err)
+	}
+	return expired
+}
+
+// functionExpiryTarget is the k8s.ExpiryTarget of the operator. The Deployment or StatefulSet
+// and Services of a Function are owned by it, so they are removed by the garbage collector
+// when the Function is deleted.
+type functionExpiryTarget struct {
+	c *Controller
+}
+
+func (t functionExpiryTarget) ListFunctions() ([]k8s.ExpiringFunction, error) {
+	functions, err := t.c.functionsLister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	expiring := make([]k8s.ExpiringFunction, 0, len(functions))
+	for _, function := range functions {
+		if function.Spec.Annotations == nil || function.DeletionTimestamp != nil {
+			continue
+		}
+
+		expiring = append(expiring, k8s.ExpiringFunction{
+			Name:        function.Name,
+			Namespace:   function.Namespace,
+			Annotations: *function.Spec.Annotations,
+			Created:     function.CreationTimestamp.Time,
+		})
+	}
+
+	return expiring, nil
+}
+
+// DeleteFunction deletes the Function, and the PersistentVolumeClaims of a stateful function
+// with the com.openfaas.storage.delete-pvcs annotation, as the delete endpoint does
+func (t functionExpiryTarget) DeleteFunction(ctx context.Context, expiring k8s.ExpiringFunction, reason string) error {
+	function, err := t.c.functionsLister.Functions(expiring.Namespace).Get(expiring.Name)
+	if err != nil {
+		return err
+	}
+
+	if k8s.DeletePVCs(expiring.Annotations) {
+		if err := k8s.DeleteFunctionPVCs(ctx, t.c.kubeclientset, function.Namespace, function.Spec.Name); err != nil {
+			return err
+		}
+	}
+
+	background := metav1.DeletePropagationBackground
+	err = t.c.faasclientset.OpenfaasV1().Functions(function.Namespace).Delete(ctx, function.Name, metav1.DeleteOptions{
+		PropagationPolicy: &background,
+	})
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+
+	if t.c.recorder != nil {
+		t.c.recorder.Event(function, corev1.EventTypeNormal, k8s.FunctionExpiredReason, "Function deleted "+reason)
+	}
+
+	return nil
+}
@@ -0,0 +1,72 @@
+package controller
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	faasv1 "github.com/openfaas/faas-netes/pkg/apis/openfaas/v1"
+	"github.com/openfaas/faas-netes/pkg/client/clientset/versioned/fake"
+	listers "github.com/openfaas/faas-netes/pkg/client/listers/openfaas/v1"
+	"github.com/openfaas/faas-netes/pkg/k8s"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func Test_SweepExpired(t *testing.T) {
+	now := time.Now()
+
+	newFunction := func(name, ttl string, age time.Duration) *faasv1.Function {
+		annotations := map[string]string{}
+		if len(ttl) > 0 {
+			annotations[k8s.TTLAnnotationKey] = ttl
+		}
+		return &faasv1.Function{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				Namespace:         "openfaas-fn",
+				CreationTimestamp: metav1.NewTime(now.Add(-age)),
+			},
+			Spec: faasv1.FunctionSpec{Name: name, Annotations: &annotations},
+		}
+	}
+
+	functions := []*faasv1.Function{
+		newFunction("expired", "1h", 2*time.Hour),
+		newFunction("alive", "1h", 30*time.Minute),
+		newFunction("no-ttl", "", 48*time.Hour),
+		newFunction("invalid", "one hour", 48*time.Hour),
+	}
+
+	expiresAt := newFunction("expires-at", "", time.Hour)
+	(*expiresAt.Spec.Annotations)[k8s.ExpiresAtAnnotationKey] = now.Add(-time.Minute).Format(time.RFC3339)
+	functions = append(functions, expiresAt)
+
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	client := fake.NewSimpleClientset()
+	for _, function := range functions {
+		indexer.Add(function)
+		client.OpenfaasV1().Functions(function.Namespace).Create(context.Background(), function, metav1.CreateOptions{})
+	}
+
+	c := &Controller{
+		faasclientset:   client,
+		functionsLister: listers.NewFunctionLister(indexer),
+	}
+
+	got := c.SweepExpired(now)
+	sort.Strings(got)
+	if want := []string{"openfaas-fn/expired", "openfaas-fn/expires-at"}; !reflect.DeepEqual(want, got) {
+		t.Fatalf("want expired: %v, got: %v", want, got)
+	}
+
+	res, err := client.OpenfaasV1().Functions("openfaas-fn").List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(res.Items) != 3 {
+		t.Errorf("want 3 functions left, got: %d", len(res.Items))
+	}
+}
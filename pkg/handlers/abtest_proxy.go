@@ -0,0 +1,69 @@
+// Copyright 2020 OpenFaaS Author(s)
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/openfaas/faas-netes/pkg/k8s"
+	"github.com/openfaas/faas-netes/pkg/metrics"
+	v1 "k8s.io/client-go/listers/apps/v1"
+)
+
+const (
+	// ABRoutePrimary is recorded for requests of an A/B tested function that it serves itself
+	ABRoutePrimary = "primary"
+
+	// ABRouteAlternative is recorded for requests sent to the alternative function
+	ABRouteAlternative = "alternative"
+)
+
+// MakeABTestProxyHandler sends the requests of functions annotated with `com.openfaas.ab.header`
+// and `com.openfaas.ab.function` to the alternative function when the header matches
+// `com.openfaas.ab.match`, all other requests go to next unchanged. The route taken by each
+// request of an A/B tested function is counted in faas_netes_ab_requests_total.
+func MakeABTestProxyHandler(next http.HandlerFunc, defaultNamespace string, deploymentLister v1.DeploymentLister) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		name := vars["name"]
+
+		deployment := lookupFunctionDeployment(name, defaultNamespace, deploymentLister)
+		if deployment == nil {
+			next(w, r)
+			return
+		}
+
+		test, err := k8s.ParseABTest(deployment.Annotations)
+		if err != nil {
+			log.Printf("Function %s A/B test ignored: %s\n", name, err)
+		}
+		if test == nil {
+			next(w, r)
+			return
+		}
+
+		if !test.Matches(r.Header) {
+			metrics.ObserveABTest(deployment.Name, ABRoutePrimary)
+			next(w, r)
+			return
+		}
+
+		target := test.Function
+		if index := strings.LastIndex(name, "."); index > -1 {
+			target += name[index:]
+		}
+
+		routed := map[string]string{}
+		for k, v := range vars {
+			routed[k] = v
+		}
+		routed["name"] = target
+
+		metrics.ObserveABTest(deployment.Name, ABRouteAlternative)
+		next(w, mux.SetURLVars(r, routed))
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright 2020 OpenFaaS Author(s)
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/openfaas/faas-netes/pkg/k8s"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_MakeABTestProxyHandler(t *testing.T) {
+	lister := newTestDeploymentLister(t,
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "nodeinfo",
+				Namespace: "openfaas-fn",
+				Annotations: map[string]string{
+					k8s.ABHeaderAnnotationKey:   "X-User-Group",
+					k8s.ABFunctionAnnotationKey: "nodeinfo-v2",
+					k8s.ABMatchAnnotationKey:    "^beta$",
+				},
+			},
+		},
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "figlet", Namespace: "openfaas-fn"},
+		},
+	)
+
+	cases := []struct {
+		name   string
+		target string
+		header string
+		want   string
+	}{
+		{name: "header matches", target: "nodeinfo", header: "beta", want: "nodeinfo-v2"},
+		{name: "header does not match", target: "nodeinfo", header: "stable", want: "nodeinfo"},
+		{name: "no header", target: "nodeinfo", want: "nodeinfo"},
+		{name: "namespace is kept", target: "nodeinfo.openfaas-fn", header: "beta", want: "nodeinfo-v2.openfaas-fn"},
+		{name: "not A/B tested", target: "figlet", header: "beta", want: "figlet"},
+		{name: "unknown function", target: "missing", header: "beta", want: "missing"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var got string
+			next := func(w http.ResponseWriter, r *http.Request) {
+				got = mux.Vars(r)["name"]
+				if params := mux.Vars(r)["params"]; params != "/path" {
+					t.Errorf("want params to be kept, got: %q", params)
+				}
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/function/"+tc.target+"/path", nil)
+			req = mux.SetURLVars(req, map[string]string{"name": tc.target, "params": "/path"})
+			if len(tc.header) > 0 {
+				req.Header.Set("X-User-Group", tc.header)
+			}
+
+			MakeABTestProxyHandler(next, "openfaas-fn", lister).ServeHTTP(httptest.NewRecorder(), req)
+
+			if got != tc.want {
+				t.Errorf("want target %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
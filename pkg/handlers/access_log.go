@@ -0,0 +1,117 @@
+// Copyright 2020 OpenFaaS Author(s)
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// callIDHeader is set by the OpenFaaS gateway to correlate a request across components
+const callIDHeader = "X-Call-Id"
+
+// sensitiveHeaders are logged with their value redacted, headers whose name contains
+// token, secret or password are also redacted
+var sensitiveHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+	"Set-Cookie":          true,
+	"X-Api-Key":           true,
+}
+
+// accessLogWriter records the status and number of bytes written to the caller
+type accessLogWriter struct {
+	http.ResponseWriter
+
+	status int
+	size   int64
+}
+
+func (w *accessLogWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *accessLogWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.size += int64(n)
+	return n, err
+}
+
+// Flush keeps streamed responses working when the access log is enabled
+func (w *accessLogWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap returns the writer of the server, so that the write deadline of streamed responses
+// can still be cleared
+func (w *accessLogWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// withAccessLog calls next and then logs one line for the request with its method, path,
+// status, duration, response size, call ID and request headers, sensitive header values
+// are redacted
+func withAccessLog(functionName string, w http.ResponseWriter, r *http.Request, next func(http.ResponseWriter)) {
+	writer := &accessLogWriter{ResponseWriter: w}
+
+	start := time.Now()
+	next(writer)
+	duration := time.Since(start)
+
+	status := writer.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	log.Printf("access function=%s method=%s path=%s status=%d duration=%fs size=%d call_id=%q headers=%s\n",
+		functionName, r.Method, r.URL.Path, status, duration.Seconds(), writer.size, r.Header.Get(callIDHeader), redactHeaders(r.Header))
+}
+
+// redactHeaders formats the headers in a stable order, with sensitive values replaced
+func redactHeaders(header http.Header) string {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		value := strings.Join(header[name], ",")
+		if isSensitiveHeader(name) {
+			value = redactedValue
+		}
+		parts = append(parts, fmt.Sprintf("%s=%q", name, value))
+	}
+
+	return "{" + strings.Join(parts, " ") + "}"
+}
+
+func isSensitiveHeader(name string) bool {
+	canonical := http.CanonicalHeaderKey(name)
+	if sensitiveHeaders[canonical] {
+		return true
+	}
+
+	lower := strings.ToLower(canonical)
+	for _, word := range []string{"token", "secret", "password"} {
+		if strings.Contains(lower, word) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+// Copyright 2020 OpenFaaS Author(s)
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/openfaas/faas-netes/pkg/k8s"
+)
+
+func Test_redactHeaders(t *testing.T) {
+	header := http.Header{}
+	header.Set("Authorization", "Bearer abc")
+	header.Set("X-Github-Token", "ghp_123")
+	header.Set("Content-Type", "application/json")
+
+	got := redactHeaders(header)
+	want := `{Authorization="[redacted]" Content-Type="application/json" X-Github-Token="[redacted]"}`
+	if got != want {
+		t.Errorf("want: %s, got: %s", want, got)
+	}
+}
+
+func Test_MakeProxyHandler_AccessLog(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte("hello"))
+	}))
+	defer upstream.Close()
+
+	lister := newTestDeploymentLister(t,
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{
+			Name:        "nodeinfo",
+			Namespace:   "openfaas-fn",
+			Annotations: map[string]string{k8s.AccessLogAnnotationKey: "true"},
+		}},
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{
+			Name:      "env",
+			Namespace: "openfaas-fn",
+		}},
+	)
+
+	srv := newProxyTestServer(t, upstream, lister)
+	defer srv.Close()
+
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	for _, name := range []string{"nodeinfo", "env"} {
+		req, _ := http.NewRequest(http.MethodPost, srv.URL+"/function/"+name+"/sub", nil)
+		req.Header.Set("X-Call-Id", "call-"+name)
+		req.Header.Set("Authorization", "Basic c2VjcmV0")
+
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		res.Body.Close()
+	}
+
+	var lines []string
+	for _, line := range strings.Split(buf.String(), "\n") {
+		if strings.Contains(line, "access function=") {
+			lines = append(lines, line)
+		}
+	}
+
+	if len(lines) != 1 {
+		t.Fatalf("want one access log line for nodeinfo only, got: %q", lines)
+	}
+
+	for _, want := range []string{"function=nodeinfo", "method=POST", "status=202", "size=5", `call_id="call-nodeinfo"`, `Authorization="[redacted]"`} {
+		if !strings.Contains(lines[0], want) {
+			t.Errorf("want %q in the access log line, got: %s", want, lines[0])
+		}
+	}
+	if strings.Contains(lines[0], "c2VjcmV0") {
+		t.Errorf("want the Authorization header to be redacted, got: %s", lines[0])
+	}
+}
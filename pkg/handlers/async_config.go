@@ -0,0 +1,75 @@
+// Copyright 2020 OpenFaaS Author(s)
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/openfaas/faas-netes/pkg/k8s"
+	v1 "k8s.io/client-go/listers/apps/v1"
+)
+
+// AsyncConfig is the configuration of a function for the queue worker
+type AsyncConfig struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+
+	// MaxConcurrency is the most invocations the queue worker should have in flight for the
+	// function, 0 leaves it to the queue worker's own limit
+	MaxConcurrency int `json:"maxConcurrency"`
+}
+
+// MakeAsyncConfigHandler returns the async configuration of a function, so that the queue
+// worker does not deliver more invocations than the function can handle
+func MakeAsyncConfigHandler(defaultNamespace string, deploymentLister v1.DeploymentLister) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Body != nil {
+			defer r.Body.Close()
+		}
+
+		functionName := mux.Vars(r)["name"]
+
+		lookupNamespace := defaultNamespace
+		if namespace := r.URL.Query().Get("namespace"); len(namespace) > 0 {
+			lookupNamespace = namespace
+		}
+
+		if lookupNamespace == "kube-system" {
+			http.Error(w, "unable to list within the kube-system namespace", http.StatusUnauthorized)
+			return
+		}
+
+		deployment, err := deploymentLister.Deployments(lookupNamespace).Get(functionName)
+		if err != nil {
+			status, reason := ProcessErrorReasons(err)
+			log.Printf("Function async config lookup error reason: %s, %v\n", reason, err)
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		// the annotation is validated on deploy, a Deployment edited directly is not
+		maxConcurrency, err := k8s.AsyncMaxConcurrency(deployment.Annotations)
+		if err != nil {
+			log.Printf("Function %s.%s: %s\n", functionName, lookupNamespace, err)
+		}
+
+		out, err := json.Marshal(AsyncConfig{
+			Name:           functionName,
+			Namespace:      lookupNamespace,
+			MaxConcurrency: maxConcurrency,
+		})
+		if err != nil {
+			log.Printf("Function async config json marshal error: %v\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(out)
+	}
+}
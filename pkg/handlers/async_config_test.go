@@ -0,0 +1,63 @@
+// Copyright 2020 OpenFaaS Author(s)
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/openfaas/faas-netes/pkg/k8s"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_MakeAsyncConfigHandler(t *testing.T) {
+	handler := MakeAsyncConfigHandler("openfaas-fn", newTestDeploymentLister(t,
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{
+			Name:        "nodeinfo",
+			Namespace:   "openfaas-fn",
+			Annotations: map[string]string{k8s.AsyncMaxConcurrencyAnnotationKey: "5"},
+		}},
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "env", Namespace: "openfaas-fn"}},
+	))
+
+	cases := []struct {
+		name       string
+		function   string
+		wantStatus int
+		want       int
+	}{
+		{name: "limited function", function: "nodeinfo", wantStatus: http.StatusOK, want: 5},
+		{name: "function without a limit", function: "env", wantStatus: http.StatusOK, want: 0},
+		{name: "missing function", function: "figlet", wantStatus: http.StatusNotFound},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/system/function/"+tc.function+"/async", nil)
+			req = mux.SetURLVars(req, map[string]string{"name": tc.function})
+			rr := httptest.NewRecorder()
+
+			handler(rr, req)
+
+			if rr.Code != tc.wantStatus {
+				t.Fatalf("want status: %d, got: %d, body: %s", tc.wantStatus, rr.Code, rr.Body.String())
+			}
+			if tc.wantStatus != http.StatusOK {
+				return
+			}
+
+			config := AsyncConfig{}
+			if err := json.Unmarshal(rr.Body.Bytes(), &config); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if config.MaxConcurrency != tc.want {
+				t.Errorf("want max concurrency %d, got %d", tc.want, config.MaxConcurrency)
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+// Copyright 2020 OpenFaaS Author(s)
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"sync"
+
+	"github.com/openfaas/faas-netes/pkg/k8s"
+
+	types "github.com/openfaas/faas-provider/types"
+)
+
+// MakeBatchDeployHandler creates a handler that deploys a list of functions with all-or-nothing
+// semantics. The functions are created in parallel and, when any of them fails, every function
+// that was created by the batch is deleted again in the reverse order of creation.
+func MakeBatchDeployHandler(functionNamespace string, factory k8s.FunctionFactory) http.HandlerFunc {
+	secrets := k8s.NewSecretsClient(factory.Client)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Body != nil {
+			defer r.Body.Close()
+		}
+
+		body, _ := ioutil.ReadAll(r.Body)
+
+		var requests []types.FunctionDeployment
+		if err := json.Unmarshal(body, &requests); err != nil {
+			wrappedErr := fmt.Errorf("failed to unmarshal request: %s", err.Error())
+			http.Error(w, wrappedErr.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if len(requests) == 0 {
+			http.Error(w, "the batch must contain at least one function", http.StatusBadRequest)
+			return
+		}
+
+		seen := map[deployResponse]bool{}
+		for i := range requests {
+			request := &requests[i]
+			if err := ValidateFunctionNameLength(request.Service, factory.Config.MaxFunctionNameLength); err != nil {
+				writeErrorCode(w, http.StatusBadRequest, FunctionNameTooLong, err)
+				return
+			}
+
+			if err := ValidateDeployRequest(request); err != nil {
+				wrappedErr := fmt.Errorf("validation failed for %q: %s", request.Service, err.Error())
+				http.Error(w, wrappedErr.Error(), http.StatusBadRequest)
+				return
+			}
+
+			if err := ValidateImagePolicy(request.Image, factory.Config.ImageNameRegex); err != nil {
+				writeErrorCode(w, http.StatusBadRequest, ImagePolicyViolation, err)
+				return
+			}
+
+			annotations := factory.WithDefaultAnnotations(request.Annotations)
+			request.Annotations = &annotations
+
+			if len(request.Namespace) == 0 {
+				request.Namespace = functionNamespace
+			}
+
+			key := deployResponse{Name: request.Service, Namespace: request.Namespace}
+			if seen[key] {
+				wrappedErr := fmt.Errorf("validation failed: %s.%s is in the batch more than once", key.Name, key.Namespace)
+				http.Error(w, wrappedErr.Error(), http.StatusBadRequest)
+				return
+			}
+			seen[key] = true
+		}
+
+		// the per-function quota checks run in parallel, so the whole batch is checked up front
+		counts := map[string]int{}
+		for _, request := range requests {
+			counts[request.Namespace]++
+		}
+		for namespace, count := range counts {
+			if err := factory.CheckFunctionQuotaFor(r.Context(), namespace, count); err != nil {
+				var quotaErr *k8s.QuotaExceededError
+				if errors.As(err, &quotaErr) {
+					http.Error(w, err.Error(), http.StatusTooManyRequests)
+					return
+				}
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+
+		// the context is not cancelled on the first error, a Create that is in flight could
+		// succeed on the API server yet be reported as a context error and so never be rolled back
+		ctx := r.Context()
+
+		var (
+			lock     sync.Mutex
+			created  []deployResponse
+			firstErr error
+			wg       sync.WaitGroup
+		)
+
+		for _, request := range requests {
+			wg.Add(1)
+			go func(request types.FunctionDeployment) {
+				defer wg.Done()
+
+				ok, err := createFunction(ctx, factory, secrets, request.Namespace, request)
+
+				lock.Lock()
+				defer lock.Unlock()
+
+				if ok {
+					created = append(created, deployResponse{Name: request.Service, Namespace: request.Namespace})
+				}
+				if err != nil && firstErr == nil {
+					firstErr = fmt.Errorf("unable to deploy %s.%s: %w", request.Service, request.Namespace, err)
+				}
+			}(request)
+		}
+
+		wg.Wait()
+
+		if firstErr != nil {
+			log.Println(firstErr)
+			annotations := map[deployResponse]map[string]string{}
+			for _, request := range requests {
+				annotations[deployResponse{Name: request.Service, Namespace: request.Namespace}] = *request.Annotations
+			}
+			rollbackFunctions(factory, created, annotations)
+
+			http.Error(w, firstErr.Error(), deployErrorStatus(firstErr))
+			return
+		}
+
+		out, _ := json.Marshal(created)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		w.Write(out)
+	}
+}
+
+// rollbackFunctions deletes the created functions in reverse order, along with their variants,
+// PodDisruptionBudgets and Certificates. The request context may already be cancelled, so a
+// fresh context is used.
+func rollbackFunctions(factory k8s.FunctionFactory, created []deployResponse, annotations map[deployResponse]map[string]string) {
+	ctx := context.Background()
+
+	for i := len(created) - 1; i >= 0; i-- {
+		fn := created[i]
+
+		if err := k8s.DeleteFunctionResources(ctx, factory.Client, fn.Namespace, fn.Name, annotations[fn]); err != nil {
+			log.Printf("Rollback of %s.%s error: %v\n", fn.Name, fn.Namespace, err)
+			continue
+		}
+
+		log.Printf("Rolled back: %s.%s\n", fn.Name, fn.Namespace)
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright 2020 OpenFaaS Author(s)
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/openfaas/faas-netes/pkg/k8s"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_MakeBatchDeployHandler(t *testing.T) {
+	cases := []struct {
+		name       string
+		body       string
+		limit      int
+		wantStatus int
+		wantNames  []string
+		wantPDBs   int
+	}{
+		{
+			name:       "all functions are deployed",
+			body:       `[{"service":"fn1","image":"alpine:latest"},{"service":"fn2","image":"alpine:latest"}]`,
+			wantStatus: http.StatusAccepted,
+			wantNames:  []string{"fn1", "fn2"},
+		},
+		{
+			name:       "a failure rolls back the batch",
+			body:       `[{"service":"fn1","image":"alpine:latest"},{"service":"fn2","image":"alpine:latest","secrets":["missing"]}]`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "a rollback removes the PodDisruptionBudget",
+			body:       `[{"service":"fn1","image":"alpine:latest","labels":{"com.openfaas.scale.min":"2"},"annotations":{"com.openfaas.pdb.min-available":"1"}},{"service":"fn2","image":"alpine:latest","secrets":["missing"]}]`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "a PodDisruptionBudget is kept when the batch succeeds",
+			body:       `[{"service":"fn1","image":"alpine:latest","labels":{"com.openfaas.scale.min":"2"},"annotations":{"com.openfaas.pdb.min-available":"1"}}]`,
+			wantStatus: http.StatusAccepted,
+			wantNames:  []string{"fn1"},
+			wantPDBs:   1,
+		},
+		{
+			name:       "a batch over the function quota is rejected",
+			body:       `[{"service":"fn1","image":"alpine:latest"},{"service":"fn2","image":"alpine:latest"}]`,
+			limit:      1,
+			wantStatus: http.StatusTooManyRequests,
+		},
+		{
+			name:       "an invalid function rejects the batch",
+			body:       `[{"service":"fn1","image":"alpine:latest"},{"service":"","image":"alpine:latest"}]`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "a duplicate function rejects the batch",
+			body:       `[{"service":"fn1","image":"alpine:latest"},{"service":"fn1","image":"alpine:latest"}]`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "an empty batch is rejected",
+			body:       `[]`,
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset()
+			factory := k8s.NewFunctionFactory(client, k8s.DeploymentConfig{
+				LivenessProbe:            &k8s.ProbeConfig{},
+				ReadinessProbe:           &k8s.ProbeConfig{},
+				MaxFunctionsPerNamespace: tc.limit,
+			}, nil)
+
+			handler := MakeBatchDeployHandler("openfaas-fn", factory)
+
+			req := httptest.NewRequest(http.MethodPost, "/system/functions/batch", strings.NewReader(tc.body))
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tc.wantStatus {
+				t.Fatalf("want status %d, got %d: %s", tc.wantStatus, rr.Code, rr.Body.String())
+			}
+
+			deployments, err := client.AppsV1().Deployments("openfaas-fn").List(context.TODO(), metav1.ListOptions{})
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if len(deployments.Items) != len(tc.wantNames) {
+				t.Fatalf("want %d Deployments, got %d", len(tc.wantNames), len(deployments.Items))
+			}
+
+			services, err := client.CoreV1().Services("openfaas-fn").List(context.TODO(), metav1.ListOptions{})
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if len(services.Items) != len(tc.wantNames) {
+				t.Fatalf("want %d Services, got %d", len(tc.wantNames), len(services.Items))
+			}
+
+			pdbs, err := client.PolicyV1().PodDisruptionBudgets("openfaas-fn").List(context.TODO(), metav1.ListOptions{})
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if len(pdbs.Items) != tc.wantPDBs {
+				t.Fatalf("want %d PodDisruptionBudgets, got %d", tc.wantPDBs, len(pdbs.Items))
+			}
+		})
+	}
+}
@@ -0,0 +1,388 @@
+// Copyright 2020 OpenFaaS Author(s)
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/openfaas/faas-netes/pkg/k8s"
+	types "github.com/openfaas/faas-provider/types"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// BlueGreenOfAnnotationKey records the function that a blue/green Deployment serves
+	BlueGreenOfAnnotationKey = "com.openfaas.blue-green.of"
+
+	// SmokeTestPathAnnotationKey is the path requested from the new version of a function
+	// before it is promoted, by default defaultSmokeTestPath
+	SmokeTestPathAnnotationKey = "com.openfaas.smoke-test.path"
+
+	// SmokeTestMethodAnnotationKey is the HTTP method of the smoke test, by default GET
+	SmokeTestMethodAnnotationKey = "com.openfaas.smoke-test.method"
+
+	defaultSmokeTestPath = "/_/health"
+
+	// greenSuffix is appended to the name of the function to name its green Deployment
+	greenSuffix = "-green"
+
+	blueGreenPollInterval = time.Second
+)
+
+// BlueGreenResult describes a promoted blue/green deploy
+type BlueGreenResult struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+
+	// Active is the Deployment that now serves the function, which is always its own
+	Active string `json:"active"`
+
+	// Retired is the green Deployment that served the function while its own Deployment was
+	// rolled out, the expiry sweeper deletes it at DeleteAfter
+	Retired     string    `json:"retired,omitempty"`
+	DeleteAfter time.Time `json:"deleteAfter,omitempty"`
+}
+
+// blueGreenDeployer runs a blue/green deploy, the readiness check and smoke test are fields
+// so that they can be replaced in tests
+type blueGreenDeployer struct {
+	factory      k8s.FunctionFactory
+	secrets      k8s.SecretsClient
+	readyTimeout time.Duration
+	drainDelay   time.Duration
+
+	ready     func(ctx context.Context, namespace, name string) error
+	smokeTest func(ctx context.Context, namespace, name, method, path string) error
+}
+
+// MakeBlueGreenDeployHandler deploys a new version of an existing function alongside the
+// version that is serving it. The function's Service keeps pointing at its own (blue)
+// Deployment until the new (green) `<name>-green` Deployment is ready and has answered a
+// smoke test. The Service is then switched over to the green Deployment while the new
+// version is rolled out to the function's own Deployment, and switched back once it is
+// ready, so that the function is always served by the Deployment named after it.
+//
+// The green Deployment is given a com.openfaas.expires-at of drainDelay, so that the expiry
+// sweeper deletes it once in-flight requests have completed. When the new version is not
+// ready within readyTimeout or its smoke test fails, the green Deployment is deleted and the
+// function is left as it was.
+func MakeBlueGreenDeployHandler(defaultNamespace string, factory k8s.FunctionFactory, readyTimeout, drainDelay time.Duration) http.HandlerFunc {
+	deployer := &blueGreenDeployer{
+		factory:      factory,
+		secrets:      k8s.NewSecretsClient(factory.Client),
+		readyTimeout: readyTimeout,
+		drainDelay:   drainDelay,
+	}
+	deployer.ready = deployer.waitForReady
+	deployer.smokeTest = deployer.requestPod
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Body != nil {
+			defer r.Body.Close()
+		}
+
+		body, _ := ioutil.ReadAll(r.Body)
+
+		request := types.FunctionDeployment{}
+		if err := json.Unmarshal(body, &request); err != nil {
+			http.Error(w, fmt.Sprintf("failed to unmarshal request: %s", err.Error()), http.StatusBadRequest)
+			return
+		}
+
+		if err := ValidateFunctionNameLength(request.Service+greenSuffix, factory.Config.MaxFunctionNameLength); err != nil {
+			writeErrorCode(w, http.StatusBadRequest, FunctionNameTooLong, err)
+			return
+		}
+
+		if err := ValidateDeployRequest(&request); err != nil {
+			http.Error(w, fmt.Sprintf("validation failed: %s", err.Error()), http.StatusBadRequest)
+			return
+		}
+
+		if err := ValidateImagePolicy(request.Image, factory.Config.ImageNameRegex); err != nil {
+			writeErrorCode(w, http.StatusBadRequest, ImagePolicyViolation, err)
+			return
+		}
+
+		namespace := defaultNamespace
+		if len(request.Namespace) > 0 {
+			namespace = request.Namespace
+		}
+
+		if namespace == "kube-system" {
+			http.Error(w, "unable to deploy within the kube-system namespace", http.StatusUnauthorized)
+			return
+		}
+
+		annotations := factory.WithDefaultAnnotations(request.Annotations)
+		request.Annotations = &annotations
+
+		result, err := deployer.Deploy(r.Context(), namespace, request)
+		if err != nil {
+			log.Printf("Blue/green deploy of %s.%s error: %v\n", request.Service, namespace, err)
+			http.Error(w, err.Error(), deployErrorStatus(err))
+			return
+		}
+
+		out, err := json.Marshal(result)
+		if err != nil {
+			log.Printf("Blue/green deploy json marshal error: %v\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(out)
+	}
+}
+
+// Deploy creates the green Deployment of the function, tests it and promotes it by rolling
+// its template out to the function's own Deployment
+func (d *blueGreenDeployer) Deploy(ctx context.Context, namespace string, request types.FunctionDeployment) (*BlueGreenResult, error) {
+	name := request.Service
+	green := name + greenSuffix
+
+	services := d.factory.Client.CoreV1().Services(namespace)
+	deployments := d.factory.Client.AppsV1().Deployments(namespace)
+
+	service, err := services.Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		status, _ := ProcessErrorReasons(err)
+		return nil, &deployError{status, fmt.Errorf("unable to find function %s: %s", name, err)}
+	}
+
+	if _, err := deployments.Get(ctx, name, metav1.GetOptions{}); err != nil {
+		status, _ := ProcessErrorReasons(err)
+		return nil, &deployError{status, fmt.Errorf("unable to find function %s: %s", name, err)}
+	}
+
+	if existing, err := deployments.Get(ctx, green, metav1.GetOptions{}); err == nil {
+		if expiresAt, ok := existing.Annotations[k8s.ExpiresAtAnnotationKey]; ok {
+			return nil, &deployError{http.StatusConflict, fmt.Errorf("deployment %s from the previous blue/green deploy of %s is draining until %s", green, name, expiresAt)}
+		}
+		return nil, &deployError{http.StatusConflict, fmt.Errorf("deployment %s already exists, a blue/green deploy of %s may be in progress", green, name)}
+	} else if !k8serrors.IsNotFound(err) {
+		return nil, &deployError{http.StatusInternalServerError, err}
+	}
+
+	annotations := map[string]string{}
+	for k, v := range *request.Annotations {
+		annotations[k] = v
+	}
+	annotations[BlueGreenOfAnnotationKey] = name
+
+	rendered := request
+	rendered.Service = green
+	rendered.Annotations = &annotations
+
+	greenSpec, _, err := renderFunction(ctx, d.factory, d.secrets, namespace, rendered)
+	if err != nil {
+		return nil, err
+	}
+
+	// the function's own Deployment is rendered up front, so that an invalid request is
+	// rejected before anything is created
+	spec, _, err := renderFunction(ctx, d.factory, d.secrets, namespace, request)
+	if err != nil {
+		return nil, err
+	}
+
+	// the green Deployment counts against the quota until it is retired
+	if err := d.factory.CheckFunctionQuota(ctx, namespace); err != nil {
+		var quotaErr *k8s.QuotaExceededError
+		if errors.As(err, &quotaErr) {
+			return nil, &deployError{http.StatusTooManyRequests, err}
+		}
+		return nil, &deployError{http.StatusInternalServerError, err}
+	}
+
+	if _, err := deployments.Create(ctx, greenSpec, metav1.CreateOptions{}); err != nil {
+		return nil, &deployError{http.StatusInternalServerError, fmt.Errorf("unable create Deployment: %s", err)}
+	}
+	log.Printf("Blue/green: created %s.%s for %s\n", green, namespace, name)
+
+	if err := d.ready(ctx, namespace, green); err != nil {
+		d.discard(namespace, green)
+		return nil, &deployError{http.StatusGatewayTimeout, fmt.Errorf("deployment %s did not become ready: %s", green, err)}
+	}
+
+	method := annotations[SmokeTestMethodAnnotationKey]
+	if len(method) == 0 {
+		method = http.MethodGet
+	}
+	path := annotations[SmokeTestPathAnnotationKey]
+	if len(path) == 0 {
+		path = defaultSmokeTestPath
+	}
+
+	if err := d.smokeTest(ctx, namespace, green, method, path); err != nil {
+		d.discard(namespace, green)
+		return nil, &deployError{http.StatusBadGateway, fmt.Errorf("smoke test of %s failed: %s", green, err)}
+	}
+
+	// the green Deployment serves the function while its own Deployment is rolled out
+	service, err = d.selectDeployment(ctx, service, green)
+	if err != nil {
+		d.discard(namespace, green)
+		return nil, &deployError{http.StatusInternalServerError, fmt.Errorf("unable to promote %s: %s", green, err)}
+	}
+	log.Printf("Blue/green: promoted %s.%s for %s\n", green, namespace, name)
+
+	if err := d.rollout(ctx, namespace, name, spec); err != nil {
+		if _, selectErr := d.selectDeployment(ctx, service, name); selectErr != nil {
+			return nil, &deployError{http.StatusInternalServerError, fmt.Errorf("unable to return %s to its Deployment after a failed rollout: %s", name, selectErr)}
+		}
+		d.discard(namespace, green)
+		return nil, &deployError{http.StatusGatewayTimeout, fmt.Errorf("unable to roll out %s: %s", name, err)}
+	}
+
+	if _, err := d.selectDeployment(ctx, service, name); err != nil {
+		return nil, &deployError{http.StatusInternalServerError, fmt.Errorf("unable to return %s to its Deployment: %s", name, err)}
+	}
+	log.Printf("Blue/green: %s.%s rolled out\n", name, namespace)
+
+	result := &BlueGreenResult{
+		Name:      name,
+		Namespace: namespace,
+		Active:    name,
+		Retired:   green,
+	}
+
+	// in-flight requests to the green Pods are given the drain delay to complete, after which
+	// the expiry sweeper deletes the Deployment, even if the provider has restarted since
+	deleteAfter := time.Now().Add(d.drainDelay).UTC().Truncate(time.Second)
+	if err := d.retire(ctx, namespace, green, deleteAfter); err != nil {
+		log.Printf("Blue/green: unable to mark %s.%s to expire: %v\n", green, namespace, err)
+		d.discard(namespace, green)
+		return result, nil
+	}
+
+	result.DeleteAfter = deleteAfter
+	return result, nil
+}
+
+// selectDeployment points the function's Service at the Pods of the named Deployment
+func (d *blueGreenDeployer) selectDeployment(ctx context.Context, service *corev1.Service, name string) (*corev1.Service, error) {
+	service.Spec.Selector = map[string]string{"faas_function": name}
+	return d.factory.Client.CoreV1().Services(service.Namespace).Update(ctx, service, metav1.UpdateOptions{})
+}
+
+// rollout updates the function's Deployment to the rendered spec and waits for it to become
+// ready, the replica count and the pause and cordon state of the function are kept
+func (d *blueGreenDeployer) rollout(ctx context.Context, namespace, name string, spec *appsv1.Deployment) error {
+	deployments := d.factory.Client.AppsV1().Deployments(namespace)
+
+	current, err := deployments.Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	replicas := current.Spec.Replicas
+	current.Labels = spec.Labels
+	current.Annotations = k8s.CopyCordonAnnotation(current.Annotations, k8s.CopyPauseAnnotations(current.Annotations, spec.Annotations))
+	current.Spec = spec.Spec
+	if replicas != nil {
+		current.Spec.Replicas = replicas
+	}
+
+	if _, err := deployments.Update(ctx, current, metav1.UpdateOptions{}); err != nil {
+		return err
+	}
+
+	return d.ready(ctx, namespace, name)
+}
+
+// retire sets the expiry of the green Deployment, so that the expiry sweeper deletes it
+func (d *blueGreenDeployer) retire(ctx context.Context, namespace, name string, deleteAfter time.Time) error {
+	patch, _ := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{k8s.ExpiresAtAnnotationKey: deleteAfter.Format(time.RFC3339)},
+		},
+	})
+
+	_, err := d.factory.Client.AppsV1().Deployments(namespace).Patch(ctx, name, k8stypes.MergePatchType, patch, metav1.PatchOptions{})
+	return err
+}
+
+// discard deletes a blue/green Deployment, it is used after a failed deploy and to remove the
+// retired colour, so it does not use the request's context
+func (d *blueGreenDeployer) discard(namespace, name string) {
+	foreground := metav1.DeletePropagationForeground
+	err := d.factory.Client.AppsV1().Deployments(namespace).Delete(context.Background(), name, metav1.DeleteOptions{PropagationPolicy: &foreground})
+	if err != nil && !k8serrors.IsNotFound(err) {
+		log.Printf("Blue/green: unable to delete %s.%s: %v\n", name, namespace, err)
+		return
+	}
+	log.Printf("Blue/green: deleted %s.%s\n", name, namespace)
+}
+
+// waitForReady polls the Deployment until all of its replicas are available
+func (d *blueGreenDeployer) waitForReady(ctx context.Context, namespace, name string) error {
+	return wait.PollImmediate(blueGreenPollInterval, d.readyTimeout, func() (bool, error) {
+		deployment, err := d.factory.Client.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		return k8s.FunctionReady(*deployment, 1), nil
+	})
+}
+
+// requestPod sends the smoke test to a ready Pod of the Deployment directly, since the
+// function's Service does not select it until it has been promoted
+func (d *blueGreenDeployer) requestPod(ctx context.Context, namespace, name, method, path string) error {
+	pods, err := d.factory.Client.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: "faas_function=" + name,
+	})
+	if err != nil {
+		return err
+	}
+
+	podIP := ""
+	for _, pod := range pods.Items {
+		if pod.Status.Phase == corev1.PodRunning && len(pod.Status.PodIP) > 0 {
+			podIP = pod.Status.PodIP
+			break
+		}
+	}
+	if len(podIP) == 0 {
+		return fmt.Errorf("no running Pods")
+	}
+
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	url := "http://" + net.JoinHostPort(podIP, watchdogPort) + path
+
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return err
+	}
+
+	client := http.Client{Timeout: 10 * time.Second}
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("%s %s returned %d", method, path, res.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,217 @@
+// Copyright 2020 OpenFaaS Author(s)
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/openfaas/faas-netes/pkg/k8s"
+	types "github.com/openfaas/faas-provider/types"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newBlueGreenDeployer(t *testing.T, smokeErr error) *blueGreenDeployer {
+	client := fake.NewSimpleClientset(
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{
+			Name:      "nodeinfo",
+			Namespace: "openfaas-fn",
+			Labels:    map[string]string{"faas_function": "nodeinfo"},
+		}},
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "nodeinfo", Namespace: "openfaas-fn"},
+			Spec:       corev1.ServiceSpec{Selector: map[string]string{"faas_function": "nodeinfo"}},
+		},
+	)
+	factory := k8s.NewFunctionFactory(client, k8s.DeploymentConfig{
+		LivenessProbe:  &k8s.ProbeConfig{},
+		ReadinessProbe: &k8s.ProbeConfig{},
+	}, nil)
+
+	return &blueGreenDeployer{
+		factory:      factory,
+		secrets:      k8s.NewSecretsClient(client),
+		readyTimeout: time.Second,
+		drainDelay:   time.Hour,
+		ready: func(ctx context.Context, namespace, name string) error {
+			return nil
+		},
+		smokeTest: func(ctx context.Context, namespace, name, method, path string) error {
+			if method != http.MethodGet || path != defaultSmokeTestPath {
+				t.Errorf("want smoke test GET %s, got %s %s", defaultSmokeTestPath, method, path)
+			}
+			return smokeErr
+		},
+	}
+}
+
+func blueGreenRequest() types.FunctionDeployment {
+	return types.FunctionDeployment{
+		Service:     "nodeinfo",
+		Image:       "functions/nodeinfo:v2",
+		Annotations: &map[string]string{},
+	}
+}
+
+func Test_blueGreenDeployer_Promotes(t *testing.T) {
+	deployer := newBlueGreenDeployer(t, nil)
+	ctx := context.Background()
+	client := deployer.factory.Client
+
+	var readied []string
+	deployer.ready = func(ctx context.Context, namespace, name string) error {
+		readied = append(readied, name)
+		return nil
+	}
+
+	result, err := deployer.Deploy(ctx, "openfaas-fn", blueGreenRequest())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if result.Active != "nodeinfo" || result.Retired != "nodeinfo-green" {
+		t.Errorf("want nodeinfo to stay active and nodeinfo-green retired, got active: %s, retired: %s", result.Active, result.Retired)
+	}
+	if want := []string{"nodeinfo-green", "nodeinfo"}; !reflect.DeepEqual(readied, want) {
+		t.Errorf("want readiness checked for %v, got %v", want, readied)
+	}
+
+	service, err := client.CoreV1().Services("openfaas-fn").Get(ctx, "nodeinfo", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got := service.Spec.Selector["faas_function"]; got != "nodeinfo" {
+		t.Errorf("want the Service to select nodeinfo, got %s", got)
+	}
+
+	deployment, err := client.AppsV1().Deployments("openfaas-fn").Get(ctx, "nodeinfo", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got := deployment.Spec.Template.Spec.Containers[0].Image; got != "functions/nodeinfo:v2" {
+		t.Errorf("want the new image rolled out to nodeinfo, got %s", got)
+	}
+	if got := deployment.Spec.Template.Labels["faas_function"]; got != "nodeinfo" {
+		t.Errorf("want the Pods of nodeinfo labelled faas_function=nodeinfo, got %s", got)
+	}
+
+	green, err := client.AppsV1().Deployments("openfaas-fn").Get(ctx, "nodeinfo-green", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("want the green Deployment kept for the drain delay, got error: %s", err)
+	}
+	if got := green.Annotations[BlueGreenOfAnnotationKey]; got != "nodeinfo" {
+		t.Errorf("want %s=nodeinfo, got %q", BlueGreenOfAnnotationKey, got)
+	}
+
+	// the expiry sweeper deletes the green Deployment once the drain delay has passed
+	expiresAt, err := k8s.ExpiresAt(green.Annotations)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !expiresAt.Equal(result.DeleteAfter) {
+		t.Errorf("want %s=%s, got %s", k8s.ExpiresAtAnnotationKey, result.DeleteAfter, expiresAt)
+	}
+	if reason, _ := k8s.FunctionExpiry(green.Annotations, time.Now(), result.DeleteAfter); len(reason) == 0 {
+		t.Errorf("want the green Deployment to expire at %s", result.DeleteAfter)
+	}
+}
+
+func Test_blueGreenDeployer_RolloutFails(t *testing.T) {
+	deployer := newBlueGreenDeployer(t, nil)
+	ctx := context.Background()
+	client := deployer.factory.Client
+
+	deployer.ready = func(ctx context.Context, namespace, name string) error {
+		if name == "nodeinfo" {
+			return errors.New("timed out waiting for the condition")
+		}
+		return nil
+	}
+
+	_, err := deployer.Deploy(ctx, "openfaas-fn", blueGreenRequest())
+	if status := deployErrorStatus(err); status != http.StatusGatewayTimeout {
+		t.Fatalf("want status %d, got %d: %v", http.StatusGatewayTimeout, status, err)
+	}
+
+	service, err := client.CoreV1().Services("openfaas-fn").Get(ctx, "nodeinfo", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got := service.Spec.Selector["faas_function"]; got != "nodeinfo" {
+		t.Errorf("want the Service returned to nodeinfo, got %s", got)
+	}
+
+	if _, err := client.AppsV1().Deployments("openfaas-fn").Get(ctx, "nodeinfo-green", metav1.GetOptions{}); err == nil {
+		t.Errorf("want the green Deployment deleted after a failed rollout")
+	}
+}
+
+func Test_blueGreenDeployer_SmokeTestFails(t *testing.T) {
+	deployer := newBlueGreenDeployer(t, errors.New("GET /_/health returned 500"))
+	ctx := context.Background()
+	client := deployer.factory.Client
+
+	_, err := deployer.Deploy(ctx, "openfaas-fn", blueGreenRequest())
+	if err == nil {
+		t.Fatalf("want an error when the smoke test fails")
+	}
+	if status := deployErrorStatus(err); status != http.StatusBadGateway {
+		t.Errorf("want status %d, got %d", http.StatusBadGateway, status)
+	}
+
+	if _, err := client.AppsV1().Deployments("openfaas-fn").Get(ctx, "nodeinfo-green", metav1.GetOptions{}); err == nil {
+		t.Errorf("want the green Deployment deleted after a failed smoke test")
+	}
+
+	service, err := client.CoreV1().Services("openfaas-fn").Get(ctx, "nodeinfo", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got := service.Spec.Selector["faas_function"]; got != "nodeinfo" {
+		t.Errorf("want the Service left on nodeinfo, got %s", got)
+	}
+}
+
+func Test_blueGreenDeployer_GreenDraining(t *testing.T) {
+	deployer := newBlueGreenDeployer(t, nil)
+	ctx := context.Background()
+	client := deployer.factory.Client
+
+	// the previous deploy retired its green Deployment, which is still draining
+	client.AppsV1().Deployments("openfaas-fn").Create(ctx, &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "nodeinfo-green",
+			Namespace:   "openfaas-fn",
+			Annotations: map[string]string{k8s.ExpiresAtAnnotationKey: "2026-10-15T12:00:00Z"},
+		},
+	}, metav1.CreateOptions{})
+
+	_, err := deployer.Deploy(ctx, "openfaas-fn", blueGreenRequest())
+	if status := deployErrorStatus(err); status != http.StatusConflict {
+		t.Errorf("want status %d, got %d: %v", http.StatusConflict, status, err)
+	}
+}
+
+func Test_blueGreenDeployer_QuotaExceeded(t *testing.T) {
+	deployer := newBlueGreenDeployer(t, nil)
+	deployer.factory.Config.MaxFunctionsPerNamespace = 1
+	ctx := context.Background()
+	client := deployer.factory.Client
+
+	_, err := deployer.Deploy(ctx, "openfaas-fn", blueGreenRequest())
+	if status := deployErrorStatus(err); status != http.StatusTooManyRequests {
+		t.Errorf("want status %d, got %d: %v", http.StatusTooManyRequests, status, err)
+	}
+
+	if _, err := client.AppsV1().Deployments("openfaas-fn").Get(ctx, "nodeinfo-green", metav1.GetOptions{}); err == nil {
+		t.Errorf("want no green Deployment over the function quota")
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright 2020 OpenFaaS Author(s)
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/openfaas/faas-netes/pkg/k8s"
+)
+
+// CacheRefreshTokenHeader carries the token that is required to refresh the caches, it must
+// match the cache_refresh_token that the provider was started with
+const CacheRefreshTokenHeader = "X-Cache-Refresh-Token"
+
+// CacheRefreshResponse is the number of objects in each cache after it was refreshed
+type CacheRefreshResponse struct {
+	Refreshed map[string]int `json:"refreshed"`
+	Total     int            `json:"total"`
+}
+
+// MakeCacheRefreshHandler re-lists the resources of the informer caches from the API server,
+// for when the caches are known to be stale, i.e. after Deployments were edited with kubectl.
+// Requests without the token in the X-Cache-Refresh-Token header are rejected with a 401.
+func MakeCacheRefreshHandler(token string, refreshers ...k8s.CacheRefresher) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Body != nil {
+			defer r.Body.Close()
+		}
+
+		given := r.Header.Get(CacheRefreshTokenHeader)
+		if len(token) == 0 || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			http.Error(w, "a valid "+CacheRefreshTokenHeader+" header is required", http.StatusUnauthorized)
+			return
+		}
+
+		res := CacheRefreshResponse{Refreshed: map[string]int{}}
+		for _, refresher := range refreshers {
+			count, err := refresher.Refresh(r.Context())
+			if err != nil {
+				log.Printf("Cache refresh error: %s\n", err)
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			res.Refreshed[refresher.Resource] = count
+			res.Total += count
+		}
+
+		log.Printf("Refreshed the caches: %v\n", res.Refreshed)
+
+		out, err := json.Marshal(res)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(out)
+	}
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/openfaas/faas-netes/pkg/k8s"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubeinformers "k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_MakeCacheRefreshHandler(t *testing.T) {
+	meta := func(name string) metav1.ObjectMeta {
+		return metav1.ObjectMeta{Name: name, Namespace: "openfaas-fn"}
+	}
+
+	cases := []struct {
+		name       string
+		token      string
+		wantStatus int
+	}{
+		{name: "missing token", token: "", wantStatus: http.StatusUnauthorized},
+		{name: "wrong token", token: "guess", wantStatus: http.StatusUnauthorized},
+		{name: "valid token", token: "s3cr3t", wantStatus: http.StatusOK},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset(
+				&appsv1.Deployment{ObjectMeta: meta("figlet")},
+				&appsv1.Deployment{ObjectMeta: meta("nodeinfo")},
+				&corev1.Endpoints{ObjectMeta: meta("figlet")},
+			)
+			factory := kubeinformers.NewSharedInformerFactoryWithOptions(client, time.Minute, kubeinformers.WithNamespace("openfaas-fn"))
+			deployments := factory.Apps().V1().Deployments()
+			endpoints := factory.Core().V1().Endpoints()
+
+			// the cache is stale, it has a function that was deleted with kubectl
+			deployments.Informer().GetStore().Add(&appsv1.Deployment{ObjectMeta: meta("deleted")})
+
+			handler := MakeCacheRefreshHandler("s3cr3t",
+				k8s.NewDeploymentCacheRefresher(client, "openfaas-fn", deployments),
+				k8s.NewEndpointsCacheRefresher(client, "openfaas-fn", endpoints))
+
+			req := httptest.NewRequest(http.MethodPost, "/-/cache/refresh", nil)
+			if len(tc.token) > 0 {
+				req.Header.Set(CacheRefreshTokenHeader, tc.token)
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tc.wantStatus {
+				t.Fatalf("want status %d, got %d: %s", tc.wantStatus, rr.Code, rr.Body.String())
+			}
+
+			keys := deployments.Informer().GetStore().ListKeys()
+			if tc.wantStatus != http.StatusOK {
+				if len(keys) != 1 {
+					t.Errorf("want the cache to be unchanged, got %v", keys)
+				}
+				return
+			}
+
+			var res CacheRefreshResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &res); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if res.Refreshed["deployments"] != 2 || res.Refreshed["endpoints"] != 1 || res.Total != 3 {
+				t.Errorf("want 2 deployments and 1 endpoints, got %+v", res)
+			}
+
+			if _, exists, _ := deployments.Informer().GetStore().GetByKey("openfaas-fn/deleted"); exists {
+				t.Errorf("want the deleted Deployment to be removed from the cache")
+			}
+			if _, err := deployments.Lister().Deployments("openfaas-fn").Get("nodeinfo"); err != nil {
+				t.Errorf("want the Deployment to be in the cache, got: %s", err)
+			}
+		})
+	}
+}
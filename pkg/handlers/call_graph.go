@@ -0,0 +1,111 @@
+// Copyright 2020 OpenFaaS Author(s)
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/openfaas/faas-netes/pkg/k8s"
+)
+
+const (
+	// callGraphCacheTTL is how long a call graph is served from the cache, building one can
+	// read hundreds of traces from the trace backend
+	callGraphCacheTTL = 60 * time.Second
+
+	// defaultCallGraphSince is the time range of the call graph when since is not given
+	defaultCallGraphSince = "1h"
+)
+
+type cachedCallGraph struct {
+	graph   k8s.CallGraph
+	expires time.Time
+}
+
+// MakeCallGraphHandler returns the graph of the calls between functions from the traces in
+// the trace backend. The time range is set with `since`, as an RFC3339 time or a duration
+// such as 10m, and an optional RFC3339 `until`. Results are cached for 60 seconds per range.
+func MakeCallGraphHandler(querier k8s.TraceQuerier) http.HandlerFunc {
+	var mu sync.Mutex
+	cache := map[string]cachedCallGraph{}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Body != nil {
+			defer r.Body.Close()
+		}
+
+		q := r.URL.Query()
+		sinceValue := q.Get("since")
+		if len(sinceValue) == 0 {
+			sinceValue = defaultCallGraphSince
+		}
+		untilValue := q.Get("until")
+		key := sinceValue + "|" + untilValue
+
+		now := time.Now()
+
+		mu.Lock()
+		cached, ok := cache[key]
+		mu.Unlock()
+
+		graph := cached.graph
+		if !ok || now.After(cached.expires) {
+			start, err := parseSinceParam(sinceValue)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			end := now
+			if len(untilValue) > 0 {
+				end, err = time.Parse(time.RFC3339, untilValue)
+				if err != nil {
+					http.Error(w, fmt.Sprintf("invalid until: %q, must be an RFC3339 time", untilValue), http.StatusBadRequest)
+					return
+				}
+			}
+
+			if !start.Before(end) {
+				http.Error(w, "since must be before until", http.StatusBadRequest)
+				return
+			}
+
+			spans, err := querier.QuerySpans(r.Context(), start, end)
+			if err != nil {
+				log.Printf("Call graph trace query error: %v\n", err)
+				http.Error(w, err.Error(), http.StatusBadGateway)
+				return
+			}
+
+			graph = k8s.BuildCallGraph(spans)
+			graph.Start = start.UTC()
+			graph.End = end.UTC()
+
+			mu.Lock()
+			for k, v := range cache {
+				if now.After(v.expires) {
+					delete(cache, k)
+				}
+			}
+			cache[key] = cachedCallGraph{graph: graph, expires: now.Add(callGraphCacheTTL)}
+			mu.Unlock()
+		}
+
+		out, err := json.Marshal(graph)
+		if err != nil {
+			log.Printf("Call graph json marshal error: %v\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(out)
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright 2020 OpenFaaS Author(s)
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/openfaas/faas-netes/pkg/k8s"
+)
+
+type testTraceQuerier struct {
+	calls int
+	spans []k8s.TraceSpan
+	err   error
+}
+
+func (q *testTraceQuerier) QuerySpans(ctx context.Context, start, end time.Time) ([]k8s.TraceSpan, error) {
+	q.calls++
+	return q.spans, q.err
+}
+
+func Test_MakeCallGraphHandler(t *testing.T) {
+	querier := &testTraceQuerier{
+		spans: []k8s.TraceSpan{
+			{TraceID: "t1", SpanID: "a", Function: "checkout"},
+			{TraceID: "t1", SpanID: "b", ParentSpanID: "a", Function: "stock"},
+		},
+	}
+	handler := MakeCallGraphHandler(querier)
+
+	for i := 0; i < 2; i++ {
+		rr := httptest.NewRecorder()
+		handler(rr, httptest.NewRequest(http.MethodGet, "/system/functions/call-graph?since=30m", nil))
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("want status: %d, got: %d, body: %s", http.StatusOK, rr.Code, rr.Body.String())
+		}
+
+		var graph k8s.CallGraph
+		if err := json.Unmarshal(rr.Body.Bytes(), &graph); err != nil {
+			t.Fatalf("unable to decode response: %s", err)
+		}
+		if len(graph.Edges) != 1 || graph.Edges[0].Caller != "checkout" || graph.Edges[0].Callee != "stock" {
+			t.Errorf("want an edge from checkout to stock, got: %v", graph.Edges)
+		}
+		if got := graph.End.Sub(graph.Start).Round(time.Minute); got != 30*time.Minute {
+			t.Errorf("want a range of 30m, got: %s", got)
+		}
+	}
+
+	if querier.calls != 1 {
+		t.Errorf("want the second request to be served from the cache, got %d queries", querier.calls)
+	}
+
+	rr := httptest.NewRecorder()
+	handler(rr, httptest.NewRequest(http.MethodGet, "/system/functions/call-graph", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("want status: %d, got: %d", http.StatusOK, rr.Code)
+	}
+	if querier.calls != 2 {
+		t.Errorf("want a different range to be queried, got %d queries", querier.calls)
+	}
+}
+
+func Test_MakeCallGraphHandler_Errors(t *testing.T) {
+	cases := []struct {
+		name       string
+		query      string
+		err        error
+		wantStatus int
+	}{
+		{name: "invalid since", query: "?since=yesterday", wantStatus: http.StatusBadRequest},
+		{name: "invalid until", query: "?until=now", wantStatus: http.StatusBadRequest},
+		{name: "until before since", query: "?since=2020-01-02T00:00:00Z&until=2020-01-01T00:00:00Z", wantStatus: http.StatusBadRequest},
+		{name: "trace backend error", err: fmt.Errorf("connection refused"), wantStatus: http.StatusBadGateway},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := MakeCallGraphHandler(&testTraceQuerier{err: tc.err})
+
+			rr := httptest.NewRecorder()
+			handler(rr, httptest.NewRequest(http.MethodGet, "/system/functions/call-graph"+tc.query, nil))
+
+			if rr.Code != tc.wantStatus {
+				t.Errorf("want status: %d, got: %d", tc.wantStatus, rr.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright 2020 OpenFaaS Author(s)
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"regexp"
+
+	"github.com/openfaas/faas-netes/pkg/config"
+	"github.com/openfaas/faas-netes/pkg/k8s"
+)
+
+// redactedValue replaces the value of sensitive configuration fields
+const redactedValue = "[redacted]"
+
+// sensitiveConfigKey matches the names of configuration fields whose values must not be returned
+var sensitiveConfigKey = regexp.MustCompile(`(?i)(secret|password|token|license|credential)`)
+
+// MakeConfigHandler returns the effective configuration of the provider, the same values
+// that are printed by config.Fprint, with any sensitive values redacted.
+func MakeConfigHandler(bootstrapConfig config.BootstrapConfig, deploymentConfig k8s.DeploymentConfig) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Body != nil {
+			defer r.Body.Close()
+		}
+
+		effective, err := redactConfig(map[string]interface{}{
+			"bootstrap":  bootstrapConfig,
+			"deployment": deploymentConfig,
+		})
+		if err != nil {
+			log.Printf("Config handler error: %v\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		out, err := json.Marshal(effective)
+		if err != nil {
+			log.Printf("Config json marshal error: %v\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(out)
+	}
+}
+
+// redactConfig converts the value to generic JSON and redacts the non-empty values
+// of any field with a sensitive name, at any depth.
+func redactConfig(value interface{}) (interface{}, error) {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var generic interface{}
+	if err := json.Unmarshal(raw, &generic); err != nil {
+		return nil, err
+	}
+
+	return redactValue(generic), nil
+}
+
+func redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			if sensitiveConfigKey.MatchString(key) && !isEmptyValue(item) {
+				v[key] = redactedValue
+				continue
+			}
+			v[key] = redactValue(item)
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = redactValue(item)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+func isEmptyValue(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return len(v) == 0
+	default:
+		return false
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright 2020 OpenFaaS Author(s)
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/openfaas/faas-netes/pkg/config"
+	"github.com/openfaas/faas-netes/pkg/k8s"
+)
+
+func Test_MakeConfigHandler(t *testing.T) {
+	bootstrapConfig := config.BootstrapConfig{
+		DefaultFunctionNamespace: "openfaas-fn",
+		ImagePullPolicy:          "Always",
+	}
+	bootstrapConfig.FaaSConfig.SecretMountPath = "/var/secrets"
+	bootstrapConfig.FaaSConfig.EnableBasicAuth = true
+
+	handler := MakeConfigHandler(bootstrapConfig, k8s.DeploymentConfig{RuntimeHTTPPort: 8080})
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/system/config", nil))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("want status: %d, got: %d", http.StatusOK, rr.Code)
+	}
+
+	got := struct {
+		Bootstrap struct {
+			DefaultFunctionNamespace string
+			FaaSConfig               map[string]interface{}
+		}
+		Deployment struct {
+			RuntimeHTTPPort int32
+		}
+	}{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if got.Bootstrap.DefaultFunctionNamespace != "openfaas-fn" {
+		t.Errorf("want DefaultFunctionNamespace: openfaas-fn, got: %q", got.Bootstrap.DefaultFunctionNamespace)
+	}
+	if got.Deployment.RuntimeHTTPPort != 8080 {
+		t.Errorf("want RuntimeHTTPPort: 8080, got: %d", got.Deployment.RuntimeHTTPPort)
+	}
+	if v := got.Bootstrap.FaaSConfig["SecretMountPath"]; v != redactedValue {
+		t.Errorf("want SecretMountPath to be redacted, got: %v", v)
+	}
+	if v := got.Bootstrap.FaaSConfig["EnableBasicAuth"]; v != true {
+		t.Errorf("want EnableBasicAuth: true, got: %v", v)
+	}
+}
+
+func Test_redactConfig(t *testing.T) {
+	got, err := redactConfig(map[string]interface{}{
+		"LicenseToken": "abc",
+		"Password":     "",
+		"Nested":       map[string]string{"apiToken": "xyz", "name": "fn"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	m := got.(map[string]interface{})
+	if m["LicenseToken"] != redactedValue {
+		t.Errorf("want LicenseToken redacted, got: %v", m["LicenseToken"])
+	}
+	if m["Password"] != "" {
+		t.Errorf("want empty values to be left empty, got: %v", m["Password"])
+	}
+
+	nested := m["Nested"].(map[string]interface{})
+	if nested["apiToken"] != redactedValue || nested["name"] != "fn" {
+		t.Errorf("want nested values redacted by name, got: %v", nested)
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright 2020 OpenFaaS Author(s)
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/openfaas/faas-netes/pkg/k8s"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/kubernetes"
+)
+
+// PodContainers lists the containers of a Pod of a function that logs can be read from
+type PodContainers struct {
+	Pod            string   `json:"pod"`
+	InitContainers []string `json:"initContainers"`
+	Containers     []string `json:"containers"`
+}
+
+// MakeContainerLogHandler wraps the log handler from faas-provider so that logs can be read from
+// a container other than the function's, such as an init container that failed, with
+// `?container=<name>`. With `?list-containers=true` the containers of each Pod of the function
+// are returned instead of its logs. The container is only honoured by the Kubernetes log
+// backend.
+func MakeContainerLogHandler(next http.HandlerFunc, defaultNamespace string, clientset kubernetes.Interface) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+
+		if query.Get("list-containers") == "true" {
+			listFunctionContainers(w, r, defaultNamespace, clientset)
+			return
+		}
+
+		if container := query.Get("container"); len(container) > 0 {
+			if errs := validation.IsDNS1123Label(container); len(errs) > 0 {
+				http.Error(w, fmt.Sprintf("invalid container: %q, %s", container, strings.Join(errs, ", ")), http.StatusBadRequest)
+				return
+			}
+			r = r.WithContext(k8s.WithLogContainer(r.Context(), container))
+		}
+
+		next(w, r)
+	}
+}
+
+func listFunctionContainers(w http.ResponseWriter, r *http.Request, defaultNamespace string, clientset kubernetes.Interface) {
+	query := r.URL.Query()
+
+	functionName := query.Get("name")
+	if len(functionName) == 0 {
+		http.Error(w, "provide a function name with ?name=", http.StatusBadRequest)
+		return
+	}
+
+	lookupNamespace := defaultNamespace
+	if namespace := query.Get("namespace"); len(namespace) > 0 {
+		lookupNamespace = namespace
+	}
+
+	if lookupNamespace == "kube-system" {
+		http.Error(w, "unable to list within the kube-system namespace", http.StatusUnauthorized)
+		return
+	}
+
+	pods, err := clientset.CoreV1().Pods(lookupNamespace).List(r.Context(), metav1.ListOptions{
+		LabelSelector: "faas_function=" + functionName,
+	})
+	if err != nil {
+		status, reason := ProcessErrorReasons(err)
+		log.Printf("Function containers list error reason: %s, %v\n", reason, err)
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	result := []PodContainers{}
+	for _, pod := range pods.Items {
+		containers := PodContainers{
+			Pod:            pod.Name,
+			InitContainers: []string{},
+			Containers:     []string{},
+		}
+		for _, c := range pod.Spec.InitContainers {
+			containers.InitContainers = append(containers.InitContainers, c.Name)
+		}
+		for _, c := range pod.Spec.Containers {
+			containers.Containers = append(containers.Containers, c.Name)
+		}
+		result = append(result, containers)
+	}
+
+	out, err := json.Marshal(result)
+	if err != nil {
+		log.Printf("Function containers json marshal error: %v\n", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(out)
+}
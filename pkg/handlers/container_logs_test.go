@@ -0,0 +1,90 @@
+// Copyright 2020 OpenFaaS Author(s)
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/openfaas/faas-netes/pkg/k8s"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_MakeContainerLogHandler_Container(t *testing.T) {
+	cases := []struct {
+		name       string
+		query      string
+		want       string
+		wantStatus int
+	}{
+		{name: "function container", query: "?name=nodeinfo", want: "", wantStatus: http.StatusOK},
+		{name: "init container", query: "?name=nodeinfo&container=init-config", want: "init-config", wantStatus: http.StatusOK},
+		{name: "invalid container", query: "?name=nodeinfo&container=Init_Config", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := ""
+			next := func(w http.ResponseWriter, r *http.Request) {
+				got = k8s.LogContainer(r.Context())
+				w.WriteHeader(http.StatusOK)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/system/logs"+tc.query, nil)
+			rr := httptest.NewRecorder()
+			MakeContainerLogHandler(next, "openfaas-fn", fake.NewSimpleClientset()).ServeHTTP(rr, req)
+
+			if rr.Code != tc.wantStatus {
+				t.Fatalf("want status %d, got %d", tc.wantStatus, rr.Code)
+			}
+			if got != tc.want {
+				t.Errorf("want container %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func Test_MakeContainerLogHandler_ListContainers(t *testing.T) {
+	client := fake.NewSimpleClientset(&corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "nodeinfo-abc",
+			Namespace: "openfaas-fn",
+			Labels:    map[string]string{"faas_function": "nodeinfo"},
+		},
+		Spec: corev1.PodSpec{
+			InitContainers: []corev1.Container{{Name: "init-config"}},
+			Containers:     []corev1.Container{{Name: "nodeinfo"}},
+		},
+	})
+
+	next := func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("want the containers listed without reading logs")
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/system/logs?name=nodeinfo&list-containers=true", nil)
+	rr := httptest.NewRecorder()
+	MakeContainerLogHandler(next, "openfaas-fn", client).ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("want status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+
+	got := []PodContainers{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(got) != 1 || got[0].Pod != "nodeinfo-abc" {
+		t.Fatalf("want one Pod nodeinfo-abc, got %v", got)
+	}
+	if len(got[0].InitContainers) != 1 || got[0].InitContainers[0] != "init-config" {
+		t.Errorf("want init container init-config, got %v", got[0].InitContainers)
+	}
+	if len(got[0].Containers) != 1 || got[0].Containers[0] != "nodeinfo" {
+		t.Errorf("want container nodeinfo, got %v", got[0].Containers)
+	}
+}
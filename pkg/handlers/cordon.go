@@ -0,0 +1,124 @@
+// Copyright 2020 OpenFaaS Author(s)
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/openfaas/faas-netes/pkg/k8s"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// CordonResult is the outcome of a namespace cordon for a single function
+type CordonResult struct {
+	Name string `json:"name"`
+
+	// Changed is false when the function was already in the requested state
+	Changed bool   `json:"changed"`
+	Error   string `json:"error,omitempty"`
+}
+
+// NamespaceCordonResult lists the functions of a namespace that were cordoned or uncordoned
+type NamespaceCordonResult struct {
+	Namespace string         `json:"namespace"`
+	Cordoned  bool           `json:"cordoned"`
+	Functions []CordonResult `json:"functions"`
+}
+
+// MakeNamespaceCordonHandler cordons every function in the namespace, so that the proxy stops
+// sending traffic to them while their replicas keep running
+func MakeNamespaceCordonHandler(defaultNamespace string, clientset kubernetes.Interface) http.HandlerFunc {
+	return makeNamespaceCordonHandler(defaultNamespace, clientset, true)
+}
+
+// MakeNamespaceUncordonHandler removes the cordon from every function in the namespace
+func MakeNamespaceUncordonHandler(defaultNamespace string, clientset kubernetes.Interface) http.HandlerFunc {
+	return makeNamespaceCordonHandler(defaultNamespace, clientset, false)
+}
+
+// makeNamespaceCordonHandler sets or removes the cordon on each function in the namespace. A
+// function that fails to update is reported in the result and does not stop the others.
+func makeNamespaceCordonHandler(defaultNamespace string, clientset kubernetes.Interface, cordon bool) http.HandlerFunc {
+	change := k8s.Uncordon
+	if cordon {
+		change = k8s.Cordon
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Body != nil {
+			defer r.Body.Close()
+		}
+
+		lookupNamespace := defaultNamespace
+		if namespace := r.URL.Query().Get("namespace"); len(namespace) > 0 {
+			lookupNamespace = namespace
+		}
+
+		if lookupNamespace == "kube-system" {
+			http.Error(w, "unable to list within the kube-system namespace", http.StatusUnauthorized)
+			return
+		}
+
+		ctx := r.Context()
+
+		res, err := clientset.AppsV1().Deployments(lookupNamespace).List(ctx, metav1.ListOptions{
+			LabelSelector: "faas_function",
+		})
+		if err != nil {
+			status, reason := ProcessErrorReasons(err)
+			log.Printf("Namespace cordon list error reason: %s, %v\n", reason, err)
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		result := NamespaceCordonResult{
+			Namespace: lookupNamespace,
+			Cordoned:  cordon,
+			Functions: []CordonResult{},
+		}
+
+		for i := range res.Items {
+			deployment := &res.Items[i]
+			if !isFunction(deployment) {
+				continue
+			}
+
+			item := CordonResult{Name: deployment.Name}
+			if change(deployment) {
+				if _, err := clientset.AppsV1().Deployments(lookupNamespace).Update(ctx, deployment, metav1.UpdateOptions{}); err != nil {
+					item.Error = err.Error()
+				} else {
+					item.Changed = true
+				}
+			}
+			result.Functions = append(result.Functions, item)
+		}
+
+		log.Printf("Namespace %s cordoned: %v, functions: %d\n", lookupNamespace, cordon, countChanged(result.Functions))
+
+		out, err := json.Marshal(result)
+		if err != nil {
+			log.Printf("Namespace cordon json marshal error: %v\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(out)
+	}
+}
+
+func countChanged(results []CordonResult) int {
+	n := 0
+	for _, r := range results {
+		if r.Changed {
+			n++
+		}
+	}
+	return n
+}
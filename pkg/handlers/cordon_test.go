@@ -0,0 +1,98 @@
+// Copyright 2020 OpenFaaS Author(s)
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/openfaas/faas-netes/pkg/k8s"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func functionDeployment(name string, annotations map[string]string) *appsv1.Deployment {
+	return &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{
+		Name:        name,
+		Namespace:   "openfaas-fn",
+		Labels:      map[string]string{"faas_function": name},
+		Annotations: annotations,
+	}}
+}
+
+func Test_MakeNamespaceCordonHandler(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		functionDeployment("nodeinfo", nil),
+		functionDeployment("env", map[string]string{k8s.CordonedAnnotationKey: "true"}),
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "openfaas-fn"}},
+	)
+
+	req := httptest.NewRequest(http.MethodPost, "/system/functions/cordon", nil)
+	rr := httptest.NewRecorder()
+	MakeNamespaceCordonHandler("openfaas-fn", client).ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("want status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+
+	result := NamespaceCordonResult{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &result); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !result.Cordoned || len(result.Functions) != 2 {
+		t.Fatalf("want two functions cordoned, got %+v", result)
+	}
+
+	changed := map[string]bool{}
+	for _, fn := range result.Functions {
+		changed[fn.Name] = fn.Changed
+	}
+	if !changed["nodeinfo"] || changed["env"] {
+		t.Errorf("want only nodeinfo changed, got %v", changed)
+	}
+
+	for _, name := range []string{"nodeinfo", "env"} {
+		deployment, _ := client.AppsV1().Deployments("openfaas-fn").Get(context.Background(), name, metav1.GetOptions{})
+		if !k8s.IsCordoned(deployment.Annotations) {
+			t.Errorf("want %s cordoned", name)
+		}
+	}
+	other, _ := client.AppsV1().Deployments("openfaas-fn").Get(context.Background(), "other", metav1.GetOptions{})
+	if k8s.IsCordoned(other.Annotations) {
+		t.Errorf("want a Deployment which is not a function left alone")
+	}
+}
+
+func Test_MakeNamespaceUncordonHandler(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		functionDeployment("nodeinfo", map[string]string{k8s.CordonedAnnotationKey: "true"}),
+	)
+
+	req := httptest.NewRequest(http.MethodDelete, "/system/functions/cordon", nil)
+	rr := httptest.NewRecorder()
+	MakeNamespaceUncordonHandler("openfaas-fn", client).ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("want status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+
+	deployment, _ := client.AppsV1().Deployments("openfaas-fn").Get(context.Background(), "nodeinfo", metav1.GetOptions{})
+	if k8s.IsCordoned(deployment.Annotations) {
+		t.Errorf("want nodeinfo uncordoned")
+	}
+}
+
+func Test_MakeNamespaceCordonHandler_KubeSystem(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/system/functions/cordon?namespace=kube-system", nil)
+	rr := httptest.NewRecorder()
+	MakeNamespaceCordonHandler("openfaas-fn", fake.NewSimpleClientset()).ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("want status %d, got %d", http.StatusUnauthorized, rr.Code)
+	}
+}
@@ -9,8 +9,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"log"
 	"net/http"
 
+	"github.com/openfaas/faas-netes/pkg/k8s"
 	"github.com/openfaas/faas-provider/types"
 	appsv1 "k8s.io/api/apps/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -74,6 +76,16 @@ func MakeDeleteHandler(defaultNamespace string, clientset *kubernetes.Clientset)
 			if err != nil {
 				return
 			}
+
+			if err := k8s.DeletePDB(r.Context(), clientset, lookupNamespace, request.FunctionName); err != nil {
+				log.Printf("PodDisruptionBudget delete error: %v\n", err)
+			}
+
+			if len(deployment.Annotations[k8s.TLSDomainAnnotationKey]) > 0 {
+				if err := k8s.NewCertificateClient(clientset).Delete(r.Context(), lookupNamespace, request.FunctionName); err != nil {
+					log.Printf("Certificate delete error: %v\n", err)
+				}
+			}
 		} else {
 			w.WriteHeader(http.StatusBadRequest)
 
@@ -7,6 +7,7 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"log"
@@ -14,6 +15,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/openfaas/faas-netes/pkg/k8s"
 
@@ -23,13 +25,15 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // initialReplicasCount how many replicas to start of creating for a function
 const initialReplicasCount = 1
 
-// MakeDeployHandler creates a handler to create new functions in the cluster
+// MakeDeployHandler creates a handler to create new functions in the cluster. With `?render=true`,
+// or when DeploymentConfig.RenderManifests is set, the manifests are returned instead of applied.
 func MakeDeployHandler(functionNamespace string, factory k8s.FunctionFactory) http.HandlerFunc {
 	secrets := k8s.NewSecretsClient(factory.Client)
 
@@ -50,75 +54,381 @@ func MakeDeployHandler(functionNamespace string, factory k8s.FunctionFactory) ht
 			return
 		}
 
+		if err := ValidateFunctionNameLength(request.Service, factory.Config.MaxFunctionNameLength); err != nil {
+			writeErrorCode(w, http.StatusBadRequest, FunctionNameTooLong, err)
+			return
+		}
+
 		if err := ValidateDeployRequest(&request); err != nil {
 			wrappedErr := fmt.Errorf("validation failed: %s", err.Error())
 			http.Error(w, wrappedErr.Error(), http.StatusBadRequest)
 			return
 		}
 
-		namespace := functionNamespace
-		if len(request.Namespace) > 0 {
-			namespace = request.Namespace
+		if err := ValidateImagePolicy(request.Image, factory.Config.ImageNameRegex); err != nil {
+			writeErrorCode(w, http.StatusBadRequest, ImagePolicyViolation, err)
+			return
 		}
 
-		existingSecrets, err := secrets.GetSecrets(namespace, request.Secrets)
-		if err != nil {
-			wrappedErr := fmt.Errorf("unable to fetch secrets: %s", err.Error())
-			http.Error(w, wrappedErr.Error(), http.StatusBadRequest)
+		if factory.Breaker.IsOpen() {
+			http.Error(w, k8s.ErrCircuitOpen.Error(), http.StatusServiceUnavailable)
 			return
 		}
 
-		deploymentSpec, specErr := makeDeploymentSpec(request, existingSecrets, factory)
+		annotations := factory.WithDefaultAnnotations(request.Annotations)
+		request.Annotations = &annotations
+
+		namespace := functionNamespace
+		if len(request.Namespace) > 0 {
+			namespace = request.Namespace
+		}
 
-		var profileList []k8s.Profile
-		if request.Annotations != nil {
-			profileNamespace := factory.Config.ProfilesNamespace
-			profileList, err = factory.GetProfiles(ctx, profileNamespace, *request.Annotations)
+		autoVersion := r.URL.Query().Get("auto_version") == "true"
+		if autoVersion {
+			name, err := nextFunctionVersion(ctx, factory, namespace, request.Service)
 			if err != nil {
-				wrappedErr := fmt.Errorf("failed create Deployment spec: %s", err.Error())
+				wrappedErr := fmt.Errorf("unable to determine function version: %s", err.Error())
 				log.Println(wrappedErr)
+				http.Error(w, wrappedErr.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			request.Service = name
+			if err := ValidateFunctionNameLength(request.Service, factory.Config.MaxFunctionNameLength); err != nil {
+				writeErrorCode(w, http.StatusBadRequest, FunctionNameTooLong, err)
+				return
+			}
+			if err := ValidateDeployRequest(&request); err != nil {
+				wrappedErr := fmt.Errorf("validation failed: %s", err.Error())
 				http.Error(w, wrappedErr.Error(), http.StatusBadRequest)
 				return
 			}
 		}
-		for _, profile := range profileList {
-			factory.ApplyProfile(profile, deploymentSpec)
+
+		if factory.Config.RenderManifests || r.URL.Query().Get("render") == "true" {
+			manifests, err := renderManifests(ctx, factory, secrets, namespace, request)
+			if err != nil {
+				log.Println(err)
+				http.Error(w, err.Error(), deployErrorStatus(err))
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			w.Write(manifests)
+			return
 		}
 
-		if specErr != nil {
-			wrappedErr := fmt.Errorf("failed create Deployment spec: %s", specErr.Error())
-			log.Println(wrappedErr)
-			http.Error(w, wrappedErr.Error(), http.StatusBadRequest)
+		if err := checkImage(ctx, factory, namespace, request); err != nil {
+			writeErrorCode(w, http.StatusBadRequest, ImageNotFound, err)
 			return
 		}
 
-		deploy := factory.Client.AppsV1().Deployments(namespace)
+		if _, err := createFunction(ctx, factory, secrets, namespace, request); err != nil {
+			log.Println(err)
+			http.Error(w, err.Error(), deployErrorStatus(err))
+			return
+		}
 
-		_, err = deploy.Create(context.TODO(), deploymentSpec, metav1.CreateOptions{})
-		if err != nil {
-			wrappedErr := fmt.Errorf("unable create Deployment: %s", err.Error())
-			log.Println(wrappedErr)
-			http.Error(w, wrappedErr.Error(), http.StatusInternalServerError)
+		if warning := pdbWarning(factory, request); len(warning) > 0 {
+			log.Printf("Warning: %s.%s %s\n", request.Service, namespace, warning)
+			w.Header().Set("Warning", fmt.Sprintf("299 - %q", warning))
+		}
+
+		if autoVersion {
+			out, _ := json.Marshal(deployResponse{Name: request.Service, Namespace: namespace})
+
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusAccepted)
+			w.Write(out)
 			return
 		}
 
-		log.Printf("Deployment created: %s.%s\n", request.Service, namespace)
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
+// pdbWarning warns when the function's PodDisruptionBudget would not allow any of its initial
+// replicas to be evicted
+func pdbWarning(factory k8s.FunctionFactory, request types.FunctionDeployment) string {
+	minAvailable, err := factory.PDBMinAvailable(buildAnnotations(request))
+	if err != nil {
+		return ""
+	}
+
+	replicas := int32(initialReplicasCount)
+	if request.Labels != nil {
+		if min := getMinReplicaCount(*request.Labels); min != nil {
+			replicas = *min
+		}
+	}
+
+	return k8s.PDBWarning(minAvailable, replicas)
+}
+
+// deployError carries the HTTP status code for a failure to create a function
+type deployError struct {
+	status int
+	err    error
+}
+
+func (e *deployError) Error() string {
+	return e.err.Error()
+}
+
+// deployErrorStatus returns the HTTP status code for an error from createFunction
+func deployErrorStatus(err error) int {
+	var de *deployError
+	if errors.As(err, &de) {
+		return de.status
+	}
+	return http.StatusInternalServerError
+}
+
+// circuitStatus returns 503 when err is from an open circuit breaker, otherwise status
+func circuitStatus(err error, status int) int {
+	if errors.Is(err, k8s.ErrCircuitOpen) {
+		return http.StatusServiceUnavailable
+	}
+	return status
+}
+
+// renderFunction builds and validates the Deployment and Service for a validated request
+// without creating them.
+func renderFunction(ctx context.Context, factory k8s.FunctionFactory, secrets k8s.SecretsClient, namespace string, request types.FunctionDeployment) (*appsv1.Deployment, *corev1.Service, error) {
+	// the spec is built from the request, which may rely on the default namespace
+	request.Namespace = namespace
+
+	existingSecrets, err := secrets.GetSecrets(namespace, request.Secrets)
+	if err != nil {
+		return nil, nil, &deployError{http.StatusBadRequest, fmt.Errorf("unable to fetch secrets: %s", err.Error())}
+	}
 
-		service := factory.Client.CoreV1().Services(namespace)
-		serviceSpec := makeServiceSpec(request, factory)
-		_, err = service.Create(context.TODO(), serviceSpec, metav1.CreateOptions{})
+	deploymentSpec, err := makeDeploymentSpec(request, existingSecrets, factory)
+	if err != nil {
+		return nil, nil, &deployError{http.StatusBadRequest, fmt.Errorf("failed create Deployment spec: %s", err.Error())}
+	}
 
+	var profileList []k8s.Profile
+	if request.Annotations != nil {
+		profileList, err = factory.GetProfiles(ctx, namespace, *request.Annotations)
 		if err != nil {
-			wrappedErr := fmt.Errorf("failed create Service: %s", err.Error())
-			log.Println(wrappedErr)
-			http.Error(w, wrappedErr.Error(), http.StatusBadRequest)
-			return
+			return nil, nil, &deployError{http.StatusBadRequest, fmt.Errorf("failed create Deployment spec: %s", err.Error())}
 		}
+	}
+	for _, profile := range profileList {
+		factory.ApplyProfile(profile, deploymentSpec)
+	}
 
-		log.Printf("Service created: %s.%s\n", request.Service, namespace)
+	if err := factory.ConfigureZone(buildAnnotations(request), deploymentSpec); err != nil {
+		return nil, nil, &deployError{http.StatusBadRequest, fmt.Errorf("failed create Deployment spec: %s", err.Error())}
+	}
 
-		w.WriteHeader(http.StatusAccepted)
+	if err := factory.ConfigurePodOverhead(buildAnnotations(request), deploymentSpec); err != nil {
+		return nil, nil, &deployError{http.StatusBadRequest, fmt.Errorf("failed create Deployment spec: %s", err.Error())}
+	}
+
+	if err := factory.ValidateSysctls(deploymentSpec.Spec.Template.Spec); err != nil {
+		return nil, nil, &deployError{http.StatusBadRequest, fmt.Errorf("failed create Deployment spec: %s", err.Error())}
+	}
+
+	if err := checkNamespacePodSecurity(ctx, factory, namespace, deploymentSpec); err != nil {
+		return nil, nil, &deployError{http.StatusBadRequest, fmt.Errorf("failed create Deployment spec: %s", err.Error())}
+	}
+
+	serviceAnnotations, err := k8s.ServiceAnnotations(buildAnnotations(request))
+	if err != nil {
+		return nil, nil, &deployError{http.StatusBadRequest, fmt.Errorf("failed create Service spec: %s", err.Error())}
+	}
+
+	if invalid := validateFunctionAnnotations(factory, request.Service, buildAnnotations(request)); invalid != nil {
+		return nil, nil, &deployError{http.StatusBadRequest, fmt.Errorf("failed create %s spec: %s", invalid.resource, invalid.Error())}
+	}
+
+	k8s.SetSpecChecksum(deploymentSpec)
+	k8s.SetUpdatedAt(deploymentSpec, time.Now())
+
+	serviceSpec := makeServiceSpec(request, factory, serviceAnnotations)
+	if err := k8s.ConfigureServicePort(buildAnnotations(request), serviceSpec); err != nil {
+		return nil, nil, &deployError{http.StatusBadRequest, fmt.Errorf("failed create Service spec: %s", err.Error())}
+	}
+
+	return deploymentSpec, serviceSpec, nil
+}
+
+// createFunction builds and creates the Deployment and Service for a validated request. The
+// returned bool is true when the Deployment was created, even if the Service then failed, so
+// that a caller can clean it up.
+func createFunction(ctx context.Context, factory k8s.FunctionFactory, secrets k8s.SecretsClient, namespace string, request types.FunctionDeployment) (bool, error) {
+	if err := factory.CheckFunctionQuota(ctx, namespace); err != nil {
+		var quotaErr *k8s.QuotaExceededError
+		if errors.As(err, &quotaErr) {
+			return false, &deployError{http.StatusTooManyRequests, err}
+		}
+		return false, &deployError{http.StatusInternalServerError, err}
+	}
+
+	deploymentSpec, serviceSpec, err := renderFunction(ctx, factory, secrets, namespace, request)
+	if err != nil {
+		return false, err
+	}
+
+	// the namespace is only labelled once the request is known to be valid
+	ensureNamespacePodSecurity(ctx, factory, namespace)
+
+	deploy := factory.Client.AppsV1().Deployments(namespace)
+
+	var created *appsv1.Deployment
+	err = factory.Breaker.Do(func() error {
+		var err error
+		created, err = deploy.Create(ctx, deploymentSpec, metav1.CreateOptions{})
+		return err
+	})
+	if err != nil {
+		return false, &deployError{circuitStatus(err, http.StatusInternalServerError), fmt.Errorf("unable create Deployment: %s", err.Error())}
 	}
+
+	log.Printf("Deployment created: %s.%s\n", request.Service, namespace)
+
+	service := factory.Client.CoreV1().Services(namespace)
+	err = factory.Breaker.Do(func() error {
+		_, err := service.Create(ctx, serviceSpec, metav1.CreateOptions{})
+		return err
+	})
+	if err != nil {
+		return true, &deployError{circuitStatus(err, http.StatusBadRequest), fmt.Errorf("failed create Service: %s", err.Error())}
+	}
+
+	log.Printf("Service created: %s.%s\n", request.Service, namespace)
+
+	// the function works without TLS, so a failure to request a Certificate is not fatal
+	if err := factory.ConfigureCertificate(ctx, namespace, request.Service, buildAnnotations(request)); err != nil {
+		log.Printf("Certificate for %s.%s error: %v\n", request.Service, namespace, err)
+	}
+
+	if err := factory.ConfigurePDB(ctx, namespace, request.Service, buildAnnotations(request), *deploymentSpec.Spec.Replicas); err != nil {
+		log.Printf("PodDisruptionBudget for %s.%s error: %v\n", request.Service, namespace, err)
+	}
+
+	// the variants are owned by the created Deployment, so they need its UID
+	if err := factory.ReconcileVariants(ctx, namespace, created); err != nil {
+		log.Printf("Variants for %s.%s error: %v\n", request.Service, namespace, err)
+	}
+
+	return true, nil
+}
+
+// checkNamespacePodSecurity validates the Deployment against the Pod Security Standard enforced
+// on the namespace, or the DeploymentConfig.PSAEnforceLevel that ensureNamespacePodSecurity will
+// set when the namespace has no label. When the namespace cannot be read, i.e. without a cluster
+// role, the check is skipped and Kubernetes will still enforce the standard when the Pods are
+// created.
+func checkNamespacePodSecurity(ctx context.Context, factory k8s.FunctionFactory, namespace string, deployment *appsv1.Deployment) error {
+	ns, err := factory.Client.CoreV1().Namespaces().Get(ctx, namespace, metav1.GetOptions{})
+	if err != nil {
+		log.Printf("Unable to read namespace %s to check Pod Security labels: %s\n", namespace, err)
+		return nil
+	}
+
+	level, ok := ns.Labels[k8s.PodSecurityEnforceLabel]
+	if !ok {
+		level = factory.Config.PSAEnforceLevel
+	}
+
+	return k8s.CheckPodSecurity(level, deployment.Spec.Template.Spec)
+}
+
+// ensureNamespacePodSecurity sets the pod-security.kubernetes.io/enforce label of the namespace
+// to DeploymentConfig.PSAEnforceLevel when the label is absent. An existing label is never
+// changed, and failures are logged because the function can still be deployed without it.
+func ensureNamespacePodSecurity(ctx context.Context, factory k8s.FunctionFactory, namespace string) {
+	level := factory.Config.PSAEnforceLevel
+	if len(level) == 0 {
+		return
+	}
+
+	ns, err := factory.Client.CoreV1().Namespaces().Get(ctx, namespace, metav1.GetOptions{})
+	if err != nil {
+		log.Printf("Unable to read namespace %s to set Pod Security labels: %s\n", namespace, err)
+		return
+	}
+
+	if existing, ok := ns.Labels[k8s.PodSecurityEnforceLabel]; ok {
+		if existing == level {
+			log.Printf("Namespace %s already enforces the %q Pod Security Standard\n", namespace, level)
+		} else {
+			log.Printf("Warning: namespace %s enforces the %q Pod Security Standard, not %q from psa_enforce_level\n", namespace, existing, level)
+		}
+		return
+	}
+
+	patch, _ := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"labels": map[string]string{k8s.PodSecurityEnforceLabel: level},
+		},
+	})
+
+	if _, err := factory.Client.CoreV1().Namespaces().Patch(ctx, namespace, k8stypes.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		log.Printf("Unable to set Pod Security label on namespace %s: %s\n", namespace, err)
+		return
+	}
+
+	log.Printf("Namespace %s labelled to enforce the %q Pod Security Standard\n", namespace, level)
+}
+
+// deployResponse is returned when the deployed name may differ from the requested name
+type deployResponse struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+}
+
+// nextFunctionVersion returns name when no Deployment of that name exists, otherwise it returns
+// the next free version suffix after the highest existing version i.e. myfn-v2, myfn-v3.
+func nextFunctionVersion(ctx context.Context, factory k8s.FunctionFactory, namespace, name string) (string, error) {
+	res, err := factory.Client.AppsV1().Deployments(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return "", err
+	}
+
+	existing := make([]string, 0, len(res.Items))
+	for _, item := range res.Items {
+		existing = append(existing, item.Name)
+	}
+
+	return nextVersionName(name, existing), nil
+}
+
+func nextVersionName(name string, existing []string) string {
+	found := false
+	latest := 1
+	prefix := name + "-v"
+
+	for _, e := range existing {
+		if e == name {
+			found = true
+			continue
+		}
+
+		if !strings.HasPrefix(e, prefix) {
+			continue
+		}
+
+		v, err := strconv.Atoi(strings.TrimPrefix(e, prefix))
+		if err != nil || v < 2 {
+			continue
+		}
+
+		found = true
+		if v > latest {
+			latest = v
+		}
+	}
+
+	if !found {
+		return name
+	}
+
+	return fmt.Sprintf("%s%d", prefix, latest+1)
 }
 
 func makeDeploymentSpec(request types.FunctionDeployment, existingSecrets map[string]*apiv1.Secret, factory k8s.FunctionFactory) (*appsv1.Deployment, error) {
@@ -163,6 +473,11 @@ func makeDeploymentSpec(request types.FunctionDeployment, existingSecrets map[st
 		return nil, err
 	}
 
+	progressDeadlineSeconds, err := factory.ProgressDeadlineSeconds(annotations)
+	if err != nil {
+		return nil, err
+	}
+
 	enableServiceLinks := false
 	allowPrivilegeEscalation := true
 	privileged := true
@@ -181,7 +496,8 @@ func makeDeploymentSpec(request types.FunctionDeployment, existingSecrets map[st
 					"faas_function": request.Service,
 				},
 			},
-			Replicas: initialReplicas,
+			Replicas:                initialReplicas,
+			ProgressDeadlineSeconds: progressDeadlineSeconds,
 			Strategy: appsv1.DeploymentStrategy{
 				Type: appsv1.RollingUpdateDeploymentStrategyType,
 				RollingUpdate: &appsv1.RollingUpdateDeployment{
@@ -199,8 +515,8 @@ func makeDeploymentSpec(request types.FunctionDeployment, existingSecrets map[st
 			Template: apiv1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
 					Name:        request.Service,
-					Labels:      labels,
-					Annotations: annotations,
+					Labels:      factory.PodLabels(request.Namespace, labels),
+					Annotations: factory.PodAnnotations(annotations),
 				},
 				Spec: apiv1.PodSpec{
 					NodeSelector: nodeSelector,
@@ -223,7 +539,7 @@ func makeDeploymentSpec(request types.FunctionDeployment, existingSecrets map[st
 							SecurityContext: &corev1.SecurityContext{
 								ReadOnlyRootFilesystem:   &request.ReadOnlyRootFilesystem,
 								AllowPrivilegeEscalation: &allowPrivilegeEscalation,
-								Privileged: &privileged,
+								Privileged:               &privileged,
 							},
 						},
 					},
@@ -240,14 +556,56 @@ func makeDeploymentSpec(request types.FunctionDeployment, existingSecrets map[st
 	factory.ConfigureReadOnlyRootFilesystem(request, deploymentSpec)
 	factory.ConfigureContainerUserID(deploymentSpec)
 
+	if err := factory.ConfigureRunAsIDs(annotations, deploymentSpec); err != nil {
+		return nil, err
+	}
+
+	if err := factory.ConfigurePortName(annotations, deploymentSpec); err != nil {
+		return nil, err
+	}
+
 	if err := factory.ConfigureSecrets(request, deploymentSpec, existingSecrets); err != nil {
 		return nil, err
 	}
 
+	if err := factory.ConfigureCacheVolume(annotations, deploymentSpec); err != nil {
+		return nil, err
+	}
+
+	if err := factory.ConfigureDownwardAPI(annotations, deploymentSpec); err != nil {
+		return nil, err
+	}
+
+	if err := factory.ConfigureWorkingDir(annotations, deploymentSpec); err != nil {
+		return nil, err
+	}
+
+	if err := factory.ConfigureSchedulerName(annotations, deploymentSpec); err != nil {
+		return nil, err
+	}
+
+	if err := factory.ConfigureNodePool(annotations, deploymentSpec); err != nil {
+		return nil, err
+	}
+
+	if err := factory.ConfigureExtendedResources(annotations, deploymentSpec); err != nil {
+		return nil, err
+	}
+
+	if err := factory.ConfigureDNSPolicy(annotations, deploymentSpec); err != nil {
+		return nil, err
+	}
+
+	if err := factory.ConfigureMetricsScraping(annotations, deploymentSpec); err != nil {
+		return nil, err
+	}
+
+	factory.ConfigureBackupAnnotations(annotations, deploymentSpec)
+
 	return deploymentSpec, nil
 }
 
-func makeServiceSpec(request types.FunctionDeployment, factory k8s.FunctionFactory) *corev1.Service {
+func makeServiceSpec(request types.FunctionDeployment, factory k8s.FunctionFactory, annotations map[string]string) *corev1.Service {
 
 	serviceSpec := &corev1.Service{
 		TypeMeta: metav1.TypeMeta{
@@ -256,7 +614,7 @@ func makeServiceSpec(request types.FunctionDeployment, factory k8s.FunctionFacto
 		},
 		ObjectMeta: metav1.ObjectMeta{
 			Name:        request.Service,
-			Annotations: buildAnnotations(request),
+			Annotations: annotations,
 		},
 		Spec: corev1.ServiceSpec{
 			Type: corev1.ServiceTypeClusterIP,
@@ -4,13 +4,25 @@
 package handlers
 
 import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
 	"testing"
+	"time"
 
+	openfaasv1 "github.com/openfaas/faas-netes/pkg/apis/openfaas/v1"
+	listers "github.com/openfaas/faas-netes/pkg/client/listers/openfaas/v1"
 	"github.com/openfaas/faas-netes/pkg/k8s"
 	types "github.com/openfaas/faas-provider/types"
+	appsv1 "k8s.io/api/apps/v1"
 	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
 
 	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func Test_buildAnnotations_Empty_In_CreateRequest(t *testing.T) {
@@ -191,3 +203,287 @@ func Test_buildEnvVars_FourSortedKeys(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func Test_nextVersionName(t *testing.T) {
+	cases := []struct {
+		name     string
+		existing []string
+		want     string
+	}{
+		{"no existing function keeps the name", []string{"other"}, "myfn"},
+		{"existing function gets v2", []string{"myfn"}, "myfn-v2"},
+		{"increments the highest version", []string{"myfn", "myfn-v2", "myfn-v3"}, "myfn-v4"},
+		{"versions without the base name increment", []string{"myfn-v5"}, "myfn-v6"},
+		{"ignores similar names", []string{"myfn-validator", "myfn-v1x"}, "myfn"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := nextVersionName("myfn", tc.existing)
+			if got != tc.want {
+				t.Errorf("want: %s, got: %s", tc.want, got)
+			}
+		})
+	}
+}
+
+func Test_ensureNamespacePodSecurity(t *testing.T) {
+	cases := []struct {
+		name     string
+		level    string
+		existing map[string]string
+		want     string
+	}{
+		{name: "not configured", level: "", want: ""},
+		{name: "label is added", level: "baseline", want: "baseline"},
+		{name: "matching label is kept", level: "baseline", existing: map[string]string{k8s.PodSecurityEnforceLabel: "baseline"}, want: "baseline"},
+		{name: "conflicting label is kept", level: "restricted", existing: map[string]string{k8s.PodSecurityEnforceLabel: "privileged"}, want: "privileged"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset(&apiv1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{Name: "openfaas-fn", Labels: tc.existing},
+			})
+			factory := k8s.NewFunctionFactory(client, k8s.DeploymentConfig{PSAEnforceLevel: tc.level}, nil)
+
+			ensureNamespacePodSecurity(context.TODO(), factory, "openfaas-fn")
+
+			ns, err := client.CoreV1().Namespaces().Get(context.TODO(), "openfaas-fn", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if got := ns.Labels[k8s.PodSecurityEnforceLabel]; got != tc.want {
+				t.Errorf("want label: %q, got: %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func Test_MakeDeployHandler_InvalidRequestDoesNotLabelNamespace(t *testing.T) {
+	client := fake.NewSimpleClientset(&apiv1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: "openfaas-fn"},
+	})
+	factory := k8s.NewFunctionFactory(client, k8s.DeploymentConfig{
+		LivenessProbe:   &k8s.ProbeConfig{},
+		ReadinessProbe:  &k8s.ProbeConfig{},
+		PSAEnforceLevel: "privileged",
+	}, nil)
+
+	body := `{"service":"nodeinfo","image":"functions/nodeinfo","annotations":{"` + k8s.ExecMaxDurationAnnotationKey + `":"forever"}}`
+	req := httptest.NewRequest(http.MethodPost, "/system/functions", strings.NewReader(body))
+	rr := httptest.NewRecorder()
+	MakeDeployHandler("openfaas-fn", factory).ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("want status %d, got %d: %s", http.StatusBadRequest, rr.Code, rr.Body.String())
+	}
+
+	ns, err := client.CoreV1().Namespaces().Get(context.TODO(), "openfaas-fn", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got, ok := ns.Labels[k8s.PodSecurityEnforceLabel]; ok {
+		t.Errorf("want the namespace to be unchanged for a rejected request, got label: %q", got)
+	}
+}
+
+func Test_checkNamespacePodSecurity_ConfiguredLevel(t *testing.T) {
+	client := fake.NewSimpleClientset(&apiv1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: "openfaas-fn"},
+	})
+	factory := k8s.NewFunctionFactory(client, k8s.DeploymentConfig{PSAEnforceLevel: "baseline"}, nil)
+
+	privileged := true
+	deployment := &appsv1.Deployment{}
+	deployment.Spec.Template.Spec.Containers = []apiv1.Container{{
+		Name:            "nodeinfo",
+		SecurityContext: &apiv1.SecurityContext{Privileged: &privileged},
+	}}
+
+	// the namespace is not labelled yet, so the level that will be set is checked
+	if err := checkNamespacePodSecurity(context.TODO(), factory, "openfaas-fn", deployment); err == nil {
+		t.Errorf("want a privileged container to be rejected by psa_enforce_level=baseline")
+	}
+}
+
+func Test_renderFunction_InvalidSpecWithProfile(t *testing.T) {
+	runtimeClass := "gvisor"
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	indexer.Add(&openfaasv1.Profile{
+		ObjectMeta: metav1.ObjectMeta{Name: "gvisor", Namespace: "openfaas"},
+		Spec:       openfaasv1.ProfileSpec{RuntimeClassName: &runtimeClass},
+	})
+
+	factory := k8s.NewFunctionFactory(fake.NewSimpleClientset(), k8s.DeploymentConfig{
+		LivenessProbe:     &k8s.ProbeConfig{},
+		ReadinessProbe:    &k8s.ProbeConfig{},
+		ProfilesNamespace: "openfaas",
+	}, listers.NewProfileLister(indexer))
+
+	request := types.FunctionDeployment{
+		Service:     "nodeinfo",
+		Image:       "functions/nodeinfo",
+		Annotations: &map[string]string{k8s.ProfileAnnotationKey: "gvisor"},
+		Limits:      &types.FunctionResources{Memory: "lots"},
+	}
+
+	_, _, err := renderFunction(context.TODO(), factory, k8s.NewSecretsClient(factory.Client), "openfaas-fn", request)
+
+	var deployErr *deployError
+	if !errors.As(err, &deployErr) || deployErr.status != http.StatusBadRequest {
+		t.Fatalf("want a %d for invalid limits, got: %v", http.StatusBadRequest, err)
+	}
+}
+
+func Test_MakeDeployHandler_CircuitOpen(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	factory := k8s.NewFunctionFactory(client, k8s.DeploymentConfig{
+		LivenessProbe:  &k8s.ProbeConfig{},
+		ReadinessProbe: &k8s.ProbeConfig{},
+	}, nil)
+	factory.Breaker = k8s.NewCircuitBreaker(1, time.Minute)
+	factory.Breaker.Do(func() error { return errors.New("connection refused") })
+
+	req := httptest.NewRequest(http.MethodPost, "/system/functions", strings.NewReader(`{"service":"nodeinfo","image":"functions/nodeinfo"}`))
+	rr := httptest.NewRecorder()
+	MakeDeployHandler("openfaas-fn", factory).ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Fatalf("want status %d, got %d: %s", http.StatusServiceUnavailable, rr.Code, rr.Body.String())
+	}
+
+	deployments, err := client.AppsV1().Deployments("openfaas-fn").List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(deployments.Items) != 0 {
+		t.Errorf("want no Deployment to be created, got %d", len(deployments.Items))
+	}
+}
+
+func Test_MakeDeployHandler_FunctionQuota(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	factory := k8s.NewFunctionFactory(client, k8s.DeploymentConfig{
+		LivenessProbe:            &k8s.ProbeConfig{},
+		ReadinessProbe:           &k8s.ProbeConfig{},
+		MaxFunctionsPerNamespace: 1,
+	}, nil)
+	handler := MakeDeployHandler("openfaas-fn", factory)
+
+	deploy := func(name string) int {
+		req := httptest.NewRequest(http.MethodPost, "/system/functions", strings.NewReader(`{"service":"`+name+`","image":"functions/nodeinfo"}`))
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	if code := deploy("first"); code != http.StatusAccepted {
+		t.Fatalf("want status %d, got %d", http.StatusAccepted, code)
+	}
+	if code := deploy("second"); code != http.StatusTooManyRequests {
+		t.Fatalf("want status %d, got %d", http.StatusTooManyRequests, code)
+	}
+}
+
+func Test_MakeDeployHandler_ImagePolicy(t *testing.T) {
+	cases := []struct {
+		scenario string
+		image    string
+		want     int
+	}{
+		{"image matches the policy", "registry.example.com/nodeinfo:0.1.0", http.StatusAccepted},
+		{"image does not match the policy", "functions/nodeinfo", http.StatusBadRequest},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.scenario, func(t *testing.T) {
+			factory := k8s.NewFunctionFactory(fake.NewSimpleClientset(), k8s.DeploymentConfig{
+				LivenessProbe:  &k8s.ProbeConfig{},
+				ReadinessProbe: &k8s.ProbeConfig{},
+				ImageNameRegex: regexp.MustCompile(`^registry\.example\.com/`),
+			}, nil)
+
+			req := httptest.NewRequest(http.MethodPost, "/system/functions", strings.NewReader(`{"service":"nodeinfo","image":"`+tc.image+`"}`))
+			rr := httptest.NewRecorder()
+			MakeDeployHandler("openfaas-fn", factory).ServeHTTP(rr, req)
+
+			if rr.Code != tc.want {
+				t.Fatalf("want status %d, got %d: %s", tc.want, rr.Code, rr.Body.String())
+			}
+			if tc.want == http.StatusBadRequest && !strings.Contains(rr.Body.String(), ImagePolicyViolation) {
+				t.Errorf("want error code %s, got: %s", ImagePolicyViolation, rr.Body.String())
+			}
+		})
+	}
+}
+
+func Test_MakeDeployHandler_ImageCheck(t *testing.T) {
+	registry := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/team/nodeinfo/manifests/0.1.0":
+			w.WriteHeader(http.StatusOK)
+		case "/v2/team/broken/manifests/0.1.0":
+			w.WriteHeader(http.StatusBadGateway)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer registry.Close()
+	host := strings.TrimPrefix(registry.URL, "https://")
+
+	cases := []struct {
+		scenario string
+		image    string
+		want     int
+	}{
+		{"image exists", host + "/team/nodeinfo:0.1.0", http.StatusAccepted},
+		{"image tag does not exist", host + "/team/nodeinfo:0.2.0", http.StatusBadRequest},
+		{"registry can not be checked", host + "/team/broken:0.1.0", http.StatusAccepted},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.scenario, func(t *testing.T) {
+			factory := k8s.NewFunctionFactory(fake.NewSimpleClientset(), k8s.DeploymentConfig{
+				LivenessProbe:  &k8s.ProbeConfig{},
+				ReadinessProbe: &k8s.ProbeConfig{},
+			}, nil)
+			factory.ImageChecker = &k8s.ImageChecker{Client: registry.Client(), Timeout: time.Second * 5}
+
+			req := httptest.NewRequest(http.MethodPost, "/system/functions", strings.NewReader(`{"service":"nodeinfo","image":"`+tc.image+`"}`))
+			rr := httptest.NewRecorder()
+			MakeDeployHandler("openfaas-fn", factory).ServeHTTP(rr, req)
+
+			if rr.Code != tc.want {
+				t.Fatalf("want status %d, got %d: %s", tc.want, rr.Code, rr.Body.String())
+			}
+			if tc.want == http.StatusBadRequest && !strings.Contains(rr.Body.String(), ImageNotFound) {
+				t.Errorf("want error code %s, got: %s", ImageNotFound, rr.Body.String())
+			}
+		})
+	}
+}
+
+func Test_MakeDeployHandler_PDBWarning(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	factory := k8s.NewFunctionFactory(client, k8s.DeploymentConfig{
+		LivenessProbe:  &k8s.ProbeConfig{},
+		ReadinessProbe: &k8s.ProbeConfig{},
+	}, nil)
+
+	body := `{"service":"nodeinfo","image":"functions/nodeinfo","labels":{"com.openfaas.scale.min":"2"},"annotations":{"com.openfaas.pdb.min-available":"2"}}`
+	req := httptest.NewRequest(http.MethodPost, "/system/functions", strings.NewReader(body))
+	rr := httptest.NewRecorder()
+	MakeDeployHandler("openfaas-fn", factory).ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("want status %d, got %d: %s", http.StatusAccepted, rr.Code, rr.Body.String())
+	}
+	if got := rr.Header().Get("Warning"); !strings.Contains(got, "min-available 2") {
+		t.Errorf("want a Warning header for min-available 2 with 2 replicas, got %q", got)
+	}
+
+	if _, err := client.PolicyV1().PodDisruptionBudgets("openfaas-fn").Get(context.Background(), "nodeinfo", metav1.GetOptions{}); err != nil {
+		t.Errorf("want a PodDisruptionBudget, got error: %s", err)
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright 2020 OpenFaaS Author(s)
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/openfaas/faas-netes/pkg/k8s"
+	v1 "k8s.io/client-go/listers/apps/v1"
+)
+
+// FunctionDrift compares the checksum recorded when a function was deployed with the
+// checksum of its live Deployment
+type FunctionDrift struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+
+	// Checksum is empty for functions deployed before checksums were recorded
+	Checksum     string `json:"checksum"`
+	LiveChecksum string `json:"liveChecksum"`
+
+	// Drifted is true when the Deployment was changed outside of faas-netes
+	Drifted bool `json:"drifted"`
+}
+
+// MakeFunctionDriftHandler reports whether the Deployment of a function has been changed
+// directly since it was last deployed or updated
+func MakeFunctionDriftHandler(defaultNamespace string, deploymentLister v1.DeploymentLister) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Body != nil {
+			defer r.Body.Close()
+		}
+
+		functionName := mux.Vars(r)["name"]
+
+		lookupNamespace := defaultNamespace
+		if namespace := r.URL.Query().Get("namespace"); len(namespace) > 0 {
+			lookupNamespace = namespace
+		}
+
+		if lookupNamespace == "kube-system" {
+			http.Error(w, "unable to list within the kube-system namespace", http.StatusUnauthorized)
+			return
+		}
+
+		deployment, err := deploymentLister.Deployments(lookupNamespace).Get(functionName)
+		if err != nil {
+			status, reason := ProcessErrorReasons(err)
+			log.Printf("Function drift lookup error reason: %s, %v\n", reason, err)
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		drift := FunctionDrift{
+			Name:         functionName,
+			Namespace:    lookupNamespace,
+			Checksum:     deployment.Annotations[k8s.SpecChecksumAnnotationKey],
+			LiveChecksum: k8s.SpecChecksum(deployment),
+			Drifted:      k8s.SpecDrifted(deployment),
+		}
+
+		out, err := json.Marshal(drift)
+		if err != nil {
+			log.Printf("Function drift json marshal error: %v\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(out)
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright 2020 OpenFaaS Author(s)
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/openfaas/faas-netes/pkg/k8s"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_MakeFunctionDriftHandler(t *testing.T) {
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "nodeinfo", Namespace: "openfaas-fn"},
+	}
+	deployment.Spec.Template.Spec.Containers = []corev1.Container{{Name: "nodeinfo", Image: "functions/nodeinfo:1.0"}}
+	k8s.SetSpecChecksum(deployment)
+	recorded := deployment.Annotations[k8s.SpecChecksumAnnotationKey]
+
+	// edited directly, i.e. with kubectl set image
+	deployment.Spec.Template.Spec.Containers[0].Image = "functions/nodeinfo:latest"
+
+	handler := MakeFunctionDriftHandler("openfaas-fn", newTestDeploymentLister(t, deployment))
+
+	cases := []struct {
+		name       string
+		function   string
+		wantStatus int
+	}{
+		{name: "drifted function", function: "nodeinfo", wantStatus: http.StatusOK},
+		{name: "missing function", function: "env", wantStatus: http.StatusNotFound},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/system/function/"+tc.function+"/drift", nil)
+			req = mux.SetURLVars(req, map[string]string{"name": tc.function})
+			rr := httptest.NewRecorder()
+
+			handler(rr, req)
+
+			if rr.Code != tc.wantStatus {
+				t.Fatalf("want status: %d, got: %d, body: %s", tc.wantStatus, rr.Code, rr.Body.String())
+			}
+			if tc.wantStatus != http.StatusOK {
+				return
+			}
+
+			drift := FunctionDrift{}
+			if err := json.Unmarshal(rr.Body.Bytes(), &drift); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !drift.Drifted {
+				t.Errorf("want the function to be reported as drifted")
+			}
+			if drift.Checksum != recorded || drift.LiveChecksum == recorded {
+				t.Errorf("want the recorded checksum %s and a different live checksum, got: %+v", recorded, drift)
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@
 package handlers
 
 import (
+	"encoding/json"
 	"net/http"
 
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
@@ -32,3 +33,18 @@ func ProcessErrorReasons(err error) (int, metav1.StatusReason) {
 		return http.StatusInternalServerError, metav1.StatusReasonInternalError
 	}
 }
+
+// ErrorResponse is returned for errors that carry a machine readable code
+type ErrorResponse struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// writeErrorCode writes err as an ErrorResponse with the code
+func writeErrorCode(w http.ResponseWriter, status int, code string, err error) {
+	out, _ := json.Marshal(ErrorResponse{Code: code, Message: err.Error()})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(out)
+}
@@ -0,0 +1,143 @@
+// Copyright 2020 OpenFaaS Author(s)
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/kubernetes"
+)
+
+// FunctionEvent is a summary of a Kubernetes Event for a function
+type FunctionEvent struct {
+	Type           string    `json:"type"`
+	Reason         string    `json:"reason"`
+	Message        string    `json:"message"`
+	Kind           string    `json:"kind"`
+	Count          int32     `json:"count"`
+	FirstTimestamp time.Time `json:"firstTimestamp"`
+	LastTimestamp  time.Time `json:"lastTimestamp"`
+}
+
+// MakeEventListHandler lists the Kubernetes Events recorded against a function's Deployment,
+// newest first. The results can be filtered with `since`, as an RFC3339 time or a duration
+// such as 10m, and capped with `limit`.
+func MakeEventListHandler(defaultNamespace string, clientset kubernetes.Interface) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Body != nil {
+			defer r.Body.Close()
+		}
+
+		functionName := mux.Vars(r)["name"]
+		q := r.URL.Query()
+
+		lookupNamespace := defaultNamespace
+		if namespace := q.Get("namespace"); len(namespace) > 0 {
+			lookupNamespace = namespace
+		}
+
+		if lookupNamespace == "kube-system" {
+			http.Error(w, "unable to list within the kube-system namespace", http.StatusUnauthorized)
+			return
+		}
+
+		var since time.Time
+		if v := q.Get("since"); len(v) > 0 {
+			parsed, err := parseSinceParam(v)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			since = parsed
+		}
+
+		limit := 0
+		if v := q.Get("limit"); len(v) > 0 {
+			parsed, err := strconv.Atoi(v)
+			if err != nil || parsed < 1 {
+				http.Error(w, fmt.Sprintf("invalid limit: %q, must be a positive integer", v), http.StatusBadRequest)
+				return
+			}
+			limit = parsed
+		}
+
+		selector := fields.OneTermEqualSelector("involvedObject.name", functionName).String()
+		res, err := clientset.CoreV1().Events(lookupNamespace).List(r.Context(), metav1.ListOptions{FieldSelector: selector})
+		if err != nil {
+			status, reason := ProcessErrorReasons(err)
+			log.Printf("Function events list error reason: %s, %v\n", reason, err)
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		events := filterEvents(res.Items, since, limit)
+
+		out, err := json.Marshal(events)
+		if err != nil {
+			log.Printf("Function events json marshal error: %v\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(out)
+	}
+}
+
+// filterEvents sorts the events by LastTimestamp, newest first, dropping those
+// before since and truncating the result to limit when it is greater than zero.
+func filterEvents(items []corev1.Event, since time.Time, limit int) []FunctionEvent {
+	events := []FunctionEvent{}
+
+	for _, item := range items {
+		if item.LastTimestamp.Time.Before(since) {
+			continue
+		}
+
+		events = append(events, FunctionEvent{
+			Type:           item.Type,
+			Reason:         item.Reason,
+			Message:        item.Message,
+			Kind:           item.InvolvedObject.Kind,
+			Count:          item.Count,
+			FirstTimestamp: item.FirstTimestamp.Time,
+			LastTimestamp:  item.LastTimestamp.Time,
+		})
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].LastTimestamp.After(events[j].LastTimestamp)
+	})
+
+	if limit > 0 && len(events) > limit {
+		events = events[:limit]
+	}
+
+	return events
+}
+
+// parseSinceParam accepts an RFC3339 timestamp or a duration relative to now
+func parseSinceParam(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid since: %q, must be an RFC3339 time or a duration", value)
+	}
+
+	return time.Now().Add(-d), nil
+}
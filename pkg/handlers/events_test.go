@@ -0,0 +1,89 @@
+// Copyright 2020 OpenFaaS Author(s)
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func functionEvent(name, reason string, last time.Time) *corev1.Event {
+	return &corev1.Event{
+		ObjectMeta:     metav1.ObjectMeta{Name: name, Namespace: "openfaas-fn"},
+		InvolvedObject: corev1.ObjectReference{Kind: "Deployment", Name: "nodeinfo"},
+		Reason:         reason,
+		LastTimestamp:  metav1.NewTime(last),
+	}
+}
+
+func Test_MakeEventListHandler_SortsAndLimits(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	clientset := fake.NewSimpleClientset(
+		functionEvent("e1", "ScalingReplicaSet", now.Add(-3*time.Hour)),
+		functionEvent("e2", "FailedCreate", now.Add(-time.Minute)),
+		functionEvent("e3", "ScalingReplicaSet", now.Add(-10*time.Minute)),
+	)
+
+	handler := MakeEventListHandler("openfaas-fn", clientset)
+
+	req := httptest.NewRequest(http.MethodGet, "/system/function/nodeinfo/events?since=1h&limit=1", nil)
+	req = mux.SetURLVars(req, map[string]string{"name": "nodeinfo"})
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("want status: %d, got: %d", http.StatusOK, rr.Code)
+	}
+
+	events := []FunctionEvent{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &events); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(events) != 1 {
+		t.Fatalf("want 1 event, got: %d", len(events))
+	}
+
+	if events[0].Reason != "FailedCreate" {
+		t.Errorf("want the newest event first, got: %s", events[0].Reason)
+	}
+}
+
+func Test_filterEvents_Since(t *testing.T) {
+	now := time.Now()
+	items := []corev1.Event{
+		*functionEvent("old", "A", now.Add(-2*time.Hour)),
+		*functionEvent("new", "B", now.Add(-time.Minute)),
+	}
+
+	events := filterEvents(items, now.Add(-time.Hour), 0)
+	if len(events) != 1 || events[0].Reason != "B" {
+		t.Errorf("want only the recent event, got: %+v", events)
+	}
+}
+
+func Test_MakeEventListHandler_InvalidParams(t *testing.T) {
+	handler := MakeEventListHandler("openfaas-fn", fake.NewSimpleClientset())
+
+	for _, query := range []string{"since=yesterday", "limit=0", "limit=ten"} {
+		req := httptest.NewRequest(http.MethodGet, "/system/function/nodeinfo/events?"+query, nil)
+		req = mux.SetURLVars(req, map[string]string{"name": "nodeinfo"})
+		rr := httptest.NewRecorder()
+
+		handler.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: want status: %d, got: %d", query, http.StatusBadRequest, rr.Code)
+		}
+	}
+}
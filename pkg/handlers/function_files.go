@@ -0,0 +1,310 @@
+// Copyright 2020 OpenFaaS Author(s)
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/openfaas/faas-netes/pkg/k8s"
+
+	types "github.com/openfaas/faas-provider/types"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+const (
+	// FunctionFileAnnotationKey records the file in the functions directory that a function was
+	// deployed from, so that it can be removed when the file is deleted
+	FunctionFileAnnotationKey = "com.openfaas.functions-dir.file"
+
+	// functionFilesInterval is how often the functions directory is read
+	functionFilesInterval = 10 * time.Second
+)
+
+// FunctionFileReconciler keeps the functions in a directory of YAML or JSON files deployed.
+// Each file holds one or more FunctionDeployment documents, in the same format as the body
+// of the deploy API, separated by `---`. Functions are created when their file is added,
+// updated when it changes and deleted when it is removed.
+type FunctionFileReconciler struct {
+	dir              string
+	defaultNamespace string
+	factory          k8s.FunctionFactory
+	secrets          k8s.SecretsClient
+
+	// files is the checksum and functions of each file at the last sync
+	files  map[string]functionFile
+	synced bool
+}
+
+type functionFile struct {
+	checksum  string
+	functions []deployResponse
+}
+
+// NewFunctionFileReconciler returns a reconciler for the function files in dir
+func NewFunctionFileReconciler(dir, defaultNamespace string, factory k8s.FunctionFactory) *FunctionFileReconciler {
+	return &FunctionFileReconciler{
+		dir:              dir,
+		defaultNamespace: defaultNamespace,
+		factory:          factory,
+		secrets:          k8s.NewSecretsClient(factory.Client),
+		files:            map[string]functionFile{},
+	}
+}
+
+// Run syncs the directory every functionFilesInterval until stopCh is closed
+func (r *FunctionFileReconciler) Run(stopCh <-chan struct{}) {
+	wait.Until(func() {
+		if err := r.Sync(context.Background()); err != nil {
+			log.Printf("Function files %s error: %s\n", r.dir, err)
+		}
+	}, functionFilesInterval, stopCh)
+}
+
+// Sync applies the files that changed since the last sync and deletes the functions whose
+// file, or document within a file, was removed. A file that cannot be parsed is reported
+// and its functions are left as they are. Functions whose Deployment is missing are created
+// again even when their file has not changed.
+func (r *FunctionFileReconciler) Sync(ctx context.Context) error {
+	entries, err := ioutil.ReadDir(r.dir)
+	if err != nil {
+		return err
+	}
+
+	files := map[string]functionFile{}
+	wanted := map[deployResponse]bool{}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !isFunctionFile(name) {
+			continue
+		}
+
+		previous, seen := r.files[name]
+
+		data, err := ioutil.ReadFile(filepath.Join(r.dir, name))
+		if err != nil {
+			log.Printf("Function file %s: %s\n", name, err)
+			if seen {
+				files[name] = previous
+				markWanted(wanted, previous.functions)
+			}
+			continue
+		}
+
+		sum := sha256.Sum256(data)
+		checksum := hex.EncodeToString(sum[:])
+
+		requests, err := r.parse(name, data)
+		if err != nil {
+			log.Printf("Function file %s: %s\n", name, err)
+			if seen {
+				files[name] = previous
+				markWanted(wanted, previous.functions)
+			}
+			continue
+		}
+
+		changed := !seen || previous.checksum != checksum
+		file := functionFile{checksum: checksum}
+
+		for _, request := range requests {
+			key := deployResponse{Name: request.Service, Namespace: request.Namespace}
+			if wanted[key] {
+				log.Printf("Function file %s: %s.%s is defined by more than one file, skipping\n", name, key.Name, key.Namespace)
+				continue
+			}
+			wanted[key] = true
+			file.functions = append(file.functions, key)
+
+			if err := r.apply(ctx, request, changed); err != nil {
+				log.Printf("Function file %s: unable to apply %s.%s: %s\n", name, key.Name, key.Namespace, err)
+				// retry on the next sync
+				file.checksum = ""
+			}
+		}
+
+		files[name] = file
+	}
+
+	for _, file := range r.files {
+		for _, key := range file.functions {
+			if !wanted[key] {
+				r.remove(ctx, key)
+			}
+		}
+	}
+
+	// after a restart, functions whose file was removed while the provider was down are only
+	// known from their annotation
+	if !r.synced {
+		if err := r.removeOrphans(ctx, wanted); err != nil {
+			log.Printf("Function files %s: unable to remove orphaned functions: %s\n", r.dir, err)
+		}
+		r.synced = true
+	}
+
+	r.files = files
+	return nil
+}
+
+// parse decodes and validates each document in a function file
+func (r *FunctionFileReconciler) parse(name string, data []byte) ([]types.FunctionDeployment, error) {
+	var requests []types.FunctionDeployment
+
+	decoder := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), 4096)
+	for {
+		request := types.FunctionDeployment{}
+		if err := decoder.Decode(&request); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, fmt.Errorf("unable to parse document %d: %s", len(requests)+1, err)
+		}
+		if len(request.Service) == 0 && len(request.Image) == 0 {
+			// empty document
+			continue
+		}
+
+		if err := ValidateFunctionNameLength(request.Service, r.factory.Config.MaxFunctionNameLength); err != nil {
+			return nil, err
+		}
+		if err := ValidateDeployRequest(&request); err != nil {
+			return nil, fmt.Errorf("validation failed for %q: %s", request.Service, err)
+		}
+
+		if len(request.Namespace) == 0 {
+			request.Namespace = r.defaultNamespace
+		}
+		if request.Namespace == "kube-system" {
+			return nil, fmt.Errorf("unable to deploy %q within the kube-system namespace", request.Service)
+		}
+
+		annotations := r.factory.WithDefaultAnnotations(request.Annotations)
+		annotations[FunctionFileAnnotationKey] = name
+		request.Annotations = &annotations
+
+		requests = append(requests, request)
+	}
+
+	return requests, nil
+}
+
+// apply creates the function when its Deployment does not exist, or updates it when the
+// file changed
+func (r *FunctionFileReconciler) apply(ctx context.Context, request types.FunctionDeployment, changed bool) error {
+	namespace := request.Namespace
+
+	_, err := r.factory.Client.AppsV1().Deployments(namespace).Get(ctx, request.Service, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		if _, err := createFunction(ctx, r.factory, r.secrets, namespace, request); err != nil {
+			return err
+		}
+		log.Printf("Function file: created %s.%s\n", request.Service, namespace)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if !changed {
+		return nil
+	}
+
+	annotations := buildAnnotations(request)
+	serviceAnnotations, err := k8s.ServiceAnnotations(annotations)
+	if err != nil {
+		return err
+	}
+
+	err, _ = retryOnConflict(r.factory.Config.UpdateConflictRetries, func() (error, int) {
+		return updateDeploymentSpec(ctx, namespace, r.factory, request, annotations)
+	})
+	if err != nil {
+		return err
+	}
+
+	err, _ = retryOnConflict(r.factory.Config.UpdateConflictRetries, func() (error, int) {
+		return updateService(namespace, r.factory, request, serviceAnnotations)
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Function file: updated %s.%s\n", request.Service, namespace)
+	return nil
+}
+
+// remove deletes the Deployment, Service and Certificate of a function
+func (r *FunctionFileReconciler) remove(ctx context.Context, fn deployResponse) {
+	foreground := metav1.DeletePropagationForeground
+	opts := metav1.DeleteOptions{PropagationPolicy: &foreground}
+
+	err := r.factory.Client.AppsV1().Deployments(fn.Namespace).Delete(ctx, fn.Name, opts)
+	if err != nil && !k8serrors.IsNotFound(err) {
+		log.Printf("Function file: unable to delete Deployment %s.%s: %v\n", fn.Name, fn.Namespace, err)
+		return
+	}
+
+	err = r.factory.Client.CoreV1().Services(fn.Namespace).Delete(ctx, fn.Name, opts)
+	if err != nil && !k8serrors.IsNotFound(err) {
+		log.Printf("Function file: unable to delete Service %s.%s: %v\n", fn.Name, fn.Namespace, err)
+	}
+
+	if err := k8s.NewCertificateClient(r.factory.Client).Delete(ctx, fn.Namespace, fn.Name); err != nil {
+		log.Printf("Function file: unable to delete Certificate %s.%s: %v\n", fn.Name, fn.Namespace, err)
+	}
+
+	log.Printf("Function file: deleted %s.%s\n", fn.Name, fn.Namespace)
+}
+
+// removeOrphans deletes the functions in the default namespace that were deployed from a
+// file but are no longer wanted
+func (r *FunctionFileReconciler) removeOrphans(ctx context.Context, wanted map[deployResponse]bool) error {
+	res, err := r.factory.Client.AppsV1().Deployments(r.defaultNamespace).List(ctx, metav1.ListOptions{
+		LabelSelector: "faas_function",
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, item := range res.Items {
+		if _, ok := item.Annotations[FunctionFileAnnotationKey]; !ok {
+			continue
+		}
+
+		key := deployResponse{Name: item.Name, Namespace: item.Namespace}
+		if !wanted[key] {
+			r.remove(ctx, key)
+		}
+	}
+
+	return nil
+}
+
+func markWanted(wanted map[deployResponse]bool, functions []deployResponse) {
+	for _, key := range functions {
+		wanted[key] = true
+	}
+}
+
+func isFunctionFile(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".yaml", ".yml", ".json":
+		return true
+	}
+	return false
+}
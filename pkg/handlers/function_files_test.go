@@ -0,0 +1,152 @@
+// Copyright 2020 OpenFaaS Author(s)
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/openfaas/faas-netes/pkg/k8s"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_FunctionFileReconciler_Sync(t *testing.T) {
+	dir, err := ioutil.TempDir("", "functions")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	writeFile := func(name, content string) {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	client := fake.NewSimpleClientset()
+	factory := k8s.NewFunctionFactory(client, k8s.DeploymentConfig{
+		LivenessProbe:  &k8s.ProbeConfig{},
+		ReadinessProbe: &k8s.ProbeConfig{},
+	}, nil)
+	reconciler := NewFunctionFileReconciler(dir, "openfaas-fn", factory)
+	ctx := context.Background()
+
+	writeFile("stack.yaml", `service: nodeinfo
+image: functions/nodeinfo:1.0
+---
+service: env
+image: functions/alpine:latest
+envProcess: env
+`)
+	writeFile("figlet.json", `{"service":"figlet","image":"functions/figlet:latest"}`)
+	writeFile("README.md", `not a function`)
+
+	if err := reconciler.Sync(ctx); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	assertFunctionNames(t, client, []string{"env", "figlet", "nodeinfo"})
+
+	deployment := getFunctionDeployment(t, client, "nodeinfo")
+	if got := deployment.Annotations[FunctionFileAnnotationKey]; got != "stack.yaml" {
+		t.Errorf("want %s annotation: %q, got: %q", FunctionFileAnnotationKey, "stack.yaml", got)
+	}
+
+	writeFile("stack.yaml", `service: nodeinfo
+image: functions/nodeinfo:2.0
+`)
+	if err := reconciler.Sync(ctx); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	assertFunctionNames(t, client, []string{"figlet", "nodeinfo"})
+
+	deployment = getFunctionDeployment(t, client, "nodeinfo")
+	if got := deployment.Spec.Template.Spec.Containers[0].Image; got != "functions/nodeinfo:2.0" {
+		t.Errorf("want the updated image, got: %q", got)
+	}
+
+	writeFile("figlet.json", `{"service":"figlet",`)
+	if err := reconciler.Sync(ctx); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	assertFunctionNames(t, client, []string{"figlet", "nodeinfo"})
+
+	os.Remove(filepath.Join(dir, "figlet.json"))
+	if err := reconciler.Sync(ctx); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	assertFunctionNames(t, client, []string{"nodeinfo"})
+}
+
+func Test_FunctionFileReconciler_RemovesOrphansOnStart(t *testing.T) {
+	dir, err := ioutil.TempDir("", "functions")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	client := fake.NewSimpleClientset(
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{
+			Name:        "removed",
+			Namespace:   "openfaas-fn",
+			Labels:      map[string]string{"faas_function": "removed"},
+			Annotations: map[string]string{FunctionFileAnnotationKey: "removed.yaml"},
+		}},
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{
+			Name:      "manual",
+			Namespace: "openfaas-fn",
+			Labels:    map[string]string{"faas_function": "manual"},
+		}},
+	)
+	factory := k8s.NewFunctionFactory(client, k8s.DeploymentConfig{
+		LivenessProbe:  &k8s.ProbeConfig{},
+		ReadinessProbe: &k8s.ProbeConfig{},
+	}, nil)
+
+	if err := NewFunctionFileReconciler(dir, "openfaas-fn", factory).Sync(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	assertFunctionNames(t, client, []string{"manual"})
+}
+
+func assertFunctionNames(t *testing.T, client kubernetes.Interface, want []string) {
+	t.Helper()
+
+	res, err := client.AppsV1().Deployments("openfaas-fn").List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var got []string
+	for _, item := range res.Items {
+		got = append(got, item.Name)
+	}
+	sort.Strings(got)
+
+	if len(got) != len(want) {
+		t.Fatalf("want functions %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("want functions %v, got %v", want, got)
+		}
+	}
+}
+
+func getFunctionDeployment(t *testing.T, client kubernetes.Interface, name string) *appsv1.Deployment {
+	t.Helper()
+
+	deployment, err := client.AppsV1().Deployments("openfaas-fn").Get(context.Background(), name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	return deployment
+}
@@ -0,0 +1,107 @@
+// Copyright 2020 OpenFaaS Author(s)
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/openfaas/faas-netes/pkg/k8s"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/kubernetes"
+)
+
+// MakeFunctionLogsHandler streams the logs of every replica of a function merged into one
+// stream of newline delimited JSON, each line tagged with the name and labels of its Pod.
+// The logs can be limited with `tail`, `since`, as an RFC3339 time or a duration such as 10m,
+// `instance` for a single Pod and `container` for another container of the Pods. With
+// `follow=true` new lines are streamed until timeout. The logs are always read from
+// Kubernetes, whichever log backend is configured.
+func MakeFunctionLogsHandler(defaultNamespace string, clientset kubernetes.Interface, timeout time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Body != nil {
+			defer r.Body.Close()
+		}
+
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			log.Println("Function logs: response is not a Flusher, required for streaming response")
+			http.NotFound(w, r)
+			return
+		}
+
+		functionName := mux.Vars(r)["name"]
+		q := r.URL.Query()
+
+		lookupNamespace := defaultNamespace
+		if namespace := q.Get("namespace"); len(namespace) > 0 {
+			lookupNamespace = namespace
+		}
+
+		if lookupNamespace == "kube-system" {
+			http.Error(w, "unable to list within the kube-system namespace", http.StatusUnauthorized)
+			return
+		}
+
+		var since *time.Time
+		if v := q.Get("since"); len(v) > 0 {
+			parsed, err := parseSinceParam(v)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			since = &parsed
+		}
+
+		var tail int64
+		if v := q.Get("tail"); len(v) > 0 {
+			parsed, err := strconv.ParseInt(v, 10, 64)
+			if err != nil || parsed < 1 {
+				http.Error(w, fmt.Sprintf("invalid tail: %q, must be a positive integer", v), http.StatusBadRequest)
+				return
+			}
+			tail = parsed
+		}
+
+		container := q.Get("container")
+		if len(container) > 0 {
+			if errs := validation.IsDNS1123Label(container); len(errs) > 0 {
+				http.Error(w, fmt.Sprintf("invalid container: %q, %s", container, strings.Join(errs, ", ")), http.StatusBadRequest)
+				return
+			}
+		}
+
+		follow := q.Get("follow") == "true"
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		lines, err := k8s.GetLogs(ctx, clientset, functionName, container, lookupNamespace, q.Get("instance"), tail, since, follow)
+		if err != nil {
+			log.Printf("Function logs error: %v\n", err)
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+
+		encoder := json.NewEncoder(w)
+		for line := range lines {
+			if err := encoder.Encode(line); err != nil {
+				log.Printf("Function logs: failed to write log line: %v\n", err)
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright 2020 OpenFaaS Author(s)
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/openfaas/faas-netes/pkg/k8s"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_MakeFunctionLogsHandler(t *testing.T) {
+	newPod := func(name, version string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "openfaas-fn",
+				Labels:    map[string]string{"faas_function": "nodeinfo", "version": version},
+			},
+		}
+	}
+	clientset := fake.NewSimpleClientset(newPod("nodeinfo-a", "1"), newPod("nodeinfo-b", "2"))
+	handler := MakeFunctionLogsHandler("openfaas-fn", clientset, time.Second*5)
+
+	cases := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantPods   []string
+	}{
+		{name: "merged replicas", wantStatus: http.StatusOK, wantPods: []string{"nodeinfo-a/1", "nodeinfo-b/2"}},
+		{name: "single instance", query: "?instance=nodeinfo-a&tail=10", wantStatus: http.StatusOK, wantPods: []string{"nodeinfo-a/1"}},
+		{name: "invalid tail", query: "?tail=-1", wantStatus: http.StatusBadRequest},
+		{name: "invalid since", query: "?since=yesterday", wantStatus: http.StatusBadRequest},
+		{name: "invalid container", query: "?container=Not_Valid", wantStatus: http.StatusBadRequest},
+		{name: "kube-system", query: "?namespace=kube-system", wantStatus: http.StatusUnauthorized},
+		{name: "no replicas", query: "?instance=nodeinfo-c", wantStatus: http.StatusNotFound},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/system/function/nodeinfo/logs"+tc.query, nil)
+			req = mux.SetURLVars(req, map[string]string{"name": "nodeinfo"})
+			rr := httptest.NewRecorder()
+
+			handler(rr, req)
+
+			if rr.Code != tc.wantStatus {
+				t.Fatalf("want status: %d, got: %d, body: %s", tc.wantStatus, rr.Code, rr.Body.String())
+			}
+			if tc.wantStatus != http.StatusOK {
+				return
+			}
+
+			if ct := rr.Header().Get("Content-Type"); ct != "application/x-ndjson" {
+				t.Errorf("want Content-Type: application/x-ndjson, got: %s", ct)
+			}
+
+			var got []string
+			scanner := bufio.NewScanner(rr.Body)
+			for scanner.Scan() {
+				var line k8s.Log
+				if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+					t.Fatalf("unable to decode line %q: %s", scanner.Text(), err)
+				}
+				got = append(got, line.PodName+"/"+line.Labels["version"])
+			}
+			sort.Strings(got)
+
+			if len(got) != len(tc.wantPods) {
+				t.Fatalf("want lines from: %v, got: %v", tc.wantPods, got)
+			}
+			for i := range got {
+				if got[i] != tc.wantPods[i] {
+					t.Errorf("want lines from: %v, got: %v", tc.wantPods, got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,369 @@
+// Copyright 2020 OpenFaaS Author(s)
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/openfaas/faas-provider/httputil"
+	"github.com/openfaas/faas-provider/proxy"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/openfaas/faas-netes/pkg/k8s"
+)
+
+const (
+	// grpcHeaderSize is the size of the prefix of each gRPC message, a compressed flag
+	// followed by the big-endian length of the message
+	grpcHeaderSize = 5
+
+	// grpcMaxMessageSize is the largest unary request or response message, which is the
+	// default receive limit of gRPC servers
+	grpcMaxMessageSize = 4 << 20
+
+	// grpcIdleTimeout is how long a connection to a function endpoint is kept in the pool
+	// without any calls, so that the connections of replicas that have gone are closed
+	grpcIdleTimeout = 5 * time.Minute
+)
+
+// grpcCodecs maps the Content-Type of a plain request to the gRPC content-subtype that the
+// function's server decodes the message with
+var grpcCodecs = map[string]string{
+	"application/json":       "json",
+	"application/protobuf":   "proto",
+	"application/x-protobuf": "proto",
+}
+
+// grpcHTTPStatus maps gRPC status codes to HTTP status codes for callers that send a plain
+// JSON or protobuf message
+var grpcHTTPStatus = map[codes.Code]int{
+	codes.OK:                 http.StatusOK,
+	codes.Canceled:           499,
+	codes.InvalidArgument:    http.StatusBadRequest,
+	codes.DeadlineExceeded:   http.StatusGatewayTimeout,
+	codes.NotFound:           http.StatusNotFound,
+	codes.AlreadyExists:      http.StatusConflict,
+	codes.PermissionDenied:   http.StatusForbidden,
+	codes.ResourceExhausted:  http.StatusTooManyRequests,
+	codes.FailedPrecondition: http.StatusBadRequest,
+	codes.Aborted:            http.StatusConflict,
+	codes.OutOfRange:         http.StatusBadRequest,
+	codes.Unimplemented:      http.StatusNotImplemented,
+	codes.Unavailable:        http.StatusServiceUnavailable,
+	codes.Unauthenticated:    http.StatusUnauthorized,
+}
+
+// grpcSkipHeaders are not copied between the caller's headers and the call's metadata, they
+// are either set by the gRPC client and server or only apply to a single connection
+var grpcSkipHeaders = map[string]bool{
+	"accept-encoding":   true,
+	"connection":        true,
+	"content-length":    true,
+	"content-type":      true,
+	"date":              true,
+	"keep-alive":        true,
+	"te":                true,
+	"trailer":           true,
+	"transfer-encoding": true,
+	"upgrade":           true,
+	"user-agent":        true,
+}
+
+// rawCodec passes messages through without decoding them, its name is sent as the
+// content-subtype so that the function's server decodes the message as JSON or protobuf
+type rawCodec struct {
+	subtype string
+}
+
+func (c rawCodec) Marshal(v interface{}) ([]byte, error) {
+	message, ok := v.(*[]byte)
+	if !ok {
+		return nil, fmt.Errorf("rawCodec: unexpected message type %T", v)
+	}
+	return *message, nil
+}
+
+func (c rawCodec) Unmarshal(data []byte, v interface{}) error {
+	message, ok := v.(*[]byte)
+	if !ok {
+		return fmt.Errorf("rawCodec: unexpected message type %T", v)
+	}
+	*message = append((*message)[:0], data...)
+	return nil
+}
+
+func (c rawCodec) Name() string {
+	return c.subtype
+}
+
+// grpcConn is a pooled connection to a function endpoint
+type grpcConn struct {
+	conn     *grpc.ClientConn
+	lastUsed time.Time
+}
+
+// grpcConnPool keeps a gRPC connection per function endpoint, that calls to the endpoint are
+// multiplexed over. Connections that have been idle for grpcIdleTimeout, or that have been
+// shut down, are closed the next time the pool is used.
+type grpcConnPool struct {
+	timeout time.Duration
+
+	lock      sync.Mutex
+	conns     map[string]*grpcConn
+	lastSweep time.Time
+}
+
+// newGRPCConnPool returns a pool of connections for functions with the grpc transport, calls
+// are made over cleartext HTTP/2 and end after timeout
+func newGRPCConnPool(timeout time.Duration) *grpcConnPool {
+	return &grpcConnPool{
+		timeout: timeout,
+		conns:   map[string]*grpcConn{},
+	}
+}
+
+// Get returns the connection for target, dialling it when there is none. The connection
+// is established in the background, so that the call's deadline also covers the dial.
+func (p *grpcConnPool) Get(target string) (*grpc.ClientConn, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	now := time.Now()
+	if now.Sub(p.lastSweep) > grpcIdleTimeout {
+		p.sweep(now)
+	}
+
+	if c, ok := p.conns[target]; ok && c.conn.GetState() != connectivity.Shutdown {
+		c.lastUsed = now
+		return c.conn, nil
+	}
+
+	conn, err := grpc.Dial(target,
+		grpc.WithInsecure(),
+		grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(grpcMaxMessageSize)),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	p.conns[target] = &grpcConn{conn: conn, lastUsed: now}
+	return conn, nil
+}
+
+// sweep closes the connections that have not been used within grpcIdleTimeout
+func (p *grpcConnPool) sweep(now time.Time) {
+	for target, c := range p.conns {
+		if now.Sub(c.lastUsed) > grpcIdleTimeout {
+			c.conn.Close()
+			delete(p.conns, target)
+		}
+	}
+	p.lastSweep = now
+}
+
+// proxyGRPCRequest forwards a unary gRPC call to the function. The path after the function's
+// name is the method, i.e. /function/greeter/helloworld.Greeter/SayHello. Requests that are
+// already gRPC framed, with an application/grpc Content-Type, get a framed reply with the
+// gRPC status in the trailers. A plain application/json or protobuf message is sent with the
+// matching content-subtype, then the reply message is returned unframed, with its gRPC
+// status mapped to an HTTP status.
+func proxyGRPCRequest(w http.ResponseWriter, originalReq *http.Request, pool *grpcConnPool, resolver proxy.BaseURLResolver, target, loadBalancer string) {
+	if originalReq.Method != http.MethodPost {
+		httputil.Errorf(w, http.StatusMethodNotAllowed, "gRPC functions must be invoked with %s.", http.MethodPost)
+		return
+	}
+
+	method := "/" + strings.TrimPrefix(mux.Vars(originalReq)["params"], "/")
+	if parts := strings.Split(method, "/"); len(parts) != 3 || len(parts[1]) == 0 || len(parts[2]) == 0 {
+		httputil.Errorf(w, http.StatusBadRequest, "Provide the gRPC method in the request path as /package.Service/Method.")
+		return
+	}
+
+	contentType, _, _ := mime.ParseMediaType(originalReq.Header.Get("Content-Type"))
+	framed := contentType == "application/grpc" || strings.HasPrefix(contentType, "application/grpc+")
+
+	subtype := "proto"
+	if framed {
+		if s := strings.TrimPrefix(contentType, "application/grpc+"); s != contentType {
+			subtype = s
+		}
+	} else {
+		var ok bool
+		if subtype, ok = grpcCodecs[contentType]; !ok {
+			httputil.Errorf(w, http.StatusUnsupportedMediaType, "Unsupported Content-Type for a gRPC function: %q.", contentType)
+			return
+		}
+	}
+
+	body, err := readLimited(originalReq.Body, grpcMaxMessageSize+grpcHeaderSize)
+	if err != nil {
+		httputil.Errorf(w, http.StatusRequestEntityTooLarge, "Request too large for a gRPC function: %s.", err)
+		return
+	}
+
+	request := body
+	if framed {
+		if request, err = grpcUnframe(body); err != nil {
+			httputil.Errorf(w, http.StatusBadRequest, "Invalid gRPC request: %s.", err)
+			return
+		}
+	}
+
+	functionAddr, release, resolveErr := resolveFunction(resolver, target, loadBalancer)
+	if resolveErr != nil {
+		log.Printf("resolver error: no endpoints for %s: %s\n", target, resolveErr.Error())
+		httputil.Errorf(w, http.StatusServiceUnavailable, "No endpoints available for: %s.", target)
+		return
+	}
+	defer release()
+
+	upstream := k8s.GRPCTarget(functionAddr, watchdogPort)
+	conn, err := pool.Get(upstream)
+	if err != nil {
+		log.Printf("error with gRPC connection to: %s, %s\n", upstream, err.Error())
+		httputil.Errorf(w, http.StatusInternalServerError, "Can't reach service for: %s.", target)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(originalReq.Context(), pool.timeout)
+	defer cancel()
+	ctx = metadata.NewOutgoingContext(ctx, grpcMetadata(originalReq))
+
+	var reply []byte
+	var header, trailer metadata.MD
+
+	start := time.Now()
+	err = conn.Invoke(ctx, method, &request, &reply,
+		grpc.ForceCodec(rawCodec{subtype: subtype}),
+		grpc.Header(&header),
+		grpc.Trailer(&trailer),
+	)
+	log.Printf("%s took %f seconds\n", target, time.Since(start).Seconds())
+
+	callStatus := status.Convert(err)
+	if err != nil {
+		log.Printf("error with gRPC request to: %s%s, %s\n", upstream, method, err.Error())
+	}
+
+	if framed {
+		// without a Content-Length the response is chunked, which is needed to send trailers
+		copyMetadata(w.Header(), header, "")
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Trailer", "Grpc-Status, Grpc-Message")
+		w.WriteHeader(http.StatusOK)
+		if err == nil {
+			w.Write(grpcFrame(reply))
+		}
+
+		copyMetadata(w.Header(), trailer, http.TrailerPrefix)
+		w.Header().Set("Grpc-Status", strconv.Itoa(int(callStatus.Code())))
+		if len(callStatus.Message()) > 0 {
+			w.Header().Set("Grpc-Message", url.PathEscape(callStatus.Message()))
+		}
+		return
+	}
+
+	w.Header().Set("Grpc-Status", strconv.Itoa(int(callStatus.Code())))
+	if err != nil {
+		httpStatus, ok := grpcHTTPStatus[callStatus.Code()]
+		if !ok {
+			httpStatus = http.StatusInternalServerError
+		}
+		httputil.Errorf(w, httpStatus, "gRPC status %d: %s", callStatus.Code(), callStatus.Message())
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	w.Write(reply)
+}
+
+// grpcMetadata forwards the caller's headers to the function as gRPC metadata. Binary
+// headers, with a -bin suffix, are base64 encoded by the caller and are not forwarded.
+func grpcMetadata(r *http.Request) metadata.MD {
+	md := metadata.MD{}
+	for k, v := range r.Header {
+		key := strings.ToLower(k)
+		if grpcSkipHeaders[key] || strings.HasPrefix(key, "grpc-") || strings.HasSuffix(key, "-bin") {
+			continue
+		}
+		md.Append(key, v...)
+	}
+
+	if len(md.Get("x-forwarded-for")) == 0 {
+		md.Set("x-forwarded-for", r.RemoteAddr)
+	}
+	return md
+}
+
+// copyMetadata adds the response metadata of a call to the headers of the reply, with
+// prefix for trailers
+func copyMetadata(destination http.Header, md metadata.MD, prefix string) {
+	for k, v := range md {
+		if grpcSkipHeaders[k] || strings.HasSuffix(k, "-bin") {
+			continue
+		}
+		for _, value := range v {
+			destination.Add(prefix+k, value)
+		}
+	}
+}
+
+// grpcFrame prefixes an uncompressed message with its gRPC message header
+func grpcFrame(message []byte) []byte {
+	framed := make([]byte, grpcHeaderSize+len(message))
+	binary.BigEndian.PutUint32(framed[1:grpcHeaderSize], uint32(len(message)))
+	copy(framed[grpcHeaderSize:], message)
+	return framed
+}
+
+// grpcUnframe returns the single message of a unary gRPC request
+func grpcUnframe(body []byte) ([]byte, error) {
+	if len(body) < grpcHeaderSize {
+		return nil, fmt.Errorf("request of %d bytes has no message", len(body))
+	}
+	if body[0] != 0 {
+		return nil, fmt.Errorf("compressed messages are not supported")
+	}
+
+	size := binary.BigEndian.Uint32(body[1:grpcHeaderSize])
+	if uint32(len(body)-grpcHeaderSize) != size {
+		return nil, fmt.Errorf("want a single message of %d bytes, got %d bytes", size, len(body)-grpcHeaderSize)
+	}
+
+	return body[grpcHeaderSize:], nil
+}
+
+// readLimited reads the whole of r, returning an error when it is longer than limit
+func readLimited(r io.Reader, limit int64) ([]byte, error) {
+	if r == nil {
+		return []byte{}, nil
+	}
+
+	data, err := ioutil.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("more than %d bytes", limit)
+	}
+	return data, nil
+}
@@ -0,0 +1,292 @@
+// Copyright 2020 OpenFaaS Author(s)
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/openfaas/faas-provider/types"
+	"golang.org/x/net/http2"
+	"google.golang.org/grpc/connectivity"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/openfaas/faas-netes/pkg/k8s"
+)
+
+// newH2CServer serves handler over cleartext HTTP/2, as a gRPC server without TLS does
+func newH2CServer(t *testing.T, handler http.Handler) (url.URL, func()) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	server := &http2.Server{}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go server.ServeConn(conn, &http2.ServeConnOpts{Handler: handler})
+		}
+	}()
+
+	return url.URL{Scheme: "http", Host: l.Addr().String()}, func() { l.Close() }
+}
+
+func Test_MakeProxyHandler_GRPC(t *testing.T) {
+	upstreamURL, closeUpstream := newH2CServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor != 2 || r.Method != http.MethodPost || r.URL.Path != "/helloworld.Greeter/SayHello" {
+			t.Errorf("unexpected request: %s %s %s", r.Proto, r.Method, r.URL.Path)
+		}
+		if te := r.Header.Get("TE"); te != "trailers" {
+			t.Errorf("want TE: trailers, got: %q", te)
+		}
+		if len(r.Header.Get("X-Forwarded-For")) == 0 {
+			t.Errorf("want the X-Forwarded-For metadata")
+		}
+
+		body, _ := ioutil.ReadAll(r.Body)
+		message, err := grpcUnframe(body)
+		if err != nil {
+			t.Errorf("unexpected request body: %s", err)
+		}
+
+		w.Header().Set("Content-Type", r.Header.Get("Content-Type"))
+		if bytes.Contains(message, []byte("nobody")) {
+			w.Header().Set("Grpc-Status", "5")
+			w.Header().Set("Grpc-Message", "no%20such%20person")
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		w.Write(grpcFrame(append([]byte(r.Header.Get("Content-Type")+" "), message...)))
+		w.Header().Set(http.TrailerPrefix+"Grpc-Status", "0")
+	}))
+	defer closeUpstream()
+
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "greeter",
+			Namespace:   "openfaas-fn",
+			Annotations: map[string]string{k8s.TransportAnnotationKey: "grpc"},
+		},
+	}
+
+	config := types.FaaSConfig{ReadTimeout: time.Second * 5, WriteTimeout: time.Second * 5}
+	handler := MakeProxyHandler(config, testResolver{url: upstreamURL}, "openfaas-fn", newTestDeploymentLister(t, deployment))
+
+	router := mux.NewRouter()
+	router.HandleFunc("/function/{name}", handler)
+	router.HandleFunc("/function/{name}/{params:.*}", handler)
+	srv := httptest.NewServer(router)
+	defer srv.Close()
+
+	cases := []struct {
+		name        string
+		method      string
+		path        string
+		contentType string
+		body        []byte
+		wantStatus  int
+		wantBody    []byte
+		wantTrailer string
+	}{
+		{
+			name:        "json message",
+			method:      http.MethodPost,
+			path:        "/function/greeter/helloworld.Greeter/SayHello",
+			contentType: "application/json",
+			body:        []byte(`{"name":"world"}`),
+			wantStatus:  http.StatusOK,
+			wantBody:    []byte(`application/grpc+json {"name":"world"}`),
+		},
+		{
+			name:        "protobuf message",
+			method:      http.MethodPost,
+			path:        "/function/greeter/helloworld.Greeter/SayHello",
+			contentType: "application/protobuf",
+			body:        []byte{0x0a, 0x05, 'w', 'o', 'r', 'l', 'd'},
+			wantStatus:  http.StatusOK,
+			wantBody:    append([]byte("application/grpc+proto "), 0x0a, 0x05, 'w', 'o', 'r', 'l', 'd'),
+		},
+		{
+			name:        "framed gRPC request is passed through",
+			method:      http.MethodPost,
+			path:        "/function/greeter/helloworld.Greeter/SayHello",
+			contentType: "application/grpc",
+			body:        grpcFrame([]byte("raw")),
+			wantStatus:  http.StatusOK,
+			wantBody:    grpcFrame([]byte("application/grpc+proto raw")),
+			wantTrailer: "0",
+		},
+		{
+			name:        "framed gRPC request keeps its content-subtype",
+			method:      http.MethodPost,
+			path:        "/function/greeter/helloworld.Greeter/SayHello",
+			contentType: "application/grpc+json",
+			body:        grpcFrame([]byte(`{"name":"world"}`)),
+			wantStatus:  http.StatusOK,
+			wantBody:    grpcFrame([]byte(`application/grpc+json {"name":"world"}`)),
+			wantTrailer: "0",
+		},
+		{
+			name:        "framed gRPC status is returned in the trailers",
+			method:      http.MethodPost,
+			path:        "/function/greeter/helloworld.Greeter/SayHello",
+			contentType: "application/grpc",
+			body:        grpcFrame([]byte("nobody")),
+			wantStatus:  http.StatusOK,
+			wantBody:    []byte{},
+			wantTrailer: "5",
+		},
+		{
+			name:        "invalid framed gRPC request",
+			method:      http.MethodPost,
+			path:        "/function/greeter/helloworld.Greeter/SayHello",
+			contentType: "application/grpc",
+			body:        []byte("raw"),
+			wantStatus:  http.StatusBadRequest,
+		},
+		{
+			name:        "gRPC status is mapped to HTTP",
+			method:      http.MethodPost,
+			path:        "/function/greeter/helloworld.Greeter/SayHello",
+			contentType: "application/json",
+			body:        []byte(`{"name":"nobody"}`),
+			wantStatus:  http.StatusNotFound,
+			wantBody:    []byte("gRPC status 5: no such person"),
+		},
+		{
+			name:       "unary calls must be POST",
+			method:     http.MethodGet,
+			path:       "/function/greeter/helloworld.Greeter/SayHello",
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:        "method is required",
+			method:      http.MethodPost,
+			path:        "/function/greeter/helloworld.Greeter",
+			contentType: "application/json",
+			wantStatus:  http.StatusBadRequest,
+		},
+		{
+			name:        "unsupported content type",
+			method:      http.MethodPost,
+			path:        "/function/greeter/helloworld.Greeter/SayHello",
+			contentType: "text/plain",
+			wantStatus:  http.StatusUnsupportedMediaType,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(tc.method, srv.URL+tc.path, bytes.NewReader(tc.body))
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if len(tc.contentType) > 0 {
+				req.Header.Set("Content-Type", tc.contentType)
+			}
+
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			defer res.Body.Close()
+
+			body, _ := ioutil.ReadAll(res.Body)
+			if res.StatusCode != tc.wantStatus {
+				t.Fatalf("want status: %d, got: %d, body: %s", tc.wantStatus, res.StatusCode, string(body))
+			}
+			if tc.wantBody != nil && !bytes.Equal(bytes.TrimSpace(body), tc.wantBody) {
+				t.Errorf("want body: %q, got: %q", tc.wantBody, body)
+			}
+			if got := res.Trailer.Get("Grpc-Status"); got != tc.wantTrailer {
+				t.Errorf("want Grpc-Status trailer: %q, got: %q", tc.wantTrailer, got)
+			}
+		})
+	}
+}
+
+func Test_grpcUnframe(t *testing.T) {
+	cases := []struct {
+		name    string
+		body    []byte
+		want    string
+		wantErr string
+	}{
+		{name: "single message", body: grpcFrame([]byte("reply")), want: "reply"},
+		{name: "empty message", body: grpcFrame([]byte{}), want: ""},
+		{name: "no message", body: []byte{}, wantErr: "has no message"},
+		{name: "compressed", body: append([]byte{1, 0, 0, 0, 1}, 'x'), wantErr: "compressed"},
+		{name: "streamed messages", body: append(grpcFrame([]byte("a")), grpcFrame([]byte("b"))...), wantErr: "single message"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := grpcUnframe(tc.body)
+			if len(tc.wantErr) > 0 {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("want error containing %q, got: %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if string(got) != tc.want {
+				t.Errorf("want: %q, got: %q", tc.want, string(got))
+			}
+		})
+	}
+}
+
+func Test_grpcConnPool(t *testing.T) {
+	pool := newGRPCConnPool(time.Second)
+
+	first, err := pool.Get("127.0.0.1:8080")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	second, err := pool.Get("127.0.0.1:8080")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if first != second {
+		t.Errorf("want the connection to an endpoint to be reused")
+	}
+
+	other, err := pool.Get("127.0.0.2:8080")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if other == first {
+		t.Errorf("want a connection per endpoint")
+	}
+
+	pool.conns["127.0.0.1:8080"].lastUsed = time.Now().Add(-2 * grpcIdleTimeout)
+	pool.sweep(time.Now())
+
+	if _, ok := pool.conns["127.0.0.1:8080"]; ok {
+		t.Errorf("want the idle connection removed from the pool")
+	}
+	if first.GetState() != connectivity.Shutdown {
+		t.Errorf("want the idle connection closed, got: %s", first.GetState())
+	}
+	if _, ok := pool.conns["127.0.0.2:8080"]; !ok {
+		t.Errorf("want the active connection kept in the pool")
+	}
+	other.Close()
+}
@@ -5,9 +5,12 @@
 package handlers
 
 import (
+	"regexp"
 	"testing"
 
+	"github.com/openfaas/faas-netes/pkg/k8s"
 	types "github.com/openfaas/faas-provider/types"
+	"k8s.io/client-go/kubernetes/fake"
 )
 
 func Test_ValidateDeployRequest_ValidCharacters(t *testing.T) {
@@ -58,3 +61,75 @@ func Test_ValidateDeployRequest_InvalidCharacters(t *testing.T) {
 		}
 	}
 }
+
+func Test_ValidateFunctionNameLength(t *testing.T) {
+	cases := []struct {
+		scenario  string
+		name      string
+		maxLength int
+		wantErr   bool
+	}{
+		{"shorter than the maximum", "figlet", 63, false},
+		{"equal to the maximum", "figlet", 6, false},
+		{"longer than the maximum", "figlet", 5, true},
+		{"check disabled", "figlet", 0, false},
+	}
+
+	for _, testCase := range cases {
+		err := ValidateFunctionNameLength(testCase.name, testCase.maxLength)
+		if testCase.wantErr != (err != nil) {
+			t.Errorf("Scenario: %s, want error: %v, got: %v", testCase.scenario, testCase.wantErr, err)
+		}
+	}
+}
+
+func Test_ValidateImagePolicy(t *testing.T) {
+	cases := []struct {
+		scenario string
+		image    string
+		pattern  *regexp.Regexp
+		wantErr  bool
+	}{
+		{"matching registry", "registry.example.com/team/figlet:0.1.0", regexp.MustCompile(`^registry\.example\.com/`), false},
+		{"different registry", "docker.io/functions/figlet:latest", regexp.MustCompile(`^registry\.example\.com/`), true},
+		{"matching tag", "ghcr.io/openfaas/figlet:0.1.0", regexp.MustCompile(`:[0-9]+\.[0-9]+\.[0-9]+$`), false},
+		{"latest tag", "ghcr.io/openfaas/figlet:latest", regexp.MustCompile(`:[0-9]+\.[0-9]+\.[0-9]+$`), true},
+		{"check disabled", "docker.io/functions/figlet:latest", nil, false},
+	}
+
+	for _, testCase := range cases {
+		err := ValidateImagePolicy(testCase.image, testCase.pattern)
+		if testCase.wantErr != (err != nil) {
+			t.Errorf("Scenario: %s, want error: %v, got: %v", testCase.scenario, testCase.wantErr, err)
+		}
+	}
+}
+
+func Test_validateFunctionAnnotations(t *testing.T) {
+	cases := []struct {
+		scenario     string
+		annotations  map[string]string
+		wantResource string
+	}{
+		{"no annotations", map[string]string{}, ""},
+		{"valid annotations", map[string]string{k8s.ExecMaxDurationAnnotationKey: "30s", k8s.TransportAnnotationKey: "grpc"}, ""},
+		{"invalid TLS domain", map[string]string{k8s.TLSDomainAnnotationKey: "not a domain"}, "Certificate"},
+		{"invalid exec max duration", map[string]string{k8s.ExecMaxDurationAnnotationKey: "forever"}, "Deployment"},
+		{"invalid transport", map[string]string{k8s.TransportAnnotationKey: "carrier-pigeon"}, "Deployment"},
+		{"invalid PDB min available", map[string]string{k8s.PDBMinAvailableAnnotationKey: "most"}, "PodDisruptionBudget"},
+	}
+
+	factory := k8s.NewFunctionFactory(fake.NewSimpleClientset(), k8s.DeploymentConfig{}, nil)
+
+	for _, testCase := range cases {
+		invalid := validateFunctionAnnotations(factory, "figlet", testCase.annotations)
+
+		got := ""
+		if invalid != nil {
+			got = invalid.resource
+		}
+		if got != testCase.wantResource {
+			t.Errorf("Scenario: %s, want resource: %q, got: %q (%v)", testCase.scenario, testCase.wantResource, got, invalid)
+		}
+	}
+}
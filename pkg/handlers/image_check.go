@@ -0,0 +1,60 @@
+// Copyright 2020 OpenFaaS Author(s)
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/openfaas/faas-netes/pkg/k8s"
+	types "github.com/openfaas/faas-provider/types"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ImageNotFound is the error code returned when the image of a function can not be found in
+// its registry
+const ImageNotFound = "IMAGE_NOT_FOUND"
+
+// checkImage returns an error when the image of the function does not exist or can not be
+// pulled with the function's pull secrets, which are the docker config secrets of the request
+// and the image pull secrets of the namespace's default ServiceAccount. When the registry can
+// not be reached in time, the failure is logged and the function is deployed anyway.
+func checkImage(ctx context.Context, factory k8s.FunctionFactory, namespace string, request types.FunctionDeployment) error {
+	if factory.ImageChecker == nil {
+		return nil
+	}
+
+	names := append([]string{}, request.Secrets...)
+	sa, err := factory.Client.CoreV1().ServiceAccounts(namespace).Get(ctx, "default", metav1.GetOptions{})
+	if err == nil {
+		for _, ref := range sa.ImagePullSecrets {
+			names = append(names, ref.Name)
+		}
+	}
+
+	var pullSecrets []corev1.Secret
+	for _, name := range names {
+		secret, err := factory.Client.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			continue
+		}
+		if secret.Type == corev1.SecretTypeDockerConfigJson || secret.Type == corev1.SecretTypeDockercfg {
+			pullSecrets = append(pullSecrets, *secret)
+		}
+	}
+
+	err = factory.ImageChecker.Check(ctx, request.Image, pullSecrets)
+	var notFound *k8s.ImageNotFoundError
+	if errors.As(err, &notFound) {
+		log.Printf("Image check failed for %s.%s: %s\n", request.Service, namespace, err)
+		return err
+	}
+	if err != nil {
+		log.Printf("Unable to check image %s for %s.%s, deploying without the check: %s\n", request.Image, request.Service, namespace, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,129 @@
+// Copyright 2020 OpenFaaS Author(s)
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gorilla/mux"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// FunctionImages lists the image running in each replica of a function
+type FunctionImages struct {
+	Name      string         `json:"name"`
+	Namespace string         `json:"namespace"`
+	Replicas  []ReplicaImage `json:"replicas"`
+	// Digests is the set of distinct digests running, more than one indicates
+	// that a rollout is in progress or that a tag was overwritten
+	Digests []string `json:"digests"`
+}
+
+// ReplicaImage is the image requested and resolved by the kubelet for a single replica
+type ReplicaImage struct {
+	Pod     string `json:"pod"`
+	Image   string `json:"image"`
+	ImageID string `json:"imageID"`
+	Digest  string `json:"digest,omitempty"`
+	Ready   bool   `json:"ready"`
+}
+
+// MakeFunctionImagesHandler returns the resolved image digest for each replica of a function,
+// as reported by the container statuses of its Pods.
+func MakeFunctionImagesHandler(defaultNamespace string, clientset kubernetes.Interface) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Body != nil {
+			defer r.Body.Close()
+		}
+
+		functionName := mux.Vars(r)["name"]
+
+		lookupNamespace := defaultNamespace
+		if namespace := r.URL.Query().Get("namespace"); len(namespace) > 0 {
+			lookupNamespace = namespace
+		}
+
+		if lookupNamespace == "kube-system" {
+			http.Error(w, "unable to list within the kube-system namespace", http.StatusUnauthorized)
+			return
+		}
+
+		opts := metav1.ListOptions{LabelSelector: fmt.Sprintf("faas_function=%s", functionName)}
+		pods, err := clientset.CoreV1().Pods(lookupNamespace).List(r.Context(), opts)
+		if err != nil {
+			status, reason := ProcessErrorReasons(err)
+			log.Printf("Function images list error reason: %s, %v\n", reason, err)
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		if len(pods.Items) == 0 {
+			http.Error(w, fmt.Sprintf("no replicas found for %s.%s", functionName, lookupNamespace), http.StatusNotFound)
+			return
+		}
+
+		res := FunctionImages{
+			Name:      functionName,
+			Namespace: lookupNamespace,
+			Replicas:  []ReplicaImage{},
+			Digests:   []string{},
+		}
+
+		digests := map[string]bool{}
+		for _, pod := range pods.Items {
+			replica := replicaImage(pod, functionName)
+			res.Replicas = append(res.Replicas, replica)
+
+			if len(replica.Digest) > 0 && !digests[replica.Digest] {
+				digests[replica.Digest] = true
+				res.Digests = append(res.Digests, replica.Digest)
+			}
+		}
+
+		sort.Strings(res.Digests)
+
+		out, err := json.Marshal(res)
+		if err != nil {
+			log.Printf("Function images json marshal error: %v\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(out)
+	}
+}
+
+// replicaImage reads the status of the function container, which shares the name of the function
+func replicaImage(pod corev1.Pod, functionName string) ReplicaImage {
+	replica := ReplicaImage{Pod: pod.Name}
+
+	for _, c := range pod.Spec.Containers {
+		if c.Name == functionName {
+			replica.Image = c.Image
+		}
+	}
+
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name != functionName {
+			continue
+		}
+
+		replica.ImageID = status.ImageID
+		replica.Ready = status.Ready
+		if i := strings.LastIndex(status.ImageID, "@"); i >= 0 {
+			replica.Digest = status.ImageID[i+1:]
+		}
+	}
+
+	return replica
+}
@@ -0,0 +1,82 @@
+// Copyright 2020 OpenFaaS Author(s)
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func functionPod(name, digest string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "openfaas-fn",
+			Labels:    map[string]string{"faas_function": "nodeinfo"},
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "nodeinfo", Image: "functions/nodeinfo:latest"}},
+		},
+		Status: corev1.PodStatus{
+			ContainerStatuses: []corev1.ContainerStatus{{
+				Name:    "nodeinfo",
+				Ready:   true,
+				ImageID: "docker.io/functions/nodeinfo@" + digest,
+			}},
+		},
+	}
+}
+
+func Test_MakeFunctionImagesHandler(t *testing.T) {
+	clientset := fake.NewSimpleClientset(
+		functionPod("nodeinfo-1", "sha256:aaa"),
+		functionPod("nodeinfo-2", "sha256:bbb"),
+	)
+
+	handler := MakeFunctionImagesHandler("openfaas-fn", clientset)
+
+	req := httptest.NewRequest(http.MethodGet, "/system/function/nodeinfo/images", nil)
+	req = mux.SetURLVars(req, map[string]string{"name": "nodeinfo"})
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("want status: %d, got: %d", http.StatusOK, rr.Code)
+	}
+
+	res := FunctionImages{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &res); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(res.Replicas) != 2 {
+		t.Fatalf("want 2 replicas, got: %d", len(res.Replicas))
+	}
+
+	if len(res.Digests) != 2 || res.Digests[0] != "sha256:aaa" || res.Digests[1] != "sha256:bbb" {
+		t.Errorf("want both digests, got: %v", res.Digests)
+	}
+}
+
+func Test_MakeFunctionImagesHandler_NotFound(t *testing.T) {
+	handler := MakeFunctionImagesHandler("openfaas-fn", fake.NewSimpleClientset())
+
+	req := httptest.NewRequest(http.MethodGet, "/system/function/nodeinfo/images", nil)
+	req = mux.SetURLVars(req, map[string]string{"name": "nodeinfo"})
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("want status: %d, got: %d", http.StatusNotFound, rr.Code)
+	}
+}
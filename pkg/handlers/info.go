@@ -7,6 +7,7 @@ import (
 	"encoding/json"
 	"net/http"
 
+	"github.com/openfaas/faas-netes/version"
 	"github.com/openfaas/faas-provider/types"
 )
 
@@ -17,21 +18,45 @@ const (
 	ProviderName = "faas-netes"
 )
 
-//MakeInfoHandler creates handler for /system/info endpoint
-func MakeInfoHandler(version, sha string) http.HandlerFunc {
+// ProviderInfo is the types.ProviderInfo returned by /system/info with the build date and Go
+// version of the provider added to its version, so existing clients can still decode it
+type ProviderInfo struct {
+	Name          string       `json:"provider"`
+	Version       *VersionInfo `json:"version"`
+	Orchestration string       `json:"orchestration"`
+}
+
+// VersionInfo extends types.VersionInfo with the build date and Go version
+type VersionInfo struct {
+	types.VersionInfo
+	BuildDate string `json:"build_date,omitempty"`
+	GoVersion string `json:"go_version"`
+}
+
+// NewProviderInfo returns the /system/info response for a provider
+func NewProviderInfo(name string, info version.ReleaseInfo) ProviderInfo {
+	return ProviderInfo{
+		Orchestration: OrchestrationIdentifier,
+		Name:          name,
+		Version: &VersionInfo{
+			VersionInfo: types.VersionInfo{
+				Release: info.Release,
+				SHA:     info.SHA,
+			},
+			BuildDate: info.BuildDate,
+			GoVersion: info.GoVersion,
+		},
+	}
+}
+
+// MakeInfoHandler creates handler for /system/info endpoint
+func MakeInfoHandler(info version.ReleaseInfo) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Body != nil {
 			defer r.Body.Close()
 		}
 
-		infoResponse := types.ProviderInfo{
-			Orchestration: OrchestrationIdentifier,
-			Name:          ProviderName,
-			Version: &types.VersionInfo{
-				Release: version,
-				SHA:     sha,
-			},
-		}
+		infoResponse := NewProviderInfo(ProviderName, info)
 
 		jsonOut, marshalErr := json.Marshal(infoResponse)
 		if marshalErr != nil {
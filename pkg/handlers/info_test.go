@@ -8,13 +8,14 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	fversion "github.com/openfaas/faas-netes/version"
 	"github.com/openfaas/faas-provider/types"
 )
 
 func Test_InfoHandler(t *testing.T) {
 	sha := "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
 	version := "0.0.1"
-	handler := MakeInfoHandler(version, sha)
+	handler := MakeInfoHandler(fversion.ReleaseInfo{SHA: sha, Release: version, GoVersion: "go1.16"})
 	w := httptest.NewRecorder()
 	r := httptest.NewRequest("GET", "/", nil)
 	handler(w, r)
@@ -40,4 +41,13 @@ func Test_InfoHandler(t *testing.T) {
 	if resp.Version.Release != version {
 		t.Fatalf("expected release %q, got %q", version, resp.Version.Release)
 	}
+
+	extended := ProviderInfo{}
+	if err := json.Unmarshal(w.Body.Bytes(), &extended); err != nil {
+		t.Fatalf("unexpected error unmarshalling the response")
+	}
+
+	if extended.Version.GoVersion != "go1.16" {
+		t.Fatalf("expected go version %q, got %q", "go1.16", extended.Version.GoVersion)
+	}
 }
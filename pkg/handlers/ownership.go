@@ -0,0 +1,184 @@
+// Copyright 2020 OpenFaaS Author(s)
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"sort"
+
+	"github.com/gorilla/mux"
+	"github.com/openfaas/faas-netes/pkg/k8s"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// OwnerLabel marks a resource as belonging to a function, for resources such as ConfigMaps
+// and Secrets which are not owned through an owner reference
+const OwnerLabel = k8s.OwnerLabel
+
+// MakeOwnershipHandler returns the Kubernetes resources that belong to a function as a map of
+// kind to names: its Deployment, the ReplicaSets and Pods owned by it, Deployments owned by it
+// such as its variants, its Service and the PodDisruptionBudgets, HorizontalPodAutoscalers,
+// NetworkPolicies, ConfigMaps and Secrets with the OwnerLabel. A function that has already
+// been deleted returns whatever is left of it, nothing is deleted.
+func MakeOwnershipHandler(defaultNamespace string, clientset kubernetes.Interface) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Body != nil {
+			defer r.Body.Close()
+		}
+
+		functionName := mux.Vars(r)["name"]
+
+		lookupNamespace := defaultNamespace
+		if namespace := r.URL.Query().Get("namespace"); len(namespace) > 0 {
+			lookupNamespace = namespace
+		}
+
+		if lookupNamespace == "kube-system" {
+			http.Error(w, "unable to list within the kube-system namespace", http.StatusUnauthorized)
+			return
+		}
+
+		resources, err := functionOwnership(r.Context(), clientset, lookupNamespace, functionName)
+		if err != nil {
+			status, reason := ProcessErrorReasons(err)
+			log.Printf("Function ownership error reason: %s, %v\n", reason, err)
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		out, err := json.Marshal(resources)
+		if err != nil {
+			log.Printf("Function ownership json marshal error: %v\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(out)
+	}
+}
+
+// functionOwnership builds the closure of the resources of a function. Owner references are
+// matched by kind and name rather than UID, so that resources orphaned by a deleted
+// Deployment are still found.
+func functionOwnership(ctx context.Context, clientset kubernetes.Interface, namespace, name string) (map[string][]string, error) {
+	resources := map[string][]string{}
+	add := func(kind, resource string) {
+		resources[kind] = append(resources[kind], resource)
+	}
+
+	if _, err := clientset.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{}); err == nil {
+		add("Deployment", name)
+	} else if !k8serrors.IsNotFound(err) {
+		return nil, err
+	}
+
+	deployments, err := clientset.AppsV1().Deployments(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	owners := map[string]bool{name: true}
+	for _, item := range deployments.Items {
+		if ownedBy(item.OwnerReferences, "Deployment", map[string]bool{name: true}) {
+			add("Deployment", item.Name)
+			owners[item.Name] = true
+		}
+	}
+
+	replicaSets, err := clientset.AppsV1().ReplicaSets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	replicaSetNames := map[string]bool{}
+	for _, item := range replicaSets.Items {
+		if ownedBy(item.OwnerReferences, "Deployment", owners) {
+			add("ReplicaSet", item.Name)
+			replicaSetNames[item.Name] = true
+		}
+	}
+
+	pods, err := clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range pods.Items {
+		if ownedBy(item.OwnerReferences, "ReplicaSet", replicaSetNames) {
+			add("Pod", item.Name)
+		}
+	}
+
+	services, err := clientset.CoreV1().Services(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range services.Items {
+		if item.Name == name || ownedBy(item.OwnerReferences, "Deployment", owners) {
+			add("Service", item.Name)
+		}
+	}
+
+	labelled := metav1.ListOptions{LabelSelector: OwnerLabel + "=" + name}
+
+	// the policy and autoscaling APIs may not be served by every cluster
+	if pdbs, err := clientset.PolicyV1().PodDisruptionBudgets(namespace).List(ctx, labelled); err == nil {
+		for _, item := range pdbs.Items {
+			add("PodDisruptionBudget", item.Name)
+		}
+	} else if !k8serrors.IsNotFound(err) {
+		return nil, err
+	}
+
+	if hpas, err := clientset.AutoscalingV1().HorizontalPodAutoscalers(namespace).List(ctx, labelled); err == nil {
+		for _, item := range hpas.Items {
+			add("HorizontalPodAutoscaler", item.Name)
+		}
+	} else if !k8serrors.IsNotFound(err) {
+		return nil, err
+	}
+
+	policies, err := clientset.NetworkingV1().NetworkPolicies(namespace).List(ctx, labelled)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range policies.Items {
+		add("NetworkPolicy", item.Name)
+	}
+
+	configMaps, err := clientset.CoreV1().ConfigMaps(namespace).List(ctx, labelled)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range configMaps.Items {
+		add("ConfigMap", item.Name)
+	}
+
+	secrets, err := clientset.CoreV1().Secrets(namespace).List(ctx, labelled)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range secrets.Items {
+		add("Secret", item.Name)
+	}
+
+	for _, names := range resources {
+		sort.Strings(names)
+	}
+
+	return resources, nil
+}
+
+func ownedBy(refs []metav1.OwnerReference, kind string, names map[string]bool) bool {
+	for _, ref := range refs {
+		if ref.Kind == kind && names[ref.Name] {
+			return true
+		}
+	}
+	return false
+}
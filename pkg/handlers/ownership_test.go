@@ -0,0 +1,91 @@
+// Copyright 2020 OpenFaaS Author(s)
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func ownedMeta(name, kind, owner string) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:            name,
+		Namespace:       "openfaas-fn",
+		OwnerReferences: []metav1.OwnerReference{{Kind: kind, Name: owner}},
+	}
+}
+
+func Test_MakeOwnershipHandler(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "nodeinfo", Namespace: "openfaas-fn"}},
+		&appsv1.Deployment{ObjectMeta: ownedMeta("nodeinfo-beta", "Deployment", "nodeinfo")},
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "env", Namespace: "openfaas-fn"}},
+		&appsv1.ReplicaSet{ObjectMeta: ownedMeta("nodeinfo-abc", "Deployment", "nodeinfo")},
+		&appsv1.ReplicaSet{ObjectMeta: ownedMeta("nodeinfo-beta-def", "Deployment", "nodeinfo-beta")},
+		&appsv1.ReplicaSet{ObjectMeta: ownedMeta("env-abc", "Deployment", "env")},
+		&corev1.Pod{ObjectMeta: ownedMeta("nodeinfo-abc-1", "ReplicaSet", "nodeinfo-abc")},
+		&corev1.Pod{ObjectMeta: ownedMeta("env-abc-1", "ReplicaSet", "env-abc")},
+		&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "nodeinfo", Namespace: "openfaas-fn"}},
+		&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "env", Namespace: "openfaas-fn"}},
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+			Name:      "nodeinfo-config",
+			Namespace: "openfaas-fn",
+			Labels:    map[string]string{OwnerLabel: "nodeinfo"},
+		}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+			Name:      "env-secret",
+			Namespace: "openfaas-fn",
+			Labels:    map[string]string{OwnerLabel: "env"},
+		}},
+	)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/system/function/{name}/ownership", MakeOwnershipHandler("openfaas-fn", client))
+
+	req := httptest.NewRequest(http.MethodGet, "/system/function/nodeinfo/ownership", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("want status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+
+	got := map[string][]string{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	want := map[string][]string{
+		"Deployment": {"nodeinfo", "nodeinfo-beta"},
+		"ReplicaSet": {"nodeinfo-abc", "nodeinfo-beta-def"},
+		"Pod":        {"nodeinfo-abc-1"},
+		"Service":    {"nodeinfo"},
+		"ConfigMap":  {"nodeinfo-config"},
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
+
+func Test_MakeOwnershipHandler_KubeSystem(t *testing.T) {
+	router := mux.NewRouter()
+	router.HandleFunc("/system/function/{name}/ownership", MakeOwnershipHandler("openfaas-fn", fake.NewSimpleClientset()))
+
+	req := httptest.NewRequest(http.MethodGet, "/system/function/nodeinfo/ownership?namespace=kube-system", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("want status %d, got %d", http.StatusUnauthorized, rr.Code)
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright 2020 OpenFaaS Author(s)
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/openfaas/faas-netes/pkg/k8s"
+	"github.com/openfaas/faas-provider/types"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// MakePauseHandler scales a function to zero and marks it as paused, the proxy then answers
+// invocations with a 503 and scale requests are refused until the function is resumed. The
+// replica count is kept in an annotation on the Deployment for MakeResumeHandler.
+func MakePauseHandler(defaultNamespace string, clientset kubernetes.Interface, history *k8s.ScaleHistory) http.HandlerFunc {
+	return makePauseHandler(defaultNamespace, clientset, history, k8s.Pause)
+}
+
+// MakeResumeHandler removes the pause from a function and restores its previous replica count
+func MakeResumeHandler(defaultNamespace string, clientset kubernetes.Interface, history *k8s.ScaleHistory) http.HandlerFunc {
+	return makePauseHandler(defaultNamespace, clientset, history, k8s.Resume)
+}
+
+// makePauseHandler applies change to the Deployment of the function, a 409 is returned without
+// updating it when change reports that the function is already in the requested state
+func makePauseHandler(defaultNamespace string, clientset kubernetes.Interface, history *k8s.ScaleHistory, change func(*appsv1.Deployment) bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Body != nil {
+			defer r.Body.Close()
+		}
+
+		functionName := mux.Vars(r)["name"]
+
+		lookupNamespace := defaultNamespace
+		if namespace := r.URL.Query().Get("namespace"); len(namespace) > 0 {
+			lookupNamespace = namespace
+		}
+
+		if lookupNamespace == "kube-system" {
+			http.Error(w, "unable to list within the kube-system namespace", http.StatusUnauthorized)
+			return
+		}
+
+		deployment, err := clientset.AppsV1().Deployments(lookupNamespace).Get(r.Context(), functionName, metav1.GetOptions{})
+		if err != nil {
+			status, reason := ProcessErrorReasons(err)
+			log.Printf("Function pause lookup error reason: %s, %v\n", reason, err)
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		oldReplicas := int32(0)
+		if deployment.Spec.Replicas != nil {
+			oldReplicas = *deployment.Spec.Replicas
+		}
+
+		if !change(deployment) {
+			state := "running"
+			if k8s.IsPaused(deployment.Annotations) {
+				state = "paused"
+			}
+			http.Error(w, fmt.Sprintf("function %s.%s is already %s", functionName, lookupNamespace, state), http.StatusConflict)
+			return
+		}
+
+		if _, err := clientset.AppsV1().Deployments(lookupNamespace).Update(r.Context(), deployment, metav1.UpdateOptions{}); err != nil {
+			status, reason := ProcessErrorReasons(err)
+			log.Printf("Function pause update error reason: %s, %v\n", reason, err)
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		if replicas := *deployment.Spec.Replicas; replicas != oldReplicas {
+			history.Record(functionName, lookupNamespace, k8s.ScaleEvent{
+				From:      oldReplicas,
+				To:        replicas,
+				Timestamp: time.Now(),
+				Source:    r.UserAgent(),
+			})
+		}
+
+		log.Printf("Function %s.%s paused: %v, replicas: %d\n", functionName, lookupNamespace,
+			k8s.IsPaused(deployment.Annotations), *deployment.Spec.Replicas)
+
+		replicas := uint64(0)
+		if deployment.Spec.Replicas != nil {
+			replicas = uint64(*deployment.Spec.Replicas)
+		}
+
+		out, _ := json.Marshal(types.ScaleServiceRequest{
+			ServiceName: functionName,
+			Replicas:    replicas,
+		})
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(out)
+	}
+}
@@ -0,0 +1,133 @@
+// Copyright 2020 OpenFaaS Author(s)
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/openfaas/faas-netes/pkg/k8s"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_MakePauseHandler_PausesAndResumes(t *testing.T) {
+	replicas := int32(2)
+	clientset := fake.NewSimpleClientset(&appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "nodeinfo", Namespace: "openfaas-fn"},
+		Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+	})
+	history := k8s.NewScaleHistory(k8s.DefaultScaleHistorySize)
+
+	serve := func(handler http.HandlerFunc, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req = mux.SetURLVars(req, map[string]string{"name": "nodeinfo"})
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := serve(MakePauseHandler("openfaas-fn", clientset, history), "/system/function/nodeinfo/pause", "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("want status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+
+	deployment := getTestDeployment(t, clientset)
+	if !k8s.IsPaused(deployment.Annotations) || *deployment.Spec.Replicas != 0 {
+		t.Fatalf("want a paused deployment with 0 replicas, got: %v, %d", deployment.Annotations, *deployment.Spec.Replicas)
+	}
+
+	rr = serve(MakeReplicaUpdater("openfaas-fn", clientset, 0, history), "/system/scale-function/nodeinfo", `{"serviceName":"nodeinfo","replicas":1}`)
+	if rr.Code != http.StatusConflict {
+		t.Errorf("want scaling a paused function to return %d, got %d", http.StatusConflict, rr.Code)
+	}
+
+	rr = serve(MakeResumeHandler("openfaas-fn", clientset, history), "/system/function/nodeinfo/resume", "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("want status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+
+	deployment = getTestDeployment(t, clientset)
+	if k8s.IsPaused(deployment.Annotations) || *deployment.Spec.Replicas != 2 {
+		t.Errorf("want a running deployment with 2 replicas, got: %v, %d", deployment.Annotations, *deployment.Spec.Replicas)
+	}
+
+	if events := history.Events("nodeinfo", "openfaas-fn"); len(events) != 2 {
+		t.Errorf("want 2 scale events, got: %d", len(events))
+	}
+}
+
+func Test_MakePauseHandler_Conflict(t *testing.T) {
+	replicas := int32(3)
+	clientset := fake.NewSimpleClientset(&appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "nodeinfo", Namespace: "openfaas-fn"},
+		Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+	})
+	history := k8s.NewScaleHistory(k8s.DefaultScaleHistorySize)
+
+	pause := MakePauseHandler("openfaas-fn", clientset, history)
+	resume := MakeResumeHandler("openfaas-fn", clientset, history)
+
+	steps := []struct {
+		name       string
+		handler    http.HandlerFunc
+		wantStatus int
+	}{
+		{name: "resume a running function", handler: resume, wantStatus: http.StatusConflict},
+		{name: "pause", handler: pause, wantStatus: http.StatusOK},
+		{name: "pause a paused function", handler: pause, wantStatus: http.StatusConflict},
+		{name: "resume", handler: resume, wantStatus: http.StatusOK},
+		{name: "resume again", handler: resume, wantStatus: http.StatusConflict},
+	}
+
+	for _, step := range steps {
+		req := httptest.NewRequest(http.MethodPost, "/system/function/nodeinfo/pause", nil)
+		req = mux.SetURLVars(req, map[string]string{"name": "nodeinfo"})
+
+		rr := httptest.NewRecorder()
+		step.handler.ServeHTTP(rr, req)
+
+		if rr.Code != step.wantStatus {
+			t.Fatalf("%s: want status %d, got %d: %s", step.name, step.wantStatus, rr.Code, rr.Body.String())
+		}
+	}
+
+	deployment := getTestDeployment(t, clientset)
+	if k8s.IsPaused(deployment.Annotations) || *deployment.Spec.Replicas != 3 {
+		t.Errorf("want a running deployment with 3 replicas, got: %v, %d", deployment.Annotations, *deployment.Spec.Replicas)
+	}
+
+	if events := history.Events("nodeinfo", "openfaas-fn"); len(events) != 2 {
+		t.Errorf("want 2 scale events, got: %d", len(events))
+	}
+}
+
+func Test_MakePauseHandler_MissingFunction(t *testing.T) {
+	handler := MakePauseHandler("openfaas-fn", fake.NewSimpleClientset(), nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/system/function/missing/pause", nil)
+	req = mux.SetURLVars(req, map[string]string{"name": "missing"})
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("want status %d, got %d", http.StatusNotFound, rr.Code)
+	}
+}
+
+func getTestDeployment(t *testing.T, clientset kubernetes.Interface) *appsv1.Deployment {
+	deployment, err := clientset.AppsV1().Deployments("openfaas-fn").Get(context.TODO(), "nodeinfo", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	return deployment
+}
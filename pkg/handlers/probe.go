@@ -0,0 +1,138 @@
+// Copyright 2020 OpenFaaS Author(s)
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/openfaas/faas-provider/proxy"
+)
+
+// defaultProbePath is the health endpoint of the of-watchdog, which is also used by the
+// HTTP readiness probe
+const defaultProbePath = "/_/health"
+
+// FunctionProbeResult is the outcome of a synthetic request to a function
+type FunctionProbeResult struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Path      string `json:"path"`
+
+	// Status is the HTTP status returned by the function, 0 when no response was received
+	Status int `json:"status"`
+
+	// Healthy is true when the function answered with a 2xx status
+	Healthy bool `json:"healthy"`
+
+	// LatencyMs is the time taken for the function to respond in milliseconds
+	LatencyMs int64 `json:"latencyMs"`
+
+	Error string `json:"error,omitempty"`
+}
+
+// MakeFunctionProbeHandler issues a synthetic GET to one of the endpoints of a function and
+// reports the status and latency, so that a deployment can be verified before it receives
+// traffic. The `path` query parameter overrides the default of /_/health. A 503 is returned
+// along with the result when the function could not be reached or was not healthy.
+func MakeFunctionProbeHandler(defaultNamespace string, resolver proxy.BaseURLResolver, timeout time.Duration) http.HandlerFunc {
+	client := &http.Client{
+		Timeout: timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Body != nil {
+			defer r.Body.Close()
+		}
+
+		q := r.URL.Query()
+		functionName := mux.Vars(r)["name"]
+
+		lookupNamespace := defaultNamespace
+		if namespace := q.Get("namespace"); len(namespace) > 0 {
+			lookupNamespace = namespace
+		}
+
+		if lookupNamespace == "kube-system" {
+			http.Error(w, "unable to list within the kube-system namespace", http.StatusUnauthorized)
+			return
+		}
+
+		path := q.Get("path")
+		if len(path) == 0 {
+			path = defaultProbePath
+		}
+		if !strings.HasPrefix(path, "/") {
+			path = "/" + path
+		}
+
+		result := probeFunction(r.Context(), client, resolver, functionName, lookupNamespace, path)
+		if len(result.Error) > 0 {
+			log.Printf("Function probe %s.%s error: %s\n", functionName, lookupNamespace, result.Error)
+		}
+
+		out, err := json.Marshal(result)
+		if err != nil {
+			log.Printf("Function probe json marshal error: %v\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		status := http.StatusOK
+		if !result.Healthy {
+			status = http.StatusServiceUnavailable
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write(out)
+	}
+}
+
+// probeFunction resolves an endpoint of the function and times a GET to the given path
+func probeFunction(ctx context.Context, client *http.Client, resolver proxy.BaseURLResolver, name, namespace, path string) FunctionProbeResult {
+	result := FunctionProbeResult{
+		Name:      name,
+		Namespace: namespace,
+		Path:      path,
+	}
+
+	functionAddr, err := resolver.Resolve(name + "." + namespace)
+	if err != nil {
+		result.Error = "no endpoints available: " + err.Error()
+		return result
+	}
+
+	functionAddr.Path = path
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, functionAddr.String(), nil)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	req.Header.Set("User-Agent", "faas-netes-probe")
+
+	start := time.Now()
+	res, err := client.Do(req)
+	result.LatencyMs = time.Since(start).Milliseconds()
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	defer res.Body.Close()
+	io.Copy(ioutil.Discard, res.Body)
+
+	result.Status = res.StatusCode
+	result.Healthy = res.StatusCode >= 200 && res.StatusCode < 300
+	return result
+}
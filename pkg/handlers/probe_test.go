@@ -0,0 +1,131 @@
+// Copyright 2020 OpenFaaS Author(s)
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+type testProbeResolver struct {
+	url  url.URL
+	err  error
+	name string
+}
+
+func (r *testProbeResolver) Resolve(name string) (url.URL, error) {
+	r.name = name
+	return r.url, r.err
+}
+
+func Test_MakeFunctionProbeHandler(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/_/health":
+			w.WriteHeader(http.StatusOK)
+		case "/ready":
+			w.WriteHeader(http.StatusAccepted)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer upstream.Close()
+
+	upstreamURL, _ := url.Parse(upstream.URL)
+
+	cases := []struct {
+		name            string
+		query           string
+		resolveErr      error
+		expectedStatus  int
+		expectedHealthy bool
+		expectedProbe   int
+		expectedResolve string
+	}{
+		{
+			name:            "default health path",
+			query:           "",
+			expectedStatus:  http.StatusOK,
+			expectedHealthy: true,
+			expectedProbe:   http.StatusOK,
+			expectedResolve: "nodeinfo.openfaas-fn",
+		},
+		{
+			name:            "custom path in another namespace",
+			query:           "?path=ready&namespace=dev",
+			expectedStatus:  http.StatusOK,
+			expectedHealthy: true,
+			expectedProbe:   http.StatusAccepted,
+			expectedResolve: "nodeinfo.dev",
+		},
+		{
+			name:            "unhealthy function",
+			query:           "?path=/broken",
+			expectedStatus:  http.StatusServiceUnavailable,
+			expectedHealthy: false,
+			expectedProbe:   http.StatusInternalServerError,
+			expectedResolve: "nodeinfo.openfaas-fn",
+		},
+		{
+			name:            "no endpoints",
+			query:           "",
+			resolveErr:      fmt.Errorf("no addresses"),
+			expectedStatus:  http.StatusServiceUnavailable,
+			expectedHealthy: false,
+			expectedProbe:   0,
+			expectedResolve: "nodeinfo.openfaas-fn",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			resolver := &testProbeResolver{url: *upstreamURL, err: tc.resolveErr}
+			handler := MakeFunctionProbeHandler("openfaas-fn", resolver, time.Second)
+
+			req := httptest.NewRequest(http.MethodGet, "/system/function/nodeinfo/probe"+tc.query, nil)
+			req = mux.SetURLVars(req, map[string]string{"name": "nodeinfo"})
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tc.expectedStatus {
+				t.Fatalf("want status %d, got %d: %s", tc.expectedStatus, rr.Code, rr.Body.String())
+			}
+
+			result := FunctionProbeResult{}
+			if err := json.Unmarshal(rr.Body.Bytes(), &result); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if result.Healthy != tc.expectedHealthy {
+				t.Errorf("want healthy %v, got %v", tc.expectedHealthy, result.Healthy)
+			}
+			if result.Status != tc.expectedProbe {
+				t.Errorf("want probe status %d, got %d", tc.expectedProbe, result.Status)
+			}
+			if resolver.name != tc.expectedResolve {
+				t.Errorf("want resolved name %q, got %q", tc.expectedResolve, resolver.name)
+			}
+		})
+	}
+}
+
+func Test_MakeFunctionProbeHandler_KubeSystem(t *testing.T) {
+	handler := MakeFunctionProbeHandler("openfaas-fn", &testProbeResolver{}, time.Second)
+
+	req := httptest.NewRequest(http.MethodGet, "/system/function/nodeinfo/probe?namespace=kube-system", nil)
+	req = mux.SetURLVars(req, map[string]string{"name": "nodeinfo"})
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("want status %d, got %d", http.StatusUnauthorized, rr.Code)
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright 2020 OpenFaaS Author(s)
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"sort"
+
+	v1 "github.com/openfaas/faas-netes/pkg/apis/openfaas/v1"
+	listers "github.com/openfaas/faas-netes/pkg/client/listers/openfaas/v1"
+	"github.com/openfaas/faas-netes/pkg/config"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// ProfileSummary describes a Profile that functions can use via the com.openfaas.profile annotation
+type ProfileSummary struct {
+	Name string `json:"name"`
+
+	// Sets lists the parts of the Pod spec that the Profile sets, omitted for the names detail level
+	Sets []string `json:"sets,omitempty"`
+
+	// Spec is the full Profile, only included for the full detail level
+	Spec *v1.ProfileSpec `json:"spec,omitempty"`
+}
+
+// MakeProfileListHandler lists the Profiles in the profiles namespace. The detail level
+// controls how much of each Profile is revealed, see config.ProfilesDetailLevel.
+func MakeProfileListHandler(profilesNamespace string, lister listers.ProfileLister, detailLevel string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Body != nil {
+			defer r.Body.Close()
+		}
+
+		profiles, err := lister.Profiles(profilesNamespace).List(labels.Everything())
+		if err != nil {
+			log.Printf("Profiles list error: %v\n", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		res := make([]ProfileSummary, 0, len(profiles))
+		for _, profile := range profiles {
+			res = append(res, summarizeProfile(profile, detailLevel))
+		}
+
+		sort.Slice(res, func(i, j int) bool {
+			return res[i].Name < res[j].Name
+		})
+
+		out, err := json.Marshal(res)
+		if err != nil {
+			log.Printf("Profiles json marshal error: %v\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(out)
+	}
+}
+
+func summarizeProfile(profile *v1.Profile, detailLevel string) ProfileSummary {
+	summary := ProfileSummary{Name: profile.Name}
+
+	if detailLevel == config.ProfilesDetailNames {
+		return summary
+	}
+
+	spec := profile.Spec
+	if len(spec.Tolerations) > 0 {
+		summary.Sets = append(summary.Sets, "tolerations")
+	}
+	if spec.RuntimeClassName != nil {
+		summary.Sets = append(summary.Sets, "runtimeClassName")
+	}
+	if spec.Affinity != nil {
+		summary.Sets = append(summary.Sets, "affinity")
+	}
+	if spec.PodSecurityContext != nil {
+		summary.Sets = append(summary.Sets, "podSecurityContext")
+	}
+	if spec.IdleTimeout != nil {
+		summary.Sets = append(summary.Sets, "idleTimeout")
+	}
+
+	if detailLevel == config.ProfilesDetailFull {
+		summary.Spec = spec.DeepCopy()
+	}
+
+	return summary
+}
@@ -0,0 +1,83 @@
+// Copyright 2020 OpenFaaS Author(s)
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	v1 "github.com/openfaas/faas-netes/pkg/apis/openfaas/v1"
+	listers "github.com/openfaas/faas-netes/pkg/client/listers/openfaas/v1"
+	"github.com/openfaas/faas-netes/pkg/config"
+)
+
+func Test_MakeProfileListHandler(t *testing.T) {
+	runtimeClass := "gvisor"
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	indexer.Add(&v1.Profile{
+		ObjectMeta: metav1.ObjectMeta{Name: "sandboxed", Namespace: "openfaas"},
+		Spec:       v1.ProfileSpec{RuntimeClassName: &runtimeClass},
+	})
+	indexer.Add(&v1.Profile{
+		ObjectMeta: metav1.ObjectMeta{Name: "gpu", Namespace: "openfaas"},
+		Spec:       v1.ProfileSpec{Tolerations: []corev1.Toleration{{Key: "gpu"}}},
+	})
+	indexer.Add(&v1.Profile{
+		ObjectMeta: metav1.ObjectMeta{Name: "elsewhere", Namespace: "other"},
+	})
+	lister := listers.NewProfileLister(indexer)
+
+	cases := []struct {
+		detailLevel string
+		want        []ProfileSummary
+	}{
+		{
+			detailLevel: config.ProfilesDetailNames,
+			want:        []ProfileSummary{{Name: "gpu"}, {Name: "sandboxed"}},
+		},
+		{
+			detailLevel: config.ProfilesDetailSummary,
+			want: []ProfileSummary{
+				{Name: "gpu", Sets: []string{"tolerations"}},
+				{Name: "sandboxed", Sets: []string{"runtimeClassName"}},
+			},
+		},
+		{
+			detailLevel: config.ProfilesDetailFull,
+			want: []ProfileSummary{
+				{Name: "gpu", Sets: []string{"tolerations"}, Spec: &v1.ProfileSpec{Tolerations: []corev1.Toleration{{Key: "gpu"}}}},
+				{Name: "sandboxed", Sets: []string{"runtimeClassName"}, Spec: &v1.ProfileSpec{RuntimeClassName: &runtimeClass}},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.detailLevel, func(t *testing.T) {
+			handler := MakeProfileListHandler("openfaas", lister, tc.detailLevel)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/system/profiles", nil))
+
+			if rr.Code != http.StatusOK {
+				t.Fatalf("want status: %d, got: %d", http.StatusOK, rr.Code)
+			}
+
+			got := []ProfileSummary{}
+			if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("want: %+v, got: %+v", tc.want, got)
+			}
+		})
+	}
+}
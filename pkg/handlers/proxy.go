@@ -0,0 +1,388 @@
+// Copyright 2020 OpenFaaS Author(s)
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/openfaas/faas-provider/httputil"
+	"github.com/openfaas/faas-provider/proxy"
+	"github.com/openfaas/faas-provider/types"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/client-go/listers/apps/v1"
+
+	"github.com/openfaas/faas-netes/pkg/k8s"
+)
+
+const (
+	watchdogPort       = "8080"
+	defaultContentType = "text/plain"
+)
+
+// BalancedResolver is implemented by resolvers that can pick an endpoint with a load balancer
+// per function, such as k8s.FunctionLookup
+type BalancedResolver interface {
+	ResolveBalanced(name, loadBalancer string) (url.URL, func(), error)
+}
+
+// MakeProxyHandler creates the function invocation proxy. It behaves like proxy.NewHandlerFunc
+// from faas-provider, except that functions annotated with `com.openfaas.http.streaming=true`
+// have their responses flushed to the caller as they arrive, without a response timeout,
+// headers from `com.openfaas.response.headers` are added to each response, paused
+// functions are answered with a 503 without being resolved, and when the resolver is a
+// BalancedResolver endpoints are picked with the function's `com.openfaas.load-balancer`.
+// Functions annotated with `com.openfaas.access.log=true` have each request logged, and
+// requests whose variant header names one of the function's `com.openfaas.variants` are
+// sent to that variant instead. The function's `com.openfaas.path.prefix` is added in front
+// of the forwarded path. Functions annotated with `com.openfaas.transport=grpc` are invoked
+// with unary gRPC calls instead of HTTP.
+func MakeProxyHandler(config types.FaaSConfig, resolver proxy.BaseURLResolver, defaultNamespace string, deploymentLister v1.DeploymentLister) http.HandlerFunc {
+	if resolver == nil {
+		panic("MakeProxyHandler: empty proxy handler resolver, cannot be nil")
+	}
+
+	proxyClient := proxy.NewProxyClientFromConfig(config)
+
+	// the streaming client relies on the request context to end the call, since the
+	// client timeout would otherwise cut the stream off part way through, the server's
+	// write deadline is cleared for the same reason in proxyRequest
+	streamingClient := proxy.NewProxyClientFromConfig(config)
+	streamingClient.Timeout = 0
+
+	grpcPool := newGRPCConnPool(config.GetReadTimeout())
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Body != nil {
+			defer r.Body.Close()
+		}
+
+		switch r.Method {
+		case http.MethodPost,
+			http.MethodPut,
+			http.MethodPatch,
+			http.MethodDelete,
+			http.MethodGet,
+			http.MethodOptions,
+			http.MethodHead:
+
+			name := mux.Vars(r)["name"]
+			deployment := lookupFunctionDeployment(name, defaultNamespace, deploymentLister)
+			if deployment != nil && k8s.IsPaused(deployment.Annotations) {
+				httputil.Errorf(w, http.StatusServiceUnavailable, "Function %s is paused.", name)
+				return
+			}
+			if deployment != nil && k8s.IsCordoned(deployment.Annotations) {
+				httputil.Errorf(w, http.StatusServiceUnavailable, "Function %s is cordoned.", name)
+				return
+			}
+
+			client := proxyClient
+			streaming := deployment != nil && k8s.IsStreaming(deployment.Spec.Template.Annotations)
+			if streaming {
+				client = streamingClient
+			}
+
+			var headers map[string]string
+			override := false
+			loadBalancer := ""
+			target := name
+			if deployment != nil {
+				target = variantTarget(name, deployment, r.Header)
+
+				var err error
+				if headers, err = k8s.ResponseHeaders(deployment.Annotations); err != nil {
+					log.Printf("Function %s response headers ignored: %s\n", name, err)
+				}
+				override = k8s.ResponseHeadersOverride(deployment.Annotations)
+
+				if loadBalancer, err = k8s.LoadBalancer(deployment.Annotations); err != nil {
+					log.Printf("Function %s load balancer ignored: %s\n", name, err)
+				}
+
+				r = withPathPrefix(name, deployment, r)
+			}
+
+			forward := func(w http.ResponseWriter) {
+				proxyRequest(w, r, client, resolver, target, loadBalancer, streaming, headers, override)
+			}
+			if deployment != nil && isGRPCTransport(name, deployment) {
+				forward = func(w http.ResponseWriter) {
+					proxyGRPCRequest(w, r, grpcPool, resolver, target, loadBalancer)
+				}
+			}
+
+			if deployment != nil && k8s.IsAccessLogEnabled(deployment.Annotations) {
+				withAccessLog(name, w, r, forward)
+				return
+			}
+
+			forward(w)
+
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// lookupFunctionDeployment finds the Deployment of the function in the cache, so that the
+// proxy can honour its annotations. Nil is returned for any lookup error, which results in
+// the default behaviour.
+func lookupFunctionDeployment(name, defaultNamespace string, deploymentLister v1.DeploymentLister) *appsv1.Deployment {
+	if deploymentLister == nil || len(name) == 0 {
+		return nil
+	}
+
+	functionName := name
+	namespace := defaultNamespace
+	if index := strings.LastIndex(name, "."); index > -1 {
+		functionName = name[:index]
+		namespace = name[index+1:]
+	}
+
+	deployment, err := deploymentLister.Deployments(namespace).Get(functionName)
+	if err != nil {
+		return nil
+	}
+
+	return deployment
+}
+
+// isGRPCTransport returns true when the function is invoked over gRPC, an invalid transport is
+// logged and the function is invoked over HTTP
+func isGRPCTransport(name string, deployment *appsv1.Deployment) bool {
+	transport, err := k8s.Transport(deployment.Annotations)
+	if err != nil {
+		log.Printf("Function %s transport ignored: %s\n", name, err)
+		return false
+	}
+	return transport == k8s.TransportGRPC
+}
+
+// withPathPrefix returns the request with the function's `com.openfaas.path.prefix` added in
+// front of the path that is forwarded to it, the query string is forwarded separately so is
+// left as it is
+func withPathPrefix(name string, deployment *appsv1.Deployment, r *http.Request) *http.Request {
+	prefix, err := k8s.PathPrefix(deployment.Annotations)
+	if err != nil {
+		log.Printf("Function %s path prefix ignored: %s\n", name, err)
+		return r
+	}
+	if len(prefix) == 0 {
+		return r
+	}
+
+	vars := map[string]string{}
+	for k, v := range mux.Vars(r) {
+		vars[k] = v
+	}
+	vars["params"] = k8s.PrefixPath(prefix, vars["params"])
+
+	return mux.SetURLVars(r, vars)
+}
+
+// variantTarget returns the name to resolve for the request, which is the variant's when the
+// function's variant header names one of its variants, otherwise the function's own name
+func variantTarget(name string, deployment *appsv1.Deployment, header http.Header) string {
+	variant := header.Get(k8s.VariantHeader(deployment.Annotations))
+	if len(variant) == 0 {
+		return name
+	}
+
+	variants, err := k8s.Variants(deployment.Annotations)
+	if err != nil {
+		log.Printf("Function %s variants ignored: %s\n", name, err)
+		return name
+	}
+	if _, ok := variants[variant]; !ok {
+		return name
+	}
+
+	target := k8s.VariantName(deployment.Name, variant)
+	if index := strings.LastIndex(name, "."); index > -1 {
+		target += name[index:]
+	}
+	return target
+}
+
+// proxyRequest resolves the target, which is the function or one of its variants, and copies
+// the response back to the caller. The responseHeaders are added to the response, replacing
+// those set by the function only when override is true.
+func proxyRequest(w http.ResponseWriter, originalReq *http.Request, proxyClient *http.Client, resolver proxy.BaseURLResolver, target, loadBalancer string, streaming bool, responseHeaders map[string]string, override bool) {
+	ctx := originalReq.Context()
+
+	pathVars := mux.Vars(originalReq)
+	functionName := target
+	if functionName == "" {
+		httputil.Errorf(w, http.StatusBadRequest, "Provide function name in the request path")
+		return
+	}
+
+	functionAddr, release, resolveErr := resolveFunction(resolver, functionName, loadBalancer)
+	if resolveErr != nil {
+		log.Printf("resolver error: no endpoints for %s: %s\n", functionName, resolveErr.Error())
+		httputil.Errorf(w, http.StatusServiceUnavailable, "No endpoints available for: %s.", functionName)
+		return
+	}
+	// the endpoint is counted as busy until the response has been copied to the caller
+	defer release()
+
+	proxyReq, err := buildProxyRequest(originalReq, functionAddr, pathVars["params"])
+	if err != nil {
+		httputil.Errorf(w, http.StatusInternalServerError, "Failed to resolve service: %s.", functionName)
+		return
+	}
+
+	if proxyReq.Body != nil {
+		defer proxyReq.Body.Close()
+	}
+
+	start := time.Now()
+	response, err := proxyClient.Do(proxyReq.WithContext(ctx))
+	seconds := time.Since(start)
+
+	if err != nil {
+		log.Printf("error with proxy request to: %s, %s\n", proxyReq.URL.String(), err.Error())
+
+		httputil.Errorf(w, http.StatusInternalServerError, "Can't reach service for: %s.", functionName)
+		return
+	}
+
+	if response.Body != nil {
+		defer response.Body.Close()
+	}
+
+	log.Printf("%s took %f seconds\n", functionName, seconds.Seconds())
+
+	copyHeaders(w.Header(), &response.Header)
+	w.Header().Set("Content-Type", getContentType(originalReq.Header, response.Header))
+
+	for k, v := range responseHeaders {
+		if override || len(response.Header.Get(k)) == 0 {
+			w.Header().Set(k, v)
+		}
+	}
+
+	w.WriteHeader(response.StatusCode)
+	if response.Body == nil {
+		return
+	}
+
+	if streaming {
+		// the server's WriteTimeout would otherwise cut the stream off part way through
+		if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+			log.Printf("unable to clear the write deadline for: %s, %s\n", functionName, err.Error())
+		}
+
+		if err := copyStream(w, response.Body); err != nil {
+			log.Printf("error streaming response for: %s, %s\n", functionName, err.Error())
+		}
+		return
+	}
+
+	io.Copy(w, response.Body)
+}
+
+// resolveFunction uses the BalancedResolver when the resolver implements it, the release func
+// is never nil
+func resolveFunction(resolver proxy.BaseURLResolver, functionName, loadBalancer string) (url.URL, func(), error) {
+	if balanced, ok := resolver.(BalancedResolver); ok {
+		address, release, err := balanced.ResolveBalanced(functionName, loadBalancer)
+		if err != nil || release == nil {
+			release = func() {}
+		}
+		return address, release, err
+	}
+
+	address, err := resolver.Resolve(functionName)
+	return address, func() {}, err
+}
+
+// copyStream writes each chunk of the response to the caller as soon as it is read
+func copyStream(w http.ResponseWriter, body io.Reader) error {
+	flusher, _ := w.(http.Flusher)
+	if flusher != nil {
+		flusher.Flush()
+	}
+
+	buf := make([]byte, 32*1024)
+	for {
+		n, readErr := body.Read(buf)
+		if n > 0 {
+			if _, err := w.Write(buf[:n]); err != nil {
+				return err
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+
+		if readErr == io.EOF {
+			return nil
+		}
+		if readErr != nil {
+			return readErr
+		}
+	}
+}
+
+// buildProxyRequest creates the upstream request, preserving the original headers
+// and setting the X-Forwarded-Host and X-Forwarded-For headers
+func buildProxyRequest(originalReq *http.Request, baseURL url.URL, extraPath string) (*http.Request, error) {
+	host := baseURL.Host
+	if baseURL.Port() == "" {
+		host = baseURL.Host + ":" + watchdogPort
+	}
+
+	upstreamURL := url.URL{
+		Scheme:   baseURL.Scheme,
+		Host:     host,
+		Path:     extraPath,
+		RawQuery: originalReq.URL.RawQuery,
+	}
+
+	upstreamReq, err := http.NewRequest(originalReq.Method, upstreamURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	copyHeaders(upstreamReq.Header, &originalReq.Header)
+
+	if len(originalReq.Host) > 0 && upstreamReq.Header.Get("X-Forwarded-Host") == "" {
+		upstreamReq.Header["X-Forwarded-Host"] = []string{originalReq.Host}
+	}
+	if upstreamReq.Header.Get("X-Forwarded-For") == "" {
+		upstreamReq.Header["X-Forwarded-For"] = []string{originalReq.RemoteAddr}
+	}
+
+	if originalReq.Body != nil {
+		upstreamReq.Body = originalReq.Body
+	}
+
+	return upstreamReq, nil
+}
+
+// copyHeaders clones the header values from the source into the destination
+func copyHeaders(destination http.Header, source *http.Header) {
+	for k, v := range *source {
+		vClone := make([]string, len(v))
+		copy(vClone, v)
+		destination[k] = vClone
+	}
+}
+
+// getContentType prefers the Content-Type of the function's response, then the request's
+func getContentType(request http.Header, proxyResponse http.Header) string {
+	if v := proxyResponse.Get("Content-Type"); len(v) > 0 {
+		return v
+	}
+	if v := request.Get("Content-Type"); len(v) > 0 {
+		return v
+	}
+	return defaultContentType
+}
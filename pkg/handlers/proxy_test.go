@@ -0,0 +1,503 @@
+// Copyright 2020 OpenFaaS Author(s)
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/openfaas/faas-provider/types"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	v1 "k8s.io/client-go/listers/apps/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/openfaas/faas-netes/pkg/k8s"
+	"github.com/openfaas/faas-netes/pkg/metrics"
+)
+
+type testResolver struct {
+	url url.URL
+}
+
+func (r testResolver) Resolve(name string) (url.URL, error) {
+	return r.url, nil
+}
+
+type testBalancedResolver struct {
+	url           url.URL
+	loadBalancers []string
+	released      int
+}
+
+func (r *testBalancedResolver) Resolve(name string) (url.URL, error) {
+	return r.url, nil
+}
+
+func (r *testBalancedResolver) ResolveBalanced(name, loadBalancer string) (url.URL, func(), error) {
+	r.loadBalancers = append(r.loadBalancers, loadBalancer)
+	return r.url, func() { r.released++ }, nil
+}
+
+func newTestDeploymentLister(t *testing.T, deployments ...*appsv1.Deployment) v1.DeploymentLister {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for _, d := range deployments {
+		if err := indexer.Add(d); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+	return v1.NewDeploymentLister(indexer)
+}
+
+func newProxyTestServer(t *testing.T, upstream *httptest.Server, lister v1.DeploymentLister) *httptest.Server {
+	return httptest.NewServer(newProxyTestRouter(t, upstream, lister))
+}
+
+func newProxyTestRouter(t *testing.T, upstream *httptest.Server, lister v1.DeploymentLister) *mux.Router {
+	upstreamURL, err := url.Parse(upstream.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	config := types.FaaSConfig{ReadTimeout: time.Second, WriteTimeout: time.Second}
+	handler := MakeProxyHandler(config, testResolver{url: *upstreamURL}, "openfaas-fn", lister)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/function/{name}", handler)
+	router.HandleFunc("/function/{name}/{params:.*}", handler)
+	return router
+}
+
+func Test_MakeProxyHandler_Buffered(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(r.URL.Path))
+	}))
+	defer upstream.Close()
+
+	srv := newProxyTestServer(t, upstream, newTestDeploymentLister(t))
+	defer srv.Close()
+
+	res, err := http.Post(srv.URL+"/function/nodeinfo/sub/path", "text/plain", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer res.Body.Close()
+
+	body, _ := ioutil.ReadAll(res.Body)
+	if res.StatusCode != http.StatusCreated {
+		t.Errorf("want status: %d, got: %d", http.StatusCreated, res.StatusCode)
+	}
+	if string(body) != "/sub/path" {
+		t.Errorf("want body: %q, got: %q", "/sub/path", string(body))
+	}
+	if got := res.Header.Get("Content-Type"); got != "text/plain; charset=utf-8" {
+		t.Errorf("want the function's Content-Type, got: %q", got)
+	}
+}
+
+func Test_MakeProxyHandler_ForwardsTraceContext(t *testing.T) {
+	const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	const tracestate = "congo=t61rcWkgMzE"
+
+	var gotTraceparent, gotTracestate string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotTraceparent = r.Header.Get("traceparent")
+		gotTracestate = r.Header.Get("tracestate")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer upstream.Close()
+
+	srv := newProxyTestServer(t, upstream, newTestDeploymentLister(t))
+	defer srv.Close()
+
+	req, err := http.NewRequest(http.MethodPost, srv.URL+"/function/nodeinfo", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	req.Header.Set("traceparent", traceparent)
+	req.Header.Set("tracestate", tracestate)
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer res.Body.Close()
+
+	if gotTraceparent != traceparent {
+		t.Errorf("want traceparent: %q, got: %q", traceparent, gotTraceparent)
+	}
+	if gotTracestate != tracestate {
+		t.Errorf("want tracestate: %q, got: %q", tracestate, gotTracestate)
+	}
+}
+
+func Test_MakeProxyHandler_StreamingFlushesEachChunk(t *testing.T) {
+	release := make(chan struct{})
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("data: 1\n\n"))
+		w.(http.Flusher).Flush()
+
+		<-release
+		w.Write([]byte("data: 2\n\n"))
+	}))
+	defer upstream.Close()
+	defer close(release)
+
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "stream", Namespace: "openfaas-fn"},
+	}
+	deployment.Spec.Template.Annotations = map[string]string{k8s.StreamingAnnotationKey: "true"}
+
+	srv := newProxyTestServer(t, upstream, newTestDeploymentLister(t, deployment))
+	defer srv.Close()
+
+	res, err := http.Get(srv.URL + "/function/stream")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer res.Body.Close()
+
+	lines := make(chan string)
+	go func() {
+		line, _ := bufio.NewReader(res.Body).ReadString('\n')
+		lines <- line
+	}()
+
+	select {
+	case line := <-lines:
+		if line != "data: 1\n" {
+			t.Errorf("want first event, got: %q", line)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the first event, the response was buffered")
+	}
+}
+
+func Test_MakeProxyHandler_StreamingOutlivesWriteTimeout(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for i := 1; i <= 3; i++ {
+			fmt.Fprintf(w, "data: %d\n\n", i)
+			w.(http.Flusher).Flush()
+			time.Sleep(200 * time.Millisecond)
+		}
+	}))
+	defer upstream.Close()
+
+	cases := []struct {
+		name        string
+		annotations map[string]string
+		observed    bool
+	}{
+		{name: "streaming", annotations: map[string]string{}},
+		{name: "streaming with the access log", annotations: map[string]string{k8s.AccessLogAnnotationKey: "true"}},
+		{name: "streaming with handler metrics", annotations: map[string]string{}, observed: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			deployment := &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "stream", Namespace: "openfaas-fn", Annotations: tc.annotations},
+			}
+			deployment.Spec.Template.Annotations = map[string]string{k8s.StreamingAnnotationKey: "true"}
+
+			var handler http.Handler = newProxyTestRouter(t, upstream, newTestDeploymentLister(t, deployment))
+			if tc.observed {
+				// the proxy is wrapped in the same way as the FunctionProxy in main.go
+				handler = metrics.ObserveHandler("proxy", handler.ServeHTTP)
+			}
+
+			srv := httptest.NewUnstartedServer(handler)
+			// the stream takes longer than the server's write timeout
+			srv.Config.WriteTimeout = 250 * time.Millisecond
+			srv.Start()
+			defer srv.Close()
+
+			res, err := http.Get(srv.URL + "/function/stream")
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			defer res.Body.Close()
+
+			body, err := ioutil.ReadAll(res.Body)
+			if err != nil {
+				t.Fatalf("want the whole stream, got error: %s after: %q", err, body)
+			}
+
+			want := "data: 1\n\ndata: 2\n\ndata: 3\n\n"
+			if string(body) != want {
+				t.Errorf("want body: %q, got: %q", want, body)
+			}
+		})
+	}
+}
+
+func Test_MakeProxyHandler_PausedFunction(t *testing.T) {
+	called := false
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer upstream.Close()
+
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "nodeinfo",
+			Namespace:   "openfaas-fn",
+			Annotations: map[string]string{k8s.PausedAnnotationKey: "true"},
+		},
+	}
+
+	srv := newProxyTestServer(t, upstream, newTestDeploymentLister(t, deployment))
+	defer srv.Close()
+
+	res, err := http.Get(srv.URL + "/function/nodeinfo")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("want status: %d, got: %d", http.StatusServiceUnavailable, res.StatusCode)
+	}
+	if called {
+		t.Errorf("want the paused function not to be invoked")
+	}
+}
+
+func Test_MakeProxyHandler_CordonedFunction(t *testing.T) {
+	called := false
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer upstream.Close()
+
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "nodeinfo",
+			Namespace:   "openfaas-fn",
+			Annotations: map[string]string{k8s.CordonedAnnotationKey: "true"},
+		},
+	}
+
+	srv := newProxyTestServer(t, upstream, newTestDeploymentLister(t, deployment))
+	defer srv.Close()
+
+	res, err := http.Get(srv.URL + "/function/nodeinfo")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("want status: %d, got: %d", http.StatusServiceUnavailable, res.StatusCode)
+	}
+	if called {
+		t.Errorf("want the cordoned function not to be invoked")
+	}
+}
+
+func Test_MakeProxyHandler_ResponseHeaders(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer upstream.Close()
+
+	cases := []struct {
+		name         string
+		override     string
+		wantCache    string
+		wantAllowOrg string
+	}{
+		{name: "function headers are kept", override: "", wantCache: "no-store", wantAllowOrg: "*"},
+		{name: "function headers are overridden", override: "true", wantCache: "max-age=60", wantAllowOrg: "*"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			deployment := &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "nodeinfo",
+					Namespace: "openfaas-fn",
+					Annotations: map[string]string{
+						k8s.ResponseHeadersAnnotationKey:         `{"Access-Control-Allow-Origin":"*","Cache-Control":"max-age=60"}`,
+						k8s.ResponseHeadersOverrideAnnotationKey: tc.override,
+					},
+				},
+			}
+
+			srv := newProxyTestServer(t, upstream, newTestDeploymentLister(t, deployment))
+			defer srv.Close()
+
+			res, err := http.Get(srv.URL + "/function/nodeinfo")
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			defer res.Body.Close()
+
+			if got := res.Header.Get("Cache-Control"); got != tc.wantCache {
+				t.Errorf("want Cache-Control: %q, got: %q", tc.wantCache, got)
+			}
+			if got := res.Header.Get("Access-Control-Allow-Origin"); got != tc.wantAllowOrg {
+				t.Errorf("want Access-Control-Allow-Origin: %q, got: %q", tc.wantAllowOrg, got)
+			}
+		})
+	}
+}
+
+func Test_lookupFunctionDeployment_Streaming(t *testing.T) {
+	streaming := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "stream", Namespace: "dev"}}
+	streaming.Spec.Template.Annotations = map[string]string{k8s.StreamingAnnotationKey: "true"}
+	buffered := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "buffered", Namespace: "openfaas-fn"}}
+
+	lister := newTestDeploymentLister(t, streaming, buffered)
+
+	cases := []struct {
+		name     string
+		function string
+		want     bool
+	}{
+		{name: "streaming in another namespace", function: "stream.dev", want: true},
+		{name: "streaming without namespace uses the default", function: "stream", want: false},
+		{name: "no annotation", function: "buffered", want: false},
+		{name: "missing function", function: "missing", want: false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			deployment := lookupFunctionDeployment(tc.function, "openfaas-fn", lister)
+			if got := deployment != nil && k8s.IsStreaming(deployment.Spec.Template.Annotations); got != tc.want {
+				t.Errorf("want: %t, got: %t", tc.want, got)
+			}
+		})
+	}
+}
+
+func Test_MakeProxyHandler_LoadBalancer(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("OK"))
+	}))
+	defer upstream.Close()
+
+	upstreamURL, err := url.Parse(upstream.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	lister := newTestDeploymentLister(t, &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{
+		Name:        "nodeinfo",
+		Namespace:   "openfaas-fn",
+		Annotations: map[string]string{k8s.LoadBalancerAnnotationKey: k8s.LoadBalancerLeastConnections},
+	}})
+
+	resolver := &testBalancedResolver{url: *upstreamURL}
+	config := types.FaaSConfig{ReadTimeout: time.Second, WriteTimeout: time.Second}
+	handler := MakeProxyHandler(config, resolver, "openfaas-fn", lister)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/function/{name}", handler)
+	srv := httptest.NewServer(router)
+	defer srv.Close()
+
+	for _, name := range []string{"nodeinfo", "env"} {
+		res, err := http.Get(srv.URL + "/function/" + name)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		res.Body.Close()
+	}
+
+	if resolver.released != 2 {
+		t.Errorf("want each endpoint to be released once the response is copied, got %d releases", resolver.released)
+	}
+	// env has no Deployment so it uses the resolver's default
+	want := []string{k8s.LoadBalancerLeastConnections, ""}
+	if fmt.Sprint(resolver.loadBalancers) != fmt.Sprint(want) {
+		t.Errorf("want load balancers %q, got %q", want, resolver.loadBalancers)
+	}
+}
+
+func Test_variantTarget(t *testing.T) {
+	deployment := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{
+		Name:      "nodeinfo",
+		Namespace: "openfaas-fn",
+		Annotations: map[string]string{
+			k8s.VariantsAnnotationKey: `{"beta":"functions/nodeinfo:beta"}`,
+		},
+	}}
+
+	cases := []struct {
+		name   string
+		fn     string
+		header http.Header
+		want   string
+	}{
+		{name: "no header", fn: "nodeinfo", header: http.Header{}, want: "nodeinfo"},
+		{name: "known variant", fn: "nodeinfo", header: http.Header{"X-Variant": []string{"beta"}}, want: "nodeinfo-beta"},
+		{name: "known variant with namespace", fn: "nodeinfo.openfaas-fn", header: http.Header{"X-Variant": []string{"beta"}}, want: "nodeinfo-beta.openfaas-fn"},
+		{name: "unknown variant", fn: "nodeinfo", header: http.Header{"X-Variant": []string{"alpha"}}, want: "nodeinfo"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := variantTarget(tc.fn, deployment, tc.header); got != tc.want {
+				t.Errorf("want %s, got %s", tc.want, got)
+			}
+		})
+	}
+}
+
+func Test_MakeProxyHandler_PathPrefix(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.RequestURI()))
+	}))
+	defer upstream.Close()
+
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "nodeinfo",
+			Namespace:   "openfaas-fn",
+			Annotations: map[string]string{k8s.PathPrefixAnnotationKey: "/api/"},
+		},
+	}
+
+	srv := newProxyTestServer(t, upstream, newTestDeploymentLister(t, deployment))
+	defer srv.Close()
+
+	cases := []struct {
+		path string
+		want string
+	}{
+		{path: "/function/nodeinfo", want: "/api/"},
+		{path: "/function/nodeinfo/", want: "/api/"},
+		{path: "/function/nodeinfo/users", want: "/api/users"},
+		{path: "/function/nodeinfo/users/", want: "/api/users/"},
+		{path: "/function/nodeinfo/users?page=2&sort=name", want: "/api/users?page=2&sort=name"},
+		{path: "/function/nodeinfo.openfaas-fn/users", want: "/api/users"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.path, func(t *testing.T) {
+			res, err := http.Get(srv.URL + tc.path)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			defer res.Body.Close()
+
+			body, _ := ioutil.ReadAll(res.Body)
+			if string(body) != tc.want {
+				t.Errorf("want upstream path: %q, got: %q", tc.want, string(body))
+			}
+		})
+	}
+}
@@ -8,8 +8,10 @@ import (
 	"encoding/json"
 	"log"
 	"net/http"
+	"time"
 
 	types "github.com/openfaas/faas-provider/types"
+	appsv1 "k8s.io/api/apps/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/selection"
 	v1 "k8s.io/client-go/listers/apps/v1"
@@ -19,7 +21,8 @@ import (
 )
 
 // MakeFunctionReader handler for reading functions deployed in the cluster as deployments.
-func MakeFunctionReader(defaultNamespace string, deploymentLister v1.DeploymentLister) http.HandlerFunc {
+// See MakeReplicaReader for statusConfig.
+func MakeFunctionReader(defaultNamespace string, deploymentLister v1.DeploymentLister, statusConfig k8s.StatusConfig) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 
 		q := r.URL.Query()
@@ -36,7 +39,7 @@ func MakeFunctionReader(defaultNamespace string, deploymentLister v1.DeploymentL
 			return
 		}
 
-		functions, err := getServiceList(lookupNamespace, deploymentLister)
+		functions, err := getServiceList(lookupNamespace, deploymentLister, statusConfig)
 		if err != nil {
 			log.Println(err)
 			w.WriteHeader(http.StatusInternalServerError)
@@ -58,8 +61,48 @@ func MakeFunctionReader(defaultNamespace string, deploymentLister v1.DeploymentL
 	}
 }
 
-func getServiceList(functionNamespace string, deploymentLister v1.DeploymentLister) ([]types.FunctionStatus, error) {
-	functions := []types.FunctionStatus{}
+// functionStatus extends the FunctionStatus with the rollout strategy of the function's Deployment
+type functionStatus struct {
+	types.FunctionStatus
+
+	// Strategy is set to recreate when the Deployment does not use a rolling update
+	Strategy string `json:"strategy,omitempty"`
+
+	// MaxSurge and MaxUnavailable are the rolling update parameters of the Deployment
+	MaxSurge       string `json:"maxSurge,omitempty"`
+	MaxUnavailable string `json:"maxUnavailable,omitempty"`
+
+	// LastUpdated is when the function was last deployed or updated, see k8s.LastUpdated, the
+	// creation time is the createdAt field of the FunctionStatus
+	LastUpdated time.Time `json:"lastUpdated"`
+}
+
+func withStrategy(function types.FunctionStatus, item appsv1.Deployment) functionStatus {
+	status := functionStatus{
+		FunctionStatus: function,
+		LastUpdated:    k8s.LastUpdated(item),
+	}
+
+	strategy := item.Spec.Strategy
+	if strategy.Type == appsv1.RecreateDeploymentStrategyType {
+		status.Strategy = "recreate"
+		return status
+	}
+
+	if strategy.RollingUpdate != nil {
+		if strategy.RollingUpdate.MaxSurge != nil {
+			status.MaxSurge = strategy.RollingUpdate.MaxSurge.String()
+		}
+		if strategy.RollingUpdate.MaxUnavailable != nil {
+			status.MaxUnavailable = strategy.RollingUpdate.MaxUnavailable.String()
+		}
+	}
+
+	return status
+}
+
+func getServiceList(functionNamespace string, deploymentLister v1.DeploymentLister, statusConfig k8s.StatusConfig) ([]functionStatus, error) {
+	functions := []functionStatus{}
 
 	sel := labels.NewSelector()
 	req, err := labels.NewRequirement("faas_function", selection.Exists, []string{})
@@ -78,7 +121,8 @@ func getServiceList(functionNamespace string, deploymentLister v1.DeploymentList
 		if item != nil {
 			function := k8s.AsFunctionStatus(*item)
 			if function != nil {
-				functions = append(functions, *function)
+				k8s.WithFunctionState(function, *item, statusConfig)
+				functions = append(functions, withStrategy(*function, *item))
 			}
 		}
 	}
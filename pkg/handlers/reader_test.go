@@ -0,0 +1,89 @@
+// Copyright 2020 OpenFaaS Author(s)
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/openfaas/faas-netes/pkg/k8s"
+)
+
+func Test_MakeFunctionReader_Strategy(t *testing.T) {
+	newDeployment := func(name string, strategy appsv1.DeploymentStrategy) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "openfaas-fn",
+				Labels:    map[string]string{"faas_function": name},
+			},
+			Spec: appsv1.DeploymentSpec{
+				Strategy: strategy,
+				Template: apiv1.PodTemplateSpec{
+					Spec: apiv1.PodSpec{
+						Containers: []apiv1.Container{{Name: name, Image: "alpine:latest"}},
+					},
+				},
+			},
+		}
+	}
+
+	maxSurge := intstr.FromString("25%")
+	maxUnavailable := intstr.FromInt(0)
+
+	lister := newTestDeploymentLister(t,
+		newDeployment("rolling", appsv1.DeploymentStrategy{
+			Type: appsv1.RollingUpdateDeploymentStrategyType,
+			RollingUpdate: &appsv1.RollingUpdateDeployment{
+				MaxSurge:       &maxSurge,
+				MaxUnavailable: &maxUnavailable,
+			},
+		}),
+		newDeployment("recreate", appsv1.DeploymentStrategy{
+			Type: appsv1.RecreateDeploymentStrategyType,
+		}),
+	)
+
+	handler := MakeFunctionReader("openfaas-fn", lister, k8s.StatusConfig{})
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/system/functions", nil))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("want status: %d, got: %d", http.StatusOK, rr.Code)
+	}
+
+	functions := []map[string]interface{}{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &functions); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	got := map[string]map[string]interface{}{}
+	for _, f := range functions {
+		got[f["name"].(string)] = f
+	}
+
+	rolling := got["rolling"]
+	if rolling["maxSurge"] != "25%" || rolling["maxUnavailable"] != "0" {
+		t.Errorf("want maxSurge: 25%% and maxUnavailable: 0, got: %v and %v", rolling["maxSurge"], rolling["maxUnavailable"])
+	}
+	if _, ok := rolling["strategy"]; ok {
+		t.Errorf("want no strategy field for a rolling update, got: %v", rolling["strategy"])
+	}
+
+	recreate := got["recreate"]
+	if recreate["strategy"] != "recreate" {
+		t.Errorf("want strategy: recreate, got: %v", recreate["strategy"])
+	}
+	if _, ok := recreate["maxSurge"]; ok {
+		t.Errorf("want no maxSurge for recreate, got: %v", recreate["maxSurge"])
+	}
+}
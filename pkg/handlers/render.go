@@ -0,0 +1,88 @@
+// Copyright 2020 OpenFaaS Author(s)
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+
+	"github.com/openfaas/faas-netes/pkg/k8s"
+	types "github.com/openfaas/faas-provider/types"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// renderManifests renders the Deployment and Service of a function as a v1 List, so that
+// they can be applied by a GitOps tool rather than by the provider. When
+// DeploymentConfig.RenderConfigMap is set, the List is also written to that ConfigMap.
+func renderManifests(ctx context.Context, factory k8s.FunctionFactory, secrets k8s.SecretsClient, namespace string, request types.FunctionDeployment) ([]byte, error) {
+	deployment, service, err := renderFunction(ctx, factory, secrets, namespace, request)
+	if err != nil {
+		return nil, err
+	}
+
+	deployment.APIVersion = "apps/v1"
+	deployment.Kind = "Deployment"
+	deployment.Namespace = namespace
+
+	service.APIVersion = "v1"
+	service.Kind = "Service"
+	service.Namespace = namespace
+
+	manifests, err := json.Marshal(metav1.List{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "List",
+		},
+		Items: []runtime.RawExtension{
+			{Object: deployment},
+			{Object: service},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if name := factory.Config.RenderConfigMap; len(name) > 0 {
+		if err := writeManifestsConfigMap(ctx, factory, namespace, name, request.Service, manifests); err != nil {
+			return nil, err
+		}
+		log.Printf("Manifests for %s.%s written to ConfigMap %s\n", request.Service, namespace, name)
+	}
+
+	return manifests, nil
+}
+
+// writeManifestsConfigMap stores the manifests of a function under the key <function>.json,
+// the ConfigMap is created when it does not exist
+func writeManifestsConfigMap(ctx context.Context, factory k8s.FunctionFactory, namespace, name, function string, manifests []byte) error {
+	configMaps := factory.Client.CoreV1().ConfigMaps(namespace)
+	key := function + ".json"
+
+	configMap, err := configMaps.Get(ctx, name, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		_, err = configMaps.Create(ctx, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+			},
+			Data: map[string]string{key: string(manifests)},
+		}, metav1.CreateOptions{})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	if configMap.Data == nil {
+		configMap.Data = map[string]string{}
+	}
+	configMap.Data[key] = string(manifests)
+
+	_, err = configMaps.Update(ctx, configMap, metav1.UpdateOptions{})
+	return err
+}
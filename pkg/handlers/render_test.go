@@ -0,0 +1,86 @@
+// Copyright 2020 OpenFaaS Author(s)
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/openfaas/faas-netes/pkg/k8s"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_MakeDeployHandler_RenderManifests(t *testing.T) {
+	cases := []struct {
+		name   string
+		query  string
+		config k8s.DeploymentConfig
+	}{
+		{name: "per request", query: "?render=true"},
+		{name: "global mode", config: k8s.DeploymentConfig{RenderManifests: true}},
+		{name: "global mode with ConfigMap sink", config: k8s.DeploymentConfig{RenderManifests: true, RenderConfigMap: "manifests"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset()
+			tc.config.LivenessProbe = &k8s.ProbeConfig{}
+			tc.config.ReadinessProbe = &k8s.ProbeConfig{}
+			factory := k8s.NewFunctionFactory(client, tc.config, nil)
+
+			handler := MakeDeployHandler("openfaas-fn", factory)
+
+			body := `{"service":"nodeinfo","image":"functions/nodeinfo:latest"}`
+			req := httptest.NewRequest(http.MethodPost, "/system/functions"+tc.query, strings.NewReader(body))
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusOK {
+				t.Fatalf("want status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+			}
+
+			var list struct {
+				Kind  string `json:"kind"`
+				Items []struct {
+					APIVersion string            `json:"apiVersion"`
+					Kind       string            `json:"kind"`
+					Metadata   metav1.ObjectMeta `json:"metadata"`
+				} `json:"items"`
+			}
+			if err := json.Unmarshal(rr.Body.Bytes(), &list); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if list.Kind != "List" || len(list.Items) != 2 {
+				t.Fatalf("want a List of 2 items, got: %s", rr.Body.String())
+			}
+			if list.Items[0].Kind != "Deployment" || list.Items[1].Kind != "Service" {
+				t.Errorf("want a Deployment and a Service, got: %s and %s", list.Items[0].Kind, list.Items[1].Kind)
+			}
+			if list.Items[0].Metadata.Namespace != "openfaas-fn" {
+				t.Errorf("want namespace openfaas-fn, got: %q", list.Items[0].Metadata.Namespace)
+			}
+
+			deployments, _ := client.AppsV1().Deployments("openfaas-fn").List(context.TODO(), metav1.ListOptions{})
+			if len(deployments.Items) != 0 {
+				t.Errorf("want no Deployments to be created, got: %d", len(deployments.Items))
+			}
+
+			if len(tc.config.RenderConfigMap) > 0 {
+				configMap, err := client.CoreV1().ConfigMaps("openfaas-fn").Get(context.TODO(), tc.config.RenderConfigMap, metav1.GetOptions{})
+				if err != nil {
+					t.Fatalf("want the manifests ConfigMap, got: %s", err)
+				}
+				if configMap.Data["nodeinfo.json"] != rr.Body.String() {
+					t.Errorf("want the manifests in the ConfigMap, got: %v", configMap.Data)
+				}
+			}
+		})
+	}
+}
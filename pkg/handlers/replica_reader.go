@@ -19,8 +19,9 @@ import (
 	glog "k8s.io/klog"
 )
 
-// MakeReplicaReader reads the amount of replicas for a deployment
-func MakeReplicaReader(defaultNamespace string, lister v1.DeploymentLister) http.HandlerFunc {
+// MakeReplicaReader reads the amount of replicas for a deployment. The state and readiness of
+// the function are added to its annotations according to statusConfig.
+func MakeReplicaReader(defaultNamespace string, lister v1.DeploymentLister, statusConfig k8s.StatusConfig) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 
 		vars := mux.Vars(r)
@@ -37,7 +38,7 @@ func MakeReplicaReader(defaultNamespace string, lister v1.DeploymentLister) http
 
 		s := time.Now()
 
-		function, err := getService(lookupNamespace, functionName, lister)
+		function, err := getService(lookupNamespace, functionName, lister, statusConfig)
 		if err != nil {
 			log.Printf("Unable to fetch service: %s %s\n", functionName, namespace)
 			w.WriteHeader(http.StatusInternalServerError)
@@ -67,7 +68,7 @@ func MakeReplicaReader(defaultNamespace string, lister v1.DeploymentLister) http
 }
 
 // getService returns a function/service or nil if not found
-func getService(functionNamespace string, functionName string, lister v1.DeploymentLister) (*types.FunctionStatus, error) {
+func getService(functionNamespace string, functionName string, lister v1.DeploymentLister, statusConfig k8s.StatusConfig) (*types.FunctionStatus, error) {
 
 	item, err := lister.Deployments(functionNamespace).
 		Get(functionName)
@@ -81,8 +82,13 @@ func getService(functionNamespace string, functionName string, lister v1.Deploym
 	}
 
 	if item != nil {
+		if k8s.ProgressDeadlineExceeded(*item) {
+			log.Printf("Function %s.%s rollout exceeded its progress deadline\n", functionName, functionNamespace)
+		}
+
 		function := k8s.AsFunctionStatus(*item)
 		if function != nil {
+			k8s.WithFunctionState(function, *item, statusConfig)
 			return function, nil
 		}
 	}
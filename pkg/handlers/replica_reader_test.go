@@ -0,0 +1,87 @@
+// Copyright 2020 OpenFaaS Author(s)
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	types "github.com/openfaas/faas-provider/types"
+	appsv1 "k8s.io/api/apps/v1"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/openfaas/faas-netes/pkg/k8s"
+)
+
+func Test_MakeReplicaReader_ProgressDeadlineExceeded(t *testing.T) {
+	stalled := appsv1.DeploymentCondition{
+		Type:   appsv1.DeploymentProgressing,
+		Status: apiv1.ConditionFalse,
+		Reason: "ProgressDeadlineExceeded",
+	}
+	progressing := appsv1.DeploymentCondition{
+		Type:   appsv1.DeploymentProgressing,
+		Status: apiv1.ConditionTrue,
+		Reason: "NewReplicaSetAvailable",
+	}
+
+	cases := []struct {
+		name      string
+		condition appsv1.DeploymentCondition
+		want      string
+	}{
+		{name: "stalled rollout", condition: stalled, want: k8s.FunctionStateProgressDeadlineExceeded},
+		{name: "progressing rollout", condition: progressing, want: ""},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			replicas := int32(1)
+			lister := newTestDeploymentLister(t, &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "nodeinfo",
+					Namespace: "openfaas-fn",
+					Labels:    map[string]string{"faas_function": "nodeinfo"},
+				},
+				Spec: appsv1.DeploymentSpec{
+					Replicas: &replicas,
+					Template: apiv1.PodTemplateSpec{
+						Spec: apiv1.PodSpec{
+							Containers: []apiv1.Container{{Name: "nodeinfo", Image: "functions/nodeinfo"}},
+						},
+					},
+				},
+				Status: appsv1.DeploymentStatus{
+					Replicas:          1,
+					AvailableReplicas: 1,
+					Conditions:        []appsv1.DeploymentCondition{tc.condition},
+				},
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/system/function/nodeinfo", nil)
+			req = mux.SetURLVars(req, map[string]string{"name": "nodeinfo"})
+			rr := httptest.NewRecorder()
+			MakeReplicaReader("openfaas-fn", lister, k8s.StatusConfig{}).ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusOK {
+				t.Fatalf("want status: %d, got: %d", http.StatusOK, rr.Code)
+			}
+
+			var status types.FunctionStatus
+			if err := json.Unmarshal(rr.Body.Bytes(), &status); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if status.Annotations == nil {
+				t.Fatalf("want annotations, got nil")
+			}
+			if got := (*status.Annotations)[k8s.FunctionStateAnnotationKey]; got != tc.want {
+				t.Errorf("want state: %q, got: %q", tc.want, got)
+			}
+		})
+	}
+}
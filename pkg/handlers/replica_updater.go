@@ -10,15 +10,19 @@ import (
 	"io/ioutil"
 	"log"
 	"net/http"
+	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/openfaas/faas-netes/pkg/k8s"
 	"github.com/openfaas/faas-provider/types"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 )
 
-// MakeReplicaUpdater updates desired count of replicas
-func MakeReplicaUpdater(defaultNamespace string, clientset *kubernetes.Clientset) http.HandlerFunc {
+// MakeReplicaUpdater updates desired count of replicas, requests above maxReplicas are clamped
+// and the applied count is returned. Changes are recorded in the history. Functions outside
+// of their scale to zero schedule keep one replica when scaled to zero.
+func MakeReplicaUpdater(defaultNamespace string, clientset kubernetes.Interface, maxReplicas int32, history *k8s.ScaleHistory) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		log.Println("Update replicas")
 
@@ -65,12 +69,36 @@ func MakeReplicaUpdater(defaultNamespace string, clientset *kubernetes.Clientset
 			return
 		}
 
+		if req.Replicas > 0 && k8s.IsPaused(deployment.Annotations) {
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte("Function " + functionName + " is paused, resume it before scaling"))
+			log.Printf("Function %s %s is paused, not scaling to %d\n", functionName, lookupNamespace, req.Replicas)
+			return
+		}
+
 		oldReplicas := *deployment.Spec.Replicas
-		replicas := int32(req.Replicas)
+		replicas, clamped := k8s.ClampReplicas(int32(req.Replicas), maxReplicas)
+		if clamped {
+			log.Printf("Clamped replicas - %s %s, requested %d, max_replicas_per_function %d\n", functionName, lookupNamespace, req.Replicas, maxReplicas)
+		}
+
+		if replicas == 0 {
+			allowed, err := k8s.ScaleToZeroAllowed(deployment.Annotations, time.Now())
+			if err != nil {
+				log.Printf("Function %s %s: %s\n", functionName, lookupNamespace, err)
+			}
+			if !allowed {
+				replicas = 1
+				log.Printf("Function %s %s is outside of its scale to zero schedule, keeping a warm replica\n", functionName, lookupNamespace)
+			}
+		}
 
 		log.Printf("Set replicas - %s %s, %d/%d\n", functionName, lookupNamespace, replicas, oldReplicas)
 
 		deployment.Spec.Replicas = &replicas
+		if oldReplicas == 0 && replicas > 0 {
+			k8s.MarkScaledFromZero(deployment, time.Now())
+		}
 
 		_, err = clientset.AppsV1().Deployments(lookupNamespace).Update(context.TODO(), deployment, metav1.UpdateOptions{})
 
@@ -81,6 +109,22 @@ func MakeReplicaUpdater(defaultNamespace string, clientset *kubernetes.Clientset
 			return
 		}
 
+		if replicas != oldReplicas {
+			history.Record(functionName, lookupNamespace, k8s.ScaleEvent{
+				From:      oldReplicas,
+				To:        replicas,
+				Timestamp: time.Now(),
+				Source:    r.UserAgent(),
+			})
+		}
+
+		out, _ := json.Marshal(types.ScaleServiceRequest{
+			ServiceName: functionName,
+			Replicas:    uint64(replicas),
+		})
+
+		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusAccepted)
+		w.Write(out)
 	}
 }
@@ -0,0 +1,115 @@
+// Copyright 2020 OpenFaaS Author(s)
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/openfaas/faas-provider/types"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_MakeReplicaUpdater_ClampsReplicas(t *testing.T) {
+	cases := []struct {
+		name        string
+		maxReplicas int32
+		requested   int
+		want        int32
+	}{
+		{name: "no cap", maxReplicas: 0, requested: 50, want: 50},
+		{name: "below the cap", maxReplicas: 10, requested: 5, want: 5},
+		{name: "above the cap", maxReplicas: 10, requested: 50, want: 10},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			replicas := int32(1)
+			clientset := fake.NewSimpleClientset(&appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "nodeinfo", Namespace: "openfaas-fn"},
+				Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+			})
+
+			handler := MakeReplicaUpdater("openfaas-fn", clientset, tc.maxReplicas, nil)
+
+			body := strings.NewReader(`{"serviceName":"nodeinfo","replicas":` + strconv.Itoa(tc.requested) + `}`)
+			req := httptest.NewRequest(http.MethodPost, "/system/scale-function/nodeinfo", body)
+			req = mux.SetURLVars(req, map[string]string{"name": "nodeinfo"})
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusAccepted {
+				t.Fatalf("want status %d, got %d: %s", http.StatusAccepted, rr.Code, rr.Body.String())
+			}
+
+			var res types.ScaleServiceRequest
+			if err := json.Unmarshal(rr.Body.Bytes(), &res); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if res.Replicas != uint64(tc.want) {
+				t.Errorf("want response replicas %d, got %d", tc.want, res.Replicas)
+			}
+
+			deployment, err := clientset.AppsV1().Deployments("openfaas-fn").Get(context.TODO(), "nodeinfo", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if *deployment.Spec.Replicas != tc.want {
+				t.Errorf("want Deployment replicas %d, got %d", tc.want, *deployment.Spec.Replicas)
+			}
+		})
+	}
+}
+
+func Test_MakeReplicaUpdater_ScaleZeroSchedule(t *testing.T) {
+	cases := []struct {
+		name        string
+		annotations map[string]string
+		want        int32
+	}{
+		{name: "no schedule", annotations: nil, want: 0},
+		{name: "inside the schedule", annotations: map[string]string{"com.openfaas.scale.zero.schedule": "* * * * *"}, want: 0},
+		{name: "outside the schedule", annotations: map[string]string{"com.openfaas.scale.zero.schedule": "* * 31 2 *"}, want: 1},
+		{name: "invalid schedule", annotations: map[string]string{"com.openfaas.scale.zero.schedule": "daily"}, want: 0},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			replicas := int32(3)
+			clientset := fake.NewSimpleClientset(&appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "nodeinfo", Namespace: "openfaas-fn", Annotations: tc.annotations},
+				Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+			})
+
+			handler := MakeReplicaUpdater("openfaas-fn", clientset, 0, nil)
+
+			req := httptest.NewRequest(http.MethodPost, "/system/scale-function/nodeinfo", strings.NewReader(`{"serviceName":"nodeinfo","replicas":0}`))
+			req = mux.SetURLVars(req, map[string]string{"name": "nodeinfo"})
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusAccepted {
+				t.Fatalf("want status %d, got %d: %s", http.StatusAccepted, rr.Code, rr.Body.String())
+			}
+
+			deployment, err := clientset.AppsV1().Deployments("openfaas-fn").Get(context.TODO(), "nodeinfo", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if *deployment.Spec.Replicas != tc.want {
+				t.Errorf("want Deployment replicas %d, got %d", tc.want, *deployment.Spec.Replicas)
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+// Copyright 2020 OpenFaaS Author(s)
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/openfaas/faas-netes/pkg/k8s"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	v1 "k8s.io/client-go/listers/apps/v1"
+)
+
+// FunctionMetricsReader returns the current usage of each Pod of a function
+type FunctionMetricsReader interface {
+	FunctionPodMetrics(ctx context.Context, namespace, function string) ([]k8s.PodUsage, error)
+}
+
+// ResourceUsage is a CPU and memory pair, the ratios are the usage as a fraction of the
+// function's requests and are omitted when no request is set
+type ResourceUsage struct {
+	CPU    string `json:"cpu"`
+	Memory string `json:"memory"`
+
+	CPURequestRatio    *float64 `json:"cpuRequestRatio,omitempty"`
+	MemoryRequestRatio *float64 `json:"memoryRequestRatio,omitempty"`
+}
+
+// ReplicaResourceUsage is the usage of a single Pod of the function
+type ReplicaResourceUsage struct {
+	Pod string `json:"pod"`
+	ResourceUsage
+}
+
+// FunctionResourceUsage compares the usage of a function with its requests and limits
+type FunctionResourceUsage struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+
+	Requests ResourceUsage `json:"requests"`
+	Limits   ResourceUsage `json:"limits"`
+
+	Replicas []ReplicaResourceUsage `json:"replicas"`
+
+	// Total is the usage of all replicas added up
+	Total ResourceUsage `json:"total"`
+
+	// Average is the usage per replica, its ratios show whether the requests are right-sized
+	Average ResourceUsage `json:"average"`
+}
+
+// MakeResourceUsageHandler returns the current CPU and memory usage of each replica of a
+// function from metrics-server, alongside its requests and limits, so that over- and
+// under-provisioned functions can be found.
+func MakeResourceUsageHandler(defaultNamespace string, deploymentLister v1.DeploymentLister, metrics FunctionMetricsReader) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Body != nil {
+			defer r.Body.Close()
+		}
+
+		functionName := mux.Vars(r)["name"]
+
+		lookupNamespace := defaultNamespace
+		if namespace := r.URL.Query().Get("namespace"); len(namespace) > 0 {
+			lookupNamespace = namespace
+		}
+
+		if lookupNamespace == "kube-system" {
+			http.Error(w, "unable to list within the kube-system namespace", http.StatusUnauthorized)
+			return
+		}
+
+		deployment, err := deploymentLister.Deployments(lookupNamespace).Get(functionName)
+		if err != nil {
+			status, reason := ProcessErrorReasons(err)
+			log.Printf("Function resource usage lookup error reason: %s, %v\n", reason, err)
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		pods, err := metrics.FunctionPodMetrics(r.Context(), lookupNamespace, functionName)
+		if err != nil {
+			log.Printf("Function resource usage metrics error: %v\n", err)
+
+			status := http.StatusInternalServerError
+			if k8serrors.IsNotFound(err) || k8serrors.IsServiceUnavailable(err) {
+				status = http.StatusServiceUnavailable
+			}
+			http.Error(w, "unable to read metrics from metrics-server: "+err.Error(), status)
+			return
+		}
+
+		var resources corev1.ResourceRequirements
+		if len(deployment.Spec.Template.Spec.Containers) > 0 {
+			resources = deployment.Spec.Template.Spec.Containers[0].Resources
+		}
+
+		out, err := json.Marshal(functionResourceUsage(functionName, lookupNamespace, resources, pods))
+		if err != nil {
+			log.Printf("Function resource usage json marshal error: %v\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(out)
+	}
+}
+
+// functionResourceUsage builds the per-replica, total and average usage of a function
+func functionResourceUsage(name, namespace string, resources corev1.ResourceRequirements, pods []k8s.PodUsage) FunctionResourceUsage {
+	cpuRequest := resources.Requests[corev1.ResourceCPU]
+	memoryRequest := resources.Requests[corev1.ResourceMemory]
+
+	usage := FunctionResourceUsage{
+		Name:      name,
+		Namespace: namespace,
+		Requests:  resourcePair(resources.Requests),
+		Limits:    resourcePair(resources.Limits),
+		Replicas:  []ReplicaResourceUsage{},
+	}
+
+	var totalCPU, totalMemory resource.Quantity
+	for _, pod := range pods {
+		usage.Replicas = append(usage.Replicas, ReplicaResourceUsage{
+			Pod:           pod.Pod,
+			ResourceUsage: usageWithRatios(pod.CPU, pod.Memory, cpuRequest, memoryRequest),
+		})
+
+		totalCPU.Add(pod.CPU)
+		totalMemory.Add(pod.Memory)
+	}
+
+	usage.Total = ResourceUsage{CPU: totalCPU.String(), Memory: totalMemory.String()}
+
+	if n := int64(len(pods)); n > 0 {
+		averageCPU := resource.NewMilliQuantity(totalCPU.MilliValue()/n, resource.DecimalSI)
+		averageMemory := resource.NewQuantity(totalMemory.Value()/n, resource.BinarySI)
+		usage.Average = usageWithRatios(*averageCPU, *averageMemory, cpuRequest, memoryRequest)
+	} else {
+		usage.Average = ResourceUsage{CPU: "0", Memory: "0"}
+	}
+
+	return usage
+}
+
+func usageWithRatios(cpu, memory, cpuRequest, memoryRequest resource.Quantity) ResourceUsage {
+	usage := ResourceUsage{CPU: cpu.String(), Memory: memory.String()}
+
+	if cpuRequest.MilliValue() > 0 {
+		ratio := float64(cpu.MilliValue()) / float64(cpuRequest.MilliValue())
+		usage.CPURequestRatio = &ratio
+	}
+	if memoryRequest.Value() > 0 {
+		ratio := float64(memory.Value()) / float64(memoryRequest.Value())
+		usage.MemoryRequestRatio = &ratio
+	}
+
+	return usage
+}
+
+func resourcePair(list corev1.ResourceList) ResourceUsage {
+	pair := ResourceUsage{}
+	if q, ok := list[corev1.ResourceCPU]; ok {
+		pair.CPU = q.String()
+	}
+	if q, ok := list[corev1.ResourceMemory]; ok {
+		pair.Memory = q.String()
+	}
+	return pair
+}
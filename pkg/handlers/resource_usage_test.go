@@ -0,0 +1,104 @@
+// Copyright 2020 OpenFaaS Author(s)
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/openfaas/faas-netes/pkg/k8s"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type testMetricsReader struct {
+	pods []k8s.PodUsage
+}
+
+func (r testMetricsReader) FunctionPodMetrics(ctx context.Context, namespace, function string) ([]k8s.PodUsage, error) {
+	return r.pods, nil
+}
+
+func Test_MakeResourceUsageHandler(t *testing.T) {
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "nodeinfo", Namespace: "openfaas-fn"},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{
+						Name: "nodeinfo",
+						Resources: corev1.ResourceRequirements{
+							Requests: corev1.ResourceList{
+								corev1.ResourceCPU:    resource.MustParse("100m"),
+								corev1.ResourceMemory: resource.MustParse("64Mi"),
+							},
+							Limits: corev1.ResourceList{
+								corev1.ResourceMemory: resource.MustParse("128Mi"),
+							},
+						},
+					}},
+				},
+			},
+		},
+	}
+
+	metrics := testMetricsReader{pods: []k8s.PodUsage{
+		{Pod: "nodeinfo-1", CPU: resource.MustParse("20m"), Memory: resource.MustParse("32Mi")},
+		{Pod: "nodeinfo-2", CPU: resource.MustParse("40m"), Memory: resource.MustParse("64Mi")},
+	}}
+
+	handler := MakeResourceUsageHandler("openfaas-fn", newTestDeploymentLister(t, deployment), metrics)
+
+	req := httptest.NewRequest(http.MethodGet, "/system/function/nodeinfo/usage", nil)
+	req = mux.SetURLVars(req, map[string]string{"name": "nodeinfo"})
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("want status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+
+	usage := FunctionResourceUsage{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &usage); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if usage.Requests.CPU != "100m" || usage.Requests.Memory != "64Mi" || usage.Limits.Memory != "128Mi" {
+		t.Errorf("unexpected requests and limits: %+v %+v", usage.Requests, usage.Limits)
+	}
+	if len(usage.Replicas) != 2 || usage.Replicas[1].Pod != "nodeinfo-2" {
+		t.Fatalf("unexpected replicas: %+v", usage.Replicas)
+	}
+	if r := usage.Replicas[1].MemoryRequestRatio; r == nil || *r != 1 {
+		t.Errorf("want a memory request ratio of 1 for nodeinfo-2, got: %v", r)
+	}
+	if usage.Total.CPU != "60m" || usage.Total.Memory != "96Mi" {
+		t.Errorf("unexpected total: %+v", usage.Total)
+	}
+	if usage.Average.CPU != "30m" || usage.Average.Memory != "48Mi" {
+		t.Errorf("unexpected average: %+v", usage.Average)
+	}
+	if r := usage.Average.CPURequestRatio; r == nil || *r != 0.3 {
+		t.Errorf("want an average cpu request ratio of 0.3, got: %v", r)
+	}
+}
+
+func Test_MakeResourceUsageHandler_NotFound(t *testing.T) {
+	handler := MakeResourceUsageHandler("openfaas-fn", newTestDeploymentLister(t), testMetricsReader{})
+
+	req := httptest.NewRequest(http.MethodGet, "/system/function/missing/usage", nil)
+	req = mux.SetURLVars(req, map[string]string{"name": "missing"})
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("want status %d, got %d", http.StatusNotFound, rr.Code)
+	}
+}
@@ -0,0 +1,40 @@
+// Copyright 2020 OpenFaaS Author(s)
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/openfaas/faas-netes/pkg/k8s"
+)
+
+// MakeRoutingTableHandler returns the cross-namespace routing table from the
+// faas-netes-routing ConfigMap, keyed by source function.
+func MakeRoutingTableHandler(table *k8s.RoutingTable) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Body != nil {
+			defer r.Body.Close()
+		}
+
+		routes, err := table.Routes(r.Context())
+		if err != nil {
+			log.Printf("Routing table error: %v\n", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		out, err := json.Marshal(routes)
+		if err != nil {
+			log.Printf("Routing table json marshal error: %v\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(out)
+	}
+}
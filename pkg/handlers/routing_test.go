@@ -0,0 +1,43 @@
+// Copyright 2020 OpenFaaS Author(s)
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/openfaas/faas-netes/pkg/k8s"
+)
+
+func Test_MakeRoutingTableHandler(t *testing.T) {
+	clientset := fake.NewSimpleClientset(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: k8s.RoutingTableConfigMap, Namespace: "openfaas"},
+		Data:       map[string]string{"billing": "billing@finance"},
+	})
+
+	handler := MakeRoutingTableHandler(k8s.NewRoutingTable(clientset, "openfaas"))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/system/routing", nil))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("want status: %d, got: %d", http.StatusOK, rr.Code)
+	}
+
+	routes := map[string]k8s.RouteTarget{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &routes); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	want := k8s.RouteTarget{Function: "billing", Namespace: "finance"}
+	if routes["billing"] != want {
+		t.Errorf("want route: %+v, got: %+v", want, routes["billing"])
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright 2020 OpenFaaS Author(s)
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/openfaas/faas-netes/pkg/k8s"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_ScaleHistory_RecordsReplicaChanges(t *testing.T) {
+	replicas := int32(1)
+	clientset := fake.NewSimpleClientset(&appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "nodeinfo", Namespace: "openfaas-fn"},
+		Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+	})
+
+	history := k8s.NewScaleHistory(10)
+	updater := MakeReplicaUpdater("openfaas-fn", clientset, 0, history)
+
+	for _, body := range []string{`{"replicas":3}`, `{"replicas":3}`, `{"replicas":0}`} {
+		req := httptest.NewRequest(http.MethodPost, "/system/scale-function/nodeinfo", strings.NewReader(body))
+		req.Header.Set("User-Agent", "autoscaler")
+		req = mux.SetURLVars(req, map[string]string{"name": "nodeinfo"})
+		updater.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	handler := MakeScaleHistoryHandler("openfaas-fn", history)
+
+	req := httptest.NewRequest(http.MethodGet, "/system/function/nodeinfo/scale-history", nil)
+	req = mux.SetURLVars(req, map[string]string{"name": "nodeinfo"})
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("want status %d, got %d", http.StatusOK, rr.Code)
+	}
+
+	var events []k8s.ScaleEvent
+	if err := json.Unmarshal(rr.Body.Bytes(), &events); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// the repeated request does not change the replicas, so it is not recorded
+	if len(events) != 2 {
+		t.Fatalf("want 2 events, got %+v", events)
+	}
+	if events[0].From != 1 || events[0].To != 3 || events[1].From != 3 || events[1].To != 0 {
+		t.Errorf("unexpected events: %+v", events)
+	}
+	if events[0].Source != "autoscaler" {
+		t.Errorf("want source autoscaler, got %q", events[0].Source)
+	}
+}
+
+func Test_MakeScaleHistoryHandler_RejectsKubeSystem(t *testing.T) {
+	handler := MakeScaleHistoryHandler("openfaas-fn", k8s.NewScaleHistory(10))
+
+	req := httptest.NewRequest(http.MethodGet, "/system/function/nodeinfo/scale-history?namespace=kube-system", nil)
+	req = mux.SetURLVars(req, map[string]string{"name": "nodeinfo"})
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusUnauthorized {
+		t.Fatalf("want status %d, got %d", http.StatusUnauthorized, rr.Code)
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright 2020 OpenFaaS Author(s)
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"sort"
+
+	"github.com/gorilla/mux"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/openfaas/faas-netes/pkg/k8s"
+)
+
+// SecretImpact is a function that would be affected by a change to a secret
+type SecretImpact struct {
+	Name       string   `json:"name"`
+	Namespace  string   `json:"namespace"`
+	References []string `json:"references"`
+}
+
+// MakeSecretImpactHandler lists the functions that reference a secret via env, envFrom,
+// volumes or imagePullSecrets. With a cluster role `all_namespaces=true` searches every
+// namespace for functions that use a secret of the same name.
+func MakeSecretImpactHandler(defaultNamespace string, clusterRole bool, clientset kubernetes.Interface) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Body != nil {
+			defer r.Body.Close()
+		}
+
+		secretName := mux.Vars(r)["name"]
+		q := r.URL.Query()
+
+		lookupNamespace := defaultNamespace
+		if namespace := q.Get("namespace"); len(namespace) > 0 {
+			lookupNamespace = namespace
+		}
+
+		if q.Get("all_namespaces") == "true" {
+			if !clusterRole {
+				http.Error(w, "unable to list across namespaces without a cluster role", http.StatusForbidden)
+				return
+			}
+			lookupNamespace = metav1.NamespaceAll
+		}
+
+		if lookupNamespace == "kube-system" {
+			http.Error(w, "unable to list within the kube-system namespace", http.StatusUnauthorized)
+			return
+		}
+
+		opts := metav1.ListOptions{LabelSelector: "faas_function"}
+		res, err := clientset.AppsV1().Deployments(lookupNamespace).List(r.Context(), opts)
+		if err != nil {
+			status, reason := ProcessErrorReasons(err)
+			log.Printf("Secret impact list error reason: %s, %v\n", reason, err)
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		impact := []SecretImpact{}
+		for _, item := range res.Items {
+			if item.Namespace == "kube-system" {
+				continue
+			}
+
+			refs := k8s.SecretReferences(item.Spec.Template.Spec, secretName)
+			if len(refs) == 0 {
+				continue
+			}
+
+			impact = append(impact, SecretImpact{
+				Name:       item.Name,
+				Namespace:  item.Namespace,
+				References: refs,
+			})
+		}
+
+		sort.Slice(impact, func(i, j int) bool {
+			if impact[i].Namespace != impact[j].Namespace {
+				return impact[i].Namespace < impact[j].Namespace
+			}
+			return impact[i].Name < impact[j].Name
+		})
+
+		out, err := json.Marshal(impact)
+		if err != nil {
+			log.Printf("Secret impact json marshal error: %v\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(out)
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright 2020 OpenFaaS Author(s)
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gorilla/mux"
+	appsv1 "k8s.io/api/apps/v1"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_MakeSecretImpactHandler(t *testing.T) {
+	withSecret := func(name, namespace, secret string) *appsv1.Deployment {
+		d := &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+				Labels:    map[string]string{"faas_function": name},
+			},
+		}
+		d.Spec.Template.Spec.ImagePullSecrets = []apiv1.LocalObjectReference{{Name: secret}}
+		return d
+	}
+
+	clientset := fake.NewSimpleClientset(
+		withSecret("a", "openfaas-fn", "registry"),
+		withSecret("b", "openfaas-fn", "other"),
+		withSecret("c", "staging", "registry"),
+	)
+
+	cases := []struct {
+		name        string
+		query       string
+		clusterRole bool
+		wantStatus  int
+		want        []SecretImpact
+	}{
+		{
+			name:       "default namespace",
+			wantStatus: http.StatusOK,
+			want:       []SecretImpact{{Name: "a", Namespace: "openfaas-fn", References: []string{"imagePullSecret"}}},
+		},
+		{
+			name:        "all namespaces with a cluster role",
+			query:       "?all_namespaces=true",
+			clusterRole: true,
+			wantStatus:  http.StatusOK,
+			want: []SecretImpact{
+				{Name: "a", Namespace: "openfaas-fn", References: []string{"imagePullSecret"}},
+				{Name: "c", Namespace: "staging", References: []string{"imagePullSecret"}},
+			},
+		},
+		{
+			name:       "all namespaces without a cluster role",
+			query:      "?all_namespaces=true",
+			wantStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := MakeSecretImpactHandler("openfaas-fn", tc.clusterRole, clientset)
+
+			req := httptest.NewRequest(http.MethodGet, "/system/secrets/registry/impact"+tc.query, nil)
+			req = mux.SetURLVars(req, map[string]string{"name": "registry"})
+			rr := httptest.NewRecorder()
+
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tc.wantStatus {
+				t.Fatalf("want status: %d, got: %d", tc.wantStatus, rr.Code)
+			}
+			if tc.wantStatus != http.StatusOK {
+				return
+			}
+
+			got := []SecretImpact{}
+			if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("want: %+v, got: %+v", tc.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright 2020 OpenFaaS Author(s)
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/openfaas/faas-netes/pkg/k8s"
+)
+
+// SecretSummary describes a Secret without revealing its values
+type SecretSummary struct {
+	Name    string            `json:"name"`
+	Created time.Time         `json:"created"`
+	Labels  map[string]string `json:"labels,omitempty"`
+}
+
+// MakeSecretListHandler lists the names of the Secrets in a namespace, the values are never
+// returned. The `managed` query parameter filters for Secrets that were, or were not, created
+// by faas-netes for functions.
+func MakeSecretListHandler(defaultNamespace string, clientset kubernetes.Interface) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Body != nil {
+			defer r.Body.Close()
+		}
+
+		q := r.URL.Query()
+
+		lookupNamespace := defaultNamespace
+		if namespace := q.Get("namespace"); len(namespace) > 0 {
+			lookupNamespace = namespace
+		}
+
+		if lookupNamespace == "kube-system" {
+			http.Error(w, "unable to list within the kube-system namespace", http.StatusUnauthorized)
+			return
+		}
+
+		var managed *bool
+		if v := q.Get("managed"); len(v) > 0 {
+			parsed, err := strconv.ParseBool(v)
+			if err != nil {
+				http.Error(w, "managed must be true or false", http.StatusBadRequest)
+				return
+			}
+			managed = &parsed
+		}
+
+		res, err := clientset.CoreV1().Secrets(lookupNamespace).List(r.Context(), metav1.ListOptions{})
+		if err != nil {
+			status, reason := ProcessErrorReasons(err)
+			log.Printf("Secret list error reason: %s, %v\n", reason, err)
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		secrets := []SecretSummary{}
+		for _, item := range res.Items {
+			if managed != nil && k8s.IsManagedSecret(item.Labels) != *managed {
+				continue
+			}
+
+			secrets = append(secrets, SecretSummary{
+				Name:    item.Name,
+				Created: item.CreationTimestamp.Time,
+				Labels:  item.Labels,
+			})
+		}
+
+		sort.Slice(secrets, func(i, j int) bool {
+			return secrets[i].Name < secrets[j].Name
+		})
+
+		out, err := json.Marshal(secrets)
+		if err != nil {
+			log.Printf("Secret list json marshal error: %v\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(out)
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright 2020 OpenFaaS Author(s)
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_MakeSecretListHandler(t *testing.T) {
+	secret := func(name string, labels map[string]string) *apiv1.Secret {
+		return &apiv1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "openfaas-fn",
+				Labels:    labels,
+			},
+			Data: map[string][]byte{"value": []byte("s3cr3t")},
+		}
+	}
+
+	clientset := fake.NewSimpleClientset(
+		secret("api-key", map[string]string{"faas-netes/managed": "true"}),
+		secret("legacy", map[string]string{"app.kubernetes.io/managed-by": "openfaas"}),
+		secret("registry", nil),
+	)
+
+	cases := []struct {
+		name       string
+		query      string
+		wantStatus int
+		want       []string
+	}{
+		{
+			name:       "all secrets",
+			wantStatus: http.StatusOK,
+			want:       []string{"api-key", "legacy", "registry"},
+		},
+		{
+			name:       "managed secrets",
+			query:      "?managed=true",
+			wantStatus: http.StatusOK,
+			want:       []string{"api-key", "legacy"},
+		},
+		{
+			name:       "unmanaged secrets",
+			query:      "?managed=false",
+			wantStatus: http.StatusOK,
+			want:       []string{"registry"},
+		},
+		{
+			name:       "invalid managed filter",
+			query:      "?managed=maybe",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "kube-system is rejected",
+			query:      "?namespace=kube-system",
+			wantStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := MakeSecretListHandler("openfaas-fn", clientset)
+
+			req := httptest.NewRequest(http.MethodGet, "/system/secrets/names"+tc.query, nil)
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tc.wantStatus {
+				t.Fatalf("want status %d, got %d: %s", tc.wantStatus, rr.Code, rr.Body.String())
+			}
+			if tc.wantStatus != http.StatusOK {
+				return
+			}
+
+			if strings.Contains(rr.Body.String(), "s3cr3t") || strings.Contains(rr.Body.String(), "czNjcjN0") {
+				t.Fatalf("secret values must not be returned: %s", rr.Body.String())
+			}
+
+			var got []SecretSummary
+			if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			names := []string{}
+			for _, s := range got {
+				names = append(names, s.Name)
+			}
+			if !reflect.DeepEqual(names, tc.want) {
+				t.Errorf("want %v, got %v", tc.want, names)
+			}
+		})
+	}
+}
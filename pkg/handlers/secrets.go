@@ -5,8 +5,10 @@ package handlers
 
 import (
 	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
+	"time"
 
 	"github.com/openfaas/faas-netes/pkg/k8s"
 	types "github.com/openfaas/faas-provider/types"
@@ -14,12 +16,19 @@ import (
 )
 
 // MakeSecretHandler makes a handler for Create/List/Delete/Update of
-// secrets in the Kubernetes API
-func MakeSecretHandler(defaultNamespace string, kube kubernetes.Interface) http.HandlerFunc {
+// secrets in the Kubernetes API. Updates wait up to lockTimeout for the
+// secret's lock, a lockTimeout of 0 disables locking.
+func MakeSecretHandler(defaultNamespace string, kube kubernetes.Interface, lockTimeout time.Duration) http.HandlerFunc {
 	handler := SecretsHandler{
 		LookupNamespace: NewNamespaceResolver(defaultNamespace, kube),
 		Secrets:         k8s.NewSecretsClient(kube),
 	}
+
+	if lockTimeout > 0 {
+		lock := k8s.NewLeaseLock(kube, lockTimeout)
+		handler.Lock = &lock
+	}
+
 	return handler.ServeHTTP
 }
 
@@ -27,6 +36,9 @@ func MakeSecretHandler(defaultNamespace string, kube kubernetes.Interface) http.
 type SecretsHandler struct {
 	Secrets         k8s.SecretsClient
 	LookupNamespace NamespaceResolver
+
+	// Lock serialises updates to the same secret, when nil no lock is taken
+	Lock *k8s.LeaseLock
 }
 
 func (h SecretsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -120,6 +132,24 @@ func (h SecretsHandler) replaceSecret(namespace string, w http.ResponseWriter, r
 	}
 
 	secret.Namespace = namespace
+
+	if h.Lock != nil {
+		release, err := h.Lock.Acquire(r.Context(), namespace, k8s.SecretLeaseName(secret.Name))
+		if err != nil {
+			if err == k8s.ErrLockTimeout {
+				log.Printf("Secret %s is locked by another update\n", secret.Name)
+				http.Error(w, fmt.Sprintf("secret %s is being updated by another request", secret.Name), http.StatusLocked)
+				return
+			}
+
+			status, reason := ProcessErrorReasons(err)
+			log.Printf("Secret lock error reason: %s, %v\n", reason, err)
+			w.WriteHeader(status)
+			return
+		}
+		defer release()
+	}
+
 	err = h.Secrets.Replace(secret)
 	if err != nil {
 		status, reason := ProcessErrorReasons(err)
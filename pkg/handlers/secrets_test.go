@@ -13,7 +13,9 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/openfaas/faas-netes/pkg/k8s"
 	types "github.com/openfaas/faas-provider/types"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -26,7 +28,7 @@ const secretLabelValue = "openfaas"
 func Test_SecretsHandler(t *testing.T) {
 	namespace := "of-fnc"
 	kube := testclient.NewSimpleClientset()
-	secretsHandler := MakeSecretHandler(namespace, kube).ServeHTTP
+	secretsHandler := MakeSecretHandler(namespace, kube, 5*time.Second).ServeHTTP
 	secretName := "testsecret"
 
 	t.Run("create managed secrets", func(t *testing.T) {
@@ -274,7 +276,7 @@ func Test_SecretsHandler(t *testing.T) {
 func Test_SecretsHandler_ListEmpty(t *testing.T) {
 	namespace := "of-fnc"
 	kube := testclient.NewSimpleClientset()
-	secretsHandler := MakeSecretHandler(namespace, kube).ServeHTTP
+	secretsHandler := MakeSecretHandler(namespace, kube, 5*time.Second).ServeHTTP
 
 	req := httptest.NewRequest("GET", "http://example.com/foo", nil)
 	w := httptest.NewRecorder()
@@ -373,3 +375,27 @@ func Test_NamespaceResolver(t *testing.T) {
 		}
 	})
 }
+
+func Test_SecretsHandler_ReplaceLockedSecret(t *testing.T) {
+	namespace := "of-fnc"
+	kube := testclient.NewSimpleClientset()
+
+	lock := k8s.NewLeaseLock(kube, time.Second)
+	release, err := lock.Acquire(context.TODO(), namespace, k8s.SecretLeaseName("testsecret"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer release()
+
+	secretsHandler := MakeSecretHandler(namespace, kube, 200*time.Millisecond).ServeHTTP
+
+	payload := `{"name": "testsecret", "value": "newvalue"}`
+	req := httptest.NewRequest(http.MethodPut, "http://example.com/foo", strings.NewReader(payload))
+	w := httptest.NewRecorder()
+
+	secretsHandler(w, req)
+
+	if w.Code != http.StatusLocked {
+		t.Errorf("want status code '%d', got '%d'", http.StatusLocked, w.Code)
+	}
+}
@@ -0,0 +1,304 @@
+// Copyright 2020 OpenFaaS Author(s)
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/openfaas/faas-netes/pkg/k8s"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// StagingLabel marks a function as a staging clone, created by MakeStagingCloneHandler
+	StagingLabel = "faas-netes/staging"
+
+	// StagingSourceAnnotationKey records the function that a staging clone was created from
+	StagingSourceAnnotationKey = "com.openfaas.staging.source"
+
+	// stagingSuffix is appended to the name of the function to name its clone
+	stagingSuffix = "-staging"
+)
+
+// StagingFunction is the staging clone of a function
+type StagingFunction struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Source    string `json:"source"`
+
+	// URL is the path of the clone on the gateway
+	URL string `json:"url"`
+}
+
+// StagingCleanupResult lists the staging functions that were deleted
+type StagingCleanupResult struct {
+	Deleted []string `json:"deleted"`
+}
+
+// MakeStagingCloneHandler creates a copy of a function named with a -staging suffix, so that it
+// can be tested without affecting the original. The clone has a single replica and the
+// StagingLabel, it is not kept in sync with the original. The clone counts against the
+// function quota of the namespace.
+func MakeStagingCloneHandler(defaultNamespace string, factory k8s.FunctionFactory, maxNameLength int) http.HandlerFunc {
+	clientset := factory.Client
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Body != nil {
+			defer r.Body.Close()
+		}
+
+		functionName := mux.Vars(r)["name"]
+
+		lookupNamespace := defaultNamespace
+		if namespace := r.URL.Query().Get("namespace"); len(namespace) > 0 {
+			lookupNamespace = namespace
+		}
+
+		if lookupNamespace == "kube-system" {
+			http.Error(w, "unable to list within the kube-system namespace", http.StatusUnauthorized)
+			return
+		}
+
+		stagingName := functionName + stagingSuffix
+		if err := ValidateFunctionNameLength(stagingName, maxNameLength); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		ctx := r.Context()
+
+		deployment, err := clientset.AppsV1().Deployments(lookupNamespace).Get(ctx, functionName, metav1.GetOptions{})
+		if err == nil && !isFunction(deployment) {
+			err = k8serrors.NewNotFound(appsv1.Resource("deployments"), functionName)
+		}
+		if err != nil {
+			status, reason := ProcessErrorReasons(err)
+			log.Printf("Function staging clone lookup error reason: %s, %v\n", reason, err)
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		if deployment.Labels[StagingLabel] == "true" {
+			http.Error(w, fmt.Sprintf("function %s is already a staging function", functionName), http.StatusBadRequest)
+			return
+		}
+
+		service, err := clientset.CoreV1().Services(lookupNamespace).Get(ctx, functionName, metav1.GetOptions{})
+		if err != nil {
+			status, reason := ProcessErrorReasons(err)
+			log.Printf("Function staging clone lookup error reason: %s, %v\n", reason, err)
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		if err := factory.CheckFunctionQuota(ctx, lookupNamespace); err != nil {
+			var quotaErr *k8s.QuotaExceededError
+			if errors.As(err, &quotaErr) {
+				http.Error(w, err.Error(), http.StatusTooManyRequests)
+				return
+			}
+			log.Printf("Function staging clone quota error: %v\n", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		_, err = clientset.AppsV1().Deployments(lookupNamespace).Create(ctx, stagingDeployment(deployment, stagingName), metav1.CreateOptions{})
+		if err != nil {
+			status, reason := ProcessErrorReasons(err)
+			log.Printf("Function staging clone error reason: %s, %v\n", reason, err)
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		_, err = clientset.CoreV1().Services(lookupNamespace).Create(ctx, stagingService(service, stagingName), metav1.CreateOptions{})
+		if err != nil {
+			log.Printf("Function staging clone Service error: %v\n", err)
+			deleteStagingFunction(ctx, clientset, lookupNamespace, stagingName)
+
+			status, _ := ProcessErrorReasons(err)
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		log.Printf("Staging clone created: %s.%s from %s\n", stagingName, lookupNamespace, functionName)
+
+		out, err := json.Marshal(StagingFunction{
+			Name:      stagingName,
+			Namespace: lookupNamespace,
+			Source:    functionName,
+			URL:       fmt.Sprintf("/function/%s.%s", stagingName, lookupNamespace),
+		})
+		if err != nil {
+			log.Printf("Function staging clone json marshal error: %v\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write(out)
+	}
+}
+
+// MakeStagingCleanupHandler deletes the staging functions in the namespace that were created
+// longer ago than ttl
+func MakeStagingCleanupHandler(defaultNamespace string, clientset kubernetes.Interface, ttl time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Body != nil {
+			defer r.Body.Close()
+		}
+
+		lookupNamespace := defaultNamespace
+		if namespace := r.URL.Query().Get("namespace"); len(namespace) > 0 {
+			lookupNamespace = namespace
+		}
+
+		if lookupNamespace == "kube-system" {
+			http.Error(w, "unable to list within the kube-system namespace", http.StatusUnauthorized)
+			return
+		}
+
+		ctx := r.Context()
+
+		res, err := clientset.AppsV1().Deployments(lookupNamespace).List(ctx, metav1.ListOptions{
+			LabelSelector: StagingLabel + "=true",
+		})
+		if err != nil {
+			status, reason := ProcessErrorReasons(err)
+			log.Printf("Staging cleanup list error reason: %s, %v\n", reason, err)
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		result := StagingCleanupResult{Deleted: []string{}}
+		for _, item := range res.Items {
+			if time.Since(item.CreationTimestamp.Time) < ttl {
+				continue
+			}
+
+			if err := deleteStagingFunction(ctx, clientset, lookupNamespace, item.Name); err != nil {
+				log.Printf("Staging cleanup error for %s.%s: %v\n", item.Name, lookupNamespace, err)
+				continue
+			}
+			result.Deleted = append(result.Deleted, item.Name)
+		}
+
+		log.Printf("Staging cleanup: deleted %d functions in %s\n", len(result.Deleted), lookupNamespace)
+
+		out, err := json.Marshal(result)
+		if err != nil {
+			log.Printf("Staging cleanup json marshal error: %v\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(out)
+	}
+}
+
+// stagingDeployment copies the Deployment of a function for its staging clone. Annotations
+// that tie the Deployment to the original, such as its TLS domain, are not copied.
+func stagingDeployment(source *appsv1.Deployment, name string) *appsv1.Deployment {
+	labels := stagingLabels(source.Labels, name)
+
+	annotations := map[string]string{}
+	for k, v := range source.Annotations {
+		annotations[k] = v
+	}
+	for _, key := range []string{k8s.TLSDomainAnnotationKey, k8s.SpecChecksumAnnotationKey, FunctionFileAnnotationKey} {
+		delete(annotations, key)
+	}
+	annotations[StagingSourceAnnotationKey] = source.Name
+
+	spec := source.Spec.DeepCopy()
+	spec.Replicas = int32p(1)
+	spec.Selector = &metav1.LabelSelector{
+		MatchLabels: map[string]string{"faas_function": name},
+	}
+	spec.Template.Labels = stagingLabels(spec.Template.Labels, name)
+	for i := range spec.Template.Spec.Containers {
+		if spec.Template.Spec.Containers[i].Name == source.Name {
+			spec.Template.Spec.Containers[i].Name = name
+		}
+	}
+
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   source.Namespace,
+			Labels:      labels,
+			Annotations: annotations,
+		},
+		Spec: *spec,
+	}
+	k8s.SetSpecChecksum(deployment)
+
+	return deployment
+}
+
+// stagingService copies the Service of a function for its staging clone
+func stagingService(source *corev1.Service, name string) *corev1.Service {
+	annotations := map[string]string{}
+	for k, v := range source.Annotations {
+		annotations[k] = v
+	}
+
+	ports := make([]corev1.ServicePort, len(source.Spec.Ports))
+	copy(ports, source.Spec.Ports)
+
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   source.Namespace,
+			Labels:      stagingLabels(source.Labels, name),
+			Annotations: annotations,
+		},
+		Spec: corev1.ServiceSpec{
+			Type:     corev1.ServiceTypeClusterIP,
+			Selector: map[string]string{"faas_function": name},
+			Ports:    ports,
+		},
+	}
+}
+
+func stagingLabels(source map[string]string, name string) map[string]string {
+	labels := map[string]string{}
+	for k, v := range source {
+		labels[k] = v
+	}
+	labels["faas_function"] = name
+	labels[StagingLabel] = "true"
+	return labels
+}
+
+// deleteStagingFunction removes the Deployment and Service of a staging function
+func deleteStagingFunction(ctx context.Context, clientset kubernetes.Interface, namespace, name string) error {
+	foreground := metav1.DeletePropagationForeground
+	opts := metav1.DeleteOptions{PropagationPolicy: &foreground}
+
+	err := clientset.AppsV1().Deployments(namespace).Delete(ctx, name, opts)
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return err
+	}
+
+	err = clientset.CoreV1().Services(namespace).Delete(ctx, name, opts)
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,171 @@
+// Copyright 2020 OpenFaaS Author(s)
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/openfaas/faas-netes/pkg/k8s"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_MakeStagingCloneHandler(t *testing.T) {
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "nodeinfo",
+			Namespace: "openfaas-fn",
+			Labels:    map[string]string{"faas_function": "nodeinfo"},
+			Annotations: map[string]string{
+				k8s.TLSDomainAnnotationKey: "nodeinfo.example.com",
+				"com.openfaas.profile":     "gpu",
+			},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: int32p(5),
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"faas_function": "nodeinfo"}},
+		},
+	}
+	deployment.Spec.Template.Labels = map[string]string{"faas_function": "nodeinfo", "team": "a"}
+	deployment.Spec.Template.Spec.Containers = []corev1.Container{{Name: "nodeinfo", Image: "functions/nodeinfo:1.0"}}
+
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "nodeinfo", Namespace: "openfaas-fn"},
+		Spec: corev1.ServiceSpec{
+			ClusterIP: "10.0.0.1",
+			Selector:  map[string]string{"faas_function": "nodeinfo"},
+			Ports:     []corev1.ServicePort{{Name: "http", Port: 8080}},
+		},
+	}
+
+	client := fake.NewSimpleClientset(deployment, service)
+	handler := MakeStagingCloneHandler("openfaas-fn", k8s.NewFunctionFactory(client, k8s.DeploymentConfig{}, nil), 63)
+
+	clone := func(name string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/system/function/"+name+"/staging", nil)
+		req = mux.SetURLVars(req, map[string]string{"name": name})
+		rr := httptest.NewRecorder()
+		handler(rr, req)
+		return rr
+	}
+
+	rr := clone("nodeinfo")
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("want status: %d, got: %d, body: %s", http.StatusCreated, rr.Code, rr.Body.String())
+	}
+
+	got := StagingFunction{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	want := StagingFunction{Name: "nodeinfo-staging", Namespace: "openfaas-fn", Source: "nodeinfo", URL: "/function/nodeinfo-staging.openfaas-fn"}
+	if got != want {
+		t.Errorf("want: %+v, got: %+v", want, got)
+	}
+
+	staging, err := client.AppsV1().Deployments("openfaas-fn").Get(context.Background(), "nodeinfo-staging", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if *staging.Spec.Replicas != 1 {
+		t.Errorf("want 1 replica, got: %d", *staging.Spec.Replicas)
+	}
+	if staging.Labels[StagingLabel] != "true" || staging.Spec.Template.Labels[StagingLabel] != "true" {
+		t.Errorf("want the %s label on the Deployment and Pods", StagingLabel)
+	}
+	if staging.Spec.Template.Labels["faas_function"] != "nodeinfo-staging" || staging.Spec.Selector.MatchLabels["faas_function"] != "nodeinfo-staging" {
+		t.Errorf("want the clone to select its own Pods, got: %v", staging.Spec.Selector.MatchLabels)
+	}
+	if staging.Spec.Template.Spec.Containers[0].Name != "nodeinfo-staging" {
+		t.Errorf("want the function container to be renamed, got: %s", staging.Spec.Template.Spec.Containers[0].Name)
+	}
+	if _, ok := staging.Annotations[k8s.TLSDomainAnnotationKey]; ok {
+		t.Errorf("want the TLS domain not to be copied")
+	}
+	if staging.Annotations[StagingSourceAnnotationKey] != "nodeinfo" {
+		t.Errorf("want the source annotation, got: %v", staging.Annotations)
+	}
+	if *deployment.Spec.Replicas != 5 {
+		t.Errorf("want the original Deployment to be unchanged")
+	}
+
+	stagingService, err := client.CoreV1().Services("openfaas-fn").Get(context.Background(), "nodeinfo-staging", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if stagingService.Spec.ClusterIP != "" || stagingService.Spec.Selector["faas_function"] != "nodeinfo-staging" {
+		t.Errorf("want a new ClusterIP Service for the clone, got: %+v", stagingService.Spec)
+	}
+
+	if rr := clone("nodeinfo"); rr.Code != http.StatusConflict {
+		t.Errorf("want status: %d for an existing clone, got: %d", http.StatusConflict, rr.Code)
+	}
+	if rr := clone("nodeinfo-staging"); rr.Code != http.StatusBadRequest {
+		t.Errorf("want status: %d to clone a clone, got: %d", http.StatusBadRequest, rr.Code)
+	}
+	if rr := clone("env"); rr.Code != http.StatusNotFound {
+		t.Errorf("want status: %d for a missing function, got: %d", http.StatusNotFound, rr.Code)
+	}
+
+	quotaClient := fake.NewSimpleClientset(deployment, service)
+	handler = MakeStagingCloneHandler("openfaas-fn", k8s.NewFunctionFactory(quotaClient, k8s.DeploymentConfig{MaxFunctionsPerNamespace: 1}, nil), 63)
+	if rr := clone("nodeinfo"); rr.Code != http.StatusTooManyRequests {
+		t.Errorf("want status: %d over the function quota, got: %d", http.StatusTooManyRequests, rr.Code)
+	}
+	if _, err := quotaClient.AppsV1().Deployments("openfaas-fn").Get(context.Background(), "nodeinfo-staging", metav1.GetOptions{}); !k8serrors.IsNotFound(err) {
+		t.Errorf("want no clone over the function quota, got: %v", err)
+	}
+}
+
+func Test_MakeStagingCleanupHandler(t *testing.T) {
+	newStaging := func(name string, age time.Duration) *appsv1.Deployment {
+		return &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         "openfaas-fn",
+			Labels:            map[string]string{"faas_function": name, StagingLabel: "true"},
+			CreationTimestamp: metav1.NewTime(time.Now().Add(-age)),
+		}}
+	}
+
+	client := fake.NewSimpleClientset(
+		newStaging("old-staging", 48*time.Hour),
+		newStaging("new-staging", time.Hour),
+		&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "old-staging", Namespace: "openfaas-fn"}},
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{
+			Name:              "nodeinfo",
+			Namespace:         "openfaas-fn",
+			Labels:            map[string]string{"faas_function": "nodeinfo"},
+			CreationTimestamp: metav1.NewTime(time.Now().Add(-72 * time.Hour)),
+		}},
+	)
+
+	handler := MakeStagingCleanupHandler("openfaas-fn", client, 24*time.Hour)
+
+	req := httptest.NewRequest(http.MethodDelete, "/system/functions/staging", nil)
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("want status: %d, got: %d, body: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+
+	result := StagingCleanupResult{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &result); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(result.Deleted) != 1 || result.Deleted[0] != "old-staging" {
+		t.Errorf("want only old-staging to be deleted, got: %v", result.Deleted)
+	}
+
+	assertFunctionNames(t, client, []string{"new-staging", "nodeinfo"})
+}
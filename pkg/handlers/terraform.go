@@ -0,0 +1,180 @@
+// Copyright 2020 OpenFaaS Author(s)
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"regexp"
+	"sort"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/openfaas/faas-netes/pkg/k8s"
+	"github.com/zclconf/go-cty/cty"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	v1 "k8s.io/client-go/listers/apps/v1"
+)
+
+// DefaultTerraformProviderSource is the registry address of the OpenFaaS Terraform provider
+const DefaultTerraformProviderSource = "ewilde/openfaas"
+
+// terraformIdentifier matches the characters that are valid in a Terraform resource name
+var terraformIdentifier = regexp.MustCompile(`[^A-Za-z0-9_-]`)
+
+// terraformIgnoredLabels are added to every function by faas-netes and are not part of the
+// function definition
+var terraformIgnoredLabels = map[string]bool{
+	"faas_function": true,
+	"uid":           true,
+}
+
+// MakeTerraformExportHandler writes the functions of a namespace as `openfaas_function`
+// resources in Terraform HCL, so that functions which were deployed by hand can be brought
+// under infrastructure-as-code. The provider source address in the `terraform` block can be
+// overridden with the `provider_source` query parameter.
+func MakeTerraformExportHandler(defaultNamespace string, deploymentLister v1.DeploymentLister) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Body != nil {
+			defer r.Body.Close()
+		}
+
+		q := r.URL.Query()
+
+		lookupNamespace := defaultNamespace
+		if namespace := q.Get("namespace"); len(namespace) > 0 {
+			lookupNamespace = namespace
+		}
+
+		if lookupNamespace == "kube-system" {
+			http.Error(w, "unable to list within the kube-system namespace", http.StatusUnauthorized)
+			return
+		}
+
+		providerSource := q.Get("provider_source")
+		if len(providerSource) == 0 {
+			providerSource = DefaultTerraformProviderSource
+		}
+
+		req, err := labels.NewRequirement("faas_function", selection.Exists, []string{})
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		deployments, err := deploymentLister.Deployments(lookupNamespace).List(labels.NewSelector().Add(*req))
+		if err != nil {
+			log.Printf("Terraform export error: %v\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(err.Error()))
+			return
+		}
+
+		sort.Slice(deployments, func(i, j int) bool {
+			return deployments[i].Name < deployments[j].Name
+		})
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		w.Write(terraformHCL(providerSource, deployments))
+	}
+}
+
+// terraformHCL renders the terraform block and a resource for each function Deployment
+func terraformHCL(providerSource string, deployments []*appsv1.Deployment) []byte {
+	file := hclwrite.NewEmptyFile()
+	body := file.Body()
+
+	providers := body.AppendNewBlock("terraform", nil).Body().AppendNewBlock("required_providers", nil)
+	providers.Body().SetAttributeValue("openfaas", cty.ObjectVal(map[string]cty.Value{
+		"source": cty.StringVal(providerSource),
+	}))
+
+	for _, item := range deployments {
+		if item == nil || len(item.Spec.Template.Spec.Containers) == 0 {
+			continue
+		}
+
+		function := k8s.AsFunctionStatus(*item)
+
+		body.AppendNewline()
+		resource := body.AppendNewBlock("resource", []string{"openfaas_function", terraformResourceName(item.Name)}).Body()
+		resource.SetAttributeValue("name", cty.StringVal(function.Name))
+		resource.SetAttributeValue("image", cty.StringVal(function.Image))
+		if len(function.EnvProcess) > 0 {
+			resource.SetAttributeValue("f_process", cty.StringVal(function.EnvProcess))
+		}
+
+		functionLabels := map[string]string{}
+		if function.Labels != nil {
+			for k, v := range *function.Labels {
+				if !terraformIgnoredLabels[k] {
+					functionLabels[k] = v
+				}
+			}
+		}
+		setHCLMap(resource, "labels", functionLabels)
+
+		if item.Annotations != nil {
+			setHCLMap(resource, "annotations", item.Annotations)
+		}
+
+		envVars := map[string]string{}
+		for _, env := range item.Spec.Template.Spec.Containers[0].Env {
+			if env.Name != k8s.EnvProcessName && env.ValueFrom == nil {
+				envVars[env.Name] = env.Value
+			}
+		}
+		setHCLMap(resource, "env_vars", envVars)
+
+		if len(function.Secrets) > 0 {
+			secrets := make([]cty.Value, len(function.Secrets))
+			for i, s := range function.Secrets {
+				secrets[i] = cty.StringVal(s)
+			}
+			resource.SetAttributeValue("secrets", cty.ListVal(secrets))
+		}
+
+		if function.Limits != nil {
+			appendHCLResources(resource, "limits", function.Limits.Memory, function.Limits.CPU)
+		}
+		if function.Requests != nil {
+			appendHCLResources(resource, "requests", function.Requests.Memory, function.Requests.CPU)
+		}
+	}
+
+	return hclwrite.Format(file.Bytes())
+}
+
+// setHCLMap sets a map attribute, hclwrite sorts the keys, nothing is set for an empty map
+func setHCLMap(body *hclwrite.Body, name string, values map[string]string) {
+	if len(values) == 0 {
+		return
+	}
+
+	attribute := map[string]cty.Value{}
+	for k, v := range values {
+		attribute[k] = cty.StringVal(v)
+	}
+	body.SetAttributeValue(name, cty.MapVal(attribute))
+}
+
+// appendHCLResources appends a limits or requests block
+func appendHCLResources(body *hclwrite.Body, name, memory, cpu string) {
+	body.AppendNewline()
+	block := body.AppendNewBlock(name, nil).Body()
+	block.SetAttributeValue("memory", cty.StringVal(memory))
+	block.SetAttributeValue("cpu", cty.StringVal(cpu))
+}
+
+// terraformResourceName converts a function name into a valid resource name, which may not
+// start with a digit
+func terraformResourceName(name string) string {
+	name = terraformIdentifier.ReplaceAllString(name, "_")
+	if len(name) == 0 || (name[0] >= '0' && name[0] <= '9') {
+		name = "fn_" + name
+	}
+	return name
+}
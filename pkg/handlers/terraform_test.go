@@ -0,0 +1,114 @@
+// Copyright 2020 OpenFaaS Author(s)
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_MakeTerraformExportHandler(t *testing.T) {
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "1nodeinfo",
+			Namespace:   "openfaas-fn",
+			Labels:      map[string]string{"faas_function": "1nodeinfo"},
+			Annotations: map[string]string{"topic": "cron-function"},
+		},
+	}
+	deployment.Spec.Template.Labels = map[string]string{"faas_function": "1nodeinfo", "uid": "123", "team": "a"}
+	deployment.Spec.Template.Spec.Containers = []corev1.Container{{
+		Name:  "1nodeinfo",
+		Image: "functions/nodeinfo:latest",
+		Env: []corev1.EnvVar{
+			{Name: "fprocess", Value: "node main.js"},
+			{Name: "greeting", Value: "hello ${name}"},
+		},
+	}}
+
+	handler := MakeTerraformExportHandler("openfaas-fn", newTestDeploymentLister(t, deployment))
+
+	req := httptest.NewRequest(http.MethodGet, "/system/functions/terraform?provider_source=example.com/openfaas", nil)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("want status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+
+	body := rr.Body.String()
+	for _, want := range []string{
+		`source = "example.com/openfaas"`,
+		`resource "openfaas_function" "fn_1nodeinfo" {`,
+		`name      = "1nodeinfo"`,
+		`image     = "functions/nodeinfo:latest"`,
+		`f_process = "node main.js"`,
+		`team = "a"`,
+		`topic = "cron-function"`,
+		`greeting = "hello $${name}"`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("want output to contain %q, got:\n%s", want, body)
+		}
+	}
+
+	for _, unwanted := range []string{`uid`, `fprocess`} {
+		if strings.Contains(body, unwanted) {
+			t.Errorf("want output not to contain %q, got:\n%s", unwanted, body)
+		}
+	}
+}
+
+func Test_terraformHCL_EscapesValues(t *testing.T) {
+	values := map[string]string{
+		"quote":      `quote " and \`,
+		"newline":    "line\nbreak",
+		"template":   "${var} and %{if}",
+		"dollar":     "cost $5",
+		"dotted.key": "value",
+	}
+
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "env", Namespace: "openfaas-fn"},
+	}
+	deployment.Spec.Template.Spec.Containers = []corev1.Container{{Name: "env", Image: "functions/alpine:latest"}}
+	for k, v := range values {
+		deployment.Spec.Template.Spec.Containers[0].Env = append(deployment.Spec.Template.Spec.Containers[0].Env, corev1.EnvVar{Name: k, Value: v})
+	}
+
+	out := terraformHCL(`registry "quoted"`, []*appsv1.Deployment{deployment})
+
+	file, diags := hclsyntax.ParseConfig(out, "main.tf", hcl.InitialPos)
+	if diags.HasErrors() {
+		t.Fatalf("want valid HCL, got: %s\n%s", diags.Error(), out)
+	}
+
+	var resource *hclsyntax.Block
+	for _, block := range file.Body.(*hclsyntax.Body).Blocks {
+		if block.Type == "resource" {
+			resource = block
+		}
+	}
+	if resource == nil {
+		t.Fatalf("want a resource block, got:\n%s", out)
+	}
+
+	envVars, diags := resource.Body.Attributes["env_vars"].Expr.Value(nil)
+	if diags.HasErrors() {
+		t.Fatalf("want env_vars without interpolation, got: %s", diags.Error())
+	}
+	for k, want := range values {
+		if got := envVars.GetAttr(k).AsString(); got != want {
+			t.Errorf("%s: want: %q, got: %q", k, want, got)
+		}
+	}
+}
@@ -15,7 +15,12 @@ import (
 	"github.com/openfaas/faas-netes/pkg/k8s"
 
 	types "github.com/openfaas/faas-provider/types"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
 )
 
 // MakeUpdateHandler update specified function
@@ -36,6 +41,16 @@ func MakeUpdateHandler(defaultNamespace string, factory k8s.FunctionFactory) htt
 			return
 		}
 
+		if err := ValidateFunctionNameLength(request.Service, factory.Config.MaxFunctionNameLength); err != nil {
+			writeErrorCode(w, http.StatusBadRequest, FunctionNameTooLong, err)
+			return
+		}
+
+		if err := ValidateImagePolicy(request.Image, factory.Config.ImageNameRegex); err != nil {
+			writeErrorCode(w, http.StatusBadRequest, ImagePolicyViolation, err)
+			return
+		}
+
 		lookupNamespace := defaultNamespace
 		if len(request.Namespace) > 0 {
 			lookupNamespace = request.Namespace
@@ -46,12 +61,34 @@ func MakeUpdateHandler(defaultNamespace string, factory k8s.FunctionFactory) htt
 			return
 		}
 
+		if factory.Breaker.IsOpen() {
+			http.Error(w, k8s.ErrCircuitOpen.Error(), http.StatusServiceUnavailable)
+			return
+		}
+
+		withDefaults := factory.WithDefaultAnnotations(request.Annotations)
+		request.Annotations = &withDefaults
+
 		annotations := buildAnnotations(request)
-		if err, status := updateDeploymentSpec(ctx, lookupNamespace, factory, request, annotations); err != nil {
+		serviceAnnotations, err := k8s.ServiceAnnotations(annotations)
+		if err != nil {
+			wrappedErr := fmt.Errorf("unable update Service: %s.%s, error: %s", request.Service, lookupNamespace, err.Error())
+			http.Error(w, wrappedErr.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if invalid := validateFunctionAnnotations(factory, request.Service, annotations); invalid != nil {
+			wrappedErr := fmt.Errorf("unable update %s: %s.%s, error: %s", invalid.resource, request.Service, lookupNamespace, invalid.Error())
+			http.Error(w, wrappedErr.Error(), http.StatusBadRequest)
+			return
+		}
+
+		err, status := retryOnConflict(factory.Config.UpdateConflictRetries, func() (error, int) {
+			return updateDeploymentSpec(ctx, lookupNamespace, factory, request, annotations)
+		})
+		if err != nil {
 			if !k8s.IsNotFound(err) {
 				log.Printf("error updating deployment: %s.%s, error: %s\n", request.Service, lookupNamespace, err)
-
-				return
 			}
 
 			wrappedErr := fmt.Errorf("unable update Deployment: %s.%s, error: %s", request.Service, lookupNamespace, err.Error())
@@ -59,7 +96,10 @@ func MakeUpdateHandler(defaultNamespace string, factory k8s.FunctionFactory) htt
 			return
 		}
 
-		if err, status := updateService(lookupNamespace, factory, request, annotations); err != nil {
+		err, status = retryOnConflict(factory.Config.UpdateConflictRetries, func() (error, int) {
+			return updateService(lookupNamespace, factory, request, serviceAnnotations)
+		})
+		if err != nil {
 			if !k8s.IsNotFound(err) {
 				log.Printf("error updating service: %s.%s, error: %s\n", request.Service, lookupNamespace, err)
 			}
@@ -69,8 +109,60 @@ func MakeUpdateHandler(defaultNamespace string, factory k8s.FunctionFactory) htt
 			return
 		}
 
-		w.WriteHeader(http.StatusAccepted)
+		if r.URL.Query().Get("wait") != "true" {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+
+		rollout, err := waitForRollout(ctx, factory.Client, lookupNamespace, request.Service, updateWaitInterval, factory.Config.UpdateWaitTimeout)
+		if err == wait.ErrWaitTimeout {
+			http.Error(w, fmt.Sprintf("rollout of %s.%s did not complete within %s", request.Service, lookupNamespace, factory.Config.UpdateWaitTimeout), http.StatusRequestTimeout)
+			return
+		}
+		if err != nil {
+			status, reason := ProcessErrorReasons(err)
+			log.Printf("Function rollout status error reason: %s, %v\n", reason, err)
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		out, err := json.Marshal(rollout)
+		if err != nil {
+			log.Printf("Function rollout status json marshal error: %v\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(out)
+	}
+}
+
+// updateWaitInterval is how often the Deployment is read while waiting for its rollout
+const updateWaitInterval = time.Second * 2
+
+// waitForRollout polls the Deployment of a function until the controller has observed the
+// update and all of its replicas run the new version, or until the timeout elapses, in which
+// case wait.ErrWaitTimeout is returned.
+func waitForRollout(ctx context.Context, client kubernetes.Interface, namespace, name string, interval, timeout time.Duration) (*appsv1.DeploymentStatus, error) {
+	var status appsv1.DeploymentStatus
+
+	err := wait.PollImmediate(interval, timeout, func() (bool, error) {
+		deployment, err := client.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		status = deployment.Status
+
+		return status.ObservedGeneration >= deployment.Generation &&
+			status.UpdatedReplicas == status.Replicas, nil
+	})
+	if err != nil {
+		return nil, err
 	}
+
+	return &status, nil
 }
 
 func updateDeploymentSpec(
@@ -82,14 +174,21 @@ func updateDeploymentSpec(
 
 	getOpts := metav1.GetOptions{}
 
-	deployment, findDeployErr := factory.Client.AppsV1().
-		Deployments(functionNamespace).
-		Get(context.TODO(), request.Service, getOpts)
+	var deployment *appsv1.Deployment
+	findDeployErr := factory.Breaker.Do(func() error {
+		var err error
+		deployment, err = factory.Client.AppsV1().
+			Deployments(functionNamespace).
+			Get(context.TODO(), request.Service, getOpts)
+		return err
+	})
 
 	if findDeployErr != nil {
-		return findDeployErr, http.StatusNotFound
+		return findDeployErr, circuitStatus(findDeployErr, http.StatusNotFound)
 	}
 
+	previousDomain := deployment.Annotations[k8s.TLSDomainAnnotationKey]
+
 	if len(deployment.Spec.Template.Spec.Containers) > 0 {
 		deployment.Spec.Template.Spec.Containers[0].Image = request.Image
 
@@ -99,11 +198,35 @@ func updateDeploymentSpec(
 		//deployment.Spec.Template.Spec.Containers[0].ImagePullPolicy = v1.PullAlways
 
 		deployment.Spec.Template.Spec.Containers[0].Env = buildEnvVars(&request)
+		if err := factory.ConfigureDownwardAPI(annotations, deployment); err != nil {
+			return err, http.StatusBadRequest
+		}
+
+		if err := factory.ConfigureWorkingDir(annotations, deployment); err != nil {
+			return err, http.StatusBadRequest
+		}
+
+		if err := factory.ConfigureSchedulerName(annotations, deployment); err != nil {
+			return err, http.StatusBadRequest
+		}
+
+		if err := factory.ConfigureDNSPolicy(annotations, deployment); err != nil {
+			return err, http.StatusBadRequest
+		}
 
 		factory.ConfigureReadOnlyRootFilesystem(request, deployment)
 		factory.ConfigureContainerUserID(deployment)
+		if err := factory.ConfigureRunAsIDs(annotations, deployment); err != nil {
+			return err, http.StatusBadRequest
+		}
+		if err := factory.ConfigurePortName(annotations, deployment); err != nil {
+			return err, http.StatusBadRequest
+		}
 
 		deployment.Spec.Template.Spec.NodeSelector = createSelector(request.Constraints)
+		if err := factory.ConfigureNodePool(annotations, deployment); err != nil {
+			return err, http.StatusBadRequest
+		}
 
 		labels := map[string]string{
 			"faas_function": request.Service,
@@ -111,7 +234,7 @@ func updateDeploymentSpec(
 		}
 
 		if request.Labels != nil {
-			if min := getMinReplicaCount(*request.Labels); min != nil {
+			if min := getMinReplicaCount(*request.Labels); min != nil && !k8s.IsPaused(deployment.Annotations) {
 				deployment.Spec.Replicas = min
 			}
 
@@ -121,14 +244,13 @@ func updateDeploymentSpec(
 		}
 
 		// deployment.Labels = labels
-		deployment.Spec.Template.ObjectMeta.Labels = labels
+		deployment.Spec.Template.ObjectMeta.Labels = factory.PodLabels(functionNamespace, labels)
 
 		// store the current annotations so that we can diff the annotations
 		// and determine which profiles need to be removed
 		currentAnnotations := deployment.Annotations
-		deployment.Annotations = annotations
-		deployment.Spec.Template.Annotations = annotations
-		deployment.Spec.Template.ObjectMeta.Annotations = annotations
+		deployment.Annotations = k8s.CopyCordonAnnotation(currentAnnotations, k8s.CopyPauseAnnotations(currentAnnotations, annotations))
+		deployment.Spec.Template.ObjectMeta.Annotations = factory.PodAnnotations(annotations)
 
 		resources, resourceErr := createResources(request)
 		if resourceErr != nil {
@@ -137,6 +259,10 @@ func updateDeploymentSpec(
 
 		deployment.Spec.Template.Spec.Containers[0].Resources = *resources
 
+		if err := factory.ConfigureExtendedResources(annotations, deployment); err != nil {
+			return err, http.StatusBadRequest
+		}
+
 		secrets := k8s.NewSecretsClient(factory.Client)
 		existingSecrets, err := secrets.GetSecrets(functionNamespace, request.Secrets)
 		if err != nil {
@@ -149,6 +275,10 @@ func updateDeploymentSpec(
 			return err, http.StatusBadRequest
 		}
 
+		if err := factory.ConfigureCacheVolume(annotations, deployment); err != nil {
+			return err, http.StatusBadRequest
+		}
+
 		probes, err := factory.MakeProbes(request)
 		if err != nil {
 			return err, http.StatusBadRequest
@@ -157,11 +287,20 @@ func updateDeploymentSpec(
 		deployment.Spec.Template.Spec.Containers[0].LivenessProbe = probes.Liveness
 		deployment.Spec.Template.Spec.Containers[0].ReadinessProbe = probes.Readiness
 
+		progressDeadlineSeconds, err := factory.ProgressDeadlineSeconds(annotations)
+		if err != nil {
+			return err, http.StatusBadRequest
+		}
+		deployment.Spec.ProgressDeadlineSeconds = progressDeadlineSeconds
+
+		// remove the zone pin so that the Affinity can be compared to the Profiles
+		// that are being removed, it is added back once the Profiles are applied
+		factory.RemoveZone(currentAnnotations[k8s.ZoneAnnotationKey], deployment)
+
 		// compare the annotations from args to the cache copy of the deployment annotations
 		// at this point we have already updated the annotations to the new value, if we
 		// compare to that it will produce an empty list
-		profileNamespace := factory.Config.ProfilesNamespace
-		profileList, err := factory.GetProfilesToRemove(ctx, profileNamespace, annotations, currentAnnotations)
+		profileList, err := factory.GetProfilesToRemove(ctx, functionNamespace, annotations, currentAnnotations)
 		if err != nil {
 			return err, http.StatusBadRequest
 		}
@@ -169,20 +308,70 @@ func updateDeploymentSpec(
 			factory.RemoveProfile(profile, deployment)
 		}
 
-		profileList, err = factory.GetProfiles(ctx, profileNamespace, annotations)
+		profileList, err = factory.GetProfiles(ctx, functionNamespace, annotations)
 		if err != nil {
 			return err, http.StatusBadRequest
 		}
 		for _, profile := range profileList {
 			factory.ApplyProfile(profile, deployment)
 		}
+
+		if err := factory.ConfigureZone(annotations, deployment); err != nil {
+			return err, http.StatusBadRequest
+		}
+
+		if err := factory.ConfigurePodOverhead(annotations, deployment); err != nil {
+			return err, http.StatusBadRequest
+		}
+
+		if err := factory.ConfigureMetricsScraping(annotations, deployment); err != nil {
+			return err, http.StatusBadRequest
+		}
+
+		factory.ConfigureBackupAnnotations(annotations, deployment)
+	}
+
+	if err := factory.ValidateSysctls(deployment.Spec.Template.Spec); err != nil {
+		return err, http.StatusBadRequest
 	}
 
-	if _, updateErr := factory.Client.AppsV1().
-		Deployments(functionNamespace).
-		Update(context.TODO(), deployment, metav1.UpdateOptions{}); updateErr != nil {
+	k8s.SetSpecChecksum(deployment)
+	k8s.SetUpdatedAt(deployment, time.Now())
+
+	updateErr := factory.Breaker.Do(func() error {
+		updated, err := factory.Client.AppsV1().
+			Deployments(functionNamespace).
+			Update(context.TODO(), deployment, metav1.UpdateOptions{})
+		if err == nil {
+			deployment = updated
+		}
+		return err
+	})
+	if updateErr != nil {
+		return updateErr, circuitStatus(updateErr, http.StatusInternalServerError)
+	}
+
+	replicas := int32(0)
+	if deployment.Spec.Replicas != nil {
+		replicas = *deployment.Spec.Replicas
+	}
+	if err := factory.ConfigurePDB(ctx, functionNamespace, request.Service, annotations, replicas); err != nil {
+		log.Printf("PodDisruptionBudget for %s.%s error: %v\n", request.Service, functionNamespace, err)
+	}
 
-		return updateErr, http.StatusInternalServerError
+	// the variants were validated with the request, a failure here leaves the function itself
+	// updated and is retried on the next update
+	if err := factory.ReconcileVariants(ctx, functionNamespace, deployment); err != nil {
+		log.Printf("Variants for %s.%s error: %v\n", request.Service, functionNamespace, err)
+	}
+
+	// the function works without TLS, so a failure to manage the Certificate is not fatal
+	if len(annotations[k8s.TLSDomainAnnotationKey]) == 0 && len(previousDomain) > 0 {
+		if err := k8s.NewCertificateClient(factory.Client).Delete(ctx, functionNamespace, request.Service); err != nil {
+			log.Printf("Certificate for %s.%s error: %v\n", request.Service, functionNamespace, err)
+		}
+	} else if err := factory.ConfigureCertificate(ctx, functionNamespace, request.Service, annotations); err != nil {
+		log.Printf("Certificate for %s.%s error: %v\n", request.Service, functionNamespace, err)
 	}
 
 	return nil, http.StatusAccepted
@@ -196,22 +385,54 @@ func updateService(
 
 	getOpts := metav1.GetOptions{}
 
-	service, findServiceErr := factory.Client.CoreV1().
-		Services(functionNamespace).
-		Get(context.TODO(), request.Service, getOpts)
+	var service *corev1.Service
+	findServiceErr := factory.Breaker.Do(func() error {
+		var err error
+		service, err = factory.Client.CoreV1().
+			Services(functionNamespace).
+			Get(context.TODO(), request.Service, getOpts)
+		return err
+	})
 
 	if findServiceErr != nil {
-		return findServiceErr, http.StatusNotFound
+		return findServiceErr, circuitStatus(findServiceErr, http.StatusNotFound)
 	}
 
 	service.Annotations = annotations
+	if err := k8s.ConfigureServicePort(buildAnnotations(request), service); err != nil {
+		return err, http.StatusBadRequest
+	}
 
-	if _, updateErr := factory.Client.CoreV1().
-		Services(functionNamespace).
-		Update(context.TODO(), service, metav1.UpdateOptions{}); updateErr != nil {
-
-		return updateErr, http.StatusInternalServerError
+	updateErr := factory.Breaker.Do(func() error {
+		_, err := factory.Client.CoreV1().
+			Services(functionNamespace).
+			Update(context.TODO(), service, metav1.UpdateOptions{})
+		return err
+	})
+	if updateErr != nil {
+		return updateErr, circuitStatus(updateErr, http.StatusInternalServerError)
 	}
 
 	return nil, http.StatusAccepted
 }
+
+// conflictRetryInterval is the wait before the first retry of a conflicting update, it is
+// doubled for each further attempt
+const conflictRetryInterval = 10 * time.Millisecond
+
+// retryOnConflict calls update, which must read the latest object each time, until it does
+// not fail with a Conflict or retries further attempts have been made
+func retryOnConflict(retries int, update func() (error, int)) (error, int) {
+	wait := conflictRetryInterval
+
+	err, status := update()
+	for attempt := 0; attempt < retries && k8serrors.IsConflict(err); attempt++ {
+		log.Printf("Conflict updating function, retrying in %s: %s\n", wait, err)
+		time.Sleep(wait)
+		wait *= 2
+
+		err, status = update()
+	}
+
+	return err, status
+}
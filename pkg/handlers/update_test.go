@@ -0,0 +1,99 @@
+// Copyright 2020 OpenFaaS Author(s)
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_retryOnConflict(t *testing.T) {
+	conflict := k8serrors.NewConflict(schema.GroupResource{Group: "apps", Resource: "deployments"}, "nodeinfo", errors.New("the object has been modified"))
+
+	cases := []struct {
+		name       string
+		retries    int
+		conflicts  int
+		wantCalls  int
+		wantStatus int
+	}{
+		{name: "no conflict", retries: 5, conflicts: 0, wantCalls: 1, wantStatus: http.StatusAccepted},
+		{name: "transient conflict", retries: 5, conflicts: 2, wantCalls: 3, wantStatus: http.StatusAccepted},
+		{name: "retries exhausted", retries: 2, conflicts: 10, wantCalls: 3, wantStatus: http.StatusInternalServerError},
+		{name: "retries disabled", retries: 0, conflicts: 1, wantCalls: 1, wantStatus: http.StatusInternalServerError},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			calls := 0
+			err, status := retryOnConflict(tc.retries, func() (error, int) {
+				calls++
+				if calls <= tc.conflicts {
+					return conflict, http.StatusInternalServerError
+				}
+				return nil, http.StatusAccepted
+			})
+
+			if calls != tc.wantCalls {
+				t.Errorf("want %d calls, got %d", tc.wantCalls, calls)
+			}
+			if status != tc.wantStatus {
+				t.Errorf("want status %d, got %d", tc.wantStatus, status)
+			}
+			if (status == http.StatusAccepted) != (err == nil) {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func Test_waitForRollout(t *testing.T) {
+	cases := []struct {
+		name    string
+		status  appsv1.DeploymentStatus
+		wantErr error
+	}{
+		{
+			name:   "rollout complete",
+			status: appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 2, UpdatedReplicas: 2},
+		},
+		{
+			name:    "old replicas remain",
+			status:  appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 3, UpdatedReplicas: 1},
+			wantErr: wait.ErrWaitTimeout,
+		},
+		{
+			name:    "update not observed yet",
+			status:  appsv1.DeploymentStatus{ObservedGeneration: 1, Replicas: 2, UpdatedReplicas: 2},
+			wantErr: wait.ErrWaitTimeout,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset(&appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "nodeinfo", Namespace: "openfaas-fn", Generation: 2},
+				Status:     tc.status,
+			})
+
+			status, err := waitForRollout(context.Background(), client, "openfaas-fn", "nodeinfo", time.Millisecond*10, time.Millisecond*50)
+			if err != tc.wantErr {
+				t.Fatalf("want error %v, got %v", tc.wantErr, err)
+			}
+			if err == nil && status.UpdatedReplicas != tc.status.UpdatedReplicas {
+				t.Errorf("want the final status returned, got %+v", status)
+			}
+		})
+	}
+}
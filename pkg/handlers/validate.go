@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"regexp"
 
+	"github.com/openfaas/faas-netes/pkg/k8s"
 	types "github.com/openfaas/faas-provider/types"
 )
 
@@ -24,3 +25,71 @@ func ValidateDeployRequest(request *types.FunctionDeployment) error {
 
 	return fmt.Errorf("(%s) must be a valid DNS entry for service name", request.Service)
 }
+
+// FunctionNameTooLong is the error code returned when a function name is longer than the
+// configured maximum
+const FunctionNameTooLong = "FUNCTION_NAME_TOO_LONG"
+
+// ValidateFunctionNameLength validates the function name against the maximum length, a
+// maxLength of 0 disables the check
+func ValidateFunctionNameLength(name string, maxLength int) error {
+	if maxLength > 0 && len(name) > maxLength {
+		return fmt.Errorf("function name %q is %d characters, the maximum is %d", name, len(name), maxLength)
+	}
+
+	return nil
+}
+
+// ImagePolicyViolation is the error code returned when an image does not match the configured
+// image_name_regex
+const ImagePolicyViolation = "IMAGE_POLICY_VIOLATION"
+
+// ValidateImagePolicy validates the image of a function against the image naming policy, a
+// nil pattern disables the check
+func ValidateImagePolicy(image string, pattern *regexp.Regexp) error {
+	if pattern != nil && !pattern.MatchString(image) {
+		return fmt.Errorf("image %q does not match the image policy %q", image, pattern.String())
+	}
+
+	return nil
+}
+
+// annotationError is returned by validateFunctionAnnotations, resource is the kind of object
+// that the invalid annotation configures i.e. Deployment
+type annotationError struct {
+	resource string
+	err      error
+}
+
+func (e *annotationError) Error() string {
+	return e.err.Error()
+}
+
+// validateFunctionAnnotations checks the annotations that are only parsed once the function is
+// running, i.e. by the proxy, so that an invalid value is rejected by both deploy and update
+func validateFunctionAnnotations(factory k8s.FunctionFactory, service string, annotations map[string]string) *annotationError {
+	validators := []struct {
+		resource string
+		validate func() error
+	}{
+		{"Certificate", func() error { return k8s.ValidateTLSDomain(annotations) }},
+		{"Deployment", func() error { _, err := k8s.ExecMaxDuration(annotations); return err }},
+		{"Deployment", func() error { _, err := k8s.ResponseHeaders(annotations); return err }},
+		{"Deployment", func() error { _, err := k8s.LoadBalancer(annotations); return err }},
+		{"Deployment", func() error { return k8s.ValidateVariants(service, annotations) }},
+		{"Deployment", func() error { _, err := k8s.AsyncMaxConcurrency(annotations); return err }},
+		{"Deployment", func() error { _, err := k8s.ScaleZeroSchedule(annotations); return err }},
+		{"Deployment", func() error { _, err := k8s.ParseABTest(annotations); return err }},
+		{"Deployment", func() error { _, err := k8s.PathPrefix(annotations); return err }},
+		{"Deployment", func() error { _, err := k8s.Transport(annotations); return err }},
+		{"PodDisruptionBudget", func() error { _, err := factory.PDBMinAvailable(annotations); return err }},
+	}
+
+	for _, v := range validators {
+		if err := v.validate(); err != nil {
+			return &annotationError{resource: v.resource, err: err}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,78 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	// ABHeaderAnnotationKey is the request header that decides whether a request is sent to
+	// the function in ABFunctionAnnotationKey instead of this one
+	ABHeaderAnnotationKey = "com.openfaas.ab.header"
+
+	// ABFunctionAnnotationKey is the alternative function of an A/B test, it must be in the
+	// same namespace as the primary function
+	ABFunctionAnnotationKey = "com.openfaas.ab.function"
+
+	// ABMatchAnnotationKey is a regular expression that the value of ABHeaderAnnotationKey
+	// must match for the request to go to the alternative function, when it is not set any
+	// non-empty value matches
+	ABMatchAnnotationKey = "com.openfaas.ab.match"
+)
+
+// ABTest routes the requests of a function whose header matches to an alternative function
+type ABTest struct {
+	Header   string
+	Function string
+	Match    *regexp.Regexp
+}
+
+// ParseABTest reads the A/B test of a function from its annotations, nil is returned when
+// the function is not A/B tested
+func ParseABTest(annotations map[string]string) (*ABTest, error) {
+	header := strings.TrimSpace(annotations[ABHeaderAnnotationKey])
+	function := strings.TrimSpace(annotations[ABFunctionAnnotationKey])
+	if len(header) == 0 && len(function) == 0 {
+		return nil, nil
+	}
+
+	if len(header) == 0 || len(function) == 0 {
+		return nil, fmt.Errorf("%s and %s must be set together", ABHeaderAnnotationKey, ABFunctionAnnotationKey)
+	}
+	if errs := validation.IsHTTPHeaderName(header); len(errs) > 0 {
+		return nil, fmt.Errorf("invalid %s: %q, %s", ABHeaderAnnotationKey, header, strings.Join(errs, ", "))
+	}
+	if errs := validation.IsDNS1123Label(function); len(errs) > 0 {
+		return nil, fmt.Errorf("invalid %s: %q, %s", ABFunctionAnnotationKey, function, strings.Join(errs, ", "))
+	}
+
+	test := &ABTest{Header: header, Function: function}
+	if v := annotations[ABMatchAnnotationKey]; len(v) > 0 {
+		match, err := regexp.Compile(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %q, %s", ABMatchAnnotationKey, v, err)
+		}
+		test.Match = match
+	}
+
+	return test, nil
+}
+
+// Matches returns true when the request should be sent to the alternative function
+func (t *ABTest) Matches(header http.Header) bool {
+	value := header.Get(t.Header)
+	if len(value) == 0 {
+		return false
+	}
+	if t.Match == nil {
+		return true
+	}
+	return t.Match.MatchString(value)
+}
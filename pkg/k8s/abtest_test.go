@@ -0,0 +1,74 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"net/http"
+	"testing"
+)
+
+func Test_ParseABTest(t *testing.T) {
+	cases := []struct {
+		name        string
+		annotations map[string]string
+		wantNil     bool
+		wantErr     bool
+	}{
+		{name: "not A/B tested", annotations: map[string]string{}, wantNil: true},
+		{name: "header and function", annotations: map[string]string{ABHeaderAnnotationKey: "X-User-Group", ABFunctionAnnotationKey: "nodeinfo-v2"}},
+		{name: "with a match", annotations: map[string]string{ABHeaderAnnotationKey: "X-User-Group", ABFunctionAnnotationKey: "nodeinfo-v2", ABMatchAnnotationKey: "^beta"}},
+		{name: "header without a function", annotations: map[string]string{ABHeaderAnnotationKey: "X-User-Group"}, wantErr: true},
+		{name: "function without a header", annotations: map[string]string{ABFunctionAnnotationKey: "nodeinfo-v2"}, wantErr: true},
+		{name: "invalid header", annotations: map[string]string{ABHeaderAnnotationKey: "X User", ABFunctionAnnotationKey: "nodeinfo-v2"}, wantErr: true},
+		{name: "invalid function", annotations: map[string]string{ABHeaderAnnotationKey: "X-User-Group", ABFunctionAnnotationKey: "Nodeinfo_v2"}, wantErr: true},
+		{name: "invalid match", annotations: map[string]string{ABHeaderAnnotationKey: "X-User-Group", ABFunctionAnnotationKey: "nodeinfo-v2", ABMatchAnnotationKey: "("}, wantErr: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ParseABTest(tc.annotations)
+			if tc.wantErr != (err != nil) {
+				t.Fatalf("want error: %v, got: %v", tc.wantErr, err)
+			}
+			if !tc.wantErr && tc.wantNil != (got == nil) {
+				t.Errorf("want nil: %v, got: %v", tc.wantNil, got)
+			}
+		})
+	}
+}
+
+func Test_ABTest_Matches(t *testing.T) {
+	cases := []struct {
+		name  string
+		match string
+		value string
+		want  bool
+	}{
+		{name: "no header", value: "", want: false},
+		{name: "any value without a match", value: "anything", want: true},
+		{name: "value matches", match: "^(beta|internal)$", value: "beta", want: true},
+		{name: "value does not match", match: "^(beta|internal)$", value: "stable", want: false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			annotations := map[string]string{ABHeaderAnnotationKey: "X-User-Group", ABFunctionAnnotationKey: "nodeinfo-v2"}
+			if len(tc.match) > 0 {
+				annotations[ABMatchAnnotationKey] = tc.match
+			}
+			test, err := ParseABTest(annotations)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			header := http.Header{}
+			if len(tc.value) > 0 {
+				header.Set("X-User-Group", tc.value)
+			}
+			if got := test.Matches(header); got != tc.want {
+				t.Errorf("want match: %v, got: %v", tc.want, got)
+			}
+		})
+	}
+}
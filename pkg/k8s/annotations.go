@@ -0,0 +1,74 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+// WithDefaultAnnotations returns a copy of the function annotations merged with
+// DeploymentConfig.DefaultFunctionAnnotations, the function annotations take precedence.
+func (f *FunctionFactory) WithDefaultAnnotations(annotations *map[string]string) map[string]string {
+	merged := map[string]string{}
+
+	for k, v := range f.Config.DefaultFunctionAnnotations {
+		merged[k] = v
+	}
+
+	if annotations != nil {
+		for k, v := range *annotations {
+			merged[k] = v
+		}
+	}
+
+	return merged
+}
+
+const (
+	// MeshInjectAnnotationKey set to false opts a function out of service mesh sidecar injection
+	MeshInjectAnnotationKey = "com.openfaas.mesh.inject"
+)
+
+// DefaultMeshInjectDisableAnnotations are the Pod annotations that disable sidecar injection
+// for Istio and Linkerd.
+var DefaultMeshInjectDisableAnnotations = map[string]string{
+	"sidecar.istio.io/inject": "false",
+	"linkerd.io/inject":       "disabled",
+}
+
+// ServiceMeshInjectAnnotations are the Pod annotations that enable sidecar injection for each
+// DeploymentConfig.ServiceMesh
+var ServiceMeshInjectAnnotations = map[string]map[string]string{
+	"linkerd": {"linkerd.io/inject": "enabled"},
+	"istio":   {"sidecar.istio.io/inject": "true"},
+}
+
+// PodAnnotations returns the annotations for the function's Pod template. The annotations are
+// merged over DeploymentConfig.DefaultAnnotations and the injection annotations of
+// DeploymentConfig.ServiceMesh, so that the function's values take precedence. When the
+// function sets `com.openfaas.mesh.inject=false` DeploymentConfig.MeshInjectDisableAnnotations
+// are added last. The function's annotations are not modified.
+func (f *FunctionFactory) PodAnnotations(annotations map[string]string) map[string]string {
+	podAnnotations := make(map[string]string, len(f.Config.DefaultAnnotations)+len(annotations))
+
+	for k, v := range ServiceMeshInjectAnnotations[f.Config.ServiceMesh] {
+		podAnnotations[k] = v
+	}
+
+	for k, v := range f.Config.DefaultAnnotations {
+		podAnnotations[k] = v
+	}
+	for k, v := range annotations {
+		podAnnotations[k] = v
+	}
+
+	if annotations[MeshInjectAnnotationKey] == "false" {
+		disable := f.Config.MeshInjectDisableAnnotations
+		if disable == nil {
+			disable = DefaultMeshInjectDisableAnnotations
+		}
+
+		for k, v := range disable {
+			podAnnotations[k] = v
+		}
+	}
+
+	return podAnnotations
+}
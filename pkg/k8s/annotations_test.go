@@ -0,0 +1,158 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_WithDefaultAnnotations(t *testing.T) {
+	factory := mockFactory()
+	factory.Config.DefaultFunctionAnnotations = map[string]string{
+		ProfileAnnotationKey:      "default",
+		"com.openfaas.scale.type": "rps",
+	}
+
+	cases := []struct {
+		name        string
+		annotations *map[string]string
+		want        map[string]string
+	}{
+		{
+			name:        "defaults are used when the function has no annotations",
+			annotations: nil,
+			want: map[string]string{
+				ProfileAnnotationKey:      "default",
+				"com.openfaas.scale.type": "rps",
+			},
+		},
+		{
+			name:        "function annotations take precedence",
+			annotations: &map[string]string{ProfileAnnotationKey: "gpu", "team": "a"},
+			want: map[string]string{
+				ProfileAnnotationKey:      "gpu",
+				"com.openfaas.scale.type": "rps",
+				"team":                    "a",
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := factory.WithDefaultAnnotations(tc.annotations)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("want: %v, got: %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func Test_PodAnnotations(t *testing.T) {
+	custom := map[string]string{"mesh.example.com/inject": "off"}
+
+	cases := []struct {
+		name        string
+		config      map[string]string
+		mesh        string
+		defaults    map[string]string
+		annotations map[string]string
+		want        map[string]string
+	}{
+		{
+			name:        "no opt-out",
+			annotations: map[string]string{"topic": "cron"},
+			want:        map[string]string{"topic": "cron"},
+		},
+		{
+			name:        "opt-out uses the default mapping",
+			annotations: map[string]string{MeshInjectAnnotationKey: "false"},
+			want: map[string]string{
+				MeshInjectAnnotationKey:   "false",
+				"sidecar.istio.io/inject": "false",
+				"linkerd.io/inject":       "disabled",
+			},
+		},
+		{
+			name:        "opt-out uses the configured mapping",
+			config:      custom,
+			annotations: map[string]string{MeshInjectAnnotationKey: "false"},
+			want: map[string]string{
+				MeshInjectAnnotationKey:   "false",
+				"mesh.example.com/inject": "off",
+			},
+		},
+		{
+			name:        "function annotations override the defaults",
+			defaults:    map[string]string{"ad.datadoghq.com/tags": "{}", "topic": "default"},
+			annotations: map[string]string{"topic": "cron"},
+			want:        map[string]string{"ad.datadoghq.com/tags": "{}", "topic": "cron"},
+		},
+		{
+			name:        "opt-out overrides a default injection annotation",
+			defaults:    map[string]string{"linkerd.io/inject": "enabled"},
+			annotations: map[string]string{MeshInjectAnnotationKey: "false"},
+			want: map[string]string{
+				MeshInjectAnnotationKey:   "false",
+				"sidecar.istio.io/inject": "false",
+				"linkerd.io/inject":       "disabled",
+			},
+		},
+		{
+			name:        "injection enabled",
+			annotations: map[string]string{MeshInjectAnnotationKey: "true"},
+			want:        map[string]string{MeshInjectAnnotationKey: "true"},
+		},
+		{
+			name:        "linkerd mesh",
+			mesh:        "linkerd",
+			annotations: map[string]string{"topic": "cron"},
+			want:        map[string]string{"topic": "cron", "linkerd.io/inject": "enabled"},
+		},
+		{
+			name:        "istio mesh",
+			mesh:        "istio",
+			annotations: map[string]string{"topic": "cron"},
+			want:        map[string]string{"topic": "cron", "sidecar.istio.io/inject": "true"},
+		},
+		{
+			name:        "no mesh",
+			mesh:        "none",
+			annotations: map[string]string{"topic": "cron"},
+			want:        map[string]string{"topic": "cron"},
+		},
+		{
+			name:        "opt-out overrides the mesh",
+			mesh:        "linkerd",
+			annotations: map[string]string{MeshInjectAnnotationKey: "false"},
+			want: map[string]string{
+				MeshInjectAnnotationKey:   "false",
+				"sidecar.istio.io/inject": "false",
+				"linkerd.io/inject":       "disabled",
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			factory := mockFactory()
+			factory.Config.MeshInjectDisableAnnotations = tc.config
+			factory.Config.DefaultAnnotations = tc.defaults
+			factory.Config.ServiceMesh = tc.mesh
+
+			original := map[string]string{}
+			for k, v := range tc.annotations {
+				original[k] = v
+			}
+
+			got := factory.PodAnnotations(tc.annotations)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("want: %v, got: %v", tc.want, got)
+			}
+			if !reflect.DeepEqual(tc.annotations, original) {
+				t.Errorf("want the function annotations to be left unchanged, got: %v", tc.annotations)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/version"
+	"k8s.io/client-go/discovery"
+)
+
+// apiServerBackoff is the retry schedule used while waiting for the Kubernetes API
+var apiServerBackoff = wait.Backoff{
+	Duration: 500 * time.Millisecond,
+	Factor:   2,
+	Jitter:   0.1,
+	Steps:    1 << 30,
+	Cap:      10 * time.Second,
+}
+
+// WaitForAPIServer blocks until the Kubernetes API server responds to a version request or
+// the timeout elapses. This allows faas-netes to start before the API server during cluster
+// bootstrap, rather than failing immediately.
+func WaitForAPIServer(client discovery.ServerVersionInterface, timeout time.Duration) (*version.Info, error) {
+	deadline := time.Now().Add(timeout)
+	backoff := apiServerBackoff
+
+	for {
+		info, err := client.ServerVersion()
+		if err == nil {
+			return info, nil
+		}
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return nil, fmt.Errorf("timed out after %s waiting for the Kubernetes API: %s", timeout, err)
+		}
+
+		delay := backoff.Step()
+		if delay > remaining {
+			delay = remaining
+		}
+
+		log.Printf("Kubernetes API not reachable, retrying in %s: %s\n", delay.Round(time.Millisecond), err)
+		time.Sleep(delay)
+	}
+}
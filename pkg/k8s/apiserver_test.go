@@ -0,0 +1,58 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/version"
+)
+
+type fakeServerVersion struct {
+	calls    int
+	failures int
+}
+
+func (f *fakeServerVersion) ServerVersion() (*version.Info, error) {
+	f.calls++
+	if f.calls <= f.failures {
+		return nil, fmt.Errorf("connection refused")
+	}
+	return &version.Info{GitVersion: "v1.21.0"}, nil
+}
+
+func Test_WaitForAPIServer_RetriesUntilReachable(t *testing.T) {
+	defer func(b wait.Backoff) { apiServerBackoff = b }(apiServerBackoff)
+	apiServerBackoff.Duration = time.Millisecond
+
+	client := &fakeServerVersion{failures: 2}
+
+	info, err := WaitForAPIServer(client, time.Second)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if client.calls != 3 {
+		t.Errorf("want 3 calls, got: %d", client.calls)
+	}
+
+	if info.GitVersion != "v1.21.0" {
+		t.Errorf("want version v1.21.0, got: %s", info.GitVersion)
+	}
+}
+
+func Test_WaitForAPIServer_TimesOut(t *testing.T) {
+	defer func(b wait.Backoff) { apiServerBackoff = b }(apiServerBackoff)
+	apiServerBackoff.Duration = time.Millisecond
+
+	client := &fakeServerVersion{failures: 1 << 30}
+
+	_, err := WaitForAPIServer(client, 20*time.Millisecond)
+	if err == nil {
+		t.Fatalf("want a timeout error")
+	}
+}
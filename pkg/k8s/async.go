@@ -0,0 +1,29 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// AsyncMaxConcurrencyAnnotationKey is the most asynchronous invocations of the function that
+// the queue worker should have in flight at once, across all of its replicas
+const AsyncMaxConcurrencyAnnotationKey = "com.openfaas.async.max-concurrency"
+
+// AsyncMaxConcurrency returns the AsyncMaxConcurrencyAnnotationKey annotation, 0 is returned
+// when it is not set and the queue worker applies its own limit.
+func AsyncMaxConcurrency(annotations map[string]string) (int, error) {
+	v, ok := annotations[AsyncMaxConcurrencyAnnotationKey]
+	if !ok || len(v) == 0 {
+		return 0, nil
+	}
+
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("invalid %s: %q, must be a positive number", AsyncMaxConcurrencyAnnotationKey, v)
+	}
+
+	return n, nil
+}
@@ -0,0 +1,43 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import "testing"
+
+func Test_AsyncMaxConcurrency(t *testing.T) {
+	cases := []struct {
+		name    string
+		value   string
+		want    int
+		wantErr bool
+	}{
+		{name: "not set", value: "", want: 0},
+		{name: "set", value: "5", want: 5},
+		{name: "zero", value: "0", wantErr: true},
+		{name: "not a number", value: "ten", wantErr: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			annotations := map[string]string{}
+			if len(tc.value) > 0 {
+				annotations[AsyncMaxConcurrencyAnnotationKey] = tc.value
+			}
+
+			got, err := AsyncMaxConcurrency(annotations)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if got != tc.want {
+				t.Errorf("want %d, got %d", tc.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"sort"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+)
+
+const (
+	// BackupAnnotationKey set to "false" opts a function out of DeploymentConfig.BackupAnnotations
+	BackupAnnotationKey = "com.openfaas.backup"
+
+	// BackupVolumesPlaceholder is replaced in the values of DeploymentConfig.BackupAnnotations
+	// with the comma-separated names of the function's volumes, such as for
+	// backup.velero.io/backup-volumes
+	BackupVolumesPlaceholder = "{{volumes}}"
+)
+
+// ConfigureBackupAnnotations adds DeploymentConfig.BackupAnnotations to the Pod template, it is
+// called once the function's volumes are configured. Secret and projected volumes are not
+// included in BackupVolumesPlaceholder since they are restored from their source, an annotation
+// whose value is empty after the substitution is left out. Annotations set by the function take
+// precedence.
+func (f *FunctionFactory) ConfigureBackupAnnotations(annotations map[string]string, deployment *appsv1.Deployment) {
+	if len(f.Config.BackupAnnotations) == 0 || annotations[BackupAnnotationKey] == "false" {
+		return
+	}
+
+	var volumes []string
+	for _, v := range deployment.Spec.Template.Spec.Volumes {
+		if v.Secret != nil || v.Projected != nil {
+			continue
+		}
+		volumes = append(volumes, v.Name)
+	}
+	sort.Strings(volumes)
+
+	template := &deployment.Spec.Template
+	for k, v := range f.Config.BackupAnnotations {
+		if _, ok := annotations[k]; ok {
+			continue
+		}
+
+		value := strings.ReplaceAll(v, BackupVolumesPlaceholder, strings.Join(volumes, ","))
+		if len(value) == 0 {
+			continue
+		}
+
+		if template.Annotations == nil {
+			template.Annotations = map[string]string{}
+		}
+		template.Annotations[k] = value
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"reflect"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func Test_ConfigureBackupAnnotations(t *testing.T) {
+	backupAnnotations := map[string]string{
+		"backup.velero.io/backup-volumes": BackupVolumesPlaceholder,
+		"example.com/backup-policy":       "daily",
+	}
+
+	cases := []struct {
+		name        string
+		config      map[string]string
+		annotations map[string]string
+		volumes     []corev1.Volume
+		want        map[string]string
+	}{
+		{
+			name:    "not configured",
+			volumes: []corev1.Volume{{Name: "temp"}},
+			want:    map[string]string{"existing": "true"},
+		},
+		{
+			name:   "data volumes are listed, secrets are skipped",
+			config: backupAnnotations,
+			volumes: []corev1.Volume{
+				{Name: "temp", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+				{Name: "cache", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+				{Name: "fn-projected-secrets", VolumeSource: corev1.VolumeSource{Projected: &corev1.ProjectedVolumeSource{}}},
+			},
+			want: map[string]string{
+				"existing":                        "true",
+				"backup.velero.io/backup-volumes": "cache,temp",
+				"example.com/backup-policy":       "daily",
+			},
+		},
+		{
+			name:   "no data volumes omits the annotation",
+			config: backupAnnotations,
+			want: map[string]string{
+				"existing":                  "true",
+				"example.com/backup-policy": "daily",
+			},
+		},
+		{
+			name:        "function opts out",
+			config:      backupAnnotations,
+			annotations: map[string]string{BackupAnnotationKey: "false"},
+			volumes:     []corev1.Volume{{Name: "temp"}},
+			want:        map[string]string{"existing": "true"},
+		},
+		{
+			name:        "function annotation takes precedence",
+			config:      backupAnnotations,
+			annotations: map[string]string{"example.com/backup-policy": "weekly"},
+			want:        map[string]string{"existing": "true"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			factory := mockFactory()
+			factory.Config.BackupAnnotations = tc.config
+
+			deployment := &appsv1.Deployment{}
+			deployment.Spec.Template.Annotations = map[string]string{"existing": "true"}
+			deployment.Spec.Template.Spec.Volumes = tc.volumes
+
+			factory.ConfigureBackupAnnotations(tc.annotations, deployment)
+
+			if got := deployment.Spec.Template.Annotations; !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("want %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"context"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	appsinformers "k8s.io/client-go/informers/apps/v1"
+	coreinformers "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+// CacheRefresher replaces the objects in the store of an informer with a fresh list from the
+// API server, for when the cache is known to be stale and the next resync is too far away
+type CacheRefresher struct {
+	// Resource is the name of the resource in the store, i.e. "deployments"
+	Resource string
+
+	store cache.Store
+	list  func(ctx context.Context) ([]interface{}, string, error)
+}
+
+// NewDeploymentCacheRefresher refreshes the Deployments of the informer, namespace must match
+// the namespace the informer was started with, an empty namespace is all namespaces
+func NewDeploymentCacheRefresher(client kubernetes.Interface, namespace string, informer appsinformers.DeploymentInformer) CacheRefresher {
+	return CacheRefresher{
+		Resource: "deployments",
+		store:    informer.Informer().GetStore(),
+		list: func(ctx context.Context) ([]interface{}, string, error) {
+			res, err := client.AppsV1().Deployments(namespace).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return nil, "", err
+			}
+
+			items := make([]interface{}, 0, len(res.Items))
+			for i := range res.Items {
+				items = append(items, &res.Items[i])
+			}
+			return items, res.ResourceVersion, nil
+		},
+	}
+}
+
+// NewEndpointsCacheRefresher refreshes the Endpoints of the informer, namespace must match
+// the namespace the informer was started with, an empty namespace is all namespaces
+func NewEndpointsCacheRefresher(client kubernetes.Interface, namespace string, informer coreinformers.EndpointsInformer) CacheRefresher {
+	return CacheRefresher{
+		Resource: "endpoints",
+		store:    informer.Informer().GetStore(),
+		list: func(ctx context.Context) ([]interface{}, string, error) {
+			res, err := client.CoreV1().Endpoints(namespace).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return nil, "", err
+			}
+
+			items := make([]interface{}, 0, len(res.Items))
+			for i := range res.Items {
+				items = append(items, &res.Items[i])
+			}
+			return items, res.ResourceVersion, nil
+		},
+	}
+}
+
+// Refresh lists the resource and replaces the contents of the store, it returns the number
+// of objects in the store. The store is left unchanged when the list fails.
+//
+// Replacing the store does not notify the event handlers of the informer, the changes are
+// seen by them on the next watch event or resync.
+func (r CacheRefresher) Refresh(ctx context.Context) (int, error) {
+	items, resourceVersion, err := r.list(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("unable to list %s: %s", r.Resource, err)
+	}
+
+	if err := r.store.Replace(items, resourceVersion); err != nil {
+		return 0, fmt.Errorf("unable to replace the cache of %s: %s", r.Resource, err)
+	}
+	return len(items), nil
+}
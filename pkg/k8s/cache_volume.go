@@ -0,0 +1,97 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const (
+	// CacheSizeAnnotationKey adds an emptyDir cache volume to the function with this size
+	// limit, i.e. 512Mi. The Pod is evicted when the volume grows past the limit.
+	CacheSizeAnnotationKey = "com.openfaas.cache.size"
+
+	// CachePathAnnotationKey is where the cache volume is mounted, by default DefaultCachePath
+	CachePathAnnotationKey = "com.openfaas.cache.path"
+
+	// DefaultCachePath is where the cache volume is mounted when CachePathAnnotationKey is not set
+	DefaultCachePath = "/cache"
+
+	// cacheVolumeName is the name of the cache volume and its mount
+	cacheVolumeName = "cache"
+)
+
+// reservedCachePaths are used by the provider or the function's runtime, so cannot be the
+// cache's mount path or one of its parents
+var reservedCachePaths = []string{"/tmp", "/var/openfaas"}
+
+// CacheVolume returns the size limit and mount path of the cache volume, a nil size is
+// returned when the function has no cache
+func CacheVolume(annotations map[string]string) (*resource.Quantity, string, error) {
+	v, ok := annotations[CacheSizeAnnotationKey]
+	if !ok || len(v) == 0 {
+		return nil, "", nil
+	}
+
+	size, err := resource.ParseQuantity(v)
+	if err != nil || size.Sign() <= 0 {
+		return nil, "", fmt.Errorf("invalid %s: %q, must be a positive quantity such as 512Mi", CacheSizeAnnotationKey, v)
+	}
+
+	mountPath := DefaultCachePath
+	if p := annotations[CachePathAnnotationKey]; len(p) > 0 {
+		mountPath = p
+	}
+	if !path.IsAbs(mountPath) || path.Clean(mountPath) != mountPath || mountPath == "/" {
+		return nil, "", fmt.Errorf("invalid %s: %q, must be a clean absolute path other than /", CachePathAnnotationKey, mountPath)
+	}
+	for _, reserved := range reservedCachePaths {
+		if mountPath == reserved || strings.HasPrefix(mountPath, reserved+"/") || strings.HasPrefix(reserved, mountPath+"/") {
+			return nil, "", fmt.Errorf("invalid %s: %q, overlaps %s which is reserved", CachePathAnnotationKey, mountPath, reserved)
+		}
+	}
+
+	return &size, mountPath, nil
+}
+
+// ConfigureCacheVolume adds an emptyDir volume with a size limit from CacheSizeAnnotationKey
+// and mounts it in the function container. Without the annotation any cache volume is
+// removed, which makes it safe to use for both create and update.
+func (f *FunctionFactory) ConfigureCacheVolume(annotations map[string]string, deployment *appsv1.Deployment) error {
+	size, mountPath, err := CacheVolume(annotations)
+	if err != nil {
+		return err
+	}
+
+	spec := &deployment.Spec.Template.Spec
+	spec.Volumes = removeVolume(cacheVolumeName, spec.Volumes)
+	if len(spec.Containers) == 0 {
+		return nil
+	}
+	container := &spec.Containers[0]
+	container.VolumeMounts = removeVolumeMount(cacheVolumeName, container.VolumeMounts)
+
+	if size == nil {
+		return nil
+	}
+
+	spec.Volumes = append(spec.Volumes, corev1.Volume{
+		Name: cacheVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			EmptyDir: &corev1.EmptyDirVolumeSource{SizeLimit: size},
+		},
+	})
+	container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+		Name:      cacheVolumeName,
+		MountPath: mountPath,
+	})
+
+	return nil
+}
@@ -0,0 +1,95 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func Test_ConfigureCacheVolume(t *testing.T) {
+	cases := []struct {
+		name        string
+		annotations map[string]string
+		wantSize    string
+		wantPath    string
+		wantErr     bool
+	}{
+		{name: "no cache", annotations: map[string]string{}},
+		{name: "default path", annotations: map[string]string{CacheSizeAnnotationKey: "512Mi"}, wantSize: "512Mi", wantPath: "/cache"},
+		{name: "custom path", annotations: map[string]string{CacheSizeAnnotationKey: "1Gi", CachePathAnnotationKey: "/home/app/.cache"}, wantSize: "1Gi", wantPath: "/home/app/.cache"},
+		{name: "invalid size", annotations: map[string]string{CacheSizeAnnotationKey: "lots"}, wantErr: true},
+		{name: "zero size", annotations: map[string]string{CacheSizeAnnotationKey: "0"}, wantErr: true},
+		{name: "relative path", annotations: map[string]string{CacheSizeAnnotationKey: "512Mi", CachePathAnnotationKey: "cache"}, wantErr: true},
+		{name: "unclean path", annotations: map[string]string{CacheSizeAnnotationKey: "512Mi", CachePathAnnotationKey: "/cache/../data"}, wantErr: true},
+		{name: "root path", annotations: map[string]string{CacheSizeAnnotationKey: "512Mi", CachePathAnnotationKey: "/"}, wantErr: true},
+		{name: "temp path", annotations: map[string]string{CacheSizeAnnotationKey: "512Mi", CachePathAnnotationKey: "/tmp"}, wantErr: true},
+		{name: "secrets path", annotations: map[string]string{CacheSizeAnnotationKey: "512Mi", CachePathAnnotationKey: "/var/openfaas/secrets"}, wantErr: true},
+		{name: "parent of a reserved path", annotations: map[string]string{CacheSizeAnnotationKey: "512Mi", CachePathAnnotationKey: "/var"}, wantErr: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			factory := mockFactory()
+
+			deployment := &appsv1.Deployment{}
+			deployment.Spec.Template.Spec.Containers = []corev1.Container{{Name: "nodeinfo"}}
+
+			err := factory.ConfigureCacheVolume(tc.annotations, deployment)
+			if tc.wantErr != (err != nil) {
+				t.Fatalf("want error: %v, got: %v", tc.wantErr, err)
+			}
+			if tc.wantErr {
+				return
+			}
+
+			volumes := deployment.Spec.Template.Spec.Volumes
+			mounts := deployment.Spec.Template.Spec.Containers[0].VolumeMounts
+			if len(tc.wantSize) == 0 {
+				if len(volumes) != 0 || len(mounts) != 0 {
+					t.Errorf("want no cache volume, got volumes: %v, mounts: %v", volumes, mounts)
+				}
+				return
+			}
+
+			if len(volumes) != 1 || volumes[0].EmptyDir == nil || volumes[0].EmptyDir.SizeLimit == nil {
+				t.Fatalf("want an emptyDir volume with a size limit, got: %v", volumes)
+			}
+			if got := volumes[0].EmptyDir.SizeLimit.String(); got != tc.wantSize {
+				t.Errorf("want size limit: %s, got: %s", tc.wantSize, got)
+			}
+			if len(mounts) != 1 || mounts[0].MountPath != tc.wantPath {
+				t.Errorf("want mount at %s, got: %v", tc.wantPath, mounts)
+			}
+		})
+	}
+}
+
+func Test_ConfigureCacheVolume_Update(t *testing.T) {
+	factory := mockFactory()
+
+	deployment := &appsv1.Deployment{}
+	deployment.Spec.Template.Spec.Containers = []corev1.Container{{Name: "nodeinfo"}}
+
+	if err := factory.ConfigureCacheVolume(map[string]string{CacheSizeAnnotationKey: "512Mi"}, deployment); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := factory.ConfigureCacheVolume(map[string]string{CacheSizeAnnotationKey: "1Gi"}, deployment); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	volumes := deployment.Spec.Template.Spec.Volumes
+	if len(volumes) != 1 || volumes[0].EmptyDir.SizeLimit.String() != "1Gi" {
+		t.Fatalf("want a single 1Gi cache volume, got: %v", volumes)
+	}
+
+	if err := factory.ConfigureCacheVolume(map[string]string{}, deployment); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(deployment.Spec.Template.Spec.Volumes) != 0 || len(deployment.Spec.Template.Spec.Containers[0].VolumeMounts) != 0 {
+		t.Errorf("want the cache volume to be removed, got: %v", deployment.Spec.Template.Spec.Volumes)
+	}
+}
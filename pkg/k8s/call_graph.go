@@ -0,0 +1,302 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// FunctionNameSpanAttribute is the span attribute that names the function a span belongs to
+	FunctionNameSpanAttribute = "faas.function_name"
+
+	// callGraphTraceLimit is the maximum number of traces read from the trace backend per query
+	callGraphTraceLimit = 200
+)
+
+// TraceSpan is the subset of a span that is needed to build a call graph, Function is empty
+// for spans without the FunctionNameSpanAttribute
+type TraceSpan struct {
+	TraceID      string
+	SpanID       string
+	ParentSpanID string
+	Function     string
+}
+
+// TraceQuerier reads the spans of the traces that were started between start and end
+type TraceQuerier interface {
+	QuerySpans(ctx context.Context, start, end time.Time) ([]TraceSpan, error)
+}
+
+// CallGraphEdge is the number of calls that Caller made to Callee
+type CallGraphEdge struct {
+	Caller string `json:"caller"`
+	Callee string `json:"callee"`
+	Calls  int    `json:"calls"`
+}
+
+// CallGraph is the graph of the calls between functions, Matrix is the adjacency matrix of
+// Nodes where Matrix[i][j] is the number of calls from Nodes[i] to Nodes[j]
+type CallGraph struct {
+	Start  time.Time       `json:"start"`
+	End    time.Time       `json:"end"`
+	Traces int             `json:"traces"`
+	Nodes  []string        `json:"nodes"`
+	Edges  []CallGraphEdge `json:"edges"`
+	Matrix [][]int         `json:"matrix"`
+}
+
+// BuildCallGraph counts the calls between functions in the spans. A call is a span of a
+// function whose nearest function ancestor in the same trace belongs to another function, so
+// the spans that a function creates for its own work, and the spans of a gateway in between
+// two functions, are not counted as calls.
+func BuildCallGraph(spans []TraceSpan) CallGraph {
+	byID := make(map[string]TraceSpan, len(spans))
+	traces := map[string]bool{}
+	for _, span := range spans {
+		byID[span.TraceID+"/"+span.SpanID] = span
+		traces[span.TraceID] = true
+	}
+
+	functions := map[string]bool{}
+	calls := map[[2]string]int{}
+	for _, span := range spans {
+		if len(span.Function) == 0 {
+			continue
+		}
+		functions[span.Function] = true
+
+		caller := ""
+		parentID := span.ParentSpanID
+		// the depth is bounded in case of a cycle in malformed trace data
+		for depth := 0; len(parentID) > 0 && depth < len(spans); depth++ {
+			parent, ok := byID[span.TraceID+"/"+parentID]
+			if !ok {
+				break
+			}
+			if len(parent.Function) > 0 {
+				caller = parent.Function
+				break
+			}
+			parentID = parent.ParentSpanID
+		}
+
+		if len(caller) > 0 && caller != span.Function {
+			calls[[2]string{caller, span.Function}]++
+		}
+	}
+
+	graph := CallGraph{
+		Traces: len(traces),
+		Nodes:  []string{},
+		Edges:  []CallGraphEdge{},
+	}
+	for function := range functions {
+		graph.Nodes = append(graph.Nodes, function)
+	}
+	sort.Strings(graph.Nodes)
+
+	index := make(map[string]int, len(graph.Nodes))
+	graph.Matrix = make([][]int, len(graph.Nodes))
+	for i, function := range graph.Nodes {
+		index[function] = i
+		graph.Matrix[i] = make([]int, len(graph.Nodes))
+	}
+
+	for pair, n := range calls {
+		graph.Edges = append(graph.Edges, CallGraphEdge{Caller: pair[0], Callee: pair[1], Calls: n})
+		graph.Matrix[index[pair[0]]][index[pair[1]]] = n
+	}
+	sort.Slice(graph.Edges, func(i, j int) bool {
+		if graph.Edges[i].Caller != graph.Edges[j].Caller {
+			return graph.Edges[i].Caller < graph.Edges[j].Caller
+		}
+		return graph.Edges[i].Callee < graph.Edges[j].Callee
+	})
+
+	return graph
+}
+
+// JaegerTraceQuerier reads the traces of a service from the Jaeger query API
+type JaegerTraceQuerier struct {
+	client  *http.Client
+	baseURL string
+	service string
+}
+
+// NewJaegerTraceQuerier returns a TraceQuerier for the traces of service, which should be
+// the service that invokes functions such as the gateway
+func NewJaegerTraceQuerier(client *http.Client, baseURL, service string) *JaegerTraceQuerier {
+	return &JaegerTraceQuerier{
+		client:  client,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		service: service,
+	}
+}
+
+// jaegerTracesResponse is the subset of the Jaeger /api/traces response that is used
+type jaegerTracesResponse struct {
+	Data []struct {
+		TraceID string `json:"traceID"`
+		Spans   []struct {
+			TraceID    string `json:"traceID"`
+			SpanID     string `json:"spanID"`
+			References []struct {
+				RefType string `json:"refType"`
+				SpanID  string `json:"spanID"`
+			} `json:"references"`
+			Tags []struct {
+				Key   string      `json:"key"`
+				Value interface{} `json:"value"`
+			} `json:"tags"`
+		} `json:"spans"`
+	} `json:"data"`
+}
+
+// QuerySpans implements the TraceQuerier interface
+func (j JaegerTraceQuerier) QuerySpans(ctx context.Context, start, end time.Time) ([]TraceSpan, error) {
+	query := url.Values{}
+	query.Set("service", j.service)
+	query.Set("start", strconv.FormatInt(start.UnixNano()/int64(time.Microsecond), 10))
+	query.Set("end", strconv.FormatInt(end.UnixNano()/int64(time.Microsecond), 10))
+	query.Set("limit", strconv.Itoa(callGraphTraceLimit))
+
+	var body jaegerTracesResponse
+	if err := getTraceJSON(ctx, j.client, j.baseURL+"/api/traces?"+query.Encode(), &body); err != nil {
+		return nil, fmt.Errorf("unable to query Jaeger: %s", err)
+	}
+
+	var spans []TraceSpan
+	for _, trace := range body.Data {
+		for _, s := range trace.Spans {
+			span := TraceSpan{TraceID: trace.TraceID, SpanID: s.SpanID}
+			for _, ref := range s.References {
+				if ref.RefType == "CHILD_OF" {
+					span.ParentSpanID = ref.SpanID
+					break
+				}
+			}
+			for _, tag := range s.Tags {
+				if tag.Key == FunctionNameSpanAttribute {
+					span.Function = fmt.Sprint(tag.Value)
+					break
+				}
+			}
+			spans = append(spans, span)
+		}
+	}
+
+	return spans, nil
+}
+
+// TempoTraceQuerier reads the traces that have a FunctionNameSpanAttribute span from the
+// Grafana Tempo API
+type TempoTraceQuerier struct {
+	client  *http.Client
+	baseURL string
+}
+
+// NewTempoTraceQuerier returns a TraceQuerier for the Tempo server at baseURL
+func NewTempoTraceQuerier(client *http.Client, baseURL string) *TempoTraceQuerier {
+	return &TempoTraceQuerier{
+		client:  client,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+// tempoSearchResponse is the subset of the Tempo /api/search response that is used
+type tempoSearchResponse struct {
+	Traces []struct {
+		TraceID string `json:"traceID"`
+	} `json:"traces"`
+}
+
+type tempoSpans struct {
+	Spans []struct {
+		SpanID       string `json:"spanId"`
+		ParentSpanID string `json:"parentSpanId"`
+		Attributes   []struct {
+			Key   string `json:"key"`
+			Value struct {
+				StringValue string `json:"stringValue"`
+			} `json:"value"`
+		} `json:"attributes"`
+	} `json:"spans"`
+}
+
+// tempoTraceResponse is the OTLP JSON returned by the Tempo /api/traces endpoint, older
+// versions of Tempo name the scopes instrumentationLibrarySpans
+type tempoTraceResponse struct {
+	Batches []struct {
+		ScopeSpans                  []tempoSpans `json:"scopeSpans"`
+		InstrumentationLibrarySpans []tempoSpans `json:"instrumentationLibrarySpans"`
+	} `json:"batches"`
+}
+
+// QuerySpans implements the TraceQuerier interface
+func (t TempoTraceQuerier) QuerySpans(ctx context.Context, start, end time.Time) ([]TraceSpan, error) {
+	query := url.Values{}
+	query.Set("q", fmt.Sprintf("{ span.%s != nil }", FunctionNameSpanAttribute))
+	query.Set("start", strconv.FormatInt(start.Unix(), 10))
+	query.Set("end", strconv.FormatInt(end.Unix(), 10))
+	query.Set("limit", strconv.Itoa(callGraphTraceLimit))
+
+	var search tempoSearchResponse
+	if err := getTraceJSON(ctx, t.client, t.baseURL+"/api/search?"+query.Encode(), &search); err != nil {
+		return nil, fmt.Errorf("unable to search Tempo: %s", err)
+	}
+
+	var spans []TraceSpan
+	for _, found := range search.Traces {
+		var trace tempoTraceResponse
+		if err := getTraceJSON(ctx, t.client, t.baseURL+"/api/traces/"+url.PathEscape(found.TraceID), &trace); err != nil {
+			return nil, fmt.Errorf("unable to get trace %s from Tempo: %s", found.TraceID, err)
+		}
+
+		for _, batch := range trace.Batches {
+			for _, scope := range append(batch.ScopeSpans, batch.InstrumentationLibrarySpans...) {
+				for _, s := range scope.Spans {
+					span := TraceSpan{TraceID: found.TraceID, SpanID: s.SpanID, ParentSpanID: s.ParentSpanID}
+					for _, attr := range s.Attributes {
+						if attr.Key == FunctionNameSpanAttribute {
+							span.Function = attr.Value.StringValue
+							break
+						}
+					}
+					spans = append(spans, span)
+				}
+			}
+		}
+	}
+
+	return spans, nil
+}
+
+func getTraceJSON(ctx context.Context, client *http.Client, u string, v interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	res, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code: %d", res.StatusCode)
+	}
+
+	return json.NewDecoder(res.Body).Decode(v)
+}
@@ -0,0 +1,145 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_BuildCallGraph(t *testing.T) {
+	spans := []TraceSpan{
+		// gateway -> checkout -> gateway -> payment, and checkout -> stock twice
+		{TraceID: "t1", SpanID: "gw1"},
+		{TraceID: "t1", SpanID: "c1", ParentSpanID: "gw1", Function: "checkout"},
+		{TraceID: "t1", SpanID: "c2", ParentSpanID: "c1", Function: "checkout"},
+		{TraceID: "t1", SpanID: "gw2", ParentSpanID: "c2"},
+		{TraceID: "t1", SpanID: "p1", ParentSpanID: "gw2", Function: "payment"},
+		{TraceID: "t1", SpanID: "s1", ParentSpanID: "c1", Function: "stock"},
+		{TraceID: "t1", SpanID: "s2", ParentSpanID: "c1", Function: "stock"},
+		// the same span IDs in another trace are not confused with the first
+		{TraceID: "t2", SpanID: "c1", Function: "payment"},
+		{TraceID: "t2", SpanID: "s1", ParentSpanID: "c1", Function: "stock"},
+		// a parent outside of the results is not a caller
+		{TraceID: "t3", SpanID: "x1", ParentSpanID: "missing", Function: "stock"},
+	}
+
+	graph := BuildCallGraph(spans)
+
+	wantNodes := []string{"checkout", "payment", "stock"}
+	if !reflect.DeepEqual(graph.Nodes, wantNodes) {
+		t.Fatalf("want nodes: %v, got: %v", wantNodes, graph.Nodes)
+	}
+
+	wantEdges := []CallGraphEdge{
+		{Caller: "checkout", Callee: "payment", Calls: 1},
+		{Caller: "checkout", Callee: "stock", Calls: 2},
+		{Caller: "payment", Callee: "stock", Calls: 1},
+	}
+	if !reflect.DeepEqual(graph.Edges, wantEdges) {
+		t.Errorf("want edges: %v, got: %v", wantEdges, graph.Edges)
+	}
+
+	wantMatrix := [][]int{
+		{0, 1, 2},
+		{0, 0, 1},
+		{0, 0, 0},
+	}
+	if !reflect.DeepEqual(graph.Matrix, wantMatrix) {
+		t.Errorf("want matrix: %v, got: %v", wantMatrix, graph.Matrix)
+	}
+
+	if graph.Traces != 3 {
+		t.Errorf("want traces: 3, got: %d", graph.Traces)
+	}
+}
+
+func Test_BuildCallGraph_Empty(t *testing.T) {
+	graph := BuildCallGraph(nil)
+
+	if graph.Nodes == nil || graph.Edges == nil || graph.Matrix == nil {
+		t.Errorf("want empty, non-nil nodes, edges and matrix, got: %+v", graph)
+	}
+}
+
+func Test_JaegerTraceQuerier_QuerySpans(t *testing.T) {
+	var gotService, gotStart string
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/traces" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		gotService = r.URL.Query().Get("service")
+		gotStart = r.URL.Query().Get("start")
+
+		w.Write([]byte(`{"data":[{"traceID":"t1","spans":[
+			{"traceID":"t1","spanID":"a","references":[],"tags":[{"key":"faas.function_name","type":"string","value":"checkout"}]},
+			{"traceID":"t1","spanID":"b","references":[{"refType":"CHILD_OF","traceID":"t1","spanID":"a"}],"tags":[{"key":"http.status_code","type":"int64","value":200}]}
+		]}]}`))
+	}))
+	defer srv.Close()
+
+	querier := NewJaegerTraceQuerier(srv.Client(), srv.URL+"/", "gateway")
+
+	start := time.Unix(10, 0)
+	spans, err := querier.QuerySpans(context.Background(), start, start.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if gotService != "gateway" {
+		t.Errorf("want service: gateway, got: %s", gotService)
+	}
+	if gotStart != "10000000" {
+		t.Errorf("want start in microseconds: 10000000, got: %s", gotStart)
+	}
+
+	want := []TraceSpan{
+		{TraceID: "t1", SpanID: "a", Function: "checkout"},
+		{TraceID: "t1", SpanID: "b", ParentSpanID: "a"},
+	}
+	if !reflect.DeepEqual(spans, want) {
+		t.Errorf("want spans: %v, got: %v", want, spans)
+	}
+}
+
+func Test_TempoTraceQuerier_QuerySpans(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/search":
+			if q := r.URL.Query().Get("q"); q != "{ span.faas.function_name != nil }" {
+				t.Errorf("unexpected query: %s", q)
+			}
+			w.Write([]byte(`{"traces":[{"traceID":"t1"}]}`))
+		case "/api/traces/t1":
+			w.Write([]byte(`{"batches":[
+				{"scopeSpans":[{"spans":[{"spanId":"a","attributes":[{"key":"faas.function_name","value":{"stringValue":"checkout"}}]}]}]},
+				{"instrumentationLibrarySpans":[{"spans":[{"spanId":"b","parentSpanId":"a","attributes":[{"key":"faas.function_name","value":{"stringValue":"stock"}}]}]}]}
+			]}`))
+		default:
+			t.Errorf("unexpected path: %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	querier := NewTempoTraceQuerier(srv.Client(), srv.URL)
+
+	spans, err := querier.QuerySpans(context.Background(), time.Now().Add(-time.Hour), time.Now())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	want := []TraceSpan{
+		{TraceID: "t1", SpanID: "a", Function: "checkout"},
+		{TraceID: "t1", SpanID: "b", ParentSpanID: "a", Function: "stock"},
+	}
+	if !reflect.DeepEqual(spans, want) {
+		t.Errorf("want spans: %v, got: %v", want, spans)
+	}
+}
@@ -0,0 +1,197 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	// TLSDomainAnnotationKey requests a cert-manager Certificate for the function's domain
+	TLSDomainAnnotationKey = "com.openfaas.tls.domain"
+
+	// certManagerGroupVersion is the cert-manager API that the Certificate is created with
+	certManagerGroupVersion = "cert-manager.io/v1"
+
+	// DefaultCertManagerIssuerKind is used when DeploymentConfig.CertManagerIssuerKind is empty
+	DefaultCertManagerIssuerKind = "Issuer"
+)
+
+// validTLSDomain is a DNS name, which may start with a wildcard
+var validTLSDomain = regexp.MustCompile(`^(\*\.)?[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`)
+
+// certificate is the subset of the cert-manager Certificate resource that is managed for a
+// function
+type certificate struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              certificateSpec `json:"spec"`
+}
+
+type certificateSpec struct {
+	SecretName string               `json:"secretName"`
+	DNSNames   []string             `json:"dnsNames"`
+	IssuerRef  certificateIssuerRef `json:"issuerRef"`
+}
+
+type certificateIssuerRef struct {
+	Name  string `json:"name"`
+	Kind  string `json:"kind,omitempty"`
+	Group string `json:"group,omitempty"`
+}
+
+// CertificateClient manages cert-manager Certificates for functions without a dependency on
+// the cert-manager clientset, the resources are sent as JSON via the REST client.
+type CertificateClient struct {
+	discovery discovery.DiscoveryInterface
+	rest      rest.Interface
+}
+
+// NewCertificateClient returns a CertificateClient that uses the REST client of the clientset
+func NewCertificateClient(kube kubernetes.Interface) CertificateClient {
+	return CertificateClient{
+		discovery: kube.Discovery(),
+		rest:      kube.Discovery().RESTClient(),
+	}
+}
+
+// Available returns true when the cert-manager Certificate CRD is installed
+func (c CertificateClient) Available() bool {
+	if c.rest == nil {
+		return false
+	}
+
+	resources, err := c.discovery.ServerResourcesForGroupVersion(certManagerGroupVersion)
+	if err != nil {
+		return false
+	}
+
+	for _, r := range resources.APIResources {
+		if r.Name == "certificates" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// apply creates or updates the Certificate for a function. The Certificate has the same name
+// as the function and its key pair is stored in the Secret <name>-tls.
+func (c CertificateClient) apply(ctx context.Context, namespace, name, domain string, issuerRef certificateIssuerRef) error {
+	cert := certificate{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: certManagerGroupVersion,
+			Kind:       "Certificate",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    map[string]string{"faas_function": name},
+		},
+		Spec: certificateSpec{
+			SecretName: name + "-tls",
+			DNSNames:   []string{domain},
+			IssuerRef:  issuerRef,
+		},
+	}
+
+	raw, err := c.rest.Get().AbsPath(c.path(namespace, name)).Do(ctx).Raw()
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return err
+	}
+
+	if err == nil {
+		var existing certificate
+		if err := json.Unmarshal(raw, &existing); err != nil {
+			return err
+		}
+		cert.ResourceVersion = existing.ResourceVersion
+
+		body, _ := json.Marshal(cert)
+		return c.rest.Put().AbsPath(c.path(namespace, name)).
+			SetHeader("Content-Type", "application/json").
+			Body(body).Do(ctx).Error()
+	}
+
+	body, _ := json.Marshal(cert)
+	return c.rest.Post().AbsPath(c.path(namespace, "")).
+		SetHeader("Content-Type", "application/json").
+		Body(body).Do(ctx).Error()
+}
+
+// Delete removes the Certificate of a function, a missing Certificate is not an error
+func (c CertificateClient) Delete(ctx context.Context, namespace, name string) error {
+	if c.rest == nil {
+		return nil
+	}
+
+	err := c.rest.Delete().AbsPath(c.path(namespace, name)).Do(ctx).Error()
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return err
+	}
+
+	return nil
+}
+
+func (c CertificateClient) path(namespace, name string) string {
+	path := fmt.Sprintf("/apis/%s/namespaces/%s/certificates", certManagerGroupVersion, namespace)
+	if len(name) > 0 {
+		path += "/" + name
+	}
+	return path
+}
+
+// ValidateTLSDomain returns an error when the TLSDomainAnnotationKey annotation is not a
+// valid DNS name
+func ValidateTLSDomain(annotations map[string]string) error {
+	domain := annotations[TLSDomainAnnotationKey]
+	if len(domain) > 0 && !validTLSDomain.MatchString(domain) {
+		return fmt.Errorf("invalid %s: %q, must be a DNS name", TLSDomainAnnotationKey, domain)
+	}
+	return nil
+}
+
+// ConfigureCertificate creates or updates the cert-manager Certificate of a function that sets
+// the TLSDomainAnnotationKey annotation. Nothing is done when cert-manager is not installed.
+func (f *FunctionFactory) ConfigureCertificate(ctx context.Context, namespace, name string, annotations map[string]string) error {
+	if err := ValidateTLSDomain(annotations); err != nil {
+		return err
+	}
+
+	domain := annotations[TLSDomainAnnotationKey]
+	if len(domain) == 0 {
+		return nil
+	}
+
+	client := NewCertificateClient(f.Client)
+	if !client.Available() {
+		log.Printf("cert-manager is not installed, skipping Certificate for %s.%s\n", name, namespace)
+		return nil
+	}
+
+	if len(f.Config.CertManagerIssuer) == 0 {
+		return fmt.Errorf("%s is set but no cert-manager issuer is configured", TLSDomainAnnotationKey)
+	}
+
+	kind := f.Config.CertManagerIssuerKind
+	if len(kind) == 0 {
+		kind = DefaultCertManagerIssuerKind
+	}
+
+	return client.apply(ctx, namespace, name, domain, certificateIssuerRef{
+		Name:  f.Config.CertManagerIssuer,
+		Kind:  kind,
+		Group: "cert-manager.io",
+	})
+}
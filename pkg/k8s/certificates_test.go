@@ -0,0 +1,133 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	fakerest "k8s.io/client-go/rest/fake"
+)
+
+func Test_ValidateTLSDomain(t *testing.T) {
+	cases := []struct {
+		domain  string
+		wantErr bool
+	}{
+		{domain: ""},
+		{domain: "fn.example.com"},
+		{domain: "*.example.com"},
+		{domain: "Fn.Example.com", wantErr: true},
+		{domain: "fn example.com", wantErr: true},
+		{domain: "https://fn.example.com", wantErr: true},
+	}
+
+	for _, tc := range cases {
+		err := ValidateTLSDomain(map[string]string{TLSDomainAnnotationKey: tc.domain})
+		if tc.wantErr != (err != nil) {
+			t.Errorf("%q: want error: %v, got: %v", tc.domain, tc.wantErr, err)
+		}
+	}
+}
+
+func Test_CertificateClient_Available(t *testing.T) {
+	discovery := &fakediscovery.FakeDiscovery{Fake: &fake.NewSimpleClientset().Fake}
+
+	client := CertificateClient{discovery: discovery, rest: &fakerest.RESTClient{}}
+	if client.Available() {
+		t.Fatalf("want cert-manager to be unavailable without the CRD")
+	}
+
+	discovery.Resources = []*metav1.APIResourceList{{
+		GroupVersion: "cert-manager.io/v1",
+		APIResources: []metav1.APIResource{{Name: "certificates"}},
+	}}
+	if !client.Available() {
+		t.Fatalf("want cert-manager to be available with the CRD")
+	}
+}
+
+func Test_CertificateClient_apply(t *testing.T) {
+	cases := []struct {
+		name       string
+		existing   bool
+		wantMethod string
+	}{
+		{name: "creates a new Certificate", existing: false, wantMethod: http.MethodPost},
+		{name: "updates an existing Certificate", existing: true, wantMethod: http.MethodPut},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var written certificate
+			var gotMethod, gotPath string
+
+			rest := &fakerest.RESTClient{
+				NegotiatedSerializer: scheme.Codecs.WithoutConversion(),
+				Client: fakerest.CreateHTTPClient(func(req *http.Request) (*http.Response, error) {
+					header := http.Header{"Content-Type": []string{"application/json"}}
+
+					if req.Method == http.MethodGet {
+						if !tc.existing {
+							body := `{"kind":"Status","apiVersion":"v1","status":"Failure","reason":"NotFound","code":404}`
+							return &http.Response{StatusCode: http.StatusNotFound, Header: header, Body: ioutil.NopCloser(bytes.NewBufferString(body))}, nil
+						}
+						body := `{"metadata":{"name":"fn","resourceVersion":"7"}}`
+						return &http.Response{StatusCode: http.StatusOK, Header: header, Body: ioutil.NopCloser(bytes.NewBufferString(body))}, nil
+					}
+
+					gotMethod, gotPath = req.Method, req.URL.Path
+					if err := json.NewDecoder(req.Body).Decode(&written); err != nil {
+						t.Fatalf("unexpected error: %s", err)
+					}
+					return &http.Response{StatusCode: http.StatusOK, Header: header, Body: ioutil.NopCloser(bytes.NewBufferString("{}"))}, nil
+				}),
+			}
+
+			client := CertificateClient{rest: rest}
+			err := client.apply(context.Background(), "openfaas-fn", "fn", "fn.example.com", certificateIssuerRef{Name: "letsencrypt", Kind: "ClusterIssuer"})
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if gotMethod != tc.wantMethod {
+				t.Errorf("want method %s, got %s", tc.wantMethod, gotMethod)
+			}
+
+			wantPath := "/apis/cert-manager.io/v1/namespaces/openfaas-fn/certificates"
+			if tc.existing {
+				wantPath += "/fn"
+				if written.ResourceVersion != "7" {
+					t.Errorf("want resourceVersion 7, got %q", written.ResourceVersion)
+				}
+			}
+			if gotPath != wantPath {
+				t.Errorf("want path %s, got %s", wantPath, gotPath)
+			}
+
+			if written.Kind != "Certificate" || written.Spec.SecretName != "fn-tls" ||
+				len(written.Spec.DNSNames) != 1 || written.Spec.DNSNames[0] != "fn.example.com" ||
+				written.Spec.IssuerRef.Name != "letsencrypt" {
+				t.Errorf("unexpected Certificate: %+v", written)
+			}
+		})
+	}
+}
+
+func Test_ConfigureCertificate_SkipsWithoutCertManager(t *testing.T) {
+	factory := mockFactory()
+
+	annotations := map[string]string{TLSDomainAnnotationKey: "fn.example.com"}
+	if err := factory.ConfigureCertificate(context.Background(), "openfaas-fn", "fn", annotations); err != nil {
+		t.Fatalf("want no error without cert-manager, got: %s", err)
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// SpecChecksumAnnotationKey is the checksum of the function's Deployment spec at the time it
+// was deployed or updated by faas-netes, see SpecChecksum
+const SpecChecksumAnnotationKey = "com.openfaas.spec.checksum"
+
+// checksumContainer holds the container fields that the API server does not default, so that
+// the checksum of a live Deployment matches the one computed before it was created
+type checksumContainer struct {
+	Name       string                      `json:"name"`
+	Image      string                      `json:"image"`
+	Command    []string                    `json:"command,omitempty"`
+	Args       []string                    `json:"args,omitempty"`
+	WorkingDir string                      `json:"workingDir,omitempty"`
+	Env        []corev1.EnvVar             `json:"env,omitempty"`
+	EnvFrom    []corev1.EnvFromSource      `json:"envFrom,omitempty"`
+	Resources  corev1.ResourceRequirements `json:"resources"`
+}
+
+type checksumSpec struct {
+	Containers   []checksumContainer `json:"containers"`
+	NodeSelector map[string]string   `json:"nodeSelector,omitempty"`
+	Tolerations  []corev1.Toleration `json:"tolerations,omitempty"`
+}
+
+// SpecChecksum returns a SHA256 checksum of the parts of the Pod template that define the
+// function: the containers' image, command, environment and resources, the node selector and
+// tolerations. Replicas, labels and annotations are left out since they change at runtime.
+func SpecChecksum(deployment *appsv1.Deployment) string {
+	podSpec := deployment.Spec.Template.Spec
+
+	spec := checksumSpec{
+		NodeSelector: podSpec.NodeSelector,
+		Tolerations:  podSpec.Tolerations,
+	}
+	for _, c := range podSpec.Containers {
+		spec.Containers = append(spec.Containers, checksumContainer{
+			Name:       c.Name,
+			Image:      c.Image,
+			Command:    c.Command,
+			Args:       c.Args,
+			WorkingDir: c.WorkingDir,
+			Env:        c.Env,
+			EnvFrom:    c.EnvFrom,
+			Resources:  c.Resources,
+		})
+	}
+
+	// the fields are all JSON safe, map keys are sorted by the encoder
+	data, _ := json.Marshal(spec)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// SetSpecChecksum records the SpecChecksum of the Deployment in its annotations, it is called
+// once the Deployment has been fully configured. The annotations map is copied so that a map
+// shared with the deploy request is not modified.
+func SetSpecChecksum(deployment *appsv1.Deployment) {
+	annotations := make(map[string]string, len(deployment.Annotations)+1)
+	for k, v := range deployment.Annotations {
+		annotations[k] = v
+	}
+	annotations[SpecChecksumAnnotationKey] = SpecChecksum(deployment)
+	deployment.Annotations = annotations
+}
+
+// SpecDrifted returns true when the Deployment no longer matches the checksum recorded when it
+// was last deployed, i.e. it was edited directly. Deployments without a checksum are not
+// reported as drifted.
+func SpecDrifted(deployment *appsv1.Deployment) bool {
+	recorded, ok := deployment.Annotations[SpecChecksumAnnotationKey]
+	if !ok || len(recorded) == 0 {
+		return false
+	}
+	return recorded != SpecChecksum(deployment)
+}
@@ -0,0 +1,100 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func newChecksumDeployment() *appsv1.Deployment {
+	deployment := &appsv1.Deployment{}
+	deployment.Annotations = map[string]string{"com.openfaas.profile": "gpu"}
+	deployment.Spec.Template.Spec.Containers = []corev1.Container{{
+		Name:  "nodeinfo",
+		Image: "functions/nodeinfo:1.0",
+		Env:   []corev1.EnvVar{{Name: "write_debug", Value: "true"}},
+		Resources: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("128Mi")},
+		},
+	}}
+	return deployment
+}
+
+func Test_SetSpecChecksum(t *testing.T) {
+	deployment := newChecksumDeployment()
+	original := deployment.Annotations
+
+	SetSpecChecksum(deployment)
+
+	if _, ok := original[SpecChecksumAnnotationKey]; ok {
+		t.Errorf("want the original annotations map to be left unchanged")
+	}
+	if got := deployment.Annotations[SpecChecksumAnnotationKey]; got != SpecChecksum(deployment) {
+		t.Errorf("want checksum %s, got: %s", SpecChecksum(deployment), got)
+	}
+	if SpecDrifted(deployment) {
+		t.Errorf("want no drift right after the checksum is set")
+	}
+}
+
+func Test_SpecDrifted(t *testing.T) {
+	cases := []struct {
+		name   string
+		modify func(*appsv1.Deployment)
+		want   bool
+	}{
+		{
+			name: "replicas and labels are ignored",
+			modify: func(d *appsv1.Deployment) {
+				replicas := int32(5)
+				d.Spec.Replicas = &replicas
+				d.Spec.Template.Labels = map[string]string{"uid": "1"}
+			},
+			want: false,
+		},
+		{
+			name: "API server defaults are ignored",
+			modify: func(d *appsv1.Deployment) {
+				d.Spec.Template.Spec.Containers[0].TerminationMessagePath = "/dev/termination-log"
+			},
+			want: false,
+		},
+		{
+			name:   "image changed",
+			modify: func(d *appsv1.Deployment) { d.Spec.Template.Spec.Containers[0].Image = "functions/nodeinfo:latest" },
+			want:   true,
+		},
+		{
+			name: "env changed",
+			modify: func(d *appsv1.Deployment) {
+				d.Spec.Template.Spec.Containers[0].Env = append(d.Spec.Template.Spec.Containers[0].Env, corev1.EnvVar{Name: "debug", Value: "1"})
+			},
+			want: true,
+		},
+		{
+			name: "checksum not recorded",
+			modify: func(d *appsv1.Deployment) {
+				delete(d.Annotations, SpecChecksumAnnotationKey)
+				d.Spec.Template.Spec.Containers[0].Image = "other"
+			},
+			want: false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			deployment := newChecksumDeployment()
+			SetSpecChecksum(deployment)
+			tc.modify(deployment)
+
+			if got := SpecDrifted(deployment); got != tc.want {
+				t.Errorf("want drifted: %v, got: %v", tc.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,195 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+const (
+	// DefaultCircuitBreakerThreshold is the number of consecutive failures that open the circuit
+	DefaultCircuitBreakerThreshold = 5
+
+	// DefaultCircuitBreakerCooldown is how long the circuit stays open before a trial call is
+	// allowed through
+	DefaultCircuitBreakerCooldown = 10 * time.Second
+)
+
+// ErrCircuitOpen is returned without calling the Kubernetes API while the circuit is open
+var ErrCircuitOpen = errors.New("the Kubernetes API is unavailable, circuit breaker is open")
+
+// CircuitState is the state of a CircuitBreaker
+type CircuitState int
+
+const (
+	// CircuitClosed lets all calls through
+	CircuitClosed CircuitState = iota
+	// CircuitHalfOpen lets a single trial call through after the cooldown
+	CircuitHalfOpen
+	// CircuitOpen fails all calls with ErrCircuitOpen
+	CircuitOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitHalfOpen:
+		return "half-open"
+	case CircuitOpen:
+		return "open"
+	}
+	return "unknown"
+}
+
+// CircuitBreaker stops calls to the Kubernetes API after a number of consecutive failures, so
+// that requests fail fast rather than queue while the API server is overloaded. A nil
+// CircuitBreaker lets every call through.
+type CircuitBreaker struct {
+	// OnStateChange is called with the lock held whenever the state changes
+	OnStateChange func(from, to CircuitState)
+
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	lock     sync.Mutex
+	state    CircuitState
+	failures int
+	openedAt time.Time
+	trial    bool
+}
+
+// NewCircuitBreaker returns a closed CircuitBreaker which opens after threshold consecutive
+// failures and allows a trial call once cooldown has passed
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+	}
+}
+
+// State returns the current state of the circuit
+func (b *CircuitBreaker) State() CircuitState {
+	if b == nil {
+		return CircuitClosed
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.state
+}
+
+// IsOpen is true when calls would be refused, without taking the trial call of a half-open
+// circuit
+func (b *CircuitBreaker) IsOpen() bool {
+	if b == nil {
+		return false
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	switch b.state {
+	case CircuitOpen:
+		return b.now().Sub(b.openedAt) < b.cooldown
+	case CircuitHalfOpen:
+		return b.trial
+	}
+	return false
+}
+
+// Do runs call unless the circuit is open, in which case ErrCircuitOpen is returned. The
+// error from call is recorded as a failure when it shows the API server to be unavailable.
+func (b *CircuitBreaker) Do(call func() error) error {
+	if b == nil {
+		return call()
+	}
+
+	if err := b.allow(); err != nil {
+		return err
+	}
+
+	err := call()
+	b.record(isAPIUnavailable(err))
+	return err
+}
+
+func (b *CircuitBreaker) allow() error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	switch b.state {
+	case CircuitOpen:
+		if b.now().Sub(b.openedAt) < b.cooldown {
+			return ErrCircuitOpen
+		}
+		b.setState(CircuitHalfOpen)
+		b.trial = true
+	case CircuitHalfOpen:
+		if b.trial {
+			return ErrCircuitOpen
+		}
+		b.trial = true
+	}
+
+	return nil
+}
+
+func (b *CircuitBreaker) record(failed bool) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.trial = false
+
+	if !failed {
+		b.failures = 0
+		b.setState(CircuitClosed)
+		return
+	}
+
+	b.failures++
+	if b.state == CircuitHalfOpen || b.failures >= b.threshold {
+		b.openedAt = b.now()
+		b.setState(CircuitOpen)
+	}
+}
+
+func (b *CircuitBreaker) setState(state CircuitState) {
+	if b.state == state {
+		return
+	}
+
+	from := b.state
+	b.state = state
+	if b.OnStateChange != nil {
+		b.OnStateChange(from, state)
+	}
+}
+
+// isAPIUnavailable is true for errors that show the API server to be overloaded or
+// unreachable, rather than a problem with the request such as a conflict or a missing object
+func isAPIUnavailable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	if k8serrors.IsInternalError(err) ||
+		k8serrors.IsServerTimeout(err) ||
+		k8serrors.IsTimeout(err) ||
+		k8serrors.IsTooManyRequests(err) ||
+		k8serrors.IsServiceUnavailable(err) ||
+		k8serrors.IsUnexpectedServerError(err) {
+		return true
+	}
+
+	var status k8serrors.APIStatus
+	return !errors.As(err, &status)
+}
@@ -0,0 +1,110 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func Test_CircuitBreaker_OpensAndRecovers(t *testing.T) {
+	now := time.Now()
+	b := NewCircuitBreaker(3, 10*time.Second)
+	b.now = func() time.Time { return now }
+
+	var transitions []string
+	b.OnStateChange = func(from, to CircuitState) {
+		transitions = append(transitions, fmt.Sprintf("%s->%s", from, to))
+	}
+
+	unavailable := k8serrors.NewServiceUnavailable("overloaded")
+	calls := 0
+	fail := func() error {
+		calls++
+		return unavailable
+	}
+	succeed := func() error {
+		calls++
+		return nil
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := b.Do(fail); err != unavailable {
+			t.Fatalf("call %d: want the API error, got: %v", i, err)
+		}
+	}
+
+	if b.State() != CircuitOpen || !b.IsOpen() {
+		t.Fatalf("want an open circuit after 3 failures, got: %s", b.State())
+	}
+
+	if err := b.Do(succeed); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("want ErrCircuitOpen, got: %v", err)
+	}
+	if calls != 3 {
+		t.Fatalf("want no call while the circuit is open, got %d calls", calls)
+	}
+
+	now = now.Add(10 * time.Second)
+	if b.IsOpen() {
+		t.Fatalf("want a trial call to be allowed after the cooldown")
+	}
+
+	if err := b.Do(fail); err != unavailable {
+		t.Fatalf("want the API error from the trial call, got: %v", err)
+	}
+	if b.State() != CircuitOpen {
+		t.Fatalf("want a failed trial call to open the circuit, got: %s", b.State())
+	}
+
+	now = now.Add(10 * time.Second)
+	if err := b.Do(succeed); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if b.State() != CircuitClosed {
+		t.Fatalf("want a successful trial call to close the circuit, got: %s", b.State())
+	}
+
+	want := []string{"closed->open", "open->half-open", "half-open->open", "open->half-open", "half-open->closed"}
+	if fmt.Sprint(transitions) != fmt.Sprint(want) {
+		t.Errorf("want transitions %v, got %v", want, transitions)
+	}
+}
+
+func Test_CircuitBreaker_IgnoresRequestErrors(t *testing.T) {
+	b := NewCircuitBreaker(1, 10*time.Second)
+
+	notFound := k8serrors.NewNotFound(schema.GroupResource{Resource: "deployments"}, "nodeinfo")
+	conflict := k8serrors.NewConflict(schema.GroupResource{Resource: "deployments"}, "nodeinfo", errors.New("modified"))
+
+	for _, err := range []error{notFound, conflict} {
+		b.Do(func() error { return err })
+	}
+
+	if b.State() != CircuitClosed {
+		t.Errorf("want the circuit to stay closed for request errors, got: %s", b.State())
+	}
+
+	b.Do(func() error { return errors.New("connection refused") })
+	if b.State() != CircuitOpen {
+		t.Errorf("want a connection error to open the circuit, got: %s", b.State())
+	}
+}
+
+func Test_CircuitBreaker_Nil(t *testing.T) {
+	var b *CircuitBreaker
+
+	called := false
+	if err := b.Do(func() error { called = true; return nil }); err != nil || !called {
+		t.Errorf("want a nil breaker to make the call, got called: %v, err: %v", called, err)
+	}
+	if b.IsOpen() {
+		t.Errorf("want a nil breaker to never be open")
+	}
+}
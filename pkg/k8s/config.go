@@ -3,6 +3,11 @@
 
 package k8s
 
+import (
+	"regexp"
+	"time"
+)
+
 // ProbeConfig holds the deployment liveness and readiness options
 type ProbeConfig struct {
 	InitialDelaySeconds int32
@@ -22,4 +27,69 @@ type DeploymentConfig struct {
 	SetNonRootUser bool
 	// ProfilesNamespace defines which namespace is used to look up available Profiles.
 	ProfilesNamespace string
+	// ProgressDeadlineSeconds is the default number of seconds a function rollout may take
+	// before Kubernetes reports it as failed.
+	ProgressDeadlineSeconds int32
+	// DefaultFunctionAnnotations are added to every function that does not already set them.
+	DefaultFunctionAnnotations map[string]string
+	// DefaultAnnotations are added to the Pod template of every function, annotations set by
+	// the function take precedence.
+	DefaultAnnotations map[string]string
+	// DefaultPodLabels are added to the Pod template of every function, labels set by the
+	// function take precedence.
+	DefaultPodLabels map[string]string
+	// DefaultSchedulerName is the scheduler of function Pods that do not set
+	// com.openfaas.scheduler-name, when empty the Kubernetes default scheduler is used.
+	DefaultSchedulerName string
+	// NodePoolLabel is the node label that com.openfaas.nodepool is matched against, such as
+	// cloud.google.com/gke-nodepool.
+	NodePoolLabel string
+	// PDBMinAvailable is the minAvailable of the PodDisruptionBudget created for functions with
+	// more than one replica, when empty only functions with com.openfaas.pdb.min-available get one.
+	PDBMinAvailable string
+	// EnablePodMetricsScraping adds Prometheus scrape annotations to the Pods of every function
+	EnablePodMetricsScraping bool
+	// BackupAnnotations are added to the Pod template of every function for backup tooling,
+	// BackupVolumesPlaceholder in a value is replaced with the function's volumes.
+	BackupAnnotations map[string]string
+	// MeshInjectDisableAnnotations are added to the Pod template of functions that set
+	// com.openfaas.mesh.inject=false, when nil DefaultMeshInjectDisableAnnotations is used.
+	MeshInjectDisableAnnotations map[string]string
+	// ServiceMesh is the key of the ServiceMeshInjectAnnotations added to every Pod template
+	ServiceMesh string
+	// AutoZoneSpread adds a zone TopologySpreadConstraint to functions with more than two
+	// replicas.
+	AutoZoneSpread bool
+	// CertManagerIssuer is the cert-manager issuer for functions that set com.openfaas.tls.domain
+	CertManagerIssuer string
+	// CertManagerIssuerKind is the kind of CertManagerIssuer, either Issuer or ClusterIssuer
+	CertManagerIssuerKind string
+	// MaxFunctionNameLength is the longest function name that may be deployed, 0 disables the check
+	MaxFunctionNameLength int
+	// AllowedUnsafeSysctls may be set by Profiles in addition to the safe sysctls, a trailing "*"
+	// matches a prefix
+	AllowedUnsafeSysctls []string
+	// RenderManifests makes the deploy handler return the manifests of a function instead of
+	// applying them
+	RenderManifests bool
+	// RenderConfigMap is the ConfigMap that rendered manifests are also written to, when empty
+	// they are only returned
+	RenderConfigMap string
+	// PSAEnforceLevel is the Pod Security Standard that is enforced on function namespaces that
+	// do not set one, when empty namespaces are left unchanged
+	PSAEnforceLevel string
+	// MaxFunctionsPerNamespace is the number of functions that may be deployed to a namespace,
+	// 0 is unlimited
+	MaxFunctionsPerNamespace int
+	// FunctionQuotaConfigMap is a ConfigMap in ProfilesNamespace with a quota for each
+	// namespace that overrides MaxFunctionsPerNamespace
+	FunctionQuotaConfigMap string
+	// UpdateConflictRetries is how many times an update that conflicts with a concurrent change
+	// is retried against the latest version of the object
+	UpdateConflictRetries int
+	// UpdateWaitTimeout is how long an update with ?wait=true waits for the rollout to complete
+	UpdateWaitTimeout time.Duration
+	// ImageNameRegex must match the image of every function that is deployed, when nil any
+	// image is accepted
+	ImageNameRegex *regexp.Regexp
 }
@@ -0,0 +1,58 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	appsv1 "k8s.io/api/apps/v1"
+)
+
+// CordonedAnnotationKey is set on the Deployment of a function that is cordoned, the proxy
+// answers its invocations with a 503 while its replicas are left running
+const CordonedAnnotationKey = "com.openfaas.cordoned"
+
+// IsCordoned returns true when the annotations mark the function as cordoned
+func IsCordoned(annotations map[string]string) bool {
+	return annotations[CordonedAnnotationKey] == "true"
+}
+
+// Cordon marks the Deployment as cordoned, the annotation is set on the Deployment rather
+// than the Pod template so that it does not trigger a rollout. It returns false when the
+// Deployment is already cordoned.
+func Cordon(deployment *appsv1.Deployment) bool {
+	if IsCordoned(deployment.Annotations) {
+		return false
+	}
+
+	if deployment.Annotations == nil {
+		deployment.Annotations = map[string]string{}
+	}
+	deployment.Annotations[CordonedAnnotationKey] = "true"
+	return true
+}
+
+// Uncordon is the inverse of Cordon, it returns false when the Deployment is not cordoned
+func Uncordon(deployment *appsv1.Deployment) bool {
+	if !IsCordoned(deployment.Annotations) {
+		return false
+	}
+
+	delete(deployment.Annotations, CordonedAnnotationKey)
+	return true
+}
+
+// CopyCordonAnnotation copies the cordon from the annotations of the existing Deployment into
+// the annotations of its replacement, so that updating a cordoned function keeps it cordoned.
+func CopyCordonAnnotation(from, to map[string]string) map[string]string {
+	if !IsCordoned(from) {
+		return to
+	}
+
+	res := make(map[string]string, len(to)+1)
+	for k, v := range to {
+		res[k] = v
+	}
+	res[CordonedAnnotationKey] = from[CordonedAnnotationKey]
+
+	return res
+}
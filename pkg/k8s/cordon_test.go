@@ -0,0 +1,50 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+)
+
+func Test_CordonUncordon(t *testing.T) {
+	deployment := &appsv1.Deployment{}
+
+	if !Cordon(deployment) {
+		t.Fatalf("want the first cordon to change the Deployment")
+	}
+	if !IsCordoned(deployment.Annotations) {
+		t.Fatalf("want the Deployment cordoned")
+	}
+	if Cordon(deployment) {
+		t.Errorf("want no change when already cordoned")
+	}
+
+	if !Uncordon(deployment) {
+		t.Fatalf("want uncordon to change the Deployment")
+	}
+	if IsCordoned(deployment.Annotations) {
+		t.Errorf("want the Deployment uncordoned")
+	}
+	if Uncordon(deployment) {
+		t.Errorf("want no change when not cordoned")
+	}
+}
+
+func Test_CopyCordonAnnotation(t *testing.T) {
+	annotations := map[string]string{"topic": "faas-request"}
+
+	got := CopyCordonAnnotation(map[string]string{CordonedAnnotationKey: "true"}, annotations)
+	if !IsCordoned(got) || got["topic"] != "faas-request" {
+		t.Errorf("want the cordon copied alongside the new annotations, got %v", got)
+	}
+	if IsCordoned(annotations) {
+		t.Errorf("want the input annotations left unchanged")
+	}
+
+	if got := CopyCordonAnnotation(map[string]string{}, annotations); len(got) != 1 {
+		t.Errorf("want the annotations unchanged when not cordoned, got %v", got)
+	}
+}
@@ -0,0 +1,35 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// DNSPolicyAnnotationKey sets the DNS policy of the function's Pods, i.e.
+// ClusterFirstWithHostNet for a function that uses the host network
+const DNSPolicyAnnotationKey = "com.openfaas.dns.policy"
+
+// ConfigureDNSPolicy sets the DNSPolicy of the Pod template from the DNSPolicyAnnotationKey
+// annotation, or ClusterFirst when it is not set, so that removing the annotation on update
+// restores the default. None is not accepted since functions cannot set a dnsConfig.
+func (f *FunctionFactory) ConfigureDNSPolicy(annotations map[string]string, deployment *appsv1.Deployment) error {
+	policy := corev1.DNSClusterFirst
+
+	if v, ok := annotations[DNSPolicyAnnotationKey]; ok && len(v) > 0 {
+		switch corev1.DNSPolicy(v) {
+		case corev1.DNSClusterFirst, corev1.DNSClusterFirstWithHostNet, corev1.DNSDefault:
+			policy = corev1.DNSPolicy(v)
+		default:
+			return fmt.Errorf("invalid %s: %q, must be one of %s, %s or %s", DNSPolicyAnnotationKey, v,
+				corev1.DNSClusterFirst, corev1.DNSClusterFirstWithHostNet, corev1.DNSDefault)
+		}
+	}
+
+	deployment.Spec.Template.Spec.DNSPolicy = policy
+	return nil
+}
@@ -0,0 +1,55 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func Test_ConfigureDNSPolicy(t *testing.T) {
+	cases := []struct {
+		name    string
+		value   string
+		want    corev1.DNSPolicy
+		wantErr bool
+	}{
+		{name: "no annotation defaults to ClusterFirst", want: corev1.DNSClusterFirst},
+		{name: "host network", value: "ClusterFirstWithHostNet", want: corev1.DNSClusterFirstWithHostNet},
+		{name: "node DNS", value: "Default", want: corev1.DNSDefault},
+		{name: "None needs a dnsConfig", value: "None", wantErr: true},
+		{name: "unknown policy", value: "clusterfirst", wantErr: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			factory := mockFactory()
+
+			deployment := &appsv1.Deployment{}
+			deployment.Spec.Template.Spec.DNSPolicy = corev1.DNSDefault
+
+			annotations := map[string]string{}
+			if len(tc.value) > 0 {
+				annotations[DNSPolicyAnnotationKey] = tc.value
+			}
+
+			err := factory.ConfigureDNSPolicy(annotations, deployment)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("want error for %q", tc.value)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if got := deployment.Spec.Template.Spec.DNSPolicy; got != tc.want {
+				t.Errorf("want DNSPolicy: %q, got: %q", tc.want, got)
+			}
+		})
+	}
+}
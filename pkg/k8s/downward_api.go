@@ -0,0 +1,143 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// DownwardAPIAnnotationKey injects Pod metadata into the function as environment variables. The
+// value is a comma separated list of preset names, i.e. POD_NAME, or NAME=fieldPath entries,
+// i.e. POD_IP=status.podIP.
+const DownwardAPIAnnotationKey = "com.openfaas.downward-api"
+
+// DownwardAPIPresets are the named environment variables that can be requested without a
+// field path
+var DownwardAPIPresets = map[string]string{
+	"POD_NAME":             "metadata.name",
+	"POD_NAMESPACE":        "metadata.namespace",
+	"POD_UID":              "metadata.uid",
+	"POD_IP":               "status.podIP",
+	"NODE_NAME":            "spec.nodeName",
+	"HOST_IP":              "status.hostIP",
+	"SERVICE_ACCOUNT_NAME": "spec.serviceAccountName",
+}
+
+// downwardAPIFieldPaths are the field paths that Kubernetes supports for environment variables
+var downwardAPIFieldPaths = map[string]bool{
+	"metadata.name":           true,
+	"metadata.namespace":      true,
+	"metadata.uid":            true,
+	"spec.nodeName":           true,
+	"spec.serviceAccountName": true,
+	"status.hostIP":           true,
+	"status.podIP":            true,
+	"status.podIPs":           true,
+}
+
+var (
+	// validDownwardAPISubscript matches a single label or annotation, i.e. metadata.labels['app']
+	validDownwardAPISubscript = regexp.MustCompile(`^metadata\.(labels|annotations)\['[A-Za-z0-9][-A-Za-z0-9_./]*'\]$`)
+
+	// validEnvVarName is the format Kubernetes accepts for environment variable names
+	validEnvVarName = regexp.MustCompile(`^[-._a-zA-Z][-._a-zA-Z0-9]*$`)
+)
+
+// DownwardAPIEnvVars parses the DownwardAPIAnnotationKey annotation into environment
+// variables that use valueFrom.fieldRef.
+func DownwardAPIEnvVars(annotations map[string]string) ([]corev1.EnvVar, error) {
+	value := strings.TrimSpace(annotations[DownwardAPIAnnotationKey])
+	if len(value) == 0 {
+		return nil, nil
+	}
+
+	var envVars []corev1.EnvVar
+	seen := map[string]bool{}
+
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if len(entry) == 0 {
+			continue
+		}
+
+		name, fieldPath := entry, ""
+		if parts := strings.SplitN(entry, "=", 2); len(parts) == 2 {
+			name, fieldPath = strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+		} else {
+			preset, ok := DownwardAPIPresets[name]
+			if !ok {
+				return nil, fmt.Errorf("invalid %s: unknown preset %q", DownwardAPIAnnotationKey, name)
+			}
+			fieldPath = preset
+		}
+
+		if !validEnvVarName.MatchString(name) {
+			return nil, fmt.Errorf("invalid %s: %q is not a valid environment variable name", DownwardAPIAnnotationKey, name)
+		}
+
+		if !downwardAPIFieldPaths[fieldPath] && !validDownwardAPISubscript.MatchString(fieldPath) {
+			return nil, fmt.Errorf("invalid %s: unsupported field path %q for %s", DownwardAPIAnnotationKey, fieldPath, name)
+		}
+
+		if seen[name] {
+			return nil, fmt.Errorf("invalid %s: %s is set more than once", DownwardAPIAnnotationKey, name)
+		}
+		seen[name] = true
+
+		envVars = append(envVars, corev1.EnvVar{
+			Name: name,
+			ValueFrom: &corev1.EnvVarSource{
+				FieldRef: &corev1.ObjectFieldSelector{
+					APIVersion: "v1",
+					FieldPath:  fieldPath,
+				},
+			},
+		})
+	}
+
+	return envVars, nil
+}
+
+// ConfigureDownwardAPI adds the environment variables from the DownwardAPIAnnotationKey
+// annotation to the function container. Variables previously added from the annotation are
+// removed first, so it is safe to use for both create and update. It is an error for the
+// annotation to use the name of an environment variable that the function already sets.
+func (f *FunctionFactory) ConfigureDownwardAPI(annotations map[string]string, deployment *appsv1.Deployment) error {
+	envVars, err := DownwardAPIEnvVars(annotations)
+	if err != nil {
+		return err
+	}
+
+	if len(deployment.Spec.Template.Spec.Containers) == 0 {
+		return nil
+	}
+
+	container := &deployment.Spec.Template.Spec.Containers[0]
+
+	env := []corev1.EnvVar{}
+	existing := map[string]bool{}
+	for _, e := range container.Env {
+		if e.ValueFrom != nil && e.ValueFrom.FieldRef != nil {
+			continue
+		}
+		env = append(env, e)
+		existing[e.Name] = true
+	}
+
+	for _, e := range envVars {
+		if existing[e.Name] {
+			return fmt.Errorf("invalid %s: %s is already set as an environment variable", DownwardAPIAnnotationKey, e.Name)
+		}
+		env = append(env, e)
+	}
+
+	container.Env = env
+
+	return nil
+}
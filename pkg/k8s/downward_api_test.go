@@ -0,0 +1,107 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func Test_DownwardAPIEnvVars(t *testing.T) {
+	cases := []struct {
+		name    string
+		value   string
+		want    map[string]string
+		wantErr bool
+	}{
+		{name: "no annotation", value: "", want: map[string]string{}},
+		{
+			name:  "presets",
+			value: "POD_NAME, POD_NAMESPACE,NODE_NAME",
+			want: map[string]string{
+				"POD_NAME":      "metadata.name",
+				"POD_NAMESPACE": "metadata.namespace",
+				"NODE_NAME":     "spec.nodeName",
+			},
+		},
+		{
+			name:  "field paths",
+			value: "MY_IP=status.podIP,TEAM=metadata.labels['team']",
+			want: map[string]string{
+				"MY_IP": "status.podIP",
+				"TEAM":  "metadata.labels['team']",
+			},
+		},
+		{name: "unknown preset", value: "POD_COLOUR", wantErr: true},
+		{name: "unsupported field path", value: "X=spec.containers", wantErr: true},
+		{name: "invalid name", value: "1 X=metadata.name", wantErr: true},
+		{name: "duplicate name", value: "POD_NAME,POD_NAME=metadata.uid", wantErr: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			envVars, err := DownwardAPIEnvVars(map[string]string{DownwardAPIAnnotationKey: tc.value})
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("want error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if len(envVars) != len(tc.want) {
+				t.Fatalf("want %d env vars, got %+v", len(tc.want), envVars)
+			}
+			for _, e := range envVars {
+				if e.ValueFrom == nil || e.ValueFrom.FieldRef == nil {
+					t.Fatalf("want %s to use a fieldRef", e.Name)
+				}
+				if e.ValueFrom.FieldRef.FieldPath != tc.want[e.Name] {
+					t.Errorf("want %s=%s, got %s", e.Name, tc.want[e.Name], e.ValueFrom.FieldRef.FieldPath)
+				}
+			}
+		})
+	}
+}
+
+func Test_ConfigureDownwardAPI(t *testing.T) {
+	factory := mockFactory()
+
+	deployment := &appsv1.Deployment{}
+	deployment.Spec.Template.Spec.Containers = []corev1.Container{{
+		Name: "fn",
+		Env:  []corev1.EnvVar{{Name: "write_debug", Value: "true"}},
+	}}
+
+	annotations := map[string]string{DownwardAPIAnnotationKey: "POD_NAME"}
+
+	// applying twice must not duplicate the variables
+	for i := 0; i < 2; i++ {
+		if err := factory.ConfigureDownwardAPI(annotations, deployment); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	env := deployment.Spec.Template.Spec.Containers[0].Env
+	if len(env) != 2 || env[0].Name != "write_debug" || env[1].Name != "POD_NAME" {
+		t.Fatalf("unexpected env: %+v", env)
+	}
+
+	// removing the annotation removes the variables
+	if err := factory.ConfigureDownwardAPI(map[string]string{}, deployment); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if env := deployment.Spec.Template.Spec.Containers[0].Env; len(env) != 1 {
+		t.Fatalf("unexpected env: %+v", env)
+	}
+
+	conflict := map[string]string{DownwardAPIAnnotationKey: "write_debug=metadata.name"}
+	if err := factory.ConfigureDownwardAPI(conflict, deployment); err == nil {
+		t.Fatalf("want error for a conflicting env var")
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	appslisters "k8s.io/client-go/listers/apps/v1"
+)
+
+const (
+	// ExecMaxDurationAnnotationKey is the longest a Pod of the function may run for, i.e. 10m,
+	// before it is deleted and replaced by the Deployment, when exec_deadline_watchdog is enabled
+	ExecMaxDurationAnnotationKey = "com.openfaas.exec.max-duration"
+
+	// execDeadlineInterval is how often the Pods of annotated functions are checked
+	execDeadlineInterval = 30 * time.Second
+)
+
+// ExecMaxDuration returns the ExecMaxDurationAnnotationKey duration, 0 when the annotation is
+// not set
+func ExecMaxDuration(annotations map[string]string) (time.Duration, error) {
+	v, ok := annotations[ExecMaxDurationAnnotationKey]
+	if !ok || len(v) == 0 {
+		return 0, nil
+	}
+
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid %s: %q, must be a positive duration", ExecMaxDurationAnnotationKey, v)
+	}
+
+	return d, nil
+}
+
+// ExecDeadlineWatchdog deletes the Pods of functions that have been running for longer than
+// their ExecMaxDurationAnnotationKey, the Deployment then replaces them with new Pods.
+type ExecDeadlineWatchdog struct {
+	client      kubernetes.Interface
+	deployments appslisters.DeploymentLister
+	now         func() time.Time
+}
+
+// NewExecDeadlineWatchdog returns a watchdog for the functions in the Deployment lister
+func NewExecDeadlineWatchdog(client kubernetes.Interface, deployments appslisters.DeploymentLister) *ExecDeadlineWatchdog {
+	return &ExecDeadlineWatchdog{
+		client:      client,
+		deployments: deployments,
+		now:         time.Now,
+	}
+}
+
+// Run checks the functions every execDeadlineInterval until stopCh is closed
+func (w *ExecDeadlineWatchdog) Run(stopCh <-chan struct{}) {
+	wait.Until(func() {
+		if err := w.Sweep(context.Background()); err != nil {
+			log.Printf("Exec deadline watchdog error: %s\n", err)
+		}
+	}, execDeadlineInterval, stopCh)
+}
+
+// Sweep deletes the Running Pods of annotated functions whose start time is further in the
+// past than the function's maximum duration
+func (w *ExecDeadlineWatchdog) Sweep(ctx context.Context) error {
+	req, err := labels.NewRequirement("faas_function", selection.Exists, []string{})
+	if err != nil {
+		return err
+	}
+
+	deployments, err := w.deployments.List(labels.NewSelector().Add(*req))
+	if err != nil {
+		return err
+	}
+
+	now := w.now()
+	for _, deployment := range deployments {
+		maxDuration, err := ExecMaxDuration(deployment.Annotations)
+		if err != nil {
+			log.Printf("Function %s.%s: %s\n", deployment.Name, deployment.Namespace, err)
+			continue
+		}
+		if maxDuration == 0 {
+			continue
+		}
+
+		pods, err := w.client.CoreV1().Pods(deployment.Namespace).List(ctx, metav1.ListOptions{
+			LabelSelector: "faas_function=" + deployment.Name,
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, pod := range pods.Items {
+			if pod.Status.Phase != corev1.PodRunning || pod.Status.StartTime == nil || pod.DeletionTimestamp != nil {
+				continue
+			}
+
+			running := now.Sub(pod.Status.StartTime.Time)
+			if running <= maxDuration {
+				continue
+			}
+
+			log.Printf("Function %s.%s pod %s exceeded its max duration of %s by %s, deleting\n",
+				deployment.Name, deployment.Namespace, pod.Name, maxDuration, (running - maxDuration).Round(time.Second))
+
+			err := w.client.CoreV1().Pods(pod.Namespace).Delete(ctx, pod.Name, metav1.DeleteOptions{})
+			if err != nil {
+				log.Printf("Function %s.%s pod %s delete error: %s\n", deployment.Name, deployment.Namespace, pod.Name, err)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,95 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	appslisters "k8s.io/client-go/listers/apps/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func Test_ExecMaxDuration(t *testing.T) {
+	cases := []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{value: "", want: 0},
+		{value: "10m", want: 10 * time.Minute},
+		{value: "ten", wantErr: true},
+		{value: "-1s", wantErr: true},
+	}
+
+	for _, tc := range cases {
+		got, err := ExecMaxDuration(map[string]string{ExecMaxDurationAnnotationKey: tc.value})
+		if tc.wantErr != (err != nil) {
+			t.Errorf("%q: want error: %v, got: %v", tc.value, tc.wantErr, err)
+		}
+		if got != tc.want {
+			t.Errorf("%q: want: %s, got: %s", tc.value, tc.want, got)
+		}
+	}
+}
+
+func Test_ExecDeadlineWatchdog_Sweep(t *testing.T) {
+	now := time.Now()
+	pod := func(name string, phase corev1.PodPhase, started time.Time) *corev1.Pod {
+		startTime := metav1.NewTime(started)
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "openfaas-fn",
+				Labels:    map[string]string{"faas_function": "batch"},
+			},
+			Status: corev1.PodStatus{Phase: phase, StartTime: &startTime},
+		}
+	}
+
+	client := fake.NewSimpleClientset(
+		pod("batch-expired", corev1.PodRunning, now.Add(-time.Hour)),
+		pod("batch-recent", corev1.PodRunning, now.Add(-time.Minute)),
+		pod("batch-pending", corev1.PodPending, now.Add(-time.Hour)),
+	)
+
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	indexer.Add(&appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "batch",
+			Namespace:   "openfaas-fn",
+			Labels:      map[string]string{"faas_function": "batch"},
+			Annotations: map[string]string{ExecMaxDurationAnnotationKey: "10m"},
+		},
+	})
+
+	watchdog := NewExecDeadlineWatchdog(client, appslisters.NewDeploymentLister(indexer))
+	watchdog.now = func() time.Time { return now }
+
+	if err := watchdog.Sweep(context.TODO()); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	pods, err := client.CoreV1().Pods("openfaas-fn").List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	remaining := map[string]bool{}
+	for _, p := range pods.Items {
+		remaining[p.Name] = true
+	}
+
+	if remaining["batch-expired"] {
+		t.Errorf("want the expired pod to be deleted")
+	}
+	if !remaining["batch-recent"] || !remaining["batch-pending"] {
+		t.Errorf("want the recent and pending pods to be kept, got: %v", remaining)
+	}
+}
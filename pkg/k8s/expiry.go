@@ -0,0 +1,287 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	appslisters "k8s.io/client-go/listers/apps/v1"
+)
+
+const (
+	// ExpiresAtAnnotationKey is the RFC3339 time after which the function is deleted, it is
+	// intended for functions that are deployed for short-lived testing
+	ExpiresAtAnnotationKey = "com.openfaas.expires-at"
+
+	// TTLAnnotationKey is how long a function lives after it was created, i.e. 72h, after which
+	// it is deleted. It is intended for preview and other ephemeral functions.
+	TTLAnnotationKey = "com.openfaas.ttl"
+
+	// FunctionExpiredReason is the reason of the Event that is recorded on a function when it
+	// is deleted for expiring
+	FunctionExpiredReason = "Expired"
+)
+
+// ExpiresAt returns the ExpiresAtAnnotationKey time, the zero time when the annotation is not
+// set
+func ExpiresAt(annotations map[string]string) (time.Time, error) {
+	v, ok := annotations[ExpiresAtAnnotationKey]
+	if !ok || len(v) == 0 {
+		return time.Time{}, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s: %q, must be an RFC3339 time", ExpiresAtAnnotationKey, v)
+	}
+
+	return t, nil
+}
+
+// FunctionTTL returns the TTLAnnotationKey duration, 0 when the annotation is not set
+func FunctionTTL(annotations map[string]string) (time.Duration, error) {
+	v, ok := annotations[TTLAnnotationKey]
+	if !ok || len(v) == 0 {
+		return 0, nil
+	}
+
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid %s: %q, must be a positive duration", TTLAnnotationKey, v)
+	}
+
+	return d, nil
+}
+
+// FunctionExpiry returns why a function created at created has expired by now, from either
+// its TTLAnnotationKey or its ExpiresAtAnnotationKey. An empty reason is returned when it has
+// not expired.
+func FunctionExpiry(annotations map[string]string, created, now time.Time) (string, error) {
+	ttl, err := FunctionTTL(annotations)
+	if err != nil {
+		return "", err
+	}
+	if ttl > 0 && now.Sub(created) >= ttl {
+		return fmt.Sprintf("after its TTL of %s", ttl), nil
+	}
+
+	expiresAt, err := ExpiresAt(annotations)
+	if err != nil {
+		return "", err
+	}
+	if !expiresAt.IsZero() && !now.Before(expiresAt) {
+		return fmt.Sprintf("as it expired at %s", expiresAt.UTC().Format(time.RFC3339)), nil
+	}
+
+	return "", nil
+}
+
+// ExpiringFunction is a function that is checked by the ExpirySweeper
+type ExpiringFunction struct {
+	Name        string
+	Namespace   string
+	Annotations map[string]string
+	Created     time.Time
+}
+
+// ExpiryTarget lists and deletes the functions of the provider, the controller deletes the
+// resources of a function directly and the operator deletes its Function
+type ExpiryTarget interface {
+	// ListFunctions returns the functions that are not already being deleted
+	ListFunctions() ([]ExpiringFunction, error)
+
+	// DeleteFunction deletes the function and everything it owns, reason is why it expired
+	DeleteFunction(ctx context.Context, function ExpiringFunction, reason string) error
+}
+
+// ExpirySweeper deletes the functions whose TTLAnnotationKey has elapsed or whose
+// ExpiresAtAnnotationKey time has passed
+type ExpirySweeper struct {
+	target ExpiryTarget
+}
+
+// NewExpirySweeper returns a sweeper for the functions of the target
+func NewExpirySweeper(target ExpiryTarget) *ExpirySweeper {
+	return &ExpirySweeper{target: target}
+}
+
+// Run checks the functions every interval until stopCh is closed, an interval of 0 disables
+// the sweeper
+func (s *ExpirySweeper) Run(interval time.Duration, stopCh <-chan struct{}) {
+	if interval <= 0 {
+		return
+	}
+
+	wait.Until(func() {
+		if _, err := s.Sweep(context.Background(), time.Now()); err != nil {
+			log.Printf("Expiry sweeper error: %s\n", err)
+		}
+	}, interval, stopCh)
+}
+
+// Sweep deletes the functions that have expired by now and returns their namespace/name keys
+func (s *ExpirySweeper) Sweep(ctx context.Context, now time.Time) ([]string, error) {
+	functions, err := s.target.ListFunctions()
+	if err != nil {
+		return nil, err
+	}
+
+	var expired []string
+	for _, function := range functions {
+		reason, err := FunctionExpiry(function.Annotations, function.Created, now)
+		if err != nil {
+			log.Printf("Function %s.%s: %s\n", function.Name, function.Namespace, err)
+			continue
+		}
+		if len(reason) == 0 {
+			continue
+		}
+
+		if err := s.target.DeleteFunction(ctx, function, reason); err != nil {
+			log.Printf("Function %s.%s expiry delete error: %s\n", function.Name, function.Namespace, err)
+			continue
+		}
+
+		log.Printf("Function %s.%s deleted %s\n", function.Name, function.Namespace, reason)
+		expired = append(expired, function.Namespace+"/"+function.Name)
+	}
+
+	return expired, nil
+}
+
+// DeploymentExpiryTarget is the ExpiryTarget of the controller, where a function is its
+// Deployment and the resources that are created alongside it
+type DeploymentExpiryTarget struct {
+	client      kubernetes.Interface
+	deployments appslisters.DeploymentLister
+	now         func() time.Time
+}
+
+// NewDeploymentExpiryTarget returns a target for the functions in the Deployment lister
+func NewDeploymentExpiryTarget(client kubernetes.Interface, deployments appslisters.DeploymentLister) *DeploymentExpiryTarget {
+	return &DeploymentExpiryTarget{
+		client:      client,
+		deployments: deployments,
+		now:         time.Now,
+	}
+}
+
+// ListFunctions returns the Deployments with the faas_function label
+func (t *DeploymentExpiryTarget) ListFunctions() ([]ExpiringFunction, error) {
+	req, err := labels.NewRequirement("faas_function", selection.Exists, []string{})
+	if err != nil {
+		return nil, err
+	}
+
+	deployments, err := t.deployments.List(labels.NewSelector().Add(*req))
+	if err != nil {
+		return nil, err
+	}
+
+	functions := make([]ExpiringFunction, 0, len(deployments))
+	for _, deployment := range deployments {
+		if deployment.DeletionTimestamp != nil {
+			continue
+		}
+
+		functions = append(functions, ExpiringFunction{
+			Name:        deployment.Name,
+			Namespace:   deployment.Namespace,
+			Annotations: deployment.Annotations,
+			Created:     deployment.CreationTimestamp.Time,
+		})
+	}
+
+	return functions, nil
+}
+
+// DeleteFunction records an Event on the Deployment to leave an audit trail, then deletes the
+// function's resources
+func (t *DeploymentExpiryTarget) DeleteFunction(ctx context.Context, function ExpiringFunction, reason string) error {
+	deployment, err := t.deployments.Deployments(function.Namespace).Get(function.Name)
+	if err != nil {
+		return err
+	}
+
+	now := t.now()
+	event := &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s.%x", deployment.Name, now.UnixNano()),
+			Namespace: deployment.Namespace,
+		},
+		InvolvedObject: corev1.ObjectReference{
+			Kind:            "Deployment",
+			APIVersion:      "apps/v1",
+			Name:            deployment.Name,
+			Namespace:       deployment.Namespace,
+			UID:             deployment.UID,
+			ResourceVersion: deployment.ResourceVersion,
+		},
+		Reason:         FunctionExpiredReason,
+		Message:        "Function deleted " + reason,
+		Type:           corev1.EventTypeNormal,
+		Source:         corev1.EventSource{Component: "faas-netes"},
+		FirstTimestamp: metav1.NewTime(now),
+		LastTimestamp:  metav1.NewTime(now),
+		Count:          1,
+	}
+	if _, err := t.client.CoreV1().Events(deployment.Namespace).Create(ctx, event, metav1.CreateOptions{}); err != nil {
+		log.Printf("Function %s.%s expiry event error: %s\n", deployment.Name, deployment.Namespace, err)
+	}
+
+	return DeleteFunctionResources(ctx, t.client, function.Namespace, function.Name, function.Annotations)
+}
+
+// DeleteFunctionResources removes the Deployment and Service of a function in the controller,
+// along with the Deployments and Services of its variants, its PodDisruptionBudget and its
+// Certificate. Variants are deleted by label because they are only owned by the function's
+// Deployment once it has been created.
+func DeleteFunctionResources(ctx context.Context, client kubernetes.Interface, namespace, name string, annotations map[string]string) error {
+	foregroundPolicy := metav1.DeletePropagationForeground
+	opts := metav1.DeleteOptions{PropagationPolicy: &foregroundPolicy}
+
+	deployments := client.AppsV1().Deployments(namespace)
+	services := client.CoreV1().Services(namespace)
+
+	variants, err := deployments.List(ctx, metav1.ListOptions{LabelSelector: VariantOfLabel + "=" + name})
+	if err != nil {
+		return fmt.Errorf("unable to list variants: %s", err)
+	}
+
+	names := []string{name}
+	for _, variant := range variants.Items {
+		names = append(names, variant.Name)
+	}
+
+	for _, item := range names {
+		if err := deployments.Delete(ctx, item, opts); err != nil && !k8serrors.IsNotFound(err) {
+			return err
+		}
+		if err := services.Delete(ctx, item, opts); err != nil && !k8serrors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	if err := DeletePDB(ctx, client, namespace, name); err != nil {
+		log.Printf("PodDisruptionBudget delete error: %v\n", err)
+	}
+
+	if len(annotations[TLSDomainAnnotationKey]) > 0 {
+		if err := NewCertificateClient(client).Delete(ctx, namespace, name); err != nil {
+			log.Printf("Certificate delete error: %v\n", err)
+		}
+	}
+
+	return nil
+}
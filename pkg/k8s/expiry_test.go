@@ -0,0 +1,176 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	appslisters "k8s.io/client-go/listers/apps/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func Test_ExpiresAt(t *testing.T) {
+	cases := []struct {
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{value: "", want: time.Time{}},
+		{value: "2021-03-10T12:00:00Z", want: time.Date(2021, time.March, 10, 12, 0, 0, 0, time.UTC)},
+		{value: "tomorrow", wantErr: true},
+		{value: "2021-03-10", wantErr: true},
+	}
+
+	for _, tc := range cases {
+		got, err := ExpiresAt(map[string]string{ExpiresAtAnnotationKey: tc.value})
+		if tc.wantErr != (err != nil) {
+			t.Errorf("%q: want error: %v, got: %v", tc.value, tc.wantErr, err)
+		}
+		if !got.Equal(tc.want) {
+			t.Errorf("%q: want: %s, got: %s", tc.value, tc.want, got)
+		}
+	}
+}
+
+func Test_FunctionExpiry(t *testing.T) {
+	now := time.Date(2021, time.March, 10, 12, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name        string
+		annotations map[string]string
+		age         time.Duration
+		wantExpired bool
+		wantErr     bool
+	}{
+		{name: "no annotations", annotations: map[string]string{}, age: 48 * time.Hour},
+		{name: "ttl elapsed", annotations: map[string]string{TTLAnnotationKey: "1h"}, age: 2 * time.Hour, wantExpired: true},
+		{name: "ttl not elapsed", annotations: map[string]string{TTLAnnotationKey: "1h"}, age: 30 * time.Minute},
+		{name: "expires-at passed", annotations: map[string]string{ExpiresAtAnnotationKey: "2021-03-10T11:00:00Z"}, wantExpired: true},
+		{name: "expires-at in the future", annotations: map[string]string{ExpiresAtAnnotationKey: "2021-03-10T13:00:00Z"}},
+		{
+			name:        "either annotation expires the function",
+			annotations: map[string]string{TTLAnnotationKey: "72h", ExpiresAtAnnotationKey: "2021-03-10T11:00:00Z"},
+			age:         time.Hour,
+			wantExpired: true,
+		},
+		{name: "invalid ttl", annotations: map[string]string{TTLAnnotationKey: "one hour"}, wantErr: true},
+		{name: "negative ttl", annotations: map[string]string{TTLAnnotationKey: "-1h"}, wantErr: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			reason, err := FunctionExpiry(tc.annotations, now.Add(-tc.age), now)
+			if tc.wantErr != (err != nil) {
+				t.Fatalf("want error: %v, got: %v", tc.wantErr, err)
+			}
+			if expired := len(reason) > 0; expired != tc.wantExpired {
+				t.Errorf("want expired: %v, got reason: %q", tc.wantExpired, reason)
+			}
+		})
+	}
+}
+
+func Test_ExpirySweeper_Sweep(t *testing.T) {
+	now := time.Date(2021, time.March, 10, 12, 0, 0, 0, time.UTC)
+
+	deployment := func(name string, annotations map[string]string) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				Namespace:         "openfaas-fn",
+				Labels:            map[string]string{"faas_function": name},
+				Annotations:       annotations,
+				CreationTimestamp: metav1.NewTime(now.Add(-2 * time.Hour)),
+			},
+		}
+	}
+	service := func(name string) *corev1.Service {
+		return &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "openfaas-fn"}}
+	}
+
+	deployments := []*appsv1.Deployment{
+		deployment("expired", map[string]string{ExpiresAtAnnotationKey: "2021-03-10T11:00:00Z"}),
+		deployment("ttl", map[string]string{TTLAnnotationKey: "1h"}),
+		deployment("alive", map[string]string{ExpiresAtAnnotationKey: "2021-03-10T13:00:00Z"}),
+		deployment("no-expiry", map[string]string{}),
+		deployment("invalid", map[string]string{ExpiresAtAnnotationKey: "yesterday"}),
+	}
+
+	// the variant of an expired function is not in the lister as it has no faas_function label
+	variant := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "expired-canary",
+			Namespace: "openfaas-fn",
+			Labels:    map[string]string{VariantOfLabel: "expired"},
+		},
+	}
+
+	client := fake.NewSimpleClientset(variant, service(variant.Name), &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{Name: "expired", Namespace: "openfaas-fn"},
+	})
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for _, d := range deployments {
+		indexer.Add(d)
+		client.AppsV1().Deployments(d.Namespace).Create(context.TODO(), d, metav1.CreateOptions{})
+		client.CoreV1().Services(d.Namespace).Create(context.TODO(), service(d.Name), metav1.CreateOptions{})
+	}
+
+	target := NewDeploymentExpiryTarget(client, appslisters.NewDeploymentLister(indexer))
+	target.now = func() time.Time { return now }
+
+	got, err := NewExpirySweeper(target).Sweep(context.TODO(), now)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	sort.Strings(got)
+	if want := []string{"openfaas-fn/expired", "openfaas-fn/ttl"}; !reflect.DeepEqual(want, got) {
+		t.Fatalf("want expired: %v, got: %v", want, got)
+	}
+
+	res, err := client.AppsV1().Deployments("openfaas-fn").List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(res.Items) != 3 {
+		t.Errorf("want 3 Deployments left, got: %d", len(res.Items))
+	}
+
+	services, err := client.CoreV1().Services("openfaas-fn").List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(services.Items) != 3 {
+		t.Errorf("want 3 Services left, got: %d", len(services.Items))
+	}
+
+	pdbs, err := client.PolicyV1().PodDisruptionBudgets("openfaas-fn").List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(pdbs.Items) != 0 {
+		t.Errorf("want the PodDisruptionBudget to be deleted, got: %d", len(pdbs.Items))
+	}
+
+	events, err := client.CoreV1().Events("openfaas-fn").List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(events.Items) != 2 {
+		t.Fatalf("want 2 Events, got: %d", len(events.Items))
+	}
+	for _, event := range events.Items {
+		if event.Reason != FunctionExpiredReason {
+			t.Errorf("want an %s Event, got: %s for %s", FunctionExpiredReason, event.Reason, event.InvolvedObject.Name)
+		}
+	}
+}
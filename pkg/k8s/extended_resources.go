@@ -0,0 +1,146 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	// ExtendedResourceRequestsAnnotationKey requests device-plugin resources for each replica of
+	// the function, as a comma separated list such as "nvidia.com/gpu=2,example.com/fpga=1"
+	ExtendedResourceRequestsAnnotationKey = "com.openfaas.resources.requests"
+
+	// ExtendedResourceLimitsAnnotationKey is the limit of the device-plugin resources of each
+	// replica, in the same format as ExtendedResourceRequestsAnnotationKey
+	ExtendedResourceLimitsAnnotationKey = "com.openfaas.resources.limits"
+)
+
+// ParseExtendedResources parses a comma separated list of name=quantity pairs of extended
+// resources. CPU, memory and the other resources native to Kubernetes are set through the
+// function's requests and limits, so are rejected, as are quantities that are not whole.
+func ParseExtendedResources(value string) (corev1.ResourceList, error) {
+	list := corev1.ResourceList{}
+
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if len(pair) == 0 {
+			continue
+		}
+
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("%q must be in the form name=quantity", pair)
+		}
+
+		name := corev1.ResourceName(strings.TrimSpace(parts[0]))
+		if err := validateExtendedResourceName(name); err != nil {
+			return nil, err
+		}
+		if _, ok := list[name]; ok {
+			return nil, fmt.Errorf("%s is given more than once", name)
+		}
+
+		qty, err := resource.ParseQuantity(strings.TrimSpace(parts[1]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid quantity for %s: %q", name, parts[1])
+		}
+		if qty.Sign() <= 0 || qty.MilliValue()%1000 != 0 {
+			return nil, fmt.Errorf("invalid quantity for %s: %q, must be a positive whole number", name, parts[1])
+		}
+
+		list[name] = qty
+	}
+
+	return list, nil
+}
+
+func validateExtendedResourceName(name corev1.ResourceName) error {
+	n := string(name)
+	if !strings.Contains(n, "/") || strings.Contains(n, corev1.ResourceDefaultNamespacePrefix) || strings.HasPrefix(n, corev1.DefaultResourceRequestsPrefix) {
+		return fmt.Errorf("%q is not an extended resource, it must be a domain-prefixed name such as nvidia.com/gpu", n)
+	}
+
+	if errs := validation.IsQualifiedName(n); len(errs) > 0 {
+		return fmt.Errorf("invalid resource name %q: %s", n, strings.Join(errs, ", "))
+	}
+
+	return nil
+}
+
+// ExtendedResources returns the requests and limits of the extended resources of a function.
+// Kubernetes does not overcommit extended resources, so a resource that is requested must have
+// an equal limit. A resource that only has a limit is also requested with the same quantity.
+func ExtendedResources(annotations map[string]string) (corev1.ResourceList, corev1.ResourceList, error) {
+	requests, err := ParseExtendedResources(annotations[ExtendedResourceRequestsAnnotationKey])
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid %s: %s", ExtendedResourceRequestsAnnotationKey, err)
+	}
+
+	limits, err := ParseExtendedResources(annotations[ExtendedResourceLimitsAnnotationKey])
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid %s: %s", ExtendedResourceLimitsAnnotationKey, err)
+	}
+
+	names := make([]string, 0, len(requests))
+	for name := range requests {
+		names = append(names, string(name))
+	}
+	sort.Strings(names)
+
+	for _, n := range names {
+		name := corev1.ResourceName(n)
+		request := requests[name]
+		limit, ok := limits[name]
+		if !ok {
+			return nil, nil, fmt.Errorf("%s is requested but has no limit, extended resources need an equal request and limit", name)
+		}
+		if request.Cmp(limit) != 0 {
+			return nil, nil, fmt.Errorf("%s request of %s does not equal its limit of %s, extended resources need an equal request and limit", name, request.String(), limit.String())
+		}
+	}
+
+	for name, limit := range limits {
+		requests[name] = limit
+	}
+
+	return requests, limits, nil
+}
+
+// ConfigureExtendedResources adds the extended resources of the function to the requests and
+// limits of its container. It must be called after the container's CPU and memory resources
+// have been set.
+func (f *FunctionFactory) ConfigureExtendedResources(annotations map[string]string, deployment *appsv1.Deployment) error {
+	requests, limits, err := ExtendedResources(annotations)
+	if err != nil {
+		return err
+	}
+	if len(limits) == 0 {
+		return nil
+	}
+
+	resources := &deployment.Spec.Template.Spec.Containers[0].Resources
+	if resources.Requests == nil {
+		resources.Requests = corev1.ResourceList{}
+	}
+	if resources.Limits == nil {
+		resources.Limits = corev1.ResourceList{}
+	}
+
+	for name, qty := range requests {
+		resources.Requests[name] = qty
+	}
+	for name, qty := range limits {
+		resources.Limits[name] = qty
+	}
+
+	return nil
+}
@@ -0,0 +1,134 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func Test_ParseExtendedResources(t *testing.T) {
+	cases := []struct {
+		name    string
+		value   string
+		want    corev1.ResourceList
+		wantErr bool
+	}{
+		{name: "empty", value: "", want: corev1.ResourceList{}},
+		{name: "single GPU", value: "nvidia.com/gpu=1", want: corev1.ResourceList{"nvidia.com/gpu": resource.MustParse("1")}},
+		{
+			name:  "multiple devices",
+			value: "nvidia.com/gpu=2, example.com/fpga=1",
+			want: corev1.ResourceList{
+				"nvidia.com/gpu":   resource.MustParse("2"),
+				"example.com/fpga": resource.MustParse("1"),
+			},
+		},
+		{name: "partitioned GPU", value: "nvidia.com/mig-1g.5gb=3", want: corev1.ResourceList{"nvidia.com/mig-1g.5gb": resource.MustParse("3")}},
+		{name: "missing quantity", value: "nvidia.com/gpu", wantErr: true},
+		{name: "fractional quantity", value: "nvidia.com/gpu=0.5", wantErr: true},
+		{name: "zero quantity", value: "nvidia.com/gpu=0", wantErr: true},
+		{name: "invalid quantity", value: "nvidia.com/gpu=two", wantErr: true},
+		{name: "native resource", value: "cpu=1", wantErr: true},
+		{name: "kubernetes.io resource", value: "kubernetes.io/gpu=1", wantErr: true},
+		{name: "duplicate resource", value: "nvidia.com/gpu=1,nvidia.com/gpu=2", wantErr: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ParseExtendedResources(tc.value)
+			if tc.wantErr != (err != nil) {
+				t.Fatalf("want error: %v, got: %v", tc.wantErr, err)
+			}
+			if tc.wantErr {
+				return
+			}
+			if len(got) != len(tc.want) {
+				t.Fatalf("want %d resources, got: %v", len(tc.want), got)
+			}
+			for name, want := range tc.want {
+				if qty, ok := got[name]; !ok || qty.Cmp(want) != 0 {
+					t.Errorf("want %s=%s, got: %v", name, want.String(), got)
+				}
+			}
+		})
+	}
+}
+
+func Test_ConfigureExtendedResources(t *testing.T) {
+	cases := []struct {
+		name        string
+		annotations map[string]string
+		wantRequest string
+		wantLimit   string
+		wantErr     bool
+	}{
+		{name: "no annotations"},
+		{
+			name:        "equal request and limit",
+			annotations: map[string]string{ExtendedResourceRequestsAnnotationKey: "nvidia.com/gpu=2", ExtendedResourceLimitsAnnotationKey: "nvidia.com/gpu=2"},
+			wantRequest: "2",
+			wantLimit:   "2",
+		},
+		{
+			name:        "limit only is also requested",
+			annotations: map[string]string{ExtendedResourceLimitsAnnotationKey: "nvidia.com/gpu=4"},
+			wantRequest: "4",
+			wantLimit:   "4",
+		},
+		{
+			name:        "request without a limit",
+			annotations: map[string]string{ExtendedResourceRequestsAnnotationKey: "nvidia.com/gpu=2"},
+			wantErr:     true,
+		},
+		{
+			name:        "request differs from the limit",
+			annotations: map[string]string{ExtendedResourceRequestsAnnotationKey: "nvidia.com/gpu=1", ExtendedResourceLimitsAnnotationKey: "nvidia.com/gpu=2"},
+			wantErr:     true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			factory := mockFactory()
+
+			deployment := &appsv1.Deployment{}
+			deployment.Spec.Template.Spec.Containers = []corev1.Container{{
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("128Mi")},
+				},
+			}}
+
+			err := factory.ConfigureExtendedResources(tc.annotations, deployment)
+			if tc.wantErr != (err != nil) {
+				t.Fatalf("want error: %v, got: %v", tc.wantErr, err)
+			}
+			if tc.wantErr {
+				return
+			}
+
+			resources := deployment.Spec.Template.Spec.Containers[0].Resources
+			if _, ok := resources.Requests[corev1.ResourceMemory]; !ok {
+				t.Errorf("want the memory request to be kept, got: %v", resources.Requests)
+			}
+
+			request, limit := resources.Requests["nvidia.com/gpu"], resources.Limits["nvidia.com/gpu"]
+			if len(tc.wantRequest) == 0 {
+				if !request.IsZero() || !limit.IsZero() {
+					t.Errorf("want no GPU resources, got request: %s, limit: %s", request.String(), limit.String())
+				}
+				return
+			}
+			if request.String() != tc.wantRequest {
+				t.Errorf("want request: %s, got: %s", tc.wantRequest, request.String())
+			}
+			if limit.String() != tc.wantLimit {
+				t.Errorf("want limit: %s, got: %s", tc.wantLimit, limit.String())
+			}
+		})
+	}
+}
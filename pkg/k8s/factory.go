@@ -19,6 +19,20 @@ type FunctionFactory struct {
 	Client   kubernetes.Interface
 	Config   DeploymentConfig
 	Profiler NamespacedProfiler
+
+	// Breaker guards the calls that create and update functions, when nil every call is made
+	Breaker *CircuitBreaker
+
+	// NamespaceLabels are inherited by the Pods of functions, when nil no labels are inherited
+	NamespaceLabels *NamespaceLabels
+
+	// LocalProfiles looks up Profiles in the function's own namespace before ProfilesNamespace,
+	// when nil only ProfilesNamespace is used
+	LocalProfiles ProfileClient
+
+	// ImageChecker checks that the image of a function exists before it is deployed, when nil
+	// images are not checked
+	ImageChecker *ImageChecker
 }
 
 func NewFunctionFactory(clientset kubernetes.Interface, config DeploymentConfig, profiler NamespacedProfiler) FunctionFactory {
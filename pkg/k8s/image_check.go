@@ -0,0 +1,357 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// dockerHubRegistry is the registry of images without a registry in their name
+	dockerHubRegistry = "docker.io"
+
+	// dockerHubAPIHost serves the registry API of dockerHubRegistry
+	dockerHubAPIHost = "registry-1.docker.io"
+)
+
+// manifestMediaTypes are the manifest formats that a container runtime pulls
+var manifestMediaTypes = []string{
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+	"application/vnd.docker.distribution.manifest.v1+prettyjws",
+}
+
+// ImageNotFoundError is returned when the registry has no manifest for an image, or does not
+// allow it to be pulled with the credentials that the function has
+type ImageNotFoundError struct {
+	Image  string
+	Reason string
+}
+
+func (e *ImageNotFoundError) Error() string {
+	return fmt.Sprintf("image %s can not be pulled: %s", e.Image, e.Reason)
+}
+
+// ImageChecker looks up the manifest of an image in its registry to check that the image
+// exists before a function is deployed with it
+type ImageChecker struct {
+	// Client is used for the calls to registries, registries are always called over HTTPS
+	Client *http.Client
+
+	// Timeout bounds each check, including any token exchange with the registry
+	Timeout time.Duration
+}
+
+// NewImageChecker returns an ImageChecker that gives up on a registry after timeout
+func NewImageChecker(timeout time.Duration) *ImageChecker {
+	return &ImageChecker{
+		Client:  &http.Client{},
+		Timeout: timeout,
+	}
+}
+
+// registryCredentials is the login for a registry from a docker config Secret
+type registryCredentials struct {
+	Username string
+	Password string
+}
+
+// imageReference is an image name split into the parts of a registry API call
+type imageReference struct {
+	Registry   string
+	Repository string
+	Reference  string
+}
+
+// parseImageReference splits an image name in the same way as the container runtime, images
+// without a registry are on Docker Hub and default to the latest tag
+func parseImageReference(image string) (imageReference, error) {
+	ref := imageReference{Registry: dockerHubRegistry}
+
+	name := image
+	if i := strings.Index(name, "@"); i >= 0 {
+		name, ref.Reference = name[:i], name[i+1:]
+	} else if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name, ref.Reference = name[:i], name[i+1:]
+	}
+	if len(ref.Reference) == 0 {
+		ref.Reference = "latest"
+	}
+
+	if i := strings.Index(name, "/"); i >= 0 {
+		host := name[:i]
+		if strings.ContainsAny(host, ".:") || host == "localhost" {
+			ref.Registry, name = host, name[i+1:]
+		}
+	}
+	if ref.Registry == dockerHubRegistry && !strings.Contains(name, "/") {
+		name = "library/" + name
+	}
+
+	if len(name) == 0 || name != strings.ToLower(name) {
+		return ref, fmt.Errorf("invalid image name %q", image)
+	}
+	ref.Repository = name
+
+	return ref, nil
+}
+
+// normalizeRegistry returns the registry of a docker config entry, which can be a URL
+func normalizeRegistry(registry string) string {
+	registry = strings.TrimPrefix(strings.TrimPrefix(registry, "https://"), "http://")
+	registry = strings.SplitN(registry, "/", 2)[0]
+
+	switch registry {
+	case "index.docker.io", dockerHubAPIHost:
+		return dockerHubRegistry
+	}
+	return registry
+}
+
+// dockerConfigEntry is the login for a single registry in a docker config file
+type dockerConfigEntry struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Auth     string `json:"auth"`
+}
+
+// parsePullSecrets reads the registry logins from the docker config Secrets, a login in an
+// earlier Secret is used over a later one for the same registry
+func parsePullSecrets(secrets []corev1.Secret) map[string]registryCredentials {
+	credentials := map[string]registryCredentials{}
+
+	for _, secret := range secrets {
+		entries := map[string]dockerConfigEntry{}
+
+		switch secret.Type {
+		case corev1.SecretTypeDockerConfigJson:
+			var config struct {
+				Auths map[string]dockerConfigEntry `json:"auths"`
+			}
+			if err := json.Unmarshal(secret.Data[corev1.DockerConfigJsonKey], &config); err != nil {
+				continue
+			}
+			entries = config.Auths
+		case corev1.SecretTypeDockercfg:
+			if err := json.Unmarshal(secret.Data[corev1.DockerConfigKey], &entries); err != nil {
+				continue
+			}
+		default:
+			continue
+		}
+
+		for registry, entry := range entries {
+			if len(entry.Auth) > 0 {
+				if decoded, err := base64.StdEncoding.DecodeString(entry.Auth); err == nil {
+					if parts := strings.SplitN(string(decoded), ":", 2); len(parts) == 2 {
+						entry.Username, entry.Password = parts[0], parts[1]
+					}
+				}
+			}
+
+			registry = normalizeRegistry(registry)
+			if _, ok := credentials[registry]; !ok && len(entry.Username) > 0 {
+				credentials[registry] = registryCredentials{Username: entry.Username, Password: entry.Password}
+			}
+		}
+	}
+
+	return credentials
+}
+
+// Check returns an ImageNotFoundError when the registry reports that the image does not exist,
+// or that it can not be pulled with the docker config pullSecrets. Any other error means the
+// registry could not be asked, such as when it is unreachable or the Timeout is reached.
+func (c *ImageChecker) Check(ctx context.Context, image string, pullSecrets []corev1.Secret) error {
+	ref, err := parseImageReference(image)
+	if err != nil {
+		return &ImageNotFoundError{Image: image, Reason: err.Error()}
+	}
+
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+
+	host := ref.Registry
+	if host == dockerHubRegistry {
+		host = dockerHubAPIHost
+	}
+	manifestURL := fmt.Sprintf("https://%s/v2/%s/manifests/%s", host, ref.Repository, ref.Reference)
+
+	credentials, hasCredentials := parsePullSecrets(pullSecrets)[ref.Registry]
+
+	res, err := c.headManifest(ctx, manifestURL, "")
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode == http.StatusUnauthorized {
+		authorization, err := c.authorize(ctx, res.Header.Get("Www-Authenticate"), credentials, hasCredentials)
+		if err != nil {
+			return err
+		}
+
+		if len(authorization) > 0 {
+			if res, err = c.headManifest(ctx, manifestURL, authorization); err != nil {
+				return err
+			}
+		}
+	}
+
+	switch res.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusNotFound:
+		return &ImageNotFoundError{Image: image, Reason: fmt.Sprintf("%s:%s was not found in %s", ref.Repository, ref.Reference, ref.Registry)}
+	case http.StatusUnauthorized, http.StatusForbidden:
+		if !hasCredentials {
+			return &ImageNotFoundError{Image: image, Reason: fmt.Sprintf("it does not exist or needs a pull secret for %s", ref.Registry)}
+		}
+		return &ImageNotFoundError{Image: image, Reason: fmt.Sprintf("it does not exist or the pull secret for %s does not have access", ref.Registry)}
+	default:
+		return fmt.Errorf("unexpected status code from %s: %d", ref.Registry, res.StatusCode)
+	}
+}
+
+func (c *ImageChecker) headManifest(ctx context.Context, manifestURL, authorization string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodHead, manifestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", strings.Join(manifestMediaTypes, ", "))
+	if len(authorization) > 0 {
+		req.Header.Set("Authorization", authorization)
+	}
+
+	res, err := c.Client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	io.Copy(ioutil.Discard, res.Body)
+	res.Body.Close()
+
+	return res, nil
+}
+
+// authorize returns the Authorization header for the challenge of a registry, exchanging the
+// credentials for a token when the registry uses bearer tokens. Anonymous tokens are requested
+// when there are no credentials, which is how public images are pulled.
+func (c *ImageChecker) authorize(ctx context.Context, challenge string, credentials registryCredentials, hasCredentials bool) (string, error) {
+	scheme, params := parseChallenge(challenge)
+
+	switch scheme {
+	case "basic":
+		if !hasCredentials {
+			return "", nil
+		}
+		login := base64.StdEncoding.EncodeToString([]byte(credentials.Username + ":" + credentials.Password))
+		return "Basic " + login, nil
+	case "bearer":
+	default:
+		return "", nil
+	}
+
+	tokenURL, err := url.Parse(params["realm"])
+	if err != nil || len(params["realm"]) == 0 {
+		return "", fmt.Errorf("invalid token realm %q", params["realm"])
+	}
+	query := tokenURL.Query()
+	for _, key := range []string{"service", "scope"} {
+		if len(params[key]) > 0 {
+			query.Set(key, params[key])
+		}
+	}
+	tokenURL.RawQuery = query.Encode()
+
+	req, err := http.NewRequest(http.MethodGet, tokenURL.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	if hasCredentials {
+		req.SetBasicAuth(credentials.Username, credentials.Password)
+	}
+
+	res, err := c.Client.Do(req.WithContext(ctx))
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusUnauthorized || res.StatusCode == http.StatusForbidden {
+		// the registry rejected the login, the manifest is looked up without it
+		return "", nil
+	}
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status code from token service: %d", res.StatusCode)
+	}
+
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("invalid token response: %s", err)
+	}
+	if len(token.Token) == 0 {
+		token.Token = token.AccessToken
+	}
+
+	return "Bearer " + token.Token, nil
+}
+
+// parseChallenge parses a WWW-Authenticate header, such as
+// Bearer realm="https://auth.docker.io/token",service="registry.docker.io"
+func parseChallenge(challenge string) (string, map[string]string) {
+	params := map[string]string{}
+
+	parts := strings.SplitN(strings.TrimSpace(challenge), " ", 2)
+	scheme := strings.ToLower(parts[0])
+	if len(parts) == 1 {
+		return scheme, params
+	}
+
+	rest := parts[1]
+	for len(rest) > 0 {
+		eq := strings.Index(rest, "=")
+		if eq < 0 {
+			break
+		}
+		key := strings.ToLower(strings.TrimSpace(rest[:eq]))
+		rest = strings.TrimSpace(rest[eq+1:])
+
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.Index(rest[1:], `"`)
+			if end < 0 {
+				value, rest = rest[1:], ""
+			} else {
+				value, rest = rest[1:end+1], rest[end+2:]
+			}
+		} else if comma := strings.Index(rest, ","); comma >= 0 {
+			value, rest = rest[:comma], rest[comma:]
+		} else {
+			value, rest = rest, ""
+		}
+
+		params[key] = value
+		rest = strings.TrimPrefix(strings.TrimSpace(rest), ",")
+	}
+
+	return scheme, params
+}
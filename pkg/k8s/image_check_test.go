@@ -0,0 +1,207 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func Test_parseImageReference(t *testing.T) {
+	cases := []struct {
+		image   string
+		want    imageReference
+		wantErr bool
+	}{
+		{image: "alpine", want: imageReference{"docker.io", "library/alpine", "latest"}},
+		{image: "functions/nodeinfo:0.1.0", want: imageReference{"docker.io", "functions/nodeinfo", "0.1.0"}},
+		{image: "ghcr.io/openfaas/figlet:latest", want: imageReference{"ghcr.io", "openfaas/figlet", "latest"}},
+		{image: "localhost/figlet", want: imageReference{"localhost", "figlet", "latest"}},
+		{image: "registry.example.com:5000/team/figlet:1.0", want: imageReference{"registry.example.com:5000", "team/figlet", "1.0"}},
+		{image: "figlet@sha256:abc", want: imageReference{"docker.io", "library/figlet", "sha256:abc"}},
+		{image: "Functions/Nodeinfo", wantErr: true},
+		{image: "registry.example.com/", wantErr: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.image, func(t *testing.T) {
+			got, err := parseImageReference(tc.image)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if got != tc.want {
+				t.Fatalf("want %+v, got %+v", tc.want, got)
+			}
+		})
+	}
+}
+
+func Test_parsePullSecrets(t *testing.T) {
+	secrets := []corev1.Secret{
+		{
+			Type: corev1.SecretTypeDockerConfigJson,
+			Data: map[string][]byte{corev1.DockerConfigJsonKey: []byte(`{"auths":{
+				"https://index.docker.io/v1/":{"auth":"aHViOnNlY3JldA=="},
+				"ghcr.io":{"username":"gh","password":"token"}}}`)},
+		},
+		{
+			Type: corev1.SecretTypeDockercfg,
+			Data: map[string][]byte{corev1.DockerConfigKey: []byte(`{"ghcr.io":{"username":"other","password":"other"},"quay.io":{"username":"q","password":"p"}}`)},
+		},
+		{
+			Type: corev1.SecretTypeOpaque,
+			Data: map[string][]byte{"token": []byte("ignored")},
+		},
+	}
+
+	got := parsePullSecrets(secrets)
+	want := map[string]registryCredentials{
+		"docker.io": {Username: "hub", Password: "secret"},
+		"ghcr.io":   {Username: "gh", Password: "token"},
+		"quay.io":   {Username: "q", Password: "p"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("want %d registries, got %d: %v", len(want), len(got), got)
+	}
+	for registry, credentials := range want {
+		if got[registry] != credentials {
+			t.Errorf("want %+v for %s, got %+v", credentials, registry, got[registry])
+		}
+	}
+}
+
+func Test_parseChallenge(t *testing.T) {
+	scheme, params := parseChallenge(`Bearer realm="https://auth.docker.io/token",service="registry.docker.io",scope="repository:library/alpine:pull"`)
+	if scheme != "bearer" {
+		t.Errorf("want scheme bearer, got %q", scheme)
+	}
+	want := map[string]string{
+		"realm":   "https://auth.docker.io/token",
+		"service": "registry.docker.io",
+		"scope":   "repository:library/alpine:pull",
+	}
+	for key, value := range want {
+		if params[key] != value {
+			t.Errorf("want %s=%q, got %q", key, value, params[key])
+		}
+	}
+}
+
+// newTestRegistry serves the manifests of tags, the "private" repository needs a token that is
+// issued for the user "robot"
+func newTestRegistry(t *testing.T, tags map[string]bool) *httptest.Server {
+	var server *httptest.Server
+	server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			user, pass, ok := r.BasicAuth()
+			scope := r.URL.Query().Get("scope")
+			if strings.Contains(scope, "private") && (!ok || user != "robot" || pass != "secret") {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]string{"token": "token-for-" + scope})
+			return
+		}
+
+		parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/v2/"), "/manifests/", 2)
+		if r.Method != http.MethodHead || len(parts) != 2 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if parts[0] == "broken/app" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		scope := fmt.Sprintf("repository:%s:pull", parts[0])
+		if r.Header.Get("Authorization") != "Bearer token-for-"+scope {
+			w.Header().Set("Www-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="test",scope="%s"`, server.URL, scope))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		if !tags[parts[0]+":"+parts[1]] {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func Test_ImageChecker_Check(t *testing.T) {
+	server := newTestRegistry(t, map[string]bool{
+		"public/app:1.0":  true,
+		"private/app:1.0": true,
+	})
+	registry := strings.TrimPrefix(server.URL, "https://")
+
+	pullSecret := corev1.Secret{
+		Type: corev1.SecretTypeDockerConfigJson,
+		Data: map[string][]byte{corev1.DockerConfigJsonKey: []byte(`{"auths":{"` + registry + `":{"username":"robot","password":"secret"}}}`)},
+	}
+
+	cases := []struct {
+		name         string
+		image        string
+		pullSecrets  []corev1.Secret
+		wantNotFound bool
+		wantErr      bool
+	}{
+		{name: "public image exists", image: registry + "/public/app:1.0"},
+		{name: "public tag does not exist", image: registry + "/public/app:2.0", wantNotFound: true, wantErr: true},
+		{name: "private image without a pull secret", image: registry + "/private/app:1.0", wantNotFound: true, wantErr: true},
+		{name: "private image with a pull secret", image: registry + "/private/app:1.0", pullSecrets: []corev1.Secret{pullSecret}},
+		{name: "registry error is not a missing image", image: registry + "/broken/app:1.0", wantErr: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			checker := &ImageChecker{Client: server.Client(), Timeout: time.Second * 5}
+
+			err := checker.Check(context.Background(), tc.image, tc.pullSecrets)
+			if tc.wantErr != (err != nil) {
+				t.Fatalf("want error: %v, got: %v", tc.wantErr, err)
+			}
+
+			var notFound *ImageNotFoundError
+			if tc.wantNotFound != errors.As(err, &notFound) {
+				t.Fatalf("want ImageNotFoundError: %v, got: %v", tc.wantNotFound, err)
+			}
+		})
+	}
+}
+
+func Test_ImageChecker_Check_Timeout(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second * 5):
+		}
+	}))
+	defer server.Close()
+
+	checker := &ImageChecker{Client: server.Client(), Timeout: time.Millisecond * 50}
+	image := strings.TrimPrefix(server.URL, "https://") + "/public/app:1.0"
+
+	err := checker.Check(context.Background(), image, nil)
+	var notFound *ImageNotFoundError
+	if err == nil || errors.As(err, &notFound) {
+		t.Fatalf("want a timeout error, got: %v", err)
+	}
+}
@@ -0,0 +1,24 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+// PodLabels returns the labels for the Pod template of a function in the given namespace. The
+// function's labels take precedence over those inherited from the namespace, which take
+// precedence over DeploymentConfig.DefaultPodLabels. The function's labels are not modified.
+func (f *FunctionFactory) PodLabels(namespace string, labels map[string]string) map[string]string {
+	inherited := f.NamespaceLabels.Get(namespace)
+	podLabels := make(map[string]string, len(f.Config.DefaultPodLabels)+len(inherited)+len(labels))
+
+	for k, v := range f.Config.DefaultPodLabels {
+		podLabels[k] = v
+	}
+	for k, v := range inherited {
+		podLabels[k] = v
+	}
+	for k, v := range labels {
+		podLabels[k] = v
+	}
+
+	return podLabels
+}
@@ -0,0 +1,88 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_PodLabels(t *testing.T) {
+	cases := []struct {
+		name     string
+		defaults map[string]string
+		labels   map[string]string
+		expected map[string]string
+	}{
+		{
+			name:     "no defaults",
+			defaults: nil,
+			labels:   map[string]string{"faas_function": "nodeinfo"},
+			expected: map[string]string{"faas_function": "nodeinfo"},
+		},
+		{
+			name:     "defaults are added",
+			defaults: map[string]string{"team": "platform", "env": "prod"},
+			labels:   map[string]string{"faas_function": "nodeinfo"},
+			expected: map[string]string{"faas_function": "nodeinfo", "team": "platform", "env": "prod"},
+		},
+		{
+			name:     "function labels take precedence",
+			defaults: map[string]string{"team": "platform", "env": "prod"},
+			labels:   map[string]string{"faas_function": "nodeinfo", "team": "payments"},
+			expected: map[string]string{"faas_function": "nodeinfo", "team": "payments", "env": "prod"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			factory := mockFactory()
+			factory.Config.DefaultPodLabels = tc.defaults
+
+			got := factory.PodLabels("openfaas-fn", tc.labels)
+			if !reflect.DeepEqual(tc.expected, got) {
+				t.Errorf("want %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func Test_PodLabels_NamespaceLabels(t *testing.T) {
+	namespaceLabels := NewNamespaceLabels("billing.example.com/")
+	namespaceLabels.Update(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name: "team-a",
+		Labels: map[string]string{
+			"billing.example.com/team":    "a",
+			"billing.example.com/project": "checkout",
+			"kubernetes.io/metadata.name": "team-a",
+		},
+	}})
+
+	factory := mockFactory()
+	factory.Config.DefaultPodLabels = map[string]string{"billing.example.com/team": "platform", "env": "prod"}
+	factory.NamespaceLabels = namespaceLabels
+
+	got := factory.PodLabels("team-a", map[string]string{"faas_function": "nodeinfo", "billing.example.com/project": "search"})
+	want := map[string]string{
+		"faas_function":               "nodeinfo",
+		"env":                         "prod",
+		"billing.example.com/team":    "a",
+		"billing.example.com/project": "search",
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+
+	if got := factory.PodLabels("team-b", nil); !reflect.DeepEqual(got, map[string]string{"billing.example.com/team": "platform", "env": "prod"}) {
+		t.Errorf("want only the defaults for a namespace without labels, got %v", got)
+	}
+
+	namespaceLabels.Update(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a"}})
+	if got := namespaceLabels.Get("team-a"); len(got) != 0 {
+		t.Errorf("want the labels to be removed from the namespace, got %v", got)
+	}
+}
@@ -0,0 +1,155 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// leaseDurationSeconds is how long a lock is valid for, so that a lock held by a replica
+	// that has crashed expires rather than blocking updates forever
+	leaseDurationSeconds = int32(15)
+
+	// leasePollInterval is how often a held lock is checked while waiting for it
+	leasePollInterval = 100 * time.Millisecond
+)
+
+// ErrLockTimeout is returned when a lock could not be acquired before the timeout
+var ErrLockTimeout = errors.New("timed out waiting for the lock")
+
+// LeaseLock is an advisory lock backed by a coordination.k8s.io/v1 Lease, it is shared
+// between all replicas of the provider.
+type LeaseLock struct {
+	client  kubernetes.Interface
+	timeout time.Duration
+}
+
+// NewLeaseLock returns a LeaseLock that waits up to timeout for a lock to be released
+func NewLeaseLock(client kubernetes.Interface, timeout time.Duration) LeaseLock {
+	return LeaseLock{
+		client:  client,
+		timeout: timeout,
+	}
+}
+
+// SecretLeaseName is the name of the Lease used to lock a secret
+func SecretLeaseName(secret string) string {
+	return "openfaas-secret-" + secret
+}
+
+// Acquire takes the Lease with the given name, creating it when it does not exist. An expired
+// Lease is taken over. ErrLockTimeout is returned when the Lease is still held by someone else
+// after the timeout. The returned func releases the lock.
+func (l LeaseLock) Acquire(ctx context.Context, namespace, name string) (func(), error) {
+	holder := leaseHolderIdentity()
+	deadline := time.Now().Add(l.timeout)
+
+	for {
+		lease, err := l.tryAcquire(ctx, namespace, name, holder)
+		if err != nil {
+			return nil, err
+		}
+
+		if lease != nil {
+			release := func() {
+				err := l.client.CoordinationV1().Leases(namespace).Delete(context.Background(), name, metav1.DeleteOptions{
+					Preconditions: &metav1.Preconditions{UID: &lease.UID},
+				})
+				if err != nil && !k8serrors.IsNotFound(err) && !k8serrors.IsConflict(err) {
+					log.Printf("unable to release lock %s.%s: %s\n", name, namespace, err)
+				}
+			}
+			return release, nil
+		}
+
+		if time.Now().After(deadline) {
+			return nil, ErrLockTimeout
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(leasePollInterval):
+		}
+	}
+}
+
+// tryAcquire returns the Lease when it is now held by holder, or nil when it is held by
+// someone else or was taken by another holder at the same time
+func (l LeaseLock) tryAcquire(ctx context.Context, namespace, name, holder string) (*coordinationv1.Lease, error) {
+	leases := l.client.CoordinationV1().Leases(namespace)
+	now := metav1.NewMicroTime(time.Now())
+	duration := leaseDurationSeconds
+
+	lease, err := leases.Get(ctx, name, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		created, err := leases.Create(ctx, &coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+			},
+			Spec: coordinationv1.LeaseSpec{
+				HolderIdentity:       &holder,
+				LeaseDurationSeconds: &duration,
+				AcquireTime:          &now,
+				RenewTime:            &now,
+			},
+		}, metav1.CreateOptions{})
+		if k8serrors.IsAlreadyExists(err) {
+			return nil, nil
+		}
+		return created, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if leaseHeld(lease, now.Time) {
+		return nil, nil
+	}
+
+	lease.Spec.HolderIdentity = &holder
+	lease.Spec.LeaseDurationSeconds = &duration
+	lease.Spec.AcquireTime = &now
+	lease.Spec.RenewTime = &now
+
+	updated, err := leases.Update(ctx, lease, metav1.UpdateOptions{})
+	if k8serrors.IsConflict(err) {
+		return nil, nil
+	}
+	return updated, err
+}
+
+// leaseHeld returns true when the Lease has a holder and has not expired
+func leaseHeld(lease *coordinationv1.Lease, now time.Time) bool {
+	spec := lease.Spec
+	if spec.HolderIdentity == nil || len(*spec.HolderIdentity) == 0 || spec.RenewTime == nil {
+		return false
+	}
+
+	duration := leaseDurationSeconds
+	if spec.LeaseDurationSeconds != nil {
+		duration = *spec.LeaseDurationSeconds
+	}
+
+	return now.Before(spec.RenewTime.Add(time.Duration(duration) * time.Second))
+}
+
+// leaseHolderIdentity is unique for each call, so that two requests served by the same
+// replica do not share a lock
+func leaseHolderIdentity() string {
+	hostname, _ := os.Hostname()
+	return fmt.Sprintf("%s-%d", hostname, time.Now().UnixNano())
+}
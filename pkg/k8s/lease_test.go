@@ -0,0 +1,58 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_LeaseLock_AcquireAndRelease(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	lock := NewLeaseLock(client, 200*time.Millisecond)
+
+	release, err := lock.Acquire(context.TODO(), "openfaas-fn", SecretLeaseName("db-password"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if _, err := lock.Acquire(context.TODO(), "openfaas-fn", SecretLeaseName("db-password")); err != ErrLockTimeout {
+		t.Errorf("want ErrLockTimeout while the lock is held, got: %v", err)
+	}
+
+	release()
+
+	release, err = lock.Acquire(context.TODO(), "openfaas-fn", SecretLeaseName("db-password"))
+	if err != nil {
+		t.Fatalf("want the lock to be acquired after release, got: %s", err)
+	}
+	release()
+}
+
+func Test_LeaseLock_TakesOverExpiredLease(t *testing.T) {
+	holder := "crashed-replica"
+	duration := int32(15)
+	renewed := metav1.NewMicroTime(time.Now().Add(-time.Minute))
+
+	client := fake.NewSimpleClientset(&coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{Name: SecretLeaseName("db-password"), Namespace: "openfaas-fn"},
+		Spec: coordinationv1.LeaseSpec{
+			HolderIdentity:       &holder,
+			LeaseDurationSeconds: &duration,
+			RenewTime:            &renewed,
+		},
+	})
+	lock := NewLeaseLock(client, 200*time.Millisecond)
+
+	release, err := lock.Acquire(context.TODO(), "openfaas-fn", SecretLeaseName("db-password"))
+	if err != nil {
+		t.Fatalf("want the expired lease to be taken over, got: %s", err)
+	}
+	release()
+}
@@ -0,0 +1,103 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+)
+
+const (
+	// LoadBalancerAnnotationKey selects how the proxy picks an endpoint of the function,
+	// overriding FunctionLookup.LoadBalancer
+	LoadBalancerAnnotationKey = "com.openfaas.load-balancer"
+
+	// LoadBalancerRandom picks an endpoint at random, this is the default
+	LoadBalancerRandom = "random"
+
+	// LoadBalancerLeastConnections picks the endpoint with the fewest in-flight requests from
+	// this provider, which gives a lower tail latency when request durations vary
+	LoadBalancerLeastConnections = "least-connections"
+)
+
+// ValidateLoadBalancer returns an error for an unknown load balancer name, an empty name is
+// valid and means the default
+func ValidateLoadBalancer(name string) error {
+	switch name {
+	case "", LoadBalancerRandom, LoadBalancerLeastConnections:
+		return nil
+	}
+	return fmt.Errorf("invalid load balancer: %q, must be %s or %s", name, LoadBalancerRandom, LoadBalancerLeastConnections)
+}
+
+// LoadBalancer returns the load balancer of a function from its annotations, an empty string
+// is returned when the function uses the default
+func LoadBalancer(annotations map[string]string) (string, error) {
+	name := annotations[LoadBalancerAnnotationKey]
+	if err := ValidateLoadBalancer(name); err != nil {
+		return "", fmt.Errorf("invalid %s: %q, must be %s or %s", LoadBalancerAnnotationKey, name, LoadBalancerRandom, LoadBalancerLeastConnections)
+	}
+	return name, nil
+}
+
+// connectionTracker counts the in-flight requests to each endpoint address
+type connectionTracker struct {
+	lock     sync.Mutex
+	inflight map[string]int
+}
+
+func newConnectionTracker() *connectionTracker {
+	return &connectionTracker{
+		inflight: map[string]int{},
+	}
+}
+
+// Acquire picks the address with the fewest in-flight requests, ties are broken at random so
+// that idle endpoints share the load. The returned release func must be called once the
+// request has completed.
+func (c *connectionTracker) Acquire(addresses []string) (string, func()) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	var candidates []string
+	least := -1
+	for _, address := range addresses {
+		n := c.inflight[address]
+		if least == -1 || n < least {
+			least = n
+			candidates = candidates[:0]
+		}
+		if n == least {
+			candidates = append(candidates, address)
+		}
+	}
+
+	address := candidates[rand.Intn(len(candidates))]
+	c.inflight[address]++
+
+	var once sync.Once
+	return address, func() {
+		once.Do(func() { c.release(address) })
+	}
+}
+
+// InFlight returns the number of in-flight requests to an address
+func (c *connectionTracker) InFlight(address string) int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.inflight[address]
+}
+
+func (c *connectionTracker) release(address string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.inflight[address] <= 1 {
+		// remove the entry so that addresses of Pods that have gone away are not kept
+		delete(c.inflight, address)
+		return
+	}
+	c.inflight[address]--
+}
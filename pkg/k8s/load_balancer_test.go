@@ -0,0 +1,119 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	corelister "k8s.io/client-go/listers/core/v1"
+)
+
+func Test_connectionTracker_PicksLeastInFlight(t *testing.T) {
+	c := newConnectionTracker()
+	addresses := []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"}
+
+	releases := map[string]func(){}
+	for i := 0; i < 3; i++ {
+		address, release := c.Acquire(addresses)
+		if _, ok := releases[address]; ok {
+			t.Fatalf("want each idle address to be used once, %s was picked again", address)
+		}
+		releases[address] = release
+	}
+
+	releases["10.0.0.2"]()
+	// a second call to release is ignored
+	releases["10.0.0.2"]()
+
+	if address, _ := c.Acquire(addresses); address != "10.0.0.2" {
+		t.Errorf("want the released address 10.0.0.2, got: %s", address)
+	}
+	if got := c.InFlight("10.0.0.1"); got != 1 {
+		t.Errorf("want 1 in-flight request to 10.0.0.1, got: %d", got)
+	}
+
+	releases["10.0.0.1"]()
+	if _, ok := c.inflight["10.0.0.1"]; ok {
+		t.Errorf("want the entry to be removed once no requests are in-flight")
+	}
+}
+
+func Test_LoadBalancer(t *testing.T) {
+	cases := []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{value: "", want: ""},
+		{value: "random", want: LoadBalancerRandom},
+		{value: "least-connections", want: LoadBalancerLeastConnections},
+		{value: "round-robin", wantErr: true},
+	}
+
+	for _, tc := range cases {
+		got, err := LoadBalancer(map[string]string{LoadBalancerAnnotationKey: tc.value})
+		if tc.wantErr != (err != nil) {
+			t.Errorf("%q: want error: %v, got: %v", tc.value, tc.wantErr, err)
+		}
+		if got != tc.want {
+			t.Errorf("%q: want: %q, got: %q", tc.value, tc.want, got)
+		}
+	}
+}
+
+type multiAddressLister struct {
+	addresses []corev1.EndpointAddress
+}
+
+func (l multiAddressLister) List(selector labels.Selector) ([]*corev1.Endpoints, error) {
+	return nil, nil
+}
+
+func (l multiAddressLister) Endpoints(namespace string) corelister.EndpointsNamespaceLister {
+	return l
+}
+
+func (l multiAddressLister) Get(name string) (*corev1.Endpoints, error) {
+	return &corev1.Endpoints{
+		Subsets: []corev1.EndpointSubset{{Addresses: l.addresses}},
+	}, nil
+}
+
+func Test_FunctionLookup_ResolveBalanced_LeastConnections(t *testing.T) {
+	lister := multiAddressLister{addresses: []corev1.EndpointAddress{{IP: "10.0.0.1"}, {IP: "10.0.0.2"}}}
+	resolver := NewFunctionLookup("openfaas-fn", lister)
+	resolver.LoadBalancer = LoadBalancerLeastConnections
+
+	first, releaseFirst, err := resolver.ResolveBalanced("nodeinfo", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	for i := 0; i < 5; i++ {
+		second, release, err := resolver.ResolveBalanced("nodeinfo", "")
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if second.Host == first.Host {
+			t.Fatalf("want the idle endpoint while %s is busy, got: %s", first.Host, second.Host)
+		}
+		release()
+	}
+	releaseFirst()
+
+	if got := resolver.connections.InFlight("10.0.0.1") + resolver.connections.InFlight("10.0.0.2"); got != 0 {
+		t.Errorf("want no in-flight requests after release, got: %d", got)
+	}
+
+	_, release, err := resolver.ResolveBalanced("nodeinfo", LoadBalancerRandom)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	release()
+	if got := len(resolver.connections.inflight); got != 0 {
+		t.Errorf("want a function that overrides the default to not be counted, got %d entries", got)
+	}
+}
@@ -12,6 +12,22 @@ import (
 	"k8s.io/client-go/kubernetes"
 )
 
+// logContainerKey holds the container to read logs from in the context of a log request
+type logContainerKey struct{}
+
+// WithLogContainer returns a context that selects the container of the function's Pods that
+// LogRequestor reads logs from, since logs.Request has no field for it
+func WithLogContainer(ctx context.Context, container string) context.Context {
+	return context.WithValue(ctx, logContainerKey{}, container)
+}
+
+// LogContainer returns the container set by WithLogContainer, or an empty string for the
+// function's own container
+func LogContainer(ctx context.Context) string {
+	container, _ := ctx.Value(logContainerKey{}).(string)
+	return container
+}
+
 // LogRequestor implements the Requestor interface for k8s
 type LogRequestor struct {
 	client            kubernetes.Interface
@@ -36,7 +52,7 @@ func (l LogRequestor) Query(ctx context.Context, r logs.Request) (<-chan logs.Me
 		ns = r.Namespace
 	}
 
-	logStream, err := GetLogs(ctx, l.client, r.Name, ns, int64(r.Tail), r.Since, r.Follow)
+	logStream, err := GetLogs(ctx, l.client, r.Name, LogContainer(ctx), ns, r.Instance, int64(r.Tail), r.Since, r.Follow)
 	if err != nil {
 		log.Printf("LogRequestor: get logs failed: %s\n", err)
 		return nil, err
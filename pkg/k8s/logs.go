@@ -6,7 +6,9 @@ import (
 	"context"
 	"io"
 	"log"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
@@ -48,13 +50,35 @@ type Log struct {
 	// FunctionName of the pod
 	FunctionName string `json:"FunctionName"`
 
+	// Labels of the pod, so that lines from different replicas or versions can be told apart
+	Labels map[string]string `json:"labels,omitempty"`
+
 	// Timestamp of the message
 	Timestamp time.Time `json:"timestamp"`
 }
 
-// GetLogs returns a channel of logs for the given function
-func GetLogs(ctx context.Context, client kubernetes.Interface, functionName, namespace string, tail int64, since *time.Time, follow bool) (<-chan Log, error) {
-	added, err := startFunctionPodInformer(ctx, client, functionName, namespace)
+// GetLogs returns a channel of logs for the given function, merged from all of its Pods and
+// tagged with the name and labels of the Pod they came from. The logs are read from the
+// function's container unless container names another container of its Pods, such as an
+// init container, and only from the Pod named instance when it is set.
+//
+// Without follow the lines of every Pod are read first, then sent in timestamp order and
+// limited to the latest tail lines overall. With follow the lines are sent as they are
+// written, including by Pods that start after the request.
+func GetLogs(ctx context.Context, client kubernetes.Interface, functionName, container, namespace, instance string, tail int64, since *time.Time, follow bool) (<-chan Log, error) {
+	if len(container) == 0 {
+		container = functionName
+	}
+
+	if !follow {
+		pods, err := listFunctionPods(ctx, client, functionName, namespace, instance)
+		if err != nil {
+			return nil, err
+		}
+		return mergedPodLogs(ctx, client, pods, functionName, container, namespace, tail, since), nil
+	}
+
+	added, err := startFunctionPodInformer(ctx, client, functionName, namespace, instance)
 	if err != nil {
 		return nil, err
 	}
@@ -62,24 +86,23 @@ func GetLogs(ctx context.Context, client kubernetes.Interface, functionName, nam
 	logs := make(chan Log, LogBufferSize)
 
 	go func() {
-		var watching uint
 		defer close(logs)
 
-		finished := make(chan error)
-
 		for {
 			select {
 			case <-ctx.Done():
 				return
-			case <-finished:
-				watching--
-				if watching == 0 && !follow {
+			case p, ok := <-added:
+				if !ok {
 					return
 				}
-			case p := <-added:
-				watching++
+				if len(instance) > 0 && p.Name != instance {
+					continue
+				}
 				go func() {
-					finished <- podLogs(ctx, client.CoreV1().Pods(namespace), p, functionName, namespace, tail, since, follow, logs)
+					if err := podLogs(ctx, client.CoreV1().Pods(namespace), p, functionName, container, namespace, tail, since, true, logs); err != nil && err != ctx.Err() {
+						log.Printf("Logger: log stream for %s failed: %s\n", p.Name, err)
+					}
 				}()
 			}
 		}
@@ -88,10 +111,65 @@ func GetLogs(ctx context.Context, client kubernetes.Interface, functionName, nam
 	return logs, nil
 }
 
+// mergedPodLogs reads the logs of each Pod to the end and sends them ordered by timestamp,
+// when tail is positive only the latest tail lines across all of the Pods are sent
+func mergedPodLogs(ctx context.Context, client kubernetes.Interface, pods []*corev1.Pod, functionName, container, namespace string, tail int64, since *time.Time) <-chan Log {
+	logs := make(chan Log, LogBufferSize)
+
+	go func() {
+		defer close(logs)
+
+		collected := make(chan Log, LogBufferSize)
+		var wg sync.WaitGroup
+		for _, p := range pods {
+			wg.Add(1)
+			go func(p *corev1.Pod) {
+				defer wg.Done()
+				if err := podLogs(ctx, client.CoreV1().Pods(namespace), p, functionName, container, namespace, tail, since, false, collected); err != nil && err != ctx.Err() {
+					log.Printf("Logger: log stream for %s failed: %s\n", p.Name, err)
+				}
+			}(p)
+		}
+		go func() {
+			wg.Wait()
+			close(collected)
+		}()
+
+		var lines []Log
+		for line := range collected {
+			lines = append(lines, line)
+		}
+
+		for _, line := range mergeLogs(lines, tail) {
+			select {
+			case logs <- line:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return logs
+}
+
+// mergeLogs orders the lines of several Pods by timestamp, keeping the order of the lines of
+// each Pod for equal timestamps, and returns the latest tail lines when tail is positive
+func mergeLogs(lines []Log, tail int64) []Log {
+	sort.SliceStable(lines, func(i, j int) bool {
+		return lines[i].Timestamp.Before(lines[j].Timestamp)
+	})
+
+	if tail > 0 && int64(len(lines)) > tail {
+		lines = lines[int64(len(lines))-tail:]
+	}
+
+	return lines
+}
+
 // podLogs returns a stream of logs lines from the specified pod
-func podLogs(ctx context.Context, i v1.PodInterface, pod, container, namespace string, tail int64, since *time.Time, follow bool, dst chan<- Log) error {
-	log.Printf("Logger: starting log stream for %s\n", pod)
-	defer log.Printf("Logger: stopping log stream for %s\n", pod)
+func podLogs(ctx context.Context, i v1.PodInterface, pod *corev1.Pod, functionName, container, namespace string, tail int64, since *time.Time, follow bool, dst chan<- Log) error {
+	log.Printf("Logger: starting log stream for %s\n", pod.Name)
+	defer log.Printf("Logger: stopping log stream for %s\n", pod.Name)
 
 	opts := &corev1.PodLogOptions{
 		Follow:     follow,
@@ -107,24 +185,28 @@ func podLogs(ctx context.Context, i v1.PodInterface, pod, container, namespace s
 		opts.SinceSeconds = parseSince(since)
 	}
 
-	stream, err := i.GetLogs(pod, opts).Stream(context.TODO())
+	stream, err := i.GetLogs(pod.Name, opts).Stream(ctx)
 	if err != nil {
 		return err
 	}
 	defer stream.Close()
 
-	done := make(chan error)
+	done := make(chan error, 1)
 	go func() {
-		reader := bufio.NewReader(stream)
-		for {
-			line, err := reader.ReadBytes('\n')
-			if err != nil {
-				done <- err
-				return
+		done <- readLogLines(stream, func(msg string, ts time.Time) {
+			line := Log{
+				Timestamp:    ts,
+				Text:         msg,
+				PodName:      pod.Name,
+				FunctionName: functionName,
+				Namespace:    namespace,
+				Labels:       pod.Labels,
 			}
-			msg, ts := extractTimestampAndMsg(string(bytes.Trim(line, "\x00")))
-			dst <- Log{Timestamp: ts, Text: msg, PodName: pod, FunctionName: container}
-		}
+			select {
+			case dst <- line:
+			case <-ctx.Done():
+			}
+		})
 	}()
 
 	select {
@@ -138,6 +220,22 @@ func podLogs(ctx context.Context, i v1.PodInterface, pod, container, namespace s
 	}
 }
 
+// readLogLines calls emit for each timestamped line of a log stream until the stream ends, a
+// final line without a newline is also emitted
+func readLogLines(stream io.Reader, emit func(msg string, ts time.Time)) error {
+	reader := bufio.NewReader(stream)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			msg, ts := extractTimestampAndMsg(string(bytes.Trim(line, "\x00")))
+			emit(msg, ts)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
 func extractTimestampAndMsg(logText string) (string, time.Time) {
 	// first 32 characters is the k8s timestamp
 	parts := strings.SplitN(logText, " ", 2)
@@ -165,53 +263,82 @@ func parseSince(r *time.Time) *int64 {
 	return &since
 }
 
-// startFunctionPodInformer will gather the list of existing Pods for the function, it will then watch
-// and watch for newly added or deleted function instances.
-func startFunctionPodInformer(ctx context.Context, client kubernetes.Interface, functionName, namespace string) (<-chan string, error) {
+// functionPodSelector selects the Pods of a function
+func functionPodSelector(functionName string) (string, error) {
 	functionSelector := &metav1.LabelSelector{
 		MatchLabels: map[string]string{"faas_function": functionName},
 	}
 	selector, err := metav1.LabelSelectorAsSelector(functionSelector)
 	if err != nil {
-		err = errors.Wrap(err, "unable to build function selector")
+		return "", errors.Wrap(err, "unable to build function selector")
+	}
+	return selector.String(), nil
+}
+
+// listFunctionPods returns the current Pods of the function, or only the Pod named instance
+// when it is set
+func listFunctionPods(ctx context.Context, client kubernetes.Interface, functionName, namespace, instance string) ([]*corev1.Pod, error) {
+	selector, err := functionPodSelector(functionName)
+	if err != nil {
 		log.Printf("PodInformer: %s", err)
 		return nil, err
 	}
 
-	log.Printf("PodInformer: starting informer for %s in: %s\n", selector.String(), namespace)
-	factory := informers.NewFilteredSharedInformerFactory(
-		client,
-		podInformerResync,
-		namespace,
-		withLabels(selector.String()),
-	)
-
-	podInformer := factory.Core().V1().Pods()
-	podsResp, err := client.CoreV1().Pods(namespace).List(context.TODO(), metav1.ListOptions{LabelSelector: selector.String()})
+	podsResp, err := client.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
 	if err != nil {
 		log.Printf("PodInformer: %s", err)
 		return nil, err
 	}
 
-	pods := podsResp.Items
+	var pods []*corev1.Pod
+	for i := range podsResp.Items {
+		if len(instance) > 0 && podsResp.Items[i].Name != instance {
+			continue
+		}
+		pods = append(pods, &podsResp.Items[i])
+	}
+
 	if len(pods) == 0 {
 		err = errors.New("no matching instances found")
 		log.Printf("PodInformer: %s", err)
 		return nil, err
 	}
 
+	return pods, nil
+}
+
+// startFunctionPodInformer will gather the list of existing Pods for the function, it will then watch
+// and watch for newly added or deleted function instances.
+func startFunctionPodInformer(ctx context.Context, client kubernetes.Interface, functionName, namespace, instance string) (<-chan *corev1.Pod, error) {
+	selector, err := functionPodSelector(functionName)
+	if err != nil {
+		log.Printf("PodInformer: %s", err)
+		return nil, err
+	}
+
+	pods, err := listFunctionPods(ctx, client, functionName, namespace, instance)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("PodInformer: starting informer for %s in: %s\n", selector, namespace)
+	factory := informers.NewFilteredSharedInformerFactory(
+		client,
+		podInformerResync,
+		namespace,
+		withLabels(selector),
+	)
+
+	podInformer := factory.Core().V1().Pods()
+
 	// prepare channel with enough space for the current instance set
-	added := make(chan string, len(pods))
+	added := make(chan *corev1.Pod, len(pods))
 	podInformer.Informer().AddEventHandler(&podLoggerEventHandler{
 		added: added,
 	})
 
 	// will add existing pods to the chan and then listen for any new pods
 	go podInformer.Informer().Run(ctx.Done())
-	go func() {
-		<-ctx.Done()
-		close(added)
-	}()
 
 	return added, nil
 }
@@ -224,14 +351,14 @@ func withLabels(selector string) internalinterfaces.TweakListOptionsFunc {
 
 type podLoggerEventHandler struct {
 	cache.ResourceEventHandler
-	added   chan<- string
+	added   chan<- *corev1.Pod
 	deleted chan<- string
 }
 
 func (h *podLoggerEventHandler) OnAdd(obj interface{}) {
 	pod := obj.(*corev1.Pod)
 	log.Printf("PodInformer: adding instance: %s", pod.Name)
-	h.added <- pod.Name
+	h.added <- pod
 }
 
 func (h *podLoggerEventHandler) OnUpdate(oldObj, newObj interface{}) {
@@ -0,0 +1,114 @@
+package k8s
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_mergeLogs(t *testing.T) {
+	at := func(s int) time.Time { return time.Unix(int64(s), 0) }
+
+	lines := []Log{
+		{PodName: "a", Text: "a1", Timestamp: at(1)},
+		{PodName: "a", Text: "a3", Timestamp: at(3)},
+		{PodName: "b", Text: "b2", Timestamp: at(2)},
+		{PodName: "b", Text: "b3", Timestamp: at(3)},
+		{PodName: "c", Text: "c4", Timestamp: at(4)},
+	}
+
+	cases := []struct {
+		name string
+		tail int64
+		want []string
+	}{
+		{name: "all lines in timestamp order", want: []string{"a1", "b2", "a3", "b3", "c4"}},
+		{name: "tail across all pods", tail: 2, want: []string{"b3", "c4"}},
+		{name: "tail longer than the logs", tail: 10, want: []string{"a1", "b2", "a3", "b3", "c4"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			input := append([]Log{}, lines...)
+
+			var got []string
+			for _, line := range mergeLogs(input, tc.tail) {
+				got = append(got, line.Text)
+			}
+
+			if !reflect.DeepEqual(tc.want, got) {
+				t.Errorf("want: %v, got: %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func Test_readLogLines(t *testing.T) {
+	stream := strings.NewReader("2020-01-01T00:00:01Z first\n2020-01-01T00:00:02Z second")
+
+	var got []string
+	readLogLines(stream, func(msg string, ts time.Time) {
+		got = append(got, ts.Format(time.RFC3339)+" "+strings.TrimSpace(msg))
+	})
+
+	want := []string{"2020-01-01T00:00:01Z first", "2020-01-01T00:00:02Z second"}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("want: %v, got: %v", want, got)
+	}
+}
+
+func Test_GetLogs_AllReplicas(t *testing.T) {
+	newPod := func(name, version string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "openfaas-fn",
+				Labels:    map[string]string{"faas_function": "fn", "version": version},
+			},
+		}
+	}
+
+	client := fake.NewSimpleClientset(newPod("fn-a", "1"), newPod("fn-b", "2"))
+
+	cases := []struct {
+		name     string
+		instance string
+		want     []string
+	}{
+		{name: "all replicas", want: []string{"fn-a/1", "fn-b/2"}},
+		{name: "single instance", instance: "fn-b", want: []string{"fn-b/2"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			lines, err := GetLogs(context.Background(), client, "fn", "", "openfaas-fn", tc.instance, 0, nil, false)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			var got []string
+			for line := range lines {
+				if line.Namespace != "openfaas-fn" || line.FunctionName != "fn" {
+					t.Errorf("want line tagged with fn in openfaas-fn, got: %+v", line)
+				}
+				got = append(got, line.PodName+"/"+line.Labels["version"])
+			}
+			sort.Strings(got)
+
+			if !reflect.DeepEqual(tc.want, got) {
+				t.Errorf("want: %v, got: %v", tc.want, got)
+			}
+		})
+	}
+
+	if _, err := GetLogs(context.Background(), client, "fn", "", "openfaas-fn", "fn-c", 0, nil, false); err == nil {
+		t.Errorf("want an error for an unknown instance")
+	}
+}
@@ -0,0 +1,184 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/openfaas/faas-provider/logs"
+)
+
+const (
+	// lokiQueryLimit is the maximum number of log lines requested from Loki per query
+	lokiQueryLimit = 5000
+
+	// lokiPollInterval is how often Loki is queried for new lines when following logs
+	lokiPollInterval = 2 * time.Second
+)
+
+// LokiLogRequestor implements the Requestor interface by querying a Loki server, so that the
+// logs of Pods that no longer exist can still be read. Log lines are selected by the
+// namespace and faas_function labels.
+type LokiLogRequestor struct {
+	client            *http.Client
+	baseURL           string
+	functionNamespace string
+}
+
+// NewLokiLogRequestor returns a new logs.Requestor that reads function logs from Loki
+func NewLokiLogRequestor(client *http.Client, baseURL, functionNamespace string) *LokiLogRequestor {
+	return &LokiLogRequestor{
+		client:            client,
+		baseURL:           strings.TrimSuffix(baseURL, "/"),
+		functionNamespace: functionNamespace,
+	}
+}
+
+// lokiQueryResponse is the subset of the Loki query_range response that is used
+type lokiQueryResponse struct {
+	Data struct {
+		Result []struct {
+			Stream map[string]string `json:"stream"`
+			Values [][2]string       `json:"values"`
+		} `json:"result"`
+	} `json:"data"`
+}
+
+// Query implements the Requestor interface. When r.Follow is set, Loki is polled for new lines
+// until the context is cancelled.
+func (l LokiLogRequestor) Query(ctx context.Context, r logs.Request) (<-chan logs.Message, error) {
+	ns := l.functionNamespace
+
+	if len(r.Namespace) > 0 && strings.ToLower(r.Namespace) != "kube-system" {
+		ns = r.Namespace
+	}
+
+	start := time.Now().Add(-defaultLogSince)
+	if r.Since != nil && !r.Since.IsZero() {
+		start = *r.Since
+	}
+
+	// the first query is made before returning, so that errors can be reported to the caller
+	messages, err := l.queryRange(ctx, r.Name, ns, r.Instance, start, r.Tail)
+	if err != nil {
+		log.Printf("LokiLogRequestor: get logs failed: %s\n", err)
+		return nil, err
+	}
+
+	msgStream := make(chan logs.Message, LogBufferSize)
+	go func() {
+		defer close(msgStream)
+
+		for {
+			for _, msg := range messages {
+				select {
+				case msgStream <- msg:
+				case <-ctx.Done():
+					return
+				}
+				start = msg.Timestamp.Add(time.Nanosecond)
+			}
+
+			if !r.Follow {
+				return
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(lokiPollInterval):
+			}
+
+			messages, err = l.queryRange(ctx, r.Name, ns, r.Instance, start, 0)
+			if err != nil {
+				log.Printf("LokiLogRequestor: follow logs failed: %s\n", err)
+				return
+			}
+		}
+	}()
+
+	return msgStream, nil
+}
+
+// queryRange returns the log lines of the function since start in chronological order. When
+// tail is positive only the latest tail lines are returned.
+func (l LokiLogRequestor) queryRange(ctx context.Context, name, namespace, instance string, start time.Time, tail int) ([]logs.Message, error) {
+	selector := fmt.Sprintf(`{namespace=%q,faas_function=%q`, namespace, name)
+	if len(instance) > 0 {
+		selector += fmt.Sprintf(`,pod=%q`, instance)
+	}
+	selector += "}"
+
+	limit := lokiQueryLimit
+	direction := "forward"
+	if tail > 0 {
+		limit = tail
+		direction = "backward"
+	}
+
+	query := url.Values{}
+	query.Set("query", selector)
+	query.Set("start", strconv.FormatInt(start.UnixNano(), 10))
+	query.Set("end", strconv.FormatInt(time.Now().UnixNano(), 10))
+	query.Set("limit", strconv.Itoa(limit))
+	query.Set("direction", direction)
+
+	req, err := http.NewRequest(http.MethodGet, l.baseURL+"/loki/api/v1/query_range?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := l.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code from Loki: %d", res.StatusCode)
+	}
+
+	var body lokiQueryResponse
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("unable to decode Loki response: %s", err)
+	}
+
+	var messages []logs.Message
+	for _, stream := range body.Data.Result {
+		for _, value := range stream.Values {
+			ts, err := strconv.ParseInt(value[0], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid timestamp in Loki response: %q", value[0])
+			}
+
+			messages = append(messages, logs.Message{
+				Name:      name,
+				Namespace: namespace,
+				Instance:  stream.Stream["pod"],
+				Timestamp: time.Unix(0, ts).UTC(),
+				Text:      value[1],
+			})
+		}
+	}
+
+	// each stream is ordered on its own, so the streams are merged by timestamp
+	sort.SliceStable(messages, func(i, j int) bool {
+		return messages[i].Timestamp.Before(messages[j].Timestamp)
+	})
+
+	if tail > 0 && len(messages) > tail {
+		messages = messages[len(messages)-tail:]
+	}
+
+	return messages, nil
+}
@@ -0,0 +1,105 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/openfaas/faas-provider/logs"
+)
+
+func Test_LokiLogRequestor_Query(t *testing.T) {
+	var gotQuery, gotDirection, gotLimit string
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/loki/api/v1/query_range" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		gotQuery = r.URL.Query().Get("query")
+		gotDirection = r.URL.Query().Get("direction")
+		gotLimit = r.URL.Query().Get("limit")
+
+		w.Write([]byte(`{"status":"success","data":{"resultType":"streams","result":[
+			{"stream":{"pod":"fn-b"},"values":[["3000000000","third"],["1000000000","first"]]},
+			{"stream":{"pod":"fn-a"},"values":[["2000000000","second"]]}
+		]}}`))
+	}))
+	defer srv.Close()
+
+	requestor := NewLokiLogRequestor(srv.Client(), srv.URL+"/", "openfaas-fn")
+
+	cases := []struct {
+		name          string
+		request       logs.Request
+		wantQuery     string
+		wantDirection string
+		wantLimit     string
+		wantText      []string
+	}{
+		{
+			name:          "all lines in order",
+			request:       logs.Request{Name: "fn"},
+			wantQuery:     `{namespace="openfaas-fn",faas_function="fn"}`,
+			wantDirection: "forward",
+			wantLimit:     "5000",
+			wantText:      []string{"first", "second", "third"},
+		},
+		{
+			name:          "tail returns the latest lines",
+			request:       logs.Request{Name: "fn", Namespace: "dev", Instance: "fn-a", Tail: 2},
+			wantQuery:     `{namespace="dev",faas_function="fn",pod="fn-a"}`,
+			wantDirection: "backward",
+			wantLimit:     "2",
+			wantText:      []string{"second", "third"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			stream, err := requestor.Query(context.Background(), tc.request)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			var got []string
+			for msg := range stream {
+				got = append(got, msg.Text)
+			}
+
+			if gotQuery != tc.wantQuery {
+				t.Errorf("want query %s, got %s", tc.wantQuery, gotQuery)
+			}
+			if gotDirection != tc.wantDirection {
+				t.Errorf("want direction %s, got %s", tc.wantDirection, gotDirection)
+			}
+			if gotLimit != tc.wantLimit {
+				t.Errorf("want limit %s, got %s", tc.wantLimit, gotLimit)
+			}
+			if len(got) != len(tc.wantText) {
+				t.Fatalf("want lines %v, got %v", tc.wantText, got)
+			}
+			for i := range got {
+				if got[i] != tc.wantText[i] {
+					t.Errorf("want lines %v, got %v", tc.wantText, got)
+					break
+				}
+			}
+		})
+	}
+}
+
+func Test_LokiLogRequestor_QueryError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	requestor := NewLokiLogRequestor(srv.Client(), srv.URL, "openfaas-fn")
+	if _, err := requestor.Query(context.Background(), logs.Request{Name: "fn"}); err == nil {
+		t.Fatalf("want error, got nil")
+	}
+}
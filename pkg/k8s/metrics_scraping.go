@@ -0,0 +1,63 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+)
+
+const (
+	// MetricsPathAnnotationKey is the path that Prometheus scrapes the function's Pods on
+	MetricsPathAnnotationKey = "com.openfaas.metrics.path"
+	// MetricsPortAnnotationKey is the port that Prometheus scrapes the function's Pods on
+	MetricsPortAnnotationKey = "com.openfaas.metrics.port"
+
+	// DefaultMetricsPath is scraped when only the port is set or scraping is enabled globally
+	DefaultMetricsPath = "/metrics"
+	// DefaultMetricsPort is scraped when only the path is set or scraping is enabled globally
+	DefaultMetricsPort = "8080"
+
+	prometheusScrapeAnnotation = "prometheus.io/scrape"
+	prometheusPathAnnotation   = "prometheus.io/path"
+	prometheusPortAnnotation   = "prometheus.io/port"
+)
+
+// ConfigureMetricsScraping writes the Prometheus scrape annotations to the Pod template when the
+// function sets MetricsPathAnnotationKey or MetricsPortAnnotationKey, or when
+// DeploymentConfig.EnablePodMetricsScraping is set. Otherwise the Pod template is left as it is.
+func (f *FunctionFactory) ConfigureMetricsScraping(annotations map[string]string, deployment *appsv1.Deployment) error {
+	path, hasPath := annotations[MetricsPathAnnotationKey]
+	port, hasPort := annotations[MetricsPortAnnotationKey]
+
+	if !hasPath && !hasPort && !f.Config.EnablePodMetricsScraping {
+		return nil
+	}
+
+	if !hasPath {
+		path = DefaultMetricsPath
+	}
+	if !hasPort {
+		port = DefaultMetricsPort
+	}
+
+	if !strings.HasPrefix(path, "/") {
+		return fmt.Errorf("invalid %s: %q, must start with /", MetricsPathAnnotationKey, path)
+	}
+	if v, err := strconv.Atoi(port); err != nil || v < 1 || v > 65535 {
+		return fmt.Errorf("invalid %s: %q, must be a port number", MetricsPortAnnotationKey, port)
+	}
+
+	if deployment.Spec.Template.Annotations == nil {
+		deployment.Spec.Template.Annotations = map[string]string{}
+	}
+	deployment.Spec.Template.Annotations[prometheusScrapeAnnotation] = "true"
+	deployment.Spec.Template.Annotations[prometheusPathAnnotation] = path
+	deployment.Spec.Template.Annotations[prometheusPortAnnotation] = port
+
+	return nil
+}
@@ -0,0 +1,83 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+)
+
+func Test_ConfigureMetricsScraping(t *testing.T) {
+	cases := []struct {
+		name        string
+		enabled     bool
+		annotations map[string]string
+		wantPath    string
+		wantPort    string
+		wantErr     bool
+	}{
+		{
+			name: "not enabled and no annotations",
+		},
+		{
+			name:     "enabled globally uses the defaults",
+			enabled:  true,
+			wantPath: "/metrics",
+			wantPort: "8080",
+		},
+		{
+			name:        "path and port from annotations",
+			annotations: map[string]string{MetricsPathAnnotationKey: "/stats", MetricsPortAnnotationKey: "9090"},
+			wantPath:    "/stats",
+			wantPort:    "9090",
+		},
+		{
+			name:        "port only uses the default path",
+			annotations: map[string]string{MetricsPortAnnotationKey: "9090"},
+			wantPath:    "/metrics",
+			wantPort:    "9090",
+		},
+		{
+			name:        "path without a leading slash",
+			annotations: map[string]string{MetricsPathAnnotationKey: "metrics"},
+			wantErr:     true,
+		},
+		{
+			name:        "port is not numeric",
+			annotations: map[string]string{MetricsPortAnnotationKey: "http"},
+			wantErr:     true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			factory := mockFactory()
+			factory.Config.EnablePodMetricsScraping = tc.enabled
+
+			deployment := &appsv1.Deployment{}
+			err := factory.ConfigureMetricsScraping(tc.annotations, deployment)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			got := deployment.Spec.Template.Annotations
+			if tc.wantPath == "" {
+				if len(got) > 0 {
+					t.Errorf("want no scrape annotations, got %v", got)
+				}
+				return
+			}
+			if got["prometheus.io/scrape"] != "true" || got["prometheus.io/path"] != tc.wantPath || got["prometheus.io/port"] != tc.wantPort {
+				t.Errorf("want path %s and port %s, got %v", tc.wantPath, tc.wantPort, got)
+			}
+		})
+	}
+}
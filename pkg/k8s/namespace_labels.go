@@ -0,0 +1,68 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"strings"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// NamespaceLabels caches the labels of each namespace that start with a prefix, such as
+// billing.example.com/, so that they can be inherited by the Pods of the functions in that
+// namespace. It is kept up to date by a namespace informer.
+type NamespaceLabels struct {
+	prefix string
+
+	lock   sync.RWMutex
+	labels map[string]map[string]string
+}
+
+// NewNamespaceLabels returns an empty cache for the labels that start with prefix
+func NewNamespaceLabels(prefix string) *NamespaceLabels {
+	return &NamespaceLabels{
+		prefix: prefix,
+		labels: map[string]map[string]string{},
+	}
+}
+
+// Update stores the matching labels of a namespace, replacing any previous labels
+func (n *NamespaceLabels) Update(namespace *corev1.Namespace) {
+	matched := map[string]string{}
+	for k, v := range namespace.Labels {
+		if strings.HasPrefix(k, n.prefix) {
+			matched[k] = v
+		}
+	}
+
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	if len(matched) == 0 {
+		delete(n.labels, namespace.Name)
+		return
+	}
+	n.labels[namespace.Name] = matched
+}
+
+// Delete removes the labels of a namespace
+func (n *NamespaceLabels) Delete(name string) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	delete(n.labels, name)
+}
+
+// Get returns the inherited labels of a namespace, a nil cache returns none
+func (n *NamespaceLabels) Get(namespace string) map[string]string {
+	if n == nil {
+		return nil
+	}
+
+	n.lock.RLock()
+	defer n.lock.RUnlock()
+
+	return n.labels[namespace]
+}
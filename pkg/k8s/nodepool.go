@@ -0,0 +1,48 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"fmt"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// NodePoolAnnotationKey places the function's Pods in a named node pool, it is mapped to a
+// nodeSelector on DeploymentConfig.NodePoolLabel so that functions do not need to know the
+// cloud-specific label, such as cloud.google.com/gke-nodepool
+const NodePoolAnnotationKey = "com.openfaas.nodepool"
+
+// ConfigureNodePool adds the node pool label from NodePoolAnnotationKey to the nodeSelector of
+// the Pod template. It must be called after the nodeSelector has been built from the
+// function's constraints, a constraint on the same label with a different value is an error.
+func (f *FunctionFactory) ConfigureNodePool(annotations map[string]string, deployment *appsv1.Deployment) error {
+	pool, ok := annotations[NodePoolAnnotationKey]
+	if !ok || len(pool) == 0 {
+		return nil
+	}
+
+	label := f.Config.NodePoolLabel
+	if len(label) == 0 {
+		return fmt.Errorf("invalid %s: node_pool_label is not configured", NodePoolAnnotationKey)
+	}
+
+	if errs := validation.IsValidLabelValue(pool); len(errs) > 0 {
+		return fmt.Errorf("invalid %s: %q, %s", NodePoolAnnotationKey, pool, strings.Join(errs, ", "))
+	}
+
+	selector := deployment.Spec.Template.Spec.NodeSelector
+	if selector == nil {
+		selector = map[string]string{}
+	}
+	if v, ok := selector[label]; ok && v != pool {
+		return fmt.Errorf("invalid %s: %q conflicts with the constraint %s=%s", NodePoolAnnotationKey, pool, label, v)
+	}
+
+	selector[label] = pool
+	deployment.Spec.Template.Spec.NodeSelector = selector
+	return nil
+}
@@ -0,0 +1,81 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+)
+
+func Test_ConfigureNodePool(t *testing.T) {
+	cases := []struct {
+		name     string
+		label    string
+		value    string
+		selector map[string]string
+		want     map[string]string
+		wantErr  bool
+	}{
+		{name: "no annotation", label: "cloud.google.com/gke-nodepool", want: nil},
+		{
+			name:  "pool added to the selector",
+			label: "cloud.google.com/gke-nodepool",
+			value: "gpu-pool",
+			want:  map[string]string{"cloud.google.com/gke-nodepool": "gpu-pool"},
+		},
+		{
+			name:     "constraints are kept",
+			label:    "cloud.google.com/gke-nodepool",
+			value:    "gpu-pool",
+			selector: map[string]string{"kubernetes.io/arch": "arm64"},
+			want:     map[string]string{"kubernetes.io/arch": "arm64", "cloud.google.com/gke-nodepool": "gpu-pool"},
+		},
+		{
+			name:     "conflicting constraint",
+			label:    "cloud.google.com/gke-nodepool",
+			value:    "gpu-pool",
+			selector: map[string]string{"cloud.google.com/gke-nodepool": "default-pool"},
+			wantErr:  true,
+		},
+		{name: "label not configured", value: "gpu-pool", wantErr: true},
+		{name: "invalid pool name", label: "cloud.google.com/gke-nodepool", value: "gpu pool!", wantErr: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			factory := mockFactory()
+			factory.Config.NodePoolLabel = tc.label
+
+			deployment := &appsv1.Deployment{}
+			deployment.Spec.Template.Spec.NodeSelector = tc.selector
+
+			annotations := map[string]string{}
+			if len(tc.value) > 0 {
+				annotations[NodePoolAnnotationKey] = tc.value
+			}
+
+			err := factory.ConfigureNodePool(annotations, deployment)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("want error for %q", tc.value)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			got := deployment.Spec.Template.Spec.NodeSelector
+			if len(got) != len(tc.want) {
+				t.Fatalf("want nodeSelector: %v, got: %v", tc.want, got)
+			}
+			for k, v := range tc.want {
+				if got[k] != v {
+					t.Errorf("want %s=%s, got %s", k, v, got[k])
+				}
+			}
+		})
+	}
+}
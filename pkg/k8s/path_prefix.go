@@ -0,0 +1,51 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// PathPrefixAnnotationKey is a base path, i.e. /api, that the proxy adds in front of the path
+// of each request before it is forwarded to the function, for frameworks that serve their
+// routes below a fixed base path
+const PathPrefixAnnotationKey = "com.openfaas.path.prefix"
+
+// PathPrefix returns the PathPrefixAnnotationKey without a trailing slash, an empty prefix is
+// returned when the annotation is not set or is /
+func PathPrefix(annotations map[string]string) (string, error) {
+	v, ok := annotations[PathPrefixAnnotationKey]
+	if !ok || len(v) == 0 {
+		return "", nil
+	}
+
+	if !strings.HasPrefix(v, "/") || strings.ContainsAny(v, "?#") {
+		return "", fmt.Errorf("invalid %s: %q, must be an absolute path without a query or fragment", PathPrefixAnnotationKey, v)
+	}
+
+	prefix := strings.TrimSuffix(v, "/")
+	if len(prefix) > 0 && path.Clean(prefix) != prefix {
+		return "", fmt.Errorf("invalid %s: %q, must be a clean path", PathPrefixAnnotationKey, v)
+	}
+
+	return prefix, nil
+}
+
+// PrefixPath adds prefix in front of the path of a request to the function. A request to the
+// root of the function goes to the root of the prefix, with a trailing slash, and any other
+// trailing slash is kept.
+func PrefixPath(prefix, requestPath string) string {
+	if len(prefix) == 0 {
+		return requestPath
+	}
+	if len(requestPath) == 0 || requestPath == "/" {
+		return prefix + "/"
+	}
+	if !strings.HasPrefix(requestPath, "/") {
+		requestPath = "/" + requestPath
+	}
+	return prefix + requestPath
+}
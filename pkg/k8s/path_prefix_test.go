@@ -0,0 +1,56 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import "testing"
+
+func Test_PathPrefix(t *testing.T) {
+	cases := []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{value: "", want: ""},
+		{value: "/", want: ""},
+		{value: "/api", want: "/api"},
+		{value: "/api/", want: "/api"},
+		{value: "/api/v1", want: "/api/v1"},
+		{value: "api", wantErr: true},
+		{value: "/api?v=1", wantErr: true},
+		{value: "/api#top", wantErr: true},
+		{value: "/api/../admin", wantErr: true},
+		{value: "//api", wantErr: true},
+	}
+
+	for _, tc := range cases {
+		got, err := PathPrefix(map[string]string{PathPrefixAnnotationKey: tc.value})
+		if tc.wantErr != (err != nil) {
+			t.Errorf("%q: want error: %v, got: %v", tc.value, tc.wantErr, err)
+		}
+		if got != tc.want {
+			t.Errorf("%q: want: %q, got: %q", tc.value, tc.want, got)
+		}
+	}
+}
+
+func Test_PrefixPath(t *testing.T) {
+	cases := []struct {
+		prefix string
+		path   string
+		want   string
+	}{
+		{prefix: "", path: "users", want: "users"},
+		{prefix: "/api", path: "", want: "/api/"},
+		{prefix: "/api", path: "/", want: "/api/"},
+		{prefix: "/api", path: "users", want: "/api/users"},
+		{prefix: "/api", path: "/users", want: "/api/users"},
+		{prefix: "/api", path: "users/", want: "/api/users/"},
+	}
+
+	for _, tc := range cases {
+		if got := PrefixPath(tc.prefix, tc.path); got != tc.want {
+			t.Errorf("PrefixPath(%q, %q) want: %q, got: %q", tc.prefix, tc.path, tc.want, got)
+		}
+	}
+}
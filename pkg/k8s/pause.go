@@ -0,0 +1,86 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"strconv"
+
+	appsv1 "k8s.io/api/apps/v1"
+)
+
+const (
+	// PausedAnnotationKey is set on the Deployment of a function that has been paused, the
+	// function is kept at zero replicas until it is resumed
+	PausedAnnotationKey = "com.openfaas.paused"
+
+	// pausedReplicasAnnotationKey records the replica count of the function when it was
+	// paused, so that it can be restored on resume
+	pausedReplicasAnnotationKey = "com.openfaas.paused.replicas"
+)
+
+// IsPaused returns true when the annotations mark the function as paused
+func IsPaused(annotations map[string]string) bool {
+	return annotations[PausedAnnotationKey] == "true"
+}
+
+// Pause scales the Deployment to zero and marks it as paused. The annotations are set on the
+// Deployment rather than the Pod template so that pausing does not trigger a rollout. It
+// returns false when the Deployment is already paused.
+func Pause(deployment *appsv1.Deployment) bool {
+	if IsPaused(deployment.Annotations) {
+		return false
+	}
+
+	replicas := int32(0)
+	if deployment.Spec.Replicas != nil {
+		replicas = *deployment.Spec.Replicas
+	}
+
+	if deployment.Annotations == nil {
+		deployment.Annotations = map[string]string{}
+	}
+	deployment.Annotations[PausedAnnotationKey] = "true"
+	deployment.Annotations[pausedReplicasAnnotationKey] = strconv.Itoa(int(replicas))
+
+	zero := int32(0)
+	deployment.Spec.Replicas = &zero
+
+	return true
+}
+
+// Resume is the inverse of Pause, the replica count from before the pause is restored with a
+// minimum of one replica. It returns false when the Deployment is not paused.
+func Resume(deployment *appsv1.Deployment) bool {
+	if !IsPaused(deployment.Annotations) {
+		return false
+	}
+
+	replicas := int32(1)
+	if v, err := strconv.Atoi(deployment.Annotations[pausedReplicasAnnotationKey]); err == nil && v > 0 {
+		replicas = int32(v)
+	}
+
+	delete(deployment.Annotations, PausedAnnotationKey)
+	delete(deployment.Annotations, pausedReplicasAnnotationKey)
+	deployment.Spec.Replicas = &replicas
+
+	return true
+}
+
+// CopyPauseAnnotations copies the pause state from the annotations of the existing Deployment
+// into the annotations of its replacement, so that updating a paused function keeps it paused.
+func CopyPauseAnnotations(from, to map[string]string) map[string]string {
+	if !IsPaused(from) {
+		return to
+	}
+
+	res := make(map[string]string, len(to)+2)
+	for k, v := range to {
+		res[k] = v
+	}
+	res[PausedAnnotationKey] = from[PausedAnnotationKey]
+	res[pausedReplicasAnnotationKey] = from[pausedReplicasAnnotationKey]
+
+	return res
+}
@@ -0,0 +1,81 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+)
+
+func Test_PauseAndResume_RestoresReplicas(t *testing.T) {
+	replicas := int32(3)
+	deployment := &appsv1.Deployment{}
+	deployment.Spec.Replicas = &replicas
+
+	if !Pause(deployment) {
+		t.Fatalf("want the deployment to be paused")
+	}
+	if !IsPaused(deployment.Annotations) {
+		t.Errorf("want %s annotation, got: %v", PausedAnnotationKey, deployment.Annotations)
+	}
+	if *deployment.Spec.Replicas != 0 {
+		t.Errorf("want 0 replicas when paused, got: %d", *deployment.Spec.Replicas)
+	}
+
+	if Pause(deployment) {
+		t.Errorf("want pausing a paused deployment to be a no-op")
+	}
+
+	if !Resume(deployment) {
+		t.Fatalf("want the deployment to be resumed")
+	}
+	if IsPaused(deployment.Annotations) || len(deployment.Annotations) != 0 {
+		t.Errorf("want the pause annotations removed, got: %v", deployment.Annotations)
+	}
+	if *deployment.Spec.Replicas != 3 {
+		t.Errorf("want 3 replicas when resumed, got: %d", *deployment.Spec.Replicas)
+	}
+
+	if Resume(deployment) {
+		t.Errorf("want resuming a running deployment to be a no-op")
+	}
+}
+
+func Test_Resume_ScaledToZeroRestoresOneReplica(t *testing.T) {
+	replicas := int32(0)
+	deployment := &appsv1.Deployment{}
+	deployment.Spec.Replicas = &replicas
+
+	Pause(deployment)
+	Resume(deployment)
+
+	if *deployment.Spec.Replicas != 1 {
+		t.Errorf("want 1 replica when resumed, got: %d", *deployment.Spec.Replicas)
+	}
+}
+
+func Test_CopyPauseAnnotations(t *testing.T) {
+	paused := map[string]string{
+		PausedAnnotationKey:         "true",
+		pausedReplicasAnnotationKey: "2",
+		"com.openfaas.old":          "value",
+	}
+	annotations := map[string]string{"com.openfaas.new": "value"}
+
+	got := CopyPauseAnnotations(paused, annotations)
+	if !IsPaused(got) || got[pausedReplicasAnnotationKey] != "2" || got["com.openfaas.new"] != "value" {
+		t.Errorf("want the pause state copied, got: %v", got)
+	}
+	if _, ok := got["com.openfaas.old"]; ok {
+		t.Errorf("want only the pause state copied, got: %v", got)
+	}
+	if IsPaused(annotations) {
+		t.Errorf("want the input annotations left unchanged")
+	}
+
+	if got := CopyPauseAnnotations(map[string]string{}, annotations); len(got) != 1 {
+		t.Errorf("want the annotations unchanged for a running function, got: %v", got)
+	}
+}
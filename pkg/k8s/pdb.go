@@ -0,0 +1,125 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	policyv1 "k8s.io/api/policy/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// PDBMinAvailableAnnotationKey overrides DeploymentConfig.PDBMinAvailable for a function, as
+	// a number of Pods or a percentage such as 50%
+	PDBMinAvailableAnnotationKey = "com.openfaas.pdb.min-available"
+
+	// OwnerLabel marks a resource as belonging to a function, for resources which are not
+	// owned through an owner reference
+	OwnerLabel = "faas-netes/owner"
+)
+
+// PDBMinAvailable returns the minAvailable of the function's PodDisruptionBudget from the
+// PDBMinAvailableAnnotationKey annotation or DeploymentConfig.PDBMinAvailable, nil is returned
+// when neither is set.
+func (f *FunctionFactory) PDBMinAvailable(annotations map[string]string) (*intstr.IntOrString, error) {
+	value := f.Config.PDBMinAvailable
+	if v, ok := annotations[PDBMinAvailableAnnotationKey]; ok && len(v) > 0 {
+		value = v
+	}
+	if len(value) == 0 {
+		return nil, nil
+	}
+
+	minAvailable := intstr.Parse(value)
+	if _, err := intstr.GetScaledValueFromIntOrPercent(&minAvailable, 100, true); err != nil {
+		return nil, fmt.Errorf("invalid %s: %q, must be a number or a percentage", PDBMinAvailableAnnotationKey, value)
+	}
+	if minAvailable.Type == intstr.Int && minAvailable.IntVal < 0 {
+		return nil, fmt.Errorf("invalid %s: %q, must not be negative", PDBMinAvailableAnnotationKey, value)
+	}
+
+	return &minAvailable, nil
+}
+
+// PDBWarning describes a minAvailable that leaves no Pod of the function to be evicted, which
+// blocks node drains. An empty string is returned when there is nothing to warn about.
+func PDBWarning(minAvailable *intstr.IntOrString, replicas int32) string {
+	if minAvailable == nil || replicas <= 1 {
+		return ""
+	}
+
+	required, err := intstr.GetScaledValueFromIntOrPercent(minAvailable, int(replicas), true)
+	if err != nil || required < int(replicas) {
+		return ""
+	}
+
+	return fmt.Sprintf("PodDisruptionBudget min-available %s is not less than the %d replicas, voluntary evictions will be blocked", minAvailable.String(), replicas)
+}
+
+// ConfigurePDB creates or updates the PodDisruptionBudget of a function when it has a
+// minAvailable and more than one replica, otherwise any existing PodDisruptionBudget is
+// deleted, so that removing the annotation on update removes it.
+func (f *FunctionFactory) ConfigurePDB(ctx context.Context, namespace, name string, annotations map[string]string, replicas int32) error {
+	minAvailable, err := f.PDBMinAvailable(annotations)
+	if err != nil {
+		return err
+	}
+
+	if minAvailable == nil || replicas <= 1 {
+		return DeletePDB(ctx, f.Client, namespace, name)
+	}
+
+	pdbs := f.Client.PolicyV1().PodDisruptionBudgets(namespace)
+
+	existing, err := pdbs.Get(ctx, name, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		_, err := pdbs.Create(ctx, &policyv1.PodDisruptionBudget{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+				Labels: map[string]string{
+					"faas_function": name,
+					OwnerLabel:      name,
+				},
+			},
+			Spec: policyv1.PodDisruptionBudgetSpec{
+				MinAvailable: minAvailable,
+				Selector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{"faas_function": name},
+				},
+			},
+		}, metav1.CreateOptions{})
+		if err == nil {
+			log.Printf("PodDisruptionBudget created: %s.%s, min-available: %s\n", name, namespace, minAvailable.String())
+		}
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	if existing.Spec.MinAvailable != nil && *existing.Spec.MinAvailable == *minAvailable {
+		return nil
+	}
+
+	existing.Spec.MinAvailable = minAvailable
+	existing.Spec.MaxUnavailable = nil
+	_, err = pdbs.Update(ctx, existing, metav1.UpdateOptions{})
+	return err
+}
+
+// DeletePDB removes the PodDisruptionBudget of a function, if it has one
+func DeletePDB(ctx context.Context, client kubernetes.Interface, namespace, name string) error {
+	err := client.PolicyV1().PodDisruptionBudgets(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"context"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func Test_PDBMinAvailable(t *testing.T) {
+	cases := []struct {
+		name       string
+		defaultVal string
+		value      string
+		want       string
+		wantErr    bool
+	}{
+		{name: "not set", want: ""},
+		{name: "global default", defaultVal: "1", want: "1"},
+		{name: "annotation overrides the default", defaultVal: "1", value: "50%", want: "50%"},
+		{name: "invalid value", value: "half", wantErr: true},
+		{name: "negative value", value: "-1", wantErr: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			factory := mockFactory()
+			factory.Config.PDBMinAvailable = tc.defaultVal
+
+			annotations := map[string]string{}
+			if len(tc.value) > 0 {
+				annotations[PDBMinAvailableAnnotationKey] = tc.value
+			}
+
+			got, err := factory.PDBMinAvailable(annotations)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("want error for %q", tc.value)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			gotVal := ""
+			if got != nil {
+				gotVal = got.String()
+			}
+			if gotVal != tc.want {
+				t.Errorf("want %q, got %q", tc.want, gotVal)
+			}
+		})
+	}
+}
+
+func Test_PDBWarning(t *testing.T) {
+	cases := []struct {
+		name         string
+		minAvailable string
+		replicas     int32
+		wantWarning  bool
+	}{
+		{name: "fewer than the replicas", minAvailable: "1", replicas: 2},
+		{name: "equal to the replicas", minAvailable: "2", replicas: 2, wantWarning: true},
+		{name: "more than the replicas", minAvailable: "3", replicas: 2, wantWarning: true},
+		{name: "percentage of all replicas", minAvailable: "100%", replicas: 3, wantWarning: true},
+		{name: "percentage rounded up", minAvailable: "90%", replicas: 3, wantWarning: true},
+		{name: "percentage below the replicas", minAvailable: "50%", replicas: 3},
+		{name: "single replica has no PDB", minAvailable: "1", replicas: 1},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			minAvailable := intstr.Parse(tc.minAvailable)
+			got := PDBWarning(&minAvailable, tc.replicas)
+			if (len(got) > 0) != tc.wantWarning {
+				t.Errorf("want warning: %v, got: %q", tc.wantWarning, got)
+			}
+		})
+	}
+}
+
+func Test_ConfigurePDB(t *testing.T) {
+	factory := mockFactory()
+	ctx := context.Background()
+	pdbs := factory.Client.PolicyV1().PodDisruptionBudgets("openfaas-fn")
+
+	annotations := map[string]string{PDBMinAvailableAnnotationKey: "1"}
+	if err := factory.ConfigurePDB(ctx, "openfaas-fn", "nodeinfo", annotations, 3); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	pdb, err := pdbs.Get(ctx, "nodeinfo", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("want a PodDisruptionBudget, got error: %s", err)
+	}
+	if got := pdb.Spec.MinAvailable.String(); got != "1" {
+		t.Errorf("want min-available 1, got %s", got)
+	}
+	if got := pdb.Spec.Selector.MatchLabels["faas_function"]; got != "nodeinfo" {
+		t.Errorf("want selector faas_function=nodeinfo, got %q", got)
+	}
+
+	annotations[PDBMinAvailableAnnotationKey] = "2"
+	if err := factory.ConfigurePDB(ctx, "openfaas-fn", "nodeinfo", annotations, 3); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	pdb, _ = pdbs.Get(ctx, "nodeinfo", metav1.GetOptions{})
+	if got := pdb.Spec.MinAvailable.String(); got != "2" {
+		t.Errorf("want min-available updated to 2, got %s", got)
+	}
+
+	delete(annotations, PDBMinAvailableAnnotationKey)
+	if err := factory.ConfigurePDB(ctx, "openfaas-fn", "nodeinfo", annotations, 3); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err := pdbs.Get(ctx, "nodeinfo", metav1.GetOptions{}); err == nil {
+		t.Errorf("want the PodDisruptionBudget deleted when the annotation is removed")
+	}
+
+	if err := factory.ConfigurePDB(ctx, "openfaas-fn", "single", map[string]string{PDBMinAvailableAnnotationKey: "1"}, 1); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err := pdbs.Get(ctx, "single", metav1.GetOptions{}); err == nil {
+		t.Errorf("want no PodDisruptionBudget for a single replica")
+	}
+}
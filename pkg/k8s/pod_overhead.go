@@ -0,0 +1,174 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// PodOverheadAnnotationKey is the resource overhead of each replica of the function for
+	// runtimes whose RuntimeClass does not define one, such as "cpu=250m,memory=120Mi"
+	PodOverheadAnnotationKey = "com.openfaas.pod.overhead"
+
+	// EffectivePodOverheadAnnotationKey is set on the Pod template to the overhead that is
+	// accounted for each replica, from either the RuntimeClass or PodOverheadAnnotationKey
+	EffectivePodOverheadAnnotationKey = "com.openfaas.pod.overhead.effective"
+)
+
+// ParsePodOverhead parses a comma separated list of cpu and memory quantities in the form
+// name=quantity
+func ParsePodOverhead(value string) (corev1.ResourceList, error) {
+	list := corev1.ResourceList{}
+
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if len(pair) == 0 {
+			continue
+		}
+
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("%q must be in the form name=quantity", pair)
+		}
+
+		name := corev1.ResourceName(strings.TrimSpace(parts[0]))
+		if name != corev1.ResourceCPU && name != corev1.ResourceMemory {
+			return nil, fmt.Errorf("%q is not supported, the overhead can only be set for cpu and memory", name)
+		}
+		if _, ok := list[name]; ok {
+			return nil, fmt.Errorf("%s is given more than once", name)
+		}
+
+		qty, err := resource.ParseQuantity(strings.TrimSpace(parts[1]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid quantity for %s: %q", name, parts[1])
+		}
+		if qty.Sign() <= 0 {
+			return nil, fmt.Errorf("invalid quantity for %s: %q, must be positive", name, parts[1])
+		}
+
+		list[name] = qty
+	}
+
+	return list, nil
+}
+
+// PodOverhead returns the overhead set in the `com.openfaas.pod.overhead` annotation
+func PodOverhead(annotations map[string]string) (corev1.ResourceList, error) {
+	overhead, err := ParsePodOverhead(annotations[PodOverheadAnnotationKey])
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %s", PodOverheadAnnotationKey, err)
+	}
+	return overhead, nil
+}
+
+// formatResourceList formats a ResourceList in the format of the PodOverheadAnnotationKey
+func formatResourceList(list corev1.ResourceList) string {
+	pairs := make([]string, 0, len(list))
+	for name, qty := range list {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", name, qty.String()))
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// ConfigurePodOverhead accounts for the resource overhead of the function's runtime.
+//
+// The overhead of a RuntimeClass is copied to each Pod by the RuntimeClass admission controller,
+// which rejects Pods whose overhead differs from their RuntimeClass, so the overhead is never set
+// on the Pod template. When the RuntimeClass defines no overhead, the overhead from the
+// `com.openfaas.pod.overhead` annotation is added to the requests of the function's container,
+// and to its limits where it has one, so that it is reserved when the Pod is scheduled.
+//
+// The overhead in effect is recorded in the `com.openfaas.pod.overhead.effective` annotation of
+// the Pod template, which is returned by the function reader. This must be called after the
+// container's resources are set and Profiles are applied, because a Profile sets the RuntimeClass.
+func (f *FunctionFactory) ConfigurePodOverhead(annotations map[string]string, deployment *appsv1.Deployment) error {
+	explicit, err := PodOverhead(annotations)
+	if err != nil {
+		return err
+	}
+
+	spec := &deployment.Spec.Template.Spec
+	spec.Overhead = nil
+	delete(deployment.Spec.Template.Annotations, EffectivePodOverheadAnnotationKey)
+
+	effective, err := f.runtimeClassOverhead(spec.RuntimeClassName)
+	if err != nil {
+		return err
+	}
+
+	if len(effective) > 0 {
+		if len(explicit) > 0 {
+			log.Printf("%s: %s is ignored, the overhead of RuntimeClass %s is used\n",
+				deployment.Name, PodOverheadAnnotationKey, *spec.RuntimeClassName)
+		}
+	} else if len(explicit) > 0 {
+		resources := &spec.Containers[0].Resources
+		if resources.Requests == nil {
+			resources.Requests = corev1.ResourceList{}
+		}
+
+		for name, overhead := range explicit {
+			request := resources.Requests[name]
+			request.Add(overhead)
+			resources.Requests[name] = request
+
+			if limit, ok := resources.Limits[name]; ok {
+				limit.Add(overhead)
+				resources.Limits[name] = limit
+			}
+		}
+		effective = explicit
+	}
+
+	if len(effective) == 0 {
+		return nil
+	}
+
+	if deployment.Spec.Template.Annotations == nil {
+		deployment.Spec.Template.Annotations = map[string]string{}
+	}
+	deployment.Spec.Template.Annotations[EffectivePodOverheadAnnotationKey] = formatResourceList(effective)
+
+	return nil
+}
+
+// runtimeClassOverhead returns the fixed overhead of the RuntimeClass, a RuntimeClass that does
+// not exist has no overhead because the Pods of the function can not be started until it does.
+// RuntimeClasses are cluster scoped, so when the provider is limited to a namespace and can not
+// read them, the RuntimeClass is treated as having no overhead.
+func (f *FunctionFactory) runtimeClassOverhead(runtimeClassName *string) (corev1.ResourceList, error) {
+	if runtimeClassName == nil || len(*runtimeClassName) == 0 {
+		return nil, nil
+	}
+
+	runtimeClass, err := f.Client.NodeV1().RuntimeClasses().Get(context.TODO(), *runtimeClassName, metav1.GetOptions{})
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			return nil, nil
+		}
+		if k8serrors.IsForbidden(err) {
+			log.Printf("Unable to read the overhead of RuntimeClass %s: %s\n", *runtimeClassName, err)
+			return nil, nil
+		}
+		return nil, fmt.Errorf("unable to get RuntimeClass %s: %s", *runtimeClassName, err)
+	}
+
+	if runtimeClass.Overhead == nil {
+		return nil, nil
+	}
+	return runtimeClass.Overhead.PodFixed, nil
+}
@@ -0,0 +1,215 @@
+package k8s
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	nodev1 "k8s.io/api/node/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func Test_ParsePodOverhead(t *testing.T) {
+	cases := []struct {
+		name    string
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{name: "empty value has no overhead", value: "", want: ""},
+		{name: "cpu and memory", value: "cpu=250m, memory=120Mi", want: "cpu=250m,memory=120Mi"},
+		{name: "memory only", value: "memory=64Mi", want: "memory=64Mi"},
+		{name: "extended resources are rejected", value: "nvidia.com/gpu=1", wantErr: true},
+		{name: "missing quantity", value: "cpu", wantErr: true},
+		{name: "invalid quantity", value: "cpu=lots", wantErr: true},
+		{name: "zero quantity", value: "memory=0", wantErr: true},
+		{name: "duplicate resource", value: "cpu=1,cpu=2", wantErr: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ParsePodOverhead(tc.value)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if formatted := formatResourceList(got); formatted != tc.want {
+				t.Fatalf("want %q, got %q", tc.want, formatted)
+			}
+		})
+	}
+}
+
+func Test_ConfigurePodOverhead(t *testing.T) {
+	kata := &nodev1.RuntimeClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "kata"},
+		Handler:    "kata",
+		Overhead: &nodev1.Overhead{PodFixed: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("250m"),
+			corev1.ResourceMemory: resource.MustParse("160Mi"),
+		}},
+	}
+	gvisor := &nodev1.RuntimeClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "gvisor"},
+		Handler:    "runsc",
+	}
+
+	cases := []struct {
+		name          string
+		runtimeClass  string
+		annotations   map[string]string
+		wantEffective string
+		wantRequests  string
+		wantLimits    string
+		wantErr       bool
+	}{
+		{
+			name:         "no overhead leaves the resources unchanged",
+			wantRequests: "cpu=100m,memory=128Mi",
+			wantLimits:   "memory=256Mi",
+		},
+		{
+			name:          "annotation overhead is added to the requests and existing limits",
+			annotations:   map[string]string{PodOverheadAnnotationKey: "cpu=50m,memory=64Mi"},
+			wantEffective: "cpu=50m,memory=64Mi",
+			wantRequests:  "cpu=150m,memory=192Mi",
+			wantLimits:    "memory=320Mi",
+		},
+		{
+			name:          "annotation overhead is used when the RuntimeClass has none",
+			runtimeClass:  "gvisor",
+			annotations:   map[string]string{PodOverheadAnnotationKey: "memory=64Mi"},
+			wantEffective: "memory=64Mi",
+			wantRequests:  "cpu=100m,memory=192Mi",
+			wantLimits:    "memory=320Mi",
+		},
+		{
+			name:          "RuntimeClass overhead is recorded and left to the admission controller",
+			runtimeClass:  "kata",
+			wantEffective: "cpu=250m,memory=160Mi",
+			wantRequests:  "cpu=100m,memory=128Mi",
+			wantLimits:    "memory=256Mi",
+		},
+		{
+			name:          "RuntimeClass overhead takes precedence over the annotation",
+			runtimeClass:  "kata",
+			annotations:   map[string]string{PodOverheadAnnotationKey: "memory=64Mi"},
+			wantEffective: "cpu=250m,memory=160Mi",
+			wantRequests:  "cpu=100m,memory=128Mi",
+			wantLimits:    "memory=256Mi",
+		},
+		{
+			name:          "missing RuntimeClass has no overhead",
+			runtimeClass:  "missing",
+			annotations:   map[string]string{PodOverheadAnnotationKey: "cpu=50m"},
+			wantEffective: "cpu=50m",
+			wantRequests:  "cpu=150m,memory=128Mi",
+			wantLimits:    "memory=256Mi",
+		},
+		{
+			name:        "invalid annotation is an error",
+			annotations: map[string]string{PodOverheadAnnotationKey: "cpu=-1"},
+			wantErr:     true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			factory := FunctionFactory{Client: fake.NewSimpleClientset(kata, gvisor)}
+
+			deployment := &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "figlet"},
+				Spec: appsv1.DeploymentSpec{
+					Template: corev1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{
+							Annotations: map[string]string{EffectivePodOverheadAnnotationKey: "cpu=1"},
+						},
+						Spec: corev1.PodSpec{
+							// a stale overhead is cleared, it is set on each Pod by the admission controller
+							Overhead: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")},
+							Containers: []corev1.Container{{
+								Name: "figlet",
+								Resources: corev1.ResourceRequirements{
+									Requests: corev1.ResourceList{
+										corev1.ResourceCPU:    resource.MustParse("100m"),
+										corev1.ResourceMemory: resource.MustParse("128Mi"),
+									},
+									Limits: corev1.ResourceList{
+										corev1.ResourceMemory: resource.MustParse("256Mi"),
+									},
+								},
+							}},
+						},
+					},
+				},
+			}
+			if len(tc.runtimeClass) > 0 {
+				deployment.Spec.Template.Spec.RuntimeClassName = &tc.runtimeClass
+			}
+
+			err := factory.ConfigurePodOverhead(tc.annotations, deployment)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			spec := deployment.Spec.Template.Spec
+			if spec.Overhead != nil {
+				t.Errorf("want no overhead on the Pod template, got %v", spec.Overhead)
+			}
+			if got := deployment.Spec.Template.Annotations[EffectivePodOverheadAnnotationKey]; got != tc.wantEffective {
+				t.Errorf("want effective overhead %q, got %q", tc.wantEffective, got)
+			}
+			if got := formatResourceList(spec.Containers[0].Resources.Requests); got != tc.wantRequests {
+				t.Errorf("want requests %q, got %q", tc.wantRequests, got)
+			}
+			if got := formatResourceList(spec.Containers[0].Resources.Limits); got != tc.wantLimits {
+				t.Errorf("want limits %q, got %q", tc.wantLimits, got)
+			}
+		})
+	}
+}
+
+func Test_ConfigurePodOverhead_RuntimeClassForbidden(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("get", "runtimeclasses", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, k8serrors.NewForbidden(schema.GroupResource{Group: "node.k8s.io", Resource: "runtimeclasses"}, "kata", nil)
+	})
+	factory := FunctionFactory{Client: client}
+
+	runtimeClass := "kata"
+	deployment := &appsv1.Deployment{
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					RuntimeClassName: &runtimeClass,
+					Containers:       []corev1.Container{{Name: "figlet"}},
+				},
+			},
+		},
+	}
+
+	err := factory.ConfigurePodOverhead(map[string]string{PodOverheadAnnotationKey: "memory=64Mi"}, deployment)
+	if err != nil {
+		t.Fatalf("want no error when RuntimeClasses can not be read, got: %s", err)
+	}
+	if got := deployment.Spec.Template.Annotations[EffectivePodOverheadAnnotationKey]; got != "memory=64Mi" {
+		t.Errorf("want the annotation overhead to be used, got %q", got)
+	}
+}
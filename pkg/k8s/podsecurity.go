@@ -0,0 +1,176 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// PodSecurityEnforceLabel is the namespace label that sets the enforced Pod Security Standard
+	PodSecurityEnforceLabel = "pod-security.kubernetes.io/enforce"
+
+	// PodSecurityPrivileged is unrestricted
+	PodSecurityPrivileged = "privileged"
+
+	// PodSecurityBaseline prevents known privilege escalations
+	PodSecurityBaseline = "baseline"
+
+	// PodSecurityRestricted additionally enforces Pod hardening best practices
+	PodSecurityRestricted = "restricted"
+)
+
+// baselineCapabilities are the capabilities that may be added under the baseline standard
+var baselineCapabilities = map[corev1.Capability]bool{
+	"AUDIT_WRITE":      true,
+	"CHOWN":            true,
+	"DAC_OVERRIDE":     true,
+	"FOWNER":           true,
+	"FSETID":           true,
+	"KILL":             true,
+	"MKNOD":            true,
+	"NET_BIND_SERVICE": true,
+	"SETFCAP":          true,
+	"SETGID":           true,
+	"SETPCAP":          true,
+	"SETUID":           true,
+	"SYS_CHROOT":       true,
+}
+
+// CheckPodSecurity validates a Pod spec against the Pod Security Standard level that is
+// enforced by a namespace, so that a rejection can be reported when the function is
+// deployed rather than when its Pods are created. The privileged level, or an unknown
+// level, allows everything.
+func CheckPodSecurity(level string, spec corev1.PodSpec) error {
+	var violations []string
+
+	switch level {
+	case PodSecurityRestricted:
+		violations = append(checkBaseline(spec), checkRestricted(spec)...)
+	case PodSecurityBaseline:
+		violations = checkBaseline(spec)
+	default:
+		return nil
+	}
+
+	if len(violations) > 0 {
+		return fmt.Errorf("the function violates the %q Pod Security Standard enforced on the namespace: %s",
+			level, strings.Join(violations, ", "))
+	}
+
+	return nil
+}
+
+func allContainers(spec corev1.PodSpec) []corev1.Container {
+	containers := append([]corev1.Container{}, spec.InitContainers...)
+	return append(containers, spec.Containers...)
+}
+
+func checkBaseline(spec corev1.PodSpec) []string {
+	var violations []string
+
+	if spec.HostNetwork || spec.HostPID || spec.HostIPC {
+		violations = append(violations, "host namespaces are not allowed")
+	}
+
+	for _, c := range allContainers(spec) {
+		sc := c.SecurityContext
+		if sc == nil {
+			continue
+		}
+
+		if sc.Privileged != nil && *sc.Privileged {
+			violations = append(violations, fmt.Sprintf("container %q must not be privileged", c.Name))
+		}
+
+		if sc.Capabilities != nil {
+			for _, capability := range sc.Capabilities.Add {
+				if !baselineCapabilities[capability] {
+					violations = append(violations, fmt.Sprintf("container %q must not add capability %s", c.Name, capability))
+				}
+			}
+		}
+	}
+
+	return violations
+}
+
+func checkRestricted(spec corev1.PodSpec) []string {
+	var violations []string
+
+	podRunAsNonRoot := false
+	podSeccomp := false
+	if psc := spec.SecurityContext; psc != nil {
+		podRunAsNonRoot = psc.RunAsNonRoot != nil && *psc.RunAsNonRoot
+		podSeccomp = validSeccompProfile(psc.SeccompProfile)
+
+		if psc.RunAsUser != nil && *psc.RunAsUser == 0 {
+			violations = append(violations, "runAsUser must not be 0")
+		}
+	}
+
+	for _, c := range allContainers(spec) {
+		sc := c.SecurityContext
+		if sc == nil {
+			sc = &corev1.SecurityContext{}
+		}
+
+		runAsNonRoot := podRunAsNonRoot
+		if sc.RunAsNonRoot != nil {
+			runAsNonRoot = *sc.RunAsNonRoot
+		}
+		if !runAsNonRoot {
+			violations = append(violations, fmt.Sprintf("container %q must set runAsNonRoot=true", c.Name))
+		}
+
+		if sc.RunAsUser != nil && *sc.RunAsUser == 0 {
+			violations = append(violations, fmt.Sprintf("container %q must not set runAsUser=0", c.Name))
+		}
+
+		if sc.AllowPrivilegeEscalation == nil || *sc.AllowPrivilegeEscalation {
+			violations = append(violations, fmt.Sprintf("container %q must set allowPrivilegeEscalation=false", c.Name))
+		}
+
+		if !dropsAllCapabilities(sc.Capabilities) {
+			violations = append(violations, fmt.Sprintf("container %q must drop ALL capabilities", c.Name))
+		}
+
+		if sc.Capabilities != nil {
+			for _, capability := range sc.Capabilities.Add {
+				if capability != "NET_BIND_SERVICE" {
+					violations = append(violations, fmt.Sprintf("container %q may only add capability NET_BIND_SERVICE", c.Name))
+				}
+			}
+		}
+
+		if sc.SeccompProfile != nil && !validSeccompProfile(sc.SeccompProfile) ||
+			sc.SeccompProfile == nil && !podSeccomp {
+			violations = append(violations, fmt.Sprintf("container %q must use the RuntimeDefault or Localhost seccomp profile", c.Name))
+		}
+	}
+
+	return violations
+}
+
+func dropsAllCapabilities(capabilities *corev1.Capabilities) bool {
+	if capabilities == nil {
+		return false
+	}
+
+	for _, capability := range capabilities.Drop {
+		if capability == "ALL" {
+			return true
+		}
+	}
+
+	return false
+}
+
+func validSeccompProfile(profile *corev1.SeccompProfile) bool {
+	return profile != nil &&
+		(profile.Type == corev1.SeccompProfileTypeRuntimeDefault || profile.Type == corev1.SeccompProfileTypeLocalhost)
+}
@@ -0,0 +1,114 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func Test_CheckPodSecurity(t *testing.T) {
+	yes := true
+	no := false
+
+	restrictedContainer := corev1.Container{
+		Name: "fn",
+		SecurityContext: &corev1.SecurityContext{
+			RunAsNonRoot:             &yes,
+			AllowPrivilegeEscalation: &no,
+			Capabilities:             &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
+			SeccompProfile:           &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault},
+		},
+	}
+
+	privilegedContainer := corev1.Container{
+		Name:            "fn",
+		SecurityContext: &corev1.SecurityContext{Privileged: &yes},
+	}
+
+	cases := []struct {
+		name    string
+		level   string
+		spec    corev1.PodSpec
+		wantErr bool
+	}{
+		{
+			name:  "privileged level allows everything",
+			level: "privileged",
+			spec:  corev1.PodSpec{Containers: []corev1.Container{privilegedContainer}},
+		},
+		{
+			name:  "no label allows everything",
+			level: "",
+			spec:  corev1.PodSpec{Containers: []corev1.Container{privilegedContainer}},
+		},
+		{
+			name:    "baseline rejects privileged",
+			level:   PodSecurityBaseline,
+			spec:    corev1.PodSpec{Containers: []corev1.Container{privilegedContainer}},
+			wantErr: true,
+		},
+		{
+			name:    "baseline rejects host network",
+			level:   PodSecurityBaseline,
+			spec:    corev1.PodSpec{HostNetwork: true, Containers: []corev1.Container{{Name: "fn"}}},
+			wantErr: true,
+		},
+		{
+			name:  "baseline allows a default container",
+			level: PodSecurityBaseline,
+			spec:  corev1.PodSpec{Containers: []corev1.Container{{Name: "fn"}}},
+		},
+		{
+			name:    "restricted rejects a default container",
+			level:   PodSecurityRestricted,
+			spec:    corev1.PodSpec{Containers: []corev1.Container{{Name: "fn"}}},
+			wantErr: true,
+		},
+		{
+			name:  "restricted allows a hardened container",
+			level: PodSecurityRestricted,
+			spec:  corev1.PodSpec{Containers: []corev1.Container{restrictedContainer}},
+		},
+		{
+			name:  "restricted rejects runAsUser 0",
+			level: PodSecurityRestricted,
+			spec: corev1.PodSpec{
+				SecurityContext: &corev1.PodSecurityContext{RunAsUser: new(int64)},
+				Containers:      []corev1.Container{restrictedContainer},
+			},
+			wantErr: true,
+		},
+		{
+			name:  "restricted accepts pod level runAsNonRoot and seccomp",
+			level: PodSecurityRestricted,
+			spec: corev1.PodSpec{
+				SecurityContext: &corev1.PodSecurityContext{
+					RunAsNonRoot:   &yes,
+					SeccompProfile: &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault},
+				},
+				Containers: []corev1.Container{{
+					Name: "fn",
+					SecurityContext: &corev1.SecurityContext{
+						AllowPrivilegeEscalation: &no,
+						Capabilities:             &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
+					},
+				}},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := CheckPodSecurity(tc.level, tc.spec)
+			if tc.wantErr && err == nil {
+				t.Fatalf("want error, got nil")
+			}
+			if !tc.wantErr && err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"fmt"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	// PortNameAnnotationKey names the port of the function's Service and container, service
+	// meshes such as Istio detect the protocol from a prefix of the name, i.e. grpc or http2
+	PortNameAnnotationKey = "com.openfaas.port.name"
+
+	// PortProtocolAnnotationKey sets the appProtocol of the function's Service port
+	PortProtocolAnnotationKey = "com.openfaas.port.protocol"
+
+	// DefaultPortName is the name of the port when PortNameAnnotationKey is not set
+	DefaultPortName = "http"
+)
+
+// portProtocols are the values accepted for PortProtocolAnnotationKey
+var portProtocols = []string{"http", "http2", "h2c", "grpc", "grpc-web"}
+
+// PortName returns the name of the function's port from the PortNameAnnotationKey
+// annotation, or DefaultPortName when it is not set
+func PortName(annotations map[string]string) (string, error) {
+	name, ok := annotations[PortNameAnnotationKey]
+	if !ok || len(name) == 0 {
+		return DefaultPortName, nil
+	}
+
+	if errs := validation.IsValidPortName(name); len(errs) > 0 {
+		return "", fmt.Errorf("invalid %s: %q, %s", PortNameAnnotationKey, name, strings.Join(errs, ", "))
+	}
+
+	return name, nil
+}
+
+// PortProtocol returns the appProtocol of the function's Service port from the
+// PortProtocolAnnotationKey annotation, nil is returned when it is not set
+func PortProtocol(annotations map[string]string) (*string, error) {
+	protocol, ok := annotations[PortProtocolAnnotationKey]
+	if !ok || len(protocol) == 0 {
+		return nil, nil
+	}
+
+	for _, p := range portProtocols {
+		if protocol == p {
+			return &protocol, nil
+		}
+	}
+
+	return nil, fmt.Errorf("invalid %s: %q, must be one of %s", PortProtocolAnnotationKey, protocol, strings.Join(portProtocols, ", "))
+}
+
+// ConfigureServicePort sets the name and appProtocol of the function's Service port, so that
+// removing the annotations on update restores the defaults
+func ConfigureServicePort(annotations map[string]string, service *corev1.Service) error {
+	name, err := PortName(annotations)
+	if err != nil {
+		return err
+	}
+	protocol, err := PortProtocol(annotations)
+	if err != nil {
+		return err
+	}
+
+	if len(service.Spec.Ports) == 0 {
+		return nil
+	}
+
+	service.Spec.Ports[0].Name = name
+	service.Spec.Ports[0].AppProtocol = protocol
+	return nil
+}
+
+// ConfigurePortName names the port of the function's container to match its Service port
+func (f *FunctionFactory) ConfigurePortName(annotations map[string]string, deployment *appsv1.Deployment) error {
+	name, err := PortName(annotations)
+	if err != nil {
+		return err
+	}
+
+	container := &deployment.Spec.Template.Spec.Containers[0]
+	if len(container.Ports) > 0 {
+		container.Ports[0].Name = name
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func Test_ConfigureServicePort(t *testing.T) {
+	cases := []struct {
+		name         string
+		annotations  map[string]string
+		wantName     string
+		wantProtocol string
+		wantErr      bool
+	}{
+		{
+			name:     "defaults",
+			wantName: "http",
+		},
+		{
+			name:         "gRPC port",
+			annotations:  map[string]string{PortNameAnnotationKey: "grpc", PortProtocolAnnotationKey: "grpc"},
+			wantName:     "grpc",
+			wantProtocol: "grpc",
+		},
+		{
+			name:        "name is not a valid port name",
+			annotations: map[string]string{PortNameAnnotationKey: "GRPC_PORT"},
+			wantErr:     true,
+		},
+		{
+			name:        "name is too long",
+			annotations: map[string]string{PortNameAnnotationKey: "http-function-port"},
+			wantErr:     true,
+		},
+		{
+			name:        "unknown protocol",
+			annotations: map[string]string{PortProtocolAnnotationKey: "thrift"},
+			wantErr:     true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			protocol := "http2"
+			service := &corev1.Service{Spec: corev1.ServiceSpec{
+				Ports: []corev1.ServicePort{{Name: "previous", AppProtocol: &protocol}},
+			}}
+
+			err := ConfigureServicePort(tc.annotations, service)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			port := service.Spec.Ports[0]
+			if port.Name != tc.wantName {
+				t.Errorf("want name %s, got %s", tc.wantName, port.Name)
+			}
+
+			got := ""
+			if port.AppProtocol != nil {
+				got = *port.AppProtocol
+			}
+			if got != tc.wantProtocol {
+				t.Errorf("want appProtocol %q, got %q", tc.wantProtocol, got)
+			}
+		})
+	}
+}
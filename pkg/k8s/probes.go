@@ -4,13 +4,40 @@
 package k8s
 
 import (
+	"fmt"
+	"log"
 	"path/filepath"
+	"strconv"
+	"strings"
 
 	types "github.com/openfaas/faas-provider/types"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
+const (
+	// ReadinessInitialDelayAnnotationKey overrides ReadinessProbe.InitialDelaySeconds for a
+	// single function
+	ReadinessInitialDelayAnnotationKey = "com.openfaas.readiness.initial-delay"
+
+	// ReadinessPeriodAnnotationKey overrides ReadinessProbe.PeriodSeconds for a single function
+	ReadinessPeriodAnnotationKey = "com.openfaas.readiness.period"
+
+	// ReadinessTimeoutAnnotationKey overrides ReadinessProbe.TimeoutSeconds for a single function
+	ReadinessTimeoutAnnotationKey = "com.openfaas.readiness.timeout"
+
+	// LivenessPathAnnotationKey makes the liveness probe an HTTP GET of this path, by default
+	// DefaultHealthPath
+	LivenessPathAnnotationKey = "com.openfaas.liveness.path"
+
+	// LivenessPortAnnotationKey makes the liveness probe an HTTP GET on this port, by default
+	// DeploymentConfig.RuntimeHTTPPort
+	LivenessPortAnnotationKey = "com.openfaas.liveness.port"
+
+	// DefaultHealthPath is the health endpoint of the OpenFaaS watchdogs
+	DefaultHealthPath = "/_/health"
+)
+
 type FunctionProbes struct {
 	Liveness  *corev1.Probe
 	Readiness *corev1.Probe
@@ -24,7 +51,7 @@ func (f *FunctionFactory) MakeProbes(r types.FunctionDeployment) (*FunctionProbe
 	if f.Config.HTTPProbe {
 		handler = corev1.Handler{
 			HTTPGet: &corev1.HTTPGetAction{
-				Path: "/_/health",
+				Path: DefaultHealthPath,
 				Port: intstr.IntOrString{
 					Type:   intstr.Int,
 					IntVal: int32(f.Config.RuntimeHTTPPort),
@@ -50,6 +77,12 @@ func (f *FunctionFactory) MakeProbes(r types.FunctionDeployment) (*FunctionProbe
 		FailureThreshold:    3,
 	}
 
+	if r.Annotations != nil {
+		if err := readinessOverrides(r.Service, *r.Annotations, probes.Readiness); err != nil {
+			return nil, err
+		}
+	}
+
 	probes.Liveness = &corev1.Probe{
 		Handler:             handler,
 		InitialDelaySeconds: f.Config.LivenessProbe.InitialDelaySeconds,
@@ -59,5 +92,93 @@ func (f *FunctionFactory) MakeProbes(r types.FunctionDeployment) (*FunctionProbe
 		FailureThreshold:    3,
 	}
 
+	if r.Annotations != nil {
+		if err := f.livenessOverrides(*r.Annotations, probes.Liveness); err != nil {
+			return nil, err
+		}
+	}
+
 	return &probes, nil
 }
+
+// livenessOverrides replaces the handler of the liveness probe with an HTTP GET when the
+// function sets LivenessPathAnnotationKey or LivenessPortAnnotationKey, the other value
+// falls back to DefaultHealthPath or the runtime port.
+func (f *FunctionFactory) livenessOverrides(annotations map[string]string, probe *corev1.Probe) error {
+	path := annotations[LivenessPathAnnotationKey]
+	port := annotations[LivenessPortAnnotationKey]
+	if len(path) == 0 && len(port) == 0 {
+		return nil
+	}
+
+	action := &corev1.HTTPGetAction{
+		Path: DefaultHealthPath,
+		Port: intstr.FromInt(int(f.Config.RuntimeHTTPPort)),
+	}
+
+	if len(path) > 0 {
+		if !strings.HasPrefix(path, "/") {
+			return fmt.Errorf("invalid %s: %q, must start with /", LivenessPathAnnotationKey, path)
+		}
+		action.Path = path
+	}
+
+	if len(port) > 0 {
+		parsed, err := strconv.Atoi(port)
+		if err != nil || parsed < 1 || parsed > 65535 {
+			return fmt.Errorf("invalid %s: %q, must be a port between 1 and 65535", LivenessPortAnnotationKey, port)
+		}
+		action.Port = intstr.FromInt(parsed)
+	}
+
+	probe.Handler = corev1.Handler{HTTPGet: action}
+	return nil
+}
+
+// readinessOverrides applies the readiness timing annotations of a function to probe. The
+// initial delay may be zero, the period and timeout must be positive and the resulting
+// timeout must be shorter than the period.
+func readinessOverrides(service string, annotations map[string]string, probe *corev1.Probe) error {
+	overrides := []struct {
+		key      string
+		value    *int32
+		minValue int64
+	}{
+		{key: ReadinessInitialDelayAnnotationKey, value: &probe.InitialDelaySeconds, minValue: 0},
+		{key: ReadinessPeriodAnnotationKey, value: &probe.PeriodSeconds, minValue: 1},
+		{key: ReadinessTimeoutAnnotationKey, value: &probe.TimeoutSeconds, minValue: 1},
+	}
+
+	applied := false
+	for _, o := range overrides {
+		v, ok := annotations[o.key]
+		if !ok || len(v) == 0 {
+			continue
+		}
+
+		parsed, err := strconv.ParseInt(v, 10, 32)
+		if err != nil || parsed < o.minValue {
+			if o.minValue == 0 {
+				return fmt.Errorf("invalid %s: %q, must be zero or a positive integer", o.key, v)
+			}
+			return fmt.Errorf("invalid %s: %q, must be a positive integer", o.key, v)
+		}
+
+		*o.value = int32(parsed)
+		applied = true
+	}
+
+	if !applied {
+		return nil
+	}
+
+	if probe.TimeoutSeconds >= probe.PeriodSeconds {
+		return fmt.Errorf("invalid readiness probe for %s: timeout (%ds) must be less than period (%ds)",
+			service, probe.TimeoutSeconds, probe.PeriodSeconds)
+	}
+
+	log.Printf("Readiness probe overrides for %s, initial delay: %ds, period: %ds, timeout: %ds\n",
+		service, probe.InitialDelaySeconds, probe.PeriodSeconds, probe.TimeoutSeconds)
+
+	return nil
+}
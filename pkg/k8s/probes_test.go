@@ -4,6 +4,7 @@
 package k8s
 
 import (
+	"strings"
 	"testing"
 
 	types "github.com/openfaas/faas-provider/types"
@@ -100,3 +101,143 @@ func Test_makeProbes_useCustomDurationHTTPProbe(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func Test_makeProbes_readinessOverrides(t *testing.T) {
+	cases := []struct {
+		name        string
+		annotations map[string]string
+		wantDelay   int32
+		wantPeriod  int32
+		wantTimeout int32
+		wantErr     string
+	}{
+		{
+			name:        "no overrides uses the global config",
+			annotations: map[string]string{},
+			wantDelay:   2, wantPeriod: 2, wantTimeout: 1,
+		},
+		{
+			name: "all overrides",
+			annotations: map[string]string{
+				ReadinessInitialDelayAnnotationKey: "0",
+				ReadinessPeriodAnnotationKey:       "10",
+				ReadinessTimeoutAnnotationKey:      "5",
+			},
+			wantDelay: 0, wantPeriod: 10, wantTimeout: 5,
+		},
+		{
+			name:        "period only",
+			annotations: map[string]string{ReadinessPeriodAnnotationKey: "30"},
+			wantDelay:   2, wantPeriod: 30, wantTimeout: 1,
+		},
+		{
+			name:        "negative period",
+			annotations: map[string]string{ReadinessPeriodAnnotationKey: "-1"},
+			wantErr:     "must be a positive integer",
+		},
+		{
+			name:        "non-integer timeout",
+			annotations: map[string]string{ReadinessTimeoutAnnotationKey: "1s"},
+			wantErr:     "must be a positive integer",
+		},
+		{
+			name:        "timeout not less than period",
+			annotations: map[string]string{ReadinessTimeoutAnnotationKey: "2"},
+			wantErr:     "must be less than period",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			f := mockFactory()
+			f.Config.ReadinessProbe = &ProbeConfig{
+				InitialDelaySeconds: 2,
+				PeriodSeconds:       2,
+				TimeoutSeconds:      1,
+			}
+
+			probes, err := f.MakeProbes(types.FunctionDeployment{
+				Service:     "testfunc",
+				Annotations: &tc.annotations,
+			})
+
+			if len(tc.wantErr) > 0 {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("want error containing %q, got: %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r := probes.Readiness
+			if r.InitialDelaySeconds != tc.wantDelay || r.PeriodSeconds != tc.wantPeriod || r.TimeoutSeconds != tc.wantTimeout {
+				t.Errorf("want delay: %d, period: %d, timeout: %d, got delay: %d, period: %d, timeout: %d",
+					tc.wantDelay, tc.wantPeriod, tc.wantTimeout, r.InitialDelaySeconds, r.PeriodSeconds, r.TimeoutSeconds)
+			}
+			if probes.Liveness.PeriodSeconds != 1 {
+				t.Errorf("want the liveness probe to be unchanged, got period: %d", probes.Liveness.PeriodSeconds)
+			}
+		})
+	}
+}
+
+func Test_makeProbes_livenessOverrides(t *testing.T) {
+	cases := []struct {
+		name        string
+		httpProbe   bool
+		annotations map[string]string
+		wantPath    string
+		wantPort    int
+		wantExec    bool
+		wantErr     bool
+	}{
+		{name: "exec probe without overrides", annotations: map[string]string{}, wantExec: true},
+		{name: "HTTP probe without overrides", httpProbe: true, annotations: map[string]string{}, wantPath: "/_/health", wantPort: 8080},
+		{name: "path", httpProbe: true, annotations: map[string]string{LivenessPathAnnotationKey: "/healthz"}, wantPath: "/healthz", wantPort: 8080},
+		{name: "port", httpProbe: true, annotations: map[string]string{LivenessPortAnnotationKey: "9000"}, wantPath: "/_/health", wantPort: 9000},
+		{name: "path and port", annotations: map[string]string{LivenessPathAnnotationKey: "/live", LivenessPortAnnotationKey: "9000"}, wantPath: "/live", wantPort: 9000},
+		{name: "path replaces the exec probe", annotations: map[string]string{LivenessPathAnnotationKey: "/healthz"}, wantPath: "/healthz", wantPort: 8080},
+		{name: "relative path", annotations: map[string]string{LivenessPathAnnotationKey: "healthz"}, wantErr: true},
+		{name: "invalid port", annotations: map[string]string{LivenessPortAnnotationKey: "http"}, wantErr: true},
+		{name: "port out of range", annotations: map[string]string{LivenessPortAnnotationKey: "70000"}, wantErr: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			f := mockFactory()
+			f.Config.HTTPProbe = tc.httpProbe
+			f.Config.RuntimeHTTPPort = 8080
+
+			probes, err := f.MakeProbes(types.FunctionDeployment{Service: "testfunc", Annotations: &tc.annotations})
+			if tc.wantErr != (err != nil) {
+				t.Fatalf("want error: %v, got: %v", tc.wantErr, err)
+			}
+			if tc.wantErr {
+				return
+			}
+
+			if tc.wantExec {
+				if probes.Liveness.Exec == nil || probes.Liveness.HTTPGet != nil {
+					t.Errorf("want an exec liveness probe, got: %+v", probes.Liveness.Handler)
+				}
+				return
+			}
+
+			action := probes.Liveness.HTTPGet
+			if action == nil {
+				t.Fatalf("want an HTTP liveness probe, got: %+v", probes.Liveness.Handler)
+			}
+			if action.Path != tc.wantPath {
+				t.Errorf("want path: %s, got: %s", tc.wantPath, action.Path)
+			}
+			if action.Port.IntValue() != tc.wantPort {
+				t.Errorf("want port: %d, got: %d", tc.wantPort, action.Port.IntValue())
+			}
+			if tc.httpProbe && probes.Readiness.HTTPGet.Path != "/_/health" {
+				t.Errorf("want the readiness probe to be unchanged, got path: %s", probes.Readiness.HTTPGet.Path)
+			}
+		})
+	}
+}
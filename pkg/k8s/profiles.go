@@ -6,9 +6,11 @@ import (
 	"strings"
 
 	v1 "github.com/openfaas/faas-netes/pkg/apis/openfaas/v1"
+	"github.com/openfaas/faas-netes/pkg/client/clientset/versioned"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/yaml"
 	typedCorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
@@ -16,6 +18,10 @@ import (
 
 const ProfileAnnotationKey = "com.openfaas.profile"
 
+// ScaleZeroDurationLabel is the label that the idler reads for how long a function may be idle
+// before it is scaled to zero
+const ScaleZeroDurationLabel = "com.openfaas.scale.zero-duration"
+
 // ProfileClient defines the interface for CRUD operations on profiles
 // and applying faas-netes profiles to function Deployments.
 type ProfileClient interface {
@@ -84,28 +90,80 @@ func (f FunctionFactory) NewConfigMapProfileClient() ProfileClient {
 
 }
 
-// GetProfiles retrieves in the names string, names is the raw csv value in the
-// function annotation
-func (f FunctionFactory) GetProfiles(ctx context.Context, namespace string, annotations map[string]string) ([]Profile, error) {
+// profileAPIClient reads Profiles from the API server rather than a lister, it is used for
+// the function namespaces which are not watched by the Profile informer
+type profileAPIClient struct {
+	client versioned.Interface
+}
+
+// NewProfileAPIClient returns a ProfileClient that reads Profile CRDs directly from the API
+func NewProfileAPIClient(client versioned.Interface) ProfileClient {
+	return &profileAPIClient{client: client}
+}
+
+func (c profileAPIClient) Get(ctx context.Context, namespace string, names ...string) ([]Profile, error) {
+	var resp []Profile
+	for _, name := range names {
+		profile, err := c.client.OpenfaasV1().Profiles(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		resp = append(resp, Profile(profile.Spec))
+	}
+	return resp, nil
+}
+
+// GetProfiles retrieves the Profiles named in the function's annotation, which is a csv value.
+// When LocalProfiles is set each Profile is looked up in the function's namespace first, then
+// in ProfilesNamespace.
+func (f FunctionFactory) GetProfiles(ctx context.Context, functionNamespace string, annotations map[string]string) ([]Profile, error) {
 	if len(annotations) == 0 {
 		return nil, nil
 	}
 
-	client := f.NewProfileClient()
 	profileNames := ParseProfileNames(annotations)
 
-	return client.Get(ctx, namespace, profileNames...)
+	return f.lookupProfiles(ctx, functionNamespace, profileNames)
 }
 
-func (f FunctionFactory) GetProfilesToRemove(ctx context.Context, namespace string, annotations, currentAnnotations map[string]string) ([]Profile, error) {
+func (f FunctionFactory) GetProfilesToRemove(ctx context.Context, functionNamespace string, annotations, currentAnnotations map[string]string) ([]Profile, error) {
 	if len(annotations) == 0 {
 		return nil, nil
 	}
 
 	toRemove := ProfilesToRemove(annotations, currentAnnotations)
 
+	return f.lookupProfiles(ctx, functionNamespace, toRemove)
+}
+
+// lookupProfiles gets each Profile from the function's namespace, falling back to
+// ProfilesNamespace when it has no Profile of that name. A namespace that the provider is not
+// allowed to read Profiles from is treated as having none.
+func (f FunctionFactory) lookupProfiles(ctx context.Context, functionNamespace string, names []string) ([]Profile, error) {
 	client := f.NewProfileClient()
-	return client.Get(ctx, namespace, toRemove...)
+
+	if f.LocalProfiles == nil || functionNamespace == f.Config.ProfilesNamespace {
+		return client.Get(ctx, f.Config.ProfilesNamespace, names...)
+	}
+
+	var resp []Profile
+	for _, name := range names {
+		local, err := f.LocalProfiles.Get(ctx, functionNamespace, name)
+		if err == nil {
+			resp = append(resp, local...)
+			continue
+		}
+		if !k8serrors.IsNotFound(err) && !k8serrors.IsForbidden(err) {
+			return nil, err
+		}
+
+		shared, err := client.Get(ctx, f.Config.ProfilesNamespace, name)
+		if err != nil {
+			return nil, err
+		}
+		resp = append(resp, shared...)
+	}
+	return resp, nil
 }
 
 // ParseProfileNames parsed the Profile annotation and returns the profile names it contains
@@ -171,6 +229,21 @@ func (f FunctionFactory) ApplyProfile(profile Profile, deployment *appsv1.Deploy
 
 		profile.PodSecurityContext.DeepCopyInto(deployment.Spec.Template.Spec.SecurityContext)
 	}
+
+	if profile.RuntimeClassName != nil {
+		runtimeClassName := *profile.RuntimeClassName
+		deployment.Spec.Template.Spec.RuntimeClassName = &runtimeClassName
+	}
+
+	if profile.IdleTimeout != nil {
+		// the function's own label takes precedence over the Profile
+		if _, ok := deployment.Spec.Template.Labels[ScaleZeroDurationLabel]; !ok {
+			if deployment.Spec.Template.Labels == nil {
+				deployment.Spec.Template.Labels = map[string]string{}
+			}
+			deployment.Spec.Template.Labels[ScaleZeroDurationLabel] = profile.IdleTimeout.Duration.String()
+		}
+	}
 }
 
 // RemoveProfile is the inverse of Apply, removing the mutations that the Profile would have applied
@@ -220,6 +293,14 @@ func (f FunctionFactory) RemoveProfile(profile Profile, deployment *appsv1.Deplo
 			deployment.Spec.Template.Spec.SecurityContext.Sysctls = nil
 		}
 	}
+
+	if profile.RuntimeClassName != nil && equalStrings(profile.RuntimeClassName, deployment.Spec.Template.Spec.RuntimeClassName) {
+		deployment.Spec.Template.Spec.RuntimeClassName = nil
+	}
+
+	if profile.IdleTimeout != nil && deployment.Spec.Template.Labels[ScaleZeroDurationLabel] == profile.IdleTimeout.Duration.String() {
+		delete(deployment.Spec.Template.Labels, ScaleZeroDurationLabel)
+	}
 }
 
 func equalStrings(a, b *string) bool {
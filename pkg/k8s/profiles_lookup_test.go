@@ -0,0 +1,83 @@
+package k8s
+
+import (
+	"context"
+	"testing"
+
+	v1 "github.com/openfaas/faas-netes/pkg/apis/openfaas/v1"
+	faasfake "github.com/openfaas/faas-netes/pkg/client/clientset/versioned/fake"
+	listers "github.com/openfaas/faas-netes/pkg/client/listers/openfaas/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func newProfile(namespace, name, runtimeClass string) *v1.Profile {
+	return &v1.Profile{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec:       v1.ProfileSpec{RuntimeClassName: &runtimeClass},
+	}
+}
+
+func Test_GetProfiles_LocalFallback(t *testing.T) {
+	shared := []*v1.Profile{
+		newProfile("openfaas", "gvisor", "shared-gvisor"),
+		newProfile("openfaas", "kata", "shared-kata"),
+	}
+
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for _, p := range shared {
+		indexer.Add(p)
+	}
+
+	annotations := map[string]string{ProfileAnnotationKey: "gvisor,kata"}
+
+	cases := []struct {
+		name      string
+		local     bool
+		namespace string
+		want      []string
+	}{
+		{
+			name:      "local Profile takes precedence",
+			local:     true,
+			namespace: "team-a",
+			want:      []string{"local-gvisor", "shared-kata"},
+		},
+		{
+			name:      "local Profiles disabled",
+			namespace: "team-a",
+			want:      []string{"shared-gvisor", "shared-kata"},
+		},
+		{
+			name:      "namespace without local Profiles",
+			local:     true,
+			namespace: "team-b",
+			want:      []string{"shared-gvisor", "shared-kata"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			factory := mockFactory()
+			factory.Config.ProfilesNamespace = "openfaas"
+			factory.Profiler = listers.NewProfileLister(indexer)
+			if tc.local {
+				factory.LocalProfiles = NewProfileAPIClient(faasfake.NewSimpleClientset(newProfile("team-a", "gvisor", "local-gvisor")))
+			}
+
+			profiles, err := factory.GetProfiles(context.Background(), tc.namespace, annotations)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if len(profiles) != len(tc.want) {
+				t.Fatalf("want %d profiles, got %d", len(tc.want), len(profiles))
+			}
+			for i, profile := range profiles {
+				if *profile.RuntimeClassName != tc.want[i] {
+					t.Errorf("want profile %d to be %s, got %s", i, tc.want[i], *profile.RuntimeClassName)
+				}
+			}
+		})
+	}
+}
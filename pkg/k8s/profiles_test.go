@@ -4,10 +4,12 @@ import (
 	"context"
 	"reflect"
 	"testing"
+	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
 	apiv1 "k8s.io/api/core/v1"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes/fake"
 )
 
@@ -201,6 +203,35 @@ func Test_RunTimeClassProfile_Apply(t *testing.T) {
 	}
 }
 
+func Test_RuntimeClassNameProfile_ApplyAndRemove(t *testing.T) {
+	runtimeClass := "gvisor"
+	p := Profile{RuntimeClassName: &runtimeClass}
+
+	basicDeployment := &appsv1.Deployment{
+		Spec: appsv1.DeploymentSpec{
+			Template: apiv1.PodTemplateSpec{
+				Spec: apiv1.PodSpec{
+					Containers: []apiv1.Container{
+						{Name: "testfunc", Image: "alpine:latest"},
+					},
+				},
+			},
+		},
+	}
+
+	factory := mockFactory()
+	factory.ApplyProfile(p, basicDeployment)
+	got := basicDeployment.Spec.Template.Spec.RuntimeClassName
+	if got == nil || *got != runtimeClass {
+		t.Fatalf("expected runtimeClassName %q, got %v", runtimeClass, got)
+	}
+
+	factory.RemoveProfile(p, basicDeployment)
+	if got := basicDeployment.Spec.Template.Spec.RuntimeClassName; got != nil {
+		t.Fatalf("expected runtimeClassName to be removed, got %q", *got)
+	}
+}
+
 func Test_TolerationsProfile_Remove(t *testing.T) {
 	tolerations := []corev1.Toleration{
 		{
@@ -439,6 +470,98 @@ func Test_PodSecurityProfile_Remove(t *testing.T) {
 	}
 }
 
+func Test_IdleTimeoutProfile_Apply(t *testing.T) {
+	p := Profile{IdleTimeout: &metav1.Duration{Duration: 15 * time.Minute}}
+
+	cases := []struct {
+		name     string
+		labels   map[string]string
+		expected string
+	}{
+		{
+			name:     "label is set from the profile",
+			labels:   nil,
+			expected: "15m0s",
+		},
+		{
+			name:     "function label takes precedence",
+			labels:   map[string]string{ScaleZeroDurationLabel: "5m"},
+			expected: "5m",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			basicDeployment := &appsv1.Deployment{
+				Spec: appsv1.DeploymentSpec{
+					Template: apiv1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{Labels: tc.labels},
+						Spec: apiv1.PodSpec{
+							Containers: []apiv1.Container{
+								{Name: "testfunc", Image: "alpine:latest"},
+							},
+						},
+					},
+				},
+			}
+
+			factory := mockFactory()
+			factory.ApplyProfile(p, basicDeployment)
+			result := basicDeployment.Spec.Template.Labels[ScaleZeroDurationLabel]
+			if result != tc.expected {
+				t.Fatalf("expected %q, got %q", tc.expected, result)
+			}
+		})
+	}
+}
+
+func Test_IdleTimeoutProfile_Remove(t *testing.T) {
+	p := Profile{IdleTimeout: &metav1.Duration{Duration: 15 * time.Minute}}
+
+	cases := []struct {
+		name     string
+		value    string
+		expected bool
+	}{
+		{
+			name:     "label from the profile is removed",
+			value:    "15m0s",
+			expected: false,
+		},
+		{
+			name:     "label set by the function is kept",
+			value:    "5m",
+			expected: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			basicDeployment := &appsv1.Deployment{
+				Spec: appsv1.DeploymentSpec{
+					Template: apiv1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{
+							Labels: map[string]string{ScaleZeroDurationLabel: tc.value},
+						},
+						Spec: apiv1.PodSpec{
+							Containers: []apiv1.Container{
+								{Name: "testfunc", Image: "alpine:latest"},
+							},
+						},
+					},
+				},
+			}
+
+			factory := mockFactory()
+			factory.RemoveProfile(p, basicDeployment)
+			_, ok := basicDeployment.Spec.Template.Labels[ScaleZeroDurationLabel]
+			if ok != tc.expected {
+				t.Fatalf("expected label present: %v, got %v", tc.expected, ok)
+			}
+		})
+	}
+}
+
 func Test_ConfigMapProfileParsing(t *testing.T) {
 	ctx := context.Background()
 	validConfig := corev1.ConfigMap{}
@@ -0,0 +1,58 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"fmt"
+	"strconv"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// ProgressDeadlineAnnotationKey overrides DeploymentConfig.ProgressDeadlineSeconds for
+	// a single function
+	ProgressDeadlineAnnotationKey = "com.openfaas.progress-deadline-seconds"
+
+	// DefaultProgressDeadlineSeconds matches the Kubernetes default for Deployments
+	DefaultProgressDeadlineSeconds = int32(600)
+
+	// progressDeadlineExceededReason is the reason Kubernetes sets on the Progressing
+	// condition when a rollout has stalled
+	progressDeadlineExceededReason = "ProgressDeadlineExceeded"
+)
+
+// ProgressDeadlineSeconds returns the rollout progress deadline for a function, the value
+// from the annotations takes precedence over the DeploymentConfig default.
+func (f *FunctionFactory) ProgressDeadlineSeconds(annotations map[string]string) (*int32, error) {
+	deadline := f.Config.ProgressDeadlineSeconds
+	if deadline == 0 {
+		deadline = DefaultProgressDeadlineSeconds
+	}
+
+	if v, ok := annotations[ProgressDeadlineAnnotationKey]; ok && len(v) > 0 {
+		parsed, err := strconv.ParseInt(v, 10, 32)
+		if err != nil || parsed <= 0 {
+			return nil, fmt.Errorf("invalid %s: %q, must be a positive integer", ProgressDeadlineAnnotationKey, v)
+		}
+		deadline = int32(parsed)
+	}
+
+	return &deadline, nil
+}
+
+// ProgressDeadlineExceeded returns true when the Deployment reports that its rollout
+// has stalled, i.e. the Progressing condition is False with reason ProgressDeadlineExceeded.
+func ProgressDeadlineExceeded(item appsv1.Deployment) bool {
+	for _, c := range item.Status.Conditions {
+		if c.Type == appsv1.DeploymentProgressing &&
+			c.Status == corev1.ConditionFalse &&
+			c.Reason == progressDeadlineExceededReason {
+			return true
+		}
+	}
+
+	return false
+}
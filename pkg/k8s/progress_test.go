@@ -0,0 +1,79 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func Test_ProgressDeadlineSeconds(t *testing.T) {
+	cases := []struct {
+		name        string
+		config      int32
+		annotations map[string]string
+		want        int32
+		wantErr     bool
+	}{
+		{"uses the Kubernetes default when not configured", 0, nil, DefaultProgressDeadlineSeconds, false},
+		{"uses the configured default", 120, nil, 120, false},
+		{"annotation overrides the configured default", 120, map[string]string{ProgressDeadlineAnnotationKey: "30"}, 30, false},
+		{"empty annotation is ignored", 120, map[string]string{ProgressDeadlineAnnotationKey: ""}, 120, false},
+		{"non-numeric annotation is rejected", 120, map[string]string{ProgressDeadlineAnnotationKey: "soon"}, 0, true},
+		{"zero annotation is rejected", 120, map[string]string{ProgressDeadlineAnnotationKey: "0"}, 0, true},
+		{"negative annotation is rejected", 120, map[string]string{ProgressDeadlineAnnotationKey: "-5"}, 0, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			factory := mockFactory()
+			factory.Config.ProgressDeadlineSeconds = tc.config
+
+			got, err := factory.ProgressDeadlineSeconds(tc.annotations)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("want error, got deadline: %d", *got)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if *got != tc.want {
+				t.Errorf("want deadline: %d, got: %d", tc.want, *got)
+			}
+		})
+	}
+}
+
+func Test_ProgressDeadlineExceeded(t *testing.T) {
+	cases := []struct {
+		name       string
+		conditions []appsv1.DeploymentCondition
+		want       bool
+	}{
+		{"no conditions", nil, false},
+		{"progressing", []appsv1.DeploymentCondition{
+			{Type: appsv1.DeploymentProgressing, Status: corev1.ConditionTrue, Reason: "NewReplicaSetAvailable"},
+		}, false},
+		{"deadline exceeded", []appsv1.DeploymentCondition{
+			{Type: appsv1.DeploymentAvailable, Status: corev1.ConditionTrue},
+			{Type: appsv1.DeploymentProgressing, Status: corev1.ConditionFalse, Reason: "ProgressDeadlineExceeded"},
+		}, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			item := appsv1.Deployment{Status: appsv1.DeploymentStatus{Conditions: tc.conditions}}
+
+			if got := ProgressDeadlineExceeded(item); got != tc.want {
+				t.Errorf("want: %v, got: %v", tc.want, got)
+			}
+		})
+	}
+}
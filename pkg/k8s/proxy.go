@@ -5,6 +5,7 @@
 package k8s
 
 import (
+	"context"
 	"fmt"
 	"math/rand"
 	"net/url"
@@ -23,6 +24,7 @@ func NewFunctionLookup(ns string, lister corelister.EndpointsLister) *FunctionLo
 		EndpointLister:   lister,
 		Listers:          map[string]corelister.EndpointsNamespaceLister{},
 		lock:             sync.RWMutex{},
+		connections:      newConnectionTracker(),
 	}
 }
 
@@ -31,7 +33,16 @@ type FunctionLookup struct {
 	EndpointLister   corelister.EndpointsLister
 	Listers          map[string]corelister.EndpointsNamespaceLister
 
-	lock sync.RWMutex
+	// RoutingTable is consulted when a function is not found in its namespace,
+	// when nil no routing is performed
+	RoutingTable *RoutingTable
+
+	// LoadBalancer is used by ResolveBalanced for functions that do not set
+	// LoadBalancerAnnotationKey, when empty LoadBalancerRandom is used
+	LoadBalancer string
+
+	lock        sync.RWMutex
+	connections *connectionTracker
 }
 
 func (f *FunctionLookup) GetLister(ns string) corelister.EndpointsNamespaceLister {
@@ -54,17 +65,57 @@ func getNamespace(name, defaultNamespace string) string {
 	return namespace
 }
 
+// Resolve picks an endpoint of the function at random, it does not take part in the
+// least-connections accounting, see ResolveBalanced.
 func (l *FunctionLookup) Resolve(name string) (url.URL, error) {
+	address, _, err := l.resolveName(name, LoadBalancerRandom)
+	return address, err
+}
+
+// ResolveBalanced picks an endpoint of the function with the given load balancer, or with
+// FunctionLookup.LoadBalancer when it is empty. The release func must be called once the
+// request to the endpoint has completed.
+func (l *FunctionLookup) ResolveBalanced(name, loadBalancer string) (url.URL, func(), error) {
+	if len(loadBalancer) == 0 {
+		loadBalancer = l.LoadBalancer
+	}
+	return l.resolveName(name, loadBalancer)
+}
+
+func (l *FunctionLookup) resolveName(name, loadBalancer string) (url.URL, func(), error) {
 	functionName := name
 	namespace := getNamespace(name, l.DefaultNamespace)
 	if err := l.verifyNamespace(namespace); err != nil {
-		return url.URL{}, err
+		return url.URL{}, nil, err
 	}
 
 	if strings.Contains(name, ".") {
 		functionName = strings.TrimSuffix(name, "."+namespace)
 	}
 
+	address, release, err := l.resolve(functionName, namespace, loadBalancer)
+	if err == nil || l.RoutingTable == nil || !IsNotFound(err) {
+		return address, release, err
+	}
+
+	target, ok, routeErr := l.RoutingTable.Lookup(context.Background(), functionName, namespace)
+	if routeErr != nil {
+		return url.URL{}, nil, fmt.Errorf("error reading routing table: %s", routeErr.Error())
+	}
+	if !ok {
+		return url.URL{}, nil, err
+	}
+
+	if err := l.verifyNamespace(target.Namespace); err != nil {
+		return url.URL{}, nil, err
+	}
+
+	return l.resolve(target.Function, target.Namespace, loadBalancer)
+}
+
+// resolve picks an endpoint of the function in the given namespace, errors from the
+// lister are wrapped so that a missing function can be detected with IsNotFound.
+func (l *FunctionLookup) resolve(functionName, namespace, loadBalancer string) (url.URL, func(), error) {
 	nsEndpointLister := l.GetLister(namespace)
 
 	if nsEndpointLister == nil {
@@ -75,30 +126,41 @@ func (l *FunctionLookup) Resolve(name string) (url.URL, error) {
 
 	svc, err := nsEndpointLister.Get(functionName)
 	if err != nil {
-		return url.URL{}, fmt.Errorf("error listing \"%s.%s\": %s", functionName, namespace, err.Error())
+		return url.URL{}, nil, fmt.Errorf("error listing \"%s.%s\": %w", functionName, namespace, err)
 	}
 
 	if len(svc.Subsets) == 0 {
-		return url.URL{}, fmt.Errorf("no subsets available for \"%s.%s\"", functionName, namespace)
+		return url.URL{}, nil, fmt.Errorf("no subsets available for \"%s.%s\"", functionName, namespace)
 	}
 
 	all := len(svc.Subsets[0].Addresses)
 	if len(svc.Subsets[0].Addresses) == 0 {
-		return url.URL{}, fmt.Errorf("no addresses in subset for \"%s.%s\"", functionName, namespace)
+		return url.URL{}, nil, fmt.Errorf("no addresses in subset for \"%s.%s\"", functionName, namespace)
 	}
 
-	target := rand.Intn(all)
-
-	serviceIP := svc.Subsets[0].Addresses[target].IP
+	var serviceIP string
+	release := func() {}
+
+	if loadBalancer == LoadBalancerLeastConnections && l.connections != nil {
+		addresses := make([]string, 0, all)
+		for _, address := range svc.Subsets[0].Addresses {
+			addresses = append(addresses, address.IP)
+		}
+		serviceIP, release = l.connections.Acquire(addresses)
+	} else {
+		target := rand.Intn(all)
+		serviceIP = svc.Subsets[0].Addresses[target].IP
+	}
 
 	urlStr := fmt.Sprintf("http://%s:%d", serviceIP, watchdogPort)
 
 	urlRes, err := url.Parse(urlStr)
 	if err != nil {
-		return url.URL{}, err
+		release()
+		return url.URL{}, nil, err
 	}
 
-	return *urlRes, nil
+	return *urlRes, release, nil
 }
 
 func (l *FunctionLookup) verifyNamespace(name string) error {
@@ -108,3 +170,21 @@ func (l *FunctionLookup) verifyNamespace(name string) error {
 	// ToDo use global namepace parse and validation
 	return fmt.Errorf("namespace not allowed")
 }
+
+// StreamingAnnotationKey marks a function as returning a streamed response, such as
+// Server-Sent Events, which the proxy must flush as it arrives rather than buffer
+const StreamingAnnotationKey = "com.openfaas.http.streaming"
+
+// AccessLogAnnotationKey makes the proxy log each request to the function, for debugging a
+// single function without enabling access logs for all of them
+const AccessLogAnnotationKey = "com.openfaas.access.log"
+
+// IsAccessLogEnabled returns true when the function's annotations enable the access log
+func IsAccessLogEnabled(annotations map[string]string) bool {
+	return strings.EqualFold(annotations[AccessLogAnnotationKey], "true")
+}
+
+// IsStreaming returns true when the function's annotations request a streamed response
+func IsStreaming(annotations map[string]string) bool {
+	return strings.EqualFold(annotations[StreamingAnnotationKey], "true")
+}
@@ -0,0 +1,84 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// QuotaExceededError is returned when a namespace already has as many functions as its quota
+type QuotaExceededError struct {
+	Namespace string
+	Limit     int
+}
+
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("namespace %s has reached its quota of %d functions", e.Namespace, e.Limit)
+}
+
+// FunctionQuota returns the maximum number of functions for a namespace, 0 means unlimited.
+// A key for the namespace in the DeploymentConfig.FunctionQuotaConfigMap overrides
+// DeploymentConfig.MaxFunctionsPerNamespace.
+func (f *FunctionFactory) FunctionQuota(ctx context.Context, namespace string) (int, error) {
+	limit := f.Config.MaxFunctionsPerNamespace
+
+	name := f.Config.FunctionQuotaConfigMap
+	if len(name) == 0 {
+		return limit, nil
+	}
+
+	cm, err := f.Client.CoreV1().ConfigMaps(f.Config.ProfilesNamespace).Get(ctx, name, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		return limit, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("unable to read function quotas from ConfigMap %s: %s", name, err)
+	}
+
+	if v, ok := cm.Data[namespace]; ok {
+		override, err := strconv.Atoi(v)
+		if err != nil || override < 0 {
+			return 0, fmt.Errorf("invalid function quota for namespace %s in ConfigMap %s: %q", namespace, name, v)
+		}
+		limit = override
+	}
+
+	return limit, nil
+}
+
+// CheckFunctionQuota returns a QuotaExceededError when one more function in the namespace
+// would exceed its FunctionQuota
+func (f *FunctionFactory) CheckFunctionQuota(ctx context.Context, namespace string) error {
+	return f.CheckFunctionQuotaFor(ctx, namespace, 1)
+}
+
+// CheckFunctionQuotaFor returns a QuotaExceededError when count more functions in the namespace
+// would exceed its FunctionQuota, it is used to check a whole batch before any of it is created.
+func (f *FunctionFactory) CheckFunctionQuotaFor(ctx context.Context, namespace string, count int) error {
+	limit, err := f.FunctionQuota(ctx, namespace)
+	if err != nil {
+		return err
+	}
+	if limit == 0 {
+		return nil
+	}
+
+	res, err := f.Client.AppsV1().Deployments(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: "faas_function",
+	})
+	if err != nil {
+		return fmt.Errorf("unable to count functions in namespace %s: %s", namespace, err)
+	}
+
+	if len(res.Items)+count > limit {
+		return &QuotaExceededError{Namespace: namespace, Limit: limit}
+	}
+
+	return nil
+}
@@ -0,0 +1,70 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_CheckFunctionQuota(t *testing.T) {
+	functions := []runtime.Object{
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "a", Namespace: "team-a", Labels: map[string]string{"faas_function": "a"}}},
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "b", Namespace: "team-a", Labels: map[string]string{"faas_function": "b"}}},
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "team-a"}},
+	}
+	quotas := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "function-quotas", Namespace: "openfaas"},
+		Data:       map[string]string{"team-a": "3", "team-b": "many"},
+	}
+
+	cases := []struct {
+		name      string
+		namespace string
+		limit     int
+		configMap string
+		count     int
+		wantQuota bool
+		wantErr   bool
+	}{
+		{name: "unlimited", namespace: "team-a", limit: 0},
+		{name: "below the limit", namespace: "team-a", limit: 3},
+		{name: "at the limit", namespace: "team-a", limit: 2, wantQuota: true},
+		{name: "ConfigMap override", namespace: "team-a", limit: 2, configMap: "function-quotas"},
+		{name: "missing ConfigMap uses the default", namespace: "team-a", limit: 2, configMap: "missing", wantQuota: true},
+		{name: "invalid override", namespace: "team-b", limit: 2, configMap: "function-quotas", wantErr: true},
+		{name: "batch within the limit", namespace: "team-a", limit: 4, count: 2},
+		{name: "batch over the limit", namespace: "team-a", limit: 3, count: 2, wantQuota: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			factory := NewFunctionFactory(fake.NewSimpleClientset(append(functions, quotas)...), DeploymentConfig{
+				ProfilesNamespace:        "openfaas",
+				MaxFunctionsPerNamespace: tc.limit,
+				FunctionQuotaConfigMap:   tc.configMap,
+			}, nil)
+
+			err := factory.CheckFunctionQuota(context.Background(), tc.namespace)
+			if tc.count > 0 {
+				err = factory.CheckFunctionQuotaFor(context.Background(), tc.namespace, tc.count)
+			}
+
+			var quotaErr *QuotaExceededError
+			if gotQuota := errors.As(err, &quotaErr); gotQuota != tc.wantQuota {
+				t.Fatalf("want quota exceeded: %v, got: %v", tc.wantQuota, err)
+			}
+			if gotErr := err != nil && quotaErr == nil; gotErr != tc.wantErr {
+				t.Fatalf("want error: %v, got: %v", tc.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"math"
+	"strconv"
+
+	appsv1 "k8s.io/api/apps/v1"
+)
+
+const (
+	// ReadyThresholdAnnotationKey overrides StatusConfig.ReadyThreshold for a function, the value
+	// is the fraction of replicas, between 0 and 1, that must be available for it to be ready.
+	ReadyThresholdAnnotationKey = "com.openfaas.ready.threshold"
+
+	// FunctionReadyAnnotationKey is added to the annotations returned by the function readers,
+	// it is true when enough replicas are available to meet the ready threshold.
+	FunctionReadyAnnotationKey = "com.openfaas.function.ready"
+)
+
+// FunctionReady returns true when the function has at least the threshold fraction of its
+// desired replicas available, and always at least one. The threshold in the function's
+// annotations takes precedence, an invalid value falls back to defaultThreshold.
+func FunctionReady(item appsv1.Deployment, defaultThreshold float64) bool {
+	if item.Spec.Replicas == nil || *item.Spec.Replicas == 0 {
+		return false
+	}
+
+	threshold := defaultThreshold
+	if v, ok := item.Spec.Template.Annotations[ReadyThresholdAnnotationKey]; ok {
+		if parsed, err := strconv.ParseFloat(v, 64); err == nil && parsed >= 0 && parsed <= 1 {
+			threshold = parsed
+		}
+	}
+
+	required := int32(math.Ceil(threshold * float64(*item.Spec.Replicas)))
+	if required < 1 {
+		required = 1
+	}
+
+	return item.Status.AvailableReplicas >= required
+}
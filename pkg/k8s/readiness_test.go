@@ -0,0 +1,47 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+)
+
+func Test_FunctionReady(t *testing.T) {
+	cases := []struct {
+		name        string
+		replicas    int32
+		available   int32
+		threshold   float64
+		annotations map[string]string
+		want        bool
+	}{
+		{name: "scaled to zero", replicas: 0, available: 0, want: false},
+		{name: "default threshold needs one replica", replicas: 4, available: 1, want: true},
+		{name: "no replicas available", replicas: 4, available: 0, want: false},
+		{name: "below threshold", replicas: 4, available: 1, threshold: 0.5, want: false},
+		{name: "meets threshold", replicas: 4, available: 2, threshold: 0.5, want: true},
+		{name: "threshold rounds up", replicas: 3, available: 1, threshold: 0.5, want: false},
+		{name: "annotation overrides threshold", replicas: 4, available: 4, threshold: 0.25,
+			annotations: map[string]string{ReadyThresholdAnnotationKey: "1"}, want: true},
+		{name: "annotation requires all replicas", replicas: 4, available: 3,
+			annotations: map[string]string{ReadyThresholdAnnotationKey: "1"}, want: false},
+		{name: "invalid annotation uses the default", replicas: 4, available: 1,
+			annotations: map[string]string{ReadyThresholdAnnotationKey: "2"}, want: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			item := appsv1.Deployment{}
+			item.Spec.Replicas = &tc.replicas
+			item.Status.AvailableReplicas = tc.available
+			item.Spec.Template.Annotations = tc.annotations
+
+			if got := FunctionReady(item, tc.threshold); got != tc.want {
+				t.Errorf("want: %t, got: %t", tc.want, got)
+			}
+		})
+	}
+}
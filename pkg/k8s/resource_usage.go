@@ -0,0 +1,98 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// metricsGroupVersion is the API served by metrics-server
+const metricsGroupVersion = "metrics.k8s.io/v1beta1"
+
+// PodUsage is the current CPU and memory usage of the function container in a Pod
+type PodUsage struct {
+	Pod    string
+	CPU    resource.Quantity
+	Memory resource.Quantity
+}
+
+// podMetricsList is the subset of the metrics.k8s.io PodMetricsList that is read
+type podMetricsList struct {
+	Items []struct {
+		Metadata struct {
+			Name string `json:"name"`
+		} `json:"metadata"`
+		Containers []struct {
+			Name  string              `json:"name"`
+			Usage corev1.ResourceList `json:"usage"`
+		} `json:"containers"`
+	} `json:"items"`
+}
+
+// PodMetricsClient reads Pod usage from metrics-server without a dependency on the metrics
+// clientset, the responses are read as JSON via the REST client.
+type PodMetricsClient struct {
+	rest rest.Interface
+}
+
+// NewPodMetricsClient returns a PodMetricsClient that uses the REST client of the clientset
+func NewPodMetricsClient(kube kubernetes.Interface) PodMetricsClient {
+	return PodMetricsClient{
+		rest: kube.Discovery().RESTClient(),
+	}
+}
+
+// FunctionPodMetrics returns the usage of each Pod of a function. The function container is
+// matched by name, when it is not found the usage of all containers in the Pod is added up.
+func (c PodMetricsClient) FunctionPodMetrics(ctx context.Context, namespace, function string) ([]PodUsage, error) {
+	if c.rest == nil {
+		return nil, fmt.Errorf("the metrics API is not available")
+	}
+
+	raw, err := c.rest.Get().
+		AbsPath("/apis/"+metricsGroupVersion, "namespaces", namespace, "pods").
+		Param("labelSelector", "faas_function="+function).
+		Do(ctx).Raw()
+	if err != nil {
+		return nil, err
+	}
+
+	list := podMetricsList{}
+	if err := json.Unmarshal(raw, &list); err != nil {
+		return nil, fmt.Errorf("unable to read PodMetrics: %s", err)
+	}
+
+	usage := make([]PodUsage, 0, len(list.Items))
+	for _, item := range list.Items {
+		pod := PodUsage{Pod: item.Metadata.Name}
+
+		matched := false
+		for _, container := range item.Containers {
+			if container.Name == function {
+				pod.CPU = container.Usage[corev1.ResourceCPU].DeepCopy()
+				pod.Memory = container.Usage[corev1.ResourceMemory].DeepCopy()
+				matched = true
+				break
+			}
+		}
+
+		if !matched {
+			for _, container := range item.Containers {
+				pod.CPU.Add(container.Usage[corev1.ResourceCPU])
+				pod.Memory.Add(container.Usage[corev1.ResourceMemory])
+			}
+		}
+
+		usage = append(usage, pod)
+	}
+
+	return usage, nil
+}
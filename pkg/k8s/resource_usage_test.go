@@ -0,0 +1,60 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"k8s.io/client-go/kubernetes/scheme"
+	fakerest "k8s.io/client-go/rest/fake"
+)
+
+func Test_PodMetricsClient_FunctionPodMetrics(t *testing.T) {
+	var gotPath, gotSelector string
+
+	rest := &fakerest.RESTClient{
+		NegotiatedSerializer: scheme.Codecs.WithoutConversion(),
+		Client: fakerest.CreateHTTPClient(func(req *http.Request) (*http.Response, error) {
+			gotPath = req.URL.Path
+			gotSelector = req.URL.Query().Get("labelSelector")
+
+			body := `{"items":[
+	{"metadata":{"name":"nodeinfo-1"},"containers":[
+		{"name":"nodeinfo","usage":{"cpu":"25m","memory":"30Mi"}},
+		{"name":"istio-proxy","usage":{"cpu":"5m","memory":"40Mi"}}]},
+	{"metadata":{"name":"nodeinfo-2"},"containers":[
+		{"name":"renamed","usage":{"cpu":"10m","memory":"10Mi"}},
+		{"name":"sidecar","usage":{"cpu":"5m","memory":"10Mi"}}]}]}`
+			header := http.Header{"Content-Type": []string{"application/json"}}
+			return &http.Response{StatusCode: http.StatusOK, Header: header, Body: ioutil.NopCloser(bytes.NewBufferString(body))}, nil
+		}),
+	}
+
+	client := PodMetricsClient{rest: rest}
+	pods, err := client.FunctionPodMetrics(context.Background(), "openfaas-fn", "nodeinfo")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if want := "/apis/metrics.k8s.io/v1beta1/namespaces/openfaas-fn/pods"; gotPath != want {
+		t.Errorf("want path %s, got %s", want, gotPath)
+	}
+	if want := "faas_function=nodeinfo"; gotSelector != want {
+		t.Errorf("want labelSelector %s, got %s", want, gotSelector)
+	}
+
+	if len(pods) != 2 {
+		t.Fatalf("want 2 pods, got %d", len(pods))
+	}
+	if pods[0].Pod != "nodeinfo-1" || pods[0].CPU.String() != "25m" || pods[0].Memory.String() != "30Mi" {
+		t.Errorf("want the function container usage, got: %s %s %s", pods[0].Pod, pods[0].CPU.String(), pods[0].Memory.String())
+	}
+	if pods[1].CPU.String() != "15m" || pods[1].Memory.String() != "20Mi" {
+		t.Errorf("want the usage of all containers, got: %s %s", pods[1].CPU.String(), pods[1].Memory.String())
+	}
+}
@@ -0,0 +1,51 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const (
+	// ResponseHeadersAnnotationKey is a JSON object of headers that the proxy adds to every
+	// response of the function, i.e. {"Access-Control-Allow-Origin":"*"}
+	ResponseHeadersAnnotationKey = "com.openfaas.response.headers"
+
+	// ResponseHeadersOverrideAnnotationKey set to true replaces headers that the function set
+	// itself with the ResponseHeadersAnnotationKey values, by default the function's win
+	ResponseHeadersOverrideAnnotationKey = "com.openfaas.response.headers.override"
+)
+
+// ResponseHeaders parses the ResponseHeadersAnnotationKey annotation, nil is returned when it
+// is not set
+func ResponseHeaders(annotations map[string]string) (map[string]string, error) {
+	v, ok := annotations[ResponseHeadersAnnotationKey]
+	if !ok || len(v) == 0 {
+		return nil, nil
+	}
+
+	headers := map[string]string{}
+	if err := json.Unmarshal([]byte(v), &headers); err != nil {
+		return nil, fmt.Errorf("invalid %s: must be a JSON object of strings: %s", ResponseHeadersAnnotationKey, err)
+	}
+
+	for name, value := range headers {
+		if len(name) == 0 || strings.ContainsAny(name, " \t\r\n:") {
+			return nil, fmt.Errorf("invalid %s: %q is not a valid header name", ResponseHeadersAnnotationKey, name)
+		}
+		if strings.ContainsAny(value, "\r\n") {
+			return nil, fmt.Errorf("invalid %s: value of %q may not contain a line break", ResponseHeadersAnnotationKey, name)
+		}
+	}
+
+	return headers, nil
+}
+
+// ResponseHeadersOverride returns true when the annotated headers replace those set by the
+// function
+func ResponseHeadersOverride(annotations map[string]string) bool {
+	return strings.EqualFold(annotations[ResponseHeadersOverrideAnnotationKey], "true")
+}
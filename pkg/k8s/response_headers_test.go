@@ -0,0 +1,62 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_ResponseHeaders(t *testing.T) {
+	cases := []struct {
+		name        string
+		annotations map[string]string
+		expected    map[string]string
+		expectedErr bool
+	}{
+		{
+			name:        "not set",
+			annotations: map[string]string{},
+			expected:    nil,
+		},
+		{
+			name:        "headers are parsed",
+			annotations: map[string]string{ResponseHeadersAnnotationKey: `{"Access-Control-Allow-Origin":"*","Cache-Control":"max-age=60"}`},
+			expected:    map[string]string{"Access-Control-Allow-Origin": "*", "Cache-Control": "max-age=60"},
+		},
+		{
+			name:        "not a JSON object",
+			annotations: map[string]string{ResponseHeadersAnnotationKey: `Cache-Control: max-age=60`},
+			expectedErr: true,
+		},
+		{
+			name:        "invalid header name",
+			annotations: map[string]string{ResponseHeadersAnnotationKey: `{"Cache Control":"max-age=60"}`},
+			expectedErr: true,
+		},
+		{
+			name:        "line break in value",
+			annotations: map[string]string{ResponseHeadersAnnotationKey: `{"X-Test":"a\r\nSet-Cookie: b"}`},
+			expectedErr: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ResponseHeaders(tc.annotations)
+			if tc.expectedErr {
+				if err == nil {
+					t.Fatalf("want an error, got: %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !reflect.DeepEqual(tc.expected, got) {
+				t.Errorf("want %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// RoutingTableConfigMap is the name of the ConfigMap that holds the routing table. Each key is
+	// a source function, as name or name.namespace, and each value is the target as name@namespace.
+	RoutingTableConfigMap = "faas-netes-routing"
+
+	// routingTableTTL is how long the routing table is cached before it is read again
+	routingTableTTL = time.Second * 10
+)
+
+// RouteTarget is the function that calls to a source function are routed to
+type RouteTarget struct {
+	Function  string `json:"function"`
+	Namespace string `json:"namespace"`
+}
+
+// RoutingTable reads the cross-namespace routing table from the faas-netes-routing ConfigMap,
+// the table is cached for a short period so that it is not read on every invocation.
+type RoutingTable struct {
+	client    kubernetes.Interface
+	namespace string
+
+	lock    sync.RWMutex
+	routes  map[string]RouteTarget
+	expires time.Time
+}
+
+// NewRoutingTable creates a RoutingTable for the ConfigMap in the given namespace
+func NewRoutingTable(client kubernetes.Interface, namespace string) *RoutingTable {
+	return &RoutingTable{
+		client:    client,
+		namespace: namespace,
+	}
+}
+
+// Routes returns the current routing table, reading the ConfigMap when the cache has expired.
+// A missing ConfigMap is an empty table.
+func (t *RoutingTable) Routes(ctx context.Context) (map[string]RouteTarget, error) {
+	t.lock.RLock()
+	routes, expires := t.routes, t.expires
+	t.lock.RUnlock()
+
+	if routes != nil && time.Now().Before(expires) {
+		return routes, nil
+	}
+
+	cm, err := t.client.CoreV1().ConfigMaps(t.namespace).Get(ctx, RoutingTableConfigMap, metav1.GetOptions{})
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return nil, err
+	}
+
+	routes = map[string]RouteTarget{}
+	if cm != nil && err == nil {
+		for source, value := range cm.Data {
+			target, err := ParseRouteTarget(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid route for %s in %s: %s", source, RoutingTableConfigMap, err)
+			}
+			routes[source] = target
+		}
+	}
+
+	t.lock.Lock()
+	t.routes = routes
+	t.expires = time.Now().Add(routingTableTTL)
+	t.lock.Unlock()
+
+	return routes, nil
+}
+
+// Lookup finds the route for a function, a route for name.namespace takes precedence
+// over a route for name.
+func (t *RoutingTable) Lookup(ctx context.Context, functionName, namespace string) (RouteTarget, bool, error) {
+	routes, err := t.Routes(ctx)
+	if err != nil {
+		return RouteTarget{}, false, err
+	}
+
+	if target, ok := routes[functionName+"."+namespace]; ok {
+		return target, true, nil
+	}
+
+	target, ok := routes[functionName]
+	return target, ok, nil
+}
+
+// ParseRouteTarget parses a route target in the form name@namespace
+func ParseRouteTarget(value string) (RouteTarget, error) {
+	parts := strings.Split(strings.TrimSpace(value), "@")
+	if len(parts) != 2 || len(parts[0]) == 0 || len(parts[1]) == 0 {
+		return RouteTarget{}, fmt.Errorf("%q must be in the form function@namespace", value)
+	}
+
+	return RouteTarget{Function: parts[0], Namespace: parts[1]}, nil
+}
@@ -0,0 +1,165 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	corelister "k8s.io/client-go/listers/core/v1"
+)
+
+type routingLister struct {
+	endpoints map[string]string
+}
+
+func (l routingLister) List(selector labels.Selector) ([]*corev1.Endpoints, error) {
+	return nil, nil
+}
+
+func (l routingLister) Endpoints(namespace string) corelister.EndpointsNamespaceLister {
+	return routingNSLister{namespace: namespace, endpoints: l.endpoints}
+}
+
+type routingNSLister struct {
+	namespace string
+	endpoints map[string]string
+}
+
+func (l routingNSLister) List(selector labels.Selector) ([]*corev1.Endpoints, error) {
+	return nil, nil
+}
+
+func (l routingNSLister) Get(name string) (*corev1.Endpoints, error) {
+	ip, ok := l.endpoints[name+"."+l.namespace]
+	if !ok {
+		return nil, k8serrors.NewNotFound(schema.GroupResource{Resource: "endpoints"}, name)
+	}
+
+	return &corev1.Endpoints{
+		Subsets: []corev1.EndpointSubset{{
+			Addresses: []corev1.EndpointAddress{{IP: ip}},
+		}},
+	}, nil
+}
+
+func routingConfigMap(data map[string]string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: RoutingTableConfigMap, Namespace: "openfaas"},
+		Data:       data,
+	}
+}
+
+func Test_FunctionLookup_RoutingTable(t *testing.T) {
+	lister := routingLister{endpoints: map[string]string{
+		"local.openfaas-fn":  "10.0.0.1",
+		"billing.finance":    "10.0.0.2",
+		"billing-v2.finance": "10.0.0.3",
+		"secret.kube-system": "10.0.0.4",
+	}}
+
+	clientset := fake.NewSimpleClientset(routingConfigMap(map[string]string{
+		"billing":         "billing@finance",
+		"billing.staging": "billing-v2@finance",
+		"escape":          "secret@kube-system",
+	}))
+
+	resolver := NewFunctionLookup("openfaas-fn", lister)
+	resolver.RoutingTable = NewRoutingTable(clientset, "openfaas")
+
+	cases := []struct {
+		name     string
+		funcName string
+		wantURL  string
+		wantErr  bool
+	}{
+		{name: "local function is not routed", funcName: "local", wantURL: "http://10.0.0.1:8080"},
+		{name: "missing function is routed", funcName: "billing", wantURL: "http://10.0.0.2:8080"},
+		{name: "namespaced route takes precedence", funcName: "billing.staging", wantURL: "http://10.0.0.3:8080"},
+		{name: "missing function without a route", funcName: "unknown", wantErr: true},
+		{name: "route into kube-system is rejected", funcName: "escape", wantErr: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := resolver.Resolve(tc.funcName)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("want error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if got.String() != tc.wantURL {
+				t.Errorf("want: %s, got: %s", tc.wantURL, got.String())
+			}
+		})
+	}
+}
+
+func Test_RoutingTable_CachesReads(t *testing.T) {
+	clientset := fake.NewSimpleClientset(routingConfigMap(map[string]string{"a": "b@c"}))
+	table := NewRoutingTable(clientset, "openfaas")
+
+	for i := 0; i < 3; i++ {
+		if _, err := table.Routes(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	if got := len(clientset.Actions()); got != 1 {
+		t.Errorf("want 1 read of the ConfigMap, got: %d", got)
+	}
+}
+
+func Test_RoutingTable_MissingConfigMap(t *testing.T) {
+	table := NewRoutingTable(fake.NewSimpleClientset(), "openfaas")
+
+	_, ok, err := table.Lookup(context.Background(), "billing", "openfaas-fn")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if ok {
+		t.Errorf("want no route when the ConfigMap does not exist")
+	}
+}
+
+func Test_ParseRouteTarget(t *testing.T) {
+	cases := []struct {
+		value   string
+		want    RouteTarget
+		wantErr bool
+	}{
+		{value: "billing@finance", want: RouteTarget{Function: "billing", Namespace: "finance"}},
+		{value: " billing@finance\n", want: RouteTarget{Function: "billing", Namespace: "finance"}},
+		{value: "billing", wantErr: true},
+		{value: "@finance", wantErr: true},
+		{value: "billing@", wantErr: true},
+		{value: "a@b@c", wantErr: true},
+	}
+
+	for _, tc := range cases {
+		got, err := ParseRouteTarget(tc.value)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("%q: want error, got nil", tc.value)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: unexpected error: %s", tc.value, err)
+		}
+		if got != tc.want {
+			t.Errorf("%q: want: %+v, got: %+v", tc.value, tc.want, got)
+		}
+	}
+}
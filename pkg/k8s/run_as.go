@@ -0,0 +1,86 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// RunAsUserAnnotationKey sets the UID of the function's container, overriding
+	// SecurityContextUserID when DeploymentConfig.SetNonRootUser is set
+	RunAsUserAnnotationKey = "com.openfaas.security.run-as-user"
+
+	// RunAsGroupAnnotationKey sets the primary GID of the function's container
+	RunAsGroupAnnotationKey = "com.openfaas.security.run-as-group"
+
+	// FSGroupAnnotationKey sets the fsGroup of the function's Pods, so that volumes are
+	// owned by a group that the function can write to
+	FSGroupAnnotationKey = "com.openfaas.security.fs-group"
+)
+
+// ConfigureRunAsIDs sets the user and group of the function's container and the fsGroup of its
+// Pods from the RunAsUserAnnotationKey, RunAsGroupAnnotationKey and FSGroupAnnotationKey
+// annotations. It must be called after ConfigureContainerUserID, whose user is kept when the
+// annotation is not set. When SetNonRootUser is enabled a user of 0 is rejected. Profiles are
+// applied afterwards and take precedence.
+func (f *FunctionFactory) ConfigureRunAsIDs(annotations map[string]string, deployment *appsv1.Deployment) error {
+	user, err := parseRunAsID(annotations, RunAsUserAnnotationKey)
+	if err != nil {
+		return err
+	}
+	group, err := parseRunAsID(annotations, RunAsGroupAnnotationKey)
+	if err != nil {
+		return err
+	}
+	fsGroup, err := parseRunAsID(annotations, FSGroupAnnotationKey)
+	if err != nil {
+		return err
+	}
+
+	if user != nil && *user == 0 && f.Config.SetNonRootUser {
+		return fmt.Errorf("invalid %s: 0, the root user is not allowed when set_nonroot_user is enabled", RunAsUserAnnotationKey)
+	}
+
+	container := &deployment.Spec.Template.Spec.Containers[0]
+	if container.SecurityContext == nil {
+		container.SecurityContext = &corev1.SecurityContext{}
+	}
+	if user != nil {
+		container.SecurityContext.RunAsUser = user
+	}
+	container.SecurityContext.RunAsGroup = group
+
+	if fsGroup != nil {
+		if deployment.Spec.Template.Spec.SecurityContext == nil {
+			deployment.Spec.Template.Spec.SecurityContext = &corev1.PodSecurityContext{}
+		}
+		deployment.Spec.Template.Spec.SecurityContext.FSGroup = fsGroup
+	} else if deployment.Spec.Template.Spec.SecurityContext != nil {
+		deployment.Spec.Template.Spec.SecurityContext.FSGroup = nil
+	}
+
+	return nil
+}
+
+// parseRunAsID returns the ID from an annotation, or nil when it is not set. IDs are limited to
+// the range of a Linux UID or GID that Kubernetes accepts.
+func parseRunAsID(annotations map[string]string, key string) (*int64, error) {
+	v, ok := annotations[key]
+	if !ok || len(v) == 0 {
+		return nil, nil
+	}
+
+	id, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || id < 0 || id > math.MaxInt32 {
+		return nil, fmt.Errorf("invalid %s: %q, must be a number between 0 and %d", key, v, math.MaxInt32)
+	}
+
+	return &id, nil
+}
@@ -0,0 +1,111 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func Test_ConfigureRunAsIDs(t *testing.T) {
+	cases := []struct {
+		name        string
+		nonRoot     bool
+		annotations map[string]string
+		wantUser    *int64
+		wantGroup   *int64
+		wantFSGroup *int64
+		wantErr     bool
+	}{
+		{
+			name:     "non-root default is kept without annotations",
+			nonRoot:  true,
+			wantUser: int64p(SecurityContextUserID),
+		},
+		{
+			name:    "annotations override the non-root default",
+			nonRoot: true,
+			annotations: map[string]string{
+				RunAsUserAnnotationKey:  "1000",
+				RunAsGroupAnnotationKey: "2000",
+				FSGroupAnnotationKey:    "3000",
+			},
+			wantUser:    int64p(1000),
+			wantGroup:   int64p(2000),
+			wantFSGroup: int64p(3000),
+		},
+		{
+			name:        "root rejected when non-root is enabled",
+			nonRoot:     true,
+			annotations: map[string]string{RunAsUserAnnotationKey: "0"},
+			wantErr:     true,
+		},
+		{
+			name:        "root allowed when non-root is disabled",
+			annotations: map[string]string{RunAsUserAnnotationKey: "0"},
+			wantUser:    int64p(0),
+		},
+		{
+			name:        "not a number",
+			annotations: map[string]string{FSGroupAnnotationKey: "users"},
+			wantErr:     true,
+		},
+		{
+			name:        "out of range",
+			annotations: map[string]string{RunAsGroupAnnotationKey: "4294967296"},
+			wantErr:     true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			factory := mockFactory()
+			factory.Config.SetNonRootUser = tc.nonRoot
+
+			deployment := &appsv1.Deployment{}
+			deployment.Spec.Template.Spec.Containers = []corev1.Container{{Name: "fn"}}
+			factory.ConfigureContainerUserID(deployment)
+
+			err := factory.ConfigureRunAsIDs(tc.annotations, deployment)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			sc := deployment.Spec.Template.Spec.Containers[0].SecurityContext
+			if !equalInt64p(sc.RunAsUser, tc.wantUser) {
+				t.Errorf("RunAsUser want %v, got %v", tc.wantUser, sc.RunAsUser)
+			}
+			if !equalInt64p(sc.RunAsGroup, tc.wantGroup) {
+				t.Errorf("RunAsGroup want %v, got %v", tc.wantGroup, sc.RunAsGroup)
+			}
+
+			var fsGroup *int64
+			if psc := deployment.Spec.Template.Spec.SecurityContext; psc != nil {
+				fsGroup = psc.FSGroup
+			}
+			if !equalInt64p(fsGroup, tc.wantFSGroup) {
+				t.Errorf("FSGroup want %v, got %v", tc.wantFSGroup, fsGroup)
+			}
+		})
+	}
+}
+
+func equalInt64p(a, b *int64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func int64p(i int64) *int64 {
+	return &i
+}
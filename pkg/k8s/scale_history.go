@@ -0,0 +1,70 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultScaleHistorySize is the number of scale events kept for each function
+const DefaultScaleHistorySize = 20
+
+// ScaleEvent is a change to the replicas of a function
+type ScaleEvent struct {
+	From      int32     `json:"from"`
+	To        int32     `json:"to"`
+	Timestamp time.Time `json:"timestamp"`
+	Source    string    `json:"source"`
+}
+
+// ScaleHistory records the latest scale events of each function in memory, so that flapping
+// or unexpected scaling can be diagnosed. The history is lost when faas-netes restarts.
+type ScaleHistory struct {
+	lock   sync.RWMutex
+	size   int
+	events map[string][]ScaleEvent
+}
+
+// NewScaleHistory returns a ScaleHistory that keeps at most size events per function
+func NewScaleHistory(size int) *ScaleHistory {
+	if size <= 0 {
+		size = DefaultScaleHistorySize
+	}
+
+	return &ScaleHistory{
+		size:   size,
+		events: map[string][]ScaleEvent{},
+	}
+}
+
+// Record adds an event for the function, dropping the oldest event when the history is full.
+// It is safe to call on a nil ScaleHistory.
+func (h *ScaleHistory) Record(name, namespace string, event ScaleEvent) {
+	if h == nil {
+		return
+	}
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	key := name + "." + namespace
+	events := append(h.events[key], event)
+	if len(events) > h.size {
+		events = append([]ScaleEvent{}, events[len(events)-h.size:]...)
+	}
+	h.events[key] = events
+}
+
+// Events returns a copy of the events of the function, oldest first
+func (h *ScaleHistory) Events(name, namespace string) []ScaleEvent {
+	if h == nil {
+		return []ScaleEvent{}
+	}
+
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+
+	return append([]ScaleEvent{}, h.events[name+"."+namespace]...)
+}
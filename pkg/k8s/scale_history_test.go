@@ -0,0 +1,44 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_ScaleHistory_IsBoundedPerFunction(t *testing.T) {
+	history := NewScaleHistory(3)
+	now := time.Now()
+
+	for i := int32(0); i < 5; i++ {
+		history.Record("fn", "openfaas-fn", ScaleEvent{From: i, To: i + 1, Timestamp: now, Source: "test"})
+	}
+	history.Record("other", "openfaas-fn", ScaleEvent{From: 1, To: 0, Timestamp: now})
+
+	events := history.Events("fn", "openfaas-fn")
+	if len(events) != 3 {
+		t.Fatalf("want 3 events, got %d", len(events))
+	}
+	if events[0].From != 2 || events[2].To != 5 {
+		t.Errorf("want the latest events oldest first, got %+v", events)
+	}
+
+	if got := history.Events("other", "openfaas-fn"); len(got) != 1 {
+		t.Errorf("want 1 event for other, got %d", len(got))
+	}
+
+	if got := history.Events("fn", "staging"); len(got) != 0 {
+		t.Errorf("want no events in another namespace, got %d", len(got))
+	}
+}
+
+func Test_ScaleHistory_Nil(t *testing.T) {
+	var history *ScaleHistory
+	history.Record("fn", "openfaas-fn", ScaleEvent{})
+
+	if got := history.Events("fn", "openfaas-fn"); len(got) != 0 {
+		t.Errorf("want no events, got %d", len(got))
+	}
+}
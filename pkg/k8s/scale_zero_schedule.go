@@ -0,0 +1,149 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ScaleZeroScheduleAnnotationKey is a cron expression of the minutes in which the function
+// may be scaled to zero, i.e. "* 0-7,19-23 * * *" keeps a warm replica during the day. The
+// five fields are minute, hour, day of month, month and day of week and are matched in UTC.
+const ScaleZeroScheduleAnnotationKey = "com.openfaas.scale.zero.schedule"
+
+// Schedule is a parsed cron expression, each field holds one bit for every value it matches
+type Schedule struct {
+	minute, hour, dom, month, dow uint64
+
+	// domStar and dowStar record a "*" day field, when both day fields are restricted a
+	// time matches when either of them matches, as in cron
+	domStar, dowStar bool
+}
+
+type scheduleField struct {
+	name     string
+	min, max int
+}
+
+var scheduleFields = []scheduleField{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 6},
+}
+
+// ParseSchedule parses a five field cron expression, each field is "*" or a list of values
+// and ranges, both of which may have a "/step"
+func ParseSchedule(expr string) (*Schedule, error) {
+	parts := strings.Fields(expr)
+	if len(parts) != len(scheduleFields) {
+		return nil, fmt.Errorf("invalid schedule: %q, want %d fields, got %d", expr, len(scheduleFields), len(parts))
+	}
+
+	bits := make([]uint64, len(parts))
+	for i, part := range parts {
+		b, err := parseScheduleField(part, scheduleFields[i])
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule: %q, %s", expr, err)
+		}
+		bits[i] = b
+	}
+
+	return &Schedule{
+		minute:  bits[0],
+		hour:    bits[1],
+		dom:     bits[2],
+		month:   bits[3],
+		dow:     bits[4],
+		domStar: parts[2] == "*",
+		dowStar: parts[4] == "*",
+	}, nil
+}
+
+func parseScheduleField(part string, field scheduleField) (uint64, error) {
+	var bits uint64
+
+	for _, item := range strings.Split(part, ",") {
+		rng, step := item, 1
+		if i := strings.Index(item, "/"); i >= 0 {
+			rng = item[:i]
+			s, err := strconv.Atoi(item[i+1:])
+			if err != nil || s < 1 {
+				return 0, fmt.Errorf("invalid step in %s: %q", field.name, item)
+			}
+			step = s
+		}
+
+		start, end := field.min, field.max
+		if rng != "*" {
+			bounds := strings.SplitN(rng, "-", 2)
+			v, err := strconv.Atoi(bounds[0])
+			if err != nil {
+				return 0, fmt.Errorf("invalid %s: %q", field.name, item)
+			}
+			start, end = v, v
+			if len(bounds) == 2 {
+				if end, err = strconv.Atoi(bounds[1]); err != nil {
+					return 0, fmt.Errorf("invalid %s: %q", field.name, item)
+				}
+			} else if step > 1 {
+				end = field.max
+			}
+		}
+
+		if start < field.min || end > field.max || start > end {
+			return 0, fmt.Errorf("%s out of range %d-%d: %q", field.name, field.min, field.max, item)
+		}
+
+		for v := start; v <= end; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+
+	return bits, nil
+}
+
+// Matches returns true when the minute of t, in UTC, matches the schedule
+func (s *Schedule) Matches(t time.Time) bool {
+	t = t.UTC()
+	if s.minute&(1<<uint(t.Minute())) == 0 || s.hour&(1<<uint(t.Hour())) == 0 || s.month&(1<<uint(t.Month())) == 0 {
+		return false
+	}
+
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domStar || s.dowStar {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// ScaleZeroSchedule returns the ScaleZeroScheduleAnnotationKey schedule, nil when the
+// annotation is not set and the function may always be scaled to zero
+func ScaleZeroSchedule(annotations map[string]string) (*Schedule, error) {
+	v, ok := annotations[ScaleZeroScheduleAnnotationKey]
+	if !ok || len(strings.TrimSpace(v)) == 0 {
+		return nil, nil
+	}
+
+	schedule, err := ParseSchedule(v)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %s", ScaleZeroScheduleAnnotationKey, err)
+	}
+	return schedule, nil
+}
+
+// ScaleToZeroAllowed returns true when the function may be scaled to zero at now, which is
+// always the case without a schedule
+func ScaleToZeroAllowed(annotations map[string]string, now time.Time) (bool, error) {
+	schedule, err := ScaleZeroSchedule(annotations)
+	if err != nil || schedule == nil {
+		return true, err
+	}
+	return schedule.Matches(now), nil
+}
@@ -0,0 +1,97 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_ParseSchedule_Matches(t *testing.T) {
+	// Wednesday
+	weekday := time.Date(2021, time.March, 10, 12, 30, 0, 0, time.UTC)
+	// Saturday
+	weekend := time.Date(2021, time.March, 13, 12, 30, 0, 0, time.UTC)
+	night := time.Date(2021, time.March, 10, 22, 15, 0, 0, time.UTC)
+
+	cases := []struct {
+		name string
+		expr string
+		time time.Time
+		want bool
+	}{
+		{"every minute", "* * * * *", weekday, true},
+		{"overnight at night", "* 0-7,19-23 * * *", night, true},
+		{"overnight at noon", "* 0-7,19-23 * * *", weekday, false},
+		{"weekends on a Saturday", "* * * * 0,6", weekend, true},
+		{"weekends on a Wednesday", "* * * * 0,6", weekday, false},
+		{"minute step matches", "*/15 * * * *", night, true},
+		{"minute step does not match", "*/20 * * * *", night, false},
+		{"value with a step", "10/5 * * * *", night, true},
+		{"day of month or day of week", "* * 1 * 3", weekday, true},
+		{"different month", "* * * 1-2 *", weekday, false},
+		{"time is matched in UTC", "* 12 * * *", weekday.In(time.FixedZone("UTC+2", 2*60*60)), true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			schedule, err := ParseSchedule(tc.expr)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if got := schedule.Matches(tc.time); got != tc.want {
+				t.Errorf("want match %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func Test_ParseSchedule_Invalid(t *testing.T) {
+	cases := []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 7",
+		"* 9-5 * * *",
+		"*/0 * * * *",
+		"a * * * *",
+	}
+
+	for _, expr := range cases {
+		if _, err := ParseSchedule(expr); err == nil {
+			t.Errorf("want error for %q", expr)
+		}
+	}
+}
+
+func Test_ScaleToZeroAllowed(t *testing.T) {
+	now := time.Date(2021, time.March, 10, 12, 30, 0, 0, time.UTC)
+
+	cases := []struct {
+		name        string
+		annotations map[string]string
+		want        bool
+		wantErr     bool
+	}{
+		{"no annotation", nil, true, false},
+		{"inside the schedule", map[string]string{ScaleZeroScheduleAnnotationKey: "* 12 * * *"}, true, false},
+		{"outside the schedule", map[string]string{ScaleZeroScheduleAnnotationKey: "* 0-7 * * *"}, false, false},
+		{"invalid schedule", map[string]string{ScaleZeroScheduleAnnotationKey: "nightly"}, true, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ScaleToZeroAllowed(tc.annotations, now)
+			if tc.wantErr != (err != nil) {
+				t.Fatalf("want error: %v, got: %v", tc.wantErr, err)
+			}
+			if got != tc.want {
+				t.Errorf("want allowed %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"strconv"
+	"time"
+
+	types "github.com/openfaas/faas-provider/types"
+	appsv1 "k8s.io/api/apps/v1"
+)
+
+const (
+	// ScaledFromZeroAnnotationKey records when the function's Deployment was last scaled
+	// up from zero replicas, it is set on the Deployment and not on the Pod template so
+	// that it does not cause a rollout.
+	ScaledFromZeroAnnotationKey = "com.openfaas.scale.from-zero-at"
+
+	// FunctionStateAnnotationKey is added to the annotations returned by the function
+	// readers when the function has no available replicas, or when its rollout has stalled.
+	FunctionStateAnnotationKey = "com.openfaas.function.state"
+
+	// FunctionStateScaling is reported while a function that was scaled from zero is
+	// within its grace period.
+	FunctionStateScaling = "scaling"
+
+	// FunctionStateUnavailable is reported when a function has no available replicas.
+	FunctionStateUnavailable = "unavailable"
+
+	// FunctionStateProgressDeadlineExceeded is reported when the function's rollout has
+	// not progressed within its progress deadline, see ProgressDeadlineSeconds.
+	FunctionStateProgressDeadlineExceeded = "progress-deadline-exceeded"
+)
+
+// ClampReplicas limits the replicas to the provider-wide maxReplicas, a maxReplicas of 0 or
+// less means there is no limit. The bool is true when the replicas were clamped.
+func ClampReplicas(replicas, maxReplicas int32) (int32, bool) {
+	if maxReplicas > 0 && replicas > maxReplicas {
+		return maxReplicas, true
+	}
+	return replicas, false
+}
+
+// MarkScaledFromZero records the time of a scale up from zero on the Deployment
+func MarkScaledFromZero(deployment *appsv1.Deployment, now time.Time) {
+	if deployment.Annotations == nil {
+		deployment.Annotations = map[string]string{}
+	}
+	deployment.Annotations[ScaledFromZeroAnnotationKey] = now.UTC().Format(time.RFC3339)
+}
+
+// FunctionState returns the state of a function whose rollout has stalled, or that wants
+// replicas but has none available. A stalled rollout is reported even when the previous
+// replicas are still available. Within gracePeriod of a scale up from zero the function is
+// scaling, afterwards it is unavailable. An empty state is returned when the function is
+// not waiting on replicas.
+func FunctionState(item appsv1.Deployment, gracePeriod time.Duration, now time.Time) string {
+	if ProgressDeadlineExceeded(item) {
+		return FunctionStateProgressDeadlineExceeded
+	}
+
+	if item.Spec.Replicas == nil || *item.Spec.Replicas == 0 || item.Status.AvailableReplicas > 0 {
+		return ""
+	}
+
+	if v, ok := item.Annotations[ScaledFromZeroAnnotationKey]; ok && gracePeriod > 0 {
+		scaledAt, err := time.Parse(time.RFC3339, v)
+		if err == nil && now.Sub(scaledAt) < gracePeriod {
+			return FunctionStateScaling
+		}
+	}
+
+	return FunctionStateUnavailable
+}
+
+// StatusConfig controls how the state and readiness of a function are reported by the readers
+type StatusConfig struct {
+	// ScaleFromZeroGracePeriod is how long a function is reported as scaling after it is
+	// scaled from zero, before it is reported as unavailable
+	ScaleFromZeroGracePeriod time.Duration
+
+	// ReadyThreshold is the default fraction of replicas that must be available for a
+	// function to be reported as ready, see ReadyThresholdAnnotationKey
+	ReadyThreshold float64
+}
+
+// WithFunctionState adds the state and readiness of the function to a copy of its annotations,
+// the original map is shared with the informer cache so must not be modified.
+func WithFunctionState(function *types.FunctionStatus, item appsv1.Deployment, config StatusConfig) {
+	annotations := map[string]string{}
+	if function.Annotations != nil {
+		for k, v := range *function.Annotations {
+			annotations[k] = v
+		}
+	}
+
+	if state := FunctionState(item, config.ScaleFromZeroGracePeriod, time.Now()); len(state) > 0 {
+		annotations[FunctionStateAnnotationKey] = state
+	}
+	annotations[FunctionReadyAnnotationKey] = strconv.FormatBool(FunctionReady(item, config.ReadyThreshold))
+
+	function.Annotations = &annotations
+}
@@ -0,0 +1,88 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"testing"
+	"time"
+
+	types "github.com/openfaas/faas-provider/types"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func Test_FunctionState(t *testing.T) {
+	now := time.Now()
+	one := int32(1)
+	zero := int32(0)
+
+	scaledAt := func(d time.Duration) map[string]string {
+		return map[string]string{ScaledFromZeroAnnotationKey: now.Add(-d).UTC().Format(time.RFC3339)}
+	}
+
+	stalled := []appsv1.DeploymentCondition{{
+		Type:   appsv1.DeploymentProgressing,
+		Status: corev1.ConditionFalse,
+		Reason: "ProgressDeadlineExceeded",
+	}}
+
+	cases := []struct {
+		name        string
+		replicas    *int32
+		available   int32
+		annotations map[string]string
+		conditions  []appsv1.DeploymentCondition
+		gracePeriod time.Duration
+		want        string
+	}{
+		{name: "available", replicas: &one, available: 1, want: ""},
+		{name: "scaled to zero", replicas: &zero, want: ""},
+		{name: "no replicas available, never scaled from zero", replicas: &one, gracePeriod: time.Minute, want: FunctionStateUnavailable},
+		{name: "within grace period", replicas: &one, annotations: scaledAt(time.Second * 5), gracePeriod: time.Minute, want: FunctionStateScaling},
+		{name: "after grace period", replicas: &one, annotations: scaledAt(time.Minute * 2), gracePeriod: time.Minute, want: FunctionStateUnavailable},
+		{name: "grace period disabled", replicas: &one, annotations: scaledAt(time.Second), gracePeriod: 0, want: FunctionStateUnavailable},
+		{name: "invalid timestamp", replicas: &one, annotations: map[string]string{ScaledFromZeroAnnotationKey: "now"}, gracePeriod: time.Minute, want: FunctionStateUnavailable},
+		{name: "rollout stalled with replicas available", replicas: &one, available: 1, conditions: stalled, want: FunctionStateProgressDeadlineExceeded},
+		{name: "rollout stalled within grace period", replicas: &one, annotations: scaledAt(time.Second * 5), conditions: stalled, gracePeriod: time.Minute, want: FunctionStateProgressDeadlineExceeded},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			item := appsv1.Deployment{}
+			item.Annotations = tc.annotations
+			item.Spec.Replicas = tc.replicas
+			item.Status.AvailableReplicas = tc.available
+			item.Status.Conditions = tc.conditions
+
+			if got := FunctionState(item, tc.gracePeriod, now); got != tc.want {
+				t.Errorf("want: %q, got: %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func Test_WithFunctionState_DoesNotModifyCache(t *testing.T) {
+	one := int32(1)
+	item := appsv1.Deployment{}
+	item.Spec.Replicas = &one
+	MarkScaledFromZero(&item, time.Now())
+
+	cached := map[string]string{"topic": "cron"}
+	function := &types.FunctionStatus{Annotations: &cached}
+
+	WithFunctionState(function, item, StatusConfig{ScaleFromZeroGracePeriod: time.Minute})
+
+	if got := (*function.Annotations)[FunctionStateAnnotationKey]; got != FunctionStateScaling {
+		t.Errorf("want state: %q, got: %q", FunctionStateScaling, got)
+	}
+	if (*function.Annotations)["topic"] != "cron" {
+		t.Errorf("want existing annotations to be kept")
+	}
+	if got := (*function.Annotations)[FunctionReadyAnnotationKey]; got != "false" {
+		t.Errorf("want ready: false, got: %q", got)
+	}
+	if _, ok := cached[FunctionStateAnnotationKey]; ok {
+		t.Errorf("want the cached annotations to be left unchanged")
+	}
+}
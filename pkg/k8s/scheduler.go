@@ -0,0 +1,38 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"fmt"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// SchedulerNameAnnotationKey sets the scheduler of the function's Pods, such as volcano,
+// overriding DeploymentConfig.DefaultSchedulerName
+const SchedulerNameAnnotationKey = "com.openfaas.scheduler-name"
+
+// ConfigureSchedulerName sets the SchedulerName of the Pod template from the
+// SchedulerNameAnnotationKey annotation or DeploymentConfig.DefaultSchedulerName. The field is
+// cleared for the Kubernetes default scheduler, rather than set to default-scheduler, which
+// makes it safe to use for both create and update.
+func (f *FunctionFactory) ConfigureSchedulerName(annotations map[string]string, deployment *appsv1.Deployment) error {
+	schedulerName := f.Config.DefaultSchedulerName
+	if v, ok := annotations[SchedulerNameAnnotationKey]; ok && len(v) > 0 {
+		if errs := validation.IsDNS1123Subdomain(v); len(errs) > 0 {
+			return fmt.Errorf("invalid %s: %q, %s", SchedulerNameAnnotationKey, v, strings.Join(errs, ", "))
+		}
+		schedulerName = v
+	}
+
+	if schedulerName == corev1.DefaultSchedulerName {
+		schedulerName = ""
+	}
+
+	deployment.Spec.Template.Spec.SchedulerName = schedulerName
+	return nil
+}
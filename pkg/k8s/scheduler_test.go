@@ -0,0 +1,56 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+)
+
+func Test_ConfigureSchedulerName(t *testing.T) {
+	cases := []struct {
+		name       string
+		defaultVal string
+		value      string
+		want       string
+		wantErr    bool
+	}{
+		{name: "no annotation or default clears the scheduler", want: ""},
+		{name: "global default", defaultVal: "volcano", want: "volcano"},
+		{name: "annotation overrides the default", defaultVal: "volcano", value: "yunikorn", want: "yunikorn"},
+		{name: "default-scheduler is omitted", defaultVal: "volcano", value: "default-scheduler", want: ""},
+		{name: "invalid name", value: "Volcano_Scheduler", wantErr: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			factory := mockFactory()
+			factory.Config.DefaultSchedulerName = tc.defaultVal
+
+			deployment := &appsv1.Deployment{}
+			deployment.Spec.Template.Spec.SchedulerName = "previous"
+
+			annotations := map[string]string{}
+			if len(tc.value) > 0 {
+				annotations[SchedulerNameAnnotationKey] = tc.value
+			}
+
+			err := factory.ConfigureSchedulerName(annotations, deployment)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("want error for %q", tc.value)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if got := deployment.Spec.Template.Spec.SchedulerName; got != tc.want {
+				t.Errorf("want SchedulerName: %q, got: %q", tc.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// SecretItemsAnnotationKey maps the keys of a function's secrets to the file names they are
+// mounted as, the value is a JSON object keyed by secret name, i.e.
+// `{"db": {"password": "db/password.txt"}}`. Keys without a mapping keep their key as the file name.
+const SecretItemsAnnotationKey = "com.openfaas.secret-items"
+
+// ParseSecretItems reads and validates the key to path mapping from the function annotations.
+// Paths must be relative and may not contain `..`, so that they stay within the secrets mount.
+func ParseSecretItems(annotations map[string]string) (map[string]map[string]string, error) {
+	value, ok := annotations[SecretItemsAnnotationKey]
+	if !ok || len(value) == 0 {
+		return nil, nil
+	}
+
+	items := map[string]map[string]string{}
+	if err := json.Unmarshal([]byte(value), &items); err != nil {
+		return nil, fmt.Errorf("invalid %s: %s", SecretItemsAnnotationKey, err)
+	}
+
+	for secretName, mapping := range items {
+		for key, p := range mapping {
+			if err := validateSecretItemPath(p); err != nil {
+				return nil, fmt.Errorf("invalid %s: path for key %q of secret %q %s", SecretItemsAnnotationKey, key, secretName, err)
+			}
+		}
+	}
+
+	return items, nil
+}
+
+func validateSecretItemPath(p string) error {
+	if len(p) == 0 {
+		return fmt.Errorf("must not be empty")
+	}
+
+	if path.IsAbs(p) {
+		return fmt.Errorf("%q must be relative", p)
+	}
+
+	for _, element := range strings.Split(p, "/") {
+		if element == ".." {
+			return fmt.Errorf("%q must not contain '..'", p)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,126 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"testing"
+
+	types "github.com/openfaas/faas-provider/types"
+	appsv1 "k8s.io/api/apps/v1"
+	apiv1 "k8s.io/api/core/v1"
+)
+
+func Test_ParseSecretItems(t *testing.T) {
+	cases := []struct {
+		name    string
+		value   string
+		wantErr bool
+	}{
+		{name: "no annotation", value: ""},
+		{name: "valid mapping", value: `{"db":{"password":"db/password.txt"}}`},
+		{name: "invalid json", value: `db=password`, wantErr: true},
+		{name: "absolute path", value: `{"db":{"password":"/etc/password"}}`, wantErr: true},
+		{name: "parent path", value: `{"db":{"password":"../password"}}`, wantErr: true},
+		{name: "empty path", value: `{"db":{"password":""}}`, wantErr: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParseSecretItems(map[string]string{SecretItemsAnnotationKey: tc.value})
+			if tc.wantErr && err == nil {
+				t.Fatalf("want error, got nil")
+			}
+			if !tc.wantErr && err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+		})
+	}
+}
+
+func Test_FunctionFactory_ConfigureSecrets_Items(t *testing.T) {
+	f := mockFactory()
+	existingSecrets := map[string]*apiv1.Secret{
+		"db":  {Type: apiv1.SecretTypeOpaque, Data: map[string][]byte{"password": []byte("p"), "user": []byte("u")}},
+		"api": {Type: apiv1.SecretTypeOpaque, Data: map[string][]byte{"token": []byte("t")}},
+	}
+
+	newDeployment := func() *appsv1.Deployment {
+		return &appsv1.Deployment{
+			Spec: appsv1.DeploymentSpec{
+				Template: apiv1.PodTemplateSpec{
+					Spec: apiv1.PodSpec{
+						Containers: []apiv1.Container{{Name: "testfunc", Image: "alpine:latest"}},
+					},
+				},
+			},
+		}
+	}
+
+	cases := []struct {
+		name      string
+		secrets   []string
+		items     string
+		wantPaths map[string]string
+		wantErr   bool
+	}{
+		{
+			name:      "mapped key uses the path, other keys keep their name",
+			secrets:   []string{"db"},
+			items:     `{"db":{"password":"db/password.txt"}}`,
+			wantPaths: map[string]string{"password": "db/password.txt", "user": "user"},
+		},
+		{
+			name:    "unknown key",
+			secrets: []string{"db"},
+			items:   `{"db":{"missing":"missing.txt"}}`,
+			wantErr: true,
+		},
+		{
+			name:    "secret not used by the function",
+			secrets: []string{"db"},
+			items:   `{"api":{"token":"token.txt"}}`,
+			wantErr: true,
+		},
+		{
+			name:    "duplicate path across secrets",
+			secrets: []string{"db", "api"},
+			items:   `{"api":{"token":"user"}}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := types.FunctionDeployment{
+				Service:     "testfunc",
+				Secrets:     tc.secrets,
+				Annotations: &map[string]string{SecretItemsAnnotationKey: tc.items},
+			}
+
+			deployment := newDeployment()
+			err := f.ConfigureSecrets(req, deployment, existingSecrets)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("want error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			items := deployment.Spec.Template.Spec.Volumes[0].Projected.Sources[0].Secret.Items
+			got := map[string]string{}
+			for _, item := range items {
+				got[item.Key] = item.Path
+			}
+
+			for key, want := range tc.wantPaths {
+				if got[key] != want {
+					t.Errorf("key %s: want path: %q, got: %q", key, want, got[key])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	apiv1 "k8s.io/api/core/v1"
+)
+
+const (
+	// SecretRefEnv is a reference from a single env variable via secretKeyRef
+	SecretRefEnv = "env"
+	// SecretRefEnvFrom is a reference from envFrom
+	SecretRefEnvFrom = "envFrom"
+	// SecretRefVolume is a reference from a secret volume or a projected volume source
+	SecretRefVolume = "volume"
+	// SecretRefImagePull is a reference from imagePullSecrets
+	SecretRefImagePull = "imagePullSecret"
+)
+
+// SecretReferences returns the ways in which a Pod spec references the named secret,
+// an empty result means that the secret is not used.
+func SecretReferences(spec apiv1.PodSpec, secretName string) []string {
+	found := map[string]bool{}
+
+	containers := append([]apiv1.Container{}, spec.InitContainers...)
+	containers = append(containers, spec.Containers...)
+
+	for _, c := range containers {
+		for _, env := range c.Env {
+			if env.ValueFrom != nil && env.ValueFrom.SecretKeyRef != nil && env.ValueFrom.SecretKeyRef.Name == secretName {
+				found[SecretRefEnv] = true
+			}
+		}
+
+		for _, envFrom := range c.EnvFrom {
+			if envFrom.SecretRef != nil && envFrom.SecretRef.Name == secretName {
+				found[SecretRefEnvFrom] = true
+			}
+		}
+	}
+
+	for _, v := range spec.Volumes {
+		if v.Secret != nil && v.Secret.SecretName == secretName {
+			found[SecretRefVolume] = true
+		}
+
+		if v.Projected != nil {
+			for _, source := range v.Projected.Sources {
+				if source.Secret != nil && source.Secret.Name == secretName {
+					found[SecretRefVolume] = true
+				}
+			}
+		}
+	}
+
+	for _, s := range spec.ImagePullSecrets {
+		if s.Name == secretName {
+			found[SecretRefImagePull] = true
+		}
+	}
+
+	refs := []string{}
+	for _, ref := range []string{SecretRefEnv, SecretRefEnvFrom, SecretRefVolume, SecretRefImagePull} {
+		if found[ref] {
+			refs = append(refs, ref)
+		}
+	}
+
+	return refs
+}
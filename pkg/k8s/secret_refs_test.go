@@ -0,0 +1,72 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"reflect"
+	"testing"
+
+	apiv1 "k8s.io/api/core/v1"
+)
+
+func Test_SecretReferences(t *testing.T) {
+	secretRef := func(name string) *apiv1.SecretKeySelector {
+		return &apiv1.SecretKeySelector{LocalObjectReference: apiv1.LocalObjectReference{Name: name}}
+	}
+
+	cases := []struct {
+		name string
+		spec apiv1.PodSpec
+		want []string
+	}{
+		{
+			name: "not referenced",
+			spec: apiv1.PodSpec{Containers: []apiv1.Container{{Name: "fn"}}},
+			want: []string{},
+		},
+		{
+			name: "env secretKeyRef",
+			spec: apiv1.PodSpec{Containers: []apiv1.Container{{
+				Env: []apiv1.EnvVar{{Name: "PASSWORD", ValueFrom: &apiv1.EnvVarSource{SecretKeyRef: secretRef("db")}}},
+			}}},
+			want: []string{SecretRefEnv},
+		},
+		{
+			name: "envFrom in an init container",
+			spec: apiv1.PodSpec{InitContainers: []apiv1.Container{{
+				EnvFrom: []apiv1.EnvFromSource{{SecretRef: &apiv1.SecretEnvSource{LocalObjectReference: apiv1.LocalObjectReference{Name: "db"}}}},
+			}}},
+			want: []string{SecretRefEnvFrom},
+		},
+		{
+			name: "projected volume and image pull secret",
+			spec: apiv1.PodSpec{
+				Volumes: []apiv1.Volume{{
+					Name: "fn-projected-secrets",
+					VolumeSource: apiv1.VolumeSource{Projected: &apiv1.ProjectedVolumeSource{
+						Sources: []apiv1.VolumeProjection{{Secret: &apiv1.SecretProjection{LocalObjectReference: apiv1.LocalObjectReference{Name: "db"}}}},
+					}},
+				}},
+				ImagePullSecrets: []apiv1.LocalObjectReference{{Name: "db"}},
+			},
+			want: []string{SecretRefVolume, SecretRefImagePull},
+		},
+		{
+			name: "secret volume of another secret",
+			spec: apiv1.PodSpec{Volumes: []apiv1.Volume{{
+				VolumeSource: apiv1.VolumeSource{Secret: &apiv1.SecretVolumeSource{SecretName: "other"}},
+			}}},
+			want: []string{},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := SecretReferences(tc.spec, "db")
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("want: %v, got: %v", tc.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"reflect"
+	"strings"
+
+	apiv1 "k8s.io/api/core/v1"
+)
+
+const (
+	// RestartOnSecretChangeAnnotationKey opts a function in to a rolling restart when a Secret
+	// that it references changes, so that values read from env variables or read once at
+	// start-up are refreshed
+	RestartOnSecretChangeAnnotationKey = "com.openfaas.restart-on-secret-change"
+
+	// SecretRestartAnnotationKey is set on the Pod template to roll the function's Pods, the
+	// value is the name and resourceVersion of the Secret that changed
+	SecretRestartAnnotationKey = "com.openfaas.secret-restart"
+)
+
+// RestartOnSecretChange returns true when the function has opted in to restarts on a change to
+// one of its Secrets
+func RestartOnSecretChange(annotations map[string]string) bool {
+	return strings.EqualFold(annotations[RestartOnSecretChangeAnnotationKey], "true")
+}
+
+// SecretChanged returns true when the data of the Secret differs between old and new, resyncs
+// and changes to the Secret's metadata are ignored
+func SecretChanged(old, new *apiv1.Secret) bool {
+	if old.ResourceVersion == new.ResourceVersion {
+		return false
+	}
+	return !reflect.DeepEqual(old.Data, new.Data) || !reflect.DeepEqual(old.StringData, new.StringData)
+}
+
+// SecretRestartRequired returns true when a Pod spec reads the named Secret, a Secret that is
+// only used to pull images does not require a restart
+func SecretRestartRequired(spec apiv1.PodSpec, secretName string) bool {
+	for _, ref := range SecretReferences(spec, secretName) {
+		if ref != SecretRefImagePull {
+			return true
+		}
+	}
+	return false
+}
+
+// CopySecretRestartAnnotation copies the SecretRestartAnnotationKey from the Pod template
+// annotations of an existing Deployment, so that updating the function does not roll its Pods
+// a second time
+func CopySecretRestartAnnotation(from, to map[string]string) map[string]string {
+	v, ok := from[SecretRestartAnnotationKey]
+	if !ok {
+		return to
+	}
+
+	res := make(map[string]string, len(to)+1)
+	for k, v := range to {
+		res[k] = v
+	}
+	res[SecretRestartAnnotationKey] = v
+
+	return res
+}
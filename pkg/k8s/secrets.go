@@ -24,8 +24,17 @@ const (
 	secretLabel                  = "app.kubernetes.io/managed-by"
 	secretLabelValue             = "openfaas"
 	secretsProjectVolumeNameTmpl = "%s-projected-secrets"
+
+	// ManagedSecretLabel marks a Secret as created by faas-netes for functions
+	ManagedSecretLabel = "faas-netes/managed"
 )
 
+// IsManagedSecret returns true when the labels mark the Secret as created by faas-netes,
+// Secrets created before ManagedSecretLabel was added are recognised by the managed-by label.
+func IsManagedSecret(labels map[string]string) bool {
+	return labels[ManagedSecretLabel] == "true" || labels[secretLabel] == secretLabelValue
+}
+
 // SecretsClient exposes the standardized CRUD behaviors for Kubernetes secrets.  These methods
 // will ensure that the secrets are structured and labelled correctly for use by the OpenFaaS system.
 type SecretsClient interface {
@@ -94,7 +103,8 @@ func (c secretClient) Create(secret types.Secret) error {
 			Name:      secret.Name,
 			Namespace: secret.Namespace,
 			Labels: map[string]string{
-				secretLabel: secretLabelValue,
+				secretLabel:        secretLabelValue,
+				ManagedSecretLabel: "true",
 			},
 		},
 	}
@@ -197,9 +207,28 @@ func (c secretClient) getValidSecretData(secret types.Secret) map[string][]byte
 // deployment spec as appropriate: secrets with type `SecretTypeDockercfg/SecretTypeDockerjson`
 // are added as ImagePullSecrets all other secrets are mounted as files in the deployments containers.
 func (f *FunctionFactory) ConfigureSecrets(request types.FunctionDeployment, deployment *appsv1.Deployment, existingSecrets map[string]*apiv1.Secret) error {
+	var annotations map[string]string
+	if request.Annotations != nil {
+		annotations = *request.Annotations
+	}
+
+	secretItems, err := ParseSecretItems(annotations)
+	if err != nil {
+		return err
+	}
+
+	for secretName := range secretItems {
+		if !contains(request.Secrets, secretName) {
+			return fmt.Errorf("%s references secret '%s' which is not used by the function", SecretItemsAnnotationKey, secretName)
+		}
+	}
+
 	// Add / reference pre-existing secrets within Kubernetes
 	secretVolumeProjections := []apiv1.VolumeProjection{}
 
+	// all secrets are projected into the same directory, so their paths must be unique
+	projectedBy := map[string]string{}
+
 	for _, secretName := range request.Secrets {
 		deployedSecret, ok := existingSecrets[secretName]
 		if !ok {
@@ -219,9 +248,26 @@ func (f *FunctionFactory) ConfigureSecrets(request types.FunctionDeployment, dep
 			)
 		default:
 
+			mapping := secretItems[secretName]
+			for secretKey := range mapping {
+				if _, ok := deployedSecret.Data[secretKey]; !ok {
+					return fmt.Errorf("%s references key '%s' which was not found in secret '%s'", SecretItemsAnnotationKey, secretKey, secretName)
+				}
+			}
+
 			projectedPaths := []apiv1.KeyToPath{}
 			for secretKey := range deployedSecret.Data {
-				projectedPaths = append(projectedPaths, apiv1.KeyToPath{Key: secretKey, Path: secretKey})
+				projectedPath := secretKey
+				if p, ok := mapping[secretKey]; ok {
+					projectedPath = p
+				}
+
+				if other, ok := projectedBy[projectedPath]; ok {
+					return fmt.Errorf("secret '%s' and secret '%s' are both mounted at path '%s'", other, secretName, projectedPath)
+				}
+				projectedBy[projectedPath] = secretName
+
+				projectedPaths = append(projectedPaths, apiv1.KeyToPath{Key: secretKey, Path: projectedPath})
 			}
 
 			projection := &apiv1.SecretProjection{Items: projectedPaths}
@@ -0,0 +1,43 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ServiceAnnotationPrefix is the prefix of function annotations that are copied to the
+// function's Service with the prefix removed, i.e.
+// `com.openfaas.service.annotation.service.beta.kubernetes.io/aws-load-balancer-type: nlb`
+const ServiceAnnotationPrefix = "com.openfaas.service.annotation."
+
+var validServiceAnnotationKey = regexp.MustCompile(`^([a-z0-9-\.]+/)?[a-z0-9-\.]+$`)
+
+// ServiceAnnotations returns the annotations for a function's Service, which are the function
+// annotations along with any `com.openfaas.service.annotation.*` annotation copied without its
+// prefix. An error is returned when a key is not a valid annotation key.
+func ServiceAnnotations(annotations map[string]string) (map[string]string, error) {
+	serviceAnnotations := make(map[string]string, len(annotations))
+
+	for k, v := range annotations {
+		serviceAnnotations[k] = v
+	}
+
+	for k, v := range annotations {
+		if !strings.HasPrefix(k, ServiceAnnotationPrefix) {
+			continue
+		}
+
+		key := strings.TrimPrefix(k, ServiceAnnotationPrefix)
+		if !validServiceAnnotationKey.MatchString(key) {
+			return nil, fmt.Errorf("invalid service annotation: %q, the key must match %s", key, validServiceAnnotationKey.String())
+		}
+
+		serviceAnnotations[key] = v
+	}
+
+	return serviceAnnotations, nil
+}
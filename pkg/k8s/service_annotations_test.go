@@ -0,0 +1,70 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_ServiceAnnotations(t *testing.T) {
+	cases := []struct {
+		name        string
+		annotations map[string]string
+		want        map[string]string
+		wantErr     bool
+	}{
+		{
+			name:        "no service annotations",
+			annotations: map[string]string{"prometheus.io.scrape": "false"},
+			want:        map[string]string{"prometheus.io.scrape": "false"},
+		},
+		{
+			name: "prefixed annotation is copied without the prefix",
+			annotations: map[string]string{
+				ServiceAnnotationPrefix + "service.beta.kubernetes.io/aws-load-balancer-type": "nlb",
+			},
+			want: map[string]string{
+				ServiceAnnotationPrefix + "service.beta.kubernetes.io/aws-load-balancer-type": "nlb",
+				"service.beta.kubernetes.io/aws-load-balancer-type":                           "nlb",
+			},
+		},
+		{
+			name:        "key without a prefix",
+			annotations: map[string]string{ServiceAnnotationPrefix + "cloud.google.com-neg": "true"},
+			want: map[string]string{
+				ServiceAnnotationPrefix + "cloud.google.com-neg": "true",
+				"cloud.google.com-neg":                           "true",
+			},
+		},
+		{
+			name:        "upper case key is rejected",
+			annotations: map[string]string{ServiceAnnotationPrefix + "Example.com/Key": "v"},
+			wantErr:     true,
+		},
+		{
+			name:        "empty key is rejected",
+			annotations: map[string]string{ServiceAnnotationPrefix: "v"},
+			wantErr:     true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ServiceAnnotations(tc.annotations)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("want error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("want: %v, got: %v", tc.want, got)
+			}
+		})
+	}
+}
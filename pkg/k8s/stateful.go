@@ -0,0 +1,109 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"path"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// StatefulAnnotationKey runs the function as a StatefulSet when set to "true", so each
+	// replica has a stable network identity and its own PersistentVolumeClaim
+	StatefulAnnotationKey = "com.openfaas.stateful"
+
+	// StorageSizeAnnotationKey is the size of the PersistentVolumeClaim of each replica of a
+	// stateful function, i.e. 5Gi
+	StorageSizeAnnotationKey = "com.openfaas.storage.size"
+
+	// StoragePathAnnotationKey is the absolute path that the storage of a stateful function is
+	// mounted at
+	StoragePathAnnotationKey = "com.openfaas.storage.path"
+
+	// DeletePVCsAnnotationKey deletes the PersistentVolumeClaims of a stateful function when
+	// the function is deleted, when not "true" the claims and their data are kept
+	DeletePVCsAnnotationKey = "com.openfaas.storage.delete-pvcs"
+
+	// StatefulStorageVolumeName is the name of the volume claim template of a stateful function
+	StatefulStorageVolumeName = "data"
+
+	// defaultStorageSize is the size of the storage of each replica when no size is given
+	defaultStorageSize = "1Gi"
+
+	// defaultStoragePath is where the storage is mounted when no path is given
+	defaultStoragePath = "/data"
+
+	// headlessServiceSuffix is appended to the function's name for the headless Service that
+	// gives the replicas of a stateful function their DNS names
+	headlessServiceSuffix = "-headless"
+)
+
+// IsStateful returns true when the function should be run as a StatefulSet
+func IsStateful(annotations map[string]string) bool {
+	return annotations[StatefulAnnotationKey] == "true"
+}
+
+// DeletePVCs returns true when the claims of a stateful function are deleted with it
+func DeletePVCs(annotations map[string]string) bool {
+	return annotations[DeletePVCsAnnotationKey] == "true"
+}
+
+// StatefulStorage returns the size of the storage of each replica of a stateful function and
+// the path that it is mounted at
+func StatefulStorage(annotations map[string]string) (resource.Quantity, string, error) {
+	value := annotations[StorageSizeAnnotationKey]
+	if len(value) == 0 {
+		value = defaultStorageSize
+	}
+
+	size, err := resource.ParseQuantity(value)
+	if err != nil {
+		return size, "", fmt.Errorf("invalid %s: %q", StorageSizeAnnotationKey, value)
+	}
+	if size.Sign() <= 0 {
+		return size, "", fmt.Errorf("invalid %s: %q, must be positive", StorageSizeAnnotationKey, value)
+	}
+
+	mountPath := annotations[StoragePathAnnotationKey]
+	if len(mountPath) == 0 {
+		mountPath = defaultStoragePath
+	}
+	if !strings.HasPrefix(mountPath, "/") || path.Clean(mountPath) != mountPath || mountPath == "/" {
+		return size, "", fmt.Errorf("invalid %s: %q, must be a clean absolute path", StoragePathAnnotationKey, mountPath)
+	}
+
+	return size, mountPath, nil
+}
+
+// HeadlessServiceName is the name of the headless Service of a stateful function, the replicas
+// are addressed as <name>-<ordinal>.<HeadlessServiceName>.<namespace>
+func HeadlessServiceName(functionName string) string {
+	return functionName + headlessServiceSuffix
+}
+
+// DeleteFunctionPVCs deletes the PersistentVolumeClaims of the replicas of a stateful function
+func DeleteFunctionPVCs(ctx context.Context, client kubernetes.Interface, namespace, functionName string) error {
+	claims := client.CoreV1().PersistentVolumeClaims(namespace)
+
+	list, err := claims.List(ctx, metav1.ListOptions{LabelSelector: "faas_function=" + functionName})
+	if err != nil {
+		return fmt.Errorf("unable to list PersistentVolumeClaims: %s", err)
+	}
+
+	for _, claim := range list.Items {
+		if err := claims.Delete(ctx, claim.Name, metav1.DeleteOptions{}); err != nil {
+			return fmt.Errorf("unable to delete PersistentVolumeClaim %s: %s", claim.Name, err)
+		}
+		log.Printf("Deleted PersistentVolumeClaim %s.%s of %s\n", claim.Name, namespace, functionName)
+	}
+
+	return nil
+}
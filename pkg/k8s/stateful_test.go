@@ -0,0 +1,59 @@
+package k8s
+
+import "testing"
+
+func Test_StatefulStorage(t *testing.T) {
+	cases := []struct {
+		name        string
+		annotations map[string]string
+		wantSize    string
+		wantPath    string
+		wantErr     bool
+	}{
+		{name: "defaults", annotations: map[string]string{}, wantSize: "1Gi", wantPath: "/data"},
+		{
+			name:        "size and path",
+			annotations: map[string]string{StorageSizeAnnotationKey: "5Gi", StoragePathAnnotationKey: "/var/lib/state"},
+			wantSize:    "5Gi",
+			wantPath:    "/var/lib/state",
+		},
+		{name: "invalid size", annotations: map[string]string{StorageSizeAnnotationKey: "lots"}, wantErr: true},
+		{name: "zero size", annotations: map[string]string{StorageSizeAnnotationKey: "0"}, wantErr: true},
+		{name: "relative path", annotations: map[string]string{StoragePathAnnotationKey: "data"}, wantErr: true},
+		{name: "unclean path", annotations: map[string]string{StoragePathAnnotationKey: "/data/../etc"}, wantErr: true},
+		{name: "root path", annotations: map[string]string{StoragePathAnnotationKey: "/"}, wantErr: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			size, path, err := StatefulStorage(tc.annotations)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %s at %s", size.String(), path)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if size.String() != tc.wantSize {
+				t.Errorf("want size %s, got %s", tc.wantSize, size.String())
+			}
+			if path != tc.wantPath {
+				t.Errorf("want path %s, got %s", tc.wantPath, path)
+			}
+		})
+	}
+}
+
+func Test_IsStateful(t *testing.T) {
+	if IsStateful(map[string]string{}) {
+		t.Errorf("want a function without the annotation to not be stateful")
+	}
+	if !IsStateful(map[string]string{StatefulAnnotationKey: "true"}) {
+		t.Errorf("want a function with %s=true to be stateful", StatefulAnnotationKey)
+	}
+	if DeletePVCs(map[string]string{StatefulAnnotationKey: "true"}) {
+		t.Errorf("want claims to be kept without %s", DeletePVCsAnnotationKey)
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// safeSysctls are the namespaced sysctls that Kubernetes considers safe, these are allowed
+// by every kubelet and may always be set by a Profile
+var safeSysctls = map[string]bool{
+	"kernel.shm_rmid_forced":              true,
+	"net.ipv4.ip_local_port_range":        true,
+	"net.ipv4.ip_unprivileged_port_start": true,
+	"net.ipv4.ping_group_range":           true,
+	"net.ipv4.tcp_syncookies":             true,
+}
+
+// ValidateSysctls returns an error when the Pod sets a sysctl that is neither in the safe set
+// nor in DeploymentConfig.AllowedUnsafeSysctls. The sysctls are set via the PodSecurityContext
+// of a Profile, so this should be called after Profiles are applied.
+func (f *FunctionFactory) ValidateSysctls(spec corev1.PodSpec) error {
+	if spec.SecurityContext == nil {
+		return nil
+	}
+
+	var rejected []string
+	for _, sysctl := range spec.SecurityContext.Sysctls {
+		if !safeSysctls[sysctl.Name] && !sysctlAllowed(sysctl.Name, f.Config.AllowedUnsafeSysctls) {
+			rejected = append(rejected, sysctl.Name)
+		}
+	}
+
+	if len(rejected) > 0 {
+		return fmt.Errorf("unsafe sysctls are not allowed: %s", strings.Join(rejected, ", "))
+	}
+
+	return nil
+}
+
+// sysctlAllowed matches a sysctl against the allow-list, which uses the same format as the
+// kubelet --allowed-unsafe-sysctls flag, i.e. a name or a prefix ending with "*"
+func sysctlAllowed(name string, allowed []string) bool {
+	for _, pattern := range allowed {
+		if strings.HasSuffix(pattern, "*") {
+			if strings.HasPrefix(name, strings.TrimSuffix(pattern, "*")) {
+				return true
+			}
+		} else if name == pattern {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,69 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func Test_ValidateSysctls(t *testing.T) {
+	cases := []struct {
+		name    string
+		allowed []string
+		sysctls []corev1.Sysctl
+		wantErr bool
+	}{
+		{
+			name:    "no sysctls",
+			wantErr: false,
+		},
+		{
+			name:    "safe sysctl",
+			sysctls: []corev1.Sysctl{{Name: "net.ipv4.ip_local_port_range", Value: "1024 65535"}},
+			wantErr: false,
+		},
+		{
+			name:    "unsafe sysctl is rejected by default",
+			sysctls: []corev1.Sysctl{{Name: "net.core.somaxconn", Value: "4096"}},
+			wantErr: true,
+		},
+		{
+			name:    "unsafe sysctl allowed by name",
+			allowed: []string{"net.core.somaxconn"},
+			sysctls: []corev1.Sysctl{{Name: "net.core.somaxconn", Value: "4096"}},
+			wantErr: false,
+		},
+		{
+			name:    "unsafe sysctl allowed by prefix",
+			allowed: []string{"net.core.*"},
+			sysctls: []corev1.Sysctl{{Name: "net.core.somaxconn", Value: "4096"}},
+			wantErr: false,
+		},
+		{
+			name:    "prefix does not match other sysctls",
+			allowed: []string{"net.core.*"},
+			sysctls: []corev1.Sysctl{{Name: "kernel.msgmax", Value: "65536"}},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			factory := mockFactory()
+			factory.Config.AllowedUnsafeSysctls = tc.allowed
+
+			spec := corev1.PodSpec{}
+			if tc.sysctls != nil {
+				spec.SecurityContext = &corev1.PodSecurityContext{Sysctls: tc.sysctls}
+			}
+
+			err := factory.ValidateSysctls(spec)
+			if tc.wantErr != (err != nil) {
+				t.Errorf("want error: %v, got: %v", tc.wantErr, err)
+			}
+		})
+	}
+}
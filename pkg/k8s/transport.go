@@ -0,0 +1,45 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+const (
+	// TransportAnnotationKey selects how the proxy invokes the function, either http, the
+	// default, or grpc for functions that implement a gRPC server without TLS
+	TransportAnnotationKey = "com.openfaas.transport"
+
+	// TransportHTTP invokes the function with HTTP/1.1 through its watchdog
+	TransportHTTP = "http"
+
+	// TransportGRPC invokes the function's gRPC server with unary calls over cleartext HTTP/2
+	TransportGRPC = "grpc"
+)
+
+// Transport returns the TransportAnnotationKey of the function, TransportHTTP when it is not set
+func Transport(annotations map[string]string) (string, error) {
+	v := strings.ToLower(strings.TrimSpace(annotations[TransportAnnotationKey]))
+	switch v {
+	case "":
+		return TransportHTTP, nil
+	case TransportHTTP, TransportGRPC:
+		return v, nil
+	default:
+		return "", fmt.Errorf("invalid %s: %q, must be %s or %s", TransportAnnotationKey, v, TransportHTTP, TransportGRPC)
+	}
+}
+
+// GRPCTarget returns the host:port target of a gRPC function from the address that the
+// FunctionLookup resolved, defaultPort is used when the address has no port
+func GRPCTarget(address url.URL, defaultPort string) string {
+	if len(address.Port()) > 0 {
+		return address.Host
+	}
+	return net.JoinHostPort(address.Hostname(), defaultPort)
+}
@@ -0,0 +1,49 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"net/url"
+	"testing"
+)
+
+func Test_Transport(t *testing.T) {
+	cases := []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{value: "", want: TransportHTTP},
+		{value: "http", want: TransportHTTP},
+		{value: "gRPC", want: TransportGRPC},
+		{value: "websocket", wantErr: true},
+	}
+
+	for _, tc := range cases {
+		got, err := Transport(map[string]string{TransportAnnotationKey: tc.value})
+		if tc.wantErr != (err != nil) {
+			t.Errorf("%q: want error: %v, got: %v", tc.value, tc.wantErr, err)
+		}
+		if got != tc.want {
+			t.Errorf("%q: want: %q, got: %q", tc.value, tc.want, got)
+		}
+	}
+}
+
+func Test_GRPCTarget(t *testing.T) {
+	cases := []struct {
+		address url.URL
+		want    string
+	}{
+		{address: url.URL{Scheme: "http", Host: "10.0.0.1:9000"}, want: "10.0.0.1:9000"},
+		{address: url.URL{Scheme: "http", Host: "10.0.0.1"}, want: "10.0.0.1:8080"},
+		{address: url.URL{Scheme: "http", Host: "greeter.openfaas-fn.svc.cluster.local"}, want: "greeter.openfaas-fn.svc.cluster.local:8080"},
+	}
+
+	for _, tc := range cases {
+		if got := GRPCTarget(tc.address, "8080"); got != tc.want {
+			t.Errorf("%s: want: %q, got: %q", tc.address.Host, tc.want, got)
+		}
+	}
+}
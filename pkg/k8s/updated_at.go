@@ -0,0 +1,46 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+)
+
+// UpdatedAtAnnotationKey records when the function was last deployed or updated through the
+// API, in RFC3339 format
+const UpdatedAtAnnotationKey = "com.openfaas.updated-at"
+
+// SetUpdatedAt stamps the Deployment with the time it was deployed or updated, the annotation
+// is on the Deployment rather than the Pod template so that it does not trigger a rollout. The
+// annotations map is copied so that a map shared with the request is not modified.
+func SetUpdatedAt(deployment *appsv1.Deployment, now time.Time) {
+	annotations := make(map[string]string, len(deployment.Annotations)+1)
+	for k, v := range deployment.Annotations {
+		annotations[k] = v
+	}
+	annotations[UpdatedAtAnnotationKey] = now.UTC().Format(time.RFC3339)
+	deployment.Annotations = annotations
+}
+
+// LastUpdated returns when the function was last updated: the UpdatedAtAnnotationKey
+// annotation, or for a Deployment without it, such as one created by the operator, the
+// latest update of one of its conditions. A Deployment with neither returns its creation time.
+func LastUpdated(deployment appsv1.Deployment) time.Time {
+	if v, ok := deployment.Annotations[UpdatedAtAnnotationKey]; ok {
+		if t, err := time.Parse(time.RFC3339, v); err == nil {
+			return t
+		}
+	}
+
+	lastUpdated := deployment.CreationTimestamp.Time
+	for _, condition := range deployment.Status.Conditions {
+		if condition.LastUpdateTime.After(lastUpdated) {
+			lastUpdated = condition.LastUpdateTime.Time
+		}
+	}
+
+	return lastUpdated
+}
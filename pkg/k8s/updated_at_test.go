@@ -0,0 +1,79 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_LastUpdated(t *testing.T) {
+	created := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+	progressed := created.Add(time.Hour)
+	stamped := created.Add(time.Hour * 2)
+
+	cases := []struct {
+		name       string
+		deployment appsv1.Deployment
+		want       time.Time
+	}{
+		{
+			name: "creation time without conditions",
+			deployment: appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.NewTime(created)},
+			},
+			want: created,
+		},
+		{
+			name: "latest condition update",
+			deployment: appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.NewTime(created)},
+				Status: appsv1.DeploymentStatus{Conditions: []appsv1.DeploymentCondition{
+					{Type: appsv1.DeploymentAvailable, LastUpdateTime: metav1.NewTime(created)},
+					{Type: appsv1.DeploymentProgressing, LastUpdateTime: metav1.NewTime(progressed)},
+				}},
+			},
+			want: progressed,
+		},
+		{
+			name: "annotation takes precedence",
+			deployment: appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{
+					CreationTimestamp: metav1.NewTime(created),
+					Annotations:       map[string]string{UpdatedAtAnnotationKey: stamped.Format(time.RFC3339)},
+				},
+				Status: appsv1.DeploymentStatus{Conditions: []appsv1.DeploymentCondition{
+					{Type: appsv1.DeploymentProgressing, LastUpdateTime: metav1.NewTime(progressed)},
+				}},
+			},
+			want: stamped,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := LastUpdated(tc.deployment); !got.Equal(tc.want) {
+				t.Errorf("want %s, got %s", tc.want, got)
+			}
+		})
+	}
+}
+
+func Test_SetUpdatedAt(t *testing.T) {
+	annotations := map[string]string{"topic": "faas-request"}
+	deployment := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Annotations: annotations}}
+
+	now := time.Date(2020, 10, 15, 9, 30, 0, 0, time.UTC)
+	SetUpdatedAt(deployment, now)
+
+	if got := deployment.Annotations[UpdatedAtAnnotationKey]; got != "2020-10-15T09:30:00Z" {
+		t.Errorf("want 2020-10-15T09:30:00Z, got %s", got)
+	}
+	if _, ok := annotations[UpdatedAtAnnotationKey]; ok {
+		t.Errorf("want the original annotations left unchanged")
+	}
+}
@@ -42,3 +42,14 @@ func removeVolumeMount(volumeName string, mounts []corev1.VolumeMount) []corev1.
 
 	return newMounts
 }
+
+// contains returns true when value is an element of values
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,243 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	// VariantsAnnotationKey is a JSON object of variant name to image, i.e. {"beta":"functions/nodeinfo:beta"},
+	// each variant is deployed alongside the function and requests are routed to it by header
+	VariantsAnnotationKey = "com.openfaas.variants"
+
+	// VariantHeaderAnnotationKey is the request header whose value selects a variant, by
+	// default DefaultVariantHeader
+	VariantHeaderAnnotationKey = "com.openfaas.variant.header"
+
+	// DefaultVariantHeader selects a variant when the function does not set
+	// VariantHeaderAnnotationKey
+	DefaultVariantHeader = "X-Variant"
+
+	// VariantLabel selects the Pods of a variant, they do not have the faas_function label so
+	// that the function's Service does not send traffic to them
+	VariantLabel = "faas_variant"
+
+	// VariantOfLabel is the name of the function that a variant belongs to
+	VariantOfLabel = "com.openfaas.variant-of"
+)
+
+// Variants parses the VariantsAnnotationKey annotation, nil is returned when it is not set
+func Variants(annotations map[string]string) (map[string]string, error) {
+	v, ok := annotations[VariantsAnnotationKey]
+	if !ok || len(v) == 0 {
+		return nil, nil
+	}
+
+	variants := map[string]string{}
+	if err := json.Unmarshal([]byte(v), &variants); err != nil {
+		return nil, fmt.Errorf("invalid %s: must be a JSON object of variant names to images: %s", VariantsAnnotationKey, err)
+	}
+
+	for name, image := range variants {
+		if errs := validation.IsDNS1123Label(name); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid %s: variant %q, %s", VariantsAnnotationKey, name, strings.Join(errs, ", "))
+		}
+		if len(strings.TrimSpace(image)) == 0 {
+			return nil, fmt.Errorf("invalid %s: variant %q has no image", VariantsAnnotationKey, name)
+		}
+	}
+
+	return variants, nil
+}
+
+// VariantHeader returns the request header that selects a variant of the function
+func VariantHeader(annotations map[string]string) string {
+	if v := annotations[VariantHeaderAnnotationKey]; len(v) > 0 {
+		return v
+	}
+	return DefaultVariantHeader
+}
+
+// VariantName is the name of the Deployment and Service of a variant
+func VariantName(function, variant string) string {
+	return function + "-" + variant
+}
+
+// ValidateVariants checks the variant annotations of a function before it is deployed
+func ValidateVariants(function string, annotations map[string]string) error {
+	variants, err := Variants(annotations)
+	if err != nil {
+		return err
+	}
+
+	for variant := range variants {
+		if name := VariantName(function, variant); len(validation.IsDNS1123Label(name)) > 0 {
+			return fmt.Errorf("invalid %s: variant name %q is not a valid DNS label", VariantsAnnotationKey, name)
+		}
+	}
+	return nil
+}
+
+// MakeVariantDeployment copies the function's Deployment for a variant with a single replica
+// and the variant's image
+func MakeVariantDeployment(base *appsv1.Deployment, variant, image string) *appsv1.Deployment {
+	name := VariantName(base.Name, variant)
+
+	spec := base.Spec.DeepCopy()
+	replicas := int32(1)
+	spec.Replicas = &replicas
+	spec.Selector = &metav1.LabelSelector{
+		MatchLabels: map[string]string{VariantLabel: name},
+	}
+
+	podLabels := map[string]string{}
+	for k, v := range spec.Template.Labels {
+		podLabels[k] = v
+	}
+	delete(podLabels, "faas_function")
+	podLabels[VariantLabel] = name
+	podLabels[VariantOfLabel] = base.Name
+	spec.Template.Labels = podLabels
+
+	if len(spec.Template.Spec.Containers) > 0 {
+		spec.Template.Spec.Containers[0].Image = image
+	}
+
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: base.Namespace,
+			Labels: map[string]string{
+				VariantLabel:   name,
+				VariantOfLabel: base.Name,
+			},
+			OwnerReferences: variantOwner(base),
+		},
+		Spec: *spec,
+	}
+}
+
+// ReconcileVariants creates or updates a Deployment and Service for each variant of the
+// function and deletes those of variants that were removed. The variants are owned by the
+// function's Deployment, so they are deleted along with it.
+func (f *FunctionFactory) ReconcileVariants(ctx context.Context, namespace string, base *appsv1.Deployment) error {
+	variants, err := Variants(base.Annotations)
+	if err != nil {
+		return err
+	}
+
+	deployments := f.Client.AppsV1().Deployments(namespace)
+	services := f.Client.CoreV1().Services(namespace)
+
+	for variant, image := range variants {
+		desired := MakeVariantDeployment(base, variant, image)
+
+		current, err := deployments.Get(ctx, desired.Name, metav1.GetOptions{})
+		if k8serrors.IsNotFound(err) {
+			if _, err := deployments.Create(ctx, desired, metav1.CreateOptions{}); err != nil {
+				return fmt.Errorf("unable to create variant %s: %s", desired.Name, err)
+			}
+			log.Printf("Variant created: %s.%s\n", desired.Name, namespace)
+		} else if err != nil {
+			return err
+		} else {
+			if current.Labels[VariantOfLabel] != base.Name {
+				return fmt.Errorf("unable to update variant %s: the Deployment is not a variant of %s", desired.Name, base.Name)
+			}
+
+			current.Labels = desired.Labels
+			current.OwnerReferences = desired.OwnerReferences
+			current.Spec = desired.Spec
+			if _, err := deployments.Update(ctx, current, metav1.UpdateOptions{}); err != nil {
+				return fmt.Errorf("unable to update variant %s: %s", desired.Name, err)
+			}
+		}
+
+		if _, err := services.Get(ctx, desired.Name, metav1.GetOptions{}); k8serrors.IsNotFound(err) {
+			if _, err := services.Create(ctx, f.makeVariantService(base, desired.Name), metav1.CreateOptions{}); err != nil {
+				return fmt.Errorf("unable to create variant Service %s: %s", desired.Name, err)
+			}
+		} else if err != nil {
+			return err
+		}
+	}
+
+	existing, err := deployments.List(ctx, metav1.ListOptions{LabelSelector: VariantOfLabel + "=" + base.Name})
+	if err != nil {
+		return err
+	}
+
+	for _, item := range existing.Items {
+		if _, ok := variants[strings.TrimPrefix(item.Name, base.Name+"-")]; ok {
+			continue
+		}
+
+		if err := deployments.Delete(ctx, item.Name, metav1.DeleteOptions{}); err != nil && !k8serrors.IsNotFound(err) {
+			return fmt.Errorf("unable to delete variant %s: %s", item.Name, err)
+		}
+		if err := services.Delete(ctx, item.Name, metav1.DeleteOptions{}); err != nil && !k8serrors.IsNotFound(err) {
+			return fmt.Errorf("unable to delete variant Service %s: %s", item.Name, err)
+		}
+		log.Printf("Variant deleted: %s.%s\n", item.Name, namespace)
+	}
+
+	return nil
+}
+
+func (f *FunctionFactory) makeVariantService(base *appsv1.Deployment, name string) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: base.Namespace,
+			Labels: map[string]string{
+				VariantLabel:   name,
+				VariantOfLabel: base.Name,
+			},
+			Annotations:     map[string]string{"prometheus.io.scrape": "false"},
+			OwnerReferences: variantOwner(base),
+		},
+		Spec: corev1.ServiceSpec{
+			Type:     corev1.ServiceTypeClusterIP,
+			Selector: map[string]string{VariantLabel: name},
+			Ports: []corev1.ServicePort{
+				{
+					Name:     "http",
+					Protocol: corev1.ProtocolTCP,
+					Port:     f.Config.RuntimeHTTPPort,
+					TargetPort: intstr.IntOrString{
+						Type:   intstr.Int,
+						IntVal: f.Config.RuntimeHTTPPort,
+					},
+				},
+			},
+		},
+	}
+}
+
+// variantOwner makes the function's Deployment the owner of its variants, it is empty until
+// the Deployment has been created and has a UID
+func variantOwner(base *appsv1.Deployment) []metav1.OwnerReference {
+	if len(base.UID) == 0 {
+		return nil
+	}
+
+	return []metav1.OwnerReference{{
+		APIVersion: "apps/v1",
+		Kind:       "Deployment",
+		Name:       base.Name,
+		UID:        base.UID,
+	}}
+}
@@ -0,0 +1,187 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"context"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func Test_Variants(t *testing.T) {
+	cases := []struct {
+		name        string
+		annotations map[string]string
+		want        map[string]string
+		wantErr     bool
+	}{
+		{
+			name:        "not set",
+			annotations: map[string]string{},
+		},
+		{
+			name:        "valid variants",
+			annotations: map[string]string{VariantsAnnotationKey: `{"beta":"functions/nodeinfo:beta"}`},
+			want:        map[string]string{"beta": "functions/nodeinfo:beta"},
+		},
+		{
+			name:        "invalid json",
+			annotations: map[string]string{VariantsAnnotationKey: `beta=functions/nodeinfo:beta`},
+			wantErr:     true,
+		},
+		{
+			name:        "invalid variant name",
+			annotations: map[string]string{VariantsAnnotationKey: `{"Beta_1":"functions/nodeinfo:beta"}`},
+			wantErr:     true,
+		},
+		{
+			name:        "missing image",
+			annotations: map[string]string{VariantsAnnotationKey: `{"beta":" "}`},
+			wantErr:     true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Variants(tc.annotations)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("want error: %v, got: %v", tc.wantErr, err)
+			}
+			if len(got) != len(tc.want) {
+				t.Fatalf("want %v, got %v", tc.want, got)
+			}
+			for k, v := range tc.want {
+				if got[k] != v {
+					t.Errorf("want %s=%s, got %s", k, v, got[k])
+				}
+			}
+		})
+	}
+}
+
+func Test_VariantHeader(t *testing.T) {
+	if got := VariantHeader(map[string]string{}); got != DefaultVariantHeader {
+		t.Errorf("want %s, got %s", DefaultVariantHeader, got)
+	}
+	if got := VariantHeader(map[string]string{VariantHeaderAnnotationKey: "X-Track"}); got != "X-Track" {
+		t.Errorf("want X-Track, got %s", got)
+	}
+}
+
+func Test_ValidateVariants_NameTooLong(t *testing.T) {
+	function := "a-function-with-a-name-that-is-almost-as-long-as-a-label-can-be"
+	annotations := map[string]string{VariantsAnnotationKey: `{"beta":"functions/nodeinfo:beta"}`}
+
+	if err := ValidateVariants(function, annotations); err == nil {
+		t.Errorf("want an error for a variant name longer than 63 characters")
+	}
+	if err := ValidateVariants("nodeinfo", annotations); err != nil {
+		t.Errorf("want no error, got %s", err)
+	}
+}
+
+func Test_MakeVariantDeployment(t *testing.T) {
+	replicas := int32(3)
+	base := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "nodeinfo",
+			Namespace: "openfaas-fn",
+			UID:       types.UID("uid-1"),
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{"faas_function": "nodeinfo", "team": "a"},
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "nodeinfo", Image: "functions/nodeinfo:latest"}},
+				},
+			},
+		},
+	}
+
+	variant := MakeVariantDeployment(base, "beta", "functions/nodeinfo:beta")
+
+	if variant.Name != "nodeinfo-beta" {
+		t.Errorf("want name nodeinfo-beta, got %s", variant.Name)
+	}
+	if *variant.Spec.Replicas != 1 {
+		t.Errorf("want 1 replica, got %d", *variant.Spec.Replicas)
+	}
+	if got := variant.Spec.Template.Spec.Containers[0].Image; got != "functions/nodeinfo:beta" {
+		t.Errorf("want the variant image, got %s", got)
+	}
+	if _, ok := variant.Spec.Template.Labels["faas_function"]; ok {
+		t.Errorf("want the faas_function label removed so the function's Service does not select the variant")
+	}
+	if got := variant.Spec.Template.Labels["team"]; got != "a" {
+		t.Errorf("want other labels copied, got team=%q", got)
+	}
+	if len(variant.OwnerReferences) != 1 || variant.OwnerReferences[0].UID != base.UID {
+		t.Errorf("want the variant owned by the function's Deployment, got %v", variant.OwnerReferences)
+	}
+	if got := base.Spec.Template.Spec.Containers[0].Image; got != "functions/nodeinfo:latest" {
+		t.Errorf("want the function's Deployment unchanged, got image %s", got)
+	}
+}
+
+func Test_ReconcileVariants(t *testing.T) {
+	factory := mockFactory()
+	ctx := context.Background()
+
+	base := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "nodeinfo",
+			Namespace: "openfaas-fn",
+			Annotations: map[string]string{
+				VariantsAnnotationKey: `{"beta":"functions/nodeinfo:beta","canary":"functions/nodeinfo:canary"}`,
+			},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "nodeinfo", Image: "functions/nodeinfo:latest"}},
+				},
+			},
+		},
+	}
+
+	if err := factory.ReconcileVariants(ctx, "openfaas-fn", base); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	for _, name := range []string{"nodeinfo-beta", "nodeinfo-canary"} {
+		if _, err := factory.Client.AppsV1().Deployments("openfaas-fn").Get(ctx, name, metav1.GetOptions{}); err != nil {
+			t.Errorf("want Deployment %s, got error: %s", name, err)
+		}
+		if _, err := factory.Client.CoreV1().Services("openfaas-fn").Get(ctx, name, metav1.GetOptions{}); err != nil {
+			t.Errorf("want Service %s, got error: %s", name, err)
+		}
+	}
+
+	base.Annotations[VariantsAnnotationKey] = `{"beta":"functions/nodeinfo:beta2"}`
+	if err := factory.ReconcileVariants(ctx, "openfaas-fn", base); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	beta, err := factory.Client.AppsV1().Deployments("openfaas-fn").Get(ctx, "nodeinfo-beta", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got := beta.Spec.Template.Spec.Containers[0].Image; got != "functions/nodeinfo:beta2" {
+		t.Errorf("want the variant updated to the new image, got %s", got)
+	}
+
+	if _, err := factory.Client.AppsV1().Deployments("openfaas-fn").Get(ctx, "nodeinfo-canary", metav1.GetOptions{}); err == nil {
+		t.Errorf("want the removed variant's Deployment deleted")
+	}
+	if _, err := factory.Client.CoreV1().Services("openfaas-fn").Get(ctx, "nodeinfo-canary", metav1.GetOptions{}); err == nil {
+		t.Errorf("want the removed variant's Service deleted")
+	}
+}
@@ -0,0 +1,32 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"fmt"
+	"path"
+
+	appsv1 "k8s.io/api/apps/v1"
+)
+
+// WorkingDirAnnotationKey sets the working directory of the function container, overriding the
+// WORKDIR of the image
+const WorkingDirAnnotationKey = "com.openfaas.workingdir"
+
+// ConfigureWorkingDir sets the WorkingDir of the function container from the
+// WorkingDirAnnotationKey annotation. Without the annotation the WorkingDir is cleared, so that
+// the image default applies, which makes it safe to use for both create and update.
+func (f *FunctionFactory) ConfigureWorkingDir(annotations map[string]string, deployment *appsv1.Deployment) error {
+	workingDir := annotations[WorkingDirAnnotationKey]
+	if len(workingDir) > 0 && (!path.IsAbs(workingDir) || path.Clean(workingDir) != workingDir) {
+		return fmt.Errorf("invalid %s: %q, must be a clean absolute path", WorkingDirAnnotationKey, workingDir)
+	}
+
+	if len(deployment.Spec.Template.Spec.Containers) == 0 {
+		return nil
+	}
+
+	deployment.Spec.Template.Spec.Containers[0].WorkingDir = workingDir
+	return nil
+}
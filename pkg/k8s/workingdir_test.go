@@ -0,0 +1,54 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func Test_ConfigureWorkingDir(t *testing.T) {
+	cases := []struct {
+		name    string
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{name: "no annotation clears the working directory", value: "", want: ""},
+		{name: "absolute path", value: "/home/app/function", want: "/home/app/function"},
+		{name: "relative path", value: "home/app", wantErr: true},
+		{name: "path with parent segments", value: "/home/../etc", wantErr: true},
+		{name: "trailing slash", value: "/home/app/", wantErr: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			factory := mockFactory()
+			deployment := &appsv1.Deployment{}
+			deployment.Spec.Template.Spec.Containers = []corev1.Container{{Name: "fn", WorkingDir: "/previous"}}
+
+			annotations := map[string]string{}
+			if len(tc.value) > 0 {
+				annotations[WorkingDirAnnotationKey] = tc.value
+			}
+
+			err := factory.ConfigureWorkingDir(annotations, deployment)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("want error for %q", tc.value)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if got := deployment.Spec.Template.Spec.Containers[0].WorkingDir; got != tc.want {
+				t.Errorf("want WorkingDir: %q, got: %q", tc.want, got)
+			}
+		})
+	}
+}
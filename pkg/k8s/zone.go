@@ -0,0 +1,199 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// ZoneAnnotationKey pins all replicas of a function to a single zone
+	ZoneAnnotationKey = "com.openfaas.zone"
+
+	// zoneTopologyKey is the well-known node label for the zone of a node
+	zoneTopologyKey = "topology.kubernetes.io/zone"
+
+	// zoneSpreadMinReplicas is the replica count above which a function is spread across zones
+	zoneSpreadMinReplicas = 2
+)
+
+// validZone is the format of a label value, which is what the zone is matched against
+var validZone = regexp.MustCompile(`^[A-Za-z0-9]([-A-Za-z0-9_.]{0,61}[A-Za-z0-9])?$`)
+
+// ConfigureZone adds a required node affinity to the Deployment that pins the function to the
+// zone named in the `com.openfaas.zone` annotation. When updating an existing Deployment, call
+// RemoveZone with the previous value of the annotation first.
+//
+// When the function is not pinned, the zone spread constraint is configured instead.
+//
+// This should be called after Profiles are applied, because a Profile replaces the Affinity.
+func (f *FunctionFactory) ConfigureZone(annotations map[string]string, deployment *appsv1.Deployment) error {
+	zone := annotations[ZoneAnnotationKey]
+	if len(zone) > 0 && !validZone.MatchString(zone) {
+		return fmt.Errorf("invalid %s: %q, must be a valid label value", ZoneAnnotationKey, zone)
+	}
+
+	f.configureZoneSpread(len(zone) == 0, deployment)
+
+	if len(zone) == 0 {
+		return nil
+	}
+
+	requirement := zoneRequirement(zone)
+
+	spec := &deployment.Spec.Template.Spec
+	if spec.Affinity == nil {
+		spec.Affinity = &corev1.Affinity{}
+	} else {
+		// the Affinity may be shared with a Profile from the informer cache
+		spec.Affinity = spec.Affinity.DeepCopy()
+	}
+	if spec.Affinity.NodeAffinity == nil {
+		spec.Affinity.NodeAffinity = &corev1.NodeAffinity{}
+	}
+
+	nodeAffinity := spec.Affinity.NodeAffinity
+	if nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+		nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution = &corev1.NodeSelector{}
+	}
+
+	required := nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+	if len(required.NodeSelectorTerms) == 0 {
+		required.NodeSelectorTerms = []corev1.NodeSelectorTerm{{}}
+	}
+
+	// terms are ORed, so the zone must be added to every term to pin the function
+	for i := range required.NodeSelectorTerms {
+		term := &required.NodeSelectorTerms[i]
+		if !hasZoneRequirement(term.MatchExpressions, requirement) {
+			term.MatchExpressions = append(term.MatchExpressions, requirement)
+		}
+	}
+
+	return nil
+}
+
+// RemoveZone is the inverse of ConfigureZone, zone is the value of the `com.openfaas.zone`
+// annotation that the Deployment was configured with. Only the requirement that ConfigureZone
+// added for that zone is removed, so a zone affinity from a Profile is kept. Empty structs are
+// reset to nil so that the remaining Affinity can be compared with the Profile it came from.
+func (f *FunctionFactory) RemoveZone(zone string, deployment *appsv1.Deployment) {
+	spec := &deployment.Spec.Template.Spec
+	if len(zone) == 0 || spec.Affinity == nil {
+		return
+	}
+
+	requirement := zoneRequirement(zone)
+
+	// the Affinity may be shared with a Profile from the informer cache
+	spec.Affinity = spec.Affinity.DeepCopy()
+	if spec.Affinity.NodeAffinity == nil {
+		return
+	}
+
+	nodeAffinity := spec.Affinity.NodeAffinity
+	required := nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+	if required == nil {
+		return
+	}
+
+	terms := required.NodeSelectorTerms[:0]
+	for _, term := range required.NodeSelectorTerms {
+		expressions := term.MatchExpressions[:0]
+		for _, e := range term.MatchExpressions {
+			if !reflect.DeepEqual(e, requirement) {
+				expressions = append(expressions, e)
+			}
+		}
+
+		if len(expressions) == 0 {
+			expressions = nil
+		}
+		term.MatchExpressions = expressions
+
+		if len(term.MatchExpressions) > 0 || len(term.MatchFields) > 0 {
+			terms = append(terms, term)
+		}
+	}
+
+	if len(terms) == 0 {
+		nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution = nil
+	} else {
+		required.NodeSelectorTerms = terms
+	}
+
+	if nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil &&
+		len(nodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution) == 0 {
+		spec.Affinity.NodeAffinity = nil
+	}
+
+	if spec.Affinity.NodeAffinity == nil &&
+		spec.Affinity.PodAffinity == nil &&
+		spec.Affinity.PodAntiAffinity == nil {
+		spec.Affinity = nil
+	}
+}
+
+// zoneRequirement is the node selector requirement that pins a function to zone
+func zoneRequirement(zone string) corev1.NodeSelectorRequirement {
+	return corev1.NodeSelectorRequirement{
+		Key:      zoneTopologyKey,
+		Operator: corev1.NodeSelectorOpIn,
+		Values:   []string{zone},
+	}
+}
+
+func hasZoneRequirement(expressions []corev1.NodeSelectorRequirement, requirement corev1.NodeSelectorRequirement) bool {
+	for _, e := range expressions {
+		if reflect.DeepEqual(e, requirement) {
+			return true
+		}
+	}
+	return false
+}
+
+// configureZoneSpread adds a TopologySpreadConstraint that spreads the replicas of the function
+// across zones when AutoZoneSpread is enabled and the function has more than two replicas. It
+// is suppressed for smaller functions, where it would only confuse scheduling, and for functions
+// that are pinned to a zone. The constraint previously added by this method is removed first,
+// constraints on the zone key from a Profile are kept.
+func (f *FunctionFactory) configureZoneSpread(enabled bool, deployment *appsv1.Deployment) {
+	spec := &deployment.Spec.Template.Spec
+	spread := zoneSpreadConstraint(deployment.Spec.Template.Labels["faas_function"])
+
+	var constraints []corev1.TopologySpreadConstraint
+	for _, c := range spec.TopologySpreadConstraints {
+		if !reflect.DeepEqual(c, spread) {
+			constraints = append(constraints, c)
+		}
+	}
+	spec.TopologySpreadConstraints = constraints
+
+	replicas := deployment.Spec.Replicas
+	if !enabled || !f.Config.AutoZoneSpread || replicas == nil || *replicas <= zoneSpreadMinReplicas {
+		return
+	}
+
+	spec.TopologySpreadConstraints = append(spec.TopologySpreadConstraints, spread)
+}
+
+// zoneSpreadConstraint is the TopologySpreadConstraint added by configureZoneSpread
+func zoneSpreadConstraint(function string) corev1.TopologySpreadConstraint {
+	return corev1.TopologySpreadConstraint{
+		MaxSkew:           1,
+		TopologyKey:       zoneTopologyKey,
+		WhenUnsatisfiable: corev1.ScheduleAnyway,
+		LabelSelector: &metav1.LabelSelector{
+			MatchLabels: map[string]string{
+				"faas_function": function,
+			},
+		},
+	}
+}
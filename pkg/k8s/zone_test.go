@@ -0,0 +1,236 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package k8s
+
+import (
+	"reflect"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_ConfigureZone_PinsZone(t *testing.T) {
+	factory := mockFactory()
+	deployment := &appsv1.Deployment{}
+
+	err := factory.ConfigureZone(map[string]string{ZoneAnnotationKey: "eu-west-1a"}, deployment)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	affinity := deployment.Spec.Template.Spec.Affinity
+	if affinity == nil || affinity.NodeAffinity == nil || affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+		t.Fatalf("expected a required node affinity, got: %+v", affinity)
+	}
+
+	terms := affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+	want := []corev1.NodeSelectorTerm{{
+		MatchExpressions: []corev1.NodeSelectorRequirement{{
+			Key:      "topology.kubernetes.io/zone",
+			Operator: corev1.NodeSelectorOpIn,
+			Values:   []string{"eu-west-1a"},
+		}},
+	}}
+
+	if !reflect.DeepEqual(terms, want) {
+		t.Errorf("want terms: %+v, got: %+v", want, terms)
+	}
+}
+
+func Test_ConfigureZone_InvalidZone(t *testing.T) {
+	factory := mockFactory()
+	deployment := &appsv1.Deployment{}
+
+	err := factory.ConfigureZone(map[string]string{ZoneAnnotationKey: "eu west/1a"}, deployment)
+	if err == nil {
+		t.Fatalf("expected an error for an invalid zone")
+	}
+}
+
+func Test_ConfigureZone_IsReversible(t *testing.T) {
+	factory := mockFactory()
+
+	profileAffinity := &corev1.Affinity{
+		NodeAffinity: &corev1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+				NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+					MatchExpressions: []corev1.NodeSelectorRequirement{{
+						Key:      "gpu",
+						Operator: corev1.NodeSelectorOpExists,
+					}},
+				}},
+			},
+		},
+	}
+	original := profileAffinity.DeepCopy()
+
+	deployment := &appsv1.Deployment{}
+	deployment.Spec.Template.Spec.Affinity = profileAffinity
+
+	err := factory.ConfigureZone(map[string]string{ZoneAnnotationKey: "zone-b"}, deployment)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if !reflect.DeepEqual(profileAffinity, original) {
+		t.Fatalf("the Profile affinity must not be mutated")
+	}
+
+	expressions := deployment.Spec.Template.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms[0].MatchExpressions
+	if len(expressions) != 2 {
+		t.Fatalf("want the zone to be added to the existing term, got: %+v", expressions)
+	}
+
+	factory.RemoveZone("zone-b", deployment)
+	if !reflect.DeepEqual(deployment.Spec.Template.Spec.Affinity, original) {
+		t.Errorf("want affinity: %+v, got: %+v", original, deployment.Spec.Template.Spec.Affinity)
+	}
+
+	deployment.Spec.Template.Spec.Affinity = nil
+	_ = factory.ConfigureZone(map[string]string{ZoneAnnotationKey: "zone-b"}, deployment)
+	factory.RemoveZone("zone-b", deployment)
+	if deployment.Spec.Template.Spec.Affinity != nil {
+		t.Errorf("want nil affinity after removing the zone, got: %+v", deployment.Spec.Template.Spec.Affinity)
+	}
+}
+
+func Test_RemoveZone_KeepsProfileZone(t *testing.T) {
+	factory := mockFactory()
+
+	// a Profile that keeps the function out of a zone
+	profileAffinity := &corev1.Affinity{
+		NodeAffinity: &corev1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+				NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+					MatchExpressions: []corev1.NodeSelectorRequirement{{
+						Key:      "topology.kubernetes.io/zone",
+						Operator: corev1.NodeSelectorOpNotIn,
+						Values:   []string{"zone-c"},
+					}},
+				}},
+			},
+		},
+	}
+	original := profileAffinity.DeepCopy()
+
+	cases := []struct {
+		name string
+		zone string
+	}{
+		{name: "zone was pinned", zone: "zone-b"},
+		{name: "zone was not pinned", zone: ""},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			deployment := &appsv1.Deployment{}
+			deployment.Spec.Template.Spec.Affinity = profileAffinity.DeepCopy()
+
+			if err := factory.ConfigureZone(map[string]string{ZoneAnnotationKey: tc.zone}, deployment); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			factory.RemoveZone(tc.zone, deployment)
+			if !reflect.DeepEqual(deployment.Spec.Template.Spec.Affinity, original) {
+				t.Errorf("want affinity: %+v, got: %+v", original, deployment.Spec.Template.Spec.Affinity)
+			}
+		})
+	}
+}
+
+func Test_ConfigureZone_SpreadsZones(t *testing.T) {
+	cases := []struct {
+		name        string
+		enabled     bool
+		replicas    int32
+		annotations map[string]string
+		want        bool
+	}{
+		{name: "three replicas are spread", enabled: true, replicas: 3, want: true},
+		{name: "two replicas are not spread", enabled: true, replicas: 2, want: false},
+		{name: "a single replica is not spread", enabled: true, replicas: 1, want: false},
+		{name: "disabled is not spread", enabled: false, replicas: 3, want: false},
+		{name: "a pinned zone is not spread", enabled: true, replicas: 3, annotations: map[string]string{ZoneAnnotationKey: "eu-west-1a"}, want: false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			factory := mockFactory()
+			factory.Config.AutoZoneSpread = tc.enabled
+
+			replicas := tc.replicas
+			deployment := &appsv1.Deployment{}
+			deployment.Spec.Replicas = &replicas
+			deployment.Spec.Template.Labels = map[string]string{"faas_function": "fn"}
+
+			if err := factory.ConfigureZone(tc.annotations, deployment); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			// a second call must not duplicate the constraint
+			if err := factory.ConfigureZone(tc.annotations, deployment); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			constraints := deployment.Spec.Template.Spec.TopologySpreadConstraints
+			if !tc.want {
+				if len(constraints) != 0 {
+					t.Fatalf("want no constraints, got: %+v", constraints)
+				}
+				return
+			}
+
+			if len(constraints) != 1 {
+				t.Fatalf("want 1 constraint, got: %+v", constraints)
+			}
+
+			c := constraints[0]
+			if c.MaxSkew != 1 || c.TopologyKey != "topology.kubernetes.io/zone" || c.WhenUnsatisfiable != corev1.ScheduleAnyway {
+				t.Errorf("unexpected constraint: %+v", c)
+			}
+			if c.LabelSelector.MatchLabels["faas_function"] != "fn" {
+				t.Errorf("want selector for fn, got: %+v", c.LabelSelector)
+			}
+		})
+	}
+}
+
+func Test_ConfigureZone_KeepsProfileSpread(t *testing.T) {
+	profile := corev1.TopologySpreadConstraint{
+		MaxSkew:           2,
+		TopologyKey:       "topology.kubernetes.io/zone",
+		WhenUnsatisfiable: corev1.DoNotSchedule,
+		LabelSelector: &metav1.LabelSelector{
+			MatchLabels: map[string]string{"team": "a"},
+		},
+	}
+
+	for _, enabled := range []bool{true, false} {
+		factory := mockFactory()
+		factory.Config.AutoZoneSpread = enabled
+
+		replicas := int32(3)
+		deployment := &appsv1.Deployment{}
+		deployment.Spec.Replicas = &replicas
+		deployment.Spec.Template.Labels = map[string]string{"faas_function": "fn"}
+		deployment.Spec.Template.Spec.TopologySpreadConstraints = []corev1.TopologySpreadConstraint{profile}
+
+		if err := factory.ConfigureZone(nil, deployment); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		constraints := deployment.Spec.Template.Spec.TopologySpreadConstraints
+		want := 1
+		if enabled {
+			want = 2
+		}
+		if len(constraints) != want {
+			t.Fatalf("enabled: %v, want %d constraints, got: %+v", enabled, want, constraints)
+		}
+		if !reflect.DeepEqual(constraints[0], profile) {
+			t.Errorf("enabled: %v, want the Profile constraint kept, got: %+v", enabled, constraints[0])
+		}
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+// Package metrics records Prometheus metrics for the faas-netes HTTP handlers
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/openfaas/faas-provider/types"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// handlerDuration is the latency of each provider handler by response status code
+var handlerDuration = prometheus.NewSummaryVec(prometheus.SummaryOpts{
+	Name:       "faas_netes_handler_duration_seconds",
+	Help:       "Seconds spent serving faas-netes handler requests.",
+	Objectives: map[float64]float64{0.5: 0.05, 0.9: 0.01, 0.99: 0.001},
+}, []string{"handler", "status_code"})
+
+// circuitBreakerState is the state of the Kubernetes API circuit breaker, 0 for closed,
+// 1 for half-open and 2 for open
+var circuitBreakerState = prometheus.NewGauge(prometheus.GaugeOpts{
+	Name: "faas_netes_circuit_breaker_state",
+	Help: "State of the Kubernetes API circuit breaker, 0 closed, 1 half-open, 2 open.",
+})
+
+// circuitBreakerTransitions counts the state changes of the Kubernetes API circuit breaker
+var circuitBreakerTransitions = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "faas_netes_circuit_breaker_transitions_total",
+	Help: "State changes of the Kubernetes API circuit breaker.",
+}, []string{"from", "to"})
+
+// abTestRequests counts the requests of A/B tested functions by the function that served them,
+// the split ratio is the rate of each route over the total
+var abTestRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "faas_netes_ab_requests_total",
+	Help: "Requests to A/B tested functions by route, primary or alternative.",
+}, []string{"function_name", "route"})
+
+func init() {
+	prometheus.MustRegister(handlerDuration)
+	prometheus.MustRegister(circuitBreakerState)
+	prometheus.MustRegister(circuitBreakerTransitions)
+	prometheus.MustRegister(abTestRequests)
+}
+
+// ObserveABTest records that a request to the A/B tested function was sent down route
+func ObserveABTest(function, route string) {
+	abTestRequests.WithLabelValues(function, route).Inc()
+}
+
+// ObserveCircuitBreaker records a state change of the Kubernetes API circuit breaker, state
+// is the numeric value of the new state
+func ObserveCircuitBreaker(from, to string, state int) {
+	circuitBreakerState.Set(float64(state))
+	circuitBreakerTransitions.WithLabelValues(from, to).Inc()
+}
+
+// InstrumentHandlers wraps each of the provider handlers with ObserveHandler
+func InstrumentHandlers(h *types.FaaSHandlers) {
+	h.FunctionProxy = ObserveHandler("proxy", h.FunctionProxy)
+	h.FunctionReader = ObserveHandler("read", h.FunctionReader)
+	h.DeployHandler = ObserveHandler("deploy", h.DeployHandler)
+	h.DeleteHandler = ObserveHandler("delete", h.DeleteHandler)
+	h.ReplicaReader = ObserveHandler("replica_read", h.ReplicaReader)
+	h.ReplicaUpdater = ObserveHandler("replica_update", h.ReplicaUpdater)
+	h.SecretHandler = ObserveHandler("secrets", h.SecretHandler)
+	h.LogHandler = ObserveHandler("logs", h.LogHandler)
+	h.UpdateHandler = ObserveHandler("update", h.UpdateHandler)
+	h.HealthHandler = ObserveHandler("health", h.HealthHandler)
+	h.InfoHandler = ObserveHandler("info", h.InfoHandler)
+	h.ListNamespaceHandler = ObserveHandler("namespaces", h.ListNamespaceHandler)
+}
+
+// ObserveHandler records the duration of each request to next in
+// faas_netes_handler_duration_seconds. A nil handler is returned as-is, so that optional
+// handlers stay disabled.
+func ObserveHandler(name string, next http.HandlerFunc) http.HandlerFunc {
+	if next == nil {
+		return nil
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		next(recorder, r)
+
+		handlerDuration.
+			WithLabelValues(name, strconv.Itoa(recorder.status)).
+			Observe(time.Since(start).Seconds())
+	}
+}
+
+// statusRecorder captures the status code of a response. It passes through Flush and
+// CloseNotify, which the proxy and log handlers need to stream responses, and unwraps to
+// the server's writer for http.ResponseController.
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (s *statusRecorder) WriteHeader(status int) {
+	if !s.wroteHeader {
+		s.status = status
+		s.wroteHeader = true
+	}
+	s.ResponseWriter.WriteHeader(status)
+}
+
+func (s *statusRecorder) Write(b []byte) (int, error) {
+	s.wroteHeader = true
+	return s.ResponseWriter.Write(b)
+}
+
+func (s *statusRecorder) Flush() {
+	if f, ok := s.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (s *statusRecorder) CloseNotify() <-chan bool {
+	if cn, ok := s.ResponseWriter.(http.CloseNotifier); ok {
+		return cn.CloseNotify()
+	}
+	return make(chan bool)
+}
+
+func (s *statusRecorder) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
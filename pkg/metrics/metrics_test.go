@@ -0,0 +1,56 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func Test_ObserveHandler_RecordsStatusCode(t *testing.T) {
+	cases := []struct {
+		name       string
+		handler    http.HandlerFunc
+		wantStatus string
+	}{
+		{
+			name: "explicit status",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusAccepted)
+			},
+			wantStatus: "202",
+		},
+		{
+			name: "implicit status",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte("ok"))
+			},
+			wantStatus: "200",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			handlerName := "test_" + tc.wantStatus
+			observed := ObserveHandler(handlerName, tc.handler)
+
+			rr := httptest.NewRecorder()
+			observed(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			metric := &dto.Metric{}
+			summary := handlerDuration.WithLabelValues(handlerName, tc.wantStatus).(prometheus.Metric)
+			if err := summary.Write(metric); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if got := metric.GetSummary().GetSampleCount(); got != 1 {
+				t.Errorf("want 1 observation with status %s, got %d", tc.wantStatus, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package metrics
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/openfaas/faas-provider/types"
+)
+
+// StatsD sends counters and timers to a StatsD server, for teams that do not scrape the
+// Prometheus metrics
+type StatsD interface {
+	// Incr increments the counter name by one
+	Incr(name string)
+
+	// Timing records a duration in milliseconds for the timer name
+	Timing(name string, d time.Duration)
+}
+
+// NewStatsD returns a StatsD client that sends to host:port over UDP, when host is empty a
+// client that discards everything is returned
+func NewStatsD(host string, port int) (StatsD, error) {
+	if len(host) == 0 {
+		return noopStatsD{}, nil
+	}
+
+	conn, err := net.Dial("udp", net.JoinHostPort(host, strconv.Itoa(port)))
+	if err != nil {
+		return nil, fmt.Errorf("unable to connect to StatsD at %s:%d: %s", host, port, err)
+	}
+
+	return &udpStatsD{conn: conn}, nil
+}
+
+type noopStatsD struct{}
+
+func (noopStatsD) Incr(name string) {}
+
+func (noopStatsD) Timing(name string, d time.Duration) {}
+
+// udpStatsD writes one datagram per metric, errors are ignored since StatsD delivery is best
+// effort and must not fail a request
+type udpStatsD struct {
+	conn net.Conn
+}
+
+func (s *udpStatsD) Incr(name string) {
+	fmt.Fprintf(s.conn, "%s:1|c", name)
+}
+
+func (s *udpStatsD) Timing(name string, d time.Duration) {
+	fmt.Fprintf(s.conn, "%s:%d|ms", name, d.Milliseconds())
+}
+
+// InstrumentStatsD wraps the deploy, update and delete handlers to send faas.deploy.success,
+// faas.deploy.failure, faas.update.success and faas.delete.success counters and the
+// faas.deploy.duration_ms timer to client
+func InstrumentStatsD(h *types.FaaSHandlers, client StatsD) {
+	h.DeployHandler = observeStatsD(client, "deploy", true, h.DeployHandler)
+	h.UpdateHandler = observeStatsD(client, "update", false, h.UpdateHandler)
+	h.DeleteHandler = observeStatsD(client, "delete", false, h.DeleteHandler)
+}
+
+// observeStatsD counts the successful requests to next as faas.<name>.success, when detailed
+// is set failures and the duration of every request are sent too
+func observeStatsD(client StatsD, name string, detailed bool, next http.HandlerFunc) http.HandlerFunc {
+	if next == nil {
+		return nil
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		next(recorder, r)
+
+		if recorder.status < http.StatusBadRequest {
+			client.Incr("faas." + name + ".success")
+		} else if detailed {
+			client.Incr("faas." + name + ".failure")
+		}
+
+		if detailed {
+			client.Timing("faas."+name+".duration_ms", time.Since(start))
+		}
+	}
+}
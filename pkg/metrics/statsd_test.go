@@ -0,0 +1,70 @@
+// Copyright 2020 OpenFaaS Authors
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package metrics
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openfaas/faas-provider/types"
+)
+
+func Test_NewStatsD_NoHost(t *testing.T) {
+	client, err := NewStatsD("", 8125)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, ok := client.(noopStatsD); !ok {
+		t.Errorf("want a no-op client when no host is set, got %T", client)
+	}
+}
+
+func Test_InstrumentStatsD(t *testing.T) {
+	server, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer server.Close()
+
+	port := server.LocalAddr().(*net.UDPAddr).Port
+	client, err := NewStatsD("127.0.0.1", port)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	handlers := &types.FaaSHandlers{
+		DeployHandler: func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+		},
+		DeleteHandler: func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusAccepted)
+		},
+	}
+	InstrumentStatsD(handlers, client)
+
+	if handlers.UpdateHandler != nil {
+		t.Errorf("want a nil handler to stay nil")
+	}
+
+	handlers.DeployHandler(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/system/functions", nil))
+	handlers.DeleteHandler(httptest.NewRecorder(), httptest.NewRequest(http.MethodDelete, "/system/functions", nil))
+
+	want := []string{"faas.deploy.failure:1|c", "faas.deploy.duration_ms:", "faas.delete.success:1|c"}
+
+	buf := make([]byte, 512)
+	server.SetReadDeadline(time.Now().Add(time.Second))
+	for i, prefix := range want {
+		n, _, err := server.ReadFrom(buf)
+		if err != nil {
+			t.Fatalf("want metric %d %q, got error: %s", i, prefix, err)
+		}
+		if got := string(buf[:n]); !strings.HasPrefix(got, prefix) {
+			t.Errorf("want metric %d to start with %q, got %q", i, prefix, got)
+		}
+	}
+}
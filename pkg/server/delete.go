@@ -7,12 +7,18 @@ import (
 	"net/http"
 
 	clientset "github.com/openfaas/faas-netes/pkg/client/clientset/versioned"
+	"github.com/openfaas/faas-netes/pkg/k8s"
 	"github.com/openfaas/faas-provider/types"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
 	glog "k8s.io/klog"
 )
 
-func makeDeleteHandler(defaultNamespace string, client clientset.Interface) http.HandlerFunc {
+// makeDeleteHandler deletes the Function, its Deployment or StatefulSet and Service are
+// garbage collected. The PersistentVolumeClaims of a stateful function are only deleted when it
+// has the com.openfaas.storage.delete-pvcs annotation, they are deleted first so that a failure
+// can be retried.
+func makeDeleteHandler(defaultNamespace string, client clientset.Interface, kube kubernetes.Interface) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 
 		q := r.URL.Query()
@@ -47,6 +53,17 @@ func makeDeleteHandler(defaultNamespace string, client clientset.Interface) http
 			return
 		}
 
+		function, err := client.OpenfaasV1().Functions(lookupNamespace).
+			Get(r.Context(), request.FunctionName, metav1.GetOptions{})
+		if err == nil && function.Spec.Annotations != nil && k8s.DeletePVCs(*function.Spec.Annotations) {
+			if err := k8s.DeleteFunctionPVCs(r.Context(), kube, lookupNamespace, function.Spec.Name); err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				w.Write([]byte(err.Error()))
+				glog.Errorf("Function %s delete error: %v", request.FunctionName, err)
+				return
+			}
+		}
+
 		err = client.OpenfaasV1().Functions(lookupNamespace).
 			Delete(r.Context(), request.FunctionName, metav1.DeleteOptions{})
 		if err != nil {
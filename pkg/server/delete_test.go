@@ -0,0 +1,72 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	faasv1 "github.com/openfaas/faas-netes/pkg/apis/openfaas/v1"
+	clientset "github.com/openfaas/faas-netes/pkg/client/clientset/versioned/fake"
+	"github.com/openfaas/faas-netes/pkg/k8s"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_makeDeleteHandler_PVCs(t *testing.T) {
+	newClaim := func(name, function string) *corev1.PersistentVolumeClaim {
+		return &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "openfaas-fn",
+				Labels:    map[string]string{"faas_function": function},
+			},
+		}
+	}
+
+	cases := []struct {
+		name       string
+		deletePVCs bool
+		wantClaims int
+	}{
+		{name: "claims are kept by default", deletePVCs: false, wantClaims: 3},
+		{name: "claims of the function are deleted", deletePVCs: true, wantClaims: 1},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			annotations := map[string]string{k8s.StatefulAnnotationKey: "true"}
+			if tc.deletePVCs {
+				annotations[k8s.DeletePVCsAnnotationKey] = "true"
+			}
+
+			client := clientset.NewSimpleClientset(&faasv1.Function{
+				ObjectMeta: metav1.ObjectMeta{Name: "counter", Namespace: "openfaas-fn"},
+				Spec:       faasv1.FunctionSpec{Name: "counter", Annotations: &annotations},
+			})
+			kube := kubefake.NewSimpleClientset(
+				newClaim("data-counter-0", "counter"),
+				newClaim("data-counter-1", "counter"),
+				newClaim("data-database-0", "database"),
+			)
+
+			req := httptest.NewRequest(http.MethodDelete, "/system/functions", strings.NewReader(`{"functionName":"counter"}`))
+			rr := httptest.NewRecorder()
+			makeDeleteHandler("openfaas-fn", client, kube).ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusAccepted {
+				t.Fatalf("want status %d, got %d: %s", http.StatusAccepted, rr.Code, rr.Body.String())
+			}
+
+			claims, err := kube.CoreV1().PersistentVolumeClaims("openfaas-fn").List(context.Background(), metav1.ListOptions{})
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if len(claims.Items) != tc.wantClaims {
+				t.Errorf("want %d claims, got %d", tc.wantClaims, len(claims.Items))
+			}
+		})
+	}
+}
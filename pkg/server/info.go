@@ -4,8 +4,8 @@ import (
 	"encoding/json"
 	"net/http"
 
+	"github.com/openfaas/faas-netes/pkg/handlers"
 	"github.com/openfaas/faas-netes/version"
-	"github.com/openfaas/faas-provider/types"
 	glog "k8s.io/klog"
 )
 
@@ -16,15 +16,7 @@ func makeInfoHandler() http.HandlerFunc {
 			defer r.Body.Close()
 		}
 
-		sha, release := version.GetReleaseInfo()
-		info := types.ProviderInfo{
-			Orchestration: "kubernetes",
-			Name:          "openfaas-operator",
-			Version: &types.VersionInfo{
-				SHA:     sha,
-				Release: release,
-			},
-		}
+		info := handlers.NewProviderInfo("openfaas-operator", version.GetReleaseInfo())
 
 		infoBytes, err := json.Marshal(info)
 		if err != nil {
@@ -5,6 +5,7 @@ import (
 	"net/http"
 
 	clientset "github.com/openfaas/faas-netes/pkg/client/clientset/versioned"
+	"github.com/openfaas/faas-netes/pkg/k8s"
 	"github.com/openfaas/faas-provider/types"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -14,7 +15,8 @@ import (
 
 func makeListHandler(defaultNamespace string,
 	client clientset.Interface,
-	deploymentLister appsv1.DeploymentLister) http.HandlerFunc {
+	deploymentLister appsv1.DeploymentLister,
+	statusConfig k8s.StatusConfig) http.HandlerFunc {
 
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Body != nil {
@@ -46,13 +48,10 @@ func makeListHandler(defaultNamespace string,
 
 		functions := []types.FunctionStatus{}
 		for _, item := range res.Items {
-			desiredReplicas, availableReplicas, err := getReplicas(item.Spec.Name, lookupNamespace, deploymentLister)
-			if err != nil {
-				glog.Warningf("Function listing getReplicas error: %v", err)
-			}
 			function := toFunctionStatus(item)
-			function.AvailableReplicas = availableReplicas
-			function.Replicas = desiredReplicas
+			if err := withDeploymentStatus(&function, lookupNamespace, deploymentLister, statusConfig); err != nil {
+				glog.Warningf("Function listing status error: %v", err)
+			}
 
 			functions = append(functions, function)
 		}
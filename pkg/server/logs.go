@@ -0,0 +1,24 @@
+// Copyright 2020 OpenFaaS Author(s)
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package server
+
+import (
+	"net/http"
+
+	"github.com/openfaas/faas-netes/pkg/config"
+	"github.com/openfaas/faas-netes/pkg/k8s"
+	"github.com/openfaas/faas-provider/logs"
+	"k8s.io/client-go/kubernetes"
+)
+
+// NewLogRequestor returns the logs.Requester for the configured LogBackend, the Kubernetes
+// API is used unless another backend is selected.
+func NewLogRequestor(cfg config.BootstrapConfig, kube kubernetes.Interface, functionNamespace string) logs.Requester {
+	switch cfg.LogBackend {
+	case config.LogBackendLoki:
+		return k8s.NewLokiLogRequestor(&http.Client{Timeout: cfg.FaaSConfig.WriteTimeout}, cfg.LokiURL, functionNamespace)
+	default:
+		return k8s.NewLogRequestor(kube, functionNamespace)
+	}
+}
@@ -0,0 +1,182 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gorilla/mux"
+	clientset "github.com/openfaas/faas-netes/pkg/client/clientset/versioned"
+	"github.com/openfaas/faas-netes/pkg/controller"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/kubernetes"
+	v1 "k8s.io/client-go/listers/apps/v1"
+	glog "k8s.io/klog"
+)
+
+const (
+	// ConditionReconciled reports whether the last reconcile of the Function by the operator
+	// succeeded
+	ConditionReconciled = "Reconciled"
+
+	// ConditionAvailable reports whether the Deployment of the Function has available replicas
+	ConditionAvailable = "Available"
+)
+
+// ReconcileCondition is one aspect of the operator's view of a Function
+type ReconcileCondition struct {
+	Type               string    `json:"type"`
+	Status             string    `json:"status"`
+	Reason             string    `json:"reason,omitempty"`
+	Message            string    `json:"message,omitempty"`
+	LastTransitionTime time.Time `json:"lastTransitionTime,omitempty"`
+}
+
+// ReconcileStatus is the operator's view of a Function, LastError is the message of the last
+// failed reconcile when it has not succeeded since
+type ReconcileStatus struct {
+	Name       string               `json:"name"`
+	Namespace  string               `json:"namespace"`
+	Generation int64                `json:"generation"`
+	Reconciled bool                 `json:"reconciled"`
+	LastError  string               `json:"lastError,omitempty"`
+	Conditions []ReconcileCondition `json:"conditions"`
+}
+
+// makeReconcileStatusHandler returns the reconcile status of a Function from the Events that
+// the operator recorded against it and the state of its Deployment
+func makeReconcileStatusHandler(defaultNamespace string, client clientset.Interface, kube kubernetes.Interface, lister v1.DeploymentLister) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		functionName := mux.Vars(r)["name"]
+
+		lookupNamespace := defaultNamespace
+		if namespace := r.URL.Query().Get("namespace"); len(namespace) > 0 {
+			lookupNamespace = namespace
+		}
+
+		if lookupNamespace == "kube-system" {
+			http.Error(w, "unable to list within the kube-system namespace", http.StatusUnauthorized)
+			return
+		}
+
+		function, err := client.OpenfaasV1().Functions(lookupNamespace).Get(r.Context(), functionName, metav1.GetOptions{})
+		if err != nil {
+			if k8serrors.IsNotFound(err) {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			glog.Errorf("Function reconcile status error: %v", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		selector := fields.Set{
+			"involvedObject.kind": "Function",
+			"involvedObject.name": functionName,
+		}.AsSelector().String()
+		events, err := kube.CoreV1().Events(lookupNamespace).List(r.Context(), metav1.ListOptions{FieldSelector: selector})
+		if err != nil {
+			glog.Errorf("Function reconcile status events error: %v", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		reconciled := reconciledCondition(functionName, events.Items)
+		status := ReconcileStatus{
+			Name:       function.Name,
+			Namespace:  function.Namespace,
+			Generation: function.Generation,
+			Reconciled: reconciled.Status == string(corev1.ConditionTrue),
+			Conditions: []ReconcileCondition{
+				reconciled,
+				availableCondition(functionName, lookupNamespace, lister),
+			},
+		}
+		if reconciled.Status == string(corev1.ConditionFalse) {
+			status.LastError = reconciled.Message
+		}
+
+		out, err := json.Marshal(status)
+		if err != nil {
+			glog.Errorf("Failed to marshal reconcile status: %s", err.Error())
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(out)
+	}
+}
+
+// reconciledCondition is taken from the newest sync Event of the Function, the status is
+// Unknown until the operator has recorded one
+func reconciledCondition(functionName string, items []corev1.Event) ReconcileCondition {
+	var syncEvents []corev1.Event
+	for _, item := range items {
+		if item.InvolvedObject.Kind != "Function" || item.InvolvedObject.Name != functionName {
+			continue
+		}
+		switch item.Reason {
+		case controller.SuccessSynced, controller.ErrSyncFailed, controller.ErrResourceExists:
+			syncEvents = append(syncEvents, item)
+		}
+	}
+
+	if len(syncEvents) == 0 {
+		return ReconcileCondition{
+			Type:    ConditionReconciled,
+			Status:  string(corev1.ConditionUnknown),
+			Reason:  "Pending",
+			Message: "the operator has not reconciled the Function yet",
+		}
+	}
+
+	sort.SliceStable(syncEvents, func(i, j int) bool {
+		return syncEvents[i].LastTimestamp.After(syncEvents[j].LastTimestamp.Time)
+	})
+	last := syncEvents[0]
+
+	status := corev1.ConditionTrue
+	if last.Reason != controller.SuccessSynced {
+		status = corev1.ConditionFalse
+	}
+
+	return ReconcileCondition{
+		Type:               ConditionReconciled,
+		Status:             string(status),
+		Reason:             last.Reason,
+		Message:            last.Message,
+		LastTransitionTime: last.LastTimestamp.Time,
+	}
+}
+
+// availableCondition is True when the Deployment of the Function has an available replica, or
+// has been scaled to zero
+func availableCondition(functionName, namespace string, lister v1.DeploymentLister) ReconcileCondition {
+	condition := ReconcileCondition{Type: ConditionAvailable, Status: string(corev1.ConditionFalse)}
+
+	deployment, err := lister.Deployments(namespace).Get(functionName)
+	if err != nil {
+		condition.Reason = "DeploymentNotFound"
+		condition.Message = err.Error()
+		return condition
+	}
+
+	switch {
+	case deployment.Spec.Replicas != nil && *deployment.Spec.Replicas == 0:
+		condition.Status = string(corev1.ConditionTrue)
+		condition.Reason = "ScaledToZero"
+	case deployment.Status.AvailableReplicas > 0:
+		condition.Status = string(corev1.ConditionTrue)
+		condition.Reason = "ReplicasAvailable"
+	default:
+		condition.Reason = "NoAvailableReplicas"
+	}
+
+	return condition
+}
@@ -0,0 +1,139 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	faasv1 "github.com/openfaas/faas-netes/pkg/apis/openfaas/v1"
+	faasfake "github.com/openfaas/faas-netes/pkg/client/clientset/versioned/fake"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	appslisters "k8s.io/client-go/listers/apps/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func Test_makeReconcileStatusHandler(t *testing.T) {
+	now := time.Now()
+
+	event := func(name, reason, message string, age time.Duration) *corev1.Event {
+		return &corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: name, Namespace: "openfaas-fn"},
+			InvolvedObject: corev1.ObjectReference{Kind: "Function", Name: "nodeinfo", Namespace: "openfaas-fn"},
+			Reason:         reason,
+			Message:        message,
+			LastTimestamp:  metav1.NewTime(now.Add(-age)),
+		}
+	}
+
+	replicas := int32(1)
+	cases := []struct {
+		name          string
+		events        []runtime.Object
+		available     int32
+		wantReconcile string
+		wantAvailable string
+		wantError     string
+	}{
+		{
+			name:          "not reconciled yet",
+			wantReconcile: "Unknown",
+			wantAvailable: "False",
+		},
+		{
+			name: "reconciled",
+			events: []runtime.Object{
+				event("failed", "SyncFailed", "secret not found", time.Minute),
+				event("synced", "Synced", "Function synced successfully", time.Second),
+			},
+			available:     1,
+			wantReconcile: "True",
+			wantAvailable: "True",
+		},
+		{
+			name: "last reconcile failed",
+			events: []runtime.Object{
+				event("synced", "Synced", "Function synced successfully", time.Minute),
+				event("failed", "SyncFailed", "secret not found", time.Second),
+			},
+			available:     1,
+			wantReconcile: "False",
+			wantAvailable: "True",
+			wantError:     "secret not found",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := faasfake.NewSimpleClientset(&faasv1.Function{
+				ObjectMeta: metav1.ObjectMeta{Name: "nodeinfo", Namespace: "openfaas-fn", Generation: 2},
+				Spec:       faasv1.FunctionSpec{Name: "nodeinfo", Image: "functions/nodeinfo"},
+			})
+			kube := fake.NewSimpleClientset(tc.events...)
+
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			if tc.available > 0 {
+				indexer.Add(&appsv1.Deployment{
+					ObjectMeta: metav1.ObjectMeta{Name: "nodeinfo", Namespace: "openfaas-fn"},
+					Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+					Status:     appsv1.DeploymentStatus{AvailableReplicas: tc.available},
+				})
+			}
+
+			handler := makeReconcileStatusHandler("openfaas-fn", client, kube, appslisters.NewDeploymentLister(indexer))
+
+			req := httptest.NewRequest(http.MethodGet, "/system/function/nodeinfo/reconcile-status", nil)
+			req = mux.SetURLVars(req, map[string]string{"name": "nodeinfo"})
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusOK {
+				t.Fatalf("want status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+			}
+
+			var status ReconcileStatus
+			if err := json.Unmarshal(rr.Body.Bytes(), &status); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if status.Generation != 2 {
+				t.Errorf("want generation 2, got %d", status.Generation)
+			}
+			if len(status.Conditions) != 2 {
+				t.Fatalf("want 2 conditions, got %v", status.Conditions)
+			}
+			if got := status.Conditions[0].Status; got != tc.wantReconcile {
+				t.Errorf("want %s condition %s, got %s", ConditionReconciled, tc.wantReconcile, got)
+			}
+			if got := status.Conditions[1].Status; got != tc.wantAvailable {
+				t.Errorf("want %s condition %s, got %s", ConditionAvailable, tc.wantAvailable, got)
+			}
+			if status.Reconciled != (tc.wantReconcile == "True") {
+				t.Errorf("want reconciled %v, got %v", tc.wantReconcile == "True", status.Reconciled)
+			}
+			if status.LastError != tc.wantError {
+				t.Errorf("want last error %q, got %q", tc.wantError, status.LastError)
+			}
+		})
+	}
+}
+
+func Test_makeReconcileStatusHandler_NotFound(t *testing.T) {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	handler := makeReconcileStatusHandler("openfaas-fn", faasfake.NewSimpleClientset(), fake.NewSimpleClientset(), appslisters.NewDeploymentLister(indexer))
+
+	req := httptest.NewRequest(http.MethodGet, "/system/function/nodeinfo/reconcile-status", nil)
+	req = mux.SetURLVars(req, map[string]string{"name": "nodeinfo"})
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("want status %d, got %d", http.StatusNotFound, rr.Code)
+	}
+}
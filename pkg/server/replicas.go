@@ -4,10 +4,12 @@ import (
 	"encoding/json"
 	"io/ioutil"
 	"net/http"
+	"time"
 
 	"github.com/gorilla/mux"
 	ofv1 "github.com/openfaas/faas-netes/pkg/apis/openfaas/v1"
 	clientset "github.com/openfaas/faas-netes/pkg/client/clientset/versioned"
+	"github.com/openfaas/faas-netes/pkg/k8s"
 	"github.com/openfaas/faas-provider/types"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
@@ -15,7 +17,7 @@ import (
 	glog "k8s.io/klog"
 )
 
-func makeReplicaReader(defaultNamespace string, client clientset.Interface, lister v1.DeploymentLister) http.HandlerFunc {
+func makeReplicaReader(defaultNamespace string, client clientset.Interface, lister v1.DeploymentLister, statusConfig k8s.StatusConfig) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		vars := mux.Vars(r)
 		functionName := vars["name"]
@@ -37,15 +39,11 @@ func makeReplicaReader(defaultNamespace string, client clientset.Interface, list
 			w.Write([]byte(err.Error()))
 			return
 		}
-		desiredReplicas, availableReplicas, err := getReplicas(functionName, lookupNamespace, lister)
-		if err != nil {
+		result := toFunctionStatus(*k8sfunc)
+		if err := withDeploymentStatus(&result, lookupNamespace, lister, statusConfig); err != nil {
 			glog.Warningf("Function replica reader error: %v", err)
 		}
 
-		result := toFunctionStatus(*k8sfunc)
-		result.AvailableReplicas = availableReplicas
-		result.Replicas = desiredReplicas
-
 		res, err := json.Marshal(result)
 		if err != nil {
 			glog.Errorf("Failed to marshal function status: %s", err.Error())
@@ -60,18 +58,26 @@ func makeReplicaReader(defaultNamespace string, client clientset.Interface, list
 	}
 }
 
-func getReplicas(functionName string, namespace string, lister v1.DeploymentLister) (uint64, uint64, error) {
-	dep, err := lister.Deployments(namespace).Get(functionName)
+// withDeploymentStatus sets the replicas of the function from its Deployment, along with the
+// state and readiness annotations that the controller's readers report
+func withDeploymentStatus(function *types.FunctionStatus, namespace string, lister v1.DeploymentLister, statusConfig k8s.StatusConfig) error {
+	dep, err := lister.Deployments(namespace).Get(function.Name)
 	if err != nil {
-		return 0, 0, err
+		return err
+	}
+
+	if k8s.ProgressDeadlineExceeded(*dep) {
+		glog.Warningf("Function %s.%s rollout exceeded its progress deadline", function.Name, namespace)
 	}
-	desiredReplicas := uint64(dep.Status.Replicas)
-	availableReplicas := uint64(dep.Status.AvailableReplicas)
 
-	return desiredReplicas, availableReplicas, nil
+	function.Replicas = uint64(dep.Status.Replicas)
+	function.AvailableReplicas = uint64(dep.Status.AvailableReplicas)
+	k8s.WithFunctionState(function, *dep, statusConfig)
+
+	return nil
 }
 
-func makeReplicaHandler(defaultNamespace string, kube kubernetes.Interface) http.HandlerFunc {
+func makeReplicaHandler(defaultNamespace string, kube kubernetes.Interface, maxReplicas int32, history *k8s.ScaleHistory) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		vars := mux.Vars(r)
 		functionName := vars["name"]
@@ -111,7 +117,38 @@ func makeReplicaHandler(defaultNamespace string, kube kubernetes.Interface) http
 			return
 		}
 
-		dep.Spec.Replicas = int32p(int32(req.Replicas))
+		if req.Replicas > 0 && k8s.IsPaused(dep.Annotations) {
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte("Function " + functionName + " is paused, resume it before scaling"))
+			glog.Infof("Function %s is paused, not scaling to %d", functionName, req.Replicas)
+			return
+		}
+
+		replicas, clamped := k8s.ClampReplicas(int32(req.Replicas), maxReplicas)
+		if clamped {
+			glog.Infof("Function %s replicas clamped from %d to max_replicas_per_function %d", functionName, req.Replicas, maxReplicas)
+		}
+
+		if replicas == 0 {
+			allowed, err := k8s.ScaleToZeroAllowed(dep.Annotations, time.Now())
+			if err != nil {
+				glog.Warningf("Function %s: %v", functionName, err)
+			}
+			if !allowed {
+				replicas = 1
+				glog.Infof("Function %s is outside of its scale to zero schedule, keeping a warm replica", functionName)
+			}
+		}
+
+		oldReplicas := int32(0)
+		if dep.Spec.Replicas != nil {
+			oldReplicas = *dep.Spec.Replicas
+		}
+
+		dep.Spec.Replicas = int32p(replicas)
+		if oldReplicas == 0 && replicas > 0 {
+			k8s.MarkScaledFromZero(dep, time.Now())
+		}
 		_, err = kube.AppsV1().Deployments(lookupNamespace).Update(r.Context(), dep, metav1.UpdateOptions{})
 		if err != nil {
 			w.WriteHeader(http.StatusInternalServerError)
@@ -120,8 +157,25 @@ func makeReplicaHandler(defaultNamespace string, kube kubernetes.Interface) http
 			return
 		}
 
-		glog.Infof("Function %v replica updated to %v", functionName, req.Replicas)
+		glog.Infof("Function %v replica updated to %v", functionName, replicas)
+
+		if replicas != oldReplicas {
+			history.Record(functionName, lookupNamespace, k8s.ScaleEvent{
+				From:      oldReplicas,
+				To:        replicas,
+				Timestamp: time.Now(),
+				Source:    r.UserAgent(),
+			})
+		}
+
+		out, _ := json.Marshal(types.ScaleServiceRequest{
+			ServiceName: functionName,
+			Replicas:    uint64(replicas),
+		})
+
+		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusAccepted)
+		w.Write(out)
 	}
 }
 
@@ -0,0 +1,206 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	faasv1 "github.com/openfaas/faas-netes/pkg/apis/openfaas/v1"
+	faasfake "github.com/openfaas/faas-netes/pkg/client/clientset/versioned/fake"
+	"github.com/openfaas/faas-netes/pkg/k8s"
+	"github.com/openfaas/faas-provider/types"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	appslisters "k8s.io/client-go/listers/apps/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func Test_makeReplicaHandler_ScaleZeroSchedule(t *testing.T) {
+	cases := []struct {
+		name        string
+		annotations map[string]string
+		want        int32
+	}{
+		{name: "no schedule", annotations: nil, want: 0},
+		{name: "inside the schedule", annotations: map[string]string{"com.openfaas.scale.zero.schedule": "* * * * *"}, want: 0},
+		{name: "outside the schedule", annotations: map[string]string{"com.openfaas.scale.zero.schedule": "* * 31 2 *"}, want: 1},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			replicas := int32(3)
+			kube := kubefake.NewSimpleClientset(&appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "nodeinfo", Namespace: "openfaas-fn", Annotations: tc.annotations},
+				Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+			})
+
+			handler := makeReplicaHandler("openfaas-fn", kube, 0, nil)
+
+			req := httptest.NewRequest(http.MethodPost, "/system/scale-function/nodeinfo", strings.NewReader(`{"serviceName":"nodeinfo","replicas":0}`))
+			req = mux.SetURLVars(req, map[string]string{"name": "nodeinfo"})
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusAccepted {
+				t.Fatalf("want status %d, got %d: %s", http.StatusAccepted, rr.Code, rr.Body.String())
+			}
+
+			deployment, err := kube.AppsV1().Deployments("openfaas-fn").Get(context.TODO(), "nodeinfo", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if *deployment.Spec.Replicas != tc.want {
+				t.Errorf("want Deployment replicas %d, got %d", tc.want, *deployment.Spec.Replicas)
+			}
+		})
+	}
+}
+
+func Test_makeReplicaHandler_MarksScaledFromZero(t *testing.T) {
+	replicas := int32(0)
+	kube := kubefake.NewSimpleClientset(&appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "nodeinfo", Namespace: "openfaas-fn"},
+		Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+	})
+
+	handler := makeReplicaHandler("openfaas-fn", kube, 0, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/system/scale-function/nodeinfo", strings.NewReader(`{"serviceName":"nodeinfo","replicas":1}`))
+	req = mux.SetURLVars(req, map[string]string{"name": "nodeinfo"})
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("want status %d, got %d: %s", http.StatusAccepted, rr.Code, rr.Body.String())
+	}
+
+	deployment, err := kube.AppsV1().Deployments("openfaas-fn").Get(context.TODO(), "nodeinfo", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, ok := deployment.Annotations[k8s.ScaledFromZeroAnnotationKey]; !ok {
+		t.Errorf("want %s set after scaling from zero", k8s.ScaledFromZeroAnnotationKey)
+	}
+}
+
+// newStatusTestReaders returns the list handler and replica reader of the operator for a
+// function that was scaled from zero a second ago and has no available replicas yet
+func newStatusTestReaders(t *testing.T, statusConfig k8s.StatusConfig) (http.HandlerFunc, http.HandlerFunc) {
+	client := faasfake.NewSimpleClientset(&faasv1.Function{
+		ObjectMeta: metav1.ObjectMeta{Name: "nodeinfo", Namespace: "openfaas-fn"},
+		Spec:       faasv1.FunctionSpec{Name: "nodeinfo", Image: "functions/nodeinfo"},
+	})
+
+	replicas := int32(1)
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	indexer.Add(&appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "nodeinfo",
+			Namespace:   "openfaas-fn",
+			Annotations: map[string]string{k8s.ScaledFromZeroAnnotationKey: time.Now().Add(-time.Second).UTC().Format(time.RFC3339)},
+		},
+		Spec:   appsv1.DeploymentSpec{Replicas: &replicas},
+		Status: appsv1.DeploymentStatus{Replicas: 1},
+	})
+	lister := appslisters.NewDeploymentLister(indexer)
+
+	return makeListHandler("openfaas-fn", client, lister, statusConfig),
+		makeReplicaReader("openfaas-fn", client, lister, statusConfig)
+}
+
+func Test_makeReplicaReader_FunctionState(t *testing.T) {
+	cases := []struct {
+		name        string
+		gracePeriod time.Duration
+		want        string
+	}{
+		{name: "within the grace period", gracePeriod: time.Minute, want: k8s.FunctionStateScaling},
+		{name: "without a grace period", gracePeriod: 0, want: k8s.FunctionStateUnavailable},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			list, reader := newStatusTestReaders(t, k8s.StatusConfig{ScaleFromZeroGracePeriod: tc.gracePeriod})
+
+			req := httptest.NewRequest(http.MethodGet, "/system/function/nodeinfo", nil)
+			req = mux.SetURLVars(req, map[string]string{"name": "nodeinfo"})
+			rr := httptest.NewRecorder()
+			reader.ServeHTTP(rr, req)
+
+			var status types.FunctionStatus
+			if err := json.Unmarshal(rr.Body.Bytes(), &status); err != nil {
+				t.Fatalf("unexpected error: %s: %s", err, rr.Body.String())
+			}
+			if status.Annotations == nil || (*status.Annotations)[k8s.FunctionStateAnnotationKey] != tc.want {
+				t.Errorf("want reader state %q, got annotations: %v", tc.want, status.Annotations)
+			}
+
+			rr = httptest.NewRecorder()
+			list.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/system/functions", nil))
+
+			var functions []types.FunctionStatus
+			if err := json.Unmarshal(rr.Body.Bytes(), &functions); err != nil {
+				t.Fatalf("unexpected error: %s: %s", err, rr.Body.String())
+			}
+			if len(functions) != 1 || functions[0].Annotations == nil || (*functions[0].Annotations)[k8s.FunctionStateAnnotationKey] != tc.want {
+				t.Errorf("want list state %q, got: %+v", tc.want, functions)
+			}
+		})
+	}
+}
+
+func Test_makeReplicaReader_FunctionReady(t *testing.T) {
+	cases := []struct {
+		name        string
+		threshold   float64
+		annotations map[string]string
+		want        string
+	}{
+		{name: "half the replicas are enough", threshold: 0.5, want: "true"},
+		{name: "every replica is required", threshold: 1, want: "false"},
+		{name: "annotation overrides the threshold", threshold: 1, annotations: map[string]string{k8s.ReadyThresholdAnnotationKey: "0.5"}, want: "true"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := faasfake.NewSimpleClientset(&faasv1.Function{
+				ObjectMeta: metav1.ObjectMeta{Name: "nodeinfo", Namespace: "openfaas-fn"},
+				Spec:       faasv1.FunctionSpec{Name: "nodeinfo", Image: "functions/nodeinfo"},
+			})
+
+			replicas := int32(4)
+			deployment := &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "nodeinfo", Namespace: "openfaas-fn"},
+				Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+				Status:     appsv1.DeploymentStatus{Replicas: 4, AvailableReplicas: 2},
+			}
+			deployment.Spec.Template.Annotations = tc.annotations
+
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			indexer.Add(deployment)
+
+			reader := makeReplicaReader("openfaas-fn", client, appslisters.NewDeploymentLister(indexer), k8s.StatusConfig{ReadyThreshold: tc.threshold})
+
+			req := httptest.NewRequest(http.MethodGet, "/system/function/nodeinfo", nil)
+			req = mux.SetURLVars(req, map[string]string{"name": "nodeinfo"})
+			rr := httptest.NewRecorder()
+			reader.ServeHTTP(rr, req)
+
+			var status types.FunctionStatus
+			if err := json.Unmarshal(rr.Body.Bytes(), &status); err != nil {
+				t.Fatalf("unexpected error: %s: %s", err, rr.Body.String())
+			}
+			if status.Annotations == nil || (*status.Annotations)[k8s.FunctionReadyAnnotationKey] != tc.want {
+				t.Errorf("want %s=%s, got annotations: %v", k8s.FunctionReadyAnnotationKey, tc.want, status.Annotations)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright 2020 OpenFaaS Author(s)
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package server
+
+import (
+	"net/http"
+
+	bootstrap "github.com/openfaas/faas-provider"
+	"github.com/openfaas/faas-provider/auth"
+	"github.com/openfaas/faas-provider/types"
+)
+
+// FunctionPath is the route template for endpoints that act on a single function
+const FunctionPath = "/system/function/{name:[" + bootstrap.NameExpression + "]+}"
+
+// SecretPath is the route template for endpoints that act on a single secret
+const SecretPath = "/system/secrets/{name:[" + bootstrap.NameExpression + "]+}"
+
+// Route is a provider endpoint that is not part of types.FaaSHandlers
+type Route struct {
+	Path    string
+	Methods []string
+	Handler http.HandlerFunc
+}
+
+// RegisterRoutes adds the routes to the faas-provider router. When basic auth is enabled the
+// routes are protected in the same way as the types.FaaSHandlers system endpoints.
+func RegisterRoutes(config *types.FaaSConfig, routes []Route) error {
+	var credentials *auth.BasicAuthCredentials
+	if config.EnableBasicAuth {
+		reader := auth.ReadBasicAuthFromDisk{
+			SecretMountPath: config.SecretMountPath,
+		}
+
+		var err error
+		credentials, err = reader.Read()
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, route := range routes {
+		handler := route.Handler
+		if credentials != nil {
+			handler = auth.DecorateWithBasicAuth(handler, credentials)
+		}
+
+		bootstrap.Router().HandleFunc(route.Path, handler).Methods(route.Methods...)
+	}
+
+	return nil
+}
@@ -10,15 +10,14 @@ import (
 	clientset "github.com/openfaas/faas-netes/pkg/client/clientset/versioned"
 	"github.com/openfaas/faas-netes/pkg/handlers"
 	"github.com/openfaas/faas-netes/pkg/k8s"
-	faasnetesk8s "github.com/openfaas/faas-netes/pkg/k8s"
+	"github.com/openfaas/faas-netes/pkg/metrics"
 	bootstrap "github.com/openfaas/faas-provider"
-	v1apps "k8s.io/client-go/listers/apps/v1"
 
 	"github.com/openfaas/faas-provider/logs"
-	"github.com/openfaas/faas-provider/proxy"
 	"github.com/openfaas/faas-provider/types"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 
+	appsinformer "k8s.io/client-go/informers/apps/v1"
 	coreinformer "k8s.io/client-go/informers/core/v1"
 	"k8s.io/client-go/kubernetes"
 	glog "k8s.io/klog"
@@ -34,8 +33,9 @@ const defaultWriteTimeout = 8
 func New(client clientset.Interface,
 	kube kubernetes.Interface,
 	endpointsInformer coreinformer.EndpointsInformer,
-	deploymentLister v1apps.DeploymentLister,
+	deploymentInformer appsinformer.DeploymentInformer,
 	clusterRole bool,
+	statusConfig k8s.StatusConfig,
 	cfg config.BootstrapConfig) *Server {
 
 	functionNamespace := "openfaas-fn"
@@ -48,8 +48,11 @@ func New(client clientset.Interface,
 		pprof = val
 	}
 
+	deploymentLister := deploymentInformer.Lister()
 	lister := endpointsInformer.Lister()
 	functionLookup := k8s.NewFunctionLookup(functionNamespace, lister)
+	functionLookup.RoutingTable = k8s.NewRoutingTable(kube, cfg.ProfilesNamespace)
+	functionLookup.LoadBalancer = cfg.ProxyLoadBalancer
 
 	bootstrapConfig := types.FaaSConfig{
 		ReadTimeout:  cfg.FaaSConfig.ReadTimeout,
@@ -58,25 +61,157 @@ func New(client clientset.Interface,
 		EnableHealth: true,
 	}
 
+	scaleHistory := k8s.NewScaleHistory(k8s.DefaultScaleHistorySize)
+
+	logHandler := logs.NewLogHandlerFunc(NewLogRequestor(cfg, kube, functionNamespace), bootstrapConfig.WriteTimeout)
+
 	bootstrapHandlers := types.FaaSHandlers{
-		FunctionProxy:        proxy.NewHandlerFunc(bootstrapConfig, functionLookup),
-		DeleteHandler:        makeDeleteHandler(functionNamespace, client),
+		FunctionProxy:        handlers.MakeABTestProxyHandler(handlers.MakeProxyHandler(bootstrapConfig, functionLookup, functionNamespace, deploymentLister), functionNamespace, deploymentLister),
+		DeleteHandler:        makeDeleteHandler(functionNamespace, client, kube),
 		DeployHandler:        makeApplyHandler(functionNamespace, client),
-		FunctionReader:       makeListHandler(functionNamespace, client, deploymentLister),
-		ReplicaReader:        makeReplicaReader(functionNamespace, client, deploymentLister),
-		ReplicaUpdater:       makeReplicaHandler(functionNamespace, kube),
+		FunctionReader:       makeListHandler(functionNamespace, client, deploymentLister, statusConfig),
+		ReplicaReader:        makeReplicaReader(functionNamespace, client, deploymentLister, statusConfig),
+		ReplicaUpdater:       makeReplicaHandler(functionNamespace, kube, int32(cfg.MaxReplicasPerFunction), scaleHistory),
 		UpdateHandler:        makeApplyHandler(functionNamespace, client),
 		HealthHandler:        makeHealthHandler(),
 		InfoHandler:          makeInfoHandler(),
-		SecretHandler:        handlers.MakeSecretHandler(functionNamespace, kube),
-		LogHandler:           logs.NewLogHandlerFunc(faasnetesk8s.NewLogRequestor(kube, functionNamespace), bootstrapConfig.WriteTimeout),
+		SecretHandler:        handlers.MakeSecretHandler(functionNamespace, kube, cfg.SecretLockTimeout),
+		LogHandler:           handlers.MakeContainerLogHandler(logHandler, functionNamespace, kube),
 		ListNamespaceHandler: handlers.MakeNamespacesLister(functionNamespace, clusterRole, kube),
 	}
 
+	routes := []Route{
+		{
+			Path:    FunctionPath + "/images",
+			Methods: []string{http.MethodGet},
+			Handler: handlers.MakeFunctionImagesHandler(functionNamespace, kube),
+		},
+		{
+			Path:    FunctionPath + "/events",
+			Methods: []string{http.MethodGet},
+			Handler: handlers.MakeEventListHandler(functionNamespace, kube),
+		},
+		{
+			Path:    FunctionPath + "/logs",
+			Methods: []string{http.MethodGet},
+			Handler: handlers.MakeFunctionLogsHandler(functionNamespace, kube, bootstrapConfig.WriteTimeout),
+		},
+		{
+			Path:    FunctionPath + "/scale-history",
+			Methods: []string{http.MethodGet},
+			Handler: handlers.MakeScaleHistoryHandler(functionNamespace, scaleHistory),
+		},
+		{
+			Path:    FunctionPath + "/probe",
+			Methods: []string{http.MethodGet},
+			Handler: handlers.MakeFunctionProbeHandler(functionNamespace, functionLookup, bootstrapConfig.ReadTimeout),
+		},
+		{
+			Path:    FunctionPath + "/usage",
+			Methods: []string{http.MethodGet},
+			Handler: handlers.MakeResourceUsageHandler(functionNamespace, deploymentLister, k8s.NewPodMetricsClient(kube)),
+		},
+		{
+			Path:    FunctionPath + "/async",
+			Methods: []string{http.MethodGet},
+			Handler: handlers.MakeAsyncConfigHandler(functionNamespace, deploymentLister),
+		},
+		{
+			Path:    FunctionPath + "/drift",
+			Methods: []string{http.MethodGet},
+			Handler: handlers.MakeFunctionDriftHandler(functionNamespace, deploymentLister),
+		},
+		{
+			Path:    FunctionPath + "/ownership",
+			Methods: []string{http.MethodGet},
+			Handler: handlers.MakeOwnershipHandler(functionNamespace, kube),
+		},
+		{
+			Path:    FunctionPath + "/reconcile-status",
+			Methods: []string{http.MethodGet},
+			Handler: makeReconcileStatusHandler(functionNamespace, client, kube, deploymentLister),
+		},
+		{
+			Path:    "/system/functions/cordon",
+			Methods: []string{http.MethodPost},
+			Handler: handlers.MakeNamespaceCordonHandler(functionNamespace, kube),
+		},
+		{
+			Path:    "/system/functions/cordon",
+			Methods: []string{http.MethodDelete},
+			Handler: handlers.MakeNamespaceUncordonHandler(functionNamespace, kube),
+		},
+		{
+			Path:    FunctionPath + "/pause",
+			Methods: []string{http.MethodPost},
+			Handler: handlers.MakePauseHandler(functionNamespace, kube, scaleHistory),
+		},
+		{
+			Path:    FunctionPath + "/resume",
+			Methods: []string{http.MethodPost},
+			Handler: handlers.MakeResumeHandler(functionNamespace, kube, scaleHistory),
+		},
+		{
+			Path:    "/system/functions/terraform",
+			Methods: []string{http.MethodGet},
+			Handler: handlers.MakeTerraformExportHandler(functionNamespace, deploymentLister),
+		},
+		{
+			Path:    "/system/secrets/names",
+			Methods: []string{http.MethodGet},
+			Handler: handlers.MakeSecretListHandler(functionNamespace, kube),
+		},
+		{
+			Path:    SecretPath + "/impact",
+			Methods: []string{http.MethodGet},
+			Handler: handlers.MakeSecretImpactHandler(functionNamespace, clusterRole, kube),
+		},
+		{
+			Path:    "/system/routing",
+			Methods: []string{http.MethodGet},
+			Handler: handlers.MakeRoutingTableHandler(functionLookup.RoutingTable),
+		},
+	}
+
+	if traces := NewTraceQuerier(cfg); traces != nil {
+		routes = append(routes, Route{
+			Path:    "/system/functions/call-graph",
+			Methods: []string{http.MethodGet},
+			Handler: handlers.MakeCallGraphHandler(traces),
+		})
+	}
+
+	if len(cfg.CacheRefreshToken) > 0 {
+		namespaceScope := functionNamespace
+		if clusterRole {
+			namespaceScope = ""
+		}
+
+		routes = append(routes, Route{
+			Path:    "/-/cache/refresh",
+			Methods: []string{http.MethodPost},
+			Handler: handlers.MakeCacheRefreshHandler(cfg.CacheRefreshToken,
+				k8s.NewDeploymentCacheRefresher(kube, namespaceScope, deploymentInformer),
+				k8s.NewEndpointsCacheRefresher(kube, namespaceScope, endpointsInformer)),
+		})
+	}
+
+	if err := RegisterRoutes(&bootstrapConfig, routes); err != nil {
+		glog.Fatalf("Error registering routes: %s", err.Error())
+	}
+
 	if pprof == "true" {
 		bootstrap.Router().PathPrefix("/debug/pprof/").Handler(http.DefaultServeMux)
 	}
 
+	metrics.InstrumentHandlers(&bootstrapHandlers)
+
+	statsd, err := metrics.NewStatsD(cfg.StatsDHost, cfg.StatsDPort)
+	if err != nil {
+		glog.Fatalf("Error creating StatsD client: %s", err.Error())
+	}
+	metrics.InstrumentStatsD(&bootstrapHandlers, statsd)
+
 	bootstrap.Router().Path("/metrics").Handler(promhttp.Handler())
 
 	glog.Infof("Using namespace '%s'", functionNamespace)
@@ -0,0 +1,27 @@
+// Copyright 2020 OpenFaaS Author(s)
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package server
+
+import (
+	"net/http"
+
+	"github.com/openfaas/faas-netes/pkg/config"
+	"github.com/openfaas/faas-netes/pkg/k8s"
+)
+
+// NewTraceQuerier returns the k8s.TraceQuerier for the configured TraceBackend, or nil when
+// no TraceQueryURL is set.
+func NewTraceQuerier(cfg config.BootstrapConfig) k8s.TraceQuerier {
+	if len(cfg.TraceQueryURL) == 0 {
+		return nil
+	}
+
+	client := &http.Client{Timeout: cfg.FaaSConfig.ReadTimeout}
+	switch cfg.TraceBackend {
+	case config.TraceBackendTempo:
+		return k8s.NewTempoTraceQuerier(client, cfg.TraceQueryURL)
+	default:
+		return k8s.NewJaegerTraceQuerier(client, cfg.TraceQueryURL, cfg.TraceService)
+	}
+}
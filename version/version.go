@@ -3,6 +3,8 @@
 
 package version
 
+import "runtime"
+
 var (
 	// Version release version of the provider
 	Version string
@@ -10,10 +12,21 @@ var (
 	// GitCommit SHA of the last git commit
 	GitCommit string
 
+	// BuildDate is when the provider was built, i.e. 2020-10-15T10:00:00Z
+	BuildDate string
+
 	// DevVersion string for the development version
 	DevVersion = "dev"
 )
 
+// ReleaseInfo describes the build of the provider
+type ReleaseInfo struct {
+	SHA       string `json:"sha"`
+	Release   string `json:"release"`
+	BuildDate string `json:"build_date,omitempty"`
+	GoVersion string `json:"go_version"`
+}
+
 // BuildVersion returns current version of the provider
 func BuildVersion() string {
 	if len(Version) == 0 {
@@ -22,7 +35,13 @@ func BuildVersion() string {
 	return Version
 }
 
-// GetReleaseInfo includes the SHA and the release version
-func GetReleaseInfo() (sha, release string) {
-	return GitCommit, BuildVersion()
+// GetReleaseInfo includes the SHA, the release version, the build date and the Go version
+// the provider was built with
+func GetReleaseInfo() ReleaseInfo {
+	return ReleaseInfo{
+		SHA:       GitCommit,
+		Release:   BuildVersion(),
+		BuildDate: BuildDate,
+		GoVersion: runtime.Version(),
+	}
 }